@@ -20,6 +20,7 @@ package apiserver
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/pole-io/pole-server/apis/pkg/types"
 )
@@ -60,6 +61,12 @@ type Apiserver interface {
 	Restart(option map[string]interface{}, api map[string]APIConfig, errCh chan error) error
 }
 
+// Drainer 支持在停止前平滑排空长连接的API服务器
+type Drainer interface {
+	// Drain 停止接收新的长连接, 并在 window 时间内分批断开存量的长连接
+	Drain(ctx context.Context, window time.Duration)
+}
+
 type EnrichApiserver interface {
 	Apiserver
 	DebugHandlers() []types.DebugHandler
@@ -71,6 +71,8 @@ const (
 	ServiceContractName = "serviceContract"
 	// GrayName gray config name
 	GrayName = "gray"
+	// HealthPolicyName health policy config name
+	HealthPolicyName = "healthPolicy"
 )
 
 type CacheIndex int
@@ -94,6 +96,7 @@ const (
 	CacheGray
 	CacheLaneRule
 	CacheRole
+	CacheHealthPolicy
 
 	CacheLast
 )
@@ -112,6 +115,15 @@ type Cache interface {
 	Close() error
 }
 
+// ChangeLogCache 支持按照存储层变更记录增量同步的缓存
+type ChangeLogCache interface {
+	Cache
+	// ChangeLogResource 变更记录中对应的资源类型
+	ChangeLogResource() string
+	// ApplyChangeLogs 按照发生变更的资源标识拉取最新数据并更新缓存, 重复执行不影响结果
+	ApplyChangeLogs(keys []string) error
+}
+
 // ConfigEntry 单个缓存资源配置
 type ConfigEntry struct {
 	Name   string                 `yaml:"name"`
@@ -162,6 +174,8 @@ type CacheManager interface {
 	Gray() GrayCache
 	// Role Get role cache information
 	Role() RoleCache
+	// HealthPolicy 获取健康检查以及过期策略缓存
+	HealthPolicy() HealthPolicyCache
 }
 
 type (
@@ -285,6 +299,17 @@ type (
 	}
 )
 
+type (
+	// HealthPolicyCache 健康检查以及过期策略的 Cache 接口
+	HealthPolicyCache interface {
+		Cache
+		// GetPolicy 获取服务生效的策略, 服务维度的策略覆盖命名空间维度的策略, 均不存在时返回 nil
+		GetPolicy(namespace, serviceName string) *svctypes.HealthPolicy
+		// ListPolicies 获取全部的策略
+		ListPolicies() []*svctypes.HealthPolicy
+	}
+)
+
 type (
 	// InstanceIterProc instance iter proc func
 	InstanceIterProc func(key string, value *svctypes.Instance) (bool, error)
@@ -106,3 +106,16 @@ func (c *CompositeHistory) Record(entry *types.RecordEntry) {
 		c.chain[i].Record(entry)
 	}
 }
+
+// ReloadHistory 热更新操作记录插件配置
+func ReloadHistory(conf apis.PluginChanConfig) error {
+	if compositeHistory == nil {
+		return nil
+	}
+	entries := conf.Entries
+	if err := apis.ReloadPluginChain(apis.PluginTypeHistory, compositeHistory.options, entries); err != nil {
+		return err
+	}
+	compositeHistory.options = entries
+	return nil
+}
@@ -122,31 +122,7 @@ func GetStatis() Statis {
 	}
 
 	statisOnce.Do(func() {
-		var (
-			entries        []apis.ConfigEntry
-			defaultEntries = []apis.ConfigEntry{
-				{
-					Name: "local",
-				},
-				{
-					Name: "prometheus",
-				},
-			}
-		)
-
-		if len(apis.GetPluginConfig().Statis.Entries) != 0 {
-			entries = append(entries, apis.GetPluginConfig().Statis.Entries...)
-		} else {
-			if apis.GetPluginConfig().Statis.Name == "local" {
-				entries = defaultEntries
-			} else {
-				entries = append(entries, apis.ConfigEntry{
-					Name:   apis.GetPluginConfig().Statis.Name,
-					Option: apis.GetPluginConfig().Statis.Option,
-				})
-			}
-		}
-
+		entries := parseStatisEntries(apis.GetPluginConfig().Statis)
 		_statis = &compositeStatis{
 			chain:   []Statis{},
 			options: entries,
@@ -158,3 +134,40 @@ func GetStatis() Statis {
 
 	return _statis
 }
+
+// ReloadStatis 热更新统计插件配置
+func ReloadStatis(conf apis.PluginChanConfig) error {
+	composite, ok := _statis.(*compositeStatis)
+	if !ok {
+		// 尚未初始化, 首次使用时按照新配置创建
+		return nil
+	}
+	entries := parseStatisEntries(conf)
+	if err := apis.ReloadPluginChain(apis.PluginTypeStatis, composite.options, entries); err != nil {
+		return err
+	}
+	composite.options = entries
+	return nil
+}
+
+func parseStatisEntries(conf apis.PluginChanConfig) []apis.ConfigEntry {
+	if len(conf.Entries) != 0 {
+		return append([]apis.ConfigEntry{}, conf.Entries...)
+	}
+	if conf.Name == "local" {
+		return []apis.ConfigEntry{
+			{
+				Name: "local",
+			},
+			{
+				Name: "prometheus",
+			},
+		}
+	}
+	return []apis.ConfigEntry{
+		{
+			Name:   conf.Name,
+			Option: conf.Option,
+		},
+	}
+}
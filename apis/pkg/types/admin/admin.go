@@ -34,6 +34,15 @@ type LeaderElection struct {
 	Valid      bool
 }
 
+// ServerSetting 服务端自身需要持久化的配置信息, 对应 server_setting 表
+type ServerSetting struct {
+	ID         string
+	Name       string
+	Rule       string
+	CreateTime time.Time
+	ModifyTime time.Time
+}
+
 type ConnReq struct {
 	Protocol string
 	Host     string
@@ -59,3 +68,75 @@ type ScopeLevel struct {
 	Name  string
 	Level string
 }
+
+// ReloadResult 配置热加载结果
+type ReloadResult struct {
+	// Applied 已经在运行期间生效的配置项
+	Applied []string `json:"applied"`
+	// RestartRequired 发生变化但需要重启进程才能生效的配置项
+	RestartRequired []string `json:"restartRequired"`
+	// Failed 热加载失败的配置项以及失败原因
+	Failed []string `json:"failed"`
+}
+
+// ProbeCheck 单项探测的结果
+type ProbeCheck struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	// Message 探测失败的原因
+	Message string `json:"message,omitempty"`
+}
+
+// ProbeResult 存活/就绪探测的结果, 全部检查项通过时 Healthy 为 true
+type ProbeResult struct {
+	Healthy bool         `json:"healthy"`
+	Checks  []ProbeCheck `json:"checks"`
+}
+
+// ConflictPolicy 恢复备份时目标集群已存在同名资源的处理策略
+type ConflictPolicy string
+
+const (
+	// ConflictSkip 保留目标集群中已有的资源
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite 使用备份中的资源覆盖目标集群中已有的资源
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictFail 存在冲突时不写入任何资源
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// RestoreResourceReport 单类资源的恢复结果
+type RestoreResourceReport struct {
+	Kind        string   `json:"kind"`
+	Total       int      `json:"total"`
+	Created     int      `json:"created"`
+	Overwritten int      `json:"overwritten"`
+	Skipped     int      `json:"skipped"`
+	Conflicts   []string `json:"conflicts,omitempty"`
+	Failed      []string `json:"failed,omitempty"`
+}
+
+// RestoreReport 恢复备份的结果, DryRun 时仅统计将要执行的操作
+type RestoreReport struct {
+	DryRun    bool                     `json:"dryRun"`
+	Policy    ConflictPolicy           `json:"conflictPolicy"`
+	Resources []*RestoreResourceReport `json:"resources"`
+}
+
+// SelfPreservationStatus 健康检查自我保护的状态
+type SelfPreservationStatus struct {
+	// Open 是否开启了自我保护
+	Open bool `json:"open"`
+	// Global 是否处于全局自我保护
+	Global bool `json:"global"`
+	// Services 处于自我保护的服务 ID 列表
+	Services []string `json:"services"`
+	// Since 进入自我保护的时间
+	Since *time.Time `json:"since,omitempty"`
+	// PendingUnhealthy 时间窗口内检测到变为不健康的实例数
+	PendingUnhealthy int `json:"pendingUnhealthy"`
+	// Preserved 自我保护期间保留实例健康状态的次数
+	Preserved uint64 `json:"preserved"`
+	// Peers 集群中其他处于自我保护的节点
+	Peers []string `json:"peers"`
+}
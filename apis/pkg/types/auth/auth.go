@@ -178,6 +178,8 @@ const (
 	MaintainModule
 	// BootstrapModule 初始化模块
 	BootstrapModule
+	// AIMCPModule AI MCP 模块
+	AIMCPModule
 )
 
 // UserRoleType 用户角色类型
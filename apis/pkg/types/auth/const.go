@@ -36,6 +36,8 @@ const (
 	DiscoverServices      ServerFunctionName = "DiscoverServices"
 	DiscoverInstances     ServerFunctionName = "DiscoverInstances"
 	UpdateInstance        ServerFunctionName = "UpdateInstance"
+	// IssueWorkloadCertificate 为服务签发 SPIFFE 身份证书, 需要对服务具有写权限
+	IssueWorkloadCertificate ServerFunctionName = "IssueWorkloadCertificate"
 
 	// 服务治理接口
 	DiscoverRouterRule         ServerFunctionName = "DiscoverRouterRule"
@@ -93,6 +95,13 @@ const (
 	DeleteServiceContractInterfaces ServerFunctionName = "DeleteServiceContractInterfaces"
 )
 
+// MCP Server 注册
+const (
+	RegisterMCPServer   ServerFunctionName = "RegisterMCPServer"
+	DeregisterMCPServer ServerFunctionName = "DeregisterMCPServer"
+	DescribeMCPServers  ServerFunctionName = "DescribeMCPServers"
+)
+
 // 服务实例
 const (
 	CreateInstances               ServerFunctionName = "CreateInstances"
@@ -257,6 +266,21 @@ const (
 	DescribeGetLogOutputLevel ServerFunctionName = "DescribeGetLogOutputLevel"
 	UpdateLogOutputLevel      ServerFunctionName = "UpdateLogOutputLevel"
 	DescribeCMDBInfo          ServerFunctionName = "DescribeCMDBInfo"
+	ReloadServerConfig        ServerFunctionName = "ReloadServerConfig"
+	ExportServerBackup        ServerFunctionName = "ExportServerBackup"
+	RestoreServerBackup       ServerFunctionName = "RestoreServerBackup"
+	DescribeSelfPreservation  ServerFunctionName = "DescribeSelfPreservation"
+	DescribeHealthPolicies    ServerFunctionName = "DescribeHealthPolicies"
+	UpdateHealthPolicy        ServerFunctionName = "UpdateHealthPolicy"
+	DeleteHealthPolicy        ServerFunctionName = "DeleteHealthPolicy"
+)
+
+// AI MCP 接口
+const (
+	// CallMCPReadTool 调用只读的 MCP tool
+	CallMCPReadTool ServerFunctionName = "CallMCPReadTool"
+	// CallMCPWriteTool 调用会修改资源的 MCP tool，需要在策略中显式授权
+	CallMCPWriteTool ServerFunctionName = "CallMCPWriteTool"
 )
 
 type ServerFunctionGroup struct {
@@ -274,6 +298,7 @@ var ServerFunctions = []ServerFunctionGroup{
 			DiscoverServices,
 			DiscoverInstances,
 			UpdateInstance,
+			IssueWorkloadCertificate,
 			DiscoverRouterRule,
 			DiscoverRateLimitRule,
 			DiscoverCircuitBreakerRule,
@@ -328,6 +353,14 @@ var ServerFunctions = []ServerFunctionGroup{
 			DeleteServiceContractInterfaces,
 		},
 	},
+	{
+		Name: "MCPServer",
+		Functions: []ServerFunctionName{
+			RegisterMCPServer,
+			DeregisterMCPServer,
+			DescribeMCPServers,
+		},
+	},
 	{
 		Name: "Instance",
 		Functions: []ServerFunctionName{
@@ -470,6 +503,13 @@ var ServerFunctions = []ServerFunctionGroup{
 			DescribePrincipalResources,
 		},
 	},
+	{
+		Name: "AIMCP",
+		Functions: []ServerFunctionName{
+			CallMCPReadTool,
+			CallMCPWriteTool,
+		},
+	},
 	// "AuthRole": {
 	// 	CreateAuthRoles,
 	// 	UpdateAuthRoles,
@@ -23,6 +23,16 @@ const (
 
 	// MetaKeyBuildRevision build revision for server
 	MetaKeyBuildRevision = "build-revision"
+
+	// MetaKeySyncOriginCluster 多集群同步时资源的来源集群
+	MetaKeySyncOriginCluster = "internal-sync-origin-cluster"
+
+	// MetaKeyMigrateFrom 从存量注册中心迁移过来的实例，value 为存量注册中心的类型
+	MetaKeyMigrateFrom = "internal-migrate-from"
+
+	// MetaKeyMigrateWriteBack 由 pole-server 回写到存量注册中心的实例标识，存量注册中心会屏蔽 internal- 前缀的元数据，
+	// 因此这里不使用 internal- 前缀
+	MetaKeyMigrateWriteBack = "pole-migrate-writeback"
 )
 
 const (
@@ -0,0 +1,120 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+import "time"
+
+// HealthPolicy 命名空间或者服务维度的健康检查以及过期策略, Service 为空时作用于整个命名空间.
+// 数值类型的字段为 0、AutoDelete 为空时表示不设置, 继承上一级的策略或者全局配置
+type HealthPolicy struct {
+	Namespace string `json:"namespace"`
+	Service   string `json:"service"`
+	// MinTtl 心跳 TTL 的下限, 单位秒
+	MinTtl uint32 `json:"minTtl"`
+	// MaxTtl 心跳 TTL 的上限, 单位秒
+	MaxTtl uint32 `json:"maxTtl"`
+	// UnhealthyDeleteDelay 实例变为不健康之后多久被删除, 单位秒
+	UnhealthyDeleteDelay uint32 `json:"unhealthyDeleteDelay"`
+	// AutoDelete 是否自动删除不健康的实例以及空服务
+	AutoDelete *bool `json:"autoDelete,omitempty"`
+	// EmptyServiceRetention 空服务的保留时间, 单位秒
+	EmptyServiceRetention uint32 `json:"emptyServiceRetention"`
+	Comment               string `json:"comment"`
+	// Valid 是否被逻辑删除
+	Valid      bool      `json:"-"`
+	CreateTime time.Time `json:"ctime"`
+	ModifyTime time.Time `json:"mtime"`
+}
+
+// Key 策略的唯一标识
+func (p *HealthPolicy) Key() string {
+	return HealthPolicyKey(p.Namespace, p.Service)
+}
+
+// HealthPolicyKey 策略的唯一标识
+func HealthPolicyKey(namespace, service string) string {
+	return namespace + "/" + service
+}
+
+// Merge 使用 override 中设置了的字段覆盖当前策略, 返回新的策略对象
+func (p *HealthPolicy) Merge(override *HealthPolicy) *HealthPolicy {
+	ret := &HealthPolicy{}
+	if p != nil {
+		*ret = *p
+	}
+	if override == nil {
+		return ret
+	}
+	ret.Namespace = override.Namespace
+	ret.Service = override.Service
+	if override.MinTtl != 0 {
+		ret.MinTtl = override.MinTtl
+	}
+	if override.MaxTtl != 0 {
+		ret.MaxTtl = override.MaxTtl
+	}
+	if override.UnhealthyDeleteDelay != 0 {
+		ret.UnhealthyDeleteDelay = override.UnhealthyDeleteDelay
+	}
+	if override.AutoDelete != nil {
+		autoDelete := *override.AutoDelete
+		ret.AutoDelete = &autoDelete
+	}
+	if override.EmptyServiceRetention != 0 {
+		ret.EmptyServiceRetention = override.EmptyServiceRetention
+	}
+	return ret
+}
+
+// ClampTtl 将实例上报的心跳 TTL 限制在策略的上下限之内
+func (p *HealthPolicy) ClampTtl(ttl uint32) uint32 {
+	if p == nil {
+		return ttl
+	}
+	if p.MinTtl != 0 && ttl < p.MinTtl {
+		ttl = p.MinTtl
+	}
+	if p.MaxTtl != 0 && ttl > p.MaxTtl {
+		ttl = p.MaxTtl
+	}
+	return ttl
+}
+
+// IsAutoDelete 是否允许自动删除, 未设置时默认允许
+func (p *HealthPolicy) IsAutoDelete() bool {
+	if p == nil || p.AutoDelete == nil {
+		return true
+	}
+	return *p.AutoDelete
+}
+
+// GetUnhealthyDeleteDelay 获取不健康实例的删除延迟, 未设置时使用 defaultDelay
+func (p *HealthPolicy) GetUnhealthyDeleteDelay(defaultDelay time.Duration) time.Duration {
+	if p == nil || p.UnhealthyDeleteDelay == 0 {
+		return defaultDelay
+	}
+	return time.Duration(p.UnhealthyDeleteDelay) * time.Second
+}
+
+// GetEmptyServiceRetention 获取空服务的保留时间, 未设置时使用 defaultRetention
+func (p *HealthPolicy) GetEmptyServiceRetention(defaultRetention time.Duration) time.Duration {
+	if p == nil || p.EmptyServiceRetention == 0 {
+		return defaultRetention
+	}
+	return time.Duration(p.EmptyServiceRetention) * time.Second
+}
@@ -71,6 +71,7 @@ const (
 	MetadataRegisterFrom                  = "internal-register-from"
 	MetadataInternalMetaHealthCheckPath   = "internal-healthcheck_path"
 	MetadataInternalMetaTraceSampling     = "internal-trace_sampling"
+	MetadataInstanceFlapCount             = "internal-flap-count"
 )
 
 // Instance 组合了api的Instance对象
@@ -447,6 +448,8 @@ const (
 	EventInstanceSendHeartbeat InstanceEventType = "InstanceSendHeartbeat"
 	// EventInstanceUpdate Instance metadata and info update event
 	EventInstanceUpdate InstanceEventType = "InstanceUpdate"
+	// EventInstanceFlapping Instance health status flapping between healthy and unhealthy
+	EventInstanceFlapping InstanceEventType = "InstanceFlapping"
 	// EventClientOffline .
 	EventClientOffline InstanceEventType = "ClientOffline"
 )
@@ -0,0 +1,124 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+)
+
+const (
+	// MCPContractType MCP Server 的能力清单以服务契约的形式保存，契约类型固定
+	MCPContractType = "mcp-server"
+	// MCPContractProtocol MCP Server 契约的协议
+	MCPContractProtocol = "mcp"
+
+	// MCPCapabilityTool 契约接口的 method 字段表示能力的类型
+	MCPCapabilityTool     = "tool"
+	MCPCapabilityPrompt   = "prompt"
+	MCPCapabilityResource = "resource"
+
+	// MetadataMCPTransport MCP Server 接入点实例的传输协议，比如 streamable-http、sse
+	MetadataMCPTransport = "internal-mcp-transport"
+	// MetadataMCPEndpointPath MCP Server 接入点的 HTTP 路径
+	MetadataMCPEndpointPath = "internal-mcp-path"
+
+	// MCPServerOnline 至少有一个健康的接入点
+	MCPServerOnline = "Online"
+	// MCPServerOffline 没有健康的接入点
+	MCPServerOffline = "Offline"
+)
+
+// MCPServer 注册在服务治理中心的 MCP Server，基于服务模型实现：服务名即 MCP Server 名称，
+// 接入点注册为服务实例并参与健康检查，tool/prompt/resource 清单保存为服务契约
+type MCPServer struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	// Tools 提供的 tool 清单
+	Tools []*MCPCapability `json:"tools"`
+	// Prompts 提供的 prompt 清单
+	Prompts []*MCPCapability `json:"prompts"`
+	// Resources 提供的 resource 清单
+	Resources []*MCPCapability `json:"resources"`
+	// Endpoints 接入点
+	Endpoints []*MCPEndpoint `json:"endpoints"`
+	// Status Online/Offline，仅在查询时返回
+	Status string `json:"status,omitempty"`
+	// Revision 能力清单的版本摘要，仅在查询时返回
+	Revision string `json:"revision,omitempty"`
+}
+
+// MCPCapability MCP Server 提供的单个 tool/prompt/resource
+type MCPCapability struct {
+	// Name tool/prompt 名称，resource 为 uri 或者 uri 模板
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Schema tool 的 inputSchema、prompt 的 arguments 等原始描述
+	Schema json.RawMessage `json:"schema,omitempty"`
+}
+
+// MCPEndpoint MCP Server 的接入点，对应一个服务实例
+type MCPEndpoint struct {
+	// InstanceID 接入点对应的实例 ID，MCP Server 通过该 ID 上报心跳
+	InstanceID string `json:"instanceId"`
+	// Transport 传输协议，streamable-http、sse
+	Transport string `json:"transport"`
+	Host      string `json:"host"`
+	Port      uint32 `json:"port"`
+	Path      string `json:"path"`
+	// TTL 心跳的 TTL，单位秒，为 0 时不开启健康检查
+	TTL     uint32 `json:"ttl"`
+	Healthy bool   `json:"healthy"`
+	Isolate bool   `json:"isolate"`
+}
+
+// HasTool 是否提供匹配的 tool，match 为名称的匹配方式
+func (m *MCPServer) HasTool(match func(name string) bool) bool {
+	for _, tool := range m.Tools {
+		if match(tool.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities 按照 类型、名称 遍历全部的能力
+func (m *MCPServer) Capabilities(fn func(kind string, item *MCPCapability)) {
+	for _, item := range m.Tools {
+		fn(MCPCapabilityTool, item)
+	}
+	for _, item := range m.Prompts {
+		fn(MCPCapabilityPrompt, item)
+	}
+	for _, item := range m.Resources {
+		fn(MCPCapabilityResource, item)
+	}
+}
+
+// AddCapability 按照类型追加能力
+func (m *MCPServer) AddCapability(kind string, item *MCPCapability) {
+	switch kind {
+	case MCPCapabilityTool:
+		m.Tools = append(m.Tools, item)
+	case MCPCapabilityPrompt:
+		m.Prompts = append(m.Prompts, item)
+	case MCPCapabilityResource:
+		m.Resources = append(m.Resources, item)
+	}
+}
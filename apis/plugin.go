@@ -1,6 +1,7 @@
 package apis
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 )
@@ -49,6 +50,46 @@ type Plugin interface {
 	Type() PluginType
 }
 
+// Reloadable 支持运行期间热更新配置的插件
+type Reloadable interface {
+	// Reload 使用新的配置刷新插件
+	Reload(c *ConfigEntry) error
+}
+
+// ErrPluginNotReloadable 插件不支持热更新, 需要重启进程生效
+var ErrPluginNotReloadable = errors.New("plugin not support reload")
+
+// ReloadPlugin 热更新指定插件的配置
+func ReloadPlugin(t PluginType, c *ConfigEntry) error {
+	item, exist := GetPlugin(t, c.Name)
+	if !exist {
+		return fmt.Errorf("plugin %s not found", c.Name)
+	}
+	reloadable, ok := item.(Reloadable)
+	if !ok {
+		return fmt.Errorf("plugin %s: %w", c.Name, ErrPluginNotReloadable)
+	}
+	return reloadable.Reload(c)
+}
+
+// ReloadPluginChain 热更新插件执行链, 执行链的组成发生变化时无法热更新
+func ReloadPluginChain(t PluginType, running, entries []ConfigEntry) error {
+	if len(running) != len(entries) {
+		return fmt.Errorf("plugin chain changed: %w", ErrPluginNotReloadable)
+	}
+	for i := range entries {
+		if running[i].Name != entries[i].Name {
+			return fmt.Errorf("plugin chain changed: %w", ErrPluginNotReloadable)
+		}
+	}
+	for i := range entries {
+		if err := ReloadPlugin(t, &entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ConfigEntry 单个插件配置
 type ConfigEntry struct {
 	Name   string                 `yaml:"name"`
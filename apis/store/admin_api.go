@@ -26,6 +26,9 @@ import (
 const (
 	ElectionKeySelfServiceChecker = "pole.checker"
 	ElectionKeyMaintainJob        = "MaintainJob"
+	ElectionKeyMultiClusterSync   = "MultiClusterSync"
+	ElectionKeyLegacyMigration    = "LegacyMigration"
+	ElectionKeyKubernetesSync     = "KubernetesSync"
 )
 
 type AdminStore interface {
@@ -51,6 +54,14 @@ type AdminStore interface {
 	BatchCleanDeletedRules(rule string, timeout time.Duration, batchSize uint32) (uint32, error)
 	// BatchCleanDeletedConfigFiles batch clean soft deleted clients
 	BatchCleanDeletedConfigFiles(timeout time.Duration, batchSize uint32) (uint32, error)
+	// GetServerSetting get server setting by name, return nil when not exist
+	GetServerSetting(name string) (*admin.ServerSetting, error)
+	// CreateServerSettingIfAbsent create server setting, do nothing when the name already exists
+	CreateServerSettingIfAbsent(setting *admin.ServerSetting) error
+	// UpsertServerSetting create server setting or update the rule when the name already exists
+	UpsertServerSetting(setting *admin.ServerSetting) error
+	// GetServerSettingsByPrefix get server settings whose name starts with the prefix
+	GetServerSettingsByPrefix(prefix string) ([]*admin.ServerSetting, error)
 }
 
 // LeaderChangeEvent
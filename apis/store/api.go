@@ -53,6 +53,8 @@ type Store interface {
 	GrayStore
 	// AuthStore Auth storage interface
 	AuthStore
+	// ChangeLogStore 资源变更记录
+	ChangeLogStore
 }
 
 // NamespaceStore Namespace storage interface
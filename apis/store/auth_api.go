@@ -96,6 +96,8 @@ type StrategyStore interface {
 		principalType authtypes.PrincipalType) (*authtypes.StrategyDetail, error)
 	// GetStrategyDetail Get strategy details
 	GetStrategyDetail(id string) (*authtypes.StrategyDetail, error)
+	// GetStrategyDetailByName Get strategy details according to Name, strategies are saved without owner
+	GetStrategyDetailByName(name string) (*authtypes.StrategyDetail, error)
 	// GetMoreStrategies Used to refresh policy cache
 	// 此方法用于 cache 增量更新，需要注意 mtime 应为数据库时间戳
 	GetMoreStrategies(mtime time.Time, firstUpdate bool) ([]*authtypes.StrategyDetail, error)
@@ -105,6 +107,8 @@ type StrategyStore interface {
 type RoleStore interface {
 	// GetRole
 	GetRole(id string) (*authtypes.Role, error)
+	// GetRoleByName Get a unique role according to Name + Owner
+	GetRoleByName(name, owner string) (*authtypes.Role, error)
 	// AddRole Add a role
 	AddRole(role *authtypes.Role) error
 	// UpdateRole Update a role
@@ -0,0 +1,60 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package store
+
+import "time"
+
+// 目前只有实例会追加变更记录, 服务、配置、治理规则等数据量较小的资源仍然按照 mtime 拉取
+const (
+	// ChangeLogInstance 实例的变更记录, Key 为实例 ID
+	ChangeLogInstance = "instance"
+)
+
+var changeLogEnable bool
+
+// SetChangeLogEnable 设置写资源时是否追加变更记录, 与缓存的 changeLog.enable 配置保持一致, 需要在写入数据前设置
+func SetChangeLogEnable(enable bool) {
+	changeLogEnable = enable
+}
+
+// ChangeLogEnabled 写资源时是否需要追加变更记录
+func ChangeLogEnabled() bool {
+	return changeLogEnable
+}
+
+// ChangeLog 资源变更记录, 写操作在修改资源的同一个事务内追加, Seq 由存储层单调递增分配
+type ChangeLog struct {
+	// Seq 变更序号
+	Seq int64
+	// Resource 资源类型
+	Resource string
+	// Key 发生变更的资源 key
+	Key string
+	// CreateTime 变更时间
+	CreateTime time.Time
+}
+
+// ChangeLogStore 资源变更记录存储接口, 缓存按照序号拉取发生变更的资源 key, 避免按照 mtime 反复扫描
+type ChangeLogStore interface {
+	// GetChangeLogs 获取序号大于 seq 的变更记录, 按照序号升序返回, 最多返回 limit 条
+	GetChangeLogs(seq int64, limit uint32) ([]*ChangeLog, error)
+	// GetMaxChangeLogSeq 获取当前最大的变更序号, 没有变更记录时返回 0
+	GetMaxChangeLogSeq() (int64, error)
+	// BatchCleanChangeLogs 清理创建时间早于 timeout 之前的变更记录, 返回清理的条数
+	BatchCleanChangeLogs(timeout time.Duration, batchSize uint32) (uint32, error)
+}
@@ -46,6 +46,8 @@ type NamingModuleStore interface {
 	ServiceContractStore
 	// LaneStore 泳道规则存储操作接口
 	LaneStore
+	// HealthPolicyStore 健康检查以及过期策略
+	HealthPolicyStore
 }
 
 // ServiceStore 服务存储接口
@@ -84,26 +86,26 @@ type ServiceStore interface {
 	GetServicesBatch(services []*svctypes.Service) ([]*svctypes.Service, error)
 }
 
-// InstanceStore 实例存储接口
+// InstanceStore 实例存储接口, 注册、反注册以及心跳链路上的方法通过 ctx 关联请求的 trace
 type InstanceStore interface {
 	// AddInstance 增加一个实例
-	AddInstance(instance *svctypes.Instance) error
+	AddInstance(ctx context.Context, instance *svctypes.Instance) error
 	// BatchAddInstances 增加多个实例
-	BatchAddInstances(instances []*svctypes.Instance) error
+	BatchAddInstances(ctx context.Context, instances []*svctypes.Instance) error
 	// UpdateInstance 更新实例
-	UpdateInstance(instance *svctypes.Instance) error
+	UpdateInstance(ctx context.Context, instance *svctypes.Instance) error
 	// DeleteInstance 删除一个实例，实际是把valid置为false
-	DeleteInstance(instanceID string) error
+	DeleteInstance(ctx context.Context, instanceID string) error
 	// BatchDeleteInstances 批量删除实例，flag=1
-	BatchDeleteInstances(ids []interface{}) error
+	BatchDeleteInstances(ctx context.Context, ids []interface{}) error
 	// CleanInstance 清空一个实例，真正删除
 	CleanInstance(instanceID string) error
 	// BatchGetInstanceIsolate 检查ID是否存在，并且返回存在的ID，以及ID的隔离状态
-	BatchGetInstanceIsolate(ids map[string]bool) (map[string]bool, error)
+	BatchGetInstanceIsolate(ctx context.Context, ids map[string]bool) (map[string]bool, error)
 	// GetInstancesBrief 获取实例关联的token
-	GetInstancesBrief(ids map[string]bool) (map[string]*svctypes.Instance, error)
+	GetInstancesBrief(ctx context.Context, ids map[string]bool) (map[string]*svctypes.Instance, error)
 	// GetInstance 查询一个实例的详情，只返回有效的数据
-	GetInstance(instanceID string) (*svctypes.Instance, error)
+	GetInstance(ctx context.Context, instanceID string) (*svctypes.Instance, error)
 	// GetInstancesCount 获取有效的实例总数
 	GetInstancesCount() (uint32, error)
 	// GetInstancesCountTx 获取有效的实例总数
@@ -119,13 +121,27 @@ type InstanceStore interface {
 	// SetInstanceHealthStatus 设置实例的健康状态
 	SetInstanceHealthStatus(instanceID string, flag int, revision string) error
 	// BatchSetInstanceHealthStatus 批量设置实例的健康状态
-	BatchSetInstanceHealthStatus(ids []interface{}, healthy int, revision string) error
+	BatchSetInstanceHealthStatus(ctx context.Context, ids []interface{}, healthy int, revision string) error
 	// BatchSetInstanceIsolate 批量修改实例的隔离状态
 	BatchSetInstanceIsolate(ids []interface{}, isolate int, revision string) error
 	// AppendInstanceMetadata 追加实例 metadata
-	BatchAppendInstanceMetadata(requests []*InstanceMetadataRequest) error
+	BatchAppendInstanceMetadata(ctx context.Context, requests []*InstanceMetadataRequest) error
 	// RemoveInstanceMetadata 删除实例指定的 metadata
-	BatchRemoveInstanceMetadata(requests []*InstanceMetadataRequest) error
+	BatchRemoveInstanceMetadata(ctx context.Context, requests []*InstanceMetadataRequest) error
+	// GetInstancesByIDs 获取指定实例的完整数据, 包括已经逻辑删除的实例, 用于缓存按照变更记录更新
+	GetInstancesByIDs(tx Tx, ids []string) (map[string]*svctypes.Instance, error)
+}
+
+// HealthPolicyStore 命名空间、服务维度的健康检查以及过期策略存储接口
+type HealthPolicyStore interface {
+	// UpsertHealthPolicy 创建或者更新策略, namespace + service 唯一确定一个策略
+	UpsertHealthPolicy(policy *svctypes.HealthPolicy) error
+	// DeleteHealthPolicy 删除策略
+	DeleteHealthPolicy(namespace, serviceName string) error
+	// GetHealthPolicy 查询策略, 不存在时返回 nil
+	GetHealthPolicy(namespace, serviceName string) (*svctypes.HealthPolicy, error)
+	// GetMoreHealthPolicies 增量获取策略, 此方法用于 cache 增量更新，需要注意 mtime 应为数据库时间戳
+	GetMoreHealthPolicies(firstUpdate bool, mtime time.Time) ([]*svctypes.HealthPolicy, error)
 }
 
 // ClientStore store interface for client info
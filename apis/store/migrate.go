@@ -0,0 +1,65 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package store
+
+import (
+	"fmt"
+)
+
+// Migration 一个版本化的表结构变更
+type Migration struct {
+	// Version 版本号, 从 1 开始连续递增
+	Version int
+	// Name 变更名称
+	Name string
+	// SQL 变更脚本
+	SQL string
+}
+
+// MigrationStatus 数据库表结构的版本状态
+type MigrationStatus struct {
+	// Current 数据库当前的版本, 0 表示数据库尚未初始化
+	Current int
+	// Latest 当前程序支持的最新版本
+	Latest int
+	// Tracked 数据库中是否已经存在版本记录表, 引入版本化迁移之前初始化的数据库为 false
+	Tracked bool
+	// Pending 待执行的变更
+	Pending []*Migration
+}
+
+// Migrator 支持版本化表结构迁移的存储插件, 不依赖 Initialize, 可以在 server 启动前单独执行
+type Migrator interface {
+	// MigrationStatus 查询数据库当前的版本以及待执行的变更
+	MigrationStatus(c *Config) (*MigrationStatus, error)
+	// Migrate 按照版本顺序执行待执行的变更, 返回本次执行的变更
+	Migrate(c *Config) ([]*Migration, error)
+}
+
+// GetMigrator 获取存储插件的表结构迁移能力
+func GetMigrator(name string) (Migrator, error) {
+	s, ok := StoreSlots[name]
+	if !ok {
+		return nil, fmt.Errorf("store `%s` not found", name)
+	}
+	migrator, ok := s.(Migrator)
+	if !ok {
+		return nil, fmt.Errorf("store `%s` not support schema migration", name)
+	}
+	return migrator, nil
+}
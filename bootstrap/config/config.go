@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
@@ -32,10 +33,13 @@ import (
 	"github.com/pole-io/pole-server/pkg/admin"
 	"github.com/pole-io/pole-server/pkg/cache"
 	"github.com/pole-io/pole-server/pkg/common/log"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/config"
 	"github.com/pole-io/pole-server/pkg/goverrule"
+	"github.com/pole-io/pole-server/pkg/multicluster"
 	"github.com/pole-io/pole-server/pkg/namespace"
 	"github.com/pole-io/pole-server/pkg/service"
+	"github.com/pole-io/pole-server/pkg/service/k8ssync"
 )
 
 // Config 配置
@@ -51,6 +55,10 @@ type Config struct {
 	Store      storeapi.Config    `yaml:"store"`
 	Auth       auth.Config        `yaml:"auth"`
 	Plugin     apis.Config        `yaml:"plugin"`
+	// MultiCluster 多集群资源同步配置
+	MultiCluster multicluster.Config `yaml:"multicluster"`
+	// KubernetesSync kubernetes 服务同步配置
+	KubernetesSync k8ssync.Config `yaml:"kubernetesSync"`
 }
 
 // Bootstrap 启动引导配置
@@ -58,6 +66,14 @@ type Bootstrap struct {
 	Logger         map[string]*log.Options
 	StartInOrder   map[string]interface{} `yaml:"startInOrder"`
 	PolarisService PolarisService         `yaml:"polaris_service"`
+	Drain          Drain                  `yaml:"drain"`
+	Tracing        tracing.Config         `yaml:"tracing"`
+}
+
+// Drain 进程退出时长连接的排空配置
+type Drain struct {
+	// Window 分批断开存量长连接的时间窗口, 为 0 时立即断开
+	Window time.Duration `yaml:"window"`
 }
 
 // PolarisService sergo-server的自注册配置
@@ -121,8 +137,10 @@ func Load(filePath string) (*Config, error) {
 	}
 
 	conf := &Config{
-		Bootstrap: defaultBootstrap(),
-		Maintain:  *admin.DefaultConfig(),
+		Bootstrap:      defaultBootstrap(),
+		Maintain:       *admin.DefaultConfig(),
+		MultiCluster:   multicluster.DefaultConfig(),
+		KubernetesSync: k8ssync.DefaultConfig(),
 	}
 	if err = parseYamlContent(string(buf), conf); err != nil {
 		fmt.Printf("[ERROR] %v\n", err)
@@ -0,0 +1,38 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadDefaultConfig 发布的默认配置文件需要能够正常解析
+func TestLoadDefaultConfig(t *testing.T) {
+	conf, err := Load("../../conf/pole-server.yaml")
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	assert.Equal(t, 10*time.Second, conf.Bootstrap.Tracing.Timeout)
+	assert.Equal(t, "pole-server", conf.Bootstrap.Tracing.ServiceName)
+	assert.Equal(t, 10*time.Second, conf.KubernetesSync.Timeout)
+	assert.Equal(t, "boltdbStore", conf.Store.Name)
+}
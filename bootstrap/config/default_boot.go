@@ -19,8 +19,10 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pole-io/pole-server/pkg/common/log"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 )
 
 func defaultBootstrap() Bootstrap {
@@ -43,6 +45,10 @@ func defaultBootstrap() Bootstrap {
 				},
 			},
 		},
+		Drain: Drain{
+			Window: 10 * time.Second,
+		},
+		Tracing: tracing.DefaultConfig(),
 	}
 }
 
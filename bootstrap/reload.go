@@ -0,0 +1,419 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pole-io/pole-server/apis"
+	"github.com/pole-io/pole-server/apis/apiserver"
+	"github.com/pole-io/pole-server/apis/observability/history"
+	"github.com/pole-io/pole-server/apis/observability/statis"
+	admintypes "github.com/pole-io/pole-server/apis/pkg/types/admin"
+	boot_config "github.com/pole-io/pole-server/bootstrap/config"
+	"github.com/pole-io/pole-server/pkg/admin"
+	"github.com/pole-io/pole-server/pkg/cache"
+	"github.com/pole-io/pole-server/pkg/common/log"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+var (
+	reloader *serverReloader
+	// defaultDrainWindow 热加载初始化前使用启动时的排空配置
+	defaultDrainWindow time.Duration
+)
+
+// serverReloader 持有当前正在生效的配置以及 apiserver, 负责配置文件的热加载
+type serverReloader struct {
+	lock    sync.Mutex
+	ctx     context.Context
+	cfg     *boot_config.Config
+	servers []apiserver.Apiserver
+	errCh   chan error
+}
+
+// reloadItem 一个可以在运行期间生效的配置变更
+type reloadItem struct {
+	name string
+	// apply 使配置变更生效
+	apply func() error
+	// commit 生效成功后更新运行中的配置
+	commit func()
+}
+
+// reloadPlan 新旧配置的比对结果
+type reloadPlan struct {
+	items   []reloadItem
+	restart []string
+}
+
+func (p *reloadPlan) add(name string, apply func() error, commit func()) {
+	p.items = append(p.items, reloadItem{name: name, apply: apply, commit: commit})
+}
+
+func (p *reloadPlan) requireRestart(name string) {
+	p.restart = append(p.restart, name)
+}
+
+// initReloader 启动完成后初始化配置热加载
+func initReloader(ctx context.Context, servers []apiserver.Apiserver, errCh chan error) error {
+	// 启动过程中各组件会修改配置对象, 这里重新加载一份原始配置作为热加载的比对基线
+	cfg, err := boot_config.Load(ConfigFilePath)
+	if err != nil {
+		return err
+	}
+	reloader = &serverReloader{
+		ctx:     ctx,
+		cfg:     cfg,
+		servers: servers,
+		errCh:   errCh,
+	}
+	admin.SetConfigReloader(func(_ context.Context) (*admintypes.ReloadResult, error) {
+		return reloader.reload()
+	})
+	return nil
+}
+
+// runningServers 获取当前正在运行的 apiserver, 热加载可能新增 apiserver
+func runningServers(servers []apiserver.Apiserver) []apiserver.Apiserver {
+	if reloader == nil {
+		return servers
+	}
+	reloader.lock.Lock()
+	defer reloader.lock.Unlock()
+	return append([]apiserver.Apiserver{}, reloader.servers...)
+}
+
+// drainWindow 获取当前生效的长连接排空时间窗口
+func drainWindow() time.Duration {
+	if reloader == nil {
+		return defaultDrainWindow
+	}
+	reloader.lock.Lock()
+	defer reloader.lock.Unlock()
+	return reloader.cfg.Bootstrap.Drain.Window
+}
+
+// reload 重新加载配置文件, 可以热更新的配置立即生效, 其余的配置变更返回给调用方提示需要重启
+func (r *serverReloader) reload() (*admintypes.ReloadResult, error) {
+	cfg, err := boot_config.Load(ConfigFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	plan := r.diff(cfg)
+	result := &admintypes.ReloadResult{
+		Applied:         []string{},
+		RestartRequired: plan.restart,
+		Failed:          []string{},
+	}
+	for _, item := range plan.items {
+		if err := item.apply(); err != nil {
+			if errors.Is(err, apis.ErrPluginNotReloadable) {
+				result.RestartRequired = append(result.RestartRequired, item.name)
+				continue
+			}
+			log.Errorf("[Bootstrap][Reload] apply %s fail: %s", item.name, err.Error())
+			result.Failed = append(result.Failed, fmt.Sprintf("%s: %s", item.name, err.Error()))
+			continue
+		}
+		if item.commit != nil {
+			item.commit()
+		}
+		result.Applied = append(result.Applied, item.name)
+	}
+	log.Infof("[Bootstrap][Reload] applied: %v, restart required: %v, failed: %v",
+		result.Applied, result.RestartRequired, result.Failed)
+	return result, nil
+}
+
+// diff 比对运行中的配置和新的配置
+func (r *serverReloader) diff(cfg *boot_config.Config) *reloadPlan {
+	plan := &reloadPlan{}
+	r.diffBootstrap(cfg, plan)
+	r.diffAPIServers(cfg, plan)
+	r.diffPlugins(cfg, plan)
+
+	running := r.cfg
+	if !reflect.DeepEqual(running.Maintain, cfg.Maintain) {
+		plan.add("maintain", func() error {
+			svr, err := admin.GetOriginServer()
+			if err != nil {
+				return err
+			}
+			return svr.ReloadMaintainJobs(&cfg.Maintain)
+		}, func() {
+			running.Maintain = cfg.Maintain
+		})
+	}
+	if !reflect.DeepEqual(running.Cache, cfg.Cache) {
+		plan.add("cache", func() error {
+			cacheMgr, err := cache.GetCacheManager()
+			if err != nil {
+				return err
+			}
+			newCache := cfg.Cache
+			return cacheMgr.Reload(&newCache)
+		}, func() {
+			running.Cache = cfg.Cache
+		})
+	}
+
+	// 以下配置在启动时用于初始化各个模块, 变更后需要重启进程
+	restartOnly := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{name: "namespace", old: running.Namespace, new: cfg.Namespace},
+		{name: "naming", old: running.Naming, new: cfg.Naming},
+		{name: "goverrule", old: running.GoverRule, new: cfg.GoverRule},
+		{name: "config", old: running.Config, new: cfg.Config},
+		{name: "store", old: running.Store, new: cfg.Store},
+		{name: "auth", old: running.Auth, new: cfg.Auth},
+		{name: "cache.changeLog", old: running.Cache.ChangeLog, new: cfg.Cache.ChangeLog},
+		{name: "multiCluster", old: running.MultiCluster, new: cfg.MultiCluster},
+		{name: "kubernetesSync", old: running.KubernetesSync, new: cfg.KubernetesSync},
+	}
+	for _, item := range restartOnly {
+		if !reflect.DeepEqual(item.old, item.new) {
+			plan.requireRestart(item.name)
+		}
+	}
+	return plan
+}
+
+func (r *serverReloader) diffBootstrap(cfg *boot_config.Config, plan *reloadPlan) {
+	running := r.cfg
+	scopes := make([]string, 0, len(cfg.Bootstrap.Logger))
+	for scope := range cfg.Bootstrap.Logger {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+
+	for _, scope := range scopes {
+		name := "bootstrap.logger." + scope
+		oldOpt, ok := running.Bootstrap.Logger[scope]
+		newOpt := cfg.Bootstrap.Logger[scope]
+		if !ok || oldOpt == nil || newOpt == nil {
+			plan.requireRestart(name)
+			continue
+		}
+		scope, oldOpt := scope, oldOpt
+		level := outputLevel(newOpt)
+		if outputLevel(oldOpt) != level {
+			plan.add(name+".outputLevel", func() error {
+				return log.SetLogOutputLevel(scope, level)
+			}, func() {
+				oldOpt.OutputLevel = newOpt.OutputLevel
+			})
+		}
+		// 除日志级别外, 输出路径、滚动策略等需要重建 zap core, 只能重启生效
+		oldCopy, newCopy := *oldOpt, *newOpt
+		oldCopy.OutputLevel, newCopy.OutputLevel = "", ""
+		if !reflect.DeepEqual(oldCopy, newCopy) {
+			plan.requireRestart(name)
+		}
+	}
+	for scope := range running.Bootstrap.Logger {
+		if _, ok := cfg.Bootstrap.Logger[scope]; !ok {
+			plan.requireRestart("bootstrap.logger." + scope)
+		}
+	}
+
+	if !reflect.DeepEqual(running.Bootstrap.StartInOrder, cfg.Bootstrap.StartInOrder) {
+		plan.requireRestart("bootstrap.startInOrder")
+	}
+	if !reflect.DeepEqual(running.Bootstrap.PolarisService, cfg.Bootstrap.PolarisService) {
+		plan.requireRestart("bootstrap.polaris_service")
+	}
+	if !reflect.DeepEqual(running.Bootstrap.Tracing, cfg.Bootstrap.Tracing) {
+		// TracerProvider 只在启动时创建
+		plan.requireRestart("bootstrap.tracing")
+	}
+	if running.Bootstrap.Drain != cfg.Bootstrap.Drain {
+		// 仅在进程退出时读取, 更新运行中的配置即可
+		plan.add("bootstrap.drain", func() error {
+			return nil
+		}, func() {
+			running.Bootstrap.Drain = cfg.Bootstrap.Drain
+		})
+	}
+}
+
+// outputLevel 未配置日志级别时与 log.Configure 的默认值保持一致
+func outputLevel(opt *log.Options) string {
+	if opt.OutputLevel == "" {
+		return log.InfoLevel.Name()
+	}
+	return opt.OutputLevel
+}
+
+func (r *serverReloader) diffAPIServers(cfg *boot_config.Config, plan *reloadPlan) {
+	running := r.cfg
+	for i := range cfg.APIServers {
+		entry := cfg.APIServers[i]
+		name := "apiservers." + entry.Name
+		index := findAPIServer(running.APIServers, entry.Name)
+		if index == -1 {
+			plan.add(name, func() error {
+				return r.startServer(entry)
+			}, func() {
+				running.APIServers = append(running.APIServers, entry)
+			})
+			continue
+		}
+		if reflect.DeepEqual(running.APIServers[index], entry) {
+			continue
+		}
+		// 连接数限制、监听地址等 apiserver 配置通过 Restart 重建监听
+		plan.add(name, func() error {
+			return r.restartServer(entry)
+		}, func() {
+			running.APIServers[findAPIServer(running.APIServers, entry.Name)] = entry
+		})
+	}
+	for i := range running.APIServers {
+		entry := running.APIServers[i]
+		if findAPIServer(cfg.APIServers, entry.Name) != -1 {
+			continue
+		}
+		// apiserver 停止时会向 errCh 上报退出错误从而导致进程退出, 移除 apiserver 需要重启生效
+		plan.requireRestart("apiservers." + entry.Name)
+	}
+}
+
+func findAPIServer(entries []apiserver.Config, name string) int {
+	for i := range entries {
+		if entries[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *serverReloader) startServer(entry apiserver.Config) error {
+	slot, exist := apiserver.Slots[entry.Name]
+	if !exist {
+		return fmt.Errorf("apiserver slot %s not exists", entry.Name)
+	}
+	ctx := r.ctx
+	if entry.Name == "api-http" {
+		ctx = context.WithValue(ctx, utils.ContextAPIServerSlot{}, apiserver.Slots)
+	}
+	if err := slot.Initialize(ctx, entry.Option, entry.API); err != nil {
+		return err
+	}
+	r.servers = append(r.servers, slot)
+	go slot.Run(r.errCh)
+	return nil
+}
+
+func (r *serverReloader) restartServer(entry apiserver.Config) error {
+	slot, exist := apiserver.Slots[entry.Name]
+	if !exist {
+		return fmt.Errorf("apiserver slot %s not exists", entry.Name)
+	}
+	for _, s := range r.servers {
+		if s == slot {
+			return slot.Restart(entry.Option, entry.API, r.errCh)
+		}
+	}
+	return r.startServer(entry)
+}
+
+func (r *serverReloader) diffPlugins(cfg *boot_config.Config, plan *reloadPlan) {
+	running := r.cfg
+	oldPlugin, newPlugin := &running.Plugin, &cfg.Plugin
+
+	if !reflect.DeepEqual(oldPlugin.Statis, newPlugin.Statis) {
+		plan.add("plugin.statis", func() error {
+			return statis.ReloadStatis(newPlugin.Statis)
+		}, func() {
+			oldPlugin.Statis = newPlugin.Statis
+			if pluginCfg := apis.GetPluginConfig(); pluginCfg != nil {
+				pluginCfg.Statis = newPlugin.Statis
+			}
+		})
+	}
+	if !reflect.DeepEqual(oldPlugin.History, newPlugin.History) {
+		plan.add("plugin.history", func() error {
+			return history.ReloadHistory(newPlugin.History)
+		}, func() {
+			oldPlugin.History = newPlugin.History
+			if pluginCfg := apis.GetPluginConfig(); pluginCfg != nil {
+				pluginCfg.History = newPlugin.History
+			}
+		})
+	}
+	diffPluginEntry(plan, "plugin.ratelimit", apis.PluginTypeRateLimit,
+		&oldPlugin.RateLimit, newPlugin.RateLimit, func(c *apis.Config) *apis.ConfigEntry {
+			return &c.RateLimit
+		})
+	diffPluginEntry(plan, "plugin.whitelist", apis.PluginTypeWhitelist,
+		&oldPlugin.Whitelist, newPlugin.Whitelist, func(c *apis.Config) *apis.ConfigEntry {
+			return &c.Whitelist
+		})
+
+	// 以下插件在启动时完成初始化并被各模块持有, 变更后需要重启进程
+	restartOnly := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{name: "plugin.cmdb", old: oldPlugin.CMDB, new: newPlugin.CMDB},
+		{name: "plugin.discoverStatis", old: oldPlugin.DiscoverStatis, new: newPlugin.DiscoverStatis},
+		{name: "plugin.parsePassword", old: oldPlugin.ParsePassword, new: newPlugin.ParsePassword},
+		{name: "plugin.meshResourceValidate", old: oldPlugin.MeshResourceValidate,
+			new: newPlugin.MeshResourceValidate},
+		{name: "plugin.discoverEvent", old: oldPlugin.DiscoverEvent, new: newPlugin.DiscoverEvent},
+		{name: "plugin.crypto", old: oldPlugin.Crypto, new: newPlugin.Crypto},
+	}
+	for _, item := range restartOnly {
+		if !reflect.DeepEqual(item.old, item.new) {
+			plan.requireRestart(item.name)
+		}
+	}
+}
+
+// diffPluginEntry 比对单个插件的配置, 插件名称不变时尝试热更新插件选项
+func diffPluginEntry(plan *reloadPlan, name string, typ apis.PluginType, old *apis.ConfigEntry,
+	entry apis.ConfigEntry, field func(c *apis.Config) *apis.ConfigEntry) {
+	if reflect.DeepEqual(*old, entry) {
+		return
+	}
+	if old.Name != entry.Name || entry.Name == "" {
+		plan.requireRestart(name)
+		return
+	}
+	plan.add(name, func() error {
+		return apis.ReloadPlugin(typ, &entry)
+	}, func() {
+		*old = entry
+		if pluginCfg := apis.GetPluginConfig(); pluginCfg != nil {
+			*field(pluginCfg) = entry
+		}
+	})
+}
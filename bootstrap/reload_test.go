@@ -0,0 +1,184 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package bootstrap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/apis"
+	"github.com/pole-io/pole-server/apis/apiserver"
+	boot_config "github.com/pole-io/pole-server/bootstrap/config"
+	"github.com/pole-io/pole-server/pkg/admin"
+	"github.com/pole-io/pole-server/pkg/common/log"
+)
+
+func newReloadTestConfig() *boot_config.Config {
+	return &boot_config.Config{
+		Bootstrap: boot_config.Bootstrap{
+			Logger: map[string]*log.Options{
+				"default": {
+					RotateOutputPath: "log/runtime/pole-default.log",
+					OutputLevel:      "info",
+				},
+				"naming": {
+					RotateOutputPath: "log/runtime/pole-naming.log",
+				},
+			},
+		},
+		APIServers: []apiserver.Config{
+			{
+				Name: "api-http",
+				Option: map[string]interface{}{
+					"listenIP":   "0.0.0.0",
+					"listenPort": 8090,
+				},
+			},
+			{
+				Name: "service-grpc",
+				Option: map[string]interface{}{
+					"listenIP":   "0.0.0.0",
+					"listenPort": 8091,
+				},
+			},
+		},
+		Maintain: *admin.DefaultConfig(),
+		Plugin: apis.Config{
+			Statis: apis.PluginChanConfig{
+				Entries: []apis.ConfigEntry{{Name: "local"}},
+			},
+			RateLimit: apis.ConfigEntry{
+				Name:   "token-bucket",
+				Option: map[string]interface{}{"enable": false},
+			},
+			Crypto: apis.PluginChanConfig{
+				Entries: []apis.ConfigEntry{{Name: "AES"}},
+			},
+		},
+	}
+}
+
+func itemNames(plan *reloadPlan) []string {
+	names := make([]string, 0, len(plan.items))
+	for _, item := range plan.items {
+		names = append(names, item.name)
+	}
+	return names
+}
+
+func TestServerReloader_Diff(t *testing.T) {
+	t.Run("no_change", func(t *testing.T) {
+		r := &serverReloader{cfg: newReloadTestConfig()}
+		plan := r.diff(newReloadTestConfig())
+		assert.Empty(t, plan.items)
+		assert.Empty(t, plan.restart)
+	})
+
+	t.Run("live_and_restart", func(t *testing.T) {
+		r := &serverReloader{cfg: newReloadTestConfig()}
+		cfg := newReloadTestConfig()
+		cfg.Bootstrap.Logger["naming"].OutputLevel = "debug"
+		cfg.Bootstrap.Logger["default"].RotationMaxSize = 200
+		cfg.APIServers[0].Option["connLimit"] = map[string]interface{}{"openConnLimit": true}
+		cfg.APIServers = cfg.APIServers[:1]
+		cfg.APIServers = append(cfg.APIServers, apiserver.Config{Name: "xdsv3"})
+		cfg.Plugin.RateLimit.Option = map[string]interface{}{"enable": true}
+		cfg.Plugin.Statis.Entries[0].Option = map[string]interface{}{"interval": 30}
+		cfg.Plugin.Crypto.Entries = append(cfg.Plugin.Crypto.Entries, apis.ConfigEntry{Name: "RSA"})
+		cfg.Maintain.Jobs[0].Enable = !cfg.Maintain.Jobs[0].Enable
+		cfg.Cache.DiffTime = 10 * time.Second
+		cfg.Store.Name = "mysql"
+
+		plan := r.diff(cfg)
+		assert.ElementsMatch(t, []string{
+			"bootstrap.logger.naming.outputLevel",
+			"apiservers.api-http",
+			"apiservers.xdsv3",
+			"plugin.statis",
+			"plugin.ratelimit",
+			"maintain",
+			"cache",
+		}, itemNames(plan))
+		assert.ElementsMatch(t, []string{
+			"bootstrap.logger.default",
+			"apiservers.service-grpc",
+			"plugin.crypto",
+			"store",
+		}, plan.restart)
+	})
+
+	t.Run("module_restart", func(t *testing.T) {
+		r := &serverReloader{cfg: newReloadTestConfig()}
+		cfg := newReloadTestConfig()
+		cfg.MultiCluster.Enable = true
+		cfg.KubernetesSync.Enable = true
+		cfg.Bootstrap.Tracing.SampleRatio = 0.5
+
+		plan := r.diff(cfg)
+		assert.Empty(t, plan.items)
+		assert.ElementsMatch(t, []string{
+			"multiCluster",
+			"kubernetesSync",
+			"bootstrap.tracing",
+		}, plan.restart)
+	})
+
+	t.Run("drain_window", func(t *testing.T) {
+		r := &serverReloader{cfg: newReloadTestConfig()}
+		cfg := newReloadTestConfig()
+		cfg.Bootstrap.Drain.Window = 30 * time.Second
+
+		plan := r.diff(cfg)
+		assert.Equal(t, []string{"bootstrap.drain"}, itemNames(plan))
+		assert.Empty(t, plan.restart)
+		for _, item := range plan.items {
+			assert.NoError(t, item.apply())
+			item.commit()
+		}
+		assert.Equal(t, 30*time.Second, r.cfg.Bootstrap.Drain.Window)
+	})
+
+	t.Run("plugin_renamed", func(t *testing.T) {
+		r := &serverReloader{cfg: newReloadTestConfig()}
+		cfg := newReloadTestConfig()
+		cfg.Plugin.RateLimit.Name = "other"
+
+		plan := r.diff(cfg)
+		assert.Empty(t, plan.items)
+		assert.Equal(t, []string{"plugin.ratelimit"}, plan.restart)
+	})
+
+	t.Run("commit_updates_running", func(t *testing.T) {
+		r := &serverReloader{cfg: newReloadTestConfig()}
+		cfg := newReloadTestConfig()
+		cfg.Bootstrap.Logger["naming"].OutputLevel = "debug"
+		cfg.APIServers = append(cfg.APIServers, apiserver.Config{Name: "xdsv3"})
+
+		plan := r.diff(cfg)
+		assert.Len(t, plan.items, 2)
+		for _, item := range plan.items {
+			item.commit()
+		}
+		// 已经生效的配置不再重复出现在比对结果中
+		plan = r.diff(cfg)
+		assert.Empty(t, plan.items)
+		assert.Empty(t, plan.restart)
+	})
+}
@@ -36,7 +36,10 @@ var (
 
 // WaitSignal 等待信号量或err chan 从而执行restart或平滑退出
 func WaitSignal(servers []apiserver.Apiserver, errCh chan error) {
-	defer StopServers(servers)
+	defer func() {
+		// 热加载可能增删 apiserver, 退出时停止当前实际运行的 apiserver
+		StopServers(runningServers(servers))
+	}()
 
 	// 监听信号量
 	signal.Notify(ch, darwinSignals...)
@@ -46,14 +49,12 @@ func WaitSignal(servers []apiserver.Apiserver, errCh chan error) {
 		case s := <-ch:
 			switch s {
 			case syscall.SIGUSR1, syscall.SIGUSR2:
-				// 重启服务
-				if err := RestartServers(errCh); err != nil { // 如果重启失败，直接退出
-					log.Errorf("restart servers err: %s", err.Error())
-					return
+				// 热加载配置, 失败时保持当前配置继续运行
+				if err := RestartServers(errCh); err != nil {
+					log.Errorf("reload config err: %s", err.Error())
+					continue
 				}
-
-				// todo 重启后需要重新监听信号量，等待重启或平滑退出
-				log.Infof("restart servers success: %s", s.String())
+				log.Infof("reload config success: %s", s.String())
 			default:
 				log.Infof("catch signal(%s), stop servers", s.String())
 				return
@@ -36,7 +36,9 @@ var (
 
 // WaitSignal 等待信号量或err chan 从而执行restart或平滑退出
 func WaitSignal(servers []apiserver.Apiserver, errCh chan error) {
-	defer StopServers(servers)
+	defer func() {
+		StopServers(runningServers(servers))
+	}()
 
 	signal.Notify(ch, winSignals...)
 
@@ -48,14 +48,17 @@ import (
 	"github.com/pole-io/pole-server/pkg/common/eventhub"
 	"github.com/pole-io/pole-server/pkg/common/log"
 	"github.com/pole-io/pole-server/pkg/common/metrics"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 	"github.com/pole-io/pole-server/pkg/common/version"
 	config_center "github.com/pole-io/pole-server/pkg/config"
 	"github.com/pole-io/pole-server/pkg/goverrule"
+	"github.com/pole-io/pole-server/pkg/multicluster"
 	"github.com/pole-io/pole-server/pkg/namespace"
 	"github.com/pole-io/pole-server/pkg/service"
 	"github.com/pole-io/pole-server/pkg/service/batch"
 	"github.com/pole-io/pole-server/pkg/service/healthcheck"
+	"github.com/pole-io/pole-server/pkg/service/k8ssync"
 )
 
 var (
@@ -82,6 +85,8 @@ func Start(configFilePath string) {
 	}
 	_, _ = fmt.Println(string(c))
 
+	defaultDrainWindow = cfg.Bootstrap.Drain.Window
+
 	// 初始化日志打印
 	err = log.Configure(cfg.Bootstrap.Logger)
 	if err != nil {
@@ -93,6 +98,15 @@ func Start(configFilePath string) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// 初始化链路追踪，退出时导出剩余的 span
+	if err = tracing.Initialize(ctx, &cfg.Bootstrap.Tracing); err != nil {
+		fmt.Printf("[ERROR] initialize tracing fail: %v\n", err)
+		return
+	}
+	defer func() {
+		_ = tracing.Shutdown(context.Background())
+	}()
+
 	// 获取本地IP地址
 	ctx, err = acquireLocalhost(ctx, &cfg.Bootstrap.PolarisService)
 	if err != nil {
@@ -110,6 +124,7 @@ func Start(configFilePath string) {
 
 	// 初始化存储层
 	storeapi.SetStoreConfig(&cfg.Store)
+	storeapi.SetChangeLogEnable(cfg.Cache.ChangeLog.Enable)
 	var s storeapi.Store
 	s, err = storeapi.GetStore()
 	if err != nil {
@@ -142,6 +157,10 @@ func Start(configFilePath string) {
 		return
 	}
 	_ = FinishBootstrapOrder(tx) // 启动完成，解锁
+	if err := initReloader(ctx, servers, errCh); err != nil {
+		fmt.Printf("[ERROR] init config reloader fail: %v\n", err)
+		return
+	}
 	fmt.Println("finish starting server")
 
 	// 等待信号量
@@ -225,10 +244,24 @@ func StartComponents(ctx context.Context, cfg *boot_config.Config) error {
 		return err
 	}
 
+	// 初始化多集群同步, 需要在 cache 启动前订阅缓存变更事件
+	if err := multicluster.Initialize(ctx, &cfg.MultiCluster, s, cacheMgn); err != nil {
+		return err
+	}
+
 	// 最后启动 cache
 	if err := cache.Run(cacheMgn, ctx); err != nil {
 		return err
 	}
+
+	// 初始化 kubernetes 服务同步, 需要在 cache 完成首次加载后启动
+	originDiscoverSvr, err := service.GetOriginServer()
+	if err != nil {
+		return err
+	}
+	if err := k8ssync.Initialize(ctx, &cfg.KubernetesSync, s, originDiscoverSvr); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -272,6 +305,7 @@ func StartDiscoverComponents(ctx context.Context, cfg *boot_config.Config, s sto
 	if cfg.Naming.HealthChecks.IsOpen() {
 		healthCheckServer.SetServiceCache(cacheMgn.Service())
 		healthCheckServer.SetInstanceCache(cacheMgn.Instance())
+		healthCheckServer.SetHealthPolicyCache(cacheMgn.HealthPolicy())
 	}
 
 	namespaceSvr, err := namespace.GetServer()
@@ -376,16 +410,21 @@ func StartServers(ctx context.Context, cfg *boot_config.Config, errCh chan error
 	return servers, nil
 }
 
-// RestartServers 重启server
+// RestartServers 重新加载配置文件, 热更新可以在运行期间生效的配置
 func RestartServers(errCh chan error) error {
-	// 重新加载配置
-	cfg, err := boot_config.Load(ConfigFilePath)
+	if reloader == nil {
+		return errors.New("config reloader not ready")
+	}
+	result, err := reloader.reload()
 	if err != nil {
-		log.Infof("restart servers, reload config")
 		return err
 	}
-	log.Infof("new config: %+v", cfg)
-	// TODO: 配置的动态加载后续统一设计
+	if len(result.RestartRequired) != 0 {
+		log.Warnf("[Bootstrap] config changed but need restart to take effect: %v", result.RestartRequired)
+	}
+	if len(result.Failed) != 0 {
+		return fmt.Errorf("reload config partially failed: %v", result.Failed)
+	}
 	return nil
 }
 
@@ -395,6 +434,11 @@ func StopServers(servers []apiserver.Apiserver) {
 	if nil != selfHeathChecker {
 		selfHeathChecker.Stop()
 	}
+	// 就绪探测不再通过, 编排系统据此摘除本节点的流量
+	admin.MarkDraining()
+	// 先反注册 pole.checker 等自注册实例, 避免客户端继续发现本节点
+	SelfDeregister()
+	drainServers(servers, drainWindow())
 	// sync stop servers
 	wg := &sync.WaitGroup{}
 	for _, s := range servers {
@@ -407,8 +451,33 @@ func StopServers(servers []apiserver.Apiserver) {
 		}(s, wg)
 	}
 	wg.Wait()
-	// deregister instances
-	SelfDeregister()
+}
+
+// drainServers 在 window 时间内排空各个 apiserver 上的长连接, 让客户端分批迁移到其他节点
+func drainServers(servers []apiserver.Apiserver, window time.Duration) {
+	// 立即应答挂起的配置长轮询, 客户端收到应答后会重新发起请求到其他节点
+	if configServer, err := config_center.GetOriginServer(); err == nil {
+		configServer.WatchCenter().Drain()
+	}
+
+	// 留出余量给最后一批连接完成断开
+	ctx, cancel := context.WithTimeout(context.Background(), window+5*time.Second)
+	defer cancel()
+	wg := &sync.WaitGroup{}
+	for _, s := range servers {
+		drainer, ok := s.(apiserver.Drainer)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(s apiserver.Apiserver, drainer apiserver.Drainer) {
+			defer wg.Done()
+			log.Infof("start drain server protocol: %s", s.GetProtocol())
+			drainer.Drain(ctx, window)
+			log.Infof("complete drain server protocol: %s", s.GetProtocol())
+		}(s, drainer)
+	}
+	wg.Wait()
 }
 
 // StartBootstrapInOrder 开始进入启动加锁
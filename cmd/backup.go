@@ -0,0 +1,133 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/admin"
+	storeapi "github.com/pole-io/pole-server/apis/store"
+	boot_config "github.com/pole-io/pole-server/bootstrap/config"
+	"github.com/pole-io/pole-server/pkg/admin/backup"
+)
+
+var (
+	backupOutput   string
+	backupFile     string
+	backupConflict string
+	backupDryRun   bool
+
+	backupCmd = &cobra.Command{
+		Use:   "backup",
+		Short: "export or restore all server resources",
+		Long:  "export or restore all server resources with a portable backup archive",
+	}
+
+	backupExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "export all server resources to a backup archive",
+		Long:  "export all server resources to a backup archive",
+		RunE: func(c *cobra.Command, args []string) error {
+			s, err := loadBackupStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = s.Destroy()
+			}()
+			f, err := os.Create(backupOutput)
+			if err != nil {
+				return err
+			}
+			manifest, err := backup.Export(s, f)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				_ = os.Remove(backupOutput)
+				return err
+			}
+			fmt.Printf("backup exported to %s\n", backupOutput)
+			kinds := make([]string, 0, len(manifest.Resources))
+			for kind := range manifest.Resources {
+				kinds = append(kinds, kind)
+			}
+			sort.Strings(kinds)
+			for _, kind := range kinds {
+				fmt.Printf("  %s: %d\n", kind, manifest.Resources[kind])
+			}
+			return nil
+		},
+	}
+
+	backupRestoreCmd = &cobra.Command{
+		Use:   "restore",
+		Short: "restore server resources from a backup archive",
+		Long:  "restore server resources from a backup archive, use --dry-run to print the report without writing",
+		RunE: func(c *cobra.Command, args []string) error {
+			policy, err := backup.ParseConflictPolicy(backupConflict)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(backupFile)
+			if err != nil {
+				return err
+			}
+			s, err := loadBackupStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = s.Destroy()
+			}()
+			report, err := backup.Restore(s, data, policy, backupDryRun)
+			if report != nil {
+				out, _ := json.MarshalIndent(report, "", "  ")
+				fmt.Println(string(out))
+			}
+			return err
+		},
+	}
+)
+
+// init 解析命令参数
+func init() {
+	backupCmd.PersistentFlags().StringVarP(&configFilePath, "config", "c", "conf/pole-server.yaml", "config file path")
+	backupExportCmd.Flags().StringVarP(&backupOutput, "output", "o", "pole-backup.zip", "backup archive path")
+	backupRestoreCmd.Flags().StringVarP(&backupFile, "file", "f", "", "backup archive path")
+	backupRestoreCmd.Flags().StringVar(&backupConflict, "conflict", string(admin.ConflictSkip),
+		"policy for existing resources: skip, overwrite, fail")
+	backupRestoreCmd.Flags().BoolVar(&backupDryRun, "dry-run", false, "print the restore report without writing")
+	_ = backupRestoreCmd.MarkFlagRequired("file")
+	backupCmd.AddCommand(backupExportCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+}
+
+func loadBackupStore() (storeapi.Store, error) {
+	cfg, err := boot_config.Load(configFilePath)
+	if err != nil {
+		return nil, err
+	}
+	storeapi.SetStoreConfig(&cfg.Store)
+	return storeapi.GetStore()
+}
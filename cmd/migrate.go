@@ -0,0 +1,125 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	storeapi "github.com/pole-io/pole-server/apis/store"
+	boot_config "github.com/pole-io/pole-server/bootstrap/config"
+)
+
+var (
+	migrateDryRun bool
+
+	migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "manage database schema migrations",
+		Long:  "manage database schema migrations",
+	}
+
+	migrateStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "print current schema version and pending migrations",
+		Long:  "print current schema version and pending migrations",
+		RunE: func(c *cobra.Command, args []string) error {
+			migrator, storeConf, err := loadMigrator()
+			if err != nil {
+				return err
+			}
+			status, err := migrator.MigrationStatus(storeConf)
+			if err != nil {
+				return err
+			}
+			printMigrationStatus(status)
+			return nil
+		},
+	}
+
+	migrateUpCmd = &cobra.Command{
+		Use:   "up",
+		Short: "apply all pending migrations",
+		Long:  "apply all pending migrations, use --dry-run to print the SQL without executing it",
+		RunE: func(c *cobra.Command, args []string) error {
+			migrator, storeConf, err := loadMigrator()
+			if err != nil {
+				return err
+			}
+			if migrateDryRun {
+				status, err := migrator.MigrationStatus(storeConf)
+				if err != nil {
+					return err
+				}
+				printMigrationStatus(status)
+				for _, migration := range status.Pending {
+					fmt.Printf("\n-- migration %d_%s\n%s\n", migration.Version, migration.Name, migration.SQL)
+				}
+				return nil
+			}
+			applied, err := migrator.Migrate(storeConf)
+			for _, migration := range applied {
+				fmt.Printf("applied migration %d_%s\n", migration.Version, migration.Name)
+			}
+			if err != nil {
+				return err
+			}
+			if len(applied) == 0 {
+				fmt.Println("database schema is up to date")
+			}
+			return nil
+		},
+	}
+)
+
+// init 解析命令参数
+func init() {
+	migrateCmd.PersistentFlags().StringVarP(&configFilePath, "config", "c", "conf/pole-server.yaml", "config file path")
+	migrateUpCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "print the SQL of pending migrations only")
+	migrateCmd.AddCommand(migrateStatusCmd)
+	migrateCmd.AddCommand(migrateUpCmd)
+}
+
+func loadMigrator() (storeapi.Migrator, *storeapi.Config, error) {
+	cfg, err := boot_config.Load(configFilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	migrator, err := storeapi.GetMigrator(cfg.Store.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return migrator, &cfg.Store, nil
+}
+
+func printMigrationStatus(status *storeapi.MigrationStatus) {
+	fmt.Printf("current version: %d\n", status.Current)
+	fmt.Printf("latest version: %d\n", status.Latest)
+	if !status.Tracked && status.Current > 0 {
+		fmt.Printf("schema version table not found, version %d will be recorded as baseline\n", status.Current)
+	}
+	if len(status.Pending) == 0 {
+		fmt.Println("pending migrations: none")
+		return
+	}
+	fmt.Println("pending migrations:")
+	for _, migration := range status.Pending {
+		fmt.Printf("  %d_%s\n", migration.Version, migration.Name)
+	}
+}
@@ -35,6 +35,8 @@ func init() {
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(revisionCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(backupCmd)
 }
 
 // Execute 执行命令行解析
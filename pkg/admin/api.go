@@ -19,6 +19,7 @@ package admin
 
 import (
 	"context"
+	"io"
 
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
@@ -58,6 +59,24 @@ type AdminOperateServer interface {
 	HasMainUser(ctx context.Context) *apiservice.Response
 	// InitMainUser .
 	InitMainUser(ctx context.Context, user *apisecurity.User) *apiservice.Response
+	// ReloadConfig 重新加载配置文件, 返回各配置项的生效情况
+	ReloadConfig(ctx context.Context) (*admin.ReloadResult, error)
+	// ExportBackup 导出全部资源的备份文件
+	ExportBackup(ctx context.Context, w io.Writer) error
+	// RestoreBackup 从备份文件恢复资源, policy 决定已存在资源的处理方式
+	RestoreBackup(ctx context.Context, data []byte, policy admin.ConflictPolicy, dryRun bool) (*admin.RestoreReport, error)
+	// GetSelfPreservation 获取健康检查自我保护的状态
+	GetSelfPreservation(ctx context.Context) (*admin.SelfPreservationStatus, error)
+	// ListHealthPolicies 查询命名空间、服务维度的健康检查以及过期策略
+	ListHealthPolicies(ctx context.Context) ([]*svctypes.HealthPolicy, error)
+	// UpsertHealthPolicy 创建或者更新健康检查以及过期策略
+	UpsertHealthPolicy(ctx context.Context, policy *svctypes.HealthPolicy) error
+	// DeleteHealthPolicy 删除健康检查以及过期策略
+	DeleteHealthPolicy(ctx context.Context, namespace, service string) error
+	// Liveness 进程存活探测
+	Liveness(ctx context.Context) *admin.ProbeResult
+	// Readiness 就绪探测, 存储层可访问、缓存完成预热、健康检查分发器就绪且节点未处于排空阶段
+	Readiness(ctx context.Context) *admin.ProbeResult
 	// GetServerFunctions Get server functions
 	GetServerFunctions(ctx context.Context) []authcommon.ServerFunctionGroup
 }
@@ -0,0 +1,54 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"io"
+
+	"go.uber.org/zap"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/admin"
+	"github.com/pole-io/pole-server/pkg/admin/backup"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+// ExportBackup 导出全部资源的备份文件
+func (s *Server) ExportBackup(ctx context.Context, w io.Writer) error {
+	manifest, err := backup.Export(s.storage, w)
+	if err != nil {
+		log.Error("[Admin][Backup] export backup fail", utils.RequestID(ctx), zap.Error(err))
+		return err
+	}
+	log.Info("[Admin][Backup] export backup", utils.RequestID(ctx), zap.Any("resources", manifest.Resources))
+	return nil
+}
+
+// RestoreBackup 从备份文件恢复资源, 恢复的数据由缓存的增量同步加载
+func (s *Server) RestoreBackup(ctx context.Context, data []byte, policy admin.ConflictPolicy,
+	dryRun bool) (*admin.RestoreReport, error) {
+	report, err := backup.Restore(s.storage, data, policy, dryRun)
+	if err != nil {
+		log.Error("[Admin][Backup] restore backup fail", utils.RequestID(ctx), zap.String("policy", string(policy)),
+			zap.Bool("dry-run", dryRun), zap.Error(err))
+		return report, err
+	}
+	log.Info("[Admin][Backup] restore backup", utils.RequestID(ctx), zap.String("policy", string(policy)),
+		zap.Bool("dry-run", dryRun))
+	return report, nil
+}
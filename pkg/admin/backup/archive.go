@@ -0,0 +1,148 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+const (
+	// FormatVersion 备份文件的格式版本, 格式发生不兼容变更时递增
+	FormatVersion = 1
+
+	manifestFile = "manifest.json"
+	resourceDir  = "resources"
+	// maxEntrySize 单个文件解压后的大小上限, 避免恶意构造的压缩文件耗尽内存
+	maxEntrySize = 256 << 20
+)
+
+var (
+	// ErrUnsupportedFormat 备份文件的格式版本不被当前程序支持
+	ErrUnsupportedFormat = errors.New("unsupported backup format version")
+	// ErrEntryTooLarge 备份文件中的单个文件解压后超过大小上限
+	ErrEntryTooLarge = errors.New("backup archive entry too large")
+)
+
+// Manifest 备份文件的描述信息
+type Manifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	ServerVersion string    `json:"serverVersion"`
+	CreateTime    time.Time `json:"createTime"`
+	// Resources 各类资源的数量
+	Resources map[string]int `json:"resources"`
+}
+
+// Archive 备份内容, 每类资源以 JSON 数组的形式保存为 resources/<kind>.json
+type Archive struct {
+	Manifest  Manifest
+	Resources map[string]json.RawMessage
+}
+
+// Write 将备份内容写为 zip 文件
+func (a *Archive) Write(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	manifest, err := json.MarshalIndent(a.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeEntry(zw, manifestFile, manifest); err != nil {
+		return err
+	}
+	for kind, data := range a.Resources {
+		if err := writeEntry(zw, path.Join(resourceDir, kind+".json"), data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeEntry(zw *zip.Writer, name string, data []byte) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(data)
+	return err
+}
+
+// ReadArchive 解析 zip 格式的备份文件
+func ReadArchive(data []byte) (*Archive, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup archive: %w", err)
+	}
+	archive := &Archive{Resources: map[string]json.RawMessage{}}
+	foundManifest := false
+	for _, f := range zr.File {
+		content, err := readEntry(f)
+		if err != nil {
+			return nil, err
+		}
+		if f.Name == manifestFile {
+			if err := json.Unmarshal(content, &archive.Manifest); err != nil {
+				return nil, fmt.Errorf("invalid backup manifest: %w", err)
+			}
+			foundManifest = true
+			continue
+		}
+		dir, file := path.Split(f.Name)
+		if path.Clean(dir) != resourceDir || path.Ext(file) != ".json" {
+			continue
+		}
+		archive.Resources[file[:len(file)-len(".json")]] = content
+	}
+	if !foundManifest {
+		return nil, errors.New("invalid backup archive: manifest.json not found")
+	}
+	if archive.Manifest.FormatVersion < 1 || archive.Manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("%w: %d, supported version <= %d", ErrUnsupportedFormat,
+			archive.Manifest.FormatVersion, FormatVersion)
+	}
+	return archive, nil
+}
+
+func readEntry(f *zip.File) ([]byte, error) {
+	return readEntryLimit(f, maxEntrySize)
+}
+
+func readEntryLimit(f *zip.File, limit int64) ([]byte, error) {
+	if f.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("%w: %s", ErrEntryTooLarge, f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	// 文件头中记录的大小可以被篡改, 读取时同样限制大小
+	content, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("%w: %s", ErrEntryTooLarge, f.Name)
+	}
+	return content, nil
+}
@@ -0,0 +1,116 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+// Package backup 将服务端管理的全部资源导出为可移植的备份文件, 并支持恢复到其他集群
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/admin"
+	"github.com/pole-io/pole-server/apis/store"
+	"github.com/pole-io/pole-server/pkg/common/version"
+)
+
+var (
+	// ErrConflict 冲突策略为 fail 时, 目标集群中存在与备份冲突的资源
+	ErrConflict = errors.New("backup conflicts with existing resources, nothing restored")
+)
+
+// ParseConflictPolicy 解析冲突策略, 为空时默认跳过冲突的资源
+func ParseConflictPolicy(v string) (admin.ConflictPolicy, error) {
+	switch policy := admin.ConflictPolicy(v); policy {
+	case "":
+		return admin.ConflictSkip, nil
+	case admin.ConflictSkip, admin.ConflictOverwrite, admin.ConflictFail:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid conflict policy %q, must be one of skip, overwrite, fail", v)
+	}
+}
+
+// Export 导出全部资源并写为备份文件
+func Export(s store.Store, w io.Writer) (*Manifest, error) {
+	return export(s, defaultSections(), w)
+}
+
+func export(s store.Store, sections []resourceSection, w io.Writer) (*Manifest, error) {
+	archive := &Archive{
+		Manifest: Manifest{
+			FormatVersion: FormatVersion,
+			ServerVersion: version.Get(),
+			CreateTime:    time.Now(),
+			Resources:     map[string]int{},
+		},
+		Resources: map[string]json.RawMessage{},
+	}
+	for _, sec := range sections {
+		data, count, err := sec.dump(s)
+		if err != nil {
+			return nil, err
+		}
+		archive.Resources[sec.kind()] = data
+		archive.Manifest.Resources[sec.kind()] = count
+	}
+	if err := archive.Write(w); err != nil {
+		return nil, err
+	}
+	return &archive.Manifest, nil
+}
+
+// Restore 将备份文件中的资源恢复到当前集群
+func Restore(s store.Store, data []byte, policy admin.ConflictPolicy, dryRun bool) (*admin.RestoreReport, error) {
+	archive, err := ReadArchive(data)
+	if err != nil {
+		return nil, err
+	}
+	return restore(s, defaultSections(), archive, policy, dryRun)
+}
+
+func restore(s store.Store, sections []resourceSection, archive *Archive, policy admin.ConflictPolicy,
+	dryRun bool) (*admin.RestoreReport, error) {
+	if policy == admin.ConflictFail && !dryRun {
+		// 先完整地检查一遍冲突, 存在冲突时不写入任何资源
+		report, err := restore(s, sections, archive, policy, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range report.Resources {
+			if len(item.Conflicts) > 0 {
+				return report, ErrConflict
+			}
+		}
+	}
+
+	report := &admin.RestoreReport{
+		DryRun:    dryRun,
+		Policy:    policy,
+		Resources: make([]*admin.RestoreResourceReport, 0, len(sections)),
+	}
+	for _, sec := range sections {
+		item, err := sec.restore(s, archive.Resources[sec.kind()], policy, dryRun)
+		if err != nil {
+			return report, err
+		}
+		report.Resources = append(report.Resources, item)
+	}
+	return report, nil
+}
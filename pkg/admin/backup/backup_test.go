@@ -0,0 +1,256 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/admin"
+	authtypes "github.com/pole-io/pole-server/apis/pkg/types/auth"
+	"github.com/pole-io/pole-server/apis/store"
+	"github.com/pole-io/pole-server/plugin/store/mock"
+)
+
+type fakeItem struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// newFakeSection 基于内存 map 的资源, 用于验证导出以及冲突策略
+func newFakeSection(name string, data map[string]string) resourceSection {
+	return &section[*fakeItem]{
+		name: name,
+		list: func(s store.Store) ([]*fakeItem, error) {
+			ret := make([]*fakeItem, 0, len(data))
+			for k, v := range data {
+				ret = append(ret, &fakeItem{Name: k, Value: v})
+			}
+			return sortByKey(ret, func(item *fakeItem) string { return item.Name }), nil
+		},
+		key: func(item *fakeItem) string { return item.Name },
+		exists: func(s store.Store, item *fakeItem) (bool, error) {
+			_, ok := data[item.Name]
+			return ok, nil
+		},
+		create: func(s store.Store, item *fakeItem) error {
+			if item.Value == "bad" {
+				return errors.New("mock create fail")
+			}
+			data[item.Name] = item.Value
+			return nil
+		},
+		update: func(s store.Store, item *fakeItem) error {
+			data[item.Name] = item.Value
+			return nil
+		},
+	}
+}
+
+func exportFake(t *testing.T, data map[string]string) *Archive {
+	buf := &bytes.Buffer{}
+	manifest, err := export(nil, []resourceSection{newFakeSection("items", data)}, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, len(data), manifest.Resources["items"])
+
+	archive, err := ReadArchive(buf.Bytes())
+	assert.NoError(t, err)
+	return archive
+}
+
+func TestExportAndReadArchive(t *testing.T) {
+	archive := exportFake(t, map[string]string{"a": "1", "b": "2"})
+	assert.Equal(t, FormatVersion, archive.Manifest.FormatVersion)
+	assert.Equal(t, 2, archive.Manifest.Resources["items"])
+	assert.JSONEq(t, `[{"name":"a","value":"1"},{"name":"b","value":"2"}]`, string(archive.Resources["items"]))
+
+	t.Run("unsupported_format", func(t *testing.T) {
+		archive.Manifest.FormatVersion = FormatVersion + 1
+		buf := &bytes.Buffer{}
+		assert.NoError(t, archive.Write(buf))
+		_, err := ReadArchive(buf.Bytes())
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	})
+
+	t.Run("not_zip", func(t *testing.T) {
+		_, err := ReadArchive([]byte("not a zip file"))
+		assert.Error(t, err)
+	})
+
+	t.Run("entry_too_large", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		zw := zip.NewWriter(buf)
+		assert.NoError(t, writeEntry(zw, "resources/items.json", bytes.Repeat([]byte("a"), 1024)))
+		assert.NoError(t, zw.Close())
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		assert.NoError(t, err)
+
+		content, err := readEntryLimit(zr.File[0], 1024)
+		assert.NoError(t, err)
+		assert.Len(t, content, 1024)
+		_, err = readEntryLimit(zr.File[0], 512)
+		assert.ErrorIs(t, err, ErrEntryTooLarge)
+	})
+}
+
+func TestUserSection_Token(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := mock.NewMockStore(ctrl)
+	s.EXPECT().GetMoreUsers(time.Time{}, true).Return([]*authtypes.User{
+		{ID: "u1", Name: "u1", Password: "hash", Token: "secret", TokenEnable: true, Valid: true},
+	}, nil)
+
+	data, count, err := userSection().dump(s)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NotContains(t, string(data), "secret")
+
+	sec := userSection().(*section[*authtypes.User])
+	// 已存在的用户按照名称覆盖, 沿用目标集群中的用户 ID 并保留原有的 Token
+	s.EXPECT().GetUserByName("u1").Return(&authtypes.User{ID: "target-u1", Name: "u1", Token: "old"}, nil)
+	s.EXPECT().UpdateUser(gomock.Any()).DoAndReturn(func(user *authtypes.User) error {
+		assert.Equal(t, "target-u1", user.ID)
+		assert.Equal(t, "old", user.Token)
+		return nil
+	})
+	assert.NoError(t, sec.update(s, &authtypes.User{ID: "u1", Name: "u1", TokenEnable: true}))
+}
+
+func TestAuthSections_ConflictByName(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := mock.NewMockStore(ctrl)
+
+	roles := roleSection().(*section[*authtypes.Role])
+	role := &authtypes.Role{ID: "backup-role", Name: "admin", Owner: "main"}
+	s.EXPECT().GetRoleByName("admin", "main").Return(&authtypes.Role{ID: "target-role"}, nil).Times(2)
+	exists, err := roles.exists(s, role)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	s.EXPECT().UpdateRole(gomock.Any()).DoAndReturn(func(item *authtypes.Role) error {
+		assert.Equal(t, "target-role", item.ID)
+		return nil
+	})
+	assert.NoError(t, roles.update(s, role))
+
+	policies := strategySection().(*section[*authtypes.StrategyDetail])
+	policy := &authtypes.StrategyDetail{
+		ID:         "backup-policy",
+		Name:       "read",
+		Resources:  []authtypes.StrategyResource{{StrategyID: "backup-policy", ResID: "ns"}},
+		Principals: []authtypes.Principal{{StrategyID: "backup-policy", PrincipalID: "u1"}},
+	}
+	s.EXPECT().GetStrategyDetailByName("read").Return(&authtypes.StrategyDetail{ID: "target-policy"}, nil)
+	s.EXPECT().UpdateStrategy(gomock.Any()).DoAndReturn(func(item *authtypes.StrategyDetail) error {
+		assert.Equal(t, "target-policy", item.ID)
+		assert.Equal(t, "target-policy", item.Resources[0].StrategyID)
+		assert.Equal(t, "target-policy", item.Principals[0].StrategyID)
+		return nil
+	})
+	assert.NoError(t, policies.update(s, policy))
+
+	// 名称不存在时不视为冲突
+	groups := userGroupSection().(*section[*authtypes.UserGroupDetail])
+	s.EXPECT().GetGroupByName("dev").Return(nil, nil)
+	exists, err = groups.exists(s, &authtypes.UserGroupDetail{UserGroup: &authtypes.UserGroup{ID: "g1", Name: "dev"}})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestRestore(t *testing.T) {
+	archive := exportFake(t, map[string]string{"a": "1", "b": "2", "c": "bad"})
+
+	cases := []struct {
+		name    string
+		policy  admin.ConflictPolicy
+		dryRun  bool
+		wantErr error
+		want    admin.RestoreResourceReport
+		target  map[string]string
+	}{
+		{
+			name:   "skip",
+			policy: admin.ConflictSkip,
+			want:   admin.RestoreResourceReport{Created: 1, Skipped: 1},
+			target: map[string]string{"a": "old", "b": "2"},
+		},
+		{
+			name:   "overwrite",
+			policy: admin.ConflictOverwrite,
+			want:   admin.RestoreResourceReport{Created: 1, Overwritten: 1},
+			target: map[string]string{"a": "1", "b": "2"},
+		},
+		{
+			name:    "fail",
+			policy:  admin.ConflictFail,
+			wantErr: ErrConflict,
+			want:    admin.RestoreResourceReport{Created: 2, Skipped: 1},
+			target:  map[string]string{"a": "old"},
+		},
+		{
+			name:   "dry_run",
+			policy: admin.ConflictOverwrite,
+			dryRun: true,
+			want:   admin.RestoreResourceReport{Created: 2, Overwritten: 1},
+			target: map[string]string{"a": "old"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			target := map[string]string{"a": "old"}
+			sections := []resourceSection{newFakeSection("items", target)}
+			report, err := restore(nil, sections, archive, c.policy, c.dryRun)
+			if c.wantErr != nil {
+				assert.ErrorIs(t, err, c.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, c.target, target)
+			assert.Equal(t, 1, len(report.Resources))
+			item := report.Resources[0]
+			assert.Equal(t, 3, item.Total)
+			assert.Equal(t, c.want.Created, item.Created)
+			assert.Equal(t, c.want.Overwritten, item.Overwritten)
+			assert.Equal(t, c.want.Skipped, item.Skipped)
+			assert.Equal(t, []string{"a"}, item.Conflicts)
+			if !c.dryRun && c.wantErr == nil {
+				assert.Equal(t, 1, len(item.Failed))
+			}
+		})
+	}
+}
+
+func TestParseConflictPolicy(t *testing.T) {
+	policy, err := ParseConflictPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, admin.ConflictSkip, policy)
+
+	policy, err = ParseConflictPolicy("overwrite")
+	assert.NoError(t, err)
+	assert.Equal(t, admin.ConflictOverwrite, policy)
+
+	_, err = ParseConflictPolicy("merge")
+	assert.Error(t, err)
+}
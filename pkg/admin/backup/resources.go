@@ -0,0 +1,725 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+	authtypes "github.com/pole-io/pole-server/apis/pkg/types/auth"
+	conftypes "github.com/pole-io/pole-server/apis/pkg/types/config"
+	"github.com/pole-io/pole-server/apis/pkg/types/rules"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/apis/store"
+)
+
+const (
+	// configFilePageSize 分页拉取配置文件的大小
+	configFilePageSize = 100
+)
+
+// defaultSections 全部资源的导出以及恢复, 按照依赖关系排序, 被依赖的资源先恢复
+func defaultSections() []resourceSection {
+	return []resourceSection{
+		namespaceSection(),
+		serviceSection(),
+		serviceContractSection(),
+		instanceSection(),
+		healthPolicySection(),
+		routerSection(),
+		rateLimitSection(),
+		circuitBreakerSection(),
+		faultDetectSection(),
+		laneGroupSection(),
+		configGroupSection(),
+		configFileSection(),
+		configReleaseSection(),
+		userSection(),
+		userGroupSection(),
+		roleSection(),
+		strategySection(),
+	}
+}
+
+// sortByKey 保证导出内容稳定, 便于比对两次备份的差异
+func sortByKey[T any](items []T, key func(T) string) []T {
+	sort.SliceStable(items, func(i, j int) bool {
+		return key(items[i]) < key(items[j])
+	})
+	return items
+}
+
+func namespaceSection() resourceSection {
+	key := func(item *types.Namespace) string { return item.Name }
+	return &section[*types.Namespace]{
+		name: "namespaces",
+		list: func(s store.Store) ([]*types.Namespace, error) {
+			items, err := s.GetMoreNamespaces(time.Time{})
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]*types.Namespace, 0, len(items))
+			for _, item := range items {
+				if item.Valid {
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *types.Namespace) (bool, error) {
+			old, err := s.GetNamespace(item.Name)
+			return old != nil, err
+		},
+		create: func(s store.Store, item *types.Namespace) error {
+			return s.AddNamespace(item)
+		},
+		update: func(s store.Store, item *types.Namespace) error {
+			return s.UpdateNamespace(item)
+		},
+	}
+}
+
+func serviceSection() resourceSection {
+	key := func(item *svctypes.Service) string { return item.Namespace + "/" + item.Name }
+	return &section[*svctypes.Service]{
+		name: "services",
+		list: func(s store.Store) ([]*svctypes.Service, error) {
+			items, err := s.GetMoreServices(time.Time{}, true, false, true)
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]*svctypes.Service, 0, len(items))
+			for _, item := range items {
+				if item.Valid {
+					ret = append(ret, item)
+				}
+			}
+			sortByKey(ret, key)
+			// 别名依赖源服务, 源服务需要先于别名恢复
+			sort.SliceStable(ret, func(i, j int) bool {
+				return !ret[i].IsAlias() && ret[j].IsAlias()
+			})
+			return ret, nil
+		},
+		key: key,
+		exists: func(s store.Store, item *svctypes.Service) (bool, error) {
+			old, err := s.GetService(item.Name, item.Namespace)
+			return old != nil, err
+		},
+		create: func(s store.Store, item *svctypes.Service) error {
+			return s.AddService(item)
+		},
+		update: func(s store.Store, item *svctypes.Service) error {
+			// 目标集群中的服务 ID 可能与备份中的不同, 按照名称覆盖
+			old, err := s.GetService(item.Name, item.Namespace)
+			if err != nil {
+				return err
+			}
+			if old == nil {
+				return s.AddService(item)
+			}
+			item.ID = old.ID
+			if item.IsAlias() {
+				return s.UpdateServiceAlias(item, true)
+			}
+			return s.UpdateService(item, true)
+		},
+	}
+}
+
+func serviceContractSection() resourceSection {
+	key := func(item *svctypes.EnrichServiceContract) string { return item.ID }
+	return &section[*svctypes.EnrichServiceContract]{
+		name: "service_contracts",
+		list: func(s store.Store) ([]*svctypes.EnrichServiceContract, error) {
+			items, err := s.GetMoreServiceContracts(true, time.Time{})
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]*svctypes.EnrichServiceContract, 0, len(items))
+			for _, item := range items {
+				if item.Valid {
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *svctypes.EnrichServiceContract) (bool, error) {
+			old, err := s.GetServiceContract(item.ID)
+			return old != nil, err
+		},
+		create: func(s store.Store, item *svctypes.EnrichServiceContract) error {
+			if err := s.CreateServiceContract(item.ServiceContract); err != nil {
+				return err
+			}
+			return s.AddServiceContractInterfaces(item)
+		},
+		update: func(s store.Store, item *svctypes.EnrichServiceContract) error {
+			if err := s.UpdateServiceContract(item.ServiceContract); err != nil {
+				return err
+			}
+			return s.AddServiceContractInterfaces(item)
+		},
+	}
+}
+
+func instanceSection() resourceSection {
+	key := func(item *svctypes.Instance) string { return item.ID() }
+	// 实例通过服务 ID 关联服务, 目标集群中的服务 ID 可能与备份中的不同
+	bindService := func(s store.Store, item *svctypes.Instance) error {
+		svc, err := s.GetService(item.Proto.GetService().GetValue(), item.Proto.GetNamespace().GetValue())
+		if err != nil {
+			return err
+		}
+		if svc == nil {
+			return fmt.Errorf("service %s/%s not found", item.Proto.GetNamespace().GetValue(),
+				item.Proto.GetService().GetValue())
+		}
+		item.ServiceID = svc.ID
+		return nil
+	}
+	return &section[*svctypes.Instance]{
+		name: "instances",
+		list: func(s store.Store) ([]*svctypes.Instance, error) {
+			tx, err := s.StartReadTx()
+			if err != nil {
+				return nil, err
+			}
+			defer func() {
+				_ = tx.Rollback()
+			}()
+			items, err := s.GetMoreInstances(tx, time.Time{}, true, true, nil)
+			if err != nil {
+				return nil, err
+			}
+			// 只备份持久化实例, 开启健康检查的临时实例由客户端重新注册
+			ret := make([]*svctypes.Instance, 0, len(items))
+			for _, item := range items {
+				if item.Valid && !item.EnableHealthCheck() {
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *svctypes.Instance) (bool, error) {
+			old, err := s.GetInstance(context.Background(), item.ID())
+			return old != nil, err
+		},
+		create: func(s store.Store, item *svctypes.Instance) error {
+			if err := bindService(s, item); err != nil {
+				return err
+			}
+			return s.AddInstance(context.Background(), item)
+		},
+		update: func(s store.Store, item *svctypes.Instance) error {
+			if err := bindService(s, item); err != nil {
+				return err
+			}
+			return s.UpdateInstance(context.Background(), item)
+		},
+	}
+}
+
+func healthPolicySection() resourceSection {
+	key := func(item *svctypes.HealthPolicy) string { return item.Key() }
+	return &section[*svctypes.HealthPolicy]{
+		name: "health_policies",
+		list: func(s store.Store) ([]*svctypes.HealthPolicy, error) {
+			items, err := s.GetMoreHealthPolicies(true, time.Time{})
+			if err != nil {
+				return nil, err
+			}
+			return sortByKey(items, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *svctypes.HealthPolicy) (bool, error) {
+			old, err := s.GetHealthPolicy(item.Namespace, item.Service)
+			return old != nil, err
+		},
+		create: func(s store.Store, item *svctypes.HealthPolicy) error {
+			return s.UpsertHealthPolicy(item)
+		},
+		update: func(s store.Store, item *svctypes.HealthPolicy) error {
+			return s.UpsertHealthPolicy(item)
+		},
+	}
+}
+
+func routerSection() resourceSection {
+	key := func(item *rules.RouterConfig) string { return item.ID }
+	return &section[*rules.RouterConfig]{
+		name: "router_rules",
+		list: func(s store.Store) ([]*rules.RouterConfig, error) {
+			items, err := s.GetRoutingConfigsForCache(time.Time{}, true)
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]*rules.RouterConfig, 0, len(items))
+			for _, item := range items {
+				if item.Valid {
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *rules.RouterConfig) (bool, error) {
+			old, err := s.GetRoutingConfigWithID(item.ID)
+			return old != nil, err
+		},
+		create: func(s store.Store, item *rules.RouterConfig) error {
+			return s.CreateRoutingConfig(item)
+		},
+		update: func(s store.Store, item *rules.RouterConfig) error {
+			return s.UpdateRoutingConfig(item)
+		},
+	}
+}
+
+func rateLimitSection() resourceSection {
+	key := func(item *rules.RateLimit) string { return item.ID }
+	return &section[*rules.RateLimit]{
+		name: "ratelimit_rules",
+		list: func(s store.Store) ([]*rules.RateLimit, error) {
+			items, err := s.GetRateLimitsForCache(time.Time{}, true)
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]*rules.RateLimit, 0, len(items))
+			for _, item := range items {
+				if item.Valid {
+					// 规则内容以 Rule 字段为准
+					item.Proto = nil
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *rules.RateLimit) (bool, error) {
+			old, err := s.GetRateLimitWithID(item.ID)
+			return old != nil, err
+		},
+		create: func(s store.Store, item *rules.RateLimit) error {
+			return s.CreateRateLimit(item)
+		},
+		update: func(s store.Store, item *rules.RateLimit) error {
+			return s.UpdateRateLimit(item)
+		},
+	}
+}
+
+func circuitBreakerSection() resourceSection {
+	key := func(item *rules.CircuitBreakerRule) string { return item.ID }
+	return &section[*rules.CircuitBreakerRule]{
+		name: "circuitbreaker_rules",
+		list: func(s store.Store) ([]*rules.CircuitBreakerRule, error) {
+			items, err := s.GetCircuitBreakerRulesForCache(time.Time{}, true)
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]*rules.CircuitBreakerRule, 0, len(items))
+			for _, item := range items {
+				if item.Valid {
+					item.Proto = nil
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *rules.CircuitBreakerRule) (bool, error) {
+			return s.HasCircuitBreakerRule(item.ID)
+		},
+		create: func(s store.Store, item *rules.CircuitBreakerRule) error {
+			return s.CreateCircuitBreakerRule(item)
+		},
+		update: func(s store.Store, item *rules.CircuitBreakerRule) error {
+			return s.UpdateCircuitBreakerRule(item)
+		},
+	}
+}
+
+func faultDetectSection() resourceSection {
+	key := func(item *rules.FaultDetectRule) string { return item.ID }
+	return &section[*rules.FaultDetectRule]{
+		name: "faultdetect_rules",
+		list: func(s store.Store) ([]*rules.FaultDetectRule, error) {
+			items, err := s.GetFaultDetectRulesForCache(time.Time{}, true)
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]*rules.FaultDetectRule, 0, len(items))
+			for _, item := range items {
+				if item.Valid {
+					item.Proto = nil
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *rules.FaultDetectRule) (bool, error) {
+			return s.HasFaultDetectRule(item.ID)
+		},
+		create: func(s store.Store, item *rules.FaultDetectRule) error {
+			return s.CreateFaultDetectRule(item)
+		},
+		update: func(s store.Store, item *rules.FaultDetectRule) error {
+			return s.UpdateFaultDetectRule(item)
+		},
+	}
+}
+
+func laneGroupSection() resourceSection {
+	key := func(item *rules.LaneGroup) string { return item.ID }
+	return &section[*rules.LaneGroup]{
+		name: "lane_groups",
+		list: func(s store.Store) ([]*rules.LaneGroup, error) {
+			items, err := s.GetMoreLaneGroups(time.Time{}, true)
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]*rules.LaneGroup, 0, len(items))
+			for _, item := range items {
+				if item.Valid {
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *rules.LaneGroup) (bool, error) {
+			old, err := s.GetLaneGroupByID(item.ID)
+			return old != nil, err
+		},
+		create: func(s store.Store, item *rules.LaneGroup) error {
+			return withTx(s, func(tx store.Tx) error {
+				return s.AddLaneGroup(tx, item)
+			})
+		},
+		update: func(s store.Store, item *rules.LaneGroup) error {
+			return withTx(s, func(tx store.Tx) error {
+				return s.UpdateLaneGroup(tx, item)
+			})
+		},
+	}
+}
+
+func configGroupSection() resourceSection {
+	key := func(item *conftypes.ConfigFileGroup) string { return item.Namespace + "/" + item.Name }
+	return &section[*conftypes.ConfigFileGroup]{
+		name: "config_groups",
+		list: func(s store.Store) ([]*conftypes.ConfigFileGroup, error) {
+			items, err := s.GetMoreConfigGroup(true, time.Time{})
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]*conftypes.ConfigFileGroup, 0, len(items))
+			for _, item := range items {
+				if item.Valid {
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *conftypes.ConfigFileGroup) (bool, error) {
+			old, err := s.GetConfigFileGroup(item.Namespace, item.Name)
+			return old != nil, err
+		},
+		create: func(s store.Store, item *conftypes.ConfigFileGroup) error {
+			_, err := s.CreateConfigFileGroup(item)
+			return err
+		},
+		update: func(s store.Store, item *conftypes.ConfigFileGroup) error {
+			return s.UpdateConfigFileGroup(item)
+		},
+	}
+}
+
+func configFileSection() resourceSection {
+	key := func(item *conftypes.ConfigFile) string { return item.Key().String() }
+	return &section[*conftypes.ConfigFile]{
+		name: "config_files",
+		list: func(s store.Store) ([]*conftypes.ConfigFile, error) {
+			ret := make([]*conftypes.ConfigFile, 0, configFilePageSize)
+			for offset := uint32(0); ; offset += configFilePageSize {
+				total, items, err := s.QueryConfigFiles(map[string]string{}, offset, configFilePageSize)
+				if err != nil {
+					return nil, err
+				}
+				ret = append(ret, items...)
+				if len(items) == 0 || offset+configFilePageSize >= total {
+					break
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *conftypes.ConfigFile) (bool, error) {
+			old, err := s.GetConfigFile(item.Namespace, item.Group, item.Name)
+			return old != nil, err
+		},
+		create: func(s store.Store, item *conftypes.ConfigFile) error {
+			return withTx(s, func(tx store.Tx) error {
+				return s.CreateConfigFileTx(tx, item)
+			})
+		},
+		update: func(s store.Store, item *conftypes.ConfigFile) error {
+			return withTx(s, func(tx store.Tx) error {
+				return s.UpdateConfigFileTx(tx, item)
+			})
+		},
+	}
+}
+
+func configReleaseSection() resourceSection {
+	key := func(item *conftypes.ConfigFileRelease) string { return item.FileKey() }
+	return &section[*conftypes.ConfigFileRelease]{
+		name: "config_releases",
+		list: func(s store.Store) ([]*conftypes.ConfigFileRelease, error) {
+			items, err := s.GetMoreReleaseFile(true, time.Time{})
+			if err != nil {
+				return nil, err
+			}
+			// 只备份正在生效的全量发布, 灰度发布以及历史版本不做备份
+			ret := make([]*conftypes.ConfigFileRelease, 0, len(items))
+			for _, item := range items {
+				if item.Valid && item.Active && item.ReleaseType != conftypes.ReleaseTypeGray {
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *conftypes.ConfigFileRelease) (bool, error) {
+			old, err := s.GetConfigFileActiveRelease(item.ToFileKey())
+			return old != nil, err
+		},
+		create: func(s store.Store, item *conftypes.ConfigFileRelease) error {
+			return withTx(s, func(tx store.Tx) error {
+				return s.CreateConfigFileReleaseTx(tx, item)
+			})
+		},
+		update: func(s store.Store, item *conftypes.ConfigFileRelease) error {
+			// 重新发布备份中的版本, 同名的历史发布需要先删除
+			return withTx(s, func(tx store.Tx) error {
+				if err := s.DeleteConfigFileReleaseTx(tx, item.ConfigFileReleaseKey); err != nil {
+					return err
+				}
+				return s.CreateConfigFileReleaseTx(tx, item)
+			})
+		},
+	}
+}
+
+// userSection 导出的用户不包含访问凭据 Token, 恢复时已存在的用户保留原有的 Token,
+// 新建的用户关闭 Token, 需要通过重置 Token 接口重新生成后才能使用.
+// 用户、用户组以及鉴权策略保存时 owner 固定为空, 按照名称判断是否冲突
+func userSection() resourceSection {
+	key := func(item *authtypes.User) string { return item.Name }
+	return &section[*authtypes.User]{
+		name: "users",
+		list: func(s store.Store) ([]*authtypes.User, error) {
+			items, err := s.GetMoreUsers(time.Time{}, true)
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]*authtypes.User, 0, len(items))
+			for _, item := range items {
+				if item.Valid {
+					item.Token = ""
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *authtypes.User) (bool, error) {
+			old, err := s.GetUserByName(item.Name)
+			return old != nil, err
+		},
+		create: createUser,
+		update: func(s store.Store, item *authtypes.User) error {
+			// 目标集群中的用户 ID 可能与备份中的不同, 按照名称覆盖
+			old, err := s.GetUserByName(item.Name)
+			if err != nil {
+				return err
+			}
+			if old == nil {
+				return createUser(s, item)
+			}
+			item.ID = old.ID
+			item.Token = old.Token
+			return s.UpdateUser(item)
+		},
+	}
+}
+
+// userGroupSection 与用户相同, 导出的用户组不包含访问凭据 Token
+func userGroupSection() resourceSection {
+	key := func(item *authtypes.UserGroupDetail) string { return item.Name }
+	return &section[*authtypes.UserGroupDetail]{
+		name: "user_groups",
+		list: func(s store.Store) ([]*authtypes.UserGroupDetail, error) {
+			items, err := s.GetMoreGroups(time.Time{}, true)
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]*authtypes.UserGroupDetail, 0, len(items))
+			for _, item := range items {
+				if item.Valid {
+					item.Token = ""
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *authtypes.UserGroupDetail) (bool, error) {
+			old, err := s.GetGroupByName(item.Name)
+			return old != nil, err
+		},
+		create: createUserGroup,
+		update: func(s store.Store, item *authtypes.UserGroupDetail) error {
+			old, err := s.GetGroupByName(item.Name)
+			if err != nil {
+				return err
+			}
+			if old == nil {
+				return createUserGroup(s, item)
+			}
+			item.ID = old.ID
+			item.Token = old.Token
+			return s.UpdateGroup(item)
+		},
+	}
+}
+
+func roleSection() resourceSection {
+	key := func(item *authtypes.Role) string { return item.Name }
+	return &section[*authtypes.Role]{
+		name: "roles",
+		list: func(s store.Store) ([]*authtypes.Role, error) {
+			items, err := s.GetMoreRoles(true, time.Time{})
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]*authtypes.Role, 0, len(items))
+			for _, item := range items {
+				if item.Valid {
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *authtypes.Role) (bool, error) {
+			old, err := s.GetRoleByName(item.Name, item.Owner)
+			return old != nil, err
+		},
+		create: func(s store.Store, item *authtypes.Role) error {
+			return s.AddRole(item)
+		},
+		update: func(s store.Store, item *authtypes.Role) error {
+			old, err := s.GetRoleByName(item.Name, item.Owner)
+			if err != nil {
+				return err
+			}
+			if old == nil {
+				return s.AddRole(item)
+			}
+			item.ID = old.ID
+			return s.UpdateRole(item)
+		},
+	}
+}
+
+func strategySection() resourceSection {
+	key := func(item *authtypes.StrategyDetail) string { return item.Name }
+	return &section[*authtypes.StrategyDetail]{
+		name: "policies",
+		list: func(s store.Store) ([]*authtypes.StrategyDetail, error) {
+			items, err := s.GetMoreStrategies(time.Time{}, true)
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]*authtypes.StrategyDetail, 0, len(items))
+			for _, item := range items {
+				if item.Valid {
+					ret = append(ret, item)
+				}
+			}
+			return sortByKey(ret, key), nil
+		},
+		key: key,
+		exists: func(s store.Store, item *authtypes.StrategyDetail) (bool, error) {
+			old, err := s.GetStrategyDetailByName(item.Name)
+			return old != nil, err
+		},
+		create: createStrategy,
+		update: func(s store.Store, item *authtypes.StrategyDetail) error {
+			old, err := s.GetStrategyDetailByName(item.Name)
+			if err != nil {
+				return err
+			}
+			if old == nil {
+				return createStrategy(s, item)
+			}
+			item.ID = old.ID
+			for i := range item.Resources {
+				item.Resources[i].StrategyID = old.ID
+			}
+			for i := range item.Principals {
+				item.Principals[i].StrategyID = old.ID
+			}
+			return s.UpdateStrategy(item)
+		},
+	}
+}
+
+func createUser(s store.Store, item *authtypes.User) error {
+	if item.Token == "" {
+		item.TokenEnable = false
+	}
+	return withTx(s, func(tx store.Tx) error {
+		return s.AddUser(tx, item)
+	})
+}
+
+func createUserGroup(s store.Store, item *authtypes.UserGroupDetail) error {
+	if item.Token == "" {
+		item.TokenEnable = false
+	}
+	return withTx(s, func(tx store.Tx) error {
+		return s.AddGroup(tx, item)
+	})
+}
+
+func createStrategy(s store.Store, item *authtypes.StrategyDetail) error {
+	return withTx(s, func(tx store.Tx) error {
+		return s.AddStrategy(tx, item)
+	})
+}
@@ -0,0 +1,128 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/admin"
+	"github.com/pole-io/pole-server/apis/store"
+)
+
+// resourceSection 一类资源的导出以及恢复
+type resourceSection interface {
+	// kind 资源类别, 对应备份文件中的 resources/<kind>.json
+	kind() string
+	// dump 导出全部资源
+	dump(s store.Store) (json.RawMessage, int, error)
+	// restore 恢复备份中的资源, dryRun 时只统计将要执行的操作
+	restore(s store.Store, data json.RawMessage, policy admin.ConflictPolicy,
+		dryRun bool) (*admin.RestoreResourceReport, error)
+}
+
+// section 基于存储层接口实现的资源导出以及恢复
+type section[T any] struct {
+	name string
+	// list 拉取全部有效的资源
+	list func(s store.Store) ([]T, error)
+	// key 资源在报告中的唯一标识
+	key func(item T) string
+	// exists 目标集群中是否已经存在该资源
+	exists func(s store.Store, item T) (bool, error)
+	create func(s store.Store, item T) error
+	update func(s store.Store, item T) error
+}
+
+func (sec *section[T]) kind() string {
+	return sec.name
+}
+
+func (sec *section[T]) dump(s store.Store) (json.RawMessage, int, error) {
+	items, err := sec.list(s)
+	if err != nil {
+		return nil, 0, fmt.Errorf("dump %s fail: %w", sec.name, err)
+	}
+	if items == nil {
+		items = []T{}
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, 0, fmt.Errorf("encode %s fail: %w", sec.name, err)
+	}
+	return data, len(items), nil
+}
+
+func (sec *section[T]) restore(s store.Store, data json.RawMessage, policy admin.ConflictPolicy,
+	dryRun bool) (*admin.RestoreResourceReport, error) {
+	report := &admin.RestoreResourceReport{Kind: sec.name}
+	if len(data) == 0 {
+		return report, nil
+	}
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("decode %s fail: %w", sec.name, err)
+	}
+	report.Total = len(items)
+	for _, item := range items {
+		key := sec.key(item)
+		exists, err := sec.exists(s, item)
+		if err != nil {
+			report.Failed = append(report.Failed, key+": "+err.Error())
+			continue
+		}
+		if !exists {
+			if !dryRun {
+				if err := sec.create(s, item); err != nil {
+					report.Failed = append(report.Failed, key+": "+err.Error())
+					continue
+				}
+			}
+			report.Created++
+			continue
+		}
+		report.Conflicts = append(report.Conflicts, key)
+		if policy != admin.ConflictOverwrite {
+			report.Skipped++
+			continue
+		}
+		if !dryRun {
+			if err := sec.update(s, item); err != nil {
+				report.Failed = append(report.Failed, key+": "+err.Error())
+				continue
+			}
+		}
+		report.Overwritten++
+	}
+	return report, nil
+}
+
+// withTx 在事务中执行只提供事务版本的存储接口
+func withTx(s store.Store, fn func(tx store.Tx) error) error {
+	tx, err := s.StartTx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
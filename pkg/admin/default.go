@@ -21,8 +21,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/pole-io/pole-server/apis/access_control/auth"
+	"github.com/pole-io/pole-server/apis/pkg/types/admin"
 	"github.com/pole-io/pole-server/apis/store"
 	"github.com/pole-io/pole-server/pkg/admin/job"
 	"github.com/pole-io/pole-server/pkg/cache"
@@ -35,8 +37,23 @@ var (
 	maintainServer       = &Server{}
 	finishInit           bool
 	serverProxyFactories = map[string]ServerProxyFactory{}
+	configReloader       ConfigReloader
+	draining             atomic.Bool
 )
 
+// ConfigReloader 配置热加载执行器, 由 bootstrap 在启动完成后注入
+type ConfigReloader func(ctx context.Context) (*admin.ReloadResult, error)
+
+// SetConfigReloader 设置配置热加载执行器
+func SetConfigReloader(reloader ConfigReloader) {
+	configReloader = reloader
+}
+
+// MarkDraining 标记节点进入排空阶段, 此后就绪探测不再通过
+func MarkDraining() {
+	draining.Store(true)
+}
+
 type ServerProxyFactory func(ctx context.Context, pre AdminOperateServer) (AdminOperateServer, error)
 
 func RegisterServerProxy(name string, factor ServerProxyFactory) error {
@@ -85,6 +102,7 @@ func InitServer(ctx context.Context, cfg *Config, namingService service.Discover
 	if err := maintainJobs.StartMaintianJobs(cfg.Jobs); err != nil {
 		return nil, nil, err
 	}
+	actualSvr.maintainJobs = maintainJobs
 
 	var proxySvr AdminOperateServer
 	proxySvr = actualSvr
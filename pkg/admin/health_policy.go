@@ -0,0 +1,74 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+var (
+	errHealthPolicyNamespace = errors.New("health policy namespace is required")
+	errHealthPolicyTtl       = errors.New("health policy minTtl must not be greater than maxTtl")
+)
+
+// ListHealthPolicies 查询全部的健康检查以及过期策略
+func (s *Server) ListHealthPolicies(_ context.Context) ([]*svctypes.HealthPolicy, error) {
+	return s.cacheMgr.HealthPolicy().ListPolicies(), nil
+}
+
+// UpsertHealthPolicy 创建或者更新策略, 写入存储后立即刷新本节点缓存
+func (s *Server) UpsertHealthPolicy(ctx context.Context, policy *svctypes.HealthPolicy) error {
+	if policy == nil || policy.Namespace == "" {
+		return errHealthPolicyNamespace
+	}
+	if policy.MinTtl != 0 && policy.MaxTtl != 0 && policy.MinTtl > policy.MaxTtl {
+		return errHealthPolicyTtl
+	}
+	if ns := s.cacheMgr.Namespace().GetNamespace(policy.Namespace); ns == nil {
+		return fmt.Errorf("namespace %s not found", policy.Namespace)
+	}
+	if err := s.storage.UpsertHealthPolicy(policy); err != nil {
+		log.Error("[Admin][HealthPolicy] upsert health policy", utils.RequestID(ctx),
+			zap.String("key", policy.Key()), zap.Error(err))
+		return err
+	}
+	log.Info("[Admin][HealthPolicy] upsert health policy", utils.RequestID(ctx), zap.String("key", policy.Key()))
+	return s.cacheMgr.HealthPolicy().Update()
+}
+
+// DeleteHealthPolicy 删除策略
+func (s *Server) DeleteHealthPolicy(ctx context.Context, namespace, service string) error {
+	if namespace == "" {
+		return errHealthPolicyNamespace
+	}
+	if err := s.storage.DeleteHealthPolicy(namespace, service); err != nil {
+		log.Error("[Admin][HealthPolicy] delete health policy", utils.RequestID(ctx),
+			zap.String("key", svctypes.HealthPolicyKey(namespace, service)), zap.Error(err))
+		return err
+	}
+	log.Info("[Admin][HealthPolicy] delete health policy", utils.RequestID(ctx),
+		zap.String("key", svctypes.HealthPolicyKey(namespace, service)))
+	return s.cacheMgr.HealthPolicy().Update()
+}
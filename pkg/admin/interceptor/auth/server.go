@@ -19,6 +19,7 @@ package auth
 
 import (
 	"context"
+	"io"
 
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
@@ -172,6 +173,44 @@ func (svr *Server) SetLogOutputLevel(ctx context.Context, scope string, level st
 	return svr.nextSvr.SetLogOutputLevel(ctx, scope, level)
 }
 
+func (svr *Server) ReloadConfig(ctx context.Context) (*admincommon.ReloadResult, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, authcommon.Modify, authcommon.ReloadServerConfig)
+	if _, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, types.ContextAuthContextKey, authCtx)
+
+	return svr.nextSvr.ReloadConfig(ctx)
+}
+
+func (svr *Server) ExportBackup(ctx context.Context, w io.Writer) error {
+	// 备份内容包含用户的密码摘要等敏感数据, 与恢复备份一样需要写权限
+	authCtx := svr.collectMaintainAuthContext(ctx, authcommon.Modify, authcommon.ExportServerBackup)
+	if _, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, types.ContextAuthContextKey, authCtx)
+
+	return svr.nextSvr.ExportBackup(ctx, w)
+}
+
+func (svr *Server) RestoreBackup(ctx context.Context, data []byte, policy admincommon.ConflictPolicy,
+	dryRun bool) (*admincommon.RestoreReport, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, authcommon.Modify, authcommon.RestoreServerBackup)
+	if _, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, types.ContextAuthContextKey, authCtx)
+
+	return svr.nextSvr.RestoreBackup(ctx, data, policy, dryRun)
+}
+
 func (svr *Server) ListLeaderElections(ctx context.Context) ([]*admincommon.LeaderElection, error) {
 	authCtx := svr.collectMaintainAuthContext(ctx, authcommon.Read, authcommon.DescribeLeaderElections)
 	if _, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
@@ -208,6 +247,64 @@ func (svr *Server) GetCMDBInfo(ctx context.Context) ([]svctypes.LocationView, er
 	return svr.nextSvr.GetCMDBInfo(ctx)
 }
 
+func (svr *Server) GetSelfPreservation(ctx context.Context) (*admincommon.SelfPreservationStatus, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, authcommon.Read, authcommon.DescribeSelfPreservation)
+	if _, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, types.ContextAuthContextKey, authCtx)
+
+	return svr.nextSvr.GetSelfPreservation(ctx)
+}
+
+func (svr *Server) ListHealthPolicies(ctx context.Context) ([]*svctypes.HealthPolicy, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, authcommon.Read, authcommon.DescribeHealthPolicies)
+	if _, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, types.ContextAuthContextKey, authCtx)
+
+	return svr.nextSvr.ListHealthPolicies(ctx)
+}
+
+func (svr *Server) UpsertHealthPolicy(ctx context.Context, policy *svctypes.HealthPolicy) error {
+	authCtx := svr.collectMaintainAuthContext(ctx, authcommon.Modify, authcommon.UpdateHealthPolicy)
+	if _, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, types.ContextAuthContextKey, authCtx)
+
+	return svr.nextSvr.UpsertHealthPolicy(ctx, policy)
+}
+
+func (svr *Server) DeleteHealthPolicy(ctx context.Context, namespace, service string) error {
+	authCtx := svr.collectMaintainAuthContext(ctx, authcommon.Delete, authcommon.DeleteHealthPolicy)
+	if _, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, types.ContextAuthContextKey, authCtx)
+
+	return svr.nextSvr.DeleteHealthPolicy(ctx, namespace, service)
+}
+
+// Liveness 探针接口供编排系统调用, 不做鉴权
+func (svr *Server) Liveness(ctx context.Context) *admincommon.ProbeResult {
+	return svr.nextSvr.Liveness(ctx)
+}
+
+// Readiness 探针接口供编排系统调用, 不做鉴权
+func (svr *Server) Readiness(ctx context.Context) *admincommon.ProbeResult {
+	return svr.nextSvr.Readiness(ctx)
+}
+
 // GetServerFunctions .
 func (svr *Server) GetServerFunctions(ctx context.Context) []authcommon.ServerFunctionGroup {
 	return svr.nextSvr.GetServerFunctions(ctx)
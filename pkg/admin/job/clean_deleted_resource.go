@@ -47,6 +47,7 @@ var cleanFuncMapping = map[string]func(timeout time.Duration, job *cleanDeletedR
 		cleanDeletedRules("lane_rule", timeout, job)
 	},
 	"config_file_release": cleanDeletedConfigFiles,
+	"change_log":          cleanChangeLogs,
 }
 
 var defaultCleanDeletedResourceConfig = CleandeletedResourceConf{
@@ -91,6 +92,10 @@ var defaultCleanDeletedResourceConfig = CleandeletedResourceConf{
 			Resource: "config_file_release",
 			Enable:   true,
 		},
+		{
+			Resource: "change_log",
+			Enable:   true,
+		},
 	},
 }
 
@@ -267,3 +272,19 @@ func cleanDeletedRules(rule string, timeout time.Duration, job *cleanDeletedReso
 		}
 	}
 }
+
+// cleanChangeLogs 清理已经过期的资源变更记录, 缓存同步落后太多时会回退为按照 mtime 拉取
+func cleanChangeLogs(timeout time.Duration, job *cleanDeletedResourceJob) {
+	batchSize := uint32(1000)
+	for {
+		count, err := job.storage.BatchCleanChangeLogs(timeout, batchSize)
+		if err != nil {
+			log.Errorf("[Maintain][Job][CleanChangeLogs] batch clean change logs, err: %v", err)
+			break
+		}
+		log.Infof("[Maintain][Job][CleanChangeLogs] clean change logs count %d", count)
+		if count < batchSize {
+			break
+		}
+	}
+}
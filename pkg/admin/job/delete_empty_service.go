@@ -93,6 +93,10 @@ func (job *deleteEmptyServiceJob) getAllEmptyServices() []*svctypes.Service {
 		if svc.IsAlias() {
 			return true, nil
 		}
+		// 策略中关闭了自动删除的服务不参与清理
+		if !getHealthPolicy(job.cacheMgn, svc.Namespace, svc.Name).IsAutoDelete() {
+			return true, nil
+		}
 		count := job.cacheMgn.Instance().GetInstancesCountByServiceID(svc.ID)
 		if count.TotalInstanceCount == 0 {
 			res = append(res, svc)
@@ -112,7 +116,8 @@ func (job *deleteEmptyServiceJob) filterToDeletedServices(services []*svctypes.S
 			m[svc.ID] = now
 			continue
 		}
-		if now.After(value.Add(timeout)) {
+		retention := getHealthPolicy(job.cacheMgn, svc.Namespace, svc.Name).GetEmptyServiceRetention(timeout)
+		if now.After(value.Add(retention)) {
 			toDeleteServices = append(toDeleteServices, svc)
 		} else {
 			m[svc.ID] = value
@@ -25,9 +25,12 @@ import (
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 
 	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
 	"github.com/pole-io/pole-server/apis/store"
+	"github.com/pole-io/pole-server/pkg/cache"
 	api "github.com/pole-io/pole-server/pkg/common/api/v1"
 	"github.com/pole-io/pole-server/pkg/service"
+	"github.com/pole-io/pole-server/pkg/service/healthcheck"
 )
 
 type DeleteUnHealthyInstanceJobConfig struct {
@@ -37,6 +40,7 @@ type DeleteUnHealthyInstanceJobConfig struct {
 type deleteUnHealthyInstanceJob struct {
 	cfg          *DeleteUnHealthyInstanceJobConfig
 	namingServer service.DiscoverServer
+	cacheMgn     *cache.CacheManager
 	storage      store.Store
 }
 
@@ -67,20 +71,23 @@ func (job *deleteUnHealthyInstanceJob) interval() time.Duration {
 }
 
 func (job *deleteUnHealthyInstanceJob) execute() {
-	batchSize := uint32(100)
+	// 健康检查处于自我保护期间，不健康的实例很可能是网络抖动导致的，不进行删除
+	if hcSvr, err := healthcheck.GetServer(); err == nil && hcSvr.IsSelfPreserving() {
+		log.Warnf("[Maintain][Job][DeleteUnHealthyInstance] health check is in self preservation, skip")
+		return
+	}
+
+	instanceIds := job.getUnHealthyInstances(time.Now())
+	batchSize := 100
 	var count int = 0
-	for {
-		instanceIds, err := job.storage.GetUnHealthyInstances(job.cfg.InstanceDeleteTimeout, batchSize)
-		if err != nil {
-			log.Errorf("[Maintain][Job][DeleteUnHealthyInstance] get unhealthy instances, err: %v", err)
-			break
-		}
-		if len(instanceIds) == 0 {
-			break
+	for i := 0; i < len(instanceIds); i += batchSize {
+		j := i + batchSize
+		if j > len(instanceIds) {
+			j = len(instanceIds)
 		}
 
 		var req []*apiservice.Instance
-		for _, id := range instanceIds {
+		for _, id := range instanceIds[i:j] {
 			req = append(req, &apiservice.Instance{Id: protobuf.NewStringValue(id)})
 		}
 
@@ -92,18 +99,40 @@ func (job *deleteUnHealthyInstanceJob) execute() {
 		resp := job.namingServer.DeleteInstances(ctx, req)
 		if api.CalcCode(resp) == 200 {
 			log.Infof("[Maintain][Job][DeleteUnHealthyInstance] delete instance count %d, list: %v",
-				len(instanceIds), instanceIds)
+				j-i, instanceIds[i:j])
 		} else {
 			log.Errorf("[Maintain][Job][DeleteUnHealthyInstance] delete instance list: %v, err: %d %s",
-				instanceIds, resp.Code.GetValue(), resp.Info.GetValue())
+				instanceIds[i:j], resp.Code.GetValue(), resp.Info.GetValue())
 			break
 		}
-		count += len(instanceIds)
+		count += j - i
 	}
 
 	log.Infof("[Maintain][Job][DeleteUnHealthyInstance] delete unhealthy instance count %d", count)
 
 }
 
+// getUnHealthyInstances 获取超过删除延迟的不健康实例, 删除延迟以及是否自动删除由实例所在命名空间、服务的策略决定
+func (job *deleteUnHealthyInstanceJob) getUnHealthyInstances(now time.Time) []string {
+	var instanceIds []string
+	_ = job.cacheMgn.Instance().IteratorInstances(func(key string, ins *svctypes.Instance) (bool, error) {
+		policy := getHealthPolicy(job.cacheMgn, ins.Namespace(), ins.Service())
+		if isUnHealthyInstanceExpired(ins, policy, job.cfg.InstanceDeleteTimeout, now) {
+			instanceIds = append(instanceIds, ins.ID())
+		}
+		return true, nil
+	})
+	return instanceIds
+}
+
+// isUnHealthyInstanceExpired 开启了健康检查的实例不健康的时间超过了删除延迟
+func isUnHealthyInstanceExpired(ins *svctypes.Instance, policy *svctypes.HealthPolicy,
+	timeout time.Duration, now time.Time) bool {
+	if !ins.EnableHealthCheck() || ins.Healthy() || !policy.IsAutoDelete() {
+		return false
+	}
+	return now.Sub(ins.ModifyTime) > policy.GetUnhealthyDeleteDelay(timeout)
+}
+
 func (job *deleteUnHealthyInstanceJob) clear() {
 }
@@ -20,6 +20,11 @@ package job
 import (
 	"testing"
 	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
 )
 
 func Test_DeleteUnHealthyInstanceJobConfigInit(t *testing.T) {
@@ -51,3 +56,40 @@ func Test_DeleteUnHealthyInstanceJobConfigInitErr(t *testing.T) {
 		t.Errorf("init deleteUnHealthyInstanceJob config should err")
 	}
 }
+
+func Test_IsUnHealthyInstanceExpired(t *testing.T) {
+	now := time.Now()
+	newInstance := func(healthy bool, mtime time.Time) *svctypes.Instance {
+		return &svctypes.Instance{
+			Proto: &apiservice.Instance{
+				Healthy:           protobuf.NewBoolValue(healthy),
+				EnableHealthCheck: protobuf.NewBoolValue(true),
+			},
+			ModifyTime: mtime,
+		}
+	}
+	timeout := 10 * time.Minute
+	expired := newInstance(false, now.Add(-20*time.Minute))
+
+	if !isUnHealthyInstanceExpired(expired, nil, timeout, now) {
+		t.Errorf("unhealthy instance should be expired without policy")
+	}
+	if isUnHealthyInstanceExpired(newInstance(true, now.Add(-20*time.Minute)), nil, timeout, now) {
+		t.Errorf("healthy instance should not be expired")
+	}
+	if isUnHealthyInstanceExpired(newInstance(false, now.Add(-5*time.Minute)), nil, timeout, now) {
+		t.Errorf("unhealthy instance should not be expired before timeout")
+	}
+
+	autoDelete := false
+	if isUnHealthyInstanceExpired(expired, &svctypes.HealthPolicy{AutoDelete: &autoDelete}, timeout, now) {
+		t.Errorf("instance should not be expired when auto delete is disabled")
+	}
+	policy := &svctypes.HealthPolicy{UnhealthyDeleteDelay: 3600}
+	if isUnHealthyInstanceExpired(expired, policy, timeout, now) {
+		t.Errorf("instance should not be expired before policy delay")
+	}
+	if !isUnHealthyInstanceExpired(newInstance(false, now.Add(-2*time.Hour)), policy, timeout, now) {
+		t.Errorf("instance should be expired after policy delay")
+	}
+}
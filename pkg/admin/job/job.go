@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/pole-io/pole-server/apis/pkg/types"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
 	"github.com/pole-io/pole-server/apis/store"
 	"github.com/pole-io/pole-server/pkg/cache"
 	commonlog "github.com/pole-io/pole-server/pkg/common/log"
@@ -46,7 +47,7 @@ func NewMaintainJobs(namingServer service.DiscoverServer, cacheMgn *cache.CacheM
 	return &MaintainJobs{
 		jobs: map[string]maintainJob{
 			"DeleteUnHealthyInstance": &deleteUnHealthyInstanceJob{
-				namingServer: namingServer, storage: storage},
+				namingServer: namingServer, cacheMgn: cacheMgn, storage: storage},
 			"DeleteEmptyService": &deleteEmptyServiceJob{
 				namingServer: namingServer, cacheMgn: cacheMgn, storage: storage},
 			"CleanConfigReleaseHistory": &cleanConfigFileHistoryJob{
@@ -92,6 +93,14 @@ func (mj *MaintainJobs) StartMaintianJobs(configs []JobConfig) error {
 	return nil
 }
 
+// getHealthPolicy 获取服务生效的健康检查以及过期策略, 不存在时返回 nil
+func getHealthPolicy(cacheMgn *cache.CacheManager, namespace, service string) *svctypes.HealthPolicy {
+	if cacheMgn == nil {
+		return nil
+	}
+	return cacheMgn.HealthPolicy().GetPolicy(namespace, service)
+}
+
 func parseJobName(name string) string {
 	// 兼容老配置
 	if name == "DeleteEmptyAutoCreatedService" {
@@ -117,6 +126,12 @@ func (mj *MaintainJobs) StopMaintainJobs() {
 	mj.startedJobs = map[string]maintainJob{}
 }
 
+// ReloadMaintainJobs 使用新的配置重新启动运维任务
+func (mj *MaintainJobs) ReloadMaintainJobs(configs []JobConfig) error {
+	mj.StopMaintainJobs()
+	return mj.StartMaintianJobs(configs)
+}
+
 func runAdminJob(ctx context.Context, name string, interval time.Duration, job maintainJob, storage store.Store) {
 	safeExec := func() {
 		if !storage.IsLeader(store.ElectionKeyMaintainJob) {
@@ -207,6 +207,13 @@ func (s *Server) GetLastHeartbeat(_ context.Context, req *apiservice.Instance) *
 	return s.healthCheckServer.GetLastHeartbeat(req)
 }
 
+func (s *Server) GetSelfPreservation(_ context.Context) (*admin.SelfPreservationStatus, error) {
+	if s.healthCheckServer == nil {
+		return &admin.SelfPreservationStatus{Services: []string{}}, nil
+	}
+	return s.healthCheckServer.SelfPreservationStatus(), nil
+}
+
 func (s *Server) GetLogOutputLevel(_ context.Context) ([]admin.ScopeLevel, error) {
 	scopes := commonlog.Scopes()
 	out := make([]admin.ScopeLevel, 0, len(scopes))
@@ -224,6 +231,23 @@ func (s *Server) SetLogOutputLevel(_ context.Context, scope string, level string
 	return commonlog.SetLogOutputLevel(scope, level)
 }
 
+func (s *Server) ReloadConfig(ctx context.Context) (*admin.ReloadResult, error) {
+	if configReloader == nil {
+		return nil, errors.New("config reloader not ready")
+	}
+	return configReloader(ctx)
+}
+
+// ReloadMaintainJobs 热更新运维任务配置
+func (s *Server) ReloadMaintainJobs(cfg *Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maintainJobs == nil {
+		return nil
+	}
+	return s.maintainJobs.ReloadMaintainJobs(cfg.Jobs)
+}
+
 func (s *Server) ListLeaderElections(_ context.Context) ([]*admin.LeaderElection, error) {
 	return s.storage.ListLeaderElections()
 }
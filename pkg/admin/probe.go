@@ -0,0 +1,107 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/admin"
+)
+
+const (
+	// storeProbeTimeout 探测存储层的最大等待时间
+	storeProbeTimeout = time.Second
+)
+
+// Liveness 进程能够处理请求即认为存活, 依赖组件的异常只影响就绪探测, 避免被编排系统反复重启
+func (s *Server) Liveness(ctx context.Context) *admin.ProbeResult {
+	return newProbeResult([]admin.ProbeCheck{{Name: "process", Healthy: true}})
+}
+
+// Readiness 就绪探测, 返回每个检查项的结果便于排查
+func (s *Server) Readiness(ctx context.Context) *admin.ProbeResult {
+	return newProbeResult([]admin.ProbeCheck{
+		s.probeStore(),
+		s.probeCache(),
+		s.probeHealthCheck(),
+		probeDraining(),
+	})
+}
+
+func (s *Server) probeStore() admin.ProbeCheck {
+	check := admin.ProbeCheck{Name: "store"}
+	if s.storage == nil {
+		check.Message = "store not initialized"
+		return check
+	}
+	if _, err := s.storage.GetUnixSecond(storeProbeTimeout); err != nil {
+		check.Message = err.Error()
+		return check
+	}
+	check.Healthy = true
+	return check
+}
+
+func (s *Server) probeCache() admin.ProbeCheck {
+	check := admin.ProbeCheck{Name: "cache"}
+	if s.cacheMgr == nil {
+		check.Message = "cache manager not initialized"
+		return check
+	}
+	if pending := s.cacheMgr.PendingWarmUp(); len(pending) > 0 {
+		check.Message = fmt.Sprintf("cache not warmed up: %s", strings.Join(pending, ","))
+		return check
+	}
+	check.Healthy = true
+	return check
+}
+
+func (s *Server) probeHealthCheck() admin.ProbeCheck {
+	check := admin.ProbeCheck{Name: "healthcheck"}
+	if s.healthCheckServer == nil {
+		check.Message = "health check server not initialized"
+		return check
+	}
+	if !s.healthCheckServer.ContinuumLoaded() {
+		check.Message = "health check dispatcher continuum not loaded"
+		return check
+	}
+	check.Healthy = true
+	return check
+}
+
+func probeDraining() admin.ProbeCheck {
+	check := admin.ProbeCheck{Name: "draining", Healthy: !draining.Load()}
+	if !check.Healthy {
+		check.Message = "server is draining"
+	}
+	return check
+}
+
+func newProbeResult(checks []admin.ProbeCheck) *admin.ProbeResult {
+	ret := &admin.ProbeResult{Healthy: true, Checks: checks}
+	for i := range checks {
+		if !checks[i].Healthy {
+			ret.Healthy = false
+		}
+	}
+	return ret
+}
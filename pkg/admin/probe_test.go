@@ -0,0 +1,74 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/admin"
+	"github.com/pole-io/pole-server/plugin/store/mock"
+)
+
+func findCheck(ret *admin.ProbeResult, name string) admin.ProbeCheck {
+	for _, check := range ret.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	return admin.ProbeCheck{}
+}
+
+func TestServer_Readiness(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	defer draining.Store(false)
+
+	mockStore := mock.NewMockStore(ctrl)
+	svr := &Server{storage: mockStore}
+
+	t.Run("liveness", func(t *testing.T) {
+		assert.True(t, svr.Liveness(context.Background()).Healthy)
+	})
+
+	t.Run("store_unreachable", func(t *testing.T) {
+		mockStore.EXPECT().GetUnixSecond(gomock.Any()).Return(int64(0), errors.New("mock store down"))
+		ret := svr.Readiness(context.Background())
+		assert.False(t, ret.Healthy)
+		check := findCheck(ret, "store")
+		assert.False(t, check.Healthy)
+		assert.Equal(t, "mock store down", check.Message)
+		// 未初始化的组件同样需要给出原因
+		assert.NotEmpty(t, findCheck(ret, "cache").Message)
+		assert.NotEmpty(t, findCheck(ret, "healthcheck").Message)
+		assert.True(t, findCheck(ret, "draining").Healthy)
+	})
+
+	t.Run("draining", func(t *testing.T) {
+		mockStore.EXPECT().GetUnixSecond(gomock.Any()).Return(int64(1), nil)
+		MarkDraining()
+		ret := svr.Readiness(context.Background())
+		assert.False(t, ret.Healthy)
+		assert.True(t, findCheck(ret, "store").Healthy)
+		assert.False(t, findCheck(ret, "draining").Healthy)
+	})
+}
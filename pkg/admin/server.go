@@ -22,6 +22,7 @@ import (
 
 	"github.com/pole-io/pole-server/apis/access_control/auth"
 	"github.com/pole-io/pole-server/apis/store"
+	"github.com/pole-io/pole-server/pkg/admin/job"
 	"github.com/pole-io/pole-server/pkg/cache"
 	"github.com/pole-io/pole-server/pkg/service"
 	"github.com/pole-io/pole-server/pkg/service/healthcheck"
@@ -37,6 +38,7 @@ type Server struct {
 	storage           store.Store
 	userSvr           auth.UserServer
 	policySvr         auth.StrategyServer
+	maintainJobs      *job.MaintainJobs
 }
 
 func GetChainOrder() []string {
@@ -20,6 +20,7 @@ package base
 import (
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	cachetypes "github.com/pole-io/pole-server/apis/cache"
@@ -30,8 +31,6 @@ import (
 // BaseCache 对于 Cache 中的一些 func 做统一实现，避免重复逻辑
 type BaseCache struct {
 	lock sync.RWMutex
-	//
-	timeDiff time.Duration
 	// firstUpdate Whether the cache is loaded for the first time
 	// this field can only make value on exec initialize/clean, and set it to false on exec update
 	firstUpdate           bool
@@ -75,8 +74,20 @@ func (bc *BaseCache) initialize() {
 
 var (
 	zeroTime = time.Unix(0, 0)
+	// timeDiff 增量拉取存储数据时向前回溯的时间范围, 由 cache.diffTime 配置并支持热更新, 需要原子读写
+	timeDiff atomic.Int64
 )
 
+// SetTimeDiff 设置增量拉取时向前回溯的时间范围
+func SetTimeDiff(diff time.Duration) {
+	timeDiff.Store(int64(diff))
+}
+
+// GetTimeDiff 获取增量拉取时向前回溯的时间范围
+func GetTimeDiff() time.Duration {
+	return time.Duration(timeDiff.Load())
+}
+
 func (bc *BaseCache) Store() store.Store {
 	return bc.s
 }
@@ -106,7 +117,7 @@ func (bc *BaseCache) LastMtime(label string) time.Time {
 
 func (bc *BaseCache) LastFetchTime() time.Time {
 	lastTime := time.Unix(bc.lastFetchTime, 0)
-	tmp := lastTime.Add(bc.timeDiff)
+	tmp := lastTime.Add(GetTimeDiff())
 	if zeroTime.After(tmp) {
 		return lastTime
 	}
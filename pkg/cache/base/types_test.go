@@ -0,0 +1,39 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package base
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseCache_LastFetchTime(t *testing.T) {
+	defer SetTimeDiff(GetTimeDiff())
+
+	bc := NewBaseCache(nil, nil)
+	bc.lastFetchTime = 100
+
+	SetTimeDiff(-5 * time.Second)
+	assert.Equal(t, time.Unix(95, 0), bc.LastFetchTime())
+	// 热更新后下一次拉取立即按新的时间范围回溯
+	SetTimeDiff(-10 * time.Second)
+	assert.Equal(t, time.Unix(90, 0), bc.LastFetchTime())
+	assert.Equal(t, time.Unix(100, 0), bc.OriginLastFetchTime())
+}
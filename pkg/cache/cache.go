@@ -20,11 +20,14 @@ package cache
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	cachetypes "github.com/pole-io/pole-server/apis/cache"
 	"github.com/pole-io/pole-server/apis/store"
+	cachebase "github.com/pole-io/pole-server/pkg/cache/base"
 	"github.com/pole-io/pole-server/pkg/common/syncs/container"
 )
 
@@ -37,27 +40,62 @@ const (
 	UpdateCacheInterval = 1 * time.Second
 )
 
-var (
+const (
 	// DefaultTimeDiff default time diff
 	DefaultTimeDiff = -5 * time.Second
-	ReportInterval  = 1 * time.Second
+	// DefaultReportInterval 默认的监控数据上报周期
+	DefaultReportInterval = 1 * time.Second
+)
+
+var (
+	// reportInterval 当前生效的监控数据上报周期, 支持热更新, 需要原子读写
+	reportInterval atomic.Int64
 )
 
+func init() {
+	cachebase.SetTimeDiff(DefaultTimeDiff)
+	reportInterval.Store(int64(DefaultReportInterval))
+}
+
+// GetTimeDiff 获取当前生效的拉取时间范围
+func GetTimeDiff() time.Duration {
+	return cachebase.GetTimeDiff()
+}
+
 // CacheManager 名字服务缓存
 type CacheManager struct {
 	storage  store.Store
 	caches   []cachetypes.Cache
 	needLoad *container.SyncSet[string]
+	// loaded 已经完成首次加载的缓存
+	loaded *container.SyncSet[string]
 }
 
 // Initialize 缓存对象初始化
 func (nc *CacheManager) Initialize() error {
-	if config.DiffTime != 0 {
-		DefaultTimeDiff = -1 * (config.DiffTime.Abs())
+	return applyConfig(config)
+}
+
+// Reload 热更新缓存配置, 仅刷新拉取时间范围以及监控上报周期. 其余缓存配置只在启动时读取, 变更需要重启生效,
+// 因此不替换启动时设置的全局配置
+func (nc *CacheManager) Reload(conf *Config) error {
+	return applyConfig(conf)
+}
+
+func applyConfig(conf *Config) error {
+	diff := DefaultTimeDiff
+	if conf.DiffTime != 0 {
+		diff = -1 * (conf.DiffTime.Abs())
 	}
-	if DefaultTimeDiff > 0 {
-		return fmt.Errorf("cache diff time to pull store must negative number: %+v", DefaultTimeDiff)
+	if diff > 0 {
+		return fmt.Errorf("cache diff time to pull store must negative number: %+v", diff)
 	}
+	interval := DefaultReportInterval
+	if conf.ReportInterval > 0 {
+		interval = conf.ReportInterval
+	}
+	cachebase.SetTimeDiff(diff)
+	reportInterval.Store(int64(interval))
 	return nil
 }
 
@@ -95,7 +133,7 @@ func (nc *CacheManager) warmUp() error {
 		wg.Add(1)
 		go func(c cachetypes.Cache) {
 			defer wg.Done()
-			_ = c.Update()
+			nc.update(name, c)
 		}(nc.caches[index])
 	}
 
@@ -103,6 +141,25 @@ func (nc *CacheManager) warmUp() error {
 	return nil
 }
 
+func (nc *CacheManager) update(name string, c cachetypes.Cache) {
+	if err := c.Update(); err != nil {
+		return
+	}
+	nc.loaded.Add(name)
+}
+
+// PendingWarmUp 返回尚未完成首次加载的缓存名称
+func (nc *CacheManager) PendingWarmUp() []string {
+	pending := make([]string, 0, 4)
+	for _, name := range nc.needLoad.ToSlice() {
+		if !nc.loaded.Contains(name) {
+			pending = append(pending, name)
+		}
+	}
+	sort.Strings(pending)
+	return pending
+}
+
 // clear 清除caches的所有缓存数据
 func (nc *CacheManager) clear() error {
 	for _, obj := range nc.caches {
@@ -128,6 +185,8 @@ func (nc *CacheManager) Close() error {
 func (nc *CacheManager) Start(ctx context.Context) error {
 	log.Infof("[Cache] cache goroutine start")
 
+	syncer := nc.newChangeLogSyncer()
+
 	// 启动的时候，先更新一版缓存
 	log.Infof("[Cache] cache update now first time")
 	if err := nc.warmUp(); err != nil {
@@ -143,24 +202,58 @@ func (nc *CacheManager) Start(ctx context.Context) error {
 		if !exist {
 			return fmt.Errorf("cache resource %s not exists", name)
 		}
+		interval := nc.GetUpdateCacheInterval()
+		if syncer != nil && syncer.handle(nc.caches[index]) {
+			// 由变更记录驱动更新的缓存, 只需要低频按照 mtime 拉取兜底
+			interval = syncer.conf.FallbackInterval
+		}
 		// 每个缓存各自在自己的协程内部按照期望的缓存更新时间完成数据缓存刷新
-		go func(c cachetypes.Cache) {
-			ticker := time.NewTicker(nc.GetUpdateCacheInterval())
+		go func(c cachetypes.Cache, interval time.Duration) {
+			ticker := time.NewTicker(interval)
 			for {
 				select {
 				case <-ticker.C:
-					_ = c.Update()
+					nc.update(name, c)
 				case <-ctx.Done():
 					ticker.Stop()
 					return
 				}
 			}
-		}(nc.caches[index])
+		}(nc.caches[index], interval)
+	}
+	if syncer != nil {
+		go syncer.run(ctx)
 	}
 
 	return nil
 }
 
+// newChangeLogSyncer 开启变更记录同步时, 为需要加载并且支持变更记录的缓存创建同步器
+func (nc *CacheManager) newChangeLogSyncer() *changeLogSyncer {
+	if config == nil || !config.ChangeLog.Enable {
+		return nil
+	}
+	caches := make([]cachetypes.ChangeLogCache, 0, 1)
+	for _, name := range nc.needLoad.ToSlice() {
+		index, exist := cacheSet[name]
+		if !exist {
+			continue
+		}
+		if c, ok := nc.caches[index].(cachetypes.ChangeLogCache); ok {
+			caches = append(caches, c)
+		}
+	}
+	if len(caches) == 0 {
+		return nil
+	}
+	syncer := newChangeLogSyncer(nc.storage, config.ChangeLog, caches)
+	if err := syncer.init(); err != nil {
+		log.Errorf("[Cache] init change log syncer err: %s, fallback to load by mtime", err.Error())
+		return nil
+	}
+	return syncer
+}
+
 // Clear 主动清除缓存数据
 func (nc *CacheManager) Clear() error {
 	return nc.clear()
@@ -173,7 +266,7 @@ func (nc *CacheManager) GetUpdateCacheInterval() time.Duration {
 
 // GetReportInterval 获取当前cache的更新间隔
 func (nc *CacheManager) GetReportInterval() time.Duration {
-	return ReportInterval
+	return time.Duration(reportInterval.Load())
 }
 
 // Service 获取Service缓存信息
@@ -256,6 +349,11 @@ func (nc *CacheManager) Role() cachetypes.RoleCache {
 	return nc.caches[cachetypes.CacheRole].(cachetypes.RoleCache)
 }
 
+// HealthPolicy 获取健康检查以及过期策略缓存
+func (nc *CacheManager) HealthPolicy() cachetypes.HealthPolicyCache {
+	return nc.caches[cachetypes.CacheHealthPolicy].(cachetypes.HealthPolicyCache)
+}
+
 // GetCacher get cachetypes.Cache impl
 func (nc *CacheManager) GetCacher(cacheIndex cachetypes.CacheIndex) cachetypes.Cache {
 	return nc.caches[cacheIndex]
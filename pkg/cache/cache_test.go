@@ -0,0 +1,52 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManager_Reload(t *testing.T) {
+	mgr := &CacheManager{}
+	defer func() {
+		_ = applyConfig(&Config{})
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = mgr.GetReportInterval()
+			_ = GetTimeDiff()
+		}
+	}()
+	assert.NoError(t, mgr.Reload(&Config{DiffTime: 10 * time.Second, ReportInterval: 3 * time.Second}))
+	wg.Wait()
+	assert.Equal(t, -10*time.Second, GetTimeDiff())
+	assert.Equal(t, 3*time.Second, mgr.GetReportInterval())
+
+	// 未配置时恢复默认值
+	assert.NoError(t, mgr.Reload(&Config{}))
+	assert.Equal(t, DefaultTimeDiff, GetTimeDiff())
+	assert.Equal(t, DefaultReportInterval, mgr.GetReportInterval())
+}
@@ -0,0 +1,181 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	cachetypes "github.com/pole-io/pole-server/apis/cache"
+	"github.com/pole-io/pole-server/apis/store"
+)
+
+// changeLogSyncer 按照存储层变更记录的序号顺序, 只拉取发生变更的资源并更新缓存
+// 变更序号由数据库自增生成, 并发事务可能乱序提交, 回滚的事务也会留下序号空洞, 因此这里维护一个连续水位,
+// 空洞在 GapTimeout 内没有被填补时, 回退为按照 mtime 拉取兜底, 然后跳过该空洞
+type changeLogSyncer struct {
+	storage store.Store
+	conf    ChangeLogConfig
+	// caches 变更记录资源类型 -> 缓存
+	caches map[string]cachetypes.ChangeLogCache
+	// watermark 已经连续应用完成的最大变更序号
+	watermark int64
+	// applied 大于 watermark 并且已经应用完成的变更序号
+	applied map[int64]struct{}
+	// gapSince 首次发现序号空洞的时间
+	gapSince time.Time
+	now      func() time.Time
+}
+
+func newChangeLogSyncer(storage store.Store, conf ChangeLogConfig,
+	caches []cachetypes.ChangeLogCache) *changeLogSyncer {
+	syncer := &changeLogSyncer{
+		storage: storage,
+		conf:    conf.withDefault(),
+		caches:  make(map[string]cachetypes.ChangeLogCache, len(caches)),
+		applied: map[int64]struct{}{},
+		now:     time.Now,
+	}
+	for i := range caches {
+		syncer.caches[caches[i].ChangeLogResource()] = caches[i]
+	}
+	return syncer
+}
+
+// init 记录当前的最大变更序号, 需要在缓存首次全量加载前执行, 加载期间发生的变更会在之后重新应用
+func (s *changeLogSyncer) init() error {
+	seq, err := s.storage.GetMaxChangeLogSeq()
+	if err != nil {
+		return err
+	}
+	s.watermark = seq
+	log.Infof("[Cache][ChangeLog] start sync change logs after seq(%d)", seq)
+	return nil
+}
+
+func (s *changeLogSyncer) run(ctx context.Context) {
+	ticker := time.NewTicker(s.conf.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = s.sync()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sync 拉取一批变更记录并应用到缓存中, 应用失败时不推进水位, 下一轮重新拉取
+func (s *changeLogSyncer) sync() error {
+	// 已经应用但是还在空洞之后的变更记录会被重复拉取, 这里扩大拉取的条数, 避免空洞期间新的变更记录无法被拉取
+	limit := s.conf.BatchSize + uint32(len(s.applied))
+	logs, err := s.storage.GetChangeLogs(s.watermark, limit)
+	if err != nil {
+		log.Errorf("[Cache][ChangeLog] get change logs after seq(%d) err: %s", s.watermark, err.Error())
+		return err
+	}
+
+	seqs := make([]int64, 0, len(logs))
+	keys := map[string][]string{}
+	exists := map[string]map[string]struct{}{}
+	for _, item := range logs {
+		if _, ok := s.applied[item.Seq]; ok {
+			continue
+		}
+		seqs = append(seqs, item.Seq)
+		if _, ok := s.caches[item.Resource]; !ok {
+			continue
+		}
+		if _, ok := exists[item.Resource]; !ok {
+			exists[item.Resource] = map[string]struct{}{}
+		}
+		if _, ok := exists[item.Resource][item.Key]; ok {
+			continue
+		}
+		exists[item.Resource][item.Key] = struct{}{}
+		keys[item.Resource] = append(keys[item.Resource], item.Key)
+	}
+	for resource, items := range keys {
+		if err := s.caches[resource].ApplyChangeLogs(items); err != nil {
+			log.Errorf("[Cache][ChangeLog] apply %s change logs err: %s", resource, err.Error())
+			return err
+		}
+	}
+	for _, seq := range seqs {
+		s.applied[seq] = struct{}{}
+	}
+	s.advance()
+	return nil
+}
+
+// advance 推进连续水位, 并检查序号空洞是否等待超时
+func (s *changeLogSyncer) advance() {
+	for {
+		next := s.watermark + 1
+		if _, ok := s.applied[next]; !ok {
+			break
+		}
+		delete(s.applied, next)
+		s.watermark = next
+	}
+	if len(s.applied) == 0 {
+		s.gapSince = time.Time{}
+		return
+	}
+	now := s.now()
+	if s.gapSince.IsZero() {
+		s.gapSince = now
+		return
+	}
+	if now.Sub(s.gapSince) < s.conf.GapTimeout {
+		return
+	}
+
+	// 空洞对应的事务可能已经回滚, 也可能是变更记录已经被清理, 无法区分时统一按照 mtime 拉取一次兜底
+	next := int64(-1)
+	for seq := range s.applied {
+		if next == -1 || seq < next {
+			next = seq
+		}
+	}
+	log.Warnf("[Cache][ChangeLog] change log seq gap (%d, %d) timeout, fallback to load by mtime",
+		s.watermark, next)
+	s.fallback()
+	s.watermark = next - 1
+	s.gapSince = time.Time{}
+	s.advance()
+}
+
+func (s *changeLogSyncer) fallback() {
+	for resource, c := range s.caches {
+		if err := c.Update(); err != nil {
+			log.Errorf("[Cache][ChangeLog] fallback update %s cache err: %s", resource, err.Error())
+		}
+	}
+}
+
+// handle 缓存是否已经由变更记录驱动更新
+func (s *changeLogSyncer) handle(c cachetypes.Cache) bool {
+	item, ok := c.(cachetypes.ChangeLogCache)
+	if !ok {
+		return false
+	}
+	_, ok = s.caches[item.ChangeLogResource()]
+	return ok
+}
@@ -0,0 +1,152 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	cachetypes "github.com/pole-io/pole-server/apis/cache"
+	"github.com/pole-io/pole-server/apis/store"
+	"github.com/pole-io/pole-server/plugin/store/mock"
+)
+
+type testChangeLogCache struct {
+	cachetypes.Cache
+	applied [][]string
+	updates int
+	err     error
+}
+
+func (c *testChangeLogCache) ChangeLogResource() string {
+	return store.ChangeLogInstance
+}
+
+func (c *testChangeLogCache) ApplyChangeLogs(keys []string) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.applied = append(c.applied, keys)
+	return nil
+}
+
+func (c *testChangeLogCache) Update() error {
+	c.updates++
+	return nil
+}
+
+func newTestChangeLog(seq int64, key string) *store.ChangeLog {
+	return &store.ChangeLog{Seq: seq, Resource: store.ChangeLogInstance, Key: key}
+}
+
+func newTestChangeLogSyncer(t *testing.T) (*mock.MockStore, *testChangeLogCache, *changeLogSyncer, *time.Time) {
+	ctl := gomock.NewController(t)
+	t.Cleanup(ctl.Finish)
+	storage := mock.NewMockStore(ctl)
+	c := &testChangeLogCache{}
+	syncer := newChangeLogSyncer(storage, ChangeLogConfig{BatchSize: 10, GapTimeout: 3 * time.Second},
+		[]cachetypes.ChangeLogCache{c})
+	now := time.Now()
+	syncer.now = func() time.Time {
+		return now
+	}
+	storage.EXPECT().GetMaxChangeLogSeq().Return(int64(10), nil)
+	assert.NoError(t, syncer.init())
+	return storage, c, syncer, &now
+}
+
+func TestChangeLogSyncer_Sync(t *testing.T) {
+	t.Run("按照序号连续应用", func(t *testing.T) {
+		storage, c, syncer, _ := newTestChangeLogSyncer(t)
+		storage.EXPECT().GetChangeLogs(int64(10), uint32(10)).Return([]*store.ChangeLog{
+			newTestChangeLog(11, "ins-1"),
+			newTestChangeLog(12, "ins-2"),
+			newTestChangeLog(13, "ins-1"),
+			{Seq: 14, Resource: "unknown", Key: "ins-3"},
+		}, nil)
+		assert.NoError(t, syncer.sync())
+		assert.Equal(t, [][]string{{"ins-1", "ins-2"}}, c.applied)
+		assert.Equal(t, int64(14), syncer.watermark)
+		assert.Empty(t, syncer.applied)
+	})
+
+	t.Run("应用失败不推进水位", func(t *testing.T) {
+		storage, c, syncer, _ := newTestChangeLogSyncer(t)
+		c.err = errors.New("mock error")
+		storage.EXPECT().GetChangeLogs(int64(10), uint32(10)).Return([]*store.ChangeLog{
+			newTestChangeLog(11, "ins-1"),
+		}, nil)
+		assert.Error(t, syncer.sync())
+		assert.Equal(t, int64(10), syncer.watermark)
+		assert.Empty(t, syncer.applied)
+	})
+
+	t.Run("空洞被填补", func(t *testing.T) {
+		storage, c, syncer, _ := newTestChangeLogSyncer(t)
+		gomock.InOrder(
+			storage.EXPECT().GetChangeLogs(int64(10), uint32(10)).Return([]*store.ChangeLog{
+				newTestChangeLog(11, "ins-1"),
+				newTestChangeLog(13, "ins-3"),
+			}, nil),
+			// 空洞之后已经应用的变更记录会被重复拉取
+			storage.EXPECT().GetChangeLogs(int64(11), uint32(11)).Return([]*store.ChangeLog{
+				newTestChangeLog(12, "ins-2"),
+				newTestChangeLog(13, "ins-3"),
+			}, nil),
+		)
+		assert.NoError(t, syncer.sync())
+		assert.Equal(t, int64(11), syncer.watermark)
+		assert.False(t, syncer.gapSince.IsZero())
+
+		assert.NoError(t, syncer.sync())
+		assert.Equal(t, [][]string{{"ins-1", "ins-3"}, {"ins-2"}}, c.applied)
+		assert.Equal(t, int64(13), syncer.watermark)
+		assert.True(t, syncer.gapSince.IsZero())
+		assert.Equal(t, 0, c.updates)
+	})
+
+	t.Run("空洞超时回退为拉取兜底", func(t *testing.T) {
+		storage, c, syncer, now := newTestChangeLogSyncer(t)
+		gomock.InOrder(
+			storage.EXPECT().GetChangeLogs(int64(10), uint32(10)).Return([]*store.ChangeLog{
+				newTestChangeLog(12, "ins-2"),
+				newTestChangeLog(13, "ins-3"),
+				newTestChangeLog(15, "ins-5"),
+			}, nil),
+			storage.EXPECT().GetChangeLogs(int64(10), uint32(13)).Return([]*store.ChangeLog{
+				newTestChangeLog(12, "ins-2"),
+				newTestChangeLog(13, "ins-3"),
+				newTestChangeLog(15, "ins-5"),
+			}, nil),
+		)
+		assert.NoError(t, syncer.sync())
+		assert.Equal(t, int64(10), syncer.watermark)
+
+		*now = now.Add(5 * time.Second)
+		assert.NoError(t, syncer.sync())
+		assert.Equal(t, 1, c.updates)
+		// 跳过第一个空洞, 剩余的空洞重新开始计时
+		assert.Equal(t, int64(13), syncer.watermark)
+		assert.Equal(t, *now, syncer.gapSince)
+		assert.Len(t, syncer.applied, 1)
+	})
+}
@@ -25,6 +25,46 @@ type Config struct {
 	DiffTime time.Duration `yaml:"diffTime"`
 	// ReportInterval 监控数据上报周期
 	ReportInterval time.Duration `yaml:"reportInterval"`
+	// ChangeLog 基于存储层变更记录的增量同步, 目前只支持实例缓存, 变更后需要重启进程
+	ChangeLog ChangeLogConfig `yaml:"changeLog"`
+}
+
+// ChangeLogConfig 变更记录同步配置
+type ChangeLogConfig struct {
+	// Enable 是否开启, 同时决定存储层写实例时是否追加变更记录, 集群内所有节点需要保持一致;
+	// 开启后实例缓存不再每秒按照 mtime 拉取, 其余缓存不受影响
+	Enable bool `yaml:"enable"`
+	// Interval 拉取变更记录的周期
+	Interval time.Duration `yaml:"interval"`
+	// BatchSize 单次拉取的变更记录数
+	BatchSize uint32 `yaml:"batchSize"`
+	// GapTimeout 变更序号出现空洞后的最长等待时间, 超时后回退为按照 mtime 拉取
+	GapTimeout time.Duration `yaml:"gapTimeout"`
+	// FallbackInterval 开启后按照 mtime 兜底拉取的周期
+	FallbackInterval time.Duration `yaml:"fallbackInterval"`
+}
+
+const (
+	defaultChangeLogInterval         = 200 * time.Millisecond
+	defaultChangeLogBatchSize        = 1000
+	defaultChangeLogGapTimeout       = 3 * time.Second
+	defaultChangeLogFallbackInterval = 60 * time.Second
+)
+
+func (c ChangeLogConfig) withDefault() ChangeLogConfig {
+	if c.Interval <= 0 {
+		c.Interval = defaultChangeLogInterval
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = defaultChangeLogBatchSize
+	}
+	if c.GapTimeout <= 0 {
+		c.GapTimeout = defaultChangeLogGapTimeout
+	}
+	if c.FallbackInterval <= 0 {
+		c.FallbackInterval = defaultChangeLogFallbackInterval
+	}
+	return c
 }
 
 var (
@@ -158,6 +158,7 @@ func (fc *fileCache) setReleases(releases []*conftypes.ConfigFileRelease) (map[s
 	del := 0
 
 	affect := map[string]map[string]struct{}{}
+	removed := make([]*conftypes.SimpleConfigFileRelease, 0)
 
 	for i := range releases {
 		item := releases[i]
@@ -171,6 +172,14 @@ func (fc *fileCache) setReleases(releases []*conftypes.ConfigFileRelease) (map[s
 			lastMtime = modifyUnix
 		}
 		oldVal, _ := fc.releases.Get(item.Id)
+		if oldVal != nil && oldVal.Active && oldVal.ReleaseType != conftypes.ReleaseTypeGray &&
+			(!item.Valid || !item.Active) {
+			release := *oldVal
+			release.Active = false
+			release.Valid = item.Valid
+			release.ModifyTime = item.ModifyTime
+			removed = append(removed, &release)
+		}
 		if !item.Valid {
 			del++
 			if err := fc.handleDeleteRelease(oldVal); err != nil {
@@ -188,6 +197,13 @@ func (fc *fileCache) setReleases(releases []*conftypes.ConfigFileRelease) (map[s
 		}
 	}
 	fc.postProcessUpdatedRelease(affect)
+	// 本批次的数据全部更新到缓存后再通知, 订阅方查询缓存时可以看到同一批次中新生效的版本
+	for i := range removed {
+		_ = eventhub.Publish(eventhub.CacheConfigReleaseEventTopic, &eventhub.CacheConfigReleaseEvent{
+			Release:   removed[i],
+			EventType: eventhub.EventDeleted,
+		})
+	}
 	return map[string]time.Time{fc.Name(): time.Unix(lastMtime, 0)}, update, del, nil
 }
 
@@ -51,6 +51,7 @@ func init() {
 	RegisterCache(cacheapi.GrayName, cacheapi.CacheGray)
 	RegisterCache(cacheapi.LaneRuleName, cacheapi.CacheLaneRule)
 	RegisterCache(cacheapi.RolesName, cacheapi.CacheRole)
+	RegisterCache(cacheapi.HealthPolicyName, cacheapi.CacheHealthPolicy)
 }
 
 var (
@@ -87,6 +88,7 @@ func newCacheManager(ctx context.Context, cacheOpt *Config, storage store.Store)
 		storage:  storage,
 		caches:   make([]cacheapi.Cache, cacheapi.CacheLast),
 		needLoad: container.NewSyncSet[string](),
+		loaded:   container.NewSyncSet[string](),
 	}
 
 	// 命名空间缓存
@@ -94,6 +96,7 @@ func newCacheManager(ctx context.Context, cacheOpt *Config, storage store.Store)
 	// 注册发现缓存
 	mgr.RegisterCacher(cacheapi.CacheService, cachesvc.NewServiceCache(storage, mgr))
 	mgr.RegisterCacher(cacheapi.CacheInstance, cachesvc.NewInstanceCache(storage, mgr))
+	mgr.RegisterCacher(cacheapi.CacheHealthPolicy, cachesvc.NewHealthPolicyCache(storage, mgr))
 	// 治理规则缓存
 	mgr.RegisterCacher(cacheapi.CacheServiceContract, cachesvc.NewServiceContractCache(storage, mgr))
 	mgr.RegisterCacher(cacheapi.CacheRoutingConfig, cacherules.NewRouteRuleCache(storage, mgr))
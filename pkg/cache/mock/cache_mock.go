@@ -279,6 +279,20 @@ func (mr *MockCacheManagerMockRecorder) Gray() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Gray", reflect.TypeOf((*MockCacheManager)(nil).Gray))
 }
 
+// HealthPolicy mocks base method.
+func (m *MockCacheManager) HealthPolicy() cache.HealthPolicyCache {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HealthPolicy")
+	ret0, _ := ret[0].(cache.HealthPolicyCache)
+	return ret0
+}
+
+// HealthPolicy indicates an expected call of HealthPolicy.
+func (mr *MockCacheManagerMockRecorder) HealthPolicy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthPolicy", reflect.TypeOf((*MockCacheManager)(nil).HealthPolicy))
+}
+
 // Instance mocks base method.
 func (m *MockCacheManager) Instance() cache.InstanceCache {
 	m.ctrl.T.Helper()
@@ -1075,6 +1089,127 @@ func (mr *MockServiceContractCacheMockRecorder) Update() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockServiceContractCache)(nil).Update))
 }
 
+// MockHealthPolicyCache is a mock of HealthPolicyCache interface.
+type MockHealthPolicyCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockHealthPolicyCacheMockRecorder
+}
+
+// MockHealthPolicyCacheMockRecorder is the mock recorder for MockHealthPolicyCache.
+type MockHealthPolicyCacheMockRecorder struct {
+	mock *MockHealthPolicyCache
+}
+
+// NewMockHealthPolicyCache creates a new mock instance.
+func NewMockHealthPolicyCache(ctrl *gomock.Controller) *MockHealthPolicyCache {
+	mock := &MockHealthPolicyCache{ctrl: ctrl}
+	mock.recorder = &MockHealthPolicyCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHealthPolicyCache) EXPECT() *MockHealthPolicyCacheMockRecorder {
+	return m.recorder
+}
+
+// Clear mocks base method.
+func (m *MockHealthPolicyCache) Clear() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clear")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Clear indicates an expected call of Clear.
+func (mr *MockHealthPolicyCacheMockRecorder) Clear() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clear", reflect.TypeOf((*MockHealthPolicyCache)(nil).Clear))
+}
+
+// Close mocks base method.
+func (m *MockHealthPolicyCache) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockHealthPolicyCacheMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockHealthPolicyCache)(nil).Close))
+}
+
+// GetPolicy mocks base method.
+func (m *MockHealthPolicyCache) GetPolicy(namespace, serviceName string) *service.HealthPolicy {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPolicy", namespace, serviceName)
+	ret0, _ := ret[0].(*service.HealthPolicy)
+	return ret0
+}
+
+// GetPolicy indicates an expected call of GetPolicy.
+func (mr *MockHealthPolicyCacheMockRecorder) GetPolicy(namespace, serviceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPolicy", reflect.TypeOf((*MockHealthPolicyCache)(nil).GetPolicy), namespace, serviceName)
+}
+
+// Initialize mocks base method.
+func (m *MockHealthPolicyCache) Initialize(c map[string]interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Initialize", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Initialize indicates an expected call of Initialize.
+func (mr *MockHealthPolicyCacheMockRecorder) Initialize(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Initialize", reflect.TypeOf((*MockHealthPolicyCache)(nil).Initialize), c)
+}
+
+// ListPolicies mocks base method.
+func (m *MockHealthPolicyCache) ListPolicies() []*service.HealthPolicy {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPolicies")
+	ret0, _ := ret[0].([]*service.HealthPolicy)
+	return ret0
+}
+
+// ListPolicies indicates an expected call of ListPolicies.
+func (mr *MockHealthPolicyCacheMockRecorder) ListPolicies() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPolicies", reflect.TypeOf((*MockHealthPolicyCache)(nil).ListPolicies))
+}
+
+// Name mocks base method.
+func (m *MockHealthPolicyCache) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockHealthPolicyCacheMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockHealthPolicyCache)(nil).Name))
+}
+
+// Update mocks base method.
+func (m *MockHealthPolicyCache) Update() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockHealthPolicyCacheMockRecorder) Update() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockHealthPolicyCache)(nil).Update))
+}
+
 // MockInstanceCache is a mock of InstanceCache interface.
 type MockInstanceCache struct {
 	ctrl     *gomock.Controller
@@ -35,6 +35,7 @@ import (
 	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
 	"github.com/pole-io/pole-server/apis/store"
 	cachebase "github.com/pole-io/pole-server/pkg/cache/base"
+	"github.com/pole-io/pole-server/pkg/common/eventhub"
 	"github.com/pole-io/pole-server/pkg/common/syncs/container"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 )
@@ -338,6 +339,12 @@ func (c *circuitBreakerCache) setCircuitBreaker(
 	var upsert, del int
 
 	lastMtime := c.LastMtime(c.Name()).Unix()
+	events := make([]*eventhub.CacheRuleEvent, 0, len(cbRules))
+	defer func() {
+		for i := range events {
+			_ = eventhub.Publish(eventhub.CacheRuleEventTopic, events[i])
+		}
+	}()
 
 	for _, cbRule := range cbRules {
 		if cbRule.ModifyTime.Unix() > lastMtime {
@@ -361,11 +368,13 @@ func (c *circuitBreakerCache) setCircuitBreaker(
 			del++
 			c.rules.Delete(cbRule.ID)
 			c.deleteCircuitBreakerFromServiceCache(cbRule.ID, svcKeys)
+			events = append(events, &eventhub.CacheRuleEvent{CircuitBreakerRule: cbRule, EventType: eventhub.EventDeleted})
 			continue
 		}
 		upsert++
 		c.rules.Store(cbRule.ID, cbRule)
 		c.storeCircuitBreakerToServiceCache(cbRule, svcKeys)
+		events = append(events, &eventhub.CacheRuleEvent{CircuitBreakerRule: cbRule, EventType: eventhub.EventUpdated})
 	}
 
 	return map[string]time.Time{
@@ -34,6 +34,7 @@ import (
 	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
 	"github.com/pole-io/pole-server/apis/store"
 	cachebase "github.com/pole-io/pole-server/pkg/cache/base"
+	"github.com/pole-io/pole-server/pkg/common/eventhub"
 	"github.com/pole-io/pole-server/pkg/common/syncs/container"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 )
@@ -309,6 +310,12 @@ func (f *faultDetectCache) setFaultDetectRules(fdRules []*rules.FaultDetectRule)
 	}
 
 	lastMtime := f.LastMtime(f.Name()).Unix()
+	events := make([]*eventhub.CacheRuleEvent, 0, len(fdRules))
+	defer func() {
+		for i := range events {
+			_ = eventhub.Publish(eventhub.CacheRuleEventTopic, events[i])
+		}
+	}()
 
 	for _, fdRule := range fdRules {
 		oldRule, ok := f.rules.Load(fdRule.ID)
@@ -331,10 +338,12 @@ func (f *faultDetectCache) setFaultDetectRules(fdRules []*rules.FaultDetectRule)
 		if !fdRule.Valid {
 			f.rules.Delete(fdRule.ID)
 			f.deleteFaultDetectRuleFromServiceCache(fdRule.ID, svcKeys)
+			events = append(events, &eventhub.CacheRuleEvent{FaultDetectRule: fdRule, EventType: eventhub.EventDeleted})
 			continue
 		}
 		f.rules.Store(fdRule.ID, fdRule)
 		f.storeFaultDetectRuleToServiceCache(fdRule, svcKeys)
+		events = append(events, &eventhub.CacheRuleEvent{FaultDetectRule: fdRule, EventType: eventhub.EventUpdated})
 	}
 
 	return map[string]time.Time{
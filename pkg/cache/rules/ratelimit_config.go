@@ -33,6 +33,7 @@ import (
 	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
 	"github.com/pole-io/pole-server/apis/store"
 	cachebase "github.com/pole-io/pole-server/pkg/cache/base"
+	"github.com/pole-io/pole-server/pkg/common/eventhub"
 )
 
 // rateLimitCache的实现
@@ -121,6 +122,12 @@ func (rlc *rateLimitCache) setRateLimit(rateLimits []*rules.RateLimit) map[strin
 	rlc.fixRulesServiceInfo()
 	updateService := map[svctypes.ServiceKey]struct{}{}
 	lastMtime := rlc.LastMtime(rlc.Name()).Unix()
+	events := make([]*eventhub.CacheRuleEvent, 0, len(rateLimits))
+	defer func() {
+		for i := range events {
+			_ = eventhub.Publish(eventhub.CacheRuleEventTopic, events[i])
+		}
+	}()
 	for _, item := range rateLimits {
 		if err := rlc.rateLimitToProto(item); nil != err {
 			log.Errorf("[Cache]fail to unmarshal rule to proto, err: %v", err)
@@ -140,9 +147,11 @@ func (rlc *rateLimitCache) setRateLimit(rateLimits []*rules.RateLimit) map[strin
 		if !item.Valid {
 			rlc.rules.delRule(item)
 			rlc.deleteWaitFixRule(item)
+			events = append(events, &eventhub.CacheRuleEvent{RateLimitRule: item, EventType: eventhub.EventDeleted})
 			continue
 		}
 		rlc.rules.saveRule(item)
+		events = append(events, &eventhub.CacheRuleEvent{RateLimitRule: item, EventType: eventhub.EventUpdated})
 	}
 
 	for serviceKey := range updateService {
@@ -31,6 +31,7 @@ import (
 	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
 	"github.com/pole-io/pole-server/apis/store"
 	cachebase "github.com/pole-io/pole-server/pkg/cache/base"
+	"github.com/pole-io/pole-server/pkg/common/eventhub"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 )
 
@@ -227,6 +228,12 @@ func (rc *RouteRuleCache) setRouterRules(lastMtimes map[string]time.Time, cs []*
 	}
 
 	lastMtimeV2 := rc.LastMtime(rc.Name() + "v2").Unix()
+	events := make([]*eventhub.CacheRuleEvent, 0, len(cs))
+	defer func() {
+		for i := range events {
+			_ = eventhub.Publish(eventhub.CacheRuleEventTopic, events[i])
+		}
+	}()
 	for _, entry := range cs {
 		if entry.ID == "" {
 			continue
@@ -236,6 +243,7 @@ func (rc *RouteRuleCache) setRouterRules(lastMtimes map[string]time.Time, cs []*
 		}
 		if !entry.Valid {
 			rc.container.deleteV2(entry.ID)
+			events = append(events, &eventhub.CacheRuleEvent{RouterRule: entry, EventType: eventhub.EventDeleted})
 			continue
 		}
 		extendEntry, err := entry.ToExpendRoutingConfig()
@@ -244,6 +252,7 @@ func (rc *RouteRuleCache) setRouterRules(lastMtimes map[string]time.Time, cs []*
 			continue
 		}
 		rc.container.saveV2(extendEntry)
+		events = append(events, &eventhub.CacheRuleEvent{RouterRule: entry, EventType: eventhub.EventUpdated})
 	}
 	lastMtimes[rc.Name()+"v2"] = time.Unix(lastMtimeV2, 0)
 }
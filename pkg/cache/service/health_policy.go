@@ -0,0 +1,141 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	cachetypes "github.com/pole-io/pole-server/apis/cache"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/apis/store"
+	cachebase "github.com/pole-io/pole-server/pkg/cache/base"
+	"github.com/pole-io/pole-server/pkg/common/syncs/container"
+)
+
+var _ cachetypes.HealthPolicyCache = (*HealthPolicyCache)(nil)
+
+func NewHealthPolicyCache(storage store.Store, cacheMgr cachetypes.CacheManager) cachetypes.HealthPolicyCache {
+	return &HealthPolicyCache{
+		BaseCache: cachebase.NewBaseCache(storage, cacheMgr),
+		// 未开启缓存时也可以安全的查询, 此时不存在任何策略
+		policies: container.NewSyncMap[string, *svctypes.HealthPolicy](),
+	}
+}
+
+// HealthPolicyCache 健康检查以及过期策略缓存
+type HealthPolicyCache struct {
+	*cachebase.BaseCache
+	// policies namespace/service -> *svctypes.HealthPolicy
+	policies    *container.SyncMap[string, *svctypes.HealthPolicy]
+	singleGroup singleflight.Group
+}
+
+// Initialize
+func (hc *HealthPolicyCache) Initialize(c map[string]interface{}) error {
+	hc.policies = container.NewSyncMap[string, *svctypes.HealthPolicy]()
+	return nil
+}
+
+// Update
+func (hc *HealthPolicyCache) Update() error {
+	// 多个线程竞争，只有一个线程进行更新
+	_, err, _ := hc.singleGroup.Do(hc.Name(), func() (interface{}, error) {
+		return nil, hc.DoCacheUpdate(hc.Name(), hc.realUpdate)
+	})
+	return err
+}
+
+func (hc *HealthPolicyCache) realUpdate() (map[string]time.Time, int64, error) {
+	policies, err := hc.Store().GetMoreHealthPolicies(hc.IsFirstUpdate(), hc.LastFetchTime())
+	if err != nil {
+		log.Error("[Cache][HealthPolicy] update health policy", zap.Error(err))
+		return nil, -1, err
+	}
+	if len(policies) == 0 {
+		return nil, 0, nil
+	}
+	lastMtimes, upsert, del := hc.setPolicies(policies)
+	log.Info("[Cache][HealthPolicy] get more health policy", zap.Int("total", len(policies)),
+		zap.Int("upsert", upsert), zap.Int("delete", del))
+	return lastMtimes, int64(len(policies)), nil
+}
+
+func (hc *HealthPolicyCache) setPolicies(policies []*svctypes.HealthPolicy) (map[string]time.Time, int, int) {
+	var (
+		upsert, del int
+		lastMtime   = hc.LastMtime(hc.Name())
+	)
+	for i := range policies {
+		item := policies[i]
+		if item.ModifyTime.After(lastMtime) {
+			lastMtime = item.ModifyTime
+		}
+		if !item.Valid {
+			del++
+			hc.policies.Delete(item.Key())
+			continue
+		}
+		upsert++
+		hc.policies.Store(item.Key(), item)
+	}
+	return map[string]time.Time{
+		hc.Name(): lastMtime,
+	}, upsert, del
+}
+
+// Clear
+func (hc *HealthPolicyCache) Clear() error {
+	hc.BaseCache.Clear()
+	hc.policies = container.NewSyncMap[string, *svctypes.HealthPolicy]()
+	return nil
+}
+
+// Name
+func (hc *HealthPolicyCache) Name() string {
+	return cachetypes.HealthPolicyName
+}
+
+// GetPolicy 获取服务生效的策略
+func (hc *HealthPolicyCache) GetPolicy(namespace, service string) *svctypes.HealthPolicy {
+	nsPolicy, nsOk := hc.policies.Load(svctypes.HealthPolicyKey(namespace, ""))
+	var svcPolicy *svctypes.HealthPolicy
+	svcOk := false
+	if service != "" {
+		svcPolicy, svcOk = hc.policies.Load(svctypes.HealthPolicyKey(namespace, service))
+	}
+	if !nsOk && !svcOk {
+		return nil
+	}
+	return nsPolicy.Merge(svcPolicy)
+}
+
+// ListPolicies 获取全部的策略
+func (hc *HealthPolicyCache) ListPolicies() []*svctypes.HealthPolicy {
+	ret := make([]*svctypes.HealthPolicy, 0, hc.policies.Len())
+	hc.policies.Range(func(_ string, val *svctypes.HealthPolicy) {
+		ret = append(ret, val)
+	})
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Key() < ret[j].Key()
+	})
+	return ret
+}
@@ -0,0 +1,94 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+func TestHealthPolicyCache_GetPolicy(t *testing.T) {
+	hc := NewHealthPolicyCache(nil, nil).(*HealthPolicyCache)
+	assert.NoError(t, hc.Initialize(nil))
+
+	now := time.Now()
+	hc.setPolicies([]*svctypes.HealthPolicy{
+		{
+			Namespace:             "production",
+			MinTtl:                5,
+			MaxTtl:                30,
+			AutoDelete:            utils.BoolPtr(false),
+			EmptyServiceRetention: 86400,
+			Valid:                 true,
+			ModifyTime:            now,
+		},
+		{
+			Namespace:            "production",
+			Service:              "order",
+			MaxTtl:               10,
+			UnhealthyDeleteDelay: 3600,
+			AutoDelete:           utils.BoolPtr(true),
+			Valid:                true,
+			ModifyTime:           now,
+		},
+	})
+
+	// 服务维度的策略覆盖命名空间维度中设置了的字段
+	policy := hc.GetPolicy("production", "order")
+	assert.NotNil(t, policy)
+	assert.Equal(t, uint32(5), policy.MinTtl)
+	assert.Equal(t, uint32(10), policy.MaxTtl)
+	assert.True(t, policy.IsAutoDelete())
+	assert.Equal(t, time.Hour, policy.GetUnhealthyDeleteDelay(time.Minute))
+	assert.Equal(t, 24*time.Hour, policy.GetEmptyServiceRetention(time.Minute))
+	assert.Equal(t, uint32(10), policy.ClampTtl(60))
+	assert.Equal(t, uint32(5), policy.ClampTtl(1))
+
+	// 没有服务维度策略的服务继承命名空间的策略
+	policy = hc.GetPolicy("production", "user")
+	assert.NotNil(t, policy)
+	assert.False(t, policy.IsAutoDelete())
+	assert.Equal(t, time.Minute, policy.GetUnhealthyDeleteDelay(time.Minute))
+
+	// 不存在策略
+	assert.Nil(t, hc.GetPolicy("dev", "order"))
+	assert.True(t, hc.GetPolicy("dev", "order").IsAutoDelete())
+	assert.Equal(t, uint32(60), hc.GetPolicy("dev", "order").ClampTtl(60))
+
+	assert.Equal(t, 2, len(hc.ListPolicies()))
+	assert.Equal(t, "", hc.ListPolicies()[0].Service)
+
+	// 删除命名空间维度的策略
+	hc.setPolicies([]*svctypes.HealthPolicy{
+		{
+			Namespace:  "production",
+			Valid:      false,
+			ModifyTime: now.Add(time.Second),
+		},
+	})
+	policy = hc.GetPolicy("production", "order")
+	assert.Equal(t, uint32(0), policy.MinTtl)
+	assert.Equal(t, uint32(10), policy.MaxTtl)
+	assert.Nil(t, hc.GetPolicy("production", "user"))
+	assert.Equal(t, 1, len(hc.ListPolicies()))
+}
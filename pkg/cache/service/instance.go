@@ -62,6 +62,8 @@ type instanceCache struct {
 	systemServiceID  []string
 	singleFlight     *singleflight.Group
 	lastCheckAllTime int64
+	// updateLock 按照 mtime 拉取与按照变更记录同步互斥修改缓存数据
+	updateLock sync.Mutex
 }
 
 // NewInstanceCache 新建一个instanceCache
@@ -149,6 +151,8 @@ func (ic *instanceCache) checkAll(tx store.Tx) {
 func (ic *instanceCache) realUpdate() (map[string]time.Time, int64, error) {
 	// 拉取diff前的所有数据
 	start := time.Now()
+	ic.updateLock.Lock()
+	defer ic.updateLock.Unlock()
 
 	tx, err := ic.storage.StartReadTx()
 	if err != nil {
@@ -215,6 +219,88 @@ func (ic *instanceCache) handleUpdate(start time.Time, tx store.Tx) ([]*eventhub
 	return events, lastMtimes, int64(len(instances)), err
 }
 
+// ChangeLogResource 实例变更记录的资源类型
+func (ic *instanceCache) ChangeLogResource() string {
+	return store.ChangeLogInstance
+}
+
+// ApplyChangeLogs 拉取发生变更的实例的最新数据并更新缓存
+func (ic *instanceCache) ApplyChangeLogs(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	start := time.Now()
+	ic.updateLock.Lock()
+	defer ic.updateLock.Unlock()
+
+	tx, err := ic.storage.StartReadTx()
+	if err != nil {
+		if tx != nil {
+			_ = tx.Rollback()
+		}
+		log.Error("[Cache][Instance] begin transaction storage read tx", zap.Error(err))
+		return err
+	}
+
+	var instanceChangeEvents []*eventhub.CacheInstanceEvent
+	defer func() {
+		for i := range instanceChangeEvents {
+			_ = eventhub.Publish(eventhub.CacheInstanceEventTopic, instanceChangeEvents[i])
+		}
+	}()
+
+	instances, err := ic.storage.GetInstancesByIDs(tx, keys)
+	_ = tx.Rollback()
+	if err != nil {
+		log.Error("[Cache][Instance] apply change logs get storage instances", zap.Error(err))
+		return err
+	}
+
+	ids := ic.ids.Load()
+	svcInsContainer := ic.services.Load()
+	for _, id := range keys {
+		if _, ok := instances[id]; ok {
+			continue
+		}
+		// 存储层已经物理删除的实例, 按照逻辑删除的方式移出缓存
+		if oldInstance, ok := ids.Load(id); ok {
+			deleted := *oldInstance
+			deleted.Valid = false
+			instances[id] = &deleted
+		}
+	}
+	if ic.disableBusiness {
+		for id, item := range instances {
+			if !ic.isSystemService(item.ServiceID) {
+				delete(instances, id)
+			}
+		}
+	}
+	// 实例所属的服务刚刚创建时, 服务缓存可能还未拉取到, 需要先刷新服务缓存以便填充实例的服务信息
+	for _, item := range instances {
+		if ic.svcCache.GetServiceByID(item.ServiceID) == nil {
+			_ = ic.svcCache.Update()
+			break
+		}
+	}
+
+	events, _, update, del := ic.setInstances(ids, svcInsContainer, instances)
+	instanceChangeEvents = events
+	log.Info("[Cache][Instance] apply change logs", zap.Int("keys", len(keys)),
+		zap.Int("pull-from-store", len(instances)), zap.Int("update", update), zap.Int("delete", del),
+		zap.Duration("used", time.Since(start)))
+	return nil
+}
+
+func (ic *instanceCache) isSystemService(serviceID string) bool {
+	for _, id := range ic.systemServiceID {
+		if id == serviceID {
+			return true
+		}
+	}
+	return false
+}
+
 // Clear 清理内部缓存数据
 func (ic *instanceCache) Clear() error {
 	ic.BaseCache.Clear()
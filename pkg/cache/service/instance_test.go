@@ -18,6 +18,7 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"sync/atomic"
 	"testing"
@@ -180,6 +181,63 @@ func TestInstanceCache_Update(t *testing.T) {
 	})
 }
 
+// TestInstanceCache_ApplyChangeLogs 按照变更记录更新缓存
+func TestInstanceCache_ApplyChangeLogs(t *testing.T) {
+	ctl, storage, ic := newTestInstanceCache(t)
+	defer ctl.Finish()
+	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil).AnyTimes()
+	storage.EXPECT().GetServicesCount().Return(uint32(0), nil).AnyTimes()
+
+	_ = ic.Clear()
+	instances := genModelInstances("service1", 3)
+	storage.EXPECT().GetMoreInstances(gomock.Any(), gomock.Any(), gomock.Any(), ic.needMeta, ic.systemServiceID).
+		Return(instances, nil)
+	storage.EXPECT().GetInstancesCountTx(gomock.Any()).Return(uint32(3), nil).AnyTimes()
+	assert.NoError(t, ic.Update())
+
+	t.Run("新增以及逻辑删除的实例", func(t *testing.T) {
+		added := genModelInstances("service2", 1)
+		deleted := &svctypes.Instance{
+			Proto:     instances["instanceID-service1-0"].Proto,
+			ServiceID: "serviceID-service1",
+			Valid:     false,
+		}
+		changed := map[string]*svctypes.Instance{"instanceID-service1-0": deleted}
+		for id, item := range added {
+			changed[id] = item
+		}
+		keys := []string{"instanceID-service1-0", "instanceID-service2-0"}
+		storage.EXPECT().GetInstancesByIDs(gomock.Any(), keys).Return(changed, nil)
+		assert.NoError(t, ic.ApplyChangeLogs(keys))
+
+		assert.Nil(t, ic.GetInstance("instanceID-service1-0"))
+		assert.NotNil(t, ic.GetInstance("instanceID-service2-0"))
+		servicesCount, instancesCount := iteratorInstances(ic)
+		assert.Equal(t, 2, servicesCount)
+		assert.Equal(t, 3, instancesCount)
+	})
+
+	t.Run("已经被物理删除的实例", func(t *testing.T) {
+		keys := []string{"instanceID-service1-1"}
+		storage.EXPECT().GetInstancesByIDs(gomock.Any(), keys).
+			Return(map[string]*svctypes.Instance{}, nil)
+		assert.NoError(t, ic.ApplyChangeLogs(keys))
+		assert.Nil(t, ic.GetInstance("instanceID-service1-1"))
+		// 缓存中原有的实例对象不受影响
+		assert.True(t, instances["instanceID-service1-1"].Valid)
+		_, instancesCount := iteratorInstances(ic)
+		assert.Equal(t, 2, instancesCount)
+	})
+
+	t.Run("拉取失败", func(t *testing.T) {
+		keys := []string{"instanceID-service1-2"}
+		storage.EXPECT().GetInstancesByIDs(gomock.Any(), keys).Return(nil, errors.New("mock error"))
+		assert.Error(t, ic.ApplyChangeLogs(keys))
+		assert.NotNil(t, ic.GetInstance("instanceID-service1-2"))
+	})
+}
+
 // TestInstanceCache_Update2 异常场景下的update测试
 func TestInstanceCache_Update2(t *testing.T) {
 	ctl, storage, ic := newTestInstanceCache(t)
@@ -444,6 +444,7 @@ func (sc *serviceCache) setServices(services map[string]*svctypes.Service) (map[
 	svcCount := sc.ids.Len()
 
 	aliases := make([]*svctypes.Service, 0, 32)
+	events := make([]*eventhub.CacheServiceEvent, 0, len(services))
 
 	for _, service := range services {
 		progress++
@@ -471,10 +472,24 @@ func (sc *serviceCache) setServices(services map[string]*svctypes.Service) (map[
 			sc.removeServices(service)
 			sc.notifyRevisionWorker(service.ID, false)
 			del++
+			if oldVal != nil {
+				events = append(events, &eventhub.CacheServiceEvent{
+					Service:   service,
+					EventType: eventhub.EventDeleted,
+				})
+			}
 			continue
 		}
 
 		update++
+		eventType := eventhub.EventCreated
+		if oldVal != nil {
+			eventType = eventhub.EventUpdated
+		}
+		events = append(events, &eventhub.CacheServiceEvent{
+			Service:   service,
+			EventType: eventType,
+		})
 
 		sc.ids.Store(service.ID, service)
 		sc.serviceList.addService(service)
@@ -500,6 +515,9 @@ func (sc *serviceCache) setServices(services map[string]*svctypes.Service) (map[
 	sc.postProcessUpdatedServices(changeNs)
 	sc.postProcessServiceExports(services)
 	sc.serviceList.reloadRevision()
+	for i := range events {
+		_ = eventhub.Publish(eventhub.CacheServiceEventTopic, events[i])
+	}
 	return map[string]time.Time{
 		sc.Name(): time.Unix(lastMtime, 0),
 	}, update, del
@@ -0,0 +1,179 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package conndrain
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// batchInterval 两个批次之间的间隔
+	batchInterval = time.Second
+)
+
+// ErrDraining 服务端处于排空阶段, 客户端需要重连到其他节点
+var ErrDraining = status.Error(codes.Unavailable, "server is draining, please reconnect to other server")
+
+// Spread 将 total 个操作在 window 时间内按批次均匀执行, 避免客户端在同一时刻集中重连;
+// ctx 结束时剩余的操作会被立即执行
+func Spread(ctx context.Context, total int, window time.Duration, fn func(i int)) {
+	if total <= 0 {
+		return
+	}
+	batches := int(window / batchInterval)
+	if batches > total {
+		batches = total
+	}
+	if batches <= 1 {
+		for i := 0; i < total; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	size := (total + batches - 1) / batches
+	ticker := time.NewTicker(window / time.Duration(batches))
+	defer ticker.Stop()
+
+	immediate := false
+	for start := 0; start < total; start += size {
+		end := start + size
+		if end > total {
+			end = total
+		}
+		for i := start; i < end; i++ {
+			fn(i)
+		}
+		if immediate || end == total {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			immediate = true
+		case <-ticker.C:
+		}
+	}
+}
+
+// StreamTracker 记录正在处理中的 gRPC stream, 进程退出前拒绝新的 stream 并分批断开存量的 stream
+type StreamTracker struct {
+	lock     sync.Mutex
+	draining bool
+	seq      uint64
+	streams  map[uint64]context.CancelFunc
+}
+
+// NewStreamTracker .
+func NewStreamTracker() *StreamTracker {
+	return &StreamTracker{
+		streams: map[uint64]context.CancelFunc{},
+	}
+}
+
+// Track 登记一个 stream, 返回的 ctx 在排空时被取消, 排空阶段不再接收新的 stream
+func (t *StreamTracker) Track(ctx context.Context) (context.Context, func(), error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.draining {
+		return nil, nil, ErrDraining
+	}
+	t.seq++
+	id := t.seq
+	ctx, cancel := context.WithCancel(ctx)
+	t.streams[id] = cancel
+	return ctx, func() {
+		cancel()
+		t.lock.Lock()
+		delete(t.streams, id)
+		t.lock.Unlock()
+	}, nil
+}
+
+// Count 当前处理中的 stream 数量
+func (t *StreamTracker) Count() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.streams)
+}
+
+// Reject 停止接收新的 stream, 存量的 stream 不受影响
+func (t *StreamTracker) Reject() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.draining = true
+}
+
+// Drain 停止接收新的 stream, 并在 window 时间内分批断开存量的 stream
+func (t *StreamTracker) Drain(ctx context.Context, window time.Duration) {
+	t.lock.Lock()
+	t.draining = true
+	cancels := make([]context.CancelFunc, 0, len(t.streams))
+	for _, cancel := range t.streams {
+		cancels = append(cancels, cancel)
+	}
+	t.lock.Unlock()
+
+	Spread(ctx, len(cancels), window, func(i int) {
+		cancels[i]()
+	})
+}
+
+// StreamServerInterceptor 返回登记 stream 的拦截器, stream 被排空时以 Unavailable 结束,
+// 客户端据此重连到其他节点
+func (t *StreamTracker) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		ctx, release, err := t.Track(ss.Context())
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		// handler 阻塞在 Recv 上无法感知 ctx, 拦截器返回后 gRPC 会结束该 stream, handler 随之退出
+		done := make(chan error, 1)
+		go func() {
+			done <- handler(srv, &trackedStream{ServerStream: ss, ctx: ctx})
+		}()
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			if ss.Context().Err() != nil {
+				// 客户端主动断开, 按照正常流程等待 handler 退出
+				return <-done
+			}
+			return ErrDraining
+		}
+	}
+}
+
+type trackedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context 返回可被排空的 ctx
+func (s *trackedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,102 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package conndrain
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestSpread(t *testing.T) {
+	t.Run("batches", func(t *testing.T) {
+		var count int32
+		start := time.Now()
+		Spread(context.Background(), 6, 3*time.Second, func(i int) {
+			atomic.AddInt32(&count, 1)
+		})
+		assert.Equal(t, int32(6), count)
+		// 3 个批次之间存在 2 次间隔
+		assert.GreaterOrEqual(t, time.Since(start), 2*time.Second)
+	})
+
+	t.Run("ctx_done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var count int32
+		start := time.Now()
+		Spread(ctx, 100, time.Minute, func(i int) {
+			atomic.AddInt32(&count, 1)
+		})
+		assert.Equal(t, int32(100), count)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("no_window", func(t *testing.T) {
+		var count int32
+		Spread(context.Background(), 10, 0, func(i int) {
+			atomic.AddInt32(&count, 1)
+		})
+		assert.Equal(t, int32(10), count)
+	})
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (m *mockServerStream) Context() context.Context {
+	return m.ctx
+}
+
+func TestStreamTracker(t *testing.T) {
+	tracker := NewStreamTracker()
+	interceptor := tracker.StreamServerInterceptor()
+
+	started := make(chan struct{})
+	handlerDone := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- interceptor(nil, &mockServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{},
+			func(srv interface{}, stream grpc.ServerStream) error {
+				close(started)
+				<-stream.Context().Done()
+				close(handlerDone)
+				return nil
+			})
+	}()
+	<-started
+	assert.Equal(t, 1, tracker.Count())
+
+	tracker.Drain(context.Background(), 0)
+	assert.Equal(t, ErrDraining, <-errCh)
+	<-handlerDone
+	assert.Equal(t, 0, tracker.Count())
+
+	// 排空后拒绝新的 stream
+	err := interceptor(nil, &mockServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{},
+		func(srv interface{}, stream grpc.ServerStream) error {
+			return nil
+		})
+	assert.Equal(t, ErrDraining, err)
+}
@@ -20,6 +20,7 @@ package eventhub
 import (
 	"github.com/pole-io/pole-server/apis/pkg/types"
 	conftypes "github.com/pole-io/pole-server/apis/pkg/types/config"
+	"github.com/pole-io/pole-server/apis/pkg/types/rules"
 	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
 )
 
@@ -41,6 +42,12 @@ const (
 	CacheNamespaceEventTopic = "cache_namespace_event"
 	// ClientEventTopic .
 	ClientEventTopic = "client_event"
+	// CacheServiceEventTopic record cache occur service add/update/del event
+	CacheServiceEventTopic = "cache_service_event"
+	// CacheRuleEventTopic record cache occur governance rule update/del event
+	CacheRuleEventTopic = "cache_rule_event"
+	// CacheConfigReleaseEventTopic record cache occur config file active release removed event
+	CacheConfigReleaseEventTopic = "cache_config_release_event"
 )
 
 // PublishConfigFileEvent 事件对象，包含类型和事件消息
@@ -75,3 +82,23 @@ type CacheNamespaceEvent struct {
 	Item      *types.Namespace
 	EventType EventType
 }
+
+type CacheServiceEvent struct {
+	Service   *svctypes.Service
+	EventType EventType
+}
+
+// CacheConfigReleaseEvent 配置文件正式发布的生效版本被删除或者下线的事件, 新的发布仍然通过 ConfigFilePublishTopic 通知
+type CacheConfigReleaseEvent struct {
+	Release   *conftypes.SimpleConfigFileRelease
+	EventType EventType
+}
+
+// CacheRuleEvent 治理规则的变更事件, 规则缓存无法区分新增与更新, 新增同样以 EventUpdated 通知
+type CacheRuleEvent struct {
+	RouterRule         *rules.RouterConfig
+	RateLimitRule      *rules.RateLimit
+	CircuitBreakerRule *rules.CircuitBreakerRule
+	FaultDetectRule    *rules.FaultDetectRule
+	EventType          EventType
+}
@@ -0,0 +1,80 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package tracing
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultEndpoint 默认的 OTLP gRPC 接收端地址
+	DefaultEndpoint = "127.0.0.1:4317"
+	// DefaultServiceName 上报 span 时使用的服务名
+	DefaultServiceName = "pole-server"
+	// DefaultTimeout 单次导出 span 的超时时间
+	DefaultTimeout = 10 * time.Second
+	// DefaultSampleRatio 默认对所有的根 span 进行采样
+	DefaultSampleRatio = 1.0
+)
+
+// Config 链路追踪配置
+type Config struct {
+	// Enable 是否开启链路追踪，关闭时仍然会透传上游的 trace 上下文
+	Enable bool `yaml:"enable"`
+	// Endpoint OTLP gRPC 接收端地址，例如 otel-collector:4317
+	Endpoint string `yaml:"endpoint"`
+	// Insecure 是否使用明文连接接收端
+	Insecure bool `yaml:"insecure"`
+	// Headers 导出 span 时携带的额外请求头，例如鉴权 token
+	Headers map[string]string `yaml:"headers"`
+	// Timeout 单次导出 span 的超时时间
+	Timeout time.Duration `yaml:"timeout"`
+	// SampleRatio 根 span 的采样比例，取值 [0, 1]，上游请求携带的采样标记优先
+	SampleRatio float64 `yaml:"sampleRatio"`
+	// ServiceName 上报 span 时使用的服务名
+	ServiceName string `yaml:"serviceName"`
+}
+
+// DefaultConfig 默认的链路追踪配置
+func DefaultConfig() Config {
+	return Config{
+		Endpoint:    DefaultEndpoint,
+		Insecure:    true,
+		Timeout:     DefaultTimeout,
+		SampleRatio: DefaultSampleRatio,
+		ServiceName: DefaultServiceName,
+	}
+}
+
+// validate 校验配置并填充默认值
+func (c *Config) validate() error {
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return errors.New("tracing sampleRatio must be in [0, 1]")
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = DefaultEndpoint
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.ServiceName == "" {
+		c.ServiceName = DefaultServiceName
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MetadataCarrier 基于 gRPC metadata 的 propagation.TextMapCarrier
+type MetadataCarrier metadata.MD
+
+// Get 获取 key 对应的第一个值
+func (c MetadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set 设置 key 对应的值
+func (c MetadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// Keys 返回全部的 key
+func (c MetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func incomingCarrier(ctx context.Context) MetadataCarrier {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return MetadataCarrier(md)
+}
+
+// isHealthMethod 健康检查请求的频率很高且没有排查价值，不创建 span
+func isHealthMethod(method string) bool {
+	return method == grpc_health_v1.Health_Check_FullMethodName ||
+		method == grpc_health_v1.Health_Watch_FullMethodName
+}
+
+// UnaryServerInterceptor 为一元 gRPC 请求创建 span，并从请求的 metadata 中解析上游的 trace 上下文
+func UnaryServerInterceptor(protocol string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if isHealthMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx, span := StartServer(ctx, incomingCarrier(ctx), info.FullMethod, protocol,
+			semconv.RPCSystemGRPC, semconv.RPCMethod(info.FullMethod))
+		rsp, err := handler(ctx, req)
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(status.Code(err))))
+		if msg, ok := rsp.(ResponseMessage); ok && err == nil {
+			EndResponse(span, msg)
+			return rsp, err
+		}
+		End(span, err)
+		return rsp, err
+	}
+}
+
+// StreamServerInterceptor 为 gRPC 流创建 span，span 覆盖整个流的生命周期
+func StreamServerInterceptor(protocol string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		if isHealthMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		ctx, span := StartServer(ss.Context(), incomingCarrier(ss.Context()), info.FullMethod, protocol,
+			semconv.RPCSystemGRPC, semconv.RPCMethod(info.FullMethod))
+		err := handler(srv, &tracedStream{ServerStream: ss, ctx: ctx})
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(status.Code(err))))
+		End(span, err)
+		return err
+	}
+}
+
+// tracedStream 替换 ServerStream 的上下文，使得流内的处理逻辑能够获取到 span
+type tracedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedStream) Context() context.Context {
+	return s.ctx
+}
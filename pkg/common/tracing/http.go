@@ -0,0 +1,57 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package tracing
+
+import (
+	"github.com/emicklei/go-restful/v3"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+// RestfulFilter 为 HTTP 请求创建 span，并从请求头中解析上游的 trace 上下文，
+// span 会写入 req.Request 的上下文中，后续的 filter 以及处理函数可以通过 Detach 获取
+func RestfulFilter(protocol string) restful.FilterFunction {
+	return func(req *restful.Request, rsp *restful.Response, chain *restful.FilterChain) {
+		route := req.SelectedRoutePath()
+		if route == "" {
+			route = req.Request.URL.Path
+		}
+		ctx, span := StartServer(req.Request.Context(), propagation.HeaderCarrier(req.Request.Header),
+			req.Request.Method+" "+route, protocol,
+			semconv.HTTPRequestMethodKey.String(req.Request.Method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(req.Request.URL.Path),
+		)
+		req.Request = req.Request.WithContext(ctx)
+		chain.ProcessFilter(req, rsp)
+
+		status := rsp.StatusCode()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if code, ok := req.Attribute(utils.PolarisCode).(uint32); ok {
+			EndCode(span, code)
+			return
+		}
+		if status >= 500 {
+			span.SetStatus(codes.Error, "")
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,235 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pole-io/pole-server/pkg/common/version"
+)
+
+// instrumentationName 创建 tracer 时使用的埋点库名称
+const instrumentationName = "github.com/pole-io/pole-server"
+
+const (
+	// AttrProtocol 请求的接入协议，例如 HTTP、gRPC、NACOS-V1、EUREKA
+	AttrProtocol = attribute.Key("pole.protocol")
+	// AttrCode pole-server 的业务返回码
+	AttrCode = attribute.Key("pole.code")
+	// AttrBatchSize 批量任务一次处理的请求数量
+	AttrBatchSize = attribute.Key("pole.batch.size")
+	// AttrStoreOperation 存储层的操作名称
+	AttrStoreOperation = attribute.Key("pole.store.operation")
+)
+
+var (
+	propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+	lock     sync.Mutex
+	provider *sdktrace.TracerProvider
+)
+
+func init() {
+	otel.SetTextMapPropagator(propagator)
+}
+
+// Initialize 按照配置创建 OTLP exporter 并设置全局的 TracerProvider，
+// 未开启时使用 otel 默认的 noop 实现，只透传上游的 trace 上下文
+func Initialize(ctx context.Context, cfg *Config) error {
+	if !cfg.Enable {
+		return nil
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(cfg.Endpoint),
+		otlptracegrpc.WithTimeout(cfg.Timeout),
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	if len(cfg.Headers) != 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+	}
+	// exporter 内部异步建立连接，接收端不可用时不会阻塞启动
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("create otlp trace exporter: %w", err)
+	}
+	SetProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(newResource(cfg.ServiceName)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	))
+	return nil
+}
+
+// SetProvider 替换全局的 TracerProvider，测试中可以传入使用内存 exporter 的实现
+func SetProvider(tp *sdktrace.TracerProvider) {
+	lock.Lock()
+	defer lock.Unlock()
+	provider = tp
+	otel.SetTracerProvider(tp)
+}
+
+// Shutdown 导出剩余的 span 并关闭 TracerProvider
+func Shutdown(ctx context.Context) error {
+	lock.Lock()
+	tp := provider
+	provider = nil
+	lock.Unlock()
+	if tp == nil {
+		return nil
+	}
+	return tp.Shutdown(ctx)
+}
+
+func newResource(serviceName string) *resource.Resource {
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(version.Get()),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		attrs = append(attrs, semconv.ServiceInstanceID(hostname))
+	}
+	return resource.NewSchemaless(attrs...)
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start 创建一个内部 span，ctx 中没有 span 时作为根 span
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartServer 为接入层收到的请求创建 span，carrier 中携带的上游 trace 上下文作为父 span
+func StartServer(ctx context.Context, carrier propagation.TextMapCarrier, name, protocol string,
+	attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if carrier != nil {
+		ctx = propagator.Extract(ctx, carrier)
+	}
+	return tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(append(attrs, AttrProtocol.String(protocol))...))
+}
+
+// StartStore 为一次存储层调用创建 span，由存储插件在执行语句时统一调用；
+// ctx 中没有有效的 span 时返回 noop span，避免缓存轮询等后台查询产生大量孤立的 trace
+func StartStore(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return tracer().Start(ctx, "store."+operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attrs, AttrStoreOperation.String(operation))...))
+}
+
+// RecordWait 补充一个从 begin 开始到当前时间结束的 span，用于记录请求在队列中的等待时间，
+// ctx 中没有有效的 span 时不记录
+func RecordWait(ctx context.Context, name string, begin time.Time) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+	_, span := tracer().Start(ctx, name, trace.WithTimestamp(begin))
+	span.End()
+}
+
+// StartBatch 为合并处理的一批请求创建 span，span 挂在第一个被采样的请求下，并关联其余请求所在的 span；
+// 所有请求都没有被采样时返回 noop span，避免后台任务产生大量孤立的 trace
+func StartBatch(name string, parents []context.Context) (context.Context, trace.Span) {
+	var (
+		parent context.Context
+		links  = make([]trace.Link, 0, len(parents))
+	)
+	for _, ctx := range parents {
+		sc := trace.SpanContextFromContext(ctx)
+		if !sc.IsSampled() {
+			continue
+		}
+		if parent == nil {
+			parent = trace.ContextWithSpanContext(context.Background(), sc)
+			continue
+		}
+		links = append(links, trace.Link{SpanContext: sc})
+	}
+	if parent == nil {
+		ctx := context.Background()
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return tracer().Start(parent, name, trace.WithLinks(links...),
+		trace.WithAttributes(AttrBatchSize.Int(len(parents))))
+}
+
+// End 结束 span，err 不为空时记录错误
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// ResponseMessage 携带 pole-server 返回码的应答
+type ResponseMessage interface {
+	GetCode() *wrappers.UInt32Value
+}
+
+// EndResponse 结束 span 并记录应答的返回码，4xx、5xx 类的返回码标记为错误
+func EndResponse(span trace.Span, rsp ResponseMessage) {
+	if rsp != nil {
+		EndCode(span, rsp.GetCode().GetValue())
+		return
+	}
+	span.End()
+}
+
+// EndCode 结束 span 并记录返回码，返回码的前三位与 HTTP 状态码的含义一致
+func EndCode(span trace.Span, code uint32) {
+	span.SetAttributes(AttrCode.Int64(int64(code)))
+	if code/1000 >= 400 {
+		span.SetStatus(codes.Error, fmt.Sprintf("code %d", code))
+	}
+	span.End()
+}
+
+// SetName 修改 ctx 中当前 span 的名称，用于解析出具体的请求类型之后细化 span
+func SetName(ctx context.Context, name string) {
+	trace.SpanFromContext(ctx).SetName(name)
+}
+
+// Detach 返回一个只携带 ctx 中 span 的新上下文，不会随着 ctx 的取消而取消，
+// 用于接入层将请求上下文转换为内部上下文时保留调用链
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
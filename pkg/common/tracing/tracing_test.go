@@ -0,0 +1,190 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testParentID    = "00f067aa0ba902b7"
+	testTraceParent = "00-" + testTraceID + "-" + testParentID + "-01"
+)
+
+func setupExporter(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	SetProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() {
+		_ = Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+	return nil
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	exporter := setupExporter(t)
+
+	md := metadata.Pairs("traceparent", testTraceParent)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	info := &grpc.UnaryServerInfo{FullMethod: "/v1.PolarisGRPC/RegisterInstance"}
+	_, err := UnaryServerInterceptor("gRPC")(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		// 模拟接入层转换上下文后进入拦截器链
+		inner, span := Start(Detach(ctx), "service.CreateInstance")
+		_, storeSpan := StartStore(inner, "AddInstance")
+		storeSpan.End()
+		EndCode(span, 400201)
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+	server := findSpan(spans, info.FullMethod)
+	service := findSpan(spans, "service.CreateInstance")
+	store := findSpan(spans, "store.AddInstance")
+	assert.NotNil(t, server)
+	assert.NotNil(t, service)
+	assert.NotNil(t, store)
+
+	assert.Equal(t, testTraceID, server.SpanContext.TraceID().String())
+	assert.Equal(t, testParentID, server.Parent.SpanID().String())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, server.SpanContext.SpanID(), service.Parent.SpanID())
+	assert.Equal(t, service.SpanContext.SpanID(), store.Parent.SpanID())
+	assert.Equal(t, codes.Error, service.Status.Code)
+}
+
+func TestUnaryServerInterceptorSkipHealth(t *testing.T) {
+	exporter := setupExporter(t)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+	_, err := UnaryServerInterceptor("gRPC")(context.Background(), nil, info,
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+	assert.NoError(t, err)
+	assert.Empty(t, exporter.GetSpans())
+}
+
+func TestRestfulFilter(t *testing.T) {
+	exporter := setupExporter(t)
+
+	ws := new(restful.WebService)
+	ws.Path("/naming/v1")
+	ws.Route(ws.POST("/instances").To(func(req *restful.Request, rsp *restful.Response) {
+		assert.True(t, trace.SpanContextFromContext(Detach(req.Request.Context())).IsValid())
+		req.SetAttribute(utils.PolarisCode, uint32(200000))
+		rsp.WriteHeader(http.StatusOK)
+	}))
+	container := restful.NewContainer()
+	container.Filter(RestfulFilter("HTTP"))
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodPost, "/naming/v1/instances", nil)
+	req.Header.Set("traceparent", testTraceParent)
+	container.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, testTraceID, spans[0].SpanContext.TraceID().String())
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	found := false
+	for _, attr := range spans[0].Attributes {
+		if attr.Key == AttrCode {
+			found = true
+			assert.Equal(t, int64(200000), attr.Value.AsInt64())
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestStartBatch(t *testing.T) {
+	exporter := setupExporter(t)
+
+	// 没有被采样的请求时不创建 span
+	_, span := StartBatch("batch.register.flush", []context.Context{context.Background(), context.Background()})
+	assert.False(t, span.SpanContext().IsValid())
+	span.End()
+
+	ctx1, span1 := Start(context.Background(), "request-1")
+	ctx2, span2 := Start(context.Background(), "request-2")
+	RecordWait(ctx1, "batch.register.wait", time.Now().Add(-time.Second))
+	_, span = StartBatch("batch.register.flush", []context.Context{ctx1, ctx2, context.Background()})
+	span.End()
+	span1.End()
+	span2.End()
+
+	spans := exporter.GetSpans()
+	wait := findSpan(spans, "batch.register.wait")
+	flush := findSpan(spans, "batch.register.flush")
+	assert.NotNil(t, wait)
+	assert.NotNil(t, flush)
+	assert.Equal(t, span1.SpanContext().SpanID(), wait.Parent.SpanID())
+	assert.True(t, wait.EndTime.Sub(wait.StartTime) >= time.Second)
+	assert.Equal(t, span1.SpanContext().SpanID(), flush.Parent.SpanID())
+	assert.Len(t, flush.Links, 1)
+	assert.Equal(t, span2.SpanContext().SpanID(), flush.Links[0].SpanContext.SpanID())
+}
+
+func TestStartStore(t *testing.T) {
+	exporter := setupExporter(t)
+
+	// 缓存轮询等没有调用方 span 的查询不创建 span
+	_, span := StartStore(context.Background(), "Query")
+	assert.False(t, span.SpanContext().IsValid())
+	span.End()
+	assert.Empty(t, exporter.GetSpans())
+
+	ctx, flush := StartBatch("batch.register.flush", []context.Context{})
+	assert.False(t, flush.SpanContext().IsValid())
+	_, span = StartStore(ctx, "Transaction")
+	assert.False(t, span.SpanContext().IsValid())
+
+	ctx, parent := Start(context.Background(), "batch.register.flush")
+	_, span = StartStore(ctx, "Transaction")
+	span.End()
+	parent.End()
+	store := findSpan(exporter.GetSpans(), "store.Transaction")
+	assert.NotNil(t, store)
+	assert.Equal(t, parent.SpanContext().SpanID(), store.Parent.SpanID())
+	assert.Equal(t, trace.SpanKindClient, store.SpanKind)
+}
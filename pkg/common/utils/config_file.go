@@ -18,6 +18,7 @@
 package utils
 
 import (
+	"bufio"
 	"strings"
 
 	conftypes "github.com/pole-io/pole-server/apis/pkg/types/config"
@@ -44,3 +45,75 @@ func ParseFileId(fileId string) (namespace, group, fileName string) {
 	fileInfo := strings.Split(fileId, conftypes.FileIdSeparator)
 	return fileInfo[0], fileInfo[1], fileInfo[2]
 }
+
+// ParseProperties 解析 java properties 格式，支持 = : 空白 三种分隔符、# ! 注释以及 \ 续行
+func ParseProperties(content string) map[string]string {
+	ret := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	var logical strings.Builder
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if logical.Len() == 0 && (line == "" || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+		if strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\") {
+			logical.WriteString(strings.TrimSuffix(line, "\\"))
+			continue
+		}
+		logical.WriteString(line)
+		key, value := splitProperty(logical.String())
+		logical.Reset()
+		if key != "" {
+			ret[key] = value
+		}
+	}
+	if logical.Len() > 0 {
+		if key, value := splitProperty(logical.String()); key != "" {
+			ret[key] = value
+		}
+	}
+	return ret
+}
+
+func splitProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':', ' ', '\t':
+			key := unescapeProperty(line[:i])
+			value := strings.TrimLeft(line[i+1:], " \t")
+			if line[i] == ' ' || line[i] == '\t' {
+				// key value 之间只有空白时，value 前面可能仍然有 = 或者 :
+				value = strings.TrimLeft(strings.TrimPrefix(strings.TrimPrefix(value, "="), ":"), " \t")
+			}
+			return key, unescapeProperty(value)
+		}
+	}
+	return unescapeProperty(line), ""
+}
+
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			buf.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			buf.WriteByte('\n')
+		case 't':
+			buf.WriteByte('\t')
+		case 'r':
+			buf.WriteByte('\r')
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String()
+}
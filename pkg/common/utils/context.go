@@ -58,6 +58,8 @@ type (
 	ContextAPIServerSlot struct{}
 	// WatchTimeoutCtx .
 	WatchTimeoutCtx struct{}
+	// WatchLabelsCtx 长轮询订阅时额外参与灰度规则匹配的客户端标签, map[string]string
+	WatchLabelsCtx struct{}
 )
 
 // WithLocalhost 存储localhost
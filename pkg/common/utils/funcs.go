@@ -28,6 +28,7 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/protobuf/types/known/wrapperspb"
@@ -275,7 +276,8 @@ func ConvertGRPCContext(ctx context.Context) context.Context {
 		}
 	}
 
-	ctx = context.Background()
+	// 只保留接入层创建的 span，内部上下文不随 gRPC 请求的结束而取消
+	ctx = trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
 	ctx = types.AppendRequestHeader(ctx, meta)
 	ctx = types.AppendContextValue(ctx, types.ContextGrpcHeader, meta)
 	ctx = types.AppendContextValue(ctx, types.ContextRequestId, requestID)
@@ -150,6 +150,11 @@ func BuildTimeoutWatchCtx(ctx context.Context, req *apiconfig.ClientWatchConfigF
 	labels := map[string]string{
 		types.ClientLabel_IP: utils.ParseClientIP(ctx),
 	}
+	if extra, ok := ctx.Value(utils.WatchLabelsCtx{}).(map[string]string); ok {
+		for k, v := range extra {
+			labels[k] = v
+		}
+	}
 	if len(req.GetClientIp().GetValue()) != 0 {
 		labels[types.ClientLabel_IP] = req.GetClientIp().GetValue()
 	}
@@ -228,6 +228,24 @@ func (s *Server) CryptoManager() crypto.CryptoManager {
 	return s.cryptoManager
 }
 
+// DecryptClientConfigFile 解密 GetConfigFileWithCache 返回的配置内容，供不支持客户端解密的协议使用
+func (s *Server) DecryptClientConfigFile(file *apiconfig.ClientConfigFileInfo) (string, error) {
+	if !file.GetEncrypted().GetValue() {
+		return file.GetContent().GetValue(), nil
+	}
+	tags := conftypes.ToTagMap(file.GetTags())
+	chain := &CryptoConfigFileChain{svr: s}
+	plainContent, err := chain.decryptConfigFileContent(tags[types.MetaKeyConfigFileDataKey],
+		tags[types.MetaKeyConfigFileEncryptAlgo], file.GetContent().GetValue())
+	if err != nil {
+		return "", err
+	}
+	if plainContent == "" {
+		return file.GetContent().GetValue(), nil
+	}
+	return plainContent, nil
+}
+
 // RecordHistory server对外提供history插件的简单封装
 func (s *Server) RecordHistory(ctx context.Context, entry *types.RecordEntry) {
 	// 如果插件没有初始化，那么不记录history
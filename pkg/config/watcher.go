@@ -20,6 +20,7 @@ package config
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -201,6 +202,8 @@ type watchCenter struct {
 	fileCache cacheapi.ConfigFileCache
 	cacheMgr  cacheapi.CacheManager
 	cancel    context.CancelFunc
+	// draining 进程退出前的排空阶段, 不再 hold 客户端的长轮询请求
+	draining atomic.Bool
 }
 
 // NewWatchCenter 创建一个客户端监听配置发布的处理中心
@@ -287,6 +290,13 @@ func (wc *watchCenter) DelWatchContext(clientId string) (WatchContext, bool) {
 // AddWatcher 新增订阅者
 func (wc *watchCenter) AddWatcher(clientId string,
 	watchFiles []*apiconfig.ClientConfigFileInfo, factory WatchContextFactory) WatchContext {
+	if wc.draining.Load() {
+		// 排空阶段长轮询请求直接响应, 不再加入订阅列表
+		if watchCtx := factory(clientId, wc.MatchBetaReleaseFile); watchCtx.IsOnce() {
+			watchCtx.Reply(notModifiedResponse)
+			return watchCtx
+		}
+	}
 	watchCtx, _ := wc.clients.ComputeIfAbsent(clientId, func(k string) WatchContext {
 		return factory(clientId, wc.MatchBetaReleaseFile)
 	})
@@ -383,6 +393,25 @@ func (wc *watchCenter) Close() {
 	wc.subCtx.Cancel()
 }
 
+// Drain 进程退出前立即响应所有挂起的长轮询请求, 之后新的长轮询请求也会被立即响应,
+// 客户端据此尽快切换到其他节点
+func (wc *watchCenter) Drain() {
+	wc.draining.Store(true)
+	waitRemove := make([]WatchContext, 0, 32)
+	wc.clients.Range(func(client string, watchCtx WatchContext) {
+		// 基于 stream 推送的订阅由各协议在断开连接时自行处理
+		if watchCtx.IsOnce() {
+			waitRemove = append(waitRemove, watchCtx)
+		}
+	})
+	log.Info("[Config][Watcher] drain all long polling watch context", zap.Int("clients", len(waitRemove)))
+	for i := range waitRemove {
+		watchCtx := waitRemove[i]
+		watchCtx.Reply(notModifiedResponse)
+		wc.RemoveAllWatcher(watchCtx.ClientID())
+	}
+}
+
 func (wc *watchCenter) startHandleTimeoutRequestWorker(ctx context.Context) {
 	t := time.NewTicker(time.Second)
 	defer t.Stop()
@@ -0,0 +1,304 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package multicluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apifault "github.com/polarismesh/specification/source/go/api/v1/fault_tolerance"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+)
+
+// targetClient 目标集群的资源读写接口
+type targetClient interface {
+	// get 查询目标集群上与 res 对应的资源, 不存在时返回 nil
+	get(ctx context.Context, res *resource) (proto.Message, error)
+	// create 在目标集群上创建资源
+	create(ctx context.Context, kind string, spec proto.Message) error
+	// update 更新目标集群上的资源, spec 中已经设置了目标集群上的资源 ID
+	update(ctx context.Context, kind string, spec proto.Message) error
+	// remove 删除目标集群上的资源, spec 中已经设置了目标集群上的资源 ID
+	remove(ctx context.Context, kind string, spec proto.Message) error
+}
+
+// openAPI 各类资源在 HTTP OpenAPI 上的访问路径
+type openAPI struct {
+	query  string
+	create string
+	update string
+	remove string
+}
+
+var openAPIs = map[string]openAPI{
+	KindService: {
+		query: "/naming/v1/services", create: "/naming/v1/services",
+		update: "/naming/v1/services", remove: "/naming/v1/services/delete",
+	},
+	KindInstance: {
+		query: "/naming/v1/instances", create: "/naming/v1/instances",
+		update: "/naming/v1/instances", remove: "/naming/v1/instances/delete",
+	},
+	KindRouterRule: {
+		query: "/naming/v1/routings", create: "/naming/v1/routings",
+		update: "/naming/v1/routings", remove: "/naming/v1/routings/delete",
+	},
+	KindRateLimitRule: {
+		query: "/naming/v1/ratelimits", create: "/naming/v1/ratelimits",
+		update: "/naming/v1/ratelimits", remove: "/naming/v1/ratelimits/delete",
+	},
+	KindCircuitBreakerRule: {
+		query: "/naming/v1/circuitbreaker/rules", create: "/naming/v1/circuitbreaker/rules",
+		update: "/naming/v1/circuitbreaker/rules", remove: "/naming/v1/circuitbreaker/rules/delete",
+	},
+	KindFaultDetectRule: {
+		query: "/naming/v1/faultdetectors", create: "/naming/v1/faultdetectors",
+		update: "/naming/v1/faultdetectors", remove: "/naming/v1/faultdetectors/delete",
+	},
+	KindConfigFile: {
+		query: "/config/v1/files/release", create: "/config/v1/files/createandpub",
+		update: "/config/v1/files/createandpub", remove: "/config/v1/files/releases/delete",
+	},
+}
+
+// httpClient 基于目标集群 HTTP OpenAPI 的 targetClient 实现
+type httpClient struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+func newHTTPClient(cfg *TargetConfig) *httpClient {
+	return &httpClient{
+		address: strings.TrimSuffix(cfg.Address, "/"),
+		token:   cfg.Token,
+		client:  &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (c *httpClient) get(ctx context.Context, res *resource) (proto.Message, error) {
+	path := openAPIs[res.kind].query
+	switch spec := res.spec.(type) {
+	case *apiservice.Service:
+		rsp := &apiservice.BatchQueryResponse{}
+		params := url.Values{"namespace": {res.namespace}, "name": {spec.GetName().GetValue()}}
+		if err := c.query(ctx, path, params, rsp); err != nil {
+			return nil, err
+		}
+		for _, item := range rsp.GetServices() {
+			if item.GetNamespace().GetValue() == res.namespace && item.GetName().GetValue() == spec.GetName().GetValue() {
+				return item, nil
+			}
+		}
+	case *apiservice.Instance:
+		rsp := &apiservice.BatchQueryResponse{}
+		params := url.Values{
+			"namespace": {res.namespace},
+			"service":   {spec.GetService().GetValue()},
+			"host":      {spec.GetHost().GetValue()},
+			"port":      {strconv.FormatUint(uint64(spec.GetPort().GetValue()), 10)},
+		}
+		if err := c.query(ctx, path, params, rsp); err != nil {
+			return nil, err
+		}
+		for _, item := range rsp.GetInstances() {
+			if item.GetHost().GetValue() == spec.GetHost().GetValue() &&
+				item.GetPort().GetValue() == spec.GetPort().GetValue() {
+				return item, nil
+			}
+		}
+	case *apitraffic.Rule:
+		rsp := &apiservice.BatchQueryResponse{}
+		params := url.Values{
+			"namespace": {res.namespace},
+			"service":   {spec.GetService().GetValue()},
+			"name":      {spec.GetName().GetValue()},
+		}
+		if err := c.query(ctx, path, params, rsp); err != nil {
+			return nil, err
+		}
+		for _, item := range rsp.GetRateLimits() {
+			if item.GetNamespace().GetValue() == res.namespace &&
+				item.GetService().GetValue() == spec.GetService().GetValue() &&
+				item.GetName().GetValue() == spec.GetName().GetValue() {
+				return item, nil
+			}
+		}
+	case *apitraffic.RouteRule:
+		return c.queryData(ctx, path, res, func() namedMessage { return &apitraffic.RouteRule{} })
+	case *apifault.CircuitBreakerRule:
+		return c.queryData(ctx, path, res, func() namedMessage { return &apifault.CircuitBreakerRule{} })
+	case *apifault.FaultDetectRule:
+		return c.queryData(ctx, path, res, func() namedMessage { return &apifault.FaultDetectRule{} })
+	case *apiconfig.ConfigFilePublishInfo:
+		rsp := &apiconfig.ConfigResponse{}
+		params := url.Values{
+			"namespace": {res.namespace},
+			"group":     {spec.GetGroup().GetValue()},
+			"file_name": {spec.GetFileName().GetValue()},
+		}
+		if err := c.query(ctx, path, params, rsp); err != nil {
+			return nil, err
+		}
+		release := rsp.GetConfigFileRelease()
+		if release == nil || release.GetFileName().GetValue() == "" {
+			return nil, nil
+		}
+		return &apiconfig.ConfigFilePublishInfo{
+			ReleaseName:        release.GetName(),
+			Namespace:          release.GetNamespace(),
+			Group:              release.GetGroup(),
+			FileName:           release.GetFileName(),
+			Content:            release.GetContent(),
+			Comment:            release.GetComment(),
+			Format:             release.GetFormat(),
+			ReleaseDescription: release.GetReleaseDescription(),
+			Tags:               release.GetTags(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("resource %s not support", res.kind)
+	}
+	return nil, nil
+}
+
+// namedMessage 以 namespace + name 唯一标识的规则
+type namedMessage interface {
+	proto.Message
+	GetNamespace() string
+	GetName() string
+}
+
+// queryData 查询以 anypb.Any 形式返回的规则列表, 并按照 namespace + name 精确匹配
+func (c *httpClient) queryData(ctx context.Context, path string, res *resource,
+	newItem func() namedMessage) (proto.Message, error) {
+	rsp := &apiservice.BatchQueryResponse{}
+	if err := c.query(ctx, path, url.Values{"name": {res.name}}, rsp); err != nil {
+		return nil, err
+	}
+	for _, data := range rsp.GetData() {
+		item := newItem()
+		if err := anypb.UnmarshalTo(data, item, proto.UnmarshalOptions{}); err != nil {
+			return nil, err
+		}
+		if item.GetNamespace() == res.namespace && item.GetName() == res.name {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *httpClient) create(ctx context.Context, kind string, spec proto.Message) error {
+	return c.write(ctx, http.MethodPost, openAPIs[kind].create, spec, kind != KindConfigFile)
+}
+
+func (c *httpClient) update(ctx context.Context, kind string, spec proto.Message) error {
+	method := http.MethodPut
+	if kind == KindConfigFile {
+		method = http.MethodPost
+	}
+	return c.write(ctx, method, openAPIs[kind].update, spec, kind != KindConfigFile)
+}
+
+func (c *httpClient) remove(ctx context.Context, kind string, spec proto.Message) error {
+	path := openAPIs[kind].remove
+	if path == "" {
+		return fmt.Errorf("resource %s not support delete", kind)
+	}
+	return c.write(ctx, http.MethodPost, path, spec, true)
+}
+
+func (c *httpClient) query(ctx context.Context, path string, params url.Values, rsp proto.Message) error {
+	status, body, err := c.do(ctx, http.MethodGet, path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("query %s http code: %d, body: %s", path, status, body)
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, rsp)
+}
+
+// write 写请求, 命名服务的写接口为批量接口, 需要以数组的形式提交
+func (c *httpClient) write(ctx context.Context, method, path string, spec proto.Message, batch bool) error {
+	data, err := protojson.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	if batch {
+		data = append(append([]byte("["), data...), ']')
+	}
+	_, body, err := c.do(ctx, method, path, data)
+	if err != nil {
+		return err
+	}
+	ret := struct {
+		Code uint32 `json:"code"`
+		Info string `json:"info"`
+	}{}
+	if err := json.Unmarshal(body, &ret); err != nil {
+		return fmt.Errorf("%s %s invalid response: %s", method, path, body)
+	}
+	switch apimodel.Code(ret.Code) {
+	case apimodel.Code_ExecuteSuccess, apimodel.Code_NoNeedUpdate:
+		return nil
+	default:
+		return fmt.Errorf("%s %s code: %d, info: %s", method, path, ret.Code, ret.Info)
+	}
+}
+
+func (c *httpClient) do(ctx context.Context, method, path string, data []byte) (int, []byte, error) {
+	var reader io.Reader
+	if data != nil {
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.address+path, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set(types.HeaderAuthorizationKey, c.token)
+	}
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return rsp.StatusCode, body, nil
+}
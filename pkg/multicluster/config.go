@@ -0,0 +1,118 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package multicluster
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// ConflictSkip 目标集群上存在非同步产生的同名资源时跳过
+	ConflictSkip = "skip"
+	// ConflictOverwrite 目标集群上存在非同步产生的同名资源时使用源集群的数据覆盖
+	ConflictOverwrite = "overwrite"
+)
+
+const (
+	// DefaultInterval 默认的同步周期
+	DefaultInterval = 5 * time.Second
+	// DefaultTimeout 默认的目标集群请求超时时间
+	DefaultTimeout = 10 * time.Second
+)
+
+// Config 多集群资源同步配置
+type Config struct {
+	// Enable 是否开启多集群同步
+	Enable bool `yaml:"enable"`
+	// ClusterName 本集群的名称, 作为同步资源的来源标识, 需要与对端集群配置的 target 名称保持一致
+	ClusterName string `yaml:"clusterName"`
+	// Interval 待同步资源下发以及失败重试的周期
+	Interval time.Duration `yaml:"interval"`
+	// Targets 同步的目标集群
+	Targets []*TargetConfig `yaml:"targets"`
+}
+
+// TargetConfig 同步的目标集群配置
+type TargetConfig struct {
+	// Name 目标集群名称, 即目标集群上配置的 clusterName
+	Name string `yaml:"name"`
+	// Address 目标集群 HTTP OpenAPI 地址, 例如 http://127.0.0.1:8090
+	Address string `yaml:"address"`
+	// Token 访问目标集群 OpenAPI 使用的鉴权 token
+	Token string `yaml:"token"`
+	// Namespaces 需要同步的命名空间, 命名空间需要在目标集群上提前创建
+	Namespaces []string `yaml:"namespaces"`
+	// Resources 需要同步的资源类型, 为空时同步全部资源类型
+	Resources []string `yaml:"resources"`
+	// ConflictPolicy 冲突处理策略, skip 或者 overwrite, 默认 skip
+	ConflictPolicy string `yaml:"conflictPolicy"`
+	// Timeout 目标集群请求超时时间
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// DefaultConfig 默认的多集群同步配置
+func DefaultConfig() Config {
+	return Config{
+		Interval: DefaultInterval,
+	}
+}
+
+// validate 校验配置并填充默认值
+func (c *Config) validate() error {
+	if c.ClusterName == "" {
+		return errors.New("multicluster clusterName is required")
+	}
+	if c.Interval <= 0 {
+		c.Interval = DefaultInterval
+	}
+	names := map[string]struct{}{}
+	for _, target := range c.Targets {
+		if target.Name == "" || target.Address == "" {
+			return errors.New("multicluster target name and address are required")
+		}
+		if target.Name == c.ClusterName {
+			return fmt.Errorf("multicluster target (%s) is the local cluster", target.Name)
+		}
+		if _, ok := names[target.Name]; ok {
+			return fmt.Errorf("multicluster target (%s) duplicated", target.Name)
+		}
+		names[target.Name] = struct{}{}
+		if len(target.Namespaces) == 0 {
+			return fmt.Errorf("multicluster target (%s) namespaces is required", target.Name)
+		}
+		for _, kind := range target.Resources {
+			if _, ok := kindOrder[kind]; !ok {
+				return fmt.Errorf("multicluster target (%s) resource (%s) not support", target.Name, kind)
+			}
+		}
+		switch target.ConflictPolicy {
+		case "":
+			target.ConflictPolicy = ConflictSkip
+		case ConflictSkip, ConflictOverwrite:
+		default:
+			return fmt.Errorf("multicluster target (%s) conflict policy (%s) not support",
+				target.Name, target.ConflictPolicy)
+		}
+		if target.Timeout <= 0 {
+			target.Timeout = DefaultTimeout
+		}
+	}
+	return nil
+}
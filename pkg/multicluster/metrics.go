@@ -0,0 +1,87 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package multicluster
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	metricstypes "github.com/pole-io/pole-server/apis/pkg/types/metrics"
+	"github.com/pole-io/pole-server/pkg/common/metrics"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+const (
+	labelTarget = "target"
+	labelKind   = "kind"
+	labelResult = "result"
+)
+
+// 单个资源的同步结果
+const (
+	resultCreated  = "created"
+	resultUpdated  = "updated"
+	resultDeleted  = "deleted"
+	resultSkipped  = "skipped"
+	resultConflict = "conflict"
+	resultFailed   = "failed"
+)
+
+var (
+	registerOnce sync.Once
+
+	syncTotal     *prometheus.CounterVec
+	conflictTotal *prometheus.CounterVec
+	syncLag       *prometheus.HistogramVec
+	pendingCount  *prometheus.GaugeVec
+)
+
+func registerMetrics() {
+	registerOnce.Do(func() {
+		constLabels := map[string]string{
+			metricstypes.LabelServerNode: utils.LocalHost,
+		}
+		syncTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "multicluster_sync_total",
+			Help:        "total number of resources synchronized to target cluster",
+			ConstLabels: constLabels,
+		}, []string{labelTarget, labelKind, labelResult})
+		conflictTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "multicluster_sync_conflict_total",
+			Help:        "total number of resources conflict with the resources owned by target cluster",
+			ConstLabels: constLabels,
+		}, []string{labelTarget, labelKind})
+		syncLag = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "multicluster_sync_lag_seconds",
+			Help:        "time from resource modified in local cluster to synchronized to target cluster",
+			ConstLabels: constLabels,
+			Buckets:     []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 300, 1800},
+		}, []string{labelTarget, labelKind})
+		pendingCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "multicluster_sync_pending",
+			Help:        "number of resources waiting to synchronize to target cluster",
+			ConstLabels: constLabels,
+		}, []string{labelTarget})
+
+		_ = metrics.GetRegistry().Register(syncTotal)
+		_ = metrics.GetRegistry().Register(conflictTotal)
+		_ = metrics.GetRegistry().Register(syncLag)
+		_ = metrics.GetRegistry().Register(pendingCount)
+	})
+}
@@ -0,0 +1,421 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package multicluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apifault "github.com/polarismesh/specification/source/go/api/v1/fault_tolerance"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+	conftypes "github.com/pole-io/pole-server/apis/pkg/types/config"
+	"github.com/pole-io/pole-server/apis/pkg/types/rules"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+)
+
+// 支持同步的资源类型
+const (
+	KindService            = "service"
+	KindInstance           = "instance"
+	KindRouterRule         = "router_rule"
+	KindRateLimitRule      = "ratelimit_rule"
+	KindCircuitBreakerRule = "circuitbreaker_rule"
+	KindFaultDetectRule    = "faultdetect_rule"
+	KindConfigFile         = "config_file"
+)
+
+// kindOrder 资源的下发顺序, 被依赖的资源先下发
+var kindOrder = map[string]int{
+	KindService:            0,
+	KindInstance:           1,
+	KindRouterRule:         2,
+	KindRateLimitRule:      3,
+	KindCircuitBreakerRule: 4,
+	KindFaultDetectRule:    5,
+	KindConfigFile:         6,
+}
+
+// untaggedKinds 规则结构中没有 metadata 无法记录来源集群的资源类型, 仅依靠内容比对避免回环
+var untaggedKinds = map[string]struct{}{
+	KindCircuitBreakerRule: {},
+	KindFaultDetectRule:    {},
+}
+
+// volatileFields 每个集群各自生成的字段, 比对资源内容时忽略
+var volatileFields = map[protoreflect.Name]struct{}{
+	"id":            {},
+	"revision":      {},
+	"ctime":         {},
+	"mtime":         {},
+	"etime":         {},
+	"editable":      {},
+	"deleteable":    {},
+	"token":         {},
+	"service_token": {},
+	"create_by":     {},
+	"modify_by":     {},
+	"md5":           {},
+}
+
+// resource 一次待同步的资源变更
+type resource struct {
+	kind      string
+	namespace string
+	// name 资源在同类型同命名空间下的唯一名称
+	name string
+	// origin 资源的来源集群, 为空表示资源产生于本集群
+	origin  string
+	mtime   time.Time
+	deleted bool
+	spec    proto.Message
+}
+
+func (r *resource) key() string {
+	return r.kind + "/" + r.namespace + "/" + r.name
+}
+
+func (r *resource) String() string {
+	return r.key()
+}
+
+func serviceResource(svc *svctypes.Service, deleted bool) *resource {
+	// 服务别名依赖源服务的 ID, 不参与同步
+	if svc == nil || svc.Reference != "" {
+		return nil
+	}
+	return &resource{
+		kind:      KindService,
+		namespace: svc.Namespace,
+		name:      svc.Name,
+		origin:    svc.Meta[types.MetaKeySyncOriginCluster],
+		mtime:     svc.ModifyTime,
+		deleted:   deleted || !svc.Valid,
+		spec:      svc.ToSpec(),
+	}
+}
+
+func instanceResource(ins *svctypes.Instance, deleted bool) *resource {
+	if ins == nil || ins.Proto == nil {
+		return nil
+	}
+	spec := proto.Clone(ins.Proto).(*apiservice.Instance)
+	// 健康状态以源集群为准, 目标集群上不再进行健康检查
+	spec.HealthCheck = nil
+	spec.EnableHealthCheck = nil
+	return &resource{
+		kind:      KindInstance,
+		namespace: ins.Namespace(),
+		name:      fmt.Sprintf("%s/%s:%d", ins.Service(), ins.Host(), ins.Port()),
+		origin:    ins.Metadata()[types.MetaKeySyncOriginCluster],
+		mtime:     ins.ModifyTime,
+		deleted:   deleted || !ins.Valid,
+		spec:      spec,
+	}
+}
+
+func routerRuleResource(rule *rules.RouterConfig, deleted bool) (*resource, error) {
+	res := &resource{
+		kind:      KindRouterRule,
+		namespace: rule.Namespace,
+		name:      rule.Name,
+		origin:    rule.Metadata[types.MetaKeySyncOriginCluster],
+		mtime:     rule.ModifyTime,
+		deleted:   deleted || !rule.Valid,
+	}
+	if res.deleted {
+		res.spec = &apitraffic.RouteRule{Namespace: rule.Namespace, Name: rule.Name}
+		return res, nil
+	}
+	extend, err := rule.ToExpendRoutingConfig()
+	if err != nil {
+		return nil, err
+	}
+	spec, err := extend.ToApi()
+	if err != nil {
+		return nil, err
+	}
+	res.spec = spec
+	return res, nil
+}
+
+func rateLimitResource(rule *rules.RateLimit, deleted bool) *resource {
+	if rule.Proto == nil || rule.Proto.GetService().GetValue() == "" {
+		return nil
+	}
+	spec := proto.Clone(rule.Proto).(*apitraffic.Rule)
+	spec.Disable = wrapperspb.Bool(rule.Disable)
+	return &resource{
+		kind:      KindRateLimitRule,
+		namespace: spec.GetNamespace().GetValue(),
+		name:      spec.GetService().GetValue() + "/" + spec.GetName().GetValue(),
+		origin:    spec.GetMetadata()[types.MetaKeySyncOriginCluster],
+		mtime:     rule.ModifyTime,
+		deleted:   deleted || !rule.Valid,
+		spec:      spec,
+	}
+}
+
+func circuitBreakerRuleResource(rule *rules.CircuitBreakerRule, deleted bool) (*resource, error) {
+	spec := &apifault.CircuitBreakerRule{}
+	if len(rule.Rule) > 0 {
+		if err := json.Unmarshal([]byte(rule.Rule), spec); err != nil {
+			return nil, err
+		}
+	}
+	spec.Name = rule.Name
+	spec.Namespace = rule.Namespace
+	spec.Description = rule.Description
+	spec.Level = apifault.Level(rule.Level)
+	spec.Enable = rule.Enable
+	return &resource{
+		kind:      KindCircuitBreakerRule,
+		namespace: rule.Namespace,
+		name:      rule.Name,
+		mtime:     rule.ModifyTime,
+		deleted:   deleted || !rule.Valid,
+		spec:      spec,
+	}, nil
+}
+
+func faultDetectRuleResource(rule *rules.FaultDetectRule, deleted bool) (*resource, error) {
+	spec := &apifault.FaultDetectRule{}
+	if len(rule.Rule) > 0 {
+		if err := json.Unmarshal([]byte(rule.Rule), spec); err != nil {
+			return nil, err
+		}
+	}
+	spec.Name = rule.Name
+	spec.Namespace = rule.Namespace
+	spec.Description = rule.Description
+	return &resource{
+		kind:      KindFaultDetectRule,
+		namespace: rule.Namespace,
+		name:      rule.Name,
+		mtime:     rule.ModifyTime,
+		deleted:   deleted || !rule.Valid,
+		spec:      spec,
+	}, nil
+}
+
+// configFileResource 配置仅同步正式发布的内容, 加密配置的数据密钥与集群相关, 不参与同步
+func configFileResource(release *conftypes.ConfigFileRelease) *resource {
+	if release == nil || release.SimpleConfigFileRelease == nil || release.ConfigFileReleaseKey == nil {
+		return nil
+	}
+	if release.ReleaseType == conftypes.ReleaseTypeGray || release.IsEncrypted() {
+		return nil
+	}
+	spec := &apiconfig.ConfigFilePublishInfo{
+		Namespace:          wrapperspb.String(release.Namespace),
+		Group:              wrapperspb.String(release.Group),
+		FileName:           wrapperspb.String(release.FileName),
+		Content:            wrapperspb.String(release.Content),
+		Comment:            wrapperspb.String(release.Comment),
+		Format:             wrapperspb.String(release.Format),
+		ReleaseDescription: wrapperspb.String(release.ReleaseDescription),
+		Tags:               conftypes.FromTagMap(release.Metadata),
+	}
+	return &resource{
+		kind:      KindConfigFile,
+		namespace: release.Namespace,
+		name:      release.Group + "/" + release.FileName,
+		origin:    release.Metadata[types.MetaKeySyncOriginCluster],
+		mtime:     release.ModifyTime,
+		spec:      spec,
+	}
+}
+
+// configFileDeletedResource 删除目标集群上同步的配置发布, 加密配置不参与同步, 因此同样不需要删除
+func configFileDeletedResource(release *conftypes.SimpleConfigFileRelease) *resource {
+	if release == nil || release.ConfigFileReleaseKey == nil {
+		return nil
+	}
+	if release.ReleaseType == conftypes.ReleaseTypeGray || release.IsEncrypted() {
+		return nil
+	}
+	return &resource{
+		kind:      KindConfigFile,
+		namespace: release.Namespace,
+		name:      release.Group + "/" + release.FileName,
+		origin:    release.Metadata[types.MetaKeySyncOriginCluster],
+		mtime:     release.ModifyTime,
+		deleted:   true,
+		spec: &apiconfig.ConfigFilePublishInfo{
+			Namespace: wrapperspb.String(release.Namespace),
+			Group:     wrapperspb.String(release.Group),
+			FileName:  wrapperspb.String(release.FileName),
+		},
+	}
+}
+
+// removeSpec 删除目标集群资源时提交的内容, 配置发布以目标集群上的发布名称标识
+func removeSpec(res *resource, exist proto.Message) proto.Message {
+	if info, ok := exist.(*apiconfig.ConfigFilePublishInfo); ok {
+		return &apiconfig.ConfigFileRelease{
+			Name:        info.GetReleaseName(),
+			Namespace:   info.GetNamespace(),
+			Group:       info.GetGroup(),
+			FileName:    info.GetFileName(),
+			ReleaseType: wrapperspb.String(conftypes.ReleaseTypeNormal),
+		}
+	}
+	return withID(res.spec, idOf(exist))
+}
+
+// originOf 获取目标集群上资源记录的来源集群
+func originOf(msg proto.Message) string {
+	if info, ok := msg.(*apiconfig.ConfigFilePublishInfo); ok {
+		return conftypes.ToTagMap(info.GetTags())[types.MetaKeySyncOriginCluster]
+	}
+	meta := metadataOf(msg)
+	if meta == nil {
+		return ""
+	}
+	val := meta.Get(protoreflect.ValueOfString(types.MetaKeySyncOriginCluster).MapKey())
+	if !val.IsValid() {
+		return ""
+	}
+	return val.String()
+}
+
+// withOrigin 返回打上来源集群标记的资源副本
+func withOrigin(msg proto.Message, origin string) proto.Message {
+	msg = proto.Clone(msg)
+	if info, ok := msg.(*apiconfig.ConfigFilePublishInfo); ok {
+		tags := conftypes.ToTagMap(info.GetTags())
+		tags[types.MetaKeySyncOriginCluster] = origin
+		info.Tags = conftypes.FromTagMap(tags)
+		return info
+	}
+	refMsg := msg.ProtoReflect()
+	field := refMsg.Descriptor().Fields().ByName("metadata")
+	if field == nil || !field.IsMap() {
+		return msg
+	}
+	refMsg.Mutable(field).Map().Set(protoreflect.ValueOfString(types.MetaKeySyncOriginCluster).MapKey(),
+		protoreflect.ValueOfString(origin))
+	return msg
+}
+
+func metadataOf(msg proto.Message) protoreflect.Map {
+	refMsg := msg.ProtoReflect()
+	field := refMsg.Descriptor().Fields().ByName("metadata")
+	if field == nil || !field.IsMap() || !refMsg.Has(field) {
+		return nil
+	}
+	return refMsg.Get(field).Map()
+}
+
+// idOf 获取资源的 ID, 兼容 string 以及 StringValue 两种类型
+func idOf(msg proto.Message) string {
+	refMsg := msg.ProtoReflect()
+	field := refMsg.Descriptor().Fields().ByName("id")
+	if field == nil || !refMsg.Has(field) {
+		return ""
+	}
+	if field.Kind() == protoreflect.StringKind {
+		return refMsg.Get(field).String()
+	}
+	if wrapper, ok := refMsg.Get(field).Message().Interface().(*wrapperspb.StringValue); ok {
+		return wrapper.GetValue()
+	}
+	return ""
+}
+
+// withID 返回设置了目标集群资源 ID 的资源副本
+func withID(msg proto.Message, id string) proto.Message {
+	msg = proto.Clone(msg)
+	refMsg := msg.ProtoReflect()
+	field := refMsg.Descriptor().Fields().ByName("id")
+	if field == nil {
+		return msg
+	}
+	if id == "" {
+		refMsg.Clear(field)
+		return msg
+	}
+	if field.Kind() == protoreflect.StringKind {
+		refMsg.Set(field, protoreflect.ValueOfString(id))
+	} else {
+		refMsg.Set(field, protoreflect.ValueOfMessage(wrapperspb.String(id).ProtoReflect()))
+	}
+	return msg
+}
+
+// equalSpec 比对两个集群上的资源内容是否一致, 忽略各集群独立生成的字段以及来源集群标记
+func equalSpec(a, b proto.Message) bool {
+	return proto.Equal(normalize(a), normalize(b))
+}
+
+func normalize(msg proto.Message) proto.Message {
+	msg = proto.Clone(msg)
+	if info, ok := msg.(*apiconfig.ConfigFilePublishInfo); ok {
+		tags := conftypes.ToTagMap(info.GetTags())
+		delete(tags, types.MetaKeySyncOriginCluster)
+		info.Tags = conftypes.FromTagMap(tags)
+		sort.Slice(info.Tags, func(i, j int) bool {
+			return info.Tags[i].GetKey().GetValue() < info.Tags[j].GetKey().GetValue()
+		})
+		info.ReleaseDescription = nil
+		info.Comment = nil
+		info.ReleaseName = nil
+	}
+	refMsg := msg.ProtoReflect()
+	refMsg.Range(func(field protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if _, ok := volatileFields[field.Name()]; ok {
+			refMsg.Clear(field)
+		}
+		return true
+	})
+	if meta := metadataOf(msg); meta != nil {
+		meta.Clear(protoreflect.ValueOfString(types.MetaKeySyncOriginCluster).MapKey())
+	}
+	pruneEmpty(refMsg)
+	return msg
+}
+
+// pruneEmpty 清理空的子消息, 避免未设置与空值在不同集群的序列化结果不一致
+func pruneEmpty(refMsg protoreflect.Message) {
+	refMsg.Range(func(field protoreflect.FieldDescriptor, val protoreflect.Value) bool {
+		switch {
+		case field.IsMap():
+			if val.Map().Len() == 0 {
+				refMsg.Clear(field)
+			}
+		case field.IsList():
+			if val.List().Len() == 0 {
+				refMsg.Clear(field)
+			}
+		case field.Message() != nil:
+			pruneEmpty(val.Message())
+			if proto.Size(val.Message().Interface()) == 0 {
+				refMsg.Clear(field)
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,391 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package multicluster
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	conftypes "github.com/pole-io/pole-server/apis/pkg/types/config"
+	"github.com/pole-io/pole-server/apis/store"
+	"github.com/pole-io/pole-server/pkg/cache"
+	"github.com/pole-io/pole-server/pkg/common/eventhub"
+)
+
+// Syncer 订阅本集群缓存的资源变更事件, 将指定命名空间下的资源同步到其他 pole-server 集群
+type Syncer struct {
+	cfg      *Config
+	workers  []*worker
+	isLeader func() bool
+	// releases 查询配置文件当前生效的发布内容
+	releases func(namespace, group, fileName string) *conftypes.ConfigFileRelease
+	subs     []*eventhub.SubscribtionContext
+}
+
+// Initialize 初始化多集群同步, 需要在缓存开始加载数据之前调用, 保证首次全量加载的资源也会同步到目标集群
+func Initialize(ctx context.Context, cfg *Config, storage store.Store, cacheMgr *cache.CacheManager) error {
+	if !cfg.Enable {
+		return nil
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	if err := storage.StartLeaderElection(store.ElectionKeyMultiClusterSync); err != nil {
+		log.Errorf("[MultiCluster] start leader election err: %v", err)
+		return err
+	}
+	clients := make(map[string]targetClient, len(cfg.Targets))
+	for _, target := range cfg.Targets {
+		clients[target.Name] = newHTTPClient(target)
+	}
+	s := newSyncer(cfg, clients, func() bool {
+		return storage.IsLeader(store.ElectionKeyMultiClusterSync)
+	})
+	s.releases = func(namespace, group, fileName string) *conftypes.ConfigFileRelease {
+		return cacheMgr.ConfigFile().GetActiveRelease(namespace, group, fileName)
+	}
+	return s.start(ctx)
+}
+
+func newSyncer(cfg *Config, clients map[string]targetClient, isLeader func() bool) *Syncer {
+	registerMetrics()
+	s := &Syncer{
+		cfg:      cfg,
+		isLeader: isLeader,
+	}
+	for _, target := range cfg.Targets {
+		s.workers = append(s.workers, newWorker(cfg.ClusterName, target, clients[target.Name]))
+	}
+	return s
+}
+
+func (s *Syncer) start(ctx context.Context) error {
+	handlers := map[string]eventhub.HandlerFunc{
+		eventhub.CacheServiceEventTopic:  s.onServiceEvent,
+		eventhub.CacheInstanceEventTopic: s.onInstanceEvent,
+		eventhub.CacheRuleEventTopic:     s.onRuleEvent,
+		eventhub.ConfigFilePublishTopic:  s.onConfigEvent,
+		// 配置文件删除或者生效版本下线时, 删除目标集群上同步的发布
+		eventhub.CacheConfigReleaseEventTopic: s.onConfigRemoveEvent,
+	}
+	for topic, handler := range handlers {
+		subCtx, err := eventhub.SubscribeWithFunc(topic, handler)
+		if err != nil {
+			s.unsubscribe()
+			return err
+		}
+		s.subs = append(s.subs, subCtx)
+	}
+	for i := range s.workers {
+		go s.runWorker(ctx, s.workers[i])
+	}
+	go func() {
+		<-ctx.Done()
+		s.unsubscribe()
+	}()
+	log.Infof("[MultiCluster] cluster (%s) start sync to %d targets", s.cfg.ClusterName, len(s.workers))
+	return nil
+}
+
+func (s *Syncer) unsubscribe() {
+	for i := range s.subs {
+		s.subs[i].Cancel()
+	}
+	s.subs = nil
+}
+
+// runWorker 只有 leader 节点向目标集群下发资源, follower 节点只合并记录待同步的资源, 保证切主后不丢失变更
+func (s *Syncer) runWorker(ctx context.Context, w *worker) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !s.isLeader() {
+				continue
+			}
+			w.flush(ctx)
+		}
+	}
+}
+
+func (s *Syncer) dispatch(res *resource) {
+	if res == nil {
+		return
+	}
+	for i := range s.workers {
+		s.workers[i].enqueue(res)
+	}
+}
+
+func (s *Syncer) onServiceEvent(_ context.Context, val any) error {
+	event, ok := val.(*eventhub.CacheServiceEvent)
+	if !ok {
+		return nil
+	}
+	s.dispatch(serviceResource(event.Service, event.EventType == eventhub.EventDeleted))
+	return nil
+}
+
+func (s *Syncer) onInstanceEvent(_ context.Context, val any) error {
+	event, ok := val.(*eventhub.CacheInstanceEvent)
+	if !ok {
+		return nil
+	}
+	s.dispatch(instanceResource(event.Instance, event.EventType == eventhub.EventDeleted))
+	return nil
+}
+
+func (s *Syncer) onRuleEvent(_ context.Context, val any) error {
+	event, ok := val.(*eventhub.CacheRuleEvent)
+	if !ok {
+		return nil
+	}
+	var (
+		res     *resource
+		err     error
+		deleted = event.EventType == eventhub.EventDeleted
+	)
+	switch {
+	case event.RouterRule != nil:
+		res, err = routerRuleResource(event.RouterRule, deleted)
+	case event.RateLimitRule != nil:
+		res = rateLimitResource(event.RateLimitRule, deleted)
+	case event.CircuitBreakerRule != nil:
+		res, err = circuitBreakerRuleResource(event.CircuitBreakerRule, deleted)
+	case event.FaultDetectRule != nil:
+		res, err = faultDetectRuleResource(event.FaultDetectRule, deleted)
+	}
+	if err != nil {
+		log.Error("[MultiCluster] convert rule to sync resource", zap.Error(err))
+		return nil
+	}
+	s.dispatch(res)
+	return nil
+}
+
+// onConfigEvent 发布事件中不携带配置内容, 需要从缓存中查询当前生效的发布内容
+func (s *Syncer) onConfigEvent(_ context.Context, val any) error {
+	event, ok := val.(*eventhub.PublishConfigFileEvent)
+	if !ok || event.Message == nil || event.Message.ConfigFileReleaseKey == nil || !event.Message.Active {
+		return nil
+	}
+	msg := event.Message
+	if msg.ReleaseType == conftypes.ReleaseTypeGray {
+		return nil
+	}
+	s.dispatch(configFileResource(s.releases(msg.Namespace, msg.Group, msg.FileName)))
+	return nil
+}
+
+// onConfigRemoveEvent 生效的发布被删除或者下线, 如果此时已经有新的版本生效则同步新的版本, 否则删除目标集群上的发布
+func (s *Syncer) onConfigRemoveEvent(_ context.Context, val any) error {
+	event, ok := val.(*eventhub.CacheConfigReleaseEvent)
+	if !ok || event.Release == nil || event.Release.ConfigFileReleaseKey == nil {
+		return nil
+	}
+	msg := event.Release
+	if active := s.releases(msg.Namespace, msg.Group, msg.FileName); active != nil {
+		s.dispatch(configFileResource(active))
+		return nil
+	}
+	s.dispatch(configFileDeletedResource(msg))
+	return nil
+}
+
+// worker 负责一个目标集群的资源同步
+type worker struct {
+	cfg         *TargetConfig
+	clusterName string
+	client      targetClient
+	namespaces  map[string]struct{}
+	kinds       map[string]struct{}
+
+	lock sync.Mutex
+	// pending 待同步的资源, 同一个资源只保留最新的一次变更
+	pending map[string]*resource
+}
+
+func newWorker(clusterName string, cfg *TargetConfig, client targetClient) *worker {
+	w := &worker{
+		cfg:         cfg,
+		clusterName: clusterName,
+		client:      client,
+		namespaces:  map[string]struct{}{},
+		kinds:       map[string]struct{}{},
+		pending:     map[string]*resource{},
+	}
+	for _, namespace := range cfg.Namespaces {
+		w.namespaces[namespace] = struct{}{}
+	}
+	for _, kind := range cfg.Resources {
+		w.kinds[kind] = struct{}{}
+	}
+	return w
+}
+
+// accept 判断资源是否需要同步到该目标集群
+func (w *worker) accept(res *resource) bool {
+	if _, ok := w.namespaces[res.namespace]; !ok {
+		return false
+	}
+	if len(w.kinds) > 0 {
+		if _, ok := w.kinds[res.kind]; !ok {
+			return false
+		}
+	}
+	// 来源于目标集群的资源不再同步回去, 避免双向同步时形成回环
+	return res.origin != w.cfg.Name
+}
+
+func (w *worker) enqueue(res *resource) {
+	if !w.accept(res) {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if old, ok := w.pending[res.key()]; ok && old.mtime.After(res.mtime) {
+		return
+	}
+	w.pending[res.key()] = res
+	pendingCount.WithLabelValues(w.cfg.Name).Set(float64(len(w.pending)))
+}
+
+// retry 同步失败的资源放回待同步队列, 期间已经有新的变更时以新的变更为准
+func (w *worker) retry(res *resource) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, ok := w.pending[res.key()]; !ok {
+		w.pending[res.key()] = res
+	}
+	pendingCount.WithLabelValues(w.cfg.Name).Set(float64(len(w.pending)))
+}
+
+// flush 下发当前所有待同步的资源, 失败的资源在下一个周期重试
+func (w *worker) flush(ctx context.Context) {
+	w.lock.Lock()
+	items := make([]*resource, 0, len(w.pending))
+	for _, res := range w.pending {
+		items = append(items, res)
+	}
+	w.pending = map[string]*resource{}
+	w.lock.Unlock()
+
+	// 先创建更新被依赖的资源, 删除时则先删除依赖方
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.deleted != b.deleted {
+			return !a.deleted
+		}
+		if a.deleted {
+			return kindOrder[a.kind] > kindOrder[b.kind]
+		}
+		return kindOrder[a.kind] < kindOrder[b.kind]
+	})
+
+	for _, res := range items {
+		if ctx.Err() != nil {
+			w.retry(res)
+			continue
+		}
+		result, err := w.sync(ctx, res)
+		syncTotal.WithLabelValues(w.cfg.Name, res.kind, result).Inc()
+		if err != nil {
+			log.Error("[MultiCluster] sync resource to target", zap.String("target", w.cfg.Name),
+				zap.String("resource", res.key()), zap.Error(err))
+			w.retry(res)
+			continue
+		}
+		switch result {
+		case resultCreated, resultUpdated, resultDeleted:
+			syncLag.WithLabelValues(w.cfg.Name, res.kind).Observe(time.Since(res.mtime).Seconds())
+		}
+	}
+
+	w.lock.Lock()
+	pendingCount.WithLabelValues(w.cfg.Name).Set(float64(len(w.pending)))
+	w.lock.Unlock()
+}
+
+// sync 将单个资源同步到目标集群
+func (w *worker) sync(ctx context.Context, res *resource) (string, error) {
+	exist, err := w.client.get(ctx, res)
+	if err != nil {
+		return resultFailed, err
+	}
+	origin := res.origin
+	if origin == "" {
+		origin = w.clusterName
+	}
+	_, untagged := untaggedKinds[res.kind]
+
+	if res.deleted {
+		if exist == nil {
+			return resultSkipped, nil
+		}
+		if !untagged && w.conflict(res, exist, origin) {
+			return resultConflict, nil
+		}
+		if err := w.client.remove(ctx, res.kind, removeSpec(res, exist)); err != nil {
+			return resultFailed, err
+		}
+		return resultDeleted, nil
+	}
+
+	spec := res.spec
+	if !untagged {
+		spec = withOrigin(spec, origin)
+	}
+	if exist == nil {
+		if err := w.client.create(ctx, res.kind, withID(spec, "")); err != nil {
+			return resultFailed, err
+		}
+		return resultCreated, nil
+	}
+	if equalSpec(spec, exist) {
+		return resultSkipped, nil
+	}
+	if !untagged && w.conflict(res, exist, origin) {
+		return resultConflict, nil
+	}
+	if err := w.client.update(ctx, res.kind, withID(spec, idOf(exist))); err != nil {
+		return resultFailed, err
+	}
+	return resultUpdated, nil
+}
+
+// conflict 目标集群上的同名资源不是由同一个来源集群同步产生时视为冲突, 按照冲突策略决定是否覆盖
+func (w *worker) conflict(res *resource, exist proto.Message, origin string) bool {
+	if originOf(exist) == origin {
+		return false
+	}
+	conflictTotal.WithLabelValues(w.cfg.Name, res.kind).Inc()
+	if w.cfg.ConflictPolicy == ConflictOverwrite {
+		return false
+	}
+	log.Warn("[MultiCluster] resource conflict with target, skip it", zap.String("target", w.cfg.Name),
+		zap.String("resource", res.key()), zap.String("origin", origin), zap.String("exist", originOf(exist)))
+	return true
+}
@@ -0,0 +1,292 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package multicluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apifault "github.com/polarismesh/specification/source/go/api/v1/fault_tolerance"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+	conftypes "github.com/pole-io/pole-server/apis/pkg/types/config"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/pkg/common/eventhub"
+)
+
+// fakeClient 以内存 map 模拟目标集群
+type fakeClient struct {
+	items  map[string]proto.Message
+	writes []string
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{items: map[string]proto.Message{}}
+}
+
+func (f *fakeClient) get(_ context.Context, res *resource) (proto.Message, error) {
+	return f.items[res.key()], nil
+}
+
+func (f *fakeClient) create(_ context.Context, kind string, spec proto.Message) error {
+	f.writes = append(f.writes, "create")
+	f.items[specKey(kind, spec)] = withID(spec, "target-id")
+	return nil
+}
+
+func (f *fakeClient) update(_ context.Context, kind string, spec proto.Message) error {
+	f.writes = append(f.writes, "update:"+idOf(spec))
+	f.items[specKey(kind, spec)] = spec
+	return nil
+}
+
+func (f *fakeClient) remove(_ context.Context, kind string, spec proto.Message) error {
+	f.writes = append(f.writes, "remove:"+idOf(spec))
+	delete(f.items, specKey(kind, spec))
+	return nil
+}
+
+func specKey(kind string, spec proto.Message) string {
+	switch msg := spec.(type) {
+	case *apiservice.Service:
+		return kind + "/" + msg.GetNamespace().GetValue() + "/" + msg.GetName().GetValue()
+	case *apifault.CircuitBreakerRule:
+		return kind + "/" + msg.GetNamespace() + "/" + msg.GetName()
+	case *apiconfig.ConfigFilePublishInfo:
+		return kind + "/" + msg.GetNamespace().GetValue() + "/" + msg.GetGroup().GetValue() + "/" +
+			msg.GetFileName().GetValue()
+	case *apiconfig.ConfigFileRelease:
+		return kind + "/" + msg.GetNamespace().GetValue() + "/" + msg.GetGroup().GetValue() + "/" +
+			msg.GetFileName().GetValue()
+	}
+	return ""
+}
+
+func newTestService(namespace, name, comment string, meta map[string]string) *svctypes.Service {
+	return &svctypes.Service{
+		ID:         "local-" + name,
+		Name:       name,
+		Namespace:  namespace,
+		Comment:    comment,
+		Meta:       meta,
+		Revision:   comment,
+		Valid:      true,
+		ModifyTime: time.Now(),
+	}
+}
+
+func newTestWorker(policy string) (*worker, *fakeClient) {
+	client := newFakeClient()
+	registerMetrics()
+	return newWorker("sh", &TargetConfig{
+		Name:           "gz",
+		Namespaces:     []string{"default"},
+		ConflictPolicy: policy,
+	}, client), client
+}
+
+func Test_WorkerSyncService(t *testing.T) {
+	w, client := newTestWorker(ConflictSkip)
+	ctx := context.Background()
+
+	t.Run("filter", func(t *testing.T) {
+		w.enqueue(serviceResource(newTestService("other", "svc", "v1", nil), false))
+		// 来源于目标集群的资源不再同步回去
+		w.enqueue(serviceResource(newTestService("default", "svc", "v1",
+			map[string]string{types.MetaKeySyncOriginCluster: "gz"}), false))
+		assert.Empty(t, w.pending)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		w.enqueue(serviceResource(newTestService("default", "svc", "v1", nil), false))
+		w.flush(ctx)
+		assert.Empty(t, w.pending)
+		assert.Equal(t, []string{"create"}, client.writes)
+		exist := client.items["service/default/svc"]
+		assert.Equal(t, "sh", originOf(exist))
+		assert.Equal(t, "target-id", idOf(exist))
+	})
+
+	t.Run("equal_skip", func(t *testing.T) {
+		// 仅 revision 不同, 内容一致不需要下发
+		res := serviceResource(newTestService("default", "svc", "v1", nil), false)
+		res.spec.(*apiservice.Service).Revision = wrapperspb.String("another")
+		w.enqueue(res)
+		w.flush(ctx)
+		assert.Equal(t, []string{"create"}, client.writes)
+	})
+
+	t.Run("coalesce_update", func(t *testing.T) {
+		older := serviceResource(newTestService("default", "svc", "v2", nil), false)
+		newer := serviceResource(newTestService("default", "svc", "v3", nil), false)
+		w.enqueue(newer)
+		w.enqueue(older)
+		assert.Len(t, w.pending, 1)
+		w.flush(ctx)
+		assert.Equal(t, []string{"create", "update:target-id"}, client.writes)
+		assert.Equal(t, "v3", client.items["service/default/svc"].(*apiservice.Service).GetComment().GetValue())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		w.enqueue(serviceResource(newTestService("default", "svc", "v3", nil), true))
+		w.flush(ctx)
+		assert.Empty(t, client.items)
+		assert.Equal(t, "remove:target-id", client.writes[len(client.writes)-1])
+	})
+}
+
+func Test_WorkerConflict(t *testing.T) {
+	ctx := context.Background()
+	owned := newTestService("default", "svc", "owned-by-gz", nil).ToSpec()
+
+	t.Run("skip", func(t *testing.T) {
+		w, client := newTestWorker(ConflictSkip)
+		client.items["service/default/svc"] = owned
+		w.enqueue(serviceResource(newTestService("default", "svc", "v1", nil), false))
+		w.flush(ctx)
+		assert.Empty(t, client.writes)
+		assert.Empty(t, w.pending)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		w, client := newTestWorker(ConflictOverwrite)
+		client.items["service/default/svc"] = owned
+		w.enqueue(serviceResource(newTestService("default", "svc", "v1", nil), false))
+		w.flush(ctx)
+		assert.Equal(t, []string{"update:local-svc"}, client.writes)
+		assert.Equal(t, "sh", originOf(client.items["service/default/svc"]))
+	})
+
+	t.Run("untagged_kind", func(t *testing.T) {
+		// 熔断规则无法记录来源集群, 只按照内容比对决定是否更新
+		w, client := newTestWorker(ConflictSkip)
+		client.items["circuitbreaker_rule/default/cb"] = &apifault.CircuitBreakerRule{
+			Id: "target-cb", Name: "cb", Namespace: "default", Description: "old",
+		}
+		w.enqueue(&resource{
+			kind: KindCircuitBreakerRule, namespace: "default", name: "cb", mtime: time.Now(),
+			spec: &apifault.CircuitBreakerRule{Id: "local-cb", Name: "cb", Namespace: "default", Description: "new"},
+		})
+		w.flush(ctx)
+		assert.Equal(t, []string{"update:target-cb"}, client.writes)
+	})
+}
+
+func Test_EqualSpec(t *testing.T) {
+	a := &apiservice.Instance{
+		Id:       wrapperspb.String("a"),
+		Host:     wrapperspb.String("127.0.0.1"),
+		Port:     wrapperspb.UInt32(8080),
+		Metadata: map[string]string{types.MetaKeySyncOriginCluster: "sh"},
+		Location: &apimodel.Location{Zone: wrapperspb.String("")},
+	}
+	b := &apiservice.Instance{
+		Id:   wrapperspb.String("b"),
+		Host: wrapperspb.String("127.0.0.1"),
+		Port: wrapperspb.UInt32(8080),
+	}
+	assert.True(t, equalSpec(a, b))
+	b.Weight = wrapperspb.UInt32(50)
+	assert.False(t, equalSpec(a, b))
+	// 比对过程中不能修改原始数据
+	assert.Equal(t, "a", idOf(a))
+	assert.Equal(t, "sh", originOf(a))
+}
+
+func Test_SyncerConfigRemove(t *testing.T) {
+	client := newFakeClient()
+	cfg := &Config{
+		ClusterName: "sh",
+		Targets:     []*TargetConfig{{Name: "gz", Namespaces: []string{"default"}}},
+	}
+	s := newSyncer(cfg, map[string]targetClient{"gz": client}, func() bool { return true })
+	var active *conftypes.ConfigFileRelease
+	s.releases = func(namespace, group, fileName string) *conftypes.ConfigFileRelease {
+		return active
+	}
+	removed := &eventhub.CacheConfigReleaseEvent{
+		Release: &conftypes.SimpleConfigFileRelease{
+			ConfigFileReleaseKey: &conftypes.ConfigFileReleaseKey{
+				Name: "v1", Namespace: "default", Group: "g", FileName: "a.yaml",
+				ReleaseType: conftypes.ReleaseTypeNormal,
+			},
+			ModifyTime: time.Now(),
+		},
+		EventType: eventhub.EventDeleted,
+	}
+	w := s.workers[0]
+	ctx := context.Background()
+
+	t.Run("new_release_active", func(t *testing.T) {
+		// 同一批次中已经有新的版本生效, 同步新的版本而不是删除
+		active = &conftypes.ConfigFileRelease{
+			SimpleConfigFileRelease: &conftypes.SimpleConfigFileRelease{
+				ConfigFileReleaseKey: removed.Release.ConfigFileReleaseKey,
+				Active:               true,
+				ModifyTime:           time.Now(),
+			},
+			Content: "a: 1",
+		}
+		assert.NoError(t, s.onConfigRemoveEvent(ctx, removed))
+		w.flush(ctx)
+		assert.Equal(t, []string{"create"}, client.writes)
+		exist := client.items["config_file/default/g/a.yaml"].(*apiconfig.ConfigFilePublishInfo)
+		assert.Equal(t, "a: 1", exist.GetContent().GetValue())
+		exist.ReleaseName = wrapperspb.String("target-v1")
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		active = nil
+		assert.NoError(t, s.onConfigRemoveEvent(ctx, removed))
+		w.flush(ctx)
+		assert.Empty(t, client.items)
+		assert.Len(t, client.writes, 2)
+	})
+
+	t.Run("not_found_skip", func(t *testing.T) {
+		assert.NoError(t, s.onConfigRemoveEvent(ctx, removed))
+		w.flush(ctx)
+		assert.Len(t, client.writes, 2)
+	})
+}
+
+func Test_RemoveSpec(t *testing.T) {
+	res := configFileDeletedResource(&conftypes.SimpleConfigFileRelease{
+		ConfigFileReleaseKey: &conftypes.ConfigFileReleaseKey{
+			Name: "v1", Namespace: "default", Group: "g", FileName: "a.yaml",
+		},
+	})
+	assert.True(t, res.deleted)
+	exist := &apiconfig.ConfigFilePublishInfo{
+		ReleaseName: wrapperspb.String("target-v1"),
+		Namespace:   wrapperspb.String("default"),
+		Group:       wrapperspb.String("g"),
+		FileName:    wrapperspb.String("a.yaml"),
+	}
+	spec := removeSpec(res, exist).(*apiconfig.ConfigFileRelease)
+	// 按照目标集群上的发布名称删除
+	assert.Equal(t, "target-v1", spec.GetName().GetValue())
+	assert.Equal(t, conftypes.ReleaseTypeNormal, spec.GetReleaseType().GetValue())
+}
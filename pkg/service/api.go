@@ -31,6 +31,8 @@ import (
 type DiscoverServer interface {
 	// ServiceContractOperateServer service contract rules operation inerface definition
 	ServiceContractOperateServer
+	// MCPServerOperateServer MCP server registry operation interface definition
+	MCPServerOperateServer
 	// ServiceAliasOperateServer Service alias operation interface definition
 	ServiceAliasOperateServer
 	// ServiceOperateServer Service operation interface definition
@@ -123,6 +125,16 @@ type ReportClientOperateServer interface {
 	GetReportClients(ctx context.Context, query map[string]string) *apiservice.BatchQueryResponse
 }
 
+// MCPServerOperateServer MCP server registry operations
+type MCPServerOperateServer interface {
+	// RegisterMCPServer register mcp server manifest and endpoints
+	RegisterMCPServer(ctx context.Context, req *svctypes.MCPServer) (*svctypes.MCPServer, error)
+	// DeregisterMCPServer deregister mcp server manifest and endpoints
+	DeregisterMCPServer(ctx context.Context, req *svctypes.MCPServer) error
+	// GetMCPServers query mcp servers, support search by tool name
+	GetMCPServers(ctx context.Context, query map[string]string) (uint32, []*svctypes.MCPServer, error)
+}
+
 // ServiceContractOperateServer service contract operations
 type ServiceContractOperateServer interface {
 	// CreateServiceContracts .
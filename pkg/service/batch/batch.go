@@ -134,8 +134,10 @@ func (bc *Controller) ClientDeregisterOpen() bool {
 }
 
 // AsyncCreateInstance 异步创建实例，返回一个future，根据future获取创建结果
-func (bc *Controller) AsyncCreateInstance(svcId string, instance *apiservice.Instance, needWait bool) *InstanceFuture {
+func (bc *Controller) AsyncCreateInstance(ctx context.Context, svcId string, instance *apiservice.Instance,
+	needWait bool) *InstanceFuture {
 	future := &InstanceFuture{
+		ctx:       ctx,
 		serviceId: svcId,
 		needWait:  needWait,
 		request:   instance,
@@ -152,8 +154,11 @@ func (bc *Controller) AsyncCreateInstance(svcId string, instance *apiservice.Ins
 }
 
 // AsyncDeleteInstance 异步合并反注册
-func (bc *Controller) AsyncDeleteInstance(instance *apiservice.Instance, needWait bool) *InstanceFuture {
+func (bc *Controller) AsyncDeleteInstance(ctx context.Context, instance *apiservice.Instance,
+	needWait bool) *InstanceFuture {
 	future := &InstanceFuture{
+		ctx:      ctx,
+		begin:    time.Now(),
 		request:  instance,
 		result:   make(chan error, 1),
 		needWait: true,
@@ -163,15 +168,18 @@ func (bc *Controller) AsyncDeleteInstance(instance *apiservice.Instance, needWai
 	return future
 }
 
-// AsyncHeartbeat 异步心跳
+// AsyncHeartbeat 异步心跳，flapCount 为实例健康状态的抖动次数，随健康状态一起写入 metadata
 func (bc *Controller) AsyncHeartbeat(instance *apiservice.Instance, healthy bool,
-	lastBeatTime int64) *InstanceFuture {
+	lastBeatTime int64, flapCount uint32) *InstanceFuture {
 	future := &InstanceFuture{
+		ctx:                  context.Background(),
+		begin:                time.Now(),
 		request:              instance,
 		result:               make(chan error, 1),
 		healthy:              healthy,
 		needWait:             true,
 		lastHeartbeatTimeSec: lastBeatTime,
+		flapCount:            flapCount,
 	}
 
 	bc.heartbeat.queue <- future
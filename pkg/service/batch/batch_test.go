@@ -104,7 +104,7 @@ func sendAsyncCreateInstance(bc *Controller, cnt int32) error {
 		wg.Add(1)
 		go func(index int32) {
 			defer wg.Done()
-			future := bc.AsyncCreateInstance(utils.NewUUID(), &apiservice.Instance{
+			future := bc.AsyncCreateInstance(context.Background(), utils.NewUUID(), &apiservice.Instance{
 				Id:           protobuf.NewStringValue(fmt.Sprintf("%d", index)),
 				ServiceToken: protobuf.NewStringValue(fmt.Sprintf("%d", index)),
 			}, true)
@@ -136,11 +136,11 @@ func TestAsyncCreateInstance(t *testing.T) {
 		})
 		mockSvc := &svctypes.Service{ID: "1"}
 		totalIns := int32(100)
-		storage.EXPECT().BatchGetInstanceIsolate(gomock.Any()).Return(nil, nil).AnyTimes()
+		storage.EXPECT().BatchGetInstanceIsolate(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
 		storage.EXPECT().GetSourceServiceToken(gomock.Any(), gomock.Any()).
 			Return(mockSvc, nil).AnyTimes()
 		storage.EXPECT().GetServiceByID(gomock.Any()).Return(mockSvc, nil).AnyTimes()
-		storage.EXPECT().BatchAddInstances(gomock.Any()).Return(nil).AnyTimes()
+		storage.EXPECT().BatchAddInstances(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 		assert.NoError(t, sendAsyncCreateInstance(bc, totalIns))
 	})
 }
@@ -166,3 +166,26 @@ func TestSendReply(t *testing.T) {
 		SendClientReply("test string", 1, nil)
 	})
 }
+
+// TestHealthMetadataRequests 测试健康状态变化时的 metadata 请求
+func TestHealthMetadataRequests(t *testing.T) {
+	t.Run("转为不健康且存在抖动", func(t *testing.T) {
+		appendReq, removeReq := HealthMetadataRequests("1", "r", false, 100, 2)
+		assert.Nil(t, removeReq)
+		assert.Equal(t, map[string]string{
+			svctypes.MetadataInstanceLastHeartbeatTime: "100",
+			svctypes.MetadataInstanceFlapCount:         "2",
+		}, appendReq.Metadata)
+	})
+	t.Run("转为健康且没有抖动", func(t *testing.T) {
+		appendReq, removeReq := HealthMetadataRequests("1", "r", true, 100, 0)
+		assert.Nil(t, appendReq)
+		assert.ElementsMatch(t, []string{svctypes.MetadataInstanceLastHeartbeatTime,
+			svctypes.MetadataInstanceFlapCount}, removeReq.Keys)
+	})
+	t.Run("转为健康且存在抖动", func(t *testing.T) {
+		appendReq, removeReq := HealthMetadataRequests("1", "r", true, 100, 1)
+		assert.Equal(t, map[string]string{svctypes.MetadataInstanceFlapCount: "1"}, appendReq.Metadata)
+		assert.Equal(t, []string{svctypes.MetadataInstanceLastHeartbeatTime}, removeReq.Keys)
+	})
+}
@@ -18,6 +18,7 @@
 package batch
 
 import (
+	"context"
 	"time"
 
 	"go.uber.org/zap"
@@ -34,6 +35,8 @@ import (
 // InstanceFuture 创建实例的异步结构体
 type InstanceFuture struct {
 	isRegis bool
+	// 发起请求的上下文，用于关联调用链
+	ctx context.Context
 	// 任务开始时间
 	begin time.Time
 	// 服务的id
@@ -52,6 +55,8 @@ type InstanceFuture struct {
 	healthy bool
 	// lastHeartbeatTimeSec 实例最后一次心跳上报时间
 	lastHeartbeatTimeSec int64
+	// flapCount 实例健康状态的抖动次数
+	flapCount uint32
 }
 
 // Reply future的应答
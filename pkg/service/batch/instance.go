@@ -34,6 +34,7 @@ import (
 	storeapi "github.com/pole-io/pole-server/apis/store"
 	"github.com/pole-io/pole-server/pkg/cache"
 	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 )
 
@@ -58,7 +59,7 @@ type InstanceCtrl struct {
 	storeThreadCh []chan []*InstanceFuture
 
 	// store协程里面调用的instance处理函数，可以是注册和反注册
-	instanceHandler func([]*InstanceFuture) error
+	instanceHandler func(context.Context, []*InstanceFuture) error
 
 	// 空闲的store协程，记录每一个空闲id
 	idleStoreThread chan int
@@ -243,10 +244,7 @@ func (ctrl *InstanceCtrl) storeWorker(ctx context.Context, index int) {
 	for {
 		select {
 		case futures := <-ctrl.storeThreadCh[index]:
-			if err := ctrl.instanceHandler(futures); err != nil {
-				// 所有的错误都在instanceHandler函数里面进行答复和处理，这里只需记录一条日志
-				log.Errorf("[Batch] %s instances err: %s", ctrl.label, err.Error())
-			}
+			ctrl.handleFutures(futures)
 			ctrl.idleStoreThread <- index
 		case <-ctx.Done():
 			// idle is not ready
@@ -256,11 +254,27 @@ func (ctrl *InstanceCtrl) storeWorker(ctx context.Context, index int) {
 	}
 }
 
+// handleFutures 处理一批请求，并记录每个请求的排队耗时以及整批请求的处理耗时
+func (ctrl *InstanceCtrl) handleFutures(futures []*InstanceFuture) {
+	parents := make([]context.Context, 0, len(futures))
+	for _, future := range futures {
+		tracing.RecordWait(future.ctx, "batch."+ctrl.label+".wait", future.begin)
+		parents = append(parents, future.ctx)
+	}
+	ctx, span := tracing.StartBatch("batch."+ctrl.label+".flush", parents)
+	err := ctrl.instanceHandler(ctx, futures)
+	tracing.End(span, err)
+	if err != nil {
+		// 所有的错误都在instanceHandler函数里面进行答复和处理，这里只需记录一条日志
+		log.Errorf("[Batch] %s instances err: %s", ctrl.label, err.Error())
+	}
+}
+
 // registerHandler 外部应该把鉴权完成
 // 判断实例是否存在，也可以提前判断，减少batch复杂度
 // 提前通过token判断，再进入batch操作
 // batch操作，只是写操作
-func (ctrl *InstanceCtrl) registerHandler(futures []*InstanceFuture) error {
+func (ctrl *InstanceCtrl) registerHandler(ctx context.Context, futures []*InstanceFuture) error {
 	if len(futures) == 0 {
 		log.Warn("[Batch] futures is empty")
 		return nil
@@ -290,7 +304,7 @@ func (ctrl *InstanceCtrl) registerHandler(futures []*InstanceFuture) error {
 	}
 
 	// 统一判断实例是否存在，存在则需要更新部分数据
-	if err := ctrl.batchRestoreInstanceIsolate(remains); err != nil {
+	if err := ctrl.batchRestoreInstanceIsolate(ctx, remains); err != nil {
 		log.Errorf("[Batch] batch check instances existed err: %s", err.Error())
 	}
 
@@ -309,7 +323,7 @@ func (ctrl *InstanceCtrl) registerHandler(futures []*InstanceFuture) error {
 	for _, entry := range remains {
 		instances = append(instances, entry.instance)
 	}
-	if err := ctrl.storage.BatchAddInstances(instances); err != nil {
+	if err := ctrl.storage.BatchAddInstances(ctx, instances); err != nil {
 		sendReply(remains, storeapi.StoreCode2APICode(err), err)
 		return err
 	}
@@ -319,15 +333,15 @@ func (ctrl *InstanceCtrl) registerHandler(futures []*InstanceFuture) error {
 }
 
 // heartbeatHandler 心跳状态变更处理函数
-func (ctrl *InstanceCtrl) heartbeatHandler(futures []*InstanceFuture) error {
+func (ctrl *InstanceCtrl) heartbeatHandler(ctx context.Context, futures []*InstanceFuture) error {
 	if len(futures) == 0 {
 		return nil
 	}
 	log.Infof("[Batch] start batch heartbeat instances count: %d", len(futures))
 	ids := make(map[string]bool, len(futures))
-	statusToIds := map[bool]map[string]int64{
-		true:  make(map[string]int64, len(futures)),
-		false: make(map[string]int64, len(futures)),
+	statusToIds := map[bool]map[string]*InstanceFuture{
+		true:  make(map[string]*InstanceFuture, len(futures)),
+		false: make(map[string]*InstanceFuture, len(futures)),
 	}
 	for _, entry := range futures {
 		// 多个记录，只有后面的一个生效
@@ -337,58 +351,75 @@ func (ctrl *InstanceCtrl) heartbeatHandler(futures []*InstanceFuture) error {
 			delete(values, id)
 		}
 		ids[id] = false
-		statusToIds[entry.healthy][id] = entry.lastHeartbeatTimeSec
+		statusToIds[entry.healthy][id] = entry
 	}
 
-	// 转为不健康的实例，需要添加 metadata
-	appendMetaReqs := make([]*store.InstanceMetadataRequest, 0, len(statusToIds[false]))
-	// 转为健康的实例，需要删除 metadata
-	removeMetaReqs := make([]*store.InstanceMetadataRequest, 0, len(statusToIds[true]))
+	appendMetaReqs := make([]*store.InstanceMetadataRequest, 0, len(ids))
+	removeMetaReqs := make([]*store.InstanceMetadataRequest, 0, len(ids))
 	revision := utils.NewUUID()
 	for healthy, values := range statusToIds {
 		if len(values) == 0 {
 			continue
 		}
 		idValues := make([]interface{}, 0, len(values))
-		for id := range values {
-			if healthy {
-				removeMetaReqs = append(removeMetaReqs, &store.InstanceMetadataRequest{
-					InstanceID: id,
-					Revision:   revision,
-					Keys:       []string{svctypes.MetadataInstanceLastHeartbeatTime},
-				})
-			} else {
-				appendMetaReqs = append(appendMetaReqs, &store.InstanceMetadataRequest{
-					InstanceID: id,
-					Revision:   revision,
-					Metadata: map[string]string{
-						svctypes.MetadataInstanceLastHeartbeatTime: strconv.FormatInt(values[id], 10),
-					},
-				})
+		for id, entry := range values {
+			appendReq, removeReq := HealthMetadataRequests(id, revision, healthy,
+				entry.lastHeartbeatTimeSec, entry.flapCount)
+			if appendReq != nil {
+				appendMetaReqs = append(appendMetaReqs, appendReq)
+			}
+			if removeReq != nil {
+				removeMetaReqs = append(removeMetaReqs, removeReq)
 			}
 			idValues = append(idValues, id)
 		}
-		err := ctrl.storage.BatchSetInstanceHealthStatus(idValues, utils.StatusBoolToInt(healthy), utils.NewUUID())
+		err := ctrl.storage.BatchSetInstanceHealthStatus(ctx, idValues, utils.StatusBoolToInt(healthy), utils.NewUUID())
 		if err != nil {
 			log.Errorf("[Batch] batch healthy check instances err: %s", err.Error())
 			sendReply(futures, storeapi.StoreCode2APICode(err), err)
 			return err
 		}
-		if err := ctrl.storage.BatchAppendInstanceMetadata(appendMetaReqs); err != nil {
-			log.Errorf("[Batch] batch healthy check instances append metadata err: %s", err.Error())
-			sendReply(futures, storeapi.StoreCode2APICode(err), err)
-			return err
-		}
-		if err := ctrl.storage.BatchRemoveInstanceMetadata(removeMetaReqs); err != nil {
-			log.Errorf("[Batch] batch healthy check instances remove metadata err: %s", err.Error())
-			sendReply(futures, storeapi.StoreCode2APICode(err), err)
-			return err
-		}
+	}
+	if err := ctrl.storage.BatchAppendInstanceMetadata(ctx, appendMetaReqs); err != nil {
+		log.Errorf("[Batch] batch healthy check instances append metadata err: %s", err.Error())
+		sendReply(futures, storeapi.StoreCode2APICode(err), err)
+		return err
+	}
+	if err := ctrl.storage.BatchRemoveInstanceMetadata(ctx, removeMetaReqs); err != nil {
+		log.Errorf("[Batch] batch healthy check instances remove metadata err: %s", err.Error())
+		sendReply(futures, storeapi.StoreCode2APICode(err), err)
+		return err
 	}
 	sendReply(futures, apimodel.Code_ExecuteSuccess, nil)
 	return nil
 }
 
+// HealthMetadataRequests 构造实例健康状态变化时需要修改的 metadata 请求，转为不健康的实例记录最后一次心跳时间，
+// 转为健康的实例删除该记录；抖动次数大于 0 时写入，否则删除，保证每个节点都能看到最新的抖动次数
+func HealthMetadataRequests(id, revision string, healthy bool, lastBeatTime int64,
+	flapCount uint32) (*store.InstanceMetadataRequest, *store.InstanceMetadataRequest) {
+	appendMeta := map[string]string{}
+	removeKeys := make([]string, 0, 2)
+	if healthy {
+		removeKeys = append(removeKeys, svctypes.MetadataInstanceLastHeartbeatTime)
+	} else {
+		appendMeta[svctypes.MetadataInstanceLastHeartbeatTime] = strconv.FormatInt(lastBeatTime, 10)
+	}
+	if flapCount > 0 {
+		appendMeta[svctypes.MetadataInstanceFlapCount] = strconv.FormatUint(uint64(flapCount), 10)
+	} else {
+		removeKeys = append(removeKeys, svctypes.MetadataInstanceFlapCount)
+	}
+	var appendReq, removeReq *store.InstanceMetadataRequest
+	if len(appendMeta) > 0 {
+		appendReq = &store.InstanceMetadataRequest{InstanceID: id, Revision: revision, Metadata: appendMeta}
+	}
+	if len(removeKeys) > 0 {
+		removeReq = &store.InstanceMetadataRequest{InstanceID: id, Revision: revision, Keys: removeKeys}
+	}
+	return appendReq, removeReq
+}
+
 // deregisterHandler 反注册处理函数
 // 步骤：
 //   - 从数据库中批量读取实例ID对应的实例简要信息：
@@ -397,7 +428,7 @@ func (ctrl *InstanceCtrl) heartbeatHandler(futures []*InstanceFuture) error {
 //   - 对于不存在的token，返回notFoundResource
 //   - 对于token校验失败的，返回校验失败
 //   - 调用批量接口删除实例
-func (ctrl *InstanceCtrl) deregisterHandler(futures []*InstanceFuture) error {
+func (ctrl *InstanceCtrl) deregisterHandler(ctx context.Context, futures []*InstanceFuture) error {
 	if len(futures) == 0 {
 		return nil
 	}
@@ -417,7 +448,7 @@ func (ctrl *InstanceCtrl) deregisterHandler(futures []*InstanceFuture) error {
 	}
 
 	// 统一鉴权与判断是否存在
-	instances, err := ctrl.storage.GetInstancesBrief(ids)
+	instances, err := ctrl.storage.GetInstancesBrief(ctx, ids)
 	if err != nil {
 		log.Errorf("[Batch] get instances service token err: %s", err.Error())
 		sendReply(remains, storeapi.StoreCode2APICode(err), err)
@@ -445,7 +476,7 @@ func (ctrl *InstanceCtrl) deregisterHandler(futures []*InstanceFuture) error {
 	for _, entry := range remains {
 		args = append(args, entry.request.GetId().GetValue())
 	}
-	if err := ctrl.storage.BatchDeleteInstances(args); err != nil {
+	if err := ctrl.storage.BatchDeleteInstances(ctx, args); err != nil {
 		log.Errorf("[Batch] batch delete instances err: %s", err.Error())
 		sendReply(remains, storeapi.StoreCode2APICode(err), err)
 		return err
@@ -456,7 +487,7 @@ func (ctrl *InstanceCtrl) deregisterHandler(futures []*InstanceFuture) error {
 }
 
 // batchRestoreInstanceIsolate 批量恢复实例的隔离状态，以请求为准，请求如果不存在，就以数据库为准
-func (ctrl *InstanceCtrl) batchRestoreInstanceIsolate(futures map[string]*InstanceFuture) error {
+func (ctrl *InstanceCtrl) batchRestoreInstanceIsolate(ctx context.Context, futures map[string]*InstanceFuture) error {
 	if len(futures) == 0 {
 		return nil
 	}
@@ -468,7 +499,7 @@ func (ctrl *InstanceCtrl) batchRestoreInstanceIsolate(futures map[string]*Instan
 	}
 	var id2Isolate map[string]bool
 	var err error
-	if id2Isolate, err = ctrl.storage.BatchGetInstanceIsolate(ids); err != nil {
+	if id2Isolate, err = ctrl.storage.BatchGetInstanceIsolate(ctx, ids); err != nil {
 		log.Errorf("[Batch] check instances existed storage err: %s", err.Error())
 		sendReply(futures, storeapi.StoreCode2APICode(err), err)
 		return err
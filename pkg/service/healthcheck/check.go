@@ -36,6 +36,7 @@ import (
 	"github.com/pole-io/pole-server/pkg/common/syncs/srand"
 	"github.com/pole-io/pole-server/pkg/common/syncs/timewheel"
 	"github.com/pole-io/pole-server/pkg/common/utils"
+	"github.com/pole-io/pole-server/pkg/service/batch"
 )
 
 const (
@@ -181,7 +182,7 @@ func (c *CheckScheduler) upsertInstanceChecker(instanceWithChecker *InstanceWith
 	c.rwMutex.Lock()
 	defer c.rwMutex.Unlock()
 	instance := instanceWithChecker.instance
-	ttl := instance.HealthCheck().GetHeartbeat().GetTtl().GetValue()
+	ttl := c.svr.instanceTtl(instance)
 	var (
 		instValue *itemValue
 		exist     bool
@@ -195,7 +196,7 @@ func (c *CheckScheduler) upsertInstanceChecker(instanceWithChecker *InstanceWith
 		instValue.mutex.Lock()
 		oldTtl := instValue.ttlDurationSec
 		instValue.checker = instanceWithChecker.checker
-		instValue.expireDurationSec = getExpireDurationSec(instance.Proto)
+		instValue.expireDurationSec = expireTtlCount * ttl
 		instValue.ttlDurationSec = ttl
 		instValue.mutex.Unlock()
 		if log.DebugEnabled() {
@@ -208,7 +209,7 @@ func (c *CheckScheduler) upsertInstanceChecker(instanceWithChecker *InstanceWith
 			host:              instance.Host(),
 			port:              instance.Port(),
 			id:                instance.ID(),
-			expireDurationSec: getExpireDurationSec(instance.Proto),
+			expireDurationSec: expireTtlCount * ttl,
 			checker:           instanceWithChecker.checker,
 			ttlDurationSec:    ttl,
 		}
@@ -250,6 +251,12 @@ func (c *CheckScheduler) getInstanceValue(instanceId string) (*itemValue, bool)
 	return value, ok
 }
 
+func (c *CheckScheduler) scheduledInstanceCount() int {
+	c.rwMutex.RLock()
+	defer c.rwMutex.RUnlock()
+	return len(c.scheduledInstances)
+}
+
 func (c *CheckScheduler) getClientValue(clientId string) (*clientItemValue, bool) {
 	c.rwMutex.RLock()
 	defer c.rwMutex.RUnlock()
@@ -279,11 +286,6 @@ func (c *CheckScheduler) AddClient(clientWithChecker *ClientWithChecker) {
 		client.Proto().GetId().GetValue(), client.Proto().GetHost(), 0)
 }
 
-func getExpireDurationSec(instance *apiservice.Instance) uint32 {
-	ttlValue := instance.GetHealthCheck().GetHeartbeat().GetTtl().GetValue()
-	return expireTtlCount * ttlValue
-}
-
 func getRandDelayMilli() uint32 {
 	delayMilli := srand.Intn(1000)
 	return uint32(delayMilli)
@@ -418,8 +420,27 @@ func (c *CheckScheduler) checkCallbackInstance(value interface{}) {
 			instanceValue.host, instanceValue.port, instanceValue.id, err)
 		return
 	}
-	if !checkResp.StayUnchanged {
-		code := setInsDbStatus(c.svr, cachedInstance, checkResp.Healthy, checkResp.LastHeartbeatTimeSec)
+	if checkResp.Healthy {
+		c.svr.selfPreservation.recover(instanceValue.id, c.svr.currentTimeSec())
+	}
+	if checkResp.StayUnchanged {
+		c.svr.damping.stay(instanceValue.id)
+	} else {
+		if !c.svr.damping.allow(instanceValue.id, checkResp.Healthy, c.svr.currentTimeSec()) {
+			log.Infof(
+				"[Health Check][Check]damping, delay changing instance status, id is %s, address is %s:%d, healthy is %v",
+				instanceValue.id, instanceValue.host, instanceValue.port, checkResp.Healthy)
+			return
+		}
+		if !checkResp.Healthy &&
+			c.svr.selfPreservation.preserve(instanceValue.id, cachedInstance.ServiceID, c.svr.currentTimeSec()) {
+			log.Warnf(
+				"[Health Check][Check]self preservation, keep instance healthy, id is %s, address is %s:%d",
+				instanceValue.id, instanceValue.host, instanceValue.port)
+			return
+		}
+		flapCount := c.svr.damping.nextFlapCount(instanceValue.id, c.svr.currentTimeSec())
+		code := setInsDbStatus(c.svr, cachedInstance, checkResp.Healthy, checkResp.LastHeartbeatTimeSec, flapCount)
 		if checkResp.Healthy {
 			// from unhealthy to healthy
 			log.Infof(
@@ -435,10 +456,35 @@ func (c *CheckScheduler) checkCallbackInstance(value interface{}) {
 			log.Errorf(
 				"[Health Check][Check]fail to update instance, id is %s, address is %s:%d, code is %d",
 				instanceValue.id, instanceValue.host, instanceValue.port, code)
+			return
 		}
+		c.recordTransition(cachedInstance)
 	}
 }
 
+// recordTransition 记录实例健康状态的变化，实例发生抖动时输出 DiscoverEvent，抖动次数已经随健康状态写入 metadata
+func (c *CheckScheduler) recordTransition(instance *svctypes.Instance) {
+	flapCount, flapped := c.svr.damping.transition(instance.ID(), c.svr.currentTimeSec())
+	if !flapped {
+		return
+	}
+	holdDown := c.svr.damping.holdDown(flapCount)
+	log.Warnf("[Health Check][Check]instance is flapping, id is %s, address is %s:%d, flap count is %d, hold down is %v",
+		instance.ID(), instance.Host(), instance.Port(), flapCount, holdDown)
+	c.svr.publishInstanceEvent(instance.ServiceID, &svctypes.InstanceEvent{
+		Id:         instance.ID(),
+		Namespace:  instance.Namespace(),
+		Service:    instance.Service(),
+		Instance:   instance.Proto,
+		EType:      svctypes.EventInstanceFlapping,
+		CreateTime: time.Now(),
+		MetaData: map[string]string{
+			svctypes.MetadataInstanceFlapCount: strconv.FormatUint(uint64(flapCount), 10),
+			"hold-down":                        holdDown.String(),
+		},
+	})
+}
+
 // DelClient del client from check
 func (c *CheckScheduler) DelClient(clientWithChecker *ClientWithChecker) {
 	client := clientWithChecker.client
@@ -453,6 +499,7 @@ func (c *CheckScheduler) DelInstance(instanceWithChecker *InstanceWithChecker) {
 	instance := instanceWithChecker.instance
 	instanceId := instance.ID()
 	exists := c.delInstanceIfPresent(instanceId)
+	c.svr.damping.remove(instanceId)
 	log.Infof("[Health Check][Check]remove check instance is %s:%d, id is %s, exists is %v",
 		instance.Host(), instance.Port(), instanceId, exists)
 }
@@ -516,8 +563,9 @@ func (c *CheckScheduler) doCheckClient(ctx context.Context) {
 	}
 }
 
-// setInsDbStatus 修改实例状态, 需要打印操作记录
-func setInsDbStatus(svr *Server, instance *svctypes.Instance, healthStatus bool, lastBeatTime int64) apimodel.Code {
+// setInsDbStatus 修改实例状态, 需要打印操作记录，flapCount 为实例健康状态的抖动次数，与健康状态一起写入 metadata
+func setInsDbStatus(svr *Server, instance *svctypes.Instance, healthStatus bool, lastBeatTime int64,
+	flapCount uint32) apimodel.Code {
 	id := instance.ID()
 	host := instance.Host()
 	port := instance.Port()
@@ -525,9 +573,9 @@ func setInsDbStatus(svr *Server, instance *svctypes.Instance, healthStatus bool,
 
 	var code apimodel.Code
 	if svr.bc.HeartbeatOpen() {
-		code = asyncSetInsDbStatus(svr, instance.Proto, healthStatus, lastBeatTime)
+		code = asyncSetInsDbStatus(svr, instance.Proto, healthStatus, lastBeatTime, flapCount)
 	} else {
-		code = serialSetInsDbStatus(svr, instance.Proto, healthStatus, lastBeatTime)
+		code = serialSetInsDbStatus(svr, instance.Proto, healthStatus, lastBeatTime, flapCount)
 	}
 	if code != apimodel.Code_ExecuteSuccess {
 		return code
@@ -577,8 +625,9 @@ func asyncDeleteClient(svr *Server, client *apiservice.Client) apimodel.Code {
 // 底层函数会合并delete请求，增加并发创建的吞吐
 // req 原始请求
 // ins 包含了req数据与instanceID，serviceToken
-func asyncSetInsDbStatus(svr *Server, ins *apiservice.Instance, healthStatus bool, lastBeatTime int64) apimodel.Code {
-	future := svr.bc.AsyncHeartbeat(ins, healthStatus, lastBeatTime)
+func asyncSetInsDbStatus(svr *Server, ins *apiservice.Instance, healthStatus bool, lastBeatTime int64,
+	flapCount uint32) apimodel.Code {
+	future := svr.bc.AsyncHeartbeat(ins, healthStatus, lastBeatTime, flapCount)
 	if err := future.Wait(); err != nil {
 		log.Error(err.Error())
 	}
@@ -588,40 +637,30 @@ func asyncSetInsDbStatus(svr *Server, ins *apiservice.Instance, healthStatus boo
 // serialSetInsDbStatus 同步串行创建实例
 // req为原始的请求体
 // ins包括了req的内容，并且填充了instanceID与serviceToken
-func serialSetInsDbStatus(svr *Server, ins *apiservice.Instance, healthStatus bool, lastBeatTime int64) apimodel.Code {
+func serialSetInsDbStatus(svr *Server, ins *apiservice.Instance, healthStatus bool, lastBeatTime int64,
+	flapCount uint32) apimodel.Code {
 	id := ins.GetId().GetValue()
 	if err := svr.storage.SetInstanceHealthStatus(id, utils.StatusBoolToInt(healthStatus), utils.NewUUID()); err != nil {
 		log.Errorf("[Health Check][Check]id: %s set db status err:%s", id, err)
 		return storeapi.StoreCode2APICode(err)
 	}
-	if healthStatus {
-		if err := svr.storage.BatchRemoveInstanceMetadata([]*store.InstanceMetadataRequest{
-			{
-				InstanceID: id,
-				Revision:   utils.NewUUID(),
-				Keys:       []string{svctypes.MetadataInstanceLastHeartbeatTime},
-			},
-		}); err != nil {
-			log.Errorf("[Batch] batch healthy check instances remove metadata err: %s", err.Error())
+	appendReq, removeReq := batch.HealthMetadataRequests(id, utils.NewUUID(), healthStatus, lastBeatTime, flapCount)
+	if appendReq != nil {
+		if err := svr.storage.BatchAppendInstanceMetadata(context.Background(), []*store.InstanceMetadataRequest{appendReq}); err != nil {
+			log.Errorf("[Batch] batch healthy check instances append metadata err: %s", err.Error())
 			return storeapi.StoreCode2APICode(err)
 		}
-	} else {
-		if err := svr.storage.BatchAppendInstanceMetadata([]*store.InstanceMetadataRequest{
-			{
-				InstanceID: id,
-				Revision:   utils.NewUUID(),
-				Metadata: map[string]string{
-					svctypes.MetadataInstanceLastHeartbeatTime: strconv.FormatInt(lastBeatTime, 10),
-				},
-			},
-		}); err != nil {
-			log.Errorf("[Batch] batch healthy check instances append metadata err: %s", err.Error())
+	}
+	if removeReq != nil {
+		if err := svr.storage.BatchRemoveInstanceMetadata(context.Background(), []*store.InstanceMetadataRequest{removeReq}); err != nil {
+			log.Errorf("[Batch] batch healthy check instances remove metadata err: %s", err.Error())
 			return storeapi.StoreCode2APICode(err)
 		}
 	}
 	return apimodel.Code_ExecuteSuccess
 }
 
-func SerialSetInsDbStatus(svr *Server, ins *apiservice.Instance, healthStatus bool, lastBeatTime int64) apimodel.Code {
-	return serialSetInsDbStatus(svr, ins, healthStatus, lastBeatTime)
+func SerialSetInsDbStatus(svr *Server, ins *apiservice.Instance, healthStatus bool, lastBeatTime int64,
+	flapCount uint32) apimodel.Code {
+	return serialSetInsDbStatus(svr, ins, healthStatus, lastBeatTime, flapCount)
 }
@@ -84,12 +84,12 @@ func Test_serialSetInsDbStatus(t *testing.T) {
 			Host:      protobuf.NewStringValue(mockHost),
 			Port:      protobuf.NewUInt32Value(uint32(mockPort)),
 			Healthy:   protobuf.NewBoolValue(true),
-		}, health, time.Now().Unix())
+		}, health, time.Now().Unix(), 0)
 
 		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), uint32(respCode), fmt.Sprintf("%d", respCode))
 
 		// 获取实例信息
-		saveIns, err := testSuit.Storage.GetInstance(instanceId)
+		saveIns, err := testSuit.Storage.GetInstance(context.Background(), instanceId)
 		if err != nil {
 			t.Fatal(err)
 		}
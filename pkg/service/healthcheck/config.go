@@ -36,6 +36,10 @@ type Config struct {
 	ClientCheckTtl      time.Duration          `yaml:"clientCheckTtl"`
 	Checkers            []apis.ConfigEntry     `yaml:"checkers"`
 	Batch               map[string]interface{} `yaml:"batch"`
+	// SelfPreservation 自我保护配置
+	SelfPreservation SelfPreservationConfig `yaml:"selfPreservation"`
+	// Damping 健康状态抖动抑制配置
+	Damping DampingConfig `yaml:"damping"`
 }
 
 const (
@@ -80,4 +84,6 @@ func (c *Config) SetDefault() {
 	if c.ClientCheckTtl == 0 {
 		c.ClientCheckTtl = defaultClientReportTtl
 	}
+	c.SelfPreservation.SetDefault()
+	c.Damping.SetDefault()
 }
@@ -0,0 +1,228 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultDampingFailureThreshold = 2
+	defaultDampingSuccessThreshold = 2
+	defaultDampingFlapWindow       = 5 * time.Minute
+	defaultDampingHoldDown         = 30 * time.Second
+	defaultDampingMaxHoldDown      = 10 * time.Minute
+)
+
+// DampingConfig 健康状态抖动抑制配置，避免实例在健康与不健康之间反复切换导致持续推送
+type DampingConfig struct {
+	Open bool `yaml:"open"`
+	// FailureThreshold 连续检测为不健康的次数达到该值时，才将实例标记为不健康
+	FailureThreshold int `yaml:"failureThreshold"`
+	// SuccessThreshold 连续检测为健康的次数达到该值时，才将实例恢复为健康
+	SuccessThreshold int `yaml:"successThreshold"`
+	// FlapWindow 与上一次状态变化的间隔小于该值时记为一次抖动，超过该时间没有状态变化则清空抖动次数
+	FlapWindow time.Duration `yaml:"flapWindow"`
+	// HoldDown 抖动实例恢复为健康前需要等待的基础时间，每多抖动一次翻倍
+	HoldDown time.Duration `yaml:"holdDown"`
+	// MaxHoldDown 等待时间的上限
+	MaxHoldDown time.Duration `yaml:"maxHoldDown"`
+}
+
+// SetDefault 设置默认值
+func (c *DampingConfig) SetDefault() {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = defaultDampingFailureThreshold
+	}
+	if c.SuccessThreshold <= 0 {
+		c.SuccessThreshold = defaultDampingSuccessThreshold
+	}
+	if c.FlapWindow <= 0 {
+		c.FlapWindow = defaultDampingFlapWindow
+	}
+	if c.HoldDown <= 0 {
+		c.HoldDown = defaultDampingHoldDown
+	}
+	if c.MaxHoldDown < c.HoldDown {
+		c.MaxHoldDown = defaultDampingMaxHoldDown
+		if c.MaxHoldDown < c.HoldDown {
+			c.MaxHoldDown = c.HoldDown
+		}
+	}
+}
+
+type dampingState struct {
+	// failures 连续检测为不健康的次数
+	failures int
+	// successes 连续检测为健康的次数
+	successes int
+	// flapCount 抖动次数
+	flapCount uint32
+	// lastTransitionSec 上一次状态变化的时间
+	lastTransitionSec int64
+}
+
+// flapDamping 记录实例的连续检测结果以及抖动次数，决定是否真正修改实例的健康状态
+type flapDamping struct {
+	cfg *DampingConfig
+
+	lock   sync.Mutex
+	states map[string]*dampingState
+}
+
+func newFlapDamping(cfg *DampingConfig) *flapDamping {
+	return &flapDamping{
+		cfg:    cfg,
+		states: map[string]*dampingState{},
+	}
+}
+
+// allow 检测结果与实例当前状态不一致时调用，返回 true 表示可以修改实例的健康状态
+func (d *flapDamping) allow(instanceID string, healthy bool, nowSec int64) bool {
+	if !d.cfg.Open {
+		return true
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	state, ok := d.states[instanceID]
+	if !ok {
+		state = &dampingState{}
+		d.states[instanceID] = state
+	}
+	d.decay(state, nowSec)
+	var allowed bool
+	if healthy {
+		state.failures = 0
+		state.successes++
+		allowed = state.successes >= d.cfg.SuccessThreshold &&
+			nowSec >= state.lastTransitionSec+int64(d.holdDown(state.flapCount)/time.Second)
+	} else {
+		state.successes = 0
+		state.failures++
+		allowed = state.failures >= d.cfg.FailureThreshold
+	}
+	if !allowed {
+		healthStatusDamped.Inc()
+	}
+	return allowed
+}
+
+// stay 检测结果与实例当前状态一致时调用，清空连续检测的计数
+func (d *flapDamping) stay(instanceID string) {
+	if !d.cfg.Open {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if state, ok := d.states[instanceID]; ok {
+		state.failures = 0
+		state.successes = 0
+	}
+}
+
+// nextFlapCount 实例健康状态即将发生变化时调用，返回本次变化后的抖动次数，不修改记录
+func (d *flapDamping) nextFlapCount(instanceID string, nowSec int64) uint32 {
+	if !d.cfg.Open {
+		return 0
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	state, ok := d.states[instanceID]
+	if !ok {
+		return 0
+	}
+	if state.lastTransitionSec > 0 && nowSec-state.lastTransitionSec < int64(d.cfg.FlapWindow/time.Second) {
+		return state.flapCount + 1
+	}
+	return 0
+}
+
+// transition 实例健康状态修改成功后调用，返回当前的抖动次数，以及本次状态变化是否为一次抖动
+func (d *flapDamping) transition(instanceID string, nowSec int64) (uint32, bool) {
+	if !d.cfg.Open {
+		return 0, false
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	state, ok := d.states[instanceID]
+	if !ok {
+		state = &dampingState{}
+		d.states[instanceID] = state
+	}
+	state.failures = 0
+	state.successes = 0
+	flapped := state.lastTransitionSec > 0 &&
+		nowSec-state.lastTransitionSec < int64(d.cfg.FlapWindow/time.Second)
+	if flapped {
+		state.flapCount++
+		instanceFlaps.Inc()
+	} else {
+		state.flapCount = 0
+	}
+	state.lastTransitionSec = nowSec
+	return state.flapCount, flapped
+}
+
+// flapCount 获取实例当前的抖动次数
+func (d *flapDamping) flapCount(instanceID string, nowSec int64) uint32 {
+	if !d.cfg.Open {
+		return 0
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	state, ok := d.states[instanceID]
+	if !ok {
+		return 0
+	}
+	d.decay(state, nowSec)
+	return state.flapCount
+}
+
+// remove 实例不再由本节点检查时清理记录
+func (d *flapDamping) remove(instanceID string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.states, instanceID)
+}
+
+// holdDown 抖动实例恢复为健康前需要等待的时间，按抖动次数指数增长
+func (d *flapDamping) holdDown(flapCount uint32) time.Duration {
+	if flapCount == 0 {
+		return 0
+	}
+	holdDown := d.cfg.HoldDown
+	for i := uint32(1); i < flapCount && holdDown < d.cfg.MaxHoldDown; i++ {
+		holdDown *= 2
+	}
+	if holdDown > d.cfg.MaxHoldDown {
+		holdDown = d.cfg.MaxHoldDown
+	}
+	return holdDown
+}
+
+// decay 超过抖动窗口没有状态变化时，清空抖动次数，调用方需要持有锁
+func (d *flapDamping) decay(state *dampingState, nowSec int64) {
+	if state.flapCount == 0 {
+		return
+	}
+	quiet := int64((d.cfg.FlapWindow + d.holdDown(state.flapCount)) / time.Second)
+	if nowSec-state.lastTransitionSec >= quiet {
+		state.flapCount = 0
+	}
+}
@@ -0,0 +1,92 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFlapDamping() *flapDamping {
+	cfg := &DampingConfig{
+		Open:        true,
+		FlapWindow:  time.Minute,
+		HoldDown:    10 * time.Second,
+		MaxHoldDown: 30 * time.Second,
+	}
+	cfg.SetDefault()
+	return newFlapDamping(cfg)
+}
+
+func TestFlapDamping_ConsecutiveThreshold(t *testing.T) {
+	d := newTestFlapDamping()
+	now := time.Now().Unix()
+
+	// 连续两次检测为不健康才修改状态
+	assert.False(t, d.allow("a", false, now))
+	assert.True(t, d.allow("a", false, now+1))
+
+	// 中间出现一次与当前状态一致的检测结果，需要重新计数
+	assert.False(t, d.allow("b", false, now))
+	d.stay("b")
+	assert.False(t, d.allow("b", false, now+1))
+	assert.True(t, d.allow("b", false, now+2))
+}
+
+func TestFlapDamping_HoldDown(t *testing.T) {
+	d := newTestFlapDamping()
+	now := time.Now().Unix()
+
+	// 首次状态变化不算抖动
+	count, flapped := d.transition("a", now)
+	assert.False(t, flapped)
+	assert.Equal(t, uint32(0), count)
+
+	// 窗口内再次变化，记为一次抖动，恢复健康需要等待 10s
+	assert.Equal(t, uint32(1), d.nextFlapCount("a", now+5))
+	count, flapped = d.transition("a", now+5)
+	assert.True(t, flapped)
+	assert.Equal(t, uint32(1), count)
+	assert.False(t, d.allow("a", true, now+6))
+	assert.False(t, d.allow("a", true, now+10))
+	assert.True(t, d.allow("a", true, now+15))
+
+	// 继续抖动，等待时间指数增长并受上限约束
+	_, _ = d.transition("a", now+15)
+	count, _ = d.transition("a", now+20)
+	assert.Equal(t, uint32(3), count)
+	assert.Equal(t, 30*time.Second, d.holdDown(count))
+	assert.Equal(t, uint32(3), d.flapCount("a", now+20))
+	assert.Equal(t, 20*time.Second, d.holdDown(2))
+
+	// 超过抖动窗口没有状态变化，抖动次数清零
+	assert.Equal(t, uint32(0), d.flapCount("a", now+20+90))
+	assert.Equal(t, uint32(0), d.nextFlapCount("a", now+20+90))
+	d.remove("a")
+	assert.Equal(t, uint32(0), d.flapCount("a", now))
+}
+
+func TestFlapDamping_Closed(t *testing.T) {
+	d := newFlapDamping(&DampingConfig{})
+	assert.True(t, d.allow("a", false, 0))
+	_, flapped := d.transition("a", 1)
+	assert.False(t, flapped)
+	assert.Equal(t, uint32(0), d.flapCount("a", 1))
+}
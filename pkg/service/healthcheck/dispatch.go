@@ -44,8 +44,10 @@ type Dispatcher struct {
 	healthCheckInstancesChanged uint32
 	healthCheckClientsChanged   uint32
 	selfServiceInstancesChanged uint32
-	managedInstances            map[string]*InstanceWithChecker
-	managedClients              map[string]*ClientWithChecker
+	// selfContinuumLoaded 是否已经根据 pole.checker 实例构建出可用的一致性哈希环
+	selfContinuumLoaded uint32
+	managedInstances    map[string]*InstanceWithChecker
+	managedClients      map[string]*ClientWithChecker
 
 	selfServiceBuckets map[commonhash.Bucket]bool
 	continuum          *commonhash.Continuum
@@ -128,6 +130,7 @@ func (d *Dispatcher) reloadSelfContinuum() bool {
 	})
 	if len(nextBuckets) == 0 {
 		d.noAvailableServers = true
+		atomic.StoreUint32(&d.selfContinuumLoaded, 0)
 	}
 	originBucket := d.selfServiceBuckets
 	log.Debugf("[Health Check][Dispatcher]reload continuum by %v, origin is %v", nextBuckets, originBucket)
@@ -143,9 +146,17 @@ func (d *Dispatcher) reloadSelfContinuum() bool {
 	}
 	d.selfServiceBuckets = nextBuckets
 	d.continuum = commonhash.New(d.selfServiceBuckets)
+	if len(nextBuckets) > 0 {
+		atomic.StoreUint32(&d.selfContinuumLoaded, 1)
+	}
 	return true
 }
 
+// continuumLoaded 一致性哈希环是否已经加载
+func (d *Dispatcher) continuumLoaded() bool {
+	return atomic.LoadUint32(&d.selfContinuumLoaded) == 1
+}
+
 func (d *Dispatcher) reloadManagedClients() {
 	nextClients := make(map[string]*ClientWithChecker)
 
@@ -122,7 +122,7 @@ func (handler *LeaderChangeEventHandler) doCheckSelfServiceInstance(cachedInstan
 			Healthy:    cachedInstance.Healthy(),
 		},
 		CurTimeSec:        handler.svr.currentTimeSec,
-		ExpireDurationSec: getExpireDurationSec(cachedInstance.Proto),
+		ExpireDurationSec: expireTtlCount * handler.svr.instanceTtl(cachedInstance),
 	}
 	checkResp, err := checker.Check(request)
 	if err != nil {
@@ -131,7 +131,7 @@ func (handler *LeaderChangeEventHandler) doCheckSelfServiceInstance(cachedInstan
 		return
 	}
 	if !checkResp.StayUnchanged {
-		code := setInsDbStatus(handler.svr, cachedInstance, checkResp.Healthy, checkResp.LastHeartbeatTimeSec)
+		code := setInsDbStatus(handler.svr, cachedInstance, checkResp.Healthy, checkResp.LastHeartbeatTimeSec, 0)
 		if checkResp.Healthy {
 			// from unhealthy to healthy
 			log.Infof(
@@ -0,0 +1,72 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	metricstypes "github.com/pole-io/pole-server/apis/pkg/types/metrics"
+	"github.com/pole-io/pole-server/pkg/common/metrics"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+var (
+	selfPreservationActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "health_check_self_preservation",
+		Help: "whether the health check is in global self preservation, 1 means true",
+		ConstLabels: map[string]string{
+			metricstypes.LabelServerNode: utils.LocalHost,
+		},
+	})
+	selfPreservationServices = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "health_check_self_preservation_services",
+		Help: "count of services in self preservation",
+		ConstLabels: map[string]string{
+			metricstypes.LabelServerNode: utils.LocalHost,
+		},
+	})
+	selfPreservationPreserved = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "health_check_self_preservation_preserved_total",
+		Help: "total times of instances kept healthy by self preservation",
+		ConstLabels: map[string]string{
+			metricstypes.LabelServerNode: utils.LocalHost,
+		},
+	})
+	healthStatusDamped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "health_check_status_damped_total",
+		Help: "total times of instance health status changes suppressed by damping",
+		ConstLabels: map[string]string{
+			metricstypes.LabelServerNode: utils.LocalHost,
+		},
+	})
+	instanceFlaps = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "health_check_instance_flap_total",
+		Help: "total times of instances flapping between healthy and unhealthy",
+		ConstLabels: map[string]string{
+			metricstypes.LabelServerNode: utils.LocalHost,
+		},
+	})
+)
+
+func registerMetrics() {
+	_ = metrics.GetRegistry().Register(selfPreservationActive)
+	_ = metrics.GetRegistry().Register(selfPreservationServices)
+	_ = metrics.GetRegistry().Register(selfPreservationPreserved)
+	_ = metrics.GetRegistry().Register(healthStatusDamped)
+	_ = metrics.GetRegistry().Register(instanceFlaps)
+}
@@ -0,0 +1,327 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/admin"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+const (
+	defaultPreservationWindow           = time.Minute
+	defaultPreservationThreshold        = 0.3
+	defaultPreservationServiceThreshold = 0.5
+	defaultPreservationMinInstances     = 10
+
+	// preservationSettingPrefix 各节点在 server_setting 中记录自我保护状态的名称前缀, 后缀为节点地址
+	preservationSettingPrefix = "healthcheck.preservation."
+	// preservationReportInterval 处于自我保护的节点上报状态的周期
+	preservationReportInterval = 5 * time.Second
+	// preservationExpireTimes 超过 N 个上报周期没有更新的记录视为失效, 避免节点异常退出后一直处于自我保护
+	preservationExpireTimes = 3
+)
+
+// SelfPreservationConfig 自我保护配置，客户端与服务端之间网络抖动时，避免大批实例被同时标记为不健康甚至被删除
+type SelfPreservationConfig struct {
+	Open bool `yaml:"open"`
+	// Window 统计实例变为不健康的时间窗口
+	Window time.Duration `yaml:"window"`
+	// Threshold 时间窗口内变为不健康的实例占本节点负责检查的实例比例超过该值时，进入全局自我保护
+	Threshold float64 `yaml:"threshold"`
+	// ServiceThreshold 时间窗口内单个服务变为不健康的实例占比超过该值时，该服务进入自我保护
+	ServiceThreshold float64 `yaml:"serviceThreshold"`
+	// MinInstances 实例数少于该值时不参与自我保护的判断，避免小规模场景下误触发
+	MinInstances int `yaml:"minInstances"`
+}
+
+// SetDefault 设置默认值
+func (c *SelfPreservationConfig) SetDefault() {
+	if c.Window <= 0 {
+		c.Window = defaultPreservationWindow
+	}
+	if c.Threshold <= 0 || c.Threshold > 1 {
+		c.Threshold = defaultPreservationThreshold
+	}
+	if c.ServiceThreshold <= 0 || c.ServiceThreshold > 1 {
+		c.ServiceThreshold = defaultPreservationServiceThreshold
+	}
+	if c.MinInstances <= 0 {
+		c.MinInstances = defaultPreservationMinInstances
+	}
+}
+
+type pendingUnhealthy struct {
+	serviceID string
+	timeSec   int64
+}
+
+// selfPreservation 统计时间窗口内变为不健康的实例，超过阈值时停止修改实例的健康状态，直到心跳恢复
+type selfPreservation struct {
+	cfg *SelfPreservationConfig
+	// total 本节点负责检查的实例数
+	total func() int
+	// serviceTotal 服务的实例总数
+	serviceTotal func(serviceID string) int
+
+	lock      sync.Mutex
+	pending   map[string]*pendingUnhealthy
+	global    bool
+	services  map[string]struct{}
+	since     time.Time
+	preserved uint64
+}
+
+func newSelfPreservation(cfg *SelfPreservationConfig, total func() int,
+	serviceTotal func(serviceID string) int) *selfPreservation {
+	return &selfPreservation{
+		cfg:          cfg,
+		total:        total,
+		serviceTotal: serviceTotal,
+		pending:      map[string]*pendingUnhealthy{},
+		services:     map[string]struct{}{},
+	}
+}
+
+// preserve 实例检测为不健康时调用，返回 true 表示处于自我保护，不应修改实例的健康状态
+func (p *selfPreservation) preserve(instanceID, serviceID string, nowSec int64) bool {
+	if !p.cfg.Open {
+		return false
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.pending[instanceID] = &pendingUnhealthy{serviceID: serviceID, timeSec: nowSec}
+	p.evaluate(nowSec)
+	_, ok := p.services[serviceID]
+	if p.global || ok {
+		p.preserved++
+		selfPreservationPreserved.Inc()
+		return true
+	}
+	return false
+}
+
+// recover 实例心跳恢复正常时调用
+func (p *selfPreservation) recover(instanceID string, nowSec int64) {
+	if !p.cfg.Open {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if _, ok := p.pending[instanceID]; !ok {
+		return
+	}
+	delete(p.pending, instanceID)
+	p.evaluate(nowSec)
+}
+
+// active 是否存在全局或者服务维度的自我保护
+func (p *selfPreservation) active(nowSec int64) bool {
+	if !p.cfg.Open {
+		return false
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.evaluate(nowSec)
+	return p.global || len(p.services) > 0
+}
+
+func (p *selfPreservation) status(nowSec int64) *admin.SelfPreservationStatus {
+	ret := &admin.SelfPreservationStatus{Open: p.cfg.Open, Services: []string{}}
+	if !p.cfg.Open {
+		return ret
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.evaluate(nowSec)
+	ret.Global = p.global
+	for id := range p.services {
+		ret.Services = append(ret.Services, id)
+	}
+	sort.Strings(ret.Services)
+	if ret.Global || len(ret.Services) > 0 {
+		since := p.since
+		ret.Since = &since
+	}
+	ret.PendingUnhealthy = len(p.pending)
+	ret.Preserved = p.preserved
+	return ret
+}
+
+// evaluate 清理时间窗口外的记录，并重新计算全局以及各个服务的自我保护状态，调用方需要持有锁
+func (p *selfPreservation) evaluate(nowSec int64) {
+	windowSec := int64(p.cfg.Window / time.Second)
+	serviceCounts := map[string]int{}
+	for id, item := range p.pending {
+		if nowSec-item.timeSec > windowSec {
+			delete(p.pending, id)
+			continue
+		}
+		serviceCounts[item.serviceID]++
+	}
+
+	wasActive := p.global || len(p.services) > 0
+	total := p.total()
+	p.global = total >= p.cfg.MinInstances && float64(len(p.pending)) > float64(total)*p.cfg.Threshold
+
+	services := map[string]struct{}{}
+	for serviceID, count := range serviceCounts {
+		serviceTotal := p.serviceTotal(serviceID)
+		if serviceTotal >= p.cfg.MinInstances && float64(count) > float64(serviceTotal)*p.cfg.ServiceThreshold {
+			services[serviceID] = struct{}{}
+		}
+	}
+	p.services = services
+
+	isActive := p.global || len(p.services) > 0
+	switch {
+	case isActive && !wasActive:
+		p.since = time.Now()
+		log.Warn("[Health Check][SelfPreservation] enter self preservation, stop turning instances unhealthy",
+			zap.Bool("global", p.global), zap.Int("services", len(p.services)),
+			zap.Int("pending", len(p.pending)), zap.Int("total", total))
+	case !isActive && wasActive:
+		log.Info("[Health Check][SelfPreservation] exit self preservation, heartbeats recovered",
+			zap.Duration("duration", time.Since(p.since)))
+	}
+	if p.global {
+		selfPreservationActive.Set(1)
+	} else {
+		selfPreservationActive.Set(0)
+	}
+	selfPreservationServices.Set(float64(len(p.services)))
+}
+
+// preservationStore 记录各节点自我保护状态的存储
+type preservationStore interface {
+	UpsertServerSetting(setting *admin.ServerSetting) error
+	GetServerSettingsByPrefix(prefix string) ([]*admin.ServerSetting, error)
+}
+
+// preservationRecord 节点记录在 server_setting 中的自我保护状态
+type preservationRecord struct {
+	Host     string   `json:"host"`
+	Global   bool     `json:"global"`
+	Services []string `json:"services"`
+}
+
+func (r *preservationRecord) active() bool {
+	return r.Global || len(r.Services) > 0
+}
+
+// clusterPreservation 每个节点只统计自己负责检查的实例, 通过存储共享各个节点的自我保护状态,
+// 使得删除不健康实例等集群级别的任务在任意节点处于自我保护时都能感知到
+type clusterPreservation struct {
+	storage   preservationStore
+	localHost string
+	local     *selfPreservation
+	// nowSec 与存储的时间对齐后的当前时间
+	nowSec func() int64
+	// reported 最近一次上报的是否为自我保护状态, 退出自我保护时需要再上报一次
+	reported bool
+}
+
+func newClusterPreservation(storage preservationStore, localHost string, local *selfPreservation,
+	nowSec func() int64) *clusterPreservation {
+	return &clusterPreservation{
+		storage:   storage,
+		localHost: localHost,
+		local:     local,
+		nowSec:    nowSec,
+	}
+}
+
+func (c *clusterPreservation) run(ctx context.Context) {
+	if !c.local.cfg.Open {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(preservationReportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.report()
+			}
+		}
+	}()
+}
+
+// report 处于自我保护期间周期性刷新记录, 未处于自我保护时只在退出时更新一次
+func (c *clusterPreservation) report() {
+	status := c.local.status(c.nowSec())
+	record := &preservationRecord{
+		Host:     c.localHost,
+		Global:   status.Global,
+		Services: status.Services,
+	}
+	if !record.active() && !c.reported {
+		return
+	}
+	rule, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	if err := c.storage.UpsertServerSetting(&admin.ServerSetting{
+		ID:   utils.NewUUID(),
+		Name: preservationSettingPrefix + c.localHost,
+		Rule: string(rule),
+	}); err != nil {
+		log.Error("[Health Check][SelfPreservation] report self preservation status", zap.Error(err))
+		return
+	}
+	c.reported = record.active()
+}
+
+// peers 获取其他处于自我保护的节点
+func (c *clusterPreservation) peers() ([]string, error) {
+	settings, err := c.storage.GetServerSettingsByPrefix(preservationSettingPrefix)
+	if err != nil {
+		return nil, err
+	}
+	expireSec := int64(preservationExpireTimes * preservationReportInterval / time.Second)
+	nowSec := c.nowSec()
+	hosts := make([]string, 0, len(settings))
+	for _, setting := range settings {
+		host := strings.TrimPrefix(setting.Name, preservationSettingPrefix)
+		if host == c.localHost || nowSec-setting.ModifyTime.Unix() > expireSec {
+			continue
+		}
+		record := &preservationRecord{}
+		if err := json.Unmarshal([]byte(setting.Rule), record); err != nil {
+			log.Warn("[Health Check][SelfPreservation] invalid self preservation record",
+				zap.String("name", setting.Name), zap.Error(err))
+			continue
+		}
+		if record.active() {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts, nil
+}
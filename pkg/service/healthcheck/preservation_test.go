@@ -0,0 +1,181 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/admin"
+)
+
+func newTestSelfPreservation(total int, serviceTotal map[string]int) *selfPreservation {
+	cfg := &SelfPreservationConfig{Open: true}
+	cfg.SetDefault()
+	return newSelfPreservation(cfg, func() int {
+		return total
+	}, func(serviceID string) int {
+		return serviceTotal[serviceID]
+	})
+}
+
+func TestSelfPreservation_Global(t *testing.T) {
+	p := newTestSelfPreservation(20, map[string]int{})
+	now := time.Now().Unix()
+
+	// 20 个实例中 6 个变为不健康，未超过 30%，正常修改健康状态
+	for i := 0; i < 6; i++ {
+		assert.False(t, p.preserve(string(rune('a'+i)), "svc", now))
+	}
+	assert.False(t, p.active(now))
+
+	// 第 7 个超过阈值，进入自我保护
+	assert.True(t, p.preserve("g", "svc", now))
+	assert.True(t, p.active(now))
+	status := p.status(now)
+	assert.True(t, status.Global)
+	assert.NotNil(t, status.Since)
+	assert.Equal(t, 7, status.PendingUnhealthy)
+
+	// 心跳恢复后退出自我保护
+	p.recover("a", now)
+	assert.False(t, p.active(now))
+}
+
+func TestSelfPreservation_Service(t *testing.T) {
+	p := newTestSelfPreservation(100, map[string]int{"svc-1": 10, "svc-2": 4})
+	now := time.Now().Unix()
+
+	for i := 0; i < 5; i++ {
+		assert.False(t, p.preserve(string(rune('a'+i)), "svc-1", now))
+	}
+	// 超过服务维度的阈值，只保护该服务
+	assert.True(t, p.preserve("f", "svc-1", now))
+	assert.False(t, p.preserve("x", "svc-2", now))
+	status := p.status(now)
+	assert.False(t, status.Global)
+	assert.Equal(t, []string{"svc-1"}, status.Services)
+
+	// 服务实例数少于 minInstances，不参与保护
+	for i := 0; i < 4; i++ {
+		assert.False(t, p.preserve(string(rune('p'+i)), "svc-2", now))
+	}
+}
+
+func TestSelfPreservation_WindowExpire(t *testing.T) {
+	p := newTestSelfPreservation(10, map[string]int{})
+	now := time.Now().Unix()
+	for i := 0; i < 4; i++ {
+		p.preserve(string(rune('a'+i)), "svc", now)
+	}
+	assert.True(t, p.active(now))
+
+	// 超过统计窗口且没有新的不健康实例，退出自我保护
+	later := now + int64(p.cfg.Window/time.Second) + 1
+	assert.False(t, p.active(later))
+	assert.Equal(t, 0, p.status(later).PendingUnhealthy)
+}
+
+func TestSelfPreservation_Close(t *testing.T) {
+	p := newTestSelfPreservation(10, map[string]int{})
+	p.cfg.Open = false
+	now := time.Now().Unix()
+	for i := 0; i < 10; i++ {
+		assert.False(t, p.preserve(string(rune('a'+i)), "svc", now))
+	}
+	assert.False(t, p.active(now))
+	assert.False(t, p.status(now).Open)
+}
+
+// memoryPreservationStore 基于内存 map 模拟 server_setting
+type memoryPreservationStore struct {
+	settings map[string]*admin.ServerSetting
+	nowSec   int64
+	err      error
+	upserts  int
+}
+
+func (m *memoryPreservationStore) UpsertServerSetting(setting *admin.ServerSetting) error {
+	m.upserts++
+	saved := *setting
+	saved.ModifyTime = time.Unix(m.nowSec, 0)
+	m.settings[setting.Name] = &saved
+	return nil
+}
+
+func (m *memoryPreservationStore) GetServerSettingsByPrefix(prefix string) ([]*admin.ServerSetting, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	ret := make([]*admin.ServerSetting, 0, len(m.settings))
+	for _, setting := range m.settings {
+		ret = append(ret, setting)
+	}
+	return ret, nil
+}
+
+func TestClusterPreservation(t *testing.T) {
+	now := time.Now().Unix()
+	storage := &memoryPreservationStore{settings: map[string]*admin.ServerSetting{}, nowSec: now}
+	nowSec := func() int64 { return storage.nowSec }
+
+	p1 := newTestSelfPreservation(20, map[string]int{})
+	p2 := newTestSelfPreservation(20, map[string]int{})
+	node1 := newClusterPreservation(storage, "10.0.0.1", p1, nowSec)
+	node2 := newClusterPreservation(storage, "10.0.0.2", p2, nowSec)
+
+	// 未处于自我保护时不写入记录
+	node1.report()
+	assert.Equal(t, 0, storage.upserts)
+
+	// node1 进入自我保护, node2 可以感知到
+	for i := 0; i < 7; i++ {
+		p1.preserve(string(rune('a'+i)), "svc", now)
+	}
+	node1.report()
+	peers, err := node2.peers()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, peers)
+	peers, err = node1.peers()
+	assert.NoError(t, err)
+	assert.Empty(t, peers)
+
+	// 记录长时间未刷新视为失效
+	storage.nowSec = now + int64(preservationExpireTimes*preservationReportInterval/time.Second) + 1
+	peers, err = node2.peers()
+	assert.NoError(t, err)
+	assert.Empty(t, peers)
+	storage.nowSec = now
+
+	// 退出自我保护后更新一次记录, 之后不再写入
+	p1.recover("a", now)
+	node1.report()
+	peers, err = node2.peers()
+	assert.NoError(t, err)
+	assert.Empty(t, peers)
+	upserts := storage.upserts
+	node1.report()
+	assert.Equal(t, upserts, storage.upserts)
+
+	storage.err = errors.New("mock store error")
+	_, err = node2.peers()
+	assert.Error(t, err)
+}
@@ -26,8 +26,10 @@ import (
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
 
 	cacheapi "github.com/pole-io/pole-server/apis/cache"
+	"github.com/pole-io/pole-server/apis/pkg/types/admin"
 	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
 	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
 	"github.com/pole-io/pole-server/apis/service/healthcheck"
@@ -58,6 +60,13 @@ type Server struct {
 	bc             *batch.Controller
 	serviceCache   cacheapi.ServiceCache
 	instanceCache  cacheapi.InstanceCache
+	policyCache    cacheapi.HealthPolicyCache
+	// selfPreservation 自我保护，避免大批实例同时被标记为不健康
+	selfPreservation *selfPreservation
+	// clusterPreservation 共享各个节点的自我保护状态
+	clusterPreservation *clusterPreservation
+	// damping 健康状态抖动抑制
+	damping *flapDamping
 
 	subCtxs []*eventhub.SubscribtionContext
 }
@@ -84,11 +93,18 @@ func NewHealthServer(ctx context.Context, hcOpt *Config, options ...serverOption
 		hcOpt:     hcOpt,
 		localHost: hcOpt.LocalHost,
 	}
+	svr.selfPreservation = newSelfPreservation(&hcOpt.SelfPreservation,
+		svr.scheduledInstanceCount, svr.serviceInstanceCount)
+	svr.damping = newFlapDamping(&hcOpt.Damping)
 	for i := range options {
 		if err := options[i](svr); err != nil {
 			return nil, err
 		}
 	}
+	if svr.storage != nil {
+		svr.clusterPreservation = newClusterPreservation(svr.storage, svr.localHost,
+			svr.selfPreservation, svr.currentTimeSec)
+	}
 	return svr, nil
 }
 
@@ -124,6 +140,7 @@ func initialize(ctx context.Context, hcOpt *Config, bc *batch.Controller) error
 	}
 
 	_server = svr
+	registerMetrics()
 
 	return svr.run(ctx)
 }
@@ -136,6 +153,9 @@ func (s *Server) run(ctx context.Context) error {
 	s.checkScheduler.run(ctx)
 	s.timeAdjuster.doTimeAdjust(ctx)
 	s.dispatcher.startDispatchingJob(ctx)
+	if s.clusterPreservation != nil {
+		s.clusterPreservation.run(ctx)
+	}
 	return nil
 }
 
@@ -189,6 +209,11 @@ func (s *Server) SetInstanceCache(instanceCache cacheapi.InstanceCache) {
 	s.instanceCache = instanceCache
 }
 
+// SetHealthPolicyCache 设置健康检查策略缓存
+func (s *Server) SetHealthPolicyCache(policyCache cacheapi.HealthPolicyCache) {
+	s.policyCache = policyCache
+}
+
 // CacheProvider get cache provider
 func (s *Server) CacheProvider() (*CacheProvider, error) {
 	if !finishInit {
@@ -197,6 +222,61 @@ func (s *Server) CacheProvider() (*CacheProvider, error) {
 	return s.cacheProvider, nil
 }
 
+// SelfPreservationStatus 获取自我保护的状态
+func (s *Server) SelfPreservationStatus() *admin.SelfPreservationStatus {
+	status := s.selfPreservation.status(s.currentTimeSec())
+	status.Peers = []string{}
+	if s.clusterPreservation != nil && status.Open {
+		peers, err := s.clusterPreservation.peers()
+		if err != nil {
+			log.Error("[Health Check][SelfPreservation] query peers self preservation status", zap.Error(err))
+		} else {
+			status.Peers = peers
+		}
+	}
+	return status
+}
+
+// IsSelfPreserving 集群中任意节点处于自我保护时返回 true，处于自我保护期间不应该删除不健康的实例.
+// 查询其他节点的状态失败时按照处于自我保护处理
+func (s *Server) IsSelfPreserving() bool {
+	if s.selfPreservation.active(s.currentTimeSec()) {
+		return true
+	}
+	if s.clusterPreservation == nil || !s.hcOpt.SelfPreservation.Open {
+		return false
+	}
+	peers, err := s.clusterPreservation.peers()
+	if err != nil {
+		log.Error("[Health Check][SelfPreservation] query peers self preservation status", zap.Error(err))
+		return true
+	}
+	return len(peers) > 0
+}
+
+// instanceTtl 获取实例的心跳 TTL, 受命名空间、服务维度策略中 TTL 上下限的约束
+func (s *Server) instanceTtl(instance *svctypes.Instance) uint32 {
+	ttl := instance.HealthCheck().GetHeartbeat().GetTtl().GetValue()
+	if s.policyCache == nil {
+		return ttl
+	}
+	return s.policyCache.GetPolicy(instance.Namespace(), instance.Service()).ClampTtl(ttl)
+}
+
+func (s *Server) scheduledInstanceCount() int {
+	if s.checkScheduler == nil {
+		return 0
+	}
+	return s.checkScheduler.scheduledInstanceCount()
+}
+
+func (s *Server) serviceInstanceCount(serviceID string) int {
+	if s.instanceCache == nil {
+		return 0
+	}
+	return int(s.instanceCache.GetInstancesCountByServiceID(serviceID).TotalInstanceCount)
+}
+
 // ListCheckerServer get checker server instance list
 func (s *Server) ListCheckerServer() []*svctypes.Instance {
 	ret := make([]*svctypes.Instance, 0, s.cacheProvider.selfServiceInstances.Count())
@@ -259,6 +339,14 @@ func (s *Server) Checkers() map[int32]healthcheck.HealthChecker {
 	return s.checkers
 }
 
+// ContinuumLoaded 健康检查分发器是否已经加载了 pole.checker 的一致性哈希环, 未开启健康检查时总是返回 true
+func (s *Server) ContinuumLoaded() bool {
+	if !s.isOpen() {
+		return true
+	}
+	return s.dispatcher != nil && s.dispatcher.continuumLoaded()
+}
+
 func (s *Server) isOpen() bool {
 	return s.hcOpt.IsOpen()
 }
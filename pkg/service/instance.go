@@ -43,6 +43,7 @@ import (
 	api "github.com/pole-io/pole-server/pkg/common/api/v1"
 	"github.com/pole-io/pole-server/pkg/common/eventhub"
 	commontime "github.com/pole-io/pole-server/pkg/common/time"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 	"github.com/pole-io/pole-server/pkg/common/valid"
 )
@@ -89,7 +90,11 @@ func (s *Server) CreateInstances(ctx context.Context, reqs []*apiservice.Instanc
 }
 
 // CreateInstance create a single service instance
-func (s *Server) CreateInstance(ctx context.Context, req *apiservice.Instance) *apiservice.Response {
+func (s *Server) CreateInstance(ctx context.Context, req *apiservice.Instance) (rsp *apiservice.Response) {
+	ctx, span := tracing.Start(ctx, "service.CreateInstance")
+	defer func() {
+		tracing.EndResponse(span, rsp)
+	}()
 	start := time.Now()
 
 	// Prevent pollution api.Instance struct, copy and fill token
@@ -162,7 +167,7 @@ func (s *Server) asyncCreateInstance(
 	ctx context.Context, svcId string, req *apiservice.Instance, ins *apiservice.Instance) (
 	*svctypes.Instance, *apiservice.Response) {
 	allowAsyncRegis, _ := ctx.Value(types.ContextOpenAsyncRegis).(bool)
-	future := s.bc.AsyncCreateInstance(svcId, ins, !allowAsyncRegis)
+	future := s.bc.AsyncCreateInstance(ctx, svcId, ins, !allowAsyncRegis)
 
 	if err := future.Wait(); err != nil {
 		if future.Code() == apimodel.Code_ExistedResource {
@@ -181,7 +186,7 @@ func (s *Server) serialCreateInstance(
 	ctx context.Context, svcId string, req *apiservice.Instance, ins *apiservice.Instance) (
 	*svctypes.Instance, *apiservice.Response) {
 
-	instance, err := s.storage.GetInstance(ins.GetId().GetValue())
+	instance, err := s.storage.GetInstance(ctx, ins.GetId().GetValue())
 	if err != nil {
 		log.Error("[Instance] get instance from store",
 			utils.RequestID(ctx), zap.Error(err))
@@ -193,7 +198,7 @@ func (s *Server) serialCreateInstance(
 	}
 	// 直接同步创建服务实例
 	data := svctypes.CreateInstanceModel(svcId, ins)
-	if err := s.storage.AddInstance(data); err != nil {
+	if err := s.storage.AddInstance(ctx, data); err != nil {
 		log.Error(err.Error(), utils.RequestID(ctx))
 		return nil, wrapperInstanceStoreResponse(req, err)
 	}
@@ -207,7 +212,11 @@ func (s *Server) DeleteInstances(ctx context.Context, req []*apiservice.Instance
 }
 
 // DeleteInstance 删除单个服务实例
-func (s *Server) DeleteInstance(ctx context.Context, req *apiservice.Instance) *apiservice.Response {
+func (s *Server) DeleteInstance(ctx context.Context, req *apiservice.Instance) (rsp *apiservice.Response) {
+	ctx, span := tracing.Start(ctx, "service.DeleteInstance")
+	defer func() {
+		tracing.EndResponse(span, rsp)
+	}()
 	ins := *req // 防止污染外部的req
 	ins.ServiceToken = protobuf.NewStringValue(parseInstanceReqToken(ctx, req))
 	return s.deleteInstance(ctx, req, &ins)
@@ -231,7 +240,7 @@ func (s *Server) serialDeleteInstance(
 	ctx context.Context, req *apiservice.Instance, ins *apiservice.Instance) *apiservice.Response {
 	start := time.Now()
 	// 检查服务实例是否存在
-	instance, err := s.storage.GetInstance(ins.GetId().GetValue())
+	instance, err := s.storage.GetInstance(ctx, ins.GetId().GetValue())
 	if err != nil {
 		log.Error(err.Error(), utils.RequestID(ctx))
 		return api.NewInstanceResponse(storeapi.StoreCode2APICode(err), req)
@@ -247,7 +256,7 @@ func (s *Server) serialDeleteInstance(
 	}
 
 	// 存储层操作
-	if err := s.storage.DeleteInstance(instance.ID()); err != nil {
+	if err := s.storage.DeleteInstance(ctx, instance.ID()); err != nil {
 		log.Error(err.Error(), utils.RequestID(ctx))
 		return wrapperInstanceStoreResponse(req, err)
 	}
@@ -276,7 +285,7 @@ func (s *Server) asyncDeleteInstance(
 	ctx context.Context, req *apiservice.Instance, ins *apiservice.Instance) *apiservice.Response {
 	start := time.Now()
 	allowAsyncRegis, _ := ctx.Value(types.ContextOpenAsyncRegis).(bool)
-	future := s.bc.AsyncDeleteInstance(ins, !allowAsyncRegis)
+	future := s.bc.AsyncDeleteInstance(ctx, ins, !allowAsyncRegis)
 	if err := future.Wait(); err != nil {
 		// 如果发现不存在资源，意味着实例已经被删除，直接返回成功
 		if future.Code() == apimodel.Code_NotFoundResource {
@@ -330,7 +339,7 @@ func (s *Server) DeleteInstanceByHost(ctx context.Context, req *apiservice.Insta
 		ids = append(ids, instance.ID())
 	}
 
-	if err := s.storage.BatchDeleteInstances(ids); err != nil {
+	if err := s.storage.BatchDeleteInstances(ctx, ids); err != nil {
 		log.Error(err.Error(), utils.RequestID(ctx))
 		return wrapperInstanceStoreResponse(req, err)
 	}
@@ -358,7 +367,11 @@ func (s *Server) UpdateInstances(ctx context.Context, req []*apiservice.Instance
 }
 
 // UpdateInstance 修改单个服务实例
-func (s *Server) UpdateInstance(ctx context.Context, req *apiservice.Instance) *apiservice.Response {
+func (s *Server) UpdateInstance(ctx context.Context, req *apiservice.Instance) (rsp *apiservice.Response) {
+	ctx, span := tracing.Start(ctx, "service.UpdateInstance")
+	defer func() {
+		tracing.EndResponse(span, rsp)
+	}()
 	service, instance, preErr := s.execInstancePreStep(ctx, req)
 	if preErr != nil {
 		return preErr
@@ -374,7 +387,7 @@ func (s *Server) UpdateInstance(ctx context.Context, req *apiservice.Instance) *
 			utils.RequestID(ctx), zap.String("instance", req.String()))
 		return api.NewInstanceResponse(apimodel.Code_NoNeedUpdate, req)
 	}
-	if err := s.storage.UpdateInstance(instance); err != nil {
+	if err := s.storage.UpdateInstance(ctx, instance); err != nil {
 		log.Error(err.Error(), utils.RequestID(ctx))
 		return wrapperInstanceStoreResponse(req, err)
 	}
@@ -850,7 +863,7 @@ func (s *Server) GetInstancesCount(ctx context.Context) *apiservice.BatchQueryRe
 func (s *Server) execInstancePreStep(ctx context.Context, req *apiservice.Instance) (
 	*svctypes.Service, *svctypes.Instance, *apiservice.Response) {
 	// 检查服务实例是否存在
-	instance, err := s.storage.GetInstance(req.GetId().GetValue())
+	instance, err := s.storage.GetInstance(ctx, req.GetId().GetValue())
 	if err != nil {
 		log.Error("[Instance] get instance from store", utils.RequestID(ctx), utils.ZapInstanceID(req.GetId().GetValue()),
 			zap.Error(err))
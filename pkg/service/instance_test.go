@@ -2011,7 +2011,7 @@ func TestUpdateInstanceField(t *testing.T) {
 		So(discoverSuit.DiscoverServer().UpdateInstance(
 			discoverSuit.DefaultCtx, request).GetCode().GetValue(), ShouldEqual, api.ExecuteSuccess)
 
-		instance, err := discoverSuit.Storage.GetInstance(instId)
+		instance, err := discoverSuit.Storage.GetInstance(context.Background(), instId)
 		So(err, ShouldBeNil)
 		So(instance.Proto.Host.GetValue(), ShouldEqual, instanceResp.Host.GetValue())
 	})
@@ -2021,7 +2021,7 @@ func TestUpdateInstanceField(t *testing.T) {
 		request.Isolate = wrapperspb.Bool(true)
 		So(discoverSuit.DiscoverServer().UpdateInstance(
 			discoverSuit.DefaultCtx, request).GetCode().GetValue(), ShouldEqual, api.ExecuteSuccess)
-		instance, err := discoverSuit.Storage.GetInstance(instId)
+		instance, err := discoverSuit.Storage.GetInstance(context.Background(), instId)
 		So(err, ShouldBeNil)
 		So(instance.Proto.Isolate.GetValue(), ShouldEqual, true)
 
@@ -2029,7 +2029,7 @@ func TestUpdateInstanceField(t *testing.T) {
 		So(discoverSuit.DiscoverServer().UpdateInstance(
 			discoverSuit.DefaultCtx, request).GetCode().GetValue(), ShouldEqual, api.ExecuteSuccess)
 
-		instance, err = discoverSuit.Storage.GetInstance(instId)
+		instance, err = discoverSuit.Storage.GetInstance(context.Background(), instId)
 		So(err, ShouldBeNil)
 		So(instance.Proto.Isolate.GetValue(), ShouldEqual, false)
 	})
@@ -2497,7 +2497,7 @@ func Test_HealthCheckInstanceMetadata(t *testing.T) {
 
 	t.Run("toUnhealth", func(t *testing.T) {
 		lastBeatTime := time.Now().Unix()
-		future := discoverSuit.BatchController().AsyncHeartbeat(ins1, false, lastBeatTime)
+		future := discoverSuit.BatchController().AsyncHeartbeat(ins1, false, lastBeatTime, 0)
 		err := future.Wait()
 		assert.NoError(t, err)
 
@@ -2511,7 +2511,7 @@ func Test_HealthCheckInstanceMetadata(t *testing.T) {
 
 	t.Run("toHealth", func(t *testing.T) {
 		lastBeatTime := time.Now().Unix()
-		future := discoverSuit.BatchController().AsyncHeartbeat(ins1, true, lastBeatTime)
+		future := discoverSuit.BatchController().AsyncHeartbeat(ins1, true, lastBeatTime, 0)
 		err := future.Wait()
 		assert.NoError(t, err)
 
@@ -2546,7 +2546,7 @@ func Test_OperateInstanceMetadata(t *testing.T) {
 	}()
 
 	t.Run("append-instance-metadata", func(t *testing.T) {
-		err := discoverSuit.Storage.BatchAppendInstanceMetadata([]*store.InstanceMetadataRequest{
+		err := discoverSuit.Storage.BatchAppendInstanceMetadata(context.Background(), []*store.InstanceMetadataRequest{
 			{
 				InstanceID: ins1.GetId().GetValue(),
 				Metadata: map[string]string{
@@ -2576,7 +2576,7 @@ func Test_OperateInstanceMetadata(t *testing.T) {
 	})
 
 	t.Run("notinstance-append-instance-metadata", func(t *testing.T) {
-		err := discoverSuit.Storage.BatchAppendInstanceMetadata([]*store.InstanceMetadataRequest{
+		err := discoverSuit.Storage.BatchAppendInstanceMetadata(context.Background(), []*store.InstanceMetadataRequest{
 			{
 				InstanceID: utils.NewUUID(),
 				Metadata: map[string]string{
@@ -2588,7 +2588,7 @@ func Test_OperateInstanceMetadata(t *testing.T) {
 	})
 
 	t.Run("remove-instance-metadata", func(t *testing.T) {
-		err := discoverSuit.Storage.BatchRemoveInstanceMetadata([]*store.InstanceMetadataRequest{
+		err := discoverSuit.Storage.BatchRemoveInstanceMetadata(context.Background(), []*store.InstanceMetadataRequest{
 			{
 				InstanceID: ins1.GetId().GetValue(),
 				Keys:       []string{"ins1_mock_key"},
@@ -2612,7 +2612,7 @@ func Test_OperateInstanceMetadata(t *testing.T) {
 	})
 
 	t.Run("notinstance-remove-instance-metadata", func(t *testing.T) {
-		err := discoverSuit.Storage.BatchRemoveInstanceMetadata([]*store.InstanceMetadataRequest{
+		err := discoverSuit.Storage.BatchRemoveInstanceMetadata(context.Background(), []*store.InstanceMetadataRequest{
 			{
 				InstanceID: utils.NewUUID(),
 				Keys:       []string{"ins1_mock_key"},
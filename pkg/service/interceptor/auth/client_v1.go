@@ -30,10 +30,15 @@ import (
 	authtypes "github.com/pole-io/pole-server/apis/pkg/types/auth"
 	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
 	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 )
 
 // RegisterInstance create one instance
-func (svr *Server) RegisterInstance(ctx context.Context, req *apiservice.Instance) *apiservice.Response {
+func (svr *Server) RegisterInstance(ctx context.Context, req *apiservice.Instance) (rsp *apiservice.Response) {
+	ctx, span := tracing.Start(ctx, "auth.RegisterInstance")
+	defer func() {
+		tracing.EndResponse(span, rsp)
+	}()
 	authCtx := svr.collectClientInstanceAuthContext(
 		ctx, []*apiservice.Instance{req}, authtypes.Create, authtypes.RegisterInstance)
 
@@ -50,7 +55,11 @@ func (svr *Server) RegisterInstance(ctx context.Context, req *apiservice.Instanc
 }
 
 // DeregisterInstance delete onr instance
-func (svr *Server) DeregisterInstance(ctx context.Context, req *apiservice.Instance) *apiservice.Response {
+func (svr *Server) DeregisterInstance(ctx context.Context, req *apiservice.Instance) (rsp *apiservice.Response) {
+	ctx, span := tracing.Start(ctx, "auth.DeregisterInstance")
+	defer func() {
+		tracing.EndResponse(span, rsp)
+	}()
 	authCtx := svr.collectClientInstanceAuthContext(
 		ctx, []*apiservice.Instance{req}, authtypes.Create, authtypes.DeregisterInstance)
 
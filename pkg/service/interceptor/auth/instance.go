@@ -26,12 +26,17 @@ import (
 	"github.com/pole-io/pole-server/apis/pkg/types"
 	authtypes "github.com/pole-io/pole-server/apis/pkg/types/auth"
 	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/service"
 )
 
 // CreateInstances create instances
 func (svr *Server) CreateInstances(ctx context.Context,
-	reqs []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	reqs []*apiservice.Instance) (rsp *apiservice.BatchWriteResponse) {
+	ctx, span := tracing.Start(ctx, "auth.CreateInstances")
+	defer func() {
+		tracing.EndResponse(span, rsp)
+	}()
 	authCtx := svr.collectInstanceAuthContext(ctx, reqs, authtypes.Create, authtypes.CreateInstances)
 
 	if _, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
@@ -49,7 +54,11 @@ func (svr *Server) CreateInstances(ctx context.Context,
 
 // DeleteInstances delete instances
 func (svr *Server) DeleteInstances(ctx context.Context,
-	reqs []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	reqs []*apiservice.Instance) (rsp *apiservice.BatchWriteResponse) {
+	ctx, span := tracing.Start(ctx, "auth.DeleteInstances")
+	defer func() {
+		tracing.EndResponse(span, rsp)
+	}()
 	authCtx := svr.collectInstanceAuthContext(ctx, reqs, authtypes.Delete, authtypes.DeleteInstances)
 
 	_, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx)
@@ -87,7 +96,11 @@ func (svr *Server) DeleteInstancesByHost(ctx context.Context,
 
 // UpdateInstances update instances
 func (svr *Server) UpdateInstances(ctx context.Context,
-	reqs []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	reqs []*apiservice.Instance) (rsp *apiservice.BatchWriteResponse) {
+	ctx, span := tracing.Start(ctx, "auth.UpdateInstances")
+	defer func() {
+		tracing.EndResponse(span, rsp)
+	}()
 	authCtx := svr.collectInstanceAuthContext(ctx, reqs, authtypes.Modify, authtypes.UpdateInstances)
 
 	_, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx)
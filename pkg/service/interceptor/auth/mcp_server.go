@@ -0,0 +1,76 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service_auth
+
+import (
+	"context"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+	authcommon "github.com/pole-io/pole-server/apis/pkg/types/auth"
+	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+)
+
+// RegisterMCPServer .
+func (svr *Server) RegisterMCPServer(ctx context.Context, req *svctypes.MCPServer) (*svctypes.MCPServer, error) {
+	authCtx := svr.collectServiceAuthContext(ctx, []*apiservice.Service{
+		{
+			Namespace: protobuf.NewStringValue(req.Namespace),
+			Name:      protobuf.NewStringValue(req.Name),
+		},
+	}, authcommon.Modify, authcommon.RegisterMCPServer)
+	if _, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, types.ContextAuthContextKey, authCtx)
+	return svr.nextSvr.RegisterMCPServer(ctx, req)
+}
+
+// DeregisterMCPServer .
+func (svr *Server) DeregisterMCPServer(ctx context.Context, req *svctypes.MCPServer) error {
+	authCtx := svr.collectServiceAuthContext(ctx, []*apiservice.Service{
+		{
+			Namespace: protobuf.NewStringValue(req.Namespace),
+			Name:      protobuf.NewStringValue(req.Name),
+		},
+	}, authcommon.Delete, authcommon.DeregisterMCPServer)
+	if _, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, types.ContextAuthContextKey, authCtx)
+	return svr.nextSvr.DeregisterMCPServer(ctx, req)
+}
+
+// GetMCPServers .
+func (svr *Server) GetMCPServers(ctx context.Context,
+	query map[string]string) (uint32, []*svctypes.MCPServer, error) {
+	authCtx := svr.collectServiceAuthContext(ctx, nil, authcommon.Read, authcommon.DescribeMCPServers)
+	if _, err := svr.policySvr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return 0, nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, types.ContextAuthContextKey, authCtx)
+	return svr.nextSvr.GetMCPServers(ctx, query)
+}
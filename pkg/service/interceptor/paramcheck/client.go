@@ -28,6 +28,7 @@ import (
 	"github.com/pole-io/pole-server/apis/pkg/types"
 	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
 	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/service"
 )
 
@@ -48,7 +49,11 @@ func (svr *Server) GetPrometheusTargets(ctx context.Context,
 }
 
 // RegisterInstance create one instance by client
-func (s *Server) RegisterInstance(ctx context.Context, req *apiservice.Instance) *apiservice.Response {
+func (s *Server) RegisterInstance(ctx context.Context, req *apiservice.Instance) (rsp *apiservice.Response) {
+	ctx, span := tracing.Start(ctx, "paramcheck.RegisterInstance")
+	defer func() {
+		tracing.EndResponse(span, rsp)
+	}()
 	// 参数检查
 	if err := checkMetadata(req.GetMetadata()); err != nil {
 		return api.NewInstanceResponse(apimodel.Code_InvalidMetadata, req)
@@ -62,7 +67,11 @@ func (s *Server) RegisterInstance(ctx context.Context, req *apiservice.Instance)
 }
 
 // DeregisterInstance delete onr instance by client
-func (s *Server) DeregisterInstance(ctx context.Context, req *apiservice.Instance) *apiservice.Response {
+func (s *Server) DeregisterInstance(ctx context.Context, req *apiservice.Instance) (rsp *apiservice.Response) {
+	ctx, span := tracing.Start(ctx, "paramcheck.DeregisterInstance")
+	defer func() {
+		tracing.EndResponse(span, rsp)
+	}()
 	instanceID, resp := checkReviseInstance(req)
 	if resp != nil {
 		return resp
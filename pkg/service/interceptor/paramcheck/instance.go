@@ -33,6 +33,7 @@ import (
 	"github.com/pole-io/pole-server/apis/access_control/ratelimit"
 	api "github.com/pole-io/pole-server/pkg/common/api/v1"
 	"github.com/pole-io/pole-server/pkg/common/log"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 	"github.com/pole-io/pole-server/pkg/common/valid"
 )
@@ -65,7 +66,11 @@ var (
 
 // CreateInstances implements service.DiscoverServer.
 func (svr *Server) CreateInstances(ctx context.Context,
-	reqs []*service_manage.Instance) *service_manage.BatchWriteResponse {
+	reqs []*service_manage.Instance) (rsp *service_manage.BatchWriteResponse) {
+	ctx, span := tracing.Start(ctx, "paramcheck.CreateInstances")
+	defer func() {
+		tracing.EndResponse(span, rsp)
+	}()
 	if checkError := checkBatchInstance(reqs); checkError != nil {
 		return checkError
 	}
@@ -96,7 +101,11 @@ func (svr *Server) CreateInstances(ctx context.Context,
 
 // DeleteInstances implements service.DiscoverServer.
 func (svr *Server) DeleteInstances(ctx context.Context,
-	reqs []*service_manage.Instance) *service_manage.BatchWriteResponse {
+	reqs []*service_manage.Instance) (rsp *service_manage.BatchWriteResponse) {
+	ctx, span := tracing.Start(ctx, "paramcheck.DeleteInstances")
+	defer func() {
+		tracing.EndResponse(span, rsp)
+	}()
 	if checkError := checkBatchInstance(reqs); checkError != nil {
 		return checkError
 	}
@@ -216,7 +225,11 @@ func (svr *Server) GetInstancesCount(ctx context.Context) *service_manage.BatchQ
 }
 
 // UpdateInstances implements service.DiscoverServer.
-func (svr *Server) UpdateInstances(ctx context.Context, reqs []*service_manage.Instance) *service_manage.BatchWriteResponse {
+func (svr *Server) UpdateInstances(ctx context.Context, reqs []*service_manage.Instance) (rsp *service_manage.BatchWriteResponse) {
+	ctx, span := tracing.Start(ctx, "paramcheck.UpdateInstances")
+	defer func() {
+		tracing.EndResponse(span, rsp)
+	}()
 	if checkError := checkBatchInstance(reqs); checkError != nil {
 		return checkError
 	}
@@ -0,0 +1,94 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package paramcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/pkg/common/valid"
+)
+
+// RegisterMCPServer implements service.DiscoverServer.
+func (svr *Server) RegisterMCPServer(ctx context.Context, req *svctypes.MCPServer) (*svctypes.MCPServer, error) {
+	if err := checkMCPServer(req); err != nil {
+		return nil, err
+	}
+	names := map[string]struct{}{}
+	var err error
+	req.Capabilities(func(kind string, item *svctypes.MCPCapability) {
+		if err != nil {
+			return
+		}
+		if item == nil || item.Name == "" {
+			err = fmt.Errorf("%s name is empty", kind)
+			return
+		}
+		key := kind + "/" + item.Name
+		if _, ok := names[key]; ok {
+			err = fmt.Errorf("%s %s is duplicated", kind, item.Name)
+			return
+		}
+		names[key] = struct{}{}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, endpoint := range req.Endpoints {
+		if endpoint == nil || endpoint.Host == "" || endpoint.Port == 0 {
+			return nil, errors.New("endpoint host and port are required")
+		}
+		if endpoint.Transport == "" {
+			return nil, errors.New("endpoint transport is required")
+		}
+	}
+	return svr.nextSvr.RegisterMCPServer(ctx, req)
+}
+
+// DeregisterMCPServer implements service.DiscoverServer.
+func (svr *Server) DeregisterMCPServer(ctx context.Context, req *svctypes.MCPServer) error {
+	if err := checkMCPServer(req); err != nil {
+		return err
+	}
+	return svr.nextSvr.DeregisterMCPServer(ctx, req)
+}
+
+// GetMCPServers implements service.DiscoverServer.
+func (svr *Server) GetMCPServers(ctx context.Context,
+	query map[string]string) (uint32, []*svctypes.MCPServer, error) {
+	return svr.nextSvr.GetMCPServers(ctx, query)
+}
+
+func checkMCPServer(req *svctypes.MCPServer) error {
+	if req == nil {
+		return errors.New("mcp server is empty")
+	}
+	if err := valid.CheckResourceName(protobuf.NewStringValue(req.Namespace)); err != nil {
+		return fmt.Errorf("invalid namespace: %w", err)
+	}
+	if err := valid.CheckResourceName(protobuf.NewStringValue(req.Name)); err != nil {
+		return fmt.Errorf("invalid mcp server name: %w", err)
+	}
+	if req.Version == "" {
+		return errors.New("mcp server version is required")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package k8ssync
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// newClientset 根据配置构造访问 kubernetes API 的 clientset，未配置 API server 地址时使用 Pod 内的 in-cluster 配置。
+// 不设置 rest.Config 的 Timeout，避免中断 informer 的 watch 长连接，单次读写的超时由调用方通过 ctx 控制
+func newClientset(cfg *Config) (kubernetes.Interface, error) {
+	var restCfg *rest.Config
+	if cfg.Address == "" {
+		inCluster, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("kubernetes sync address is required when running outside of kubernetes: %w", err)
+		}
+		restCfg = inCluster
+	} else {
+		restCfg = &rest.Config{Host: cfg.Address}
+	}
+	// 显式配置的凭据优先于 in-cluster 的 ServiceAccount，BearerTokenFile 会定期重新读取以支持 token 轮换
+	if cfg.Token != "" {
+		restCfg.BearerToken = cfg.Token
+		restCfg.BearerTokenFile = ""
+	} else if cfg.TokenFile != "" {
+		restCfg.BearerToken = ""
+		restCfg.BearerTokenFile = cfg.TokenFile
+	}
+	if cfg.CAFile != "" {
+		restCfg.TLSClientConfig.CAFile = cfg.CAFile
+		restCfg.TLSClientConfig.CAData = nil
+	}
+	if cfg.InsecureSkipVerify {
+		restCfg.TLSClientConfig.Insecure = true
+		restCfg.TLSClientConfig.CAFile = ""
+		restCfg.TLSClientConfig.CAData = nil
+	}
+	restCfg.UserAgent = ManagedByPole
+	return kubernetes.NewForConfig(restCfg)
+}
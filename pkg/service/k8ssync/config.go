@@ -0,0 +1,126 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package k8ssync
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultResyncInterval 周期性全量对账的间隔，用于兜底 watch 丢失的事件
+	DefaultResyncInterval = 60 * time.Second
+	// DefaultDebounce 收到变更事件后等待合并的时间
+	DefaultDebounce = time.Second
+	// DefaultTimeout 访问 kubernetes API 的超时时间
+	DefaultTimeout = 10 * time.Second
+)
+
+// Config kubernetes 服务同步配置
+type Config struct {
+	// Enable 是否开启 kubernetes 服务同步
+	Enable bool `yaml:"enable"`
+	// Address kubernetes API server 地址，为空时使用 Pod 内的 in-cluster 配置
+	Address string `yaml:"address"`
+	// Token 访问 API server 使用的 bearer token，为空时读取 TokenFile
+	Token string `yaml:"token"`
+	// TokenFile bearer token 文件，in-cluster 时默认使用 ServiceAccount 的 token
+	TokenFile string `yaml:"tokenFile"`
+	// CAFile API server 的 CA 证书，in-cluster 时默认使用 ServiceAccount 的 CA
+	CAFile string `yaml:"caFile"`
+	// InsecureSkipVerify 不校验 API server 的证书
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify"`
+	// Timeout 单次读写 API server 的超时时间，不影响 informer 的 watch
+	Timeout time.Duration `yaml:"timeout"`
+	// ResyncInterval 周期性全量对账的间隔
+	ResyncInterval time.Duration `yaml:"resyncInterval"`
+	// Debounce 收到变更事件后等待合并的时间
+	Debounce time.Duration `yaml:"debounce"`
+	// ToPole kubernetes 服务同步到 pole-server
+	ToPole ToPoleConfig `yaml:"toPole"`
+	// ToKubernetes pole-server 服务回写为 kubernetes 的 headless Service
+	ToKubernetes ToKubernetesConfig `yaml:"toKubernetes"`
+}
+
+// ToPoleConfig kubernetes Service/EndpointSlice 同步为 pole-server 服务实例的配置
+type ToPoleConfig struct {
+	Enable bool `yaml:"enable"`
+	// Namespaces 需要同步的 kubernetes 命名空间，为空时同步全部命名空间
+	Namespaces []string `yaml:"namespaces"`
+	// NamespaceMapping kubernetes 命名空间到 pole-server 命名空间的映射，未配置的使用同名命名空间
+	NamespaceMapping map[string]string `yaml:"namespaceMapping"`
+}
+
+// ToKubernetesConfig pole-server 服务回写为 kubernetes headless Service 的配置
+type ToKubernetesConfig struct {
+	Enable bool `yaml:"enable"`
+	// Namespaces pole-server 命名空间到 kubernetes 命名空间的映射，只回写这些命名空间下的服务
+	Namespaces map[string]string `yaml:"namespaces"`
+}
+
+// DefaultConfig 默认的 kubernetes 服务同步配置
+func DefaultConfig() Config {
+	return Config{
+		ResyncInterval: DefaultResyncInterval,
+		Debounce:       DefaultDebounce,
+		Timeout:        DefaultTimeout,
+		ToPole:         ToPoleConfig{Enable: true},
+	}
+}
+
+// validate 校验配置并填充默认值
+func (c *Config) validate() error {
+	if c.ResyncInterval <= 0 {
+		c.ResyncInterval = DefaultResyncInterval
+	}
+	if c.Debounce <= 0 {
+		c.Debounce = DefaultDebounce
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if !c.ToPole.Enable && !c.ToKubernetes.Enable {
+		return errors.New("kubernetes sync has nothing to do, enable toPole or toKubernetes")
+	}
+	if c.ToKubernetes.Enable && len(c.ToKubernetes.Namespaces) == 0 {
+		return errors.New("kubernetes sync toKubernetes.namespaces is required")
+	}
+	for poleNs, k8sNs := range c.ToKubernetes.Namespaces {
+		if poleNs == "" || k8sNs == "" {
+			return fmt.Errorf("kubernetes sync toKubernetes namespace mapping (%s: %s) is invalid", poleNs, k8sNs)
+		}
+	}
+	return nil
+}
+
+// poleNamespace kubernetes 命名空间对应的 pole-server 命名空间
+func (c *ToPoleConfig) poleNamespace(k8sNamespace string) string {
+	if ns, ok := c.NamespaceMapping[k8sNamespace]; ok && ns != "" {
+		return ns
+	}
+	return k8sNamespace
+}
+
+// watchNamespaces 需要监听的 kubernetes 命名空间，空字符串表示全部命名空间
+func (c *ToPoleConfig) watchNamespaces() []string {
+	if len(c.Namespaces) == 0 {
+		return []string{""}
+	}
+	return c.Namespaces
+}
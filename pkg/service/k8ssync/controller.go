@@ -0,0 +1,428 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package k8ssync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/pole-io/pole-server/apis/store"
+	"github.com/pole-io/pole-server/pkg/common/eventhub"
+	"github.com/pole-io/pole-server/pkg/namespace"
+	"github.com/pole-io/pole-server/pkg/service"
+	"github.com/pole-io/pole-server/pkg/service/migrate"
+)
+
+// Controller 通过 informer 监听 kubernetes 的 Service/EndpointSlice 同步为 pole-server 的服务实例，
+// 并按需将 pole-server 的服务回写为 kubernetes 的 headless Service。informer 的事件只用于触发对账，
+// 每次对账都以 informer 缓存中的全量数据为准，并由周期性的全量对账兜底
+type Controller struct {
+	cfg      *Config
+	client   kubernetes.Interface
+	registry registry
+	isLeader func() bool
+	trigger  chan struct{}
+	sub      *eventhub.SubscribtionContext
+
+	factories     []informers.SharedInformerFactory
+	serviceLister []corelisters.ServiceLister
+	sliceLister   []discoverylisters.EndpointSliceLister
+	synced        []cache.InformerSynced
+}
+
+// Initialize 初始化 kubernetes 服务同步，需要在缓存完成首次加载之后调用，
+// 避免在缓存为空时误判 pole-server 侧的服务已经全部下线
+func Initialize(ctx context.Context, cfg *Config, storage store.Store, svr service.DiscoverServer) error {
+	if !cfg.Enable {
+		return nil
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	client, err := newClientset(cfg)
+	if err != nil {
+		return err
+	}
+	if err := storage.StartLeaderElection(store.ElectionKeyKubernetesSync); err != nil {
+		log.Errorf("[KubernetesSync] start leader election err: %v", err)
+		return err
+	}
+	c, err := newController(cfg, client, newPoleRegistry(svr), func() bool {
+		return storage.IsLeader(store.ElectionKeyKubernetesSync)
+	})
+	if err != nil {
+		return err
+	}
+	return c.start(ctx)
+}
+
+func newController(cfg *Config, client kubernetes.Interface, reg registry, isLeader func() bool) (*Controller, error) {
+	c := &Controller{
+		cfg:      cfg,
+		client:   client,
+		registry: reg,
+		isLeader: isLeader,
+		trigger:  make(chan struct{}, 1),
+	}
+	if !cfg.ToPole.Enable {
+		return c, nil
+	}
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(any) { c.notify() },
+		UpdateFunc: func(any, any) { c.notify() },
+		DeleteFunc: func(any) { c.notify() },
+	}
+	for _, ns := range cfg.ToPole.watchNamespaces() {
+		// 不开启 informer 的定期 resync，由 ResyncInterval 周期性对账兜底
+		factory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(ns))
+		services := factory.Core().V1().Services()
+		slices := factory.Discovery().V1().EndpointSlices()
+		for _, informer := range []cache.SharedIndexInformer{services.Informer(), slices.Informer()} {
+			if _, err := informer.AddEventHandler(handler); err != nil {
+				return nil, err
+			}
+			c.synced = append(c.synced, informer.HasSynced)
+		}
+		c.factories = append(c.factories, factory)
+		c.serviceLister = append(c.serviceLister, services.Lister())
+		c.sliceLister = append(c.sliceLister, slices.Lister())
+	}
+	return c, nil
+}
+
+func (c *Controller) start(ctx context.Context) error {
+	if c.cfg.ToKubernetes.Enable {
+		sub, err := eventhub.SubscribeWithFunc(eventhub.CacheInstanceEventTopic, c.onInstanceEvent)
+		if err != nil {
+			return err
+		}
+		c.sub = sub
+	}
+	for _, factory := range c.factories {
+		factory.Start(ctx.Done())
+	}
+	go c.run(ctx)
+	go func() {
+		// informer 完成首次全量加载后立即对账，未完成前的对账会被跳过
+		if cache.WaitForCacheSync(ctx.Done(), c.synced...) {
+			c.notify()
+		}
+	}()
+	log.Infof("[KubernetesSync] start sync with %s, toPole: %v, toKubernetes: %v", c.cfg.Address,
+		c.cfg.ToPole.Enable, c.cfg.ToKubernetes.Enable)
+	return nil
+}
+
+// notify 触发一次对账，已有待处理的对账时合并
+func (c *Controller) notify() {
+	select {
+	case c.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Controller) onInstanceEvent(_ context.Context, val any) error {
+	event, ok := val.(*eventhub.CacheInstanceEvent)
+	if !ok || event.Instance == nil {
+		return nil
+	}
+	// 镜像过来的实例不会回写，其变更不需要触发对账
+	if migrate.IsMirrored(event.Instance.Metadata()) {
+		return nil
+	}
+	if _, ok := c.cfg.ToKubernetes.Namespaces[event.Instance.Namespace()]; ok {
+		c.notify()
+	}
+	return nil
+}
+
+func (c *Controller) run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.ResyncInterval)
+	defer ticker.Stop()
+	debounce := time.NewTimer(c.cfg.Debounce)
+	debounce.Stop()
+	pending := false
+	defer func() {
+		debounce.Stop()
+		if c.sub != nil {
+			c.sub.Cancel()
+		}
+		for _, factory := range c.factories {
+			factory.Shutdown()
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.trigger:
+			if !pending {
+				pending = true
+				debounce.Reset(c.cfg.Debounce)
+			}
+		case <-debounce.C:
+			pending = false
+			c.reconcile(ctx)
+		case <-ticker.C:
+			c.reconcile(ctx)
+		}
+	}
+}
+
+// reconcile 只有 leader 节点执行同步，避免多个节点同时写入
+func (c *Controller) reconcile(ctx context.Context) {
+	if !c.isLeader() {
+		return
+	}
+	if c.cfg.ToPole.Enable {
+		if err := c.syncToPole(ctx); err != nil {
+			log.Errorf("[KubernetesSync] sync kubernetes services to pole-server err: %v", err)
+		}
+	}
+	if c.cfg.ToKubernetes.Enable {
+		if err := c.syncToKubernetes(ctx); err != nil {
+			log.Errorf("[KubernetesSync] sync pole-server services to kubernetes err: %v", err)
+		}
+	}
+}
+
+// syncToPole 以 informer 缓存中的全量数据为准同步实例，缓存未完成首次加载时放弃本轮同步，避免误删实例
+func (c *Controller) syncToPole(ctx context.Context) error {
+	for _, synced := range c.synced {
+		if !synced() {
+			return errors.New("kubernetes informer caches are not synced yet")
+		}
+	}
+	var (
+		services []*corev1.Service
+		slices   []*discoveryv1.EndpointSlice
+	)
+	for i := range c.serviceLister {
+		svcList, err := c.serviceLister[i].List(labels.Everything())
+		if err != nil {
+			return fmt.Errorf("list services: %w", err)
+		}
+		sliceList, err := c.sliceLister[i].List(labels.Everything())
+		if err != nil {
+			return fmt.Errorf("list endpointslices: %w", err)
+		}
+		services = append(services, svcList...)
+		slices = append(slices, sliceList...)
+	}
+	desired := toInstances(&c.cfg.ToPole, services, slices)
+	// kubernetes 侧的服务全部删除后，对应命名空间下镜像过来的实例也需要清理
+	for _, ns := range c.registry.MirroredNamespaces() {
+		if _, ok := desired[ns]; !ok {
+			desired[ns] = nil
+		}
+	}
+	namespaces := make([]string, 0, len(desired))
+	for ns := range desired {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	ctx = namespace.AllowAutoCreate(ctx)
+	ctx = service.AllowAutoCreate(ctx)
+	var errs []error
+	for _, ns := range namespaces {
+		if err := c.registry.Sync(ctx, ns, desired[ns]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// syncToKubernetes 将 pole-server 命名空间下直接注册的服务回写为 kubernetes 的 headless Service，
+// 只修改带有 pole-server 管理标签的资源，不会覆盖用户自行创建的同名资源
+func (c *Controller) syncToKubernetes(ctx context.Context) error {
+	selector := metav1.ListOptions{LabelSelector: LabelManagedBy + "=" + ManagedByPole}
+	var errs []error
+	for poleNs, k8sNs := range c.cfg.ToKubernetes.Namespaces {
+		listCtx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+		svcList, err := c.client.CoreV1().Services(k8sNs).List(listCtx, selector)
+		if err != nil {
+			cancel()
+			errs = append(errs, fmt.Errorf("list services in namespace (%s): %w", k8sNs, err))
+			continue
+		}
+		epList, err := c.client.CoreV1().Endpoints(k8sNs).List(listCtx, selector)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("list endpoints in namespace (%s): %w", k8sNs, err))
+			continue
+		}
+		currentSvcs := map[string]*corev1.Service{}
+		for i := range svcList.Items {
+			if svc := &svcList.Items[i]; svc.Annotations[AnnotationNamespace] == poleNs {
+				currentSvcs[svc.Name] = svc
+			}
+		}
+		currentEps := map[string]*corev1.Endpoints{}
+		for i := range epList.Items {
+			if ep := &epList.Items[i]; ep.Annotations[AnnotationNamespace] == poleNs {
+				currentEps[ep.Name] = ep
+			}
+		}
+
+		for svcName, instances := range c.registry.NativeServices(poleNs) {
+			svc, ep := buildHeadless(poleNs, svcName, k8sNs, instances)
+			if len(svc.Spec.Ports) == 0 {
+				continue
+			}
+			name := svc.Name
+			if err := c.applyService(ctx, svc, currentSvcs[name]); err != nil {
+				errs = append(errs, err)
+			}
+			delete(currentSvcs, name)
+			if err := c.applyEndpoints(ctx, ep, currentEps[name]); err != nil {
+				errs = append(errs, err)
+			}
+			delete(currentEps, name)
+		}
+
+		for name := range currentSvcs {
+			err := c.withTimeout(ctx, func(ctx context.Context) error {
+				return c.client.CoreV1().Services(k8sNs).Delete(ctx, name, metav1.DeleteOptions{})
+			})
+			if err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, err)
+				continue
+			}
+			log.Infof("[KubernetesSync] delete service %s/%s", k8sNs, name)
+		}
+		for name := range currentEps {
+			err := c.withTimeout(ctx, func(ctx context.Context) error {
+				return c.client.CoreV1().Endpoints(k8sNs).Delete(ctx, name, metav1.DeleteOptions{})
+			})
+			if err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// withTimeout 单次读写 API server 的超时控制
+func (c *Controller) withTimeout(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+func (c *Controller) applyService(ctx context.Context, svc, exist *corev1.Service) error {
+	services := c.client.CoreV1().Services(svc.Namespace)
+	if exist == nil {
+		err := c.withTimeout(ctx, func(ctx context.Context) error {
+			_, err := services.Create(ctx, svc, metav1.CreateOptions{})
+			return err
+		})
+		if apierrors.IsAlreadyExists(err) {
+			log.Warnf("[KubernetesSync] service %s/%s already exists and is not managed by pole-server, skip",
+				svc.Namespace, svc.Name)
+			return nil
+		}
+		if err == nil {
+			log.Infof("[KubernetesSync] create service %s/%s", svc.Namespace, svc.Name)
+		}
+		return err
+	}
+	if serviceEqual(svc, exist) {
+		return nil
+	}
+	// 保留 API server 分配的字段，只覆盖需要同步的部分
+	update := exist.DeepCopy()
+	update.Labels = svc.Labels
+	update.Annotations = svc.Annotations
+	update.Spec.Ports = svc.Spec.Ports
+	return c.withTimeout(ctx, func(ctx context.Context) error {
+		_, err := services.Update(ctx, update, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func (c *Controller) applyEndpoints(ctx context.Context, ep, exist *corev1.Endpoints) error {
+	endpoints := c.client.CoreV1().Endpoints(ep.Namespace)
+	if exist == nil {
+		err := c.withTimeout(ctx, func(ctx context.Context) error {
+			_, err := endpoints.Create(ctx, ep, metav1.CreateOptions{})
+			return err
+		})
+		if apierrors.IsAlreadyExists(err) {
+			log.Warnf("[KubernetesSync] endpoints %s/%s already exists and is not managed by pole-server, skip",
+				ep.Namespace, ep.Name)
+			return nil
+		}
+		return err
+	}
+	if endpointsEqual(ep, exist) {
+		return nil
+	}
+	ep.ResourceVersion = exist.ResourceVersion
+	return c.withTimeout(ctx, func(ctx context.Context) error {
+		_, err := endpoints.Update(ctx, ep, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func serviceEqual(a, b *corev1.Service) bool {
+	return a.Annotations[AnnotationService] == b.Annotations[AnnotationService] &&
+		equality.Semantic.DeepEqual(a.Spec.Ports, b.Spec.Ports)
+}
+
+// endpointsEqual kubernetes 会对 Endpoints 的 subsets 重新分组，按照地址和端口的组合进行比较
+func endpointsEqual(a, b *corev1.Endpoints) bool {
+	keysA, keysB := endpointsKeys(a), endpointsKeys(b)
+	if len(keysA) != len(keysB) {
+		return false
+	}
+	for i := range keysA {
+		if keysA[i] != keysB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func endpointsKeys(ep *corev1.Endpoints) []string {
+	keys := make([]string, 0, len(ep.Subsets))
+	for _, subset := range ep.Subsets {
+		for _, address := range subset.Addresses {
+			for _, port := range subset.Ports {
+				keys = append(keys, fmt.Sprintf("%s/%s/%d/%s", address.IP, port.Name, port.Port, port.Protocol))
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,374 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package k8ssync
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+)
+
+// takeWrites 返回 fake clientset 上的写操作，格式为 verb-resource:namespace/name
+func takeWrites(client *fake.Clientset) []string {
+	var ret []string
+	for _, action := range client.Actions() {
+		var name string
+		switch a := action.(type) {
+		case k8stesting.CreateAction:
+			name = a.GetObject().(metav1.Object).GetName()
+		case k8stesting.UpdateAction:
+			name = a.GetObject().(metav1.Object).GetName()
+		case k8stesting.DeleteAction:
+			name = a.GetName()
+		default:
+			continue
+		}
+		ret = append(ret, action.GetVerb()+"-"+action.GetResource().Resource+":"+action.GetNamespace()+"/"+name)
+	}
+	client.ClearActions()
+	sort.Strings(ret)
+	return ret
+}
+
+// fakeRegistry 记录每个命名空间最近一次同步的实例
+type fakeRegistry struct {
+	lock     sync.Mutex
+	synced   map[string][]*apiservice.Instance
+	mirrored []string
+	natives  map[string]map[string][]*svctypes.Instance
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		synced:  map[string][]*apiservice.Instance{},
+		natives: map[string]map[string][]*svctypes.Instance{},
+	}
+}
+
+func (r *fakeRegistry) Sync(_ context.Context, namespace string, instances []*apiservice.Instance) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.synced[namespace] = instances
+	return nil
+}
+
+func (r *fakeRegistry) MirroredNamespaces() []string {
+	return r.mirrored
+}
+
+func (r *fakeRegistry) NativeServices(namespace string) map[string][]*svctypes.Instance {
+	return r.natives[namespace]
+}
+
+func (r *fakeRegistry) syncedOf(namespace string) ([]*apiservice.Instance, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	ret, ok := r.synced[namespace]
+	return ret, ok
+}
+
+func strPtr(v string) *string {
+	return &v
+}
+
+func newK8sService(namespace, name string, labels map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec: corev1.ServiceSpec{ClusterIP: "10.96.0.10", Ports: []corev1.ServicePort{{
+			Name: "http",
+			Port: 80,
+		}}},
+	}
+}
+
+func newSlice(namespace, service string, port int32, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+	protocol := corev1.ProtocolTCP
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      service + "-abcde",
+			Namespace: namespace,
+			Labels:    map[string]string{LabelServiceName: service},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints:   endpoints,
+		Ports: []discoveryv1.EndpointPort{{
+			Name:     strPtr("http"),
+			Protocol: &protocol,
+			Port:     &port,
+		}},
+	}
+}
+
+func newNativeInstance(host string, port uint32, healthy, isolate bool) *svctypes.Instance {
+	return &svctypes.Instance{Proto: &apiservice.Instance{
+		Host:    protobuf.NewStringValue(host),
+		Port:    protobuf.NewUInt32Value(port),
+		Healthy: protobuf.NewBoolValue(healthy),
+		Isolate: protobuf.NewBoolValue(isolate),
+	}}
+}
+
+func Test_toInstances(t *testing.T) {
+	notReady := false
+	cfg := &ToPoleConfig{NamespaceMapping: map[string]string{"prod": "production"}}
+	services := []*corev1.Service{
+		newK8sService("prod", "order", map[string]string{"app": "order"}),
+		newK8sService("prod", "pay", map[string]string{LabelManagedBy: ManagedByPole}),
+		newK8sService("dev", "order", nil),
+	}
+	slices := []*discoveryv1.EndpointSlice{
+		newSlice("prod", "order", 8080,
+			discoveryv1.Endpoint{Addresses: []string{"10.0.0.1"}, Zone: strPtr("zone-a"),
+				NodeName:  strPtr("node-1"),
+				TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "order-0"}},
+			discoveryv1.Endpoint{Addresses: []string{"10.0.0.2"},
+				Conditions: discoveryv1.EndpointConditions{Ready: &notReady}}),
+		// pole-server 回写的 Service 不会再同步回来
+		newSlice("prod", "pay", 8080, discoveryv1.Endpoint{Addresses: []string{"10.0.1.1"}}),
+		newSlice("dev", "order", 9090, discoveryv1.Endpoint{Addresses: []string{"10.0.2.1"}}),
+		// 没有对应 Service 的 EndpointSlice
+		newSlice("dev", "unknown", 9090, discoveryv1.Endpoint{Addresses: []string{"10.0.3.1"}}),
+	}
+	fqdn := newSlice("dev", "order", 9090, discoveryv1.Endpoint{Addresses: []string{"order.example.com"}})
+	fqdn.AddressType = discoveryv1.AddressTypeFQDN
+	slices = append(slices, fqdn)
+
+	ret := toInstances(cfg, services, slices)
+	assert.Equal(t, 2, len(ret))
+	assert.Equal(t, 2, len(ret["production"]))
+	assert.Equal(t, 1, len(ret["dev"]))
+
+	ins := ret["production"][0]
+	assert.Equal(t, "order", ins.GetService().GetValue())
+	assert.Equal(t, "production", ins.GetNamespace().GetValue())
+	assert.Equal(t, "10.0.0.1", ins.GetHost().GetValue())
+	assert.Equal(t, uint32(8080), ins.GetPort().GetValue())
+	assert.Equal(t, "tcp", ins.GetProtocol().GetValue())
+	assert.True(t, ins.GetHealthy().GetValue())
+	assert.Equal(t, "zone-a", ins.GetLocation().GetZone().GetValue())
+	assert.Equal(t, "order", ins.GetMetadata()["app"])
+	assert.Equal(t, "prod", ins.GetMetadata()[MetaKeyK8sNamespace])
+	assert.Equal(t, "order-0", ins.GetMetadata()[MetaKeyK8sPod])
+	assert.Equal(t, "node-1", ins.GetMetadata()[MetaKeyK8sNode])
+	assert.Equal(t, "http", ins.GetMetadata()[MetaKeyK8sPortName])
+	assert.False(t, ret["production"][1].GetHealthy().GetValue())
+}
+
+func Test_kubernetesName(t *testing.T) {
+	assert.Equal(t, "order", kubernetesName("order"))
+	name := kubernetesName("com.example.OrderService")
+	assert.Regexp(t, "^com-example-orderservice-[0-9a-f]{8}$", name)
+	assert.NotEqual(t, name, kubernetesName("com-example-orderservice"))
+	assert.Regexp(t, "^pole-9order-[0-9a-f]{8}$", kubernetesName("9order"))
+	for i := 1; i < 100; i++ {
+		name := kubernetesName(strings.Repeat("a.", i))
+		assert.LessOrEqual(t, len(name), maxNameLength)
+		assert.Regexp(t, "^[a-z][a-z0-9-]*[a-z0-9]$", name)
+	}
+}
+
+func Test_buildHeadless(t *testing.T) {
+	svc, ep := buildHeadless("default", "order", "pole", []*svctypes.Instance{
+		newNativeInstance("10.0.0.2", 8080, true, false),
+		newNativeInstance("10.0.0.1", 8080, true, false),
+		newNativeInstance("10.0.0.3", 8080, false, false),
+		newNativeInstance("10.0.0.4", 8080, true, true),
+		newNativeInstance("10.0.0.1", 9090, false, false),
+		newNativeInstance("order.example.com", 7070, true, false),
+	})
+	assert.Equal(t, ClusterIPNone, svc.Spec.ClusterIP)
+	assert.Empty(t, svc.Spec.Selector)
+	assert.Equal(t, ManagedByPole, svc.Labels[LabelManagedBy])
+	assert.Equal(t, "order", svc.Annotations[AnnotationService])
+	// 没有健康实例的端口依然保留在 Service 中
+	assert.Equal(t, []corev1.ServicePort{
+		{Name: "port-8080", Protocol: corev1.ProtocolTCP, Port: 8080, TargetPort: intstr.FromInt32(8080)},
+		{Name: "port-9090", Protocol: corev1.ProtocolTCP, Port: 9090, TargetPort: intstr.FromInt32(9090)},
+	}, svc.Spec.Ports)
+	assert.Equal(t, []corev1.EndpointSubset{{
+		Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}},
+		Ports:     []corev1.EndpointPort{{Name: "port-8080", Port: 8080, Protocol: corev1.ProtocolTCP}},
+	}}, ep.Subsets)
+}
+
+// startInformers 启动 informer 并等待首次同步完成
+func startInformers(t *testing.T, c *Controller) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	for _, factory := range c.factories {
+		factory.Start(ctx.Done())
+		factory.WaitForCacheSync(ctx.Done())
+	}
+}
+
+func Test_Controller_syncToPole(t *testing.T) {
+	client := fake.NewClientset(
+		newK8sService("prod", "order", nil),
+		newSlice("prod", "order", 8080, discoveryv1.Endpoint{Addresses: []string{"10.0.0.1"}}),
+	)
+	reg := newFakeRegistry()
+	reg.mirrored = []string{"staging"}
+	cfg := DefaultConfig()
+	c, err := newController(&cfg, client, reg, func() bool { return true })
+	assert.NoError(t, err)
+
+	// informer 未完成首次同步时不会修改实例
+	assert.Error(t, c.syncToPole(context.Background()))
+	_, ok := reg.syncedOf("prod")
+	assert.False(t, ok)
+
+	startInformers(t, c)
+	assert.NoError(t, c.syncToPole(context.Background()))
+	synced, _ := reg.syncedOf("prod")
+	assert.Equal(t, 1, len(synced))
+	// kubernetes 侧已经没有服务的命名空间需要清空镜像实例
+	synced, ok = reg.syncedOf("staging")
+	assert.True(t, ok)
+	assert.Empty(t, synced)
+	// 读取的是 informer 缓存，不会再请求 API server
+	for _, action := range client.Actions() {
+		assert.Contains(t, []string{"list", "watch"}, action.GetVerb())
+	}
+}
+
+func Test_Controller_syncToKubernetes(t *testing.T) {
+	// 用户自行创建的同名资源不会被覆盖
+	client := fake.NewClientset(
+		newK8sService("pole", "pay", nil),
+		&corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "pay", Namespace: "pole"}},
+	)
+	reg := newFakeRegistry()
+	reg.natives["default"] = map[string][]*svctypes.Instance{
+		"order": {newNativeInstance("10.0.0.1", 8080, true, false)},
+		"pay":   {newNativeInstance("10.0.0.2", 8080, true, false)},
+	}
+	cfg := DefaultConfig()
+	cfg.ToPole.Enable = false
+	cfg.ToKubernetes = ToKubernetesConfig{Enable: true, Namespaces: map[string]string{"default": "pole"}}
+	c, err := newController(&cfg, client, reg, func() bool { return true })
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	// 与用户资源同名时每轮都会尝试创建并被 API server 拒绝
+	rejected := func(writes ...string) []string {
+		return append([]string{"create-endpoints:pole/pay", "create-services:pole/pay"}, writes...)
+	}
+	assert.NoError(t, c.syncToKubernetes(ctx))
+	assert.Equal(t, []string{"create-endpoints:pole/order", "create-endpoints:pole/pay", "create-services:pole/order",
+		"create-services:pole/pay"}, takeWrites(client))
+	pay, err := client.CoreV1().Services("pole").Get(ctx, "pay", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, pay.Labels)
+
+	// 没有变化时不会写入
+	assert.NoError(t, c.syncToKubernetes(ctx))
+	assert.Equal(t, rejected(), takeWrites(client))
+
+	// kubernetes 对 subsets 重新分组后依然视为相同
+	ep, err := client.CoreV1().Endpoints("pole").Get(ctx, "order", metav1.GetOptions{})
+	assert.NoError(t, err)
+	ep.Subsets = []corev1.EndpointSubset{{
+		Ports:     []corev1.EndpointPort{{Name: "port-8080", Port: 8080, Protocol: corev1.ProtocolTCP}},
+		Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}},
+	}}
+	_, err = client.CoreV1().Endpoints("pole").Update(ctx, ep, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	client.ClearActions()
+	assert.NoError(t, c.syncToKubernetes(ctx))
+	assert.Equal(t, rejected(), takeWrites(client))
+
+	reg.natives["default"]["order"] = append(reg.natives["default"]["order"],
+		newNativeInstance("10.0.0.3", 8080, true, false), newNativeInstance("10.0.0.3", 9090, true, false))
+	assert.NoError(t, c.syncToKubernetes(ctx))
+	assert.Equal(t, rejected("update-endpoints:pole/order", "update-services:pole/order"), takeWrites(client))
+	svc, err := client.CoreV1().Services("pole").Get(ctx, "order", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(svc.Spec.Ports))
+
+	delete(reg.natives["default"], "order")
+	assert.NoError(t, c.syncToKubernetes(ctx))
+	assert.Equal(t, rejected("delete-endpoints:pole/order", "delete-services:pole/order"), takeWrites(client))
+	_, err = client.CoreV1().Services("pole").Get(ctx, "pay", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func Test_Controller_informer(t *testing.T) {
+	client := fake.NewClientset(newK8sService("prod", "order", nil))
+	// fake clientset 不会补发 watch 建立之前的事件，等待 watch 建立后再写入
+	watchStarted := make(chan struct{}, 2)
+	client.PrependWatchReactor("*", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		gvr := action.GetResource()
+		ns := action.GetNamespace()
+		w, err := client.Tracker().Watch(gvr, ns)
+		if err != nil {
+			return false, nil, err
+		}
+		watchStarted <- struct{}{}
+		return true, w, nil
+	})
+	reg := newFakeRegistry()
+	cfg := DefaultConfig()
+	cfg.Debounce = 10 * time.Millisecond
+	cfg.ToPole.Namespaces = []string{"prod"}
+	leader := false
+	var lock sync.Mutex
+	c, err := newController(&cfg, client, reg, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return leader
+	})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, c.start(ctx))
+	<-watchStarted
+	<-watchStarted
+
+	// follower 节点不执行同步
+	time.Sleep(50 * time.Millisecond)
+	_, ok := reg.syncedOf("prod")
+	assert.False(t, ok)
+
+	lock.Lock()
+	leader = true
+	lock.Unlock()
+	_, err = client.DiscoveryV1().EndpointSlices("prod").Create(ctx,
+		newSlice("prod", "order", 8080, discoveryv1.Endpoint{Addresses: []string{"10.0.0.1"}}),
+		metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		synced, _ := reg.syncedOf("prod")
+		return len(synced) == 1
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,233 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package k8ssync
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+)
+
+const (
+	// MetaKeyK8sNamespace 实例所在的 kubernetes 命名空间
+	MetaKeyK8sNamespace = "k8s-namespace"
+	// MetaKeyK8sService 实例所属的 kubernetes Service
+	MetaKeyK8sService = "k8s-service"
+	// MetaKeyK8sPortName 实例端口在 Service 中的名称
+	MetaKeyK8sPortName = "k8s-port-name"
+	// MetaKeyK8sPod 实例对应的 Pod
+	MetaKeyK8sPod = "k8s-pod"
+	// MetaKeyK8sNode 实例所在的节点
+	MetaKeyK8sNode = "k8s-node"
+
+	// defaultWeight 同步到 pole-server 的实例权重
+	defaultWeight = 100
+	// maxNameLength kubernetes Service 名称的最大长度
+	maxNameLength = 63
+)
+
+// isManagedByPole kubernetes 资源是否是 pole-server 回写的
+func isManagedByPole(meta *metav1.ObjectMeta) bool {
+	return meta.Labels[LabelManagedBy] == ManagedByPole
+}
+
+// toInstances 将 kubernetes 的 Service 以及 EndpointSlice 转换为 pole-server 的实例，按照 pole-server 的命名空间分组。
+// pole-server 回写的 Service 不会再同步回来
+func toInstances(cfg *ToPoleConfig, services []*corev1.Service,
+	slices []*discoveryv1.EndpointSlice) map[string][]*apiservice.Instance {
+	index := make(map[string]*corev1.Service, len(services))
+	for _, svc := range services {
+		if isManagedByPole(&svc.ObjectMeta) {
+			continue
+		}
+		index[svc.Namespace+"/"+svc.Name] = svc
+	}
+
+	ret := map[string][]*apiservice.Instance{}
+	for _, slice := range slices {
+		if slice.AddressType != discoveryv1.AddressTypeIPv4 && slice.AddressType != discoveryv1.AddressTypeIPv6 {
+			continue
+		}
+		svc, ok := index[slice.Namespace+"/"+slice.Labels[LabelServiceName]]
+		if !ok {
+			continue
+		}
+		namespace := cfg.poleNamespace(svc.Namespace)
+		for _, endpoint := range slice.Endpoints {
+			for _, port := range slice.Ports {
+				if port.Port == nil {
+					continue
+				}
+				for _, address := range endpoint.Addresses {
+					ret[namespace] = append(ret[namespace], toInstance(namespace, svc, &endpoint, &port, address))
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// toInstance Endpoint 的 ready 状态映射为实例的健康状态，Service 的标签作为实例的元数据
+func toInstance(namespace string, svc *corev1.Service, endpoint *discoveryv1.Endpoint,
+	port *discoveryv1.EndpointPort, address string) *apiservice.Instance {
+	metadata := make(map[string]string, len(svc.Labels)+5)
+	for k, v := range svc.Labels {
+		metadata[k] = v
+	}
+	metadata[MetaKeyK8sNamespace] = svc.Namespace
+	metadata[MetaKeyK8sService] = svc.Name
+	if name := stringValue(port.Name); name != "" {
+		metadata[MetaKeyK8sPortName] = name
+	}
+	if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+		metadata[MetaKeyK8sPod] = endpoint.TargetRef.Name
+	}
+	if node := stringValue(endpoint.NodeName); node != "" {
+		metadata[MetaKeyK8sNode] = node
+	}
+
+	protocol := stringValue(port.AppProtocol)
+	if protocol == "" && port.Protocol != nil {
+		protocol = strings.ToLower(string(*port.Protocol))
+	}
+	ins := &apiservice.Instance{
+		Service:   &wrappers.StringValue{Value: svc.Name},
+		Namespace: &wrappers.StringValue{Value: namespace},
+		Host:      &wrappers.StringValue{Value: address},
+		Port:      &wrappers.UInt32Value{Value: uint32(*port.Port)},
+		Protocol:  &wrappers.StringValue{Value: protocol},
+		Weight:    &wrappers.UInt32Value{Value: defaultWeight},
+		Healthy:   &wrappers.BoolValue{Value: boolValue(endpoint.Conditions.Ready, true)},
+		Metadata:  metadata,
+	}
+	if zone := stringValue(endpoint.Zone); zone != "" {
+		ins.Location = &apimodel.Location{Zone: &wrappers.StringValue{Value: zone}}
+	}
+	return ins
+}
+
+// kubernetesName 将 pole-server 的服务名转换为合法的 kubernetes Service 名称（RFC 1035 label），
+// 名称被改写时追加哈希后缀避免冲突
+func kubernetesName(name string) string {
+	var buf strings.Builder
+	for _, c := range strings.ToLower(name) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			buf.WriteRune(c)
+		} else {
+			buf.WriteByte('-')
+		}
+	}
+	ret := strings.Trim(buf.String(), "-")
+	if ret == "" || (ret[0] >= '0' && ret[0] <= '9') {
+		ret = "pole-" + ret
+	}
+	if ret == name && len(ret) <= maxNameLength {
+		return ret
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	suffix := fmt.Sprintf("-%08x", h.Sum32())
+	if len(ret) > maxNameLength-len(suffix) {
+		ret = strings.TrimRight(ret[:maxNameLength-len(suffix)], "-")
+	}
+	return ret + suffix
+}
+
+// portName 回写的 Service 中端口的名称，多个端口时 kubernetes 要求每个端口都有名称
+func portName(port uint32) string {
+	return fmt.Sprintf("port-%d", port)
+}
+
+// buildHeadless 将 pole-server 的服务构造为没有 selector 的 headless Service 以及对应的 Endpoints，
+// 只有健康、未隔离的实例会出现在 Endpoints 中
+func buildHeadless(poleNamespace, serviceName, k8sNamespace string,
+	instances []*svctypes.Instance) (*corev1.Service, *corev1.Endpoints) {
+	meta := metav1.ObjectMeta{
+		Name:      kubernetesName(serviceName),
+		Namespace: k8sNamespace,
+		Labels:    map[string]string{LabelManagedBy: ManagedByPole},
+		Annotations: map[string]string{
+			AnnotationNamespace: poleNamespace,
+			AnnotationService:   serviceName,
+		},
+	}
+	addresses := map[uint32][]string{}
+	for _, ins := range instances {
+		if net.ParseIP(ins.Host()) == nil || ins.Port() == 0 {
+			continue
+		}
+		if _, ok := addresses[ins.Port()]; !ok {
+			addresses[ins.Port()] = []string{}
+		}
+		if ins.Healthy() && !ins.Isolate() {
+			addresses[ins.Port()] = append(addresses[ins.Port()], ins.Host())
+		}
+	}
+	ports := make([]uint32, 0, len(addresses))
+	for port := range addresses {
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i] < ports[j]
+	})
+
+	svc := &corev1.Service{
+		ObjectMeta: meta,
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: ClusterIPNone,
+			Ports:     []corev1.ServicePort{},
+		},
+	}
+	ep := &corev1.Endpoints{ObjectMeta: *meta.DeepCopy(), Subsets: []corev1.EndpointSubset{}}
+	for _, port := range ports {
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+			Name:       portName(port),
+			Protocol:   corev1.ProtocolTCP,
+			Port:       int32(port),
+			TargetPort: intstr.FromInt32(int32(port)),
+		})
+		hosts := addresses[port]
+		if len(hosts) == 0 {
+			continue
+		}
+		sort.Strings(hosts)
+		subset := corev1.EndpointSubset{Ports: []corev1.EndpointPort{{
+			Name:     portName(port),
+			Port:     int32(port),
+			Protocol: corev1.ProtocolTCP,
+		}}}
+		for _, host := range hosts {
+			subset.Addresses = append(subset.Addresses, corev1.EndpointAddress{IP: host})
+		}
+		ep.Subsets = append(ep.Subsets, subset)
+	}
+	return svc, ep
+}
@@ -0,0 +1,101 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package k8ssync
+
+import (
+	"context"
+	"sync"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/pkg/service"
+	"github.com/pole-io/pole-server/pkg/service/migrate"
+)
+
+// SourceKubernetes 从 kubernetes 同步过来的实例在 migrate-from 元数据中的取值
+const SourceKubernetes = "kubernetes"
+
+// registry pole-server 侧的实例读写，便于在测试中替换
+type registry interface {
+	// Sync 以 instances 为准，对命名空间下从 kubernetes 同步过来的实例进行增删改
+	Sync(ctx context.Context, namespace string, instances []*apiservice.Instance) error
+	// MirroredNamespaces 曾经同步过实例的命名空间，kubernetes 侧的服务全部删除后仍需要清理这些命名空间
+	MirroredNamespaces() []string
+	// NativeServices 命名空间下直接注册到 pole-server 的实例，key 为服务名
+	NativeServices(namespace string) map[string][]*svctypes.Instance
+}
+
+// poleRegistry 基于 migrate.Mirror 实现的 registry
+type poleRegistry struct {
+	svr     service.DiscoverServer
+	lock    sync.Mutex
+	mirrors map[string]*migrate.Mirror
+}
+
+func newPoleRegistry(svr service.DiscoverServer) *poleRegistry {
+	return &poleRegistry{
+		svr:     svr,
+		mirrors: map[string]*migrate.Mirror{},
+	}
+}
+
+func (r *poleRegistry) mirror(namespace string) *migrate.Mirror {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	m, ok := r.mirrors[namespace]
+	if !ok {
+		m = migrate.NewMirror(r.svr, namespace, SourceKubernetes)
+		r.mirrors[namespace] = m
+	}
+	return m
+}
+
+func (r *poleRegistry) Sync(ctx context.Context, namespace string, instances []*apiservice.Instance) error {
+	return r.mirror(namespace).Sync(ctx, instances)
+}
+
+// MirroredNamespaces 从缓存中查找，保证重启后仍然能够清理 kubernetes 侧已经删除的服务
+func (r *poleRegistry) MirroredNamespaces() []string {
+	namespaces := map[string]struct{}{}
+	cacheMgr := r.svr.Cache()
+	_ = cacheMgr.Service().IteratorServices(func(_ string, svc *svctypes.Service) (bool, error) {
+		if _, ok := namespaces[svc.Namespace]; ok {
+			return true, nil
+		}
+		_ = cacheMgr.Instance().IteratorInstancesWithService(svc.ID,
+			func(_ string, ins *svctypes.Instance) (bool, error) {
+				if ins.Metadata()[types.MetaKeyMigrateFrom] == SourceKubernetes {
+					namespaces[svc.Namespace] = struct{}{}
+					return false, nil
+				}
+				return true, nil
+			})
+		return true, nil
+	})
+	ret := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		ret = append(ret, namespace)
+	}
+	return ret
+}
+
+func (r *poleRegistry) NativeServices(namespace string) map[string][]*svctypes.Instance {
+	return r.mirror(namespace).NativeInstances()
+}
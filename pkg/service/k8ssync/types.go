@@ -0,0 +1,53 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package k8ssync
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+)
+
+const (
+	// LabelManagedBy kubernetes 推荐的资源管理者标签，pole-server 创建的资源都带有该标签
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	// ManagedByPole LabelManagedBy 的取值
+	ManagedByPole = "pole-server"
+	// LabelServiceName EndpointSlice 所属 Service 的标签
+	LabelServiceName = discoveryv1.LabelServiceName
+	// AnnotationNamespace 回写的 Service 对应的 pole 命名空间
+	AnnotationNamespace = "pole.io/namespace"
+	// AnnotationService 回写的 Service 对应的 pole 服务名
+	AnnotationService = "pole.io/service"
+
+	// ClusterIPNone headless Service 的 clusterIP
+	ClusterIPNone = corev1.ClusterIPNone
+)
+
+func boolValue(v *bool, defaultValue bool) bool {
+	if v == nil {
+		return defaultValue
+	}
+	return *v
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
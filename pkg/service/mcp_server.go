@@ -0,0 +1,286 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+	"github.com/pole-io/pole-server/pkg/common/valid"
+)
+
+const (
+	// mcpContractPageSize 分页加载 MCP Server 契约的每页大小
+	mcpContractPageSize = 100
+)
+
+// mcpCapabilityContent 能力在契约接口 content 字段中保存的内容
+type mcpCapabilityContent struct {
+	Description string          `json:"description"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+}
+
+// RegisterMCPServer 注册 MCP Server，能力清单全量覆盖，接入点注册为服务实例
+func (s *Server) RegisterMCPServer(ctx context.Context, req *svctypes.MCPServer) (*svctypes.MCPServer, error) {
+	contract := mcpServerContract(req)
+	if rsp := s.CreateServiceContract(ctx, contract); !isMCPWriteSuccess(rsp) {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+
+	var err error
+	req.Capabilities(func(kind string, item *svctypes.MCPCapability) {
+		if err != nil {
+			return
+		}
+		var content []byte
+		content, err = json.Marshal(&mcpCapabilityContent{Description: item.Description, Schema: item.Schema})
+		contract.Interfaces = append(contract.Interfaces, &apiservice.InterfaceDescriptor{
+			Method:  kind,
+			Path:    item.Name,
+			Content: string(content),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	rsp := s.CreateServiceContractInterfaces(ctx, contract, apiservice.InterfaceDescriptor_Manual)
+	if !isMCPWriteSuccess(rsp) {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+
+	for _, endpoint := range req.Endpoints {
+		ins := mcpEndpointInstance(req, endpoint)
+		rsp := s.CreateInstance(ctx, ins)
+		if rsp.GetCode().GetValue() == api.ExistedResource {
+			rsp = s.UpdateInstance(ctx, ins)
+		}
+		if !isMCPWriteSuccess(rsp) {
+			log.Error("[Service][MCP] register mcp server endpoint", utils.RequestID(ctx),
+				zap.String("namespace", req.Namespace), zap.String("name", req.Name),
+				zap.String("host", endpoint.Host), zap.Uint32("port", endpoint.Port),
+				zap.String("info", rsp.GetInfo().GetValue()))
+			return nil, errors.New(rsp.GetInfo().GetValue())
+		}
+		endpoint.InstanceID = ins.GetId().GetValue()
+	}
+	log.Info("[Service][MCP] register mcp server", utils.RequestID(ctx), zap.String("namespace", req.Namespace),
+		zap.String("name", req.Name), zap.String("version", req.Version), zap.Int("tools", len(req.Tools)))
+	return req, nil
+}
+
+// DeregisterMCPServer 反注册 MCP Server，删除能力清单以及对应版本的接入点
+func (s *Server) DeregisterMCPServer(ctx context.Context, req *svctypes.MCPServer) error {
+	if rsp := s.DeleteServiceContract(ctx, mcpServerContract(req)); !isMCPWriteSuccess(rsp) {
+		return errors.New(rsp.GetInfo().GetValue())
+	}
+
+	endpoints := req.Endpoints
+	if len(endpoints) == 0 {
+		// 未指定接入点时删除该版本全部的接入点
+		endpoints = s.loadMCPEndpoints(req.Namespace, req.Name, req.Version)
+	}
+	for _, endpoint := range endpoints {
+		ins := mcpEndpointInstance(req, endpoint)
+		rsp := s.DeleteInstance(ctx, ins)
+		if !isMCPWriteSuccess(rsp) && rsp.GetCode().GetValue() != api.NotFoundInstance {
+			return errors.New(rsp.GetInfo().GetValue())
+		}
+	}
+	log.Info("[Service][MCP] deregister mcp server", utils.RequestID(ctx), zap.String("namespace", req.Namespace),
+		zap.String("name", req.Name), zap.String("version", req.Version))
+	return nil
+}
+
+// GetMCPServers 查询 MCP Server，支持按照 tool 名称（支持 * 通配）查找提供该能力的 MCP Server
+func (s *Server) GetMCPServers(ctx context.Context, query map[string]string) (uint32, []*svctypes.MCPServer, error) {
+	offset, limit, err := valid.ParseOffsetAndLimit(query)
+	if err != nil {
+		return 0, nil, err
+	}
+	healthyOnly, _ := strconv.ParseBool(query["healthy_only"])
+	tool := query["tool"]
+
+	filter := map[string]string{
+		"type":     svctypes.MCPContractType,
+		"protocol": svctypes.MCPContractProtocol,
+	}
+	for k, storeKey := range map[string]string{"namespace": "namespace", "name": "service", "version": "version"} {
+		if v := query[k]; v != "" {
+			filter[storeKey] = v
+		}
+	}
+
+	servers := make([]*svctypes.MCPServer, 0, mcpContractPageSize)
+	for page := uint32(0); ; page += mcpContractPageSize {
+		pageFilter := make(map[string]string, len(filter))
+		for k, v := range filter {
+			pageFilter[k] = v
+		}
+		total, contracts, err := s.storage.GetServiceContracts(ctx, pageFilter, page, mcpContractPageSize)
+		if err != nil {
+			log.Error("[Service][MCP] query mcp server contracts", utils.RequestID(ctx), zap.Error(err))
+			return 0, nil, err
+		}
+		for _, contract := range contracts {
+			server := toMCPServer(contract)
+			if tool != "" && !server.HasTool(func(name string) bool { return utils.IsWildMatch(name, tool) }) {
+				continue
+			}
+			server.Endpoints = s.loadMCPEndpoints(server.Namespace, server.Name, server.Version)
+			server.Status = svctypes.MCPServerOffline
+			for _, endpoint := range server.Endpoints {
+				if endpoint.Healthy && !endpoint.Isolate {
+					server.Status = svctypes.MCPServerOnline
+					break
+				}
+			}
+			if healthyOnly && server.Status != svctypes.MCPServerOnline {
+				continue
+			}
+			servers = append(servers, server)
+		}
+		if len(contracts) == 0 || page+uint32(len(contracts)) >= total {
+			break
+		}
+	}
+
+	amount := uint32(len(servers))
+	if offset >= amount {
+		return amount, []*svctypes.MCPServer{}, nil
+	}
+	end := offset + limit
+	if end > amount {
+		end = amount
+	}
+	return amount, servers[offset:end], nil
+}
+
+// loadMCPEndpoints 从缓存中加载 MCP Server 某个版本的接入点
+func (s *Server) loadMCPEndpoints(namespace, name, version string) []*svctypes.MCPEndpoint {
+	endpoints := make([]*svctypes.MCPEndpoint, 0, 4)
+	svc := s.caches.Service().GetServiceByName(name, namespace)
+	if svc == nil {
+		return endpoints
+	}
+	for _, ins := range s.caches.Instance().GetInstancesByServiceID(svc.ID) {
+		transport, ok := ins.Metadata()[svctypes.MetadataMCPTransport]
+		if !ok || ins.Version() != version {
+			continue
+		}
+		endpoints = append(endpoints, &svctypes.MCPEndpoint{
+			InstanceID: ins.ID(),
+			Transport:  transport,
+			Host:       ins.Host(),
+			Port:       ins.Port(),
+			Path:       ins.Metadata()[svctypes.MetadataMCPEndpointPath],
+			TTL:        ins.HealthCheck().GetHeartbeat().GetTtl().GetValue(),
+			Healthy:    ins.Healthy(),
+			Isolate:    ins.Isolate(),
+		})
+	}
+	return endpoints
+}
+
+// mcpServerContract MCP Server 对应的服务契约
+func mcpServerContract(req *svctypes.MCPServer) *apiservice.ServiceContract {
+	contract := &apiservice.ServiceContract{
+		Name:      svctypes.MCPContractType,
+		Type:      svctypes.MCPContractType,
+		Namespace: req.Namespace,
+		Service:   req.Name,
+		Protocol:  svctypes.MCPContractProtocol,
+		Version:   req.Version,
+		Content:   req.Description,
+	}
+	contract.Id, _ = valid.CheckContractTetrad(contract)
+	return contract
+}
+
+// mcpEndpointInstance MCP Server 接入点对应的服务实例
+func mcpEndpointInstance(req *svctypes.MCPServer, endpoint *svctypes.MCPEndpoint) *apiservice.Instance {
+	ins := &apiservice.Instance{
+		Namespace: protobuf.NewStringValue(req.Namespace),
+		Service:   protobuf.NewStringValue(req.Name),
+		Host:      protobuf.NewStringValue(endpoint.Host),
+		Port:      protobuf.NewUInt32Value(endpoint.Port),
+		Protocol:  protobuf.NewStringValue(endpoint.Transport),
+		Version:   protobuf.NewStringValue(req.Version),
+		Metadata: map[string]string{
+			svctypes.MetadataMCPTransport:    endpoint.Transport,
+			svctypes.MetadataMCPEndpointPath: endpoint.Path,
+		},
+	}
+	if endpoint.TTL > 0 {
+		ins.EnableHealthCheck = protobuf.NewBoolValue(true)
+		ins.HealthCheck = &apiservice.HealthCheck{
+			Type: apiservice.HealthCheck_HEARTBEAT,
+			Heartbeat: &apiservice.HeartbeatHealthCheck{
+				Ttl: protobuf.NewUInt32Value(endpoint.TTL),
+			},
+		}
+	}
+	ins.Id = protobuf.NewStringValue(endpoint.InstanceID)
+	if endpoint.InstanceID == "" {
+		insID, _ := valid.CheckInstanceTetrad(ins)
+		ins.Id = protobuf.NewStringValue(insID)
+	}
+	return ins
+}
+
+// toMCPServer 将服务契约转换为 MCP Server
+func toMCPServer(contract *svctypes.EnrichServiceContract) *svctypes.MCPServer {
+	server := &svctypes.MCPServer{
+		Namespace:   contract.Namespace,
+		Name:        contract.Service,
+		Version:     contract.Version,
+		Description: contract.Content,
+		Revision:    contract.Revision,
+		Tools:       []*svctypes.MCPCapability{},
+		Prompts:     []*svctypes.MCPCapability{},
+		Resources:   []*svctypes.MCPCapability{},
+	}
+	for _, item := range contract.Interfaces {
+		content := &mcpCapabilityContent{}
+		if item.Content != "" {
+			if err := json.Unmarshal([]byte(item.Content), content); err != nil {
+				log.Warn("[Service][MCP] unmarshal mcp capability content", zap.String("id", item.ID),
+					zap.Error(err))
+			}
+		}
+		server.AddCapability(item.Method, &svctypes.MCPCapability{
+			Name:        item.Path,
+			Description: content.Description,
+			Schema:      content.Schema,
+		})
+	}
+	return server
+}
+
+func isMCPWriteSuccess(rsp *apiservice.Response) bool {
+	code := rsp.GetCode().GetValue()
+	return code == api.ExecuteSuccess || code == api.NoNeedUpdate
+}
@@ -0,0 +1,86 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+)
+
+func Test_mcpServerContract(t *testing.T) {
+	server := &svctypes.MCPServer{
+		Namespace: "default",
+		Name:      "weather",
+		Version:   "1.0.0",
+		Endpoints: []*svctypes.MCPEndpoint{
+			{Transport: "streamable-http", Host: "127.0.0.1", Port: 8080, Path: "/mcp", TTL: 5},
+		},
+	}
+
+	contract := mcpServerContract(server)
+	assert.NotEmpty(t, contract.Id)
+	assert.Equal(t, contract.Id, mcpServerContract(server).Id)
+	assert.Equal(t, svctypes.MCPContractType, contract.Type)
+	assert.Equal(t, svctypes.MCPContractProtocol, contract.Protocol)
+
+	ins := mcpEndpointInstance(server, server.Endpoints[0])
+	assert.NotEmpty(t, ins.GetId().GetValue())
+	assert.Equal(t, "1.0.0", ins.GetVersion().GetValue())
+	assert.Equal(t, "/mcp", ins.GetMetadata()[svctypes.MetadataMCPEndpointPath])
+	assert.Equal(t, "streamable-http", ins.GetMetadata()[svctypes.MetadataMCPTransport])
+	assert.True(t, ins.GetEnableHealthCheck().GetValue())
+	assert.Equal(t, apiservice.HealthCheck_HEARTBEAT, ins.GetHealthCheck().GetType())
+	assert.Equal(t, uint32(5), ins.GetHealthCheck().GetHeartbeat().GetTtl().GetValue())
+}
+
+func Test_toMCPServer(t *testing.T) {
+	toolContent, _ := json.Marshal(&mcpCapabilityContent{
+		Description: "query weather",
+		Schema:      json.RawMessage(`{"type":"object"}`),
+	})
+	contract := &svctypes.EnrichServiceContract{
+		ServiceContract: &svctypes.ServiceContract{
+			Namespace: "default",
+			Service:   "weather",
+			Version:   "1.0.0",
+			Content:   "weather mcp server",
+		},
+		Interfaces: []*svctypes.InterfaceDescriptor{
+			{Method: svctypes.MCPCapabilityTool, Path: "query_weather", Content: string(toolContent)},
+			{Method: svctypes.MCPCapabilityPrompt, Path: "forecast"},
+			{Method: svctypes.MCPCapabilityResource, Path: "weather://{city}"},
+		},
+	}
+
+	server := toMCPServer(contract)
+	assert.Equal(t, "weather", server.Name)
+	assert.Equal(t, "weather mcp server", server.Description)
+	assert.Len(t, server.Tools, 1)
+	assert.Equal(t, "query weather", server.Tools[0].Description)
+	assert.JSONEq(t, `{"type":"object"}`, string(server.Tools[0].Schema))
+	assert.Len(t, server.Prompts, 1)
+	assert.Len(t, server.Resources, 1)
+
+	assert.True(t, server.HasTool(func(name string) bool { return name == "query_weather" }))
+	assert.False(t, server.HasTool(func(name string) bool { return name == "forecast" }))
+}
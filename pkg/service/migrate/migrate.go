@@ -0,0 +1,244 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package migrate
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/apis/store"
+	commonlog "github.com/pole-io/pole-server/pkg/common/log"
+	"github.com/pole-io/pole-server/pkg/common/valid"
+	"github.com/pole-io/pole-server/pkg/service"
+)
+
+var log = commonlog.GetScopeOrDefaultByName(commonlog.NamingLoggerName)
+
+// StartElection 参与存量注册中心迁移任务的选主，同一时刻只有 leader 节点执行迁移同步
+func StartElection() (func() bool, error) {
+	storage, err := store.GetStore()
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.StartLeaderElection(store.ElectionKeyLegacyMigration); err != nil {
+		return nil, err
+	}
+	return func() bool {
+		return storage.IsLeader(store.ElectionKeyLegacyMigration)
+	}, nil
+}
+
+// IsMirrored 实例是否是从存量注册中心迁移过来的
+func IsMirrored(metadata map[string]string) bool {
+	_, ok := metadata[types.MetaKeyMigrateFrom]
+	return ok
+}
+
+// IsWriteBack 存量注册中心中的实例是否是由 pole-server 回写过去的
+func IsWriteBack(metadata map[string]string) bool {
+	_, ok := metadata[types.MetaKeyMigrateWriteBack]
+	return ok
+}
+
+// Mirror 将存量注册中心的实例镜像到 pole-server 的某个命名空间下
+type Mirror struct {
+	svr       service.DiscoverServer
+	namespace string
+	source    string
+}
+
+// NewMirror source 为存量注册中心的类型，用于区分不同来源镜像过来的实例
+func NewMirror(svr service.DiscoverServer, namespace, source string) *Mirror {
+	return &Mirror{
+		svr:       svr,
+		namespace: namespace,
+		source:    source,
+	}
+}
+
+// Namespace 镜像的目标命名空间
+func (m *Mirror) Namespace() string {
+	return m.namespace
+}
+
+// Sync 以存量注册中心的实例列表为准，对目标命名空间下镜像过来的实例进行增删改
+func (m *Mirror) Sync(ctx context.Context, instances []*apiservice.Instance) error {
+	desired := make(map[string]*apiservice.Instance, len(instances))
+	for i := range instances {
+		ins := m.prepare(instances[i])
+		id, errRsp := valid.CheckInstanceTetrad(ins)
+		if errRsp != nil {
+			log.Warn("[Migrate] skip invalid legacy instance", zap.String("source", m.source),
+				zap.String("service", ins.GetService().GetValue()), zap.String("host", ins.GetHost().GetValue()),
+				zap.String("info", errRsp.GetInfo().GetValue()))
+			continue
+		}
+		// 客户端已经直接注册到 pole-server 的实例优先，不使用存量注册中心的数据覆盖
+		if exist := m.svr.Cache().Instance().GetInstance(id); exist != nil && !IsMirrored(exist.Metadata()) {
+			continue
+		}
+		ins.Id = &wrappers.StringValue{Value: id}
+		desired[id] = ins
+	}
+
+	creates, updates, deletes := Plan(desired, m.mirrored())
+	var errs []error
+	for i := range creates {
+		if err := m.register(ctx, creates[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for i := range updates {
+		resp := m.svr.UpdateInstance(ctx, updates[i])
+		if !isSuccess(resp.GetCode().GetValue()) {
+			errs = append(errs, errors.New(resp.GetInfo().GetValue()))
+		}
+	}
+	for i := range deletes {
+		resp := m.svr.DeregisterInstance(ctx, deletes[i])
+		if !isSuccess(resp.GetCode().GetValue()) && resp.GetCode().GetValue() != uint32(apimodel.Code_NotFoundResource) {
+			errs = append(errs, errors.New(resp.GetInfo().GetValue()))
+		}
+	}
+	if len(creates)+len(updates)+len(deletes) > 0 {
+		log.Info("[Migrate] mirror legacy instances", zap.String("source", m.source),
+			zap.String("namespace", m.namespace), zap.Int("create", len(creates)),
+			zap.Int("update", len(updates)), zap.Int("delete", len(deletes)), zap.Int("fail", len(errs)))
+	}
+	return errors.Join(errs...)
+}
+
+// NativeInstances 目标命名空间下直接注册到 pole-server 的实例，key 为服务名
+func (m *Mirror) NativeInstances() map[string][]*svctypes.Instance {
+	ret := map[string][]*svctypes.Instance{}
+	m.iterate(func(svc *svctypes.Service, ins *svctypes.Instance) {
+		if IsMirrored(ins.Metadata()) {
+			return
+		}
+		ret[svc.Name] = append(ret[svc.Name], ins)
+	})
+	return ret
+}
+
+// mirrored 目标命名空间下当前由本来源镜像过来的实例
+func (m *Mirror) mirrored() map[string]*apiservice.Instance {
+	ret := map[string]*apiservice.Instance{}
+	m.iterate(func(svc *svctypes.Service, ins *svctypes.Instance) {
+		if ins.Metadata()[types.MetaKeyMigrateFrom] == m.source {
+			ret[ins.ID()] = ins.Proto
+		}
+	})
+	return ret
+}
+
+func (m *Mirror) iterate(handle func(svc *svctypes.Service, ins *svctypes.Instance)) {
+	cacheMgr := m.svr.Cache()
+	_ = cacheMgr.Service().IteratorServices(func(_ string, svc *svctypes.Service) (bool, error) {
+		if svc.Namespace != m.namespace {
+			return true, nil
+		}
+		_ = cacheMgr.Instance().IteratorInstancesWithService(svc.ID,
+			func(_ string, ins *svctypes.Instance) (bool, error) {
+				handle(svc, ins)
+				return true, nil
+			})
+		return true, nil
+	})
+}
+
+// prepare 镜像实例不参与 pole-server 的健康检查，健康状态完全以存量注册中心为准
+func (m *Mirror) prepare(ins *apiservice.Instance) *apiservice.Instance {
+	ins.Namespace = &wrappers.StringValue{Value: m.namespace}
+	if ins.Metadata == nil {
+		ins.Metadata = map[string]string{}
+	}
+	ins.Metadata[types.MetaKeyMigrateFrom] = m.source
+	ins.EnableHealthCheck = &wrappers.BoolValue{Value: false}
+	ins.HealthCheck = nil
+	return ins
+}
+
+func (m *Mirror) register(ctx context.Context, ins *apiservice.Instance) error {
+	resp := m.svr.RegisterInstance(ctx, ins)
+	if resp.GetCode().GetValue() == uint32(apimodel.Code_NotFoundResource) {
+		svcResp := m.svr.CreateServices(ctx, []*apiservice.Service{{
+			Namespace: ins.GetNamespace(),
+			Name:      ins.GetService(),
+		}})
+		if code := svcResp.GetCode().GetValue(); !isSuccess(code) {
+			return errors.New(svcResp.GetInfo().GetValue())
+		}
+		resp = m.svr.RegisterInstance(ctx, ins)
+	}
+	if !isSuccess(resp.GetCode().GetValue()) {
+		return errors.New(resp.GetInfo().GetValue())
+	}
+	return nil
+}
+
+// Plan 对比期望的实例与当前已镜像的实例，计算需要新增、更新、删除的实例
+func Plan(desired, current map[string]*apiservice.Instance) (creates, updates, deletes []*apiservice.Instance) {
+	for id, ins := range desired {
+		exist, ok := current[id]
+		if !ok {
+			creates = append(creates, ins)
+			continue
+		}
+		if !Equal(exist, ins) {
+			updates = append(updates, ins)
+		}
+	}
+	for id, ins := range current {
+		if _, ok := desired[id]; !ok {
+			deletes = append(deletes, ins)
+		}
+	}
+	return creates, updates, deletes
+}
+
+// Equal 判断两个实例中迁移关心的属性是否一致
+func Equal(a, b *apiservice.Instance) bool {
+	if a.GetHost().GetValue() != b.GetHost().GetValue() ||
+		a.GetPort().GetValue() != b.GetPort().GetValue() ||
+		a.GetProtocol().GetValue() != b.GetProtocol().GetValue() ||
+		a.GetWeight().GetValue() != b.GetWeight().GetValue() ||
+		a.GetHealthy().GetValue() != b.GetHealthy().GetValue() ||
+		a.GetIsolate().GetValue() != b.GetIsolate().GetValue() {
+		return false
+	}
+	if len(a.GetMetadata()) != len(b.GetMetadata()) {
+		return false
+	}
+	for k, v := range a.GetMetadata() {
+		if bv, ok := b.GetMetadata()[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func isSuccess(code uint32) bool {
+	return code == uint32(apimodel.Code_ExecuteSuccess) || code == uint32(apimodel.Code_ExistedResource)
+}
@@ -0,0 +1,80 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package migrate
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+)
+
+func newInstance(host string, port uint32, healthy bool, metadata map[string]string) *apiservice.Instance {
+	return &apiservice.Instance{
+		Service:   &wrappers.StringValue{Value: "svc"},
+		Namespace: &wrappers.StringValue{Value: "default"},
+		Host:      &wrappers.StringValue{Value: host},
+		Port:      &wrappers.UInt32Value{Value: port},
+		Weight:    &wrappers.UInt32Value{Value: 100},
+		Healthy:   &wrappers.BoolValue{Value: healthy},
+		Metadata:  metadata,
+	}
+}
+
+func TestPlan(t *testing.T) {
+	current := map[string]*apiservice.Instance{
+		"keep":   newInstance("127.0.0.1", 8080, true, map[string]string{"k": "v"}),
+		"change": newInstance("127.0.0.2", 8080, true, nil),
+		"remove": newInstance("127.0.0.3", 8080, true, nil),
+	}
+	desired := map[string]*apiservice.Instance{
+		"keep":   newInstance("127.0.0.1", 8080, true, map[string]string{"k": "v"}),
+		"change": newInstance("127.0.0.2", 8080, false, nil),
+		"add":    newInstance("127.0.0.4", 8080, true, nil),
+	}
+
+	creates, updates, deletes := Plan(desired, current)
+	assert.Len(t, creates, 1)
+	assert.Equal(t, "127.0.0.4", creates[0].GetHost().GetValue())
+	assert.Len(t, updates, 1)
+	assert.Equal(t, "127.0.0.2", updates[0].GetHost().GetValue())
+	assert.Len(t, deletes, 1)
+	assert.Equal(t, "127.0.0.3", deletes[0].GetHost().GetValue())
+}
+
+func TestEqual(t *testing.T) {
+	a := newInstance("127.0.0.1", 8080, true, map[string]string{"k": "v"})
+	assert.True(t, Equal(a, newInstance("127.0.0.1", 8080, true, map[string]string{"k": "v"})))
+	assert.False(t, Equal(a, newInstance("127.0.0.1", 8080, true, map[string]string{"k": "v2"})))
+	assert.False(t, Equal(a, newInstance("127.0.0.1", 8080, true, nil)))
+	assert.False(t, Equal(a, newInstance("127.0.0.1", 8081, true, map[string]string{"k": "v"})))
+
+	b := newInstance("127.0.0.1", 8080, true, map[string]string{"k": "v"})
+	b.Isolate = &wrappers.BoolValue{Value: true}
+	assert.False(t, Equal(a, b))
+}
+
+func TestMarker(t *testing.T) {
+	assert.True(t, IsMirrored(map[string]string{types.MetaKeyMigrateFrom: "eureka"}))
+	assert.False(t, IsMirrored(map[string]string{"k": "v"}))
+	assert.True(t, IsWriteBack(map[string]string{types.MetaKeyMigrateWriteBack: "true"}))
+	assert.False(t, IsWriteBack(nil))
+}
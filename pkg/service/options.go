@@ -62,6 +62,9 @@ var (
 		{
 			Name: cacheapi.ClientName,
 		},
+		{
+			Name: cacheapi.HealthPolicyName,
+		},
 	}
 )
 
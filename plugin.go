@@ -33,11 +33,15 @@ import (
 	_ "github.com/pole-io/pole-server/plugin/access_control/auth/user"
 	_ "github.com/pole-io/pole-server/plugin/access_control/ratelimit/token"
 	_ "github.com/pole-io/pole-server/plugin/access_control/whitelist/ip"
+	_ "github.com/pole-io/pole-server/plugin/apiserver/apolloserver"
+	_ "github.com/pole-io/pole-server/plugin/apiserver/dnsserver"
 	_ "github.com/pole-io/pole-server/plugin/apiserver/eurekaserver"
 	_ "github.com/pole-io/pole-server/plugin/apiserver/grpcserver/config"
 	_ "github.com/pole-io/pole-server/plugin/apiserver/grpcserver/discover"
+	_ "github.com/pole-io/pole-server/plugin/apiserver/grpcserver/limiter"
 	_ "github.com/pole-io/pole-server/plugin/apiserver/httpserver"
 	_ "github.com/pole-io/pole-server/plugin/apiserver/nacosserver"
+	_ "github.com/pole-io/pole-server/plugin/apiserver/springcloudconfig"
 	_ "github.com/pole-io/pole-server/plugin/apiserver/xdsserverv3"
 	_ "github.com/pole-io/pole-server/plugin/cmdb/memory"
 	_ "github.com/pole-io/pole-server/plugin/crypto/aes"
@@ -45,6 +49,7 @@ import (
 	_ "github.com/pole-io/pole-server/plugin/observability/discoverevent/local"
 	_ "github.com/pole-io/pole-server/plugin/observability/history/logger"
 	_ "github.com/pole-io/pole-server/plugin/observability/statis/logger"
+	_ "github.com/pole-io/pole-server/plugin/observability/statis/otlp"
 	_ "github.com/pole-io/pole-server/plugin/observability/statis/prometheus"
 	_ "github.com/pole-io/pole-server/plugin/service/healthchecker/heartbeat"
 	_ "github.com/pole-io/pole-server/plugin/store/mysql"
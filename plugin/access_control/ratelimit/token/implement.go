@@ -30,7 +30,9 @@ func (tb *tokenBucket) initialize(c *apis.ConfigEntry) error {
 		return err
 	}
 	if !config.Enable {
+		tb.mu.Lock()
 		tb.config = config
+		tb.mu.Unlock()
 		return nil
 	}
 	// 加载本地配置
@@ -46,30 +48,34 @@ func (tb *tokenBucket) initialize(c *apis.ConfigEntry) error {
 		// TODO 监听规则
 	}
 
-	tb.config = config
-	tb.limiters = make(map[ratelimit.RatelimitType]limiter)
+	limiters := make(map[ratelimit.RatelimitType]limiter)
 
 	// IP限流
 	irt, err := newResourceRatelimit(ratelimit.IPRatelimit, config.IPLimitConf)
 	if err != nil {
 		return err
 	}
-	tb.limiters[ratelimit.IPRatelimit] = irt
+	limiters[ratelimit.IPRatelimit] = irt
 
 	// 接口限流
 	art, err := newAPIRatelimit(config.APILimitConf)
 	if err != nil {
 		return err
 	}
-	tb.limiters[ratelimit.APIRatelimit] = art
+	limiters[ratelimit.APIRatelimit] = art
 
 	// 操作实例限流
 	instance, err := newResourceRatelimit(ratelimit.InstanceRatelimit, config.InstanceLimitConf)
 	if err != nil {
 		return err
 	}
-	tb.limiters[ratelimit.InstanceRatelimit] = instance
+	limiters[ratelimit.InstanceRatelimit] = instance
 
+	// 全部构建成功后再整体替换, 热更新失败时保留旧的限流器
+	tb.mu.Lock()
+	tb.config = config
+	tb.limiters = limiters
+	tb.mu.Unlock()
 	return nil
 }
 
@@ -79,7 +85,9 @@ func (tb *tokenBucket) allow(typ ratelimit.RatelimitType, key string) bool {
 	if key == "" {
 		return true
 	}
+	tb.mu.RLock()
 	l, ok := tb.limiters[typ]
+	tb.mu.RUnlock()
 	if !ok {
 		return true
 	}
@@ -18,12 +18,15 @@
 package token
 
 import (
+	"sync"
+
 	"github.com/pole-io/pole-server/apis"
 	"github.com/pole-io/pole-server/apis/access_control/ratelimit"
 )
 
 // tokenBucket 实现Plugin接口
 type tokenBucket struct {
+	mu       sync.RWMutex
 	config   *Config
 	limiters map[ratelimit.RatelimitType]limiter
 }
@@ -38,6 +41,11 @@ func (tb *tokenBucket) Initialize(c *apis.ConfigEntry) error {
 	return tb.initialize(c)
 }
 
+// Reload 实现Reloadable接口，使用新配置重建限流器
+func (tb *tokenBucket) Reload(c *apis.ConfigEntry) error {
+	return tb.initialize(c)
+}
+
 // Destroy 实现Plugin接口，Destroy方法
 func (tb *tokenBucket) Destroy() error {
 	return nil
@@ -49,7 +57,10 @@ func (tb *tokenBucket) Type() apis.PluginType {
 
 // Allow 限流接口实现
 func (tb *tokenBucket) Allow(typ ratelimit.RatelimitType, key string) bool {
-	if !tb.config.Enable {
+	tb.mu.RLock()
+	enable := tb.config.Enable
+	tb.mu.RUnlock()
+	if !enable {
 		return true
 	}
 	return tb.allow(typ, key)
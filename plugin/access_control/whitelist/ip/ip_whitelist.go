@@ -19,6 +19,7 @@ package ip
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/pole-io/pole-server/apis"
 )
@@ -30,6 +31,7 @@ func init() {
 }
 
 type ipWhitelist struct {
+	mu  sync.RWMutex
 	ips map[string]bool
 }
 
@@ -40,17 +42,31 @@ func (i *ipWhitelist) Name() string {
 
 // Initialize 初始化IP白名单插件
 func (i *ipWhitelist) Initialize(conf *apis.ConfigEntry) error {
-	i.ips = make(map[string]bool)
 	ips, ok := conf.Option["ip"].([]interface{})
 	if !ok {
+		// 热更新失败时保留原有的白名单
+		i.mu.Lock()
+		if i.ips == nil {
+			i.ips = make(map[string]bool)
+		}
+		i.mu.Unlock()
 		return errors.New("whitelist plugin initialize error")
 	}
+	newIPs := make(map[string]bool, len(ips))
 	for _, ip := range ips {
-		i.ips[ip.(string)] = true
+		newIPs[ip.(string)] = true
 	}
+	i.mu.Lock()
+	i.ips = newIPs
+	i.mu.Unlock()
 	return nil
 }
 
+// Reload 热更新IP白名单
+func (i *ipWhitelist) Reload(conf *apis.ConfigEntry) error {
+	return i.Initialize(conf)
+}
+
 func (i *ipWhitelist) Type() apis.PluginType {
 	return apis.PluginTypeWhitelist
 }
@@ -63,5 +79,7 @@ func (i *ipWhitelist) Destroy() error {
 // Contain 白名单是否包含IP
 func (i *ipWhitelist) Contain(entry interface{}) bool {
 	ip, _ := entry.(string)
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.ips[ip]
 }
@@ -0,0 +1,211 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package apolloserver
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+	"go.uber.org/zap"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+)
+
+const (
+	// attrAPI 请求命中的接口名称，用于接口调用统计
+	attrAPI = "apollo-api"
+
+	operationConfigs         = "GET:/configs/{appId}/{clusterName}/{namespace}"
+	operationConfigFiles     = "GET:/configfiles/{appId}/{clusterName}/{namespace}"
+	operationConfigFilesJson = "GET:/configfiles/json/{appId}/{clusterName}/{namespace}"
+	operationNotifications   = "GET:/notifications/v2"
+	operationServices        = "GET:/services/config"
+
+	// defaultNamespace 未指定 namespace 时 apollo 使用的 namespace
+	defaultNamespace = "application"
+	// configServiceName apollo 客户端通过 meta server 查询的 config service 名称
+	configServiceName = "APOLLO-CONFIGSERVICE"
+)
+
+// ApolloServiceInstance /services/config 接口的返回结构
+type ApolloServiceInstance struct {
+	AppName     string `json:"appName"`
+	InstanceID  string `json:"instanceId"`
+	HomepageURL string `json:"homepageUrl"`
+}
+
+// GetApolloConfigServer 注册 apollo 客户端协议的路由
+func (h *ApolloServer) GetApolloConfigServer() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/services/config").To(h.getServices))
+	ws.Route(ws.GET("/configs/{appId}/{clusterName}").To(h.getConfigs))
+	ws.Route(ws.GET("/configs/{appId}/{clusterName}/{namespace}").To(h.getConfigs))
+	ws.Route(ws.GET("/configfiles/{appId}/{clusterName}/{namespace}").To(h.getConfigFiles))
+	ws.Route(ws.GET("/configfiles/json/{appId}/{clusterName}/{namespace}").To(h.getConfigFilesJson))
+	ws.Route(ws.GET("/notifications/v2").To(h.getNotifications))
+	return ws
+}
+
+// getServices 兼容只配置了 apollo.meta 的客户端，meta server 与 config service 均为当前服务
+func (h *ApolloServer) getServices(req *restful.Request, rsp *restful.Response) {
+	req.SetAttribute(attrAPI, operationServices)
+	scheme := "http"
+	if req.Request.TLS != nil {
+		scheme = "https"
+	}
+	_ = rsp.WriteAsJson([]*ApolloServiceInstance{
+		{
+			AppName:     configServiceName,
+			InstanceID:  req.Request.Host,
+			HomepageURL: scheme + "://" + req.Request.Host + "/",
+		},
+	})
+}
+
+// getConfigs 处理 /configs/{appId}/{clusterName}/{namespace}，releaseKey 未变化时返回 304
+func (h *ApolloServer) getConfigs(req *restful.Request, rsp *restful.Response) {
+	req.SetAttribute(attrAPI, operationConfigs)
+	ret, _, ok := h.queryConfig(req, rsp)
+	if !ok {
+		return
+	}
+	if releaseKey := req.QueryParameter("releaseKey"); releaseKey != "" && releaseKey == ret.ReleaseKey {
+		rsp.WriteHeader(http.StatusNotModified)
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// getConfigFiles 处理 /configfiles/{appId}/{clusterName}/{namespace}，直接返回配置文件的内容
+func (h *ApolloServer) getConfigFiles(req *restful.Request, rsp *restful.Response) {
+	req.SetAttribute(attrAPI, operationConfigFiles)
+	_, content, ok := h.queryConfig(req, rsp)
+	if !ok {
+		return
+	}
+	rsp.Header().Set(restful.HEADER_ContentType, "text/plain; charset=utf-8")
+	rsp.WriteHeader(http.StatusOK)
+	_, _ = rsp.Write([]byte(content))
+}
+
+// getConfigFilesJson 处理 /configfiles/json/{appId}/{clusterName}/{namespace}
+func (h *ApolloServer) getConfigFilesJson(req *restful.Request, rsp *restful.Response) {
+	req.SetAttribute(attrAPI, operationConfigFilesJson)
+	ret, _, ok := h.queryConfig(req, rsp)
+	if !ok {
+		return
+	}
+	_ = rsp.WriteAsJson(ret.Configurations)
+}
+
+// getNotifications 处理 /notifications/v2 长轮询，超时无变更时返回 304
+func (h *ApolloServer) getNotifications(req *restful.Request, rsp *restful.Response) {
+	req.SetAttribute(attrAPI, operationNotifications)
+	clientReq := parseClientRequest(req, req.QueryParameter("appId"), req.QueryParameter("cluster"))
+	if clientReq.AppID == "" {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, "appId is required")
+		return
+	}
+	notifications := make([]*ApolloNotification, 0, 4)
+	if err := json.Unmarshal([]byte(req.QueryParameter("notifications")), &notifications); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, "invalid notifications: "+err.Error())
+		return
+	}
+	if len(notifications) == 0 {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, "notifications is empty")
+		return
+	}
+	for _, item := range notifications {
+		item.NamespaceName = normalizeNamespace(item.NamespaceName)
+	}
+
+	changed, err := h.svc.pollNotifications(newRequestContext(req), clientReq, notifications)
+	if err != nil {
+		h.writeError(req, rsp, err)
+		return
+	}
+	if len(changed) == 0 {
+		rsp.WriteHeader(http.StatusNotModified)
+		return
+	}
+	_ = rsp.WriteAsJson(changed)
+}
+
+// queryConfig 查询请求对应的配置，配置不存在或者查询失败时已经写入响应
+func (h *ApolloServer) queryConfig(req *restful.Request, rsp *restful.Response) (*ApolloConfig, string, bool) {
+	clientReq := parseClientRequest(req, req.PathParameter("appId"), req.PathParameter("clusterName"))
+	namespace := normalizeNamespace(req.PathParameter("namespace"))
+	ret, content, err := h.svc.queryConfig(newRequestContext(req), clientReq, namespace)
+	if err != nil {
+		h.writeError(req, rsp, err)
+		return nil, "", false
+	}
+	if ret == nil {
+		_ = rsp.WriteErrorString(http.StatusNotFound, "config not found: "+
+			messageKey(clientReq.AppID, clientReq.Cluster, namespace))
+		return nil, "", false
+	}
+	return ret, content, true
+}
+
+func (h *ApolloServer) writeError(req *restful.Request, rsp *restful.Response, err error) {
+	log.Error("[Apollo] query config", zap.String("url", req.Request.URL.String()), zap.Error(err))
+	_ = rsp.WriteErrorString(http.StatusInternalServerError, err.Error())
+}
+
+// parseClientRequest 解析客户端的公共参数，未上报 ip 时使用连接的来源地址
+func parseClientRequest(req *restful.Request, appID, cluster string) *clientRequest {
+	if cluster == "" {
+		cluster = DefaultCluster
+	}
+	ip := req.QueryParameter("ip")
+	if ip == "" {
+		ip = req.Request.RemoteAddr
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+	return &clientRequest{
+		AppID:   appID,
+		Cluster: cluster,
+		IP:      ip,
+		Label:   req.QueryParameter("label"),
+	}
+}
+
+// normalizeNamespace apollo 客户端对 properties 格式的 namespace 可能会带上 .properties 后缀
+func normalizeNamespace(namespace string) string {
+	namespace = strings.TrimSpace(namespace)
+	if namespace == "" {
+		return defaultNamespace
+	}
+	if len(namespace) > len(propertiesExt) && isPropertiesFile(namespace) {
+		return namespace[:len(namespace)-len(propertiesExt)]
+	}
+	return namespace
+}
+
+func newRequestContext(req *restful.Request) context.Context {
+	ctx := context.WithValue(req.Request.Context(), types.ContextRequestId, req.HeaderParameter("Request-Id"))
+	ctx = context.WithValue(ctx, types.ContextClientAddress, req.Request.RemoteAddr)
+	return ctx
+}
@@ -0,0 +1,218 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package apolloserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"go.uber.org/zap"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+	"github.com/pole-io/pole-server/pkg/config"
+)
+
+// clientRequest apollo 客户端请求中的公共参数
+type clientRequest struct {
+	AppID   string
+	Cluster string
+	IP      string
+	Label   string
+}
+
+// labels 客户端标签，参与灰度发布规则的匹配
+func (r *clientRequest) labels() map[string]string {
+	labels := make(map[string]string, 2)
+	if r.IP != "" {
+		labels[types.ClientLabel_IP] = r.IP
+	}
+	if r.Label != "" {
+		labels[ClientLabelApollo] = r.Label
+	}
+	return labels
+}
+
+// tags 客户端标签，查询配置文件时参与灰度发布规则的匹配
+func (r *clientRequest) tags() []*apiconfig.ConfigFileTag {
+	labels := r.labels()
+	tags := make([]*apiconfig.ConfigFileTag, 0, len(labels))
+	for k, v := range labels {
+		tags = append(tags, &apiconfig.ConfigFileTag{
+			Key:   protobuf.NewStringValue(k),
+			Value: protobuf.NewStringValue(v),
+		})
+	}
+	return tags
+}
+
+// configService 将 apollo 的 appId/cluster/namespace 映射为 命名空间/配置分组/配置文件
+type configService struct {
+	configSvr       config.ConfigFileClientOperate
+	decrypt         func(file *apiconfig.ClientConfigFileInfo) (string, error)
+	longPollTimeout time.Duration
+}
+
+// getFile 查询单个配置文件，灰度发布由 GetConfigFileWithCache 根据客户端标签匹配，不存在时返回 nil
+func (s *configService) getFile(ctx context.Context, req *clientRequest, cluster,
+	fileName string) (*apiconfig.ClientConfigFileInfo, error) {
+	rsp := s.configSvr.GetConfigFileWithCache(ctx, &apiconfig.ClientConfigFileInfo{
+		Namespace: protobuf.NewStringValue(req.AppID),
+		Group:     protobuf.NewStringValue(cluster),
+		FileName:  protobuf.NewStringValue(fileName),
+		Tags:      req.tags(),
+	})
+	switch rsp.GetCode().GetValue() {
+	case uint32(apimodel.Code_ExecuteSuccess):
+		return rsp.GetConfigFile(), nil
+	case uint32(apimodel.Code_NotFoundResource):
+		return nil, nil
+	default:
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+}
+
+// resolveFile 按照 apollo 的集群回退规则查询配置文件，返回命中的集群
+func (s *configService) resolveFile(ctx context.Context, req *clientRequest,
+	namespace string) (*apiconfig.ClientConfigFileInfo, string, error) {
+	fileName := toFileName(namespace)
+	for _, cluster := range clusterCandidates(req.Cluster) {
+		file, err := s.getFile(ctx, req, cluster, fileName)
+		if err != nil {
+			return nil, "", err
+		}
+		if file != nil {
+			return file, cluster, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// queryConfig 查询 apollo 格式的配置，properties 以外格式的配置内容放在 content 中，同时返回解密后的原始内容，
+// 不存在时返回 nil
+func (s *configService) queryConfig(ctx context.Context, req *clientRequest,
+	namespace string) (*ApolloConfig, string, error) {
+	file, cluster, err := s.resolveFile(ctx, req, namespace)
+	if err != nil || file == nil {
+		return nil, "", err
+	}
+	content, err := s.decrypt(file)
+	if err != nil {
+		return nil, "", err
+	}
+	ret := &ApolloConfig{
+		AppID:         req.AppID,
+		Cluster:       cluster,
+		NamespaceName: namespace,
+		ReleaseKey:    file.GetMd5().GetValue(),
+	}
+	if isPropertiesFile(file.GetFileName().GetValue()) {
+		ret.Configurations = utils.ParseProperties(content)
+	} else {
+		ret.Configurations = map[string]string{"content": content}
+	}
+	return ret, content, nil
+}
+
+// diffNotifications 返回通知 ID 与服务端不一致的 namespace，通知 ID 为当前生效的发布版本
+func (s *configService) diffNotifications(ctx context.Context, req *clientRequest,
+	notifications []*ApolloNotification) ([]*ApolloNotification, error) {
+	changed := make([]*ApolloNotification, 0, len(notifications))
+	for _, item := range notifications {
+		file, cluster, err := s.resolveFile(ctx, req, item.NamespaceName)
+		if err != nil {
+			return nil, err
+		}
+		notificationID := initNotificationID
+		if file != nil {
+			notificationID = int64(file.GetVersion().GetValue())
+		}
+		if notificationID == item.NotificationID {
+			continue
+		}
+		if cluster == "" {
+			cluster = req.Cluster
+		}
+		changed = append(changed, &ApolloNotification{
+			NamespaceName:  item.NamespaceName,
+			NotificationID: notificationID,
+			Messages: &ApolloNotificationMessages{
+				Details: map[string]int64{
+					messageKey(req.AppID, cluster, item.NamespaceName): notificationID,
+				},
+			},
+		})
+	}
+	return changed, nil
+}
+
+// pollNotifications 长轮询等待配置变更，超时未变更时返回空
+func (s *configService) pollNotifications(ctx context.Context, req *clientRequest,
+	notifications []*ApolloNotification) ([]*ApolloNotification, error) {
+	changed, err := s.diffNotifications(ctx, req, notifications)
+	if err != nil || len(changed) > 0 {
+		return changed, err
+	}
+
+	watchReq := &apiconfig.ClientWatchConfigFileRequest{
+		ClientIp: protobuf.NewStringValue(req.IP),
+	}
+	// 回退的默认集群同样需要监听，指定集群新发布配置后客户端才能感知
+	for _, item := range notifications {
+		fileName := toFileName(item.NamespaceName)
+		for _, cluster := range clusterCandidates(req.Cluster) {
+			file, err := s.getFile(ctx, req, cluster, fileName)
+			if err != nil {
+				return nil, err
+			}
+			watchReq.WatchFiles = append(watchReq.WatchFiles, &apiconfig.ClientConfigFileInfo{
+				Namespace: protobuf.NewStringValue(req.AppID),
+				Group:     protobuf.NewStringValue(cluster),
+				FileName:  protobuf.NewStringValue(fileName),
+				Version:   protobuf.NewUInt64Value(file.GetVersion().GetValue()),
+			})
+		}
+	}
+
+	ctx = context.WithValue(ctx, utils.WatchTimeoutCtx{}, s.longPollTimeout)
+	// 长轮询期间的灰度发布同样需要按照客户端标签匹配
+	ctx = context.WithValue(ctx, utils.WatchLabelsCtx{}, req.labels())
+	callback, err := s.configSvr.LongPullWatchFile(ctx, watchReq)
+	if err != nil {
+		return nil, err
+	}
+	ret := callback()
+	// 订阅被关闭时没有任何响应，当作无变更处理
+	if ret == nil {
+		return nil, nil
+	}
+	switch ret.GetCode().GetValue() {
+	case uint32(apimodel.Code_ExecuteSuccess):
+		return s.diffNotifications(ctx, req, notifications)
+	case uint32(apimodel.Code_DataNoChange):
+		return nil, nil
+	default:
+		log.Error("[Apollo] long polling notifications", utils.RequestID(ctx), zap.String("app-id", req.AppID),
+			zap.Uint32("code", ret.GetCode().GetValue()), zap.String("msg", ret.GetInfo().GetValue()))
+		return nil, errors.New(ret.GetInfo().GetValue())
+	}
+}
@@ -0,0 +1,196 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package apolloserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
+	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+	"github.com/pole-io/pole-server/pkg/config"
+)
+
+type fakeConfigServer struct {
+	config.ConfigFileClientOperate
+	files     map[string]*apiconfig.ClientConfigFileInfo
+	grayFiles map[string]*apiconfig.ClientConfigFileInfo
+	grayLabel string
+	watched   []*apiconfig.ClientConfigFileInfo
+	labels    map[string]string
+	onWatch   func()
+}
+
+func (f *fakeConfigServer) GetConfigFileWithCache(_ context.Context,
+	req *apiconfig.ClientConfigFileInfo) *apiconfig.ConfigClientResponse {
+	key := req.GetNamespace().GetValue() + "/" + req.GetGroup().GetValue() + "/" + req.GetFileName().GetValue()
+	for _, tag := range req.GetTags() {
+		if tag.GetKey().GetValue() == ClientLabelApollo && tag.GetValue().GetValue() == f.grayLabel {
+			if file, ok := f.grayFiles[key]; ok {
+				return api.NewConfigClientResponse(apimodel.Code_ExecuteSuccess, file)
+			}
+		}
+	}
+	file, ok := f.files[key]
+	if !ok {
+		return api.NewConfigClientResponse(apimodel.Code_NotFoundResource, req)
+	}
+	return api.NewConfigClientResponse(apimodel.Code_ExecuteSuccess, file)
+}
+
+func (f *fakeConfigServer) LongPullWatchFile(ctx context.Context,
+	req *apiconfig.ClientWatchConfigFileRequest) (config.WatchCallback, error) {
+	f.watched = req.GetWatchFiles()
+	f.labels, _ = ctx.Value(utils.WatchLabelsCtx{}).(map[string]string)
+	return func() *apiconfig.ConfigClientResponse {
+		if f.onWatch == nil {
+			return api.NewConfigClientResponse(apimodel.Code_DataNoChange, nil)
+		}
+		f.onWatch()
+		return api.NewConfigClientResponse(apimodel.Code_ExecuteSuccess, nil)
+	}, nil
+}
+
+func newClientFile(fileName, content, md5 string, version uint64) *apiconfig.ClientConfigFileInfo {
+	return &apiconfig.ClientConfigFileInfo{
+		FileName: protobuf.NewStringValue(fileName),
+		Content:  protobuf.NewStringValue(content),
+		Md5:      protobuf.NewStringValue(md5),
+		Version:  protobuf.NewUInt64Value(version),
+	}
+}
+
+func plainContent(file *apiconfig.ClientConfigFileInfo) (string, error) {
+	return file.GetContent().GetValue(), nil
+}
+
+func Test_toFileName(t *testing.T) {
+	assert.Equal(t, "application.properties", toFileName("application"))
+	assert.Equal(t, "datasource.yml", toFileName("datasource.yml"))
+	assert.Equal(t, "common.redis.properties", toFileName("common.redis"))
+	assert.Equal(t, "application", normalizeNamespace("application.properties"))
+	assert.Equal(t, "application", normalizeNamespace(""))
+	assert.Equal(t, []string{DefaultCluster}, clusterCandidates(""))
+	assert.Equal(t, []string{"sh", DefaultCluster}, clusterCandidates("sh"))
+}
+
+func Test_configService_queryConfig(t *testing.T) {
+	svr := &fakeConfigServer{
+		files: map[string]*apiconfig.ClientConfigFileInfo{
+			"order/default/application.properties": newClientFile("application.properties", "a=1\nb = 2\n", "v1", 1),
+			"order/sh/datasource.yml":              newClientFile("datasource.yml", "url: mysql", "v2", 2),
+		},
+		grayFiles: map[string]*apiconfig.ClientConfigFileInfo{
+			"order/default/application.properties": newClientFile("application.properties", "a=gray\n", "v3", 3),
+		},
+		grayLabel: "canary",
+	}
+	svc := &configService{configSvr: svr, decrypt: plainContent, longPollTimeout: time.Second}
+
+	// 指定集群下没有配置时回退到默认集群
+	ret, content, err := svc.queryConfig(context.Background(), &clientRequest{AppID: "order", Cluster: "sh"},
+		"application")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultCluster, ret.Cluster)
+	assert.Equal(t, "v1", ret.ReleaseKey)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, ret.Configurations)
+	assert.Equal(t, "a=1\nb = 2\n", content)
+
+	ret, _, err = svc.queryConfig(context.Background(), &clientRequest{AppID: "order", Cluster: "sh"},
+		"datasource.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, "sh", ret.Cluster)
+	assert.Equal(t, map[string]string{"content": "url: mysql"}, ret.Configurations)
+
+	// 命中灰度规则的客户端获取灰度配置
+	ret, _, err = svc.queryConfig(context.Background(), &clientRequest{AppID: "order", Label: "canary"},
+		"application")
+	assert.NoError(t, err)
+	assert.Equal(t, "v3", ret.ReleaseKey)
+	assert.Equal(t, map[string]string{"a": "gray"}, ret.Configurations)
+
+	ret, _, err = svc.queryConfig(context.Background(), &clientRequest{AppID: "order"}, "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, ret)
+}
+
+func Test_configService_pollNotifications(t *testing.T) {
+	svr := &fakeConfigServer{
+		files: map[string]*apiconfig.ClientConfigFileInfo{
+			"order/default/application.properties": newClientFile("application.properties", "a=1", "v1", 1),
+		},
+	}
+	svc := &configService{configSvr: svr, decrypt: plainContent, longPollTimeout: time.Second}
+	req := &clientRequest{AppID: "order", Cluster: "sh", IP: "127.0.0.1", Label: "gray"}
+
+	// 首次订阅时立即返回当前的通知 ID
+	changed, err := svc.pollNotifications(context.Background(), req, []*ApolloNotification{
+		{NamespaceName: "application", NotificationID: initNotificationID},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(changed))
+	assert.Equal(t, int64(1), changed[0].NotificationID)
+	assert.Equal(t, map[string]int64{"order+default+application": 1}, changed[0].Messages.Details)
+
+	// 没有变更时监听指定集群以及默认集群，超时后返回空
+	changed, err = svc.pollNotifications(context.Background(), req, []*ApolloNotification{
+		{NamespaceName: "application", NotificationID: 1},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, changed)
+	assert.Equal(t, 2, len(svr.watched))
+	assert.Equal(t, "sh", svr.watched[0].GetGroup().GetValue())
+	assert.Equal(t, DefaultCluster, svr.watched[1].GetGroup().GetValue())
+	assert.Equal(t, map[string]string{types.ClientLabel_IP: "127.0.0.1", ClientLabelApollo: "gray"}, svr.labels)
+
+	// 指定集群发布了新的配置
+	svr.onWatch = func() {
+		svr.files["order/sh/application.properties"] = newClientFile("application.properties", "a=2", "v5", 5)
+	}
+	changed, err = svc.pollNotifications(context.Background(), req, []*ApolloNotification{
+		{NamespaceName: "application", NotificationID: 1},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(changed))
+	assert.Equal(t, int64(5), changed[0].NotificationID)
+	assert.Equal(t, map[string]int64{"order+sh+application": 5}, changed[0].Messages.Details)
+}
+
+func Test_parseClientRequest(t *testing.T) {
+	httpReq := httptest.NewRequest(http.MethodGet, "/configs/order/sh/application?label=gray", nil)
+	httpReq.RemoteAddr = "10.0.0.1:52341"
+	req := parseClientRequest(restful.NewRequest(httpReq), "order", "")
+	assert.Equal(t, DefaultCluster, req.Cluster)
+	assert.Equal(t, "10.0.0.1", req.IP)
+	assert.Equal(t, "gray", req.Label)
+
+	httpReq = httptest.NewRequest(http.MethodGet, "/configs/order/sh/application?ip=10.0.0.2", nil)
+	req = parseClientRequest(restful.NewRequest(httpReq), "order", "sh")
+	assert.Equal(t, "sh", req.Cluster)
+	assert.Equal(t, "10.0.0.2", req.IP)
+}
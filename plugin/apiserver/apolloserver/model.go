@@ -0,0 +1,93 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package apolloserver
+
+import (
+	"path"
+	"strings"
+)
+
+const (
+	// DefaultCluster apollo 默认集群，指定集群下没有对应配置时回退到该集群
+	DefaultCluster = "default"
+	// ClientLabelApollo apollo 客户端通过 label 参数上报的标签，用于灰度发布规则的匹配
+	ClientLabelApollo = "CLIENT_LABEL"
+	// clusterNamespaceSeparator apollo 通知消息中 appId、cluster、namespace 的连接符
+	clusterNamespaceSeparator = "+"
+	// propertiesExt apollo 中没有后缀的 namespace 均为 properties 格式
+	propertiesExt = ".properties"
+	// initNotificationID 客户端首次订阅时上报的通知 ID
+	initNotificationID int64 = -1
+)
+
+// supportFileExts apollo 支持的 namespace 格式
+var supportFileExts = map[string]struct{}{
+	".properties": {},
+	".xml":        {},
+	".json":       {},
+	".yml":        {},
+	".yaml":       {},
+	".txt":        {},
+}
+
+// ApolloConfig /configs 接口的返回结构
+type ApolloConfig struct {
+	AppID          string            `json:"appId"`
+	Cluster        string            `json:"cluster"`
+	NamespaceName  string            `json:"namespaceName"`
+	Configurations map[string]string `json:"configurations"`
+	ReleaseKey     string            `json:"releaseKey"`
+}
+
+// ApolloNotification /notifications/v2 接口的请求及返回结构
+type ApolloNotification struct {
+	NamespaceName  string                      `json:"namespaceName"`
+	NotificationID int64                       `json:"notificationId"`
+	Messages       *ApolloNotificationMessages `json:"messages,omitempty"`
+}
+
+// ApolloNotificationMessages 通知消息明细
+type ApolloNotificationMessages struct {
+	Details map[string]int64 `json:"details"`
+}
+
+// toFileName apollo namespace 映射为配置文件名称，没有后缀的 namespace 为 properties 格式
+func toFileName(namespace string) string {
+	if _, ok := supportFileExts[strings.ToLower(path.Ext(namespace))]; ok {
+		return namespace
+	}
+	return namespace + propertiesExt
+}
+
+// isPropertiesFile 判断配置文件是否为 properties 格式
+func isPropertiesFile(fileName string) bool {
+	return strings.EqualFold(path.Ext(fileName), propertiesExt)
+}
+
+// clusterCandidates 按照 apollo 的规则返回需要查找的集群
+func clusterCandidates(cluster string) []string {
+	if cluster == "" || cluster == DefaultCluster {
+		return []string{DefaultCluster}
+	}
+	return []string{cluster, DefaultCluster}
+}
+
+// messageKey 通知消息明细的 key
+func messageKey(appID, cluster, namespace string) string {
+	return strings.Join([]string{appID, cluster, namespace}, clusterNamespaceSeparator)
+}
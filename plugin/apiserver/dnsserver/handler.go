@@ -0,0 +1,330 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dnsserver
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// minUDPSize 客户端未携带 EDNS0 时 UDP 响应的最大长度
+	minUDPSize = 512
+	// maxUDPSize 服务端支持的 EDNS0 UDP 响应的最大长度
+	maxUDPSize = 4096
+	// maxTCPSize TCP 响应的最大长度
+	maxTCPSize = 65535
+	// soaRefresh 合成的 SOA 记录中与区域传输相关的时间，DNS 服务器不支持区域传输，只用于填充
+	soaRefresh = 3600
+)
+
+// dnsHandler 处理单个 DNS 查询报文，后缀以外的域名转发到上游的 DNS 服务器
+type dnsHandler struct {
+	resolver        *recordResolver
+	ttl             uint32
+	upstreams       []string
+	upstreamTimeout time.Duration
+}
+
+// handle 处理查询报文，返回响应报文以及用于统计的接口名称和响应码。无法解析的报文返回 nil，直接丢弃
+func (h *dnsHandler) handle(req []byte, clientIP string, tcp bool) ([]byte, string, dnsmessage.RCode) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(req)
+	if err != nil {
+		return nil, "", dnsmessage.RCodeFormatError
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return h.reply(header, nil, dnsmessage.RCodeFormatError), "", dnsmessage.RCodeFormatError
+	}
+	api := "DNS:" + strings.TrimPrefix(question.Type.String(), "Type")
+	if header.Response || header.OpCode != 0 {
+		return h.reply(header, &question, dnsmessage.RCodeNotImplemented), api, dnsmessage.RCodeNotImplemented
+	}
+	if !h.resolver.inZone(question.Name.String()) {
+		rsp, rcode := h.forward(header, &question, req, tcp)
+		return rsp, api, rcode
+	}
+
+	udpSize, hasEDNS := requestUDPSize(&parser)
+	msg := h.answer(header, question, clientIP)
+	if hasEDNS {
+		opt := dnsmessage.Resource{Body: &dnsmessage.OPTResource{}}
+		_ = opt.Header.SetEDNS0(maxUDPSize, dnsmessage.RCodeSuccess, false)
+		msg.Additionals = append(msg.Additionals, opt)
+	}
+	limit := udpSize
+	if tcp {
+		limit = maxTCPSize
+	}
+	rsp, err := packWithLimit(msg, limit)
+	if err != nil {
+		log.Error("[DNS] pack response", zap.String("name", question.Name.String()), zap.Error(err))
+		return h.reply(header, &question, dnsmessage.RCodeServerFailure), api, dnsmessage.RCodeServerFailure
+	}
+	return rsp, api, msg.Header.RCode
+}
+
+// answer 根据服务实例构造 A/AAAA/SRV 记录，服务不存在时返回 NXDOMAIN，没有对应类型的记录时返回 NODATA
+func (h *dnsHandler) answer(header dnsmessage.Header, question dnsmessage.Question,
+	clientIP string) *dnsmessage.Message {
+	msg := &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 header.ID,
+			Response:           true,
+			Authoritative:      true,
+			RecursionDesired:   header.RecursionDesired,
+			RecursionAvailable: len(h.upstreams) > 0,
+		},
+		Questions: []dnsmessage.Question{question},
+	}
+	result := h.resolver.lookup(question.Name.String(), clientIP)
+	if result.service == nil {
+		msg.Header.RCode = dnsmessage.RCodeNameError
+		msg.Authorities = append(msg.Authorities, h.soa())
+		return msg
+	}
+
+	rrHeader := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: h.ttl}
+	for _, ins := range result.instances {
+		switch question.Type {
+		case dnsmessage.TypeA, dnsmessage.TypeAAAA, dnsmessage.TypeALL:
+			if rr, ok := h.addressRecord(question.Name, ins.Host(), question.Type); ok {
+				msg.Answers = append(msg.Answers, rr)
+			}
+		case dnsmessage.TypeSRV:
+			target, err := dnsmessage.NewName(encodeTargetHost(ins.Host()) + "." + question.Name.String())
+			if err != nil {
+				continue
+			}
+			msg.Answers = append(msg.Answers, dnsmessage.Resource{
+				Header: rrHeader,
+				Body: &dnsmessage.SRVResource{
+					Priority: uint16(min(ins.Priority(), 65535)),
+					Weight:   uint16(min(ins.Weight(), 65535)),
+					Port:     uint16(ins.Port()),
+					Target:   target,
+				},
+			})
+			if rr, ok := h.addressRecord(target, ins.Host(), dnsmessage.TypeALL); ok {
+				msg.Additionals = append(msg.Additionals, rr)
+			}
+		}
+	}
+	if len(msg.Answers) == 0 {
+		msg.Authorities = append(msg.Authorities, h.soa())
+	}
+	return msg
+}
+
+// addressRecord 构造实例 IP 对应的 A 或者 AAAA 记录，qtype 为 TypeALL 时两者均可
+func (h *dnsHandler) addressRecord(name dnsmessage.Name, host string,
+	qtype dnsmessage.Type) (dnsmessage.Resource, bool) {
+	ip := net.ParseIP(host)
+	rrHeader := dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: h.ttl}
+	if ip4 := ip.To4(); ip4 != nil {
+		if qtype == dnsmessage.TypeAAAA {
+			return dnsmessage.Resource{}, false
+		}
+		body := &dnsmessage.AResource{}
+		copy(body.A[:], ip4)
+		return dnsmessage.Resource{Header: rrHeader, Body: body}, true
+	}
+	if ip == nil || qtype == dnsmessage.TypeA {
+		return dnsmessage.Resource{}, false
+	}
+	body := &dnsmessage.AAAAResource{}
+	copy(body.AAAA[:], ip.To16())
+	return dnsmessage.Resource{Header: rrHeader, Body: body}, true
+}
+
+// soa 合成的 SOA 记录，用于否定应答的缓存时间
+func (h *dnsHandler) soa() dnsmessage.Resource {
+	zone := dnsmessage.MustNewName(h.resolver.suffix)
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: zone, Class: dnsmessage.ClassINET, TTL: h.ttl},
+		Body: &dnsmessage.SOAResource{
+			NS:      dnsmessage.MustNewName("ns." + h.resolver.suffix),
+			MBox:    dnsmessage.MustNewName("hostmaster." + h.resolver.suffix),
+			Serial:  uint32(time.Now().Unix()),
+			Refresh: soaRefresh,
+			Retry:   soaRefresh,
+			Expire:  soaRefresh,
+			MinTTL:  h.ttl,
+		},
+	}
+}
+
+// reply 构造不携带记录的响应
+func (h *dnsHandler) reply(header dnsmessage.Header, question *dnsmessage.Question,
+	rcode dnsmessage.RCode) []byte {
+	msg := &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               header.ID,
+			Response:         true,
+			OpCode:           header.OpCode,
+			RecursionDesired: header.RecursionDesired,
+			RCode:            rcode,
+		},
+	}
+	if question != nil {
+		msg.Questions = []dnsmessage.Question{*question}
+	}
+	rsp, err := msg.Pack()
+	if err != nil {
+		return nil
+	}
+	return rsp
+}
+
+// forward 将查询转发给上游的 DNS 服务器，按顺序尝试直到成功，没有配置上游时拒绝查询
+func (h *dnsHandler) forward(header dnsmessage.Header, question *dnsmessage.Question, req []byte,
+	tcp bool) ([]byte, dnsmessage.RCode) {
+	if len(h.upstreams) == 0 {
+		return h.reply(header, question, dnsmessage.RCodeRefused), dnsmessage.RCodeRefused
+	}
+	var lastErr error
+	for _, upstream := range h.upstreams {
+		rsp, err := exchange(upstream, req, tcp, h.upstreamTimeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var parser dnsmessage.Parser
+		rspHeader, err := parser.Start(rsp)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return rsp, rspHeader.RCode
+	}
+	log.Warn("[DNS] forward query to upstreams", zap.String("name", question.Name.String()),
+		zap.Strings("upstreams", h.upstreams), zap.Error(lastErr))
+	return h.reply(header, question, dnsmessage.RCodeServerFailure), dnsmessage.RCodeServerFailure
+}
+
+// exchange 向单个上游发送查询报文并读取响应，TCP 报文带有 2 字节的长度前缀
+func exchange(upstream string, req []byte, tcp bool, timeout time.Duration) ([]byte, error) {
+	network := "udp"
+	if tcp {
+		network = "tcp"
+	}
+	conn, err := net.DialTimeout(network, upstream, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	if !tcp {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, maxTCPSize)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+	if err := writeTCPMessage(conn, req); err != nil {
+		return nil, err
+	}
+	return readTCPMessage(conn)
+}
+
+// readTCPMessage 读取一个带 2 字节长度前缀的报文
+func readTCPMessage(r io.Reader) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// writeTCPMessage 写入一个带 2 字节长度前缀的报文
+func writeTCPMessage(w io.Writer, msg []byte) error {
+	if len(msg) > maxTCPSize {
+		return errors.New("dns message too large")
+	}
+	buf := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+	copy(buf[2:], msg)
+	_, err := w.Write(buf)
+	return err
+}
+
+// requestUDPSize 读取查询报文中 EDNS0 声明的 UDP 报文长度
+func requestUDPSize(parser *dnsmessage.Parser) (int, bool) {
+	if err := parser.SkipAllQuestions(); err != nil {
+		return minUDPSize, false
+	}
+	if err := parser.SkipAllAnswers(); err != nil {
+		return minUDPSize, false
+	}
+	if err := parser.SkipAllAuthorities(); err != nil {
+		return minUDPSize, false
+	}
+	for {
+		header, err := parser.AdditionalHeader()
+		if err != nil {
+			return minUDPSize, false
+		}
+		if header.Type == dnsmessage.TypeOPT {
+			return max(minUDPSize, min(int(header.Class), maxUDPSize)), true
+		}
+		if err := parser.SkipAdditional(); err != nil {
+			return minUDPSize, false
+		}
+	}
+}
+
+// packWithLimit 打包响应报文，超出长度时减少记录并设置 TC 标记，客户端会改用 TCP 重新查询
+func packWithLimit(msg *dnsmessage.Message, limit int) ([]byte, error) {
+	rsp, err := msg.Pack()
+	if err != nil || len(rsp) <= limit {
+		return rsp, err
+	}
+	msg.Header.Truncated = true
+	// 保留 EDNS0 的 OPT 记录，其余的附加记录全部丢弃
+	additionals := msg.Additionals[:0]
+	for _, rr := range msg.Additionals {
+		if rr.Header.Type == dnsmessage.TypeOPT {
+			additionals = append(additionals, rr)
+		}
+	}
+	msg.Additionals = additionals
+	for {
+		if rsp, err = msg.Pack(); err != nil || len(rsp) <= limit || len(msg.Answers) == 0 {
+			return rsp, err
+		}
+		msg.Answers = msg.Answers[:len(msg.Answers)/2]
+	}
+}
@@ -0,0 +1,171 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dnsserver
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+)
+
+func newQuery(t *testing.T, name string, qtype dnsmessage.Type, udpSize uint16) []byte {
+	msg := &dnsmessage.Message{
+		Header: dnsmessage.Header{ID: 1024, RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: dnsmessage.MustNewName(name), Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}
+	if udpSize > 0 {
+		opt := dnsmessage.Resource{Body: &dnsmessage.OPTResource{}}
+		assert.NoError(t, opt.Header.SetEDNS0(int(udpSize), dnsmessage.RCodeSuccess, false))
+		msg.Additionals = append(msg.Additionals, opt)
+	}
+	req, err := msg.Pack()
+	assert.NoError(t, err)
+	return req
+}
+
+func unpack(t *testing.T, rsp []byte) *dnsmessage.Message {
+	msg := &dnsmessage.Message{}
+	assert.NoError(t, msg.Unpack(rsp))
+	return msg
+}
+
+func Test_dnsHandler_answer(t *testing.T) {
+	handler := &dnsHandler{resolver: newTestResolver(), ttl: 5}
+
+	rsp, api, rcode := handler.handle(newQuery(t, "order.default.pole.local.", dnsmessage.TypeA, 0), "", false)
+	assert.Equal(t, "DNS:A", api)
+	assert.Equal(t, dnsmessage.RCodeSuccess, rcode)
+	msg := unpack(t, rsp)
+	assert.Equal(t, uint16(1024), msg.Header.ID)
+	assert.True(t, msg.Header.Authoritative)
+	assert.Equal(t, 2, len(msg.Answers))
+	assert.Equal(t, uint32(5), msg.Answers[0].Header.TTL)
+	assert.Equal(t, [4]byte{10, 0, 0, 1}, msg.Answers[0].Body.(*dnsmessage.AResource).A)
+
+	rsp, _, _ = handler.handle(newQuery(t, "order.default.pole.local.", dnsmessage.TypeAAAA, 0), "", false)
+	msg = unpack(t, rsp)
+	assert.Equal(t, 1, len(msg.Answers))
+	assert.Equal(t, net.ParseIP("fd00::1").To16(), net.IP(msg.Answers[0].Body.(*dnsmessage.AAAAResource).AAAA[:]))
+
+	// SRV 记录携带端口，target 的地址放在附加记录中
+	rsp, api, _ = handler.handle(newQuery(t, "order.default.pole.local.", dnsmessage.TypeSRV, 1232), "", false)
+	assert.Equal(t, "DNS:SRV", api)
+	msg = unpack(t, rsp)
+	assert.Equal(t, 3, len(msg.Answers))
+	srv := msg.Answers[0].Body.(*dnsmessage.SRVResource)
+	assert.Equal(t, uint16(8080), srv.Port)
+	assert.Equal(t, uint16(100), srv.Weight)
+	assert.Equal(t, "10-0-0-1.order.default.pole.local.", srv.Target.String())
+	// 3 条地址记录以及 EDNS0 的 OPT 记录
+	assert.Equal(t, 4, len(msg.Additionals))
+	assert.Equal(t, dnsmessage.TypeOPT, msg.Additionals[3].Header.Type)
+
+	// 服务存在但没有对应类型的记录
+	rsp, _, rcode = handler.handle(newQuery(t, "pay-service.production.pole.local.", dnsmessage.TypeA, 0), "", false)
+	assert.Equal(t, dnsmessage.RCodeSuccess, rcode)
+	msg = unpack(t, rsp)
+	assert.Empty(t, msg.Answers)
+	assert.Equal(t, dnsmessage.TypeSOA, msg.Authorities[0].Header.Type)
+
+	rsp, _, rcode = handler.handle(newQuery(t, "unknown.default.pole.local.", dnsmessage.TypeA, 0), "", false)
+	assert.Equal(t, dnsmessage.RCodeNameError, rcode)
+	msg = unpack(t, rsp)
+	assert.Equal(t, dnsmessage.RCodeNameError, msg.Header.RCode)
+	assert.Equal(t, uint32(5), msg.Authorities[0].Body.(*dnsmessage.SOAResource).MinTTL)
+
+	// 没有配置上游时拒绝解析其他域名
+	_, _, rcode = handler.handle(newQuery(t, "example.com.", dnsmessage.TypeA, 0), "", false)
+	assert.Equal(t, dnsmessage.RCodeRefused, rcode)
+
+	rsp, _, _ = handler.handle([]byte{0x01}, "", false)
+	assert.Nil(t, rsp)
+}
+
+func Test_dnsHandler_truncate(t *testing.T) {
+	resolver := newTestResolver()
+	instances := make([]*svctypes.Instance, 0, 100)
+	for i := 0; i < 100; i++ {
+		instances = append(instances, newInstance(fmt.Sprintf("10.0.1.%d", i+1), 8080, 100, true, false, ""))
+	}
+	resolver.instanceCache.(*fakeInstanceCache).instances["order-id"] = instances
+	handler := &dnsHandler{resolver: resolver, ttl: 5}
+
+	rsp, _, _ := handler.handle(newQuery(t, "order.default.pole.local.", dnsmessage.TypeA, 0), "", false)
+	assert.LessOrEqual(t, len(rsp), minUDPSize)
+	msg := unpack(t, rsp)
+	assert.True(t, msg.Header.Truncated)
+	assert.NotEmpty(t, msg.Answers)
+
+	rsp, _, _ = handler.handle(newQuery(t, "order.default.pole.local.", dnsmessage.TypeA, 0), "", true)
+	msg = unpack(t, rsp)
+	assert.False(t, msg.Header.Truncated)
+	assert.Equal(t, 100, len(msg.Answers))
+}
+
+func Test_dnsHandler_forward(t *testing.T) {
+	upstream, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer upstream.Close()
+	go func() {
+		buf := make([]byte, minUDPSize)
+		for {
+			n, addr, err := upstream.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			msg := &dnsmessage.Message{}
+			if err := msg.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			msg.Header.Response = true
+			msg.Answers = append(msg.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: msg.Questions[0].Name, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.AResource{A: [4]byte{93, 184, 216, 34}},
+			})
+			rsp, _ := msg.Pack()
+			_, _ = upstream.WriteTo(rsp, addr)
+		}
+	}()
+
+	// 第一个上游不可用时尝试下一个
+	closed, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	closedAddr := closed.LocalAddr().String()
+	_ = closed.Close()
+
+	handler := &dnsHandler{
+		resolver:        newTestResolver(),
+		ttl:             5,
+		upstreams:       []string{closedAddr, upstream.LocalAddr().String()},
+		upstreamTimeout: 500 * time.Millisecond,
+	}
+	rsp, _, rcode := handler.handle(newQuery(t, "example.com.", dnsmessage.TypeA, 0), "", false)
+	assert.Equal(t, dnsmessage.RCodeSuccess, rcode)
+	msg := unpack(t, rsp)
+	assert.Equal(t, uint16(1024), msg.Header.ID)
+	assert.Equal(t, 1, len(msg.Answers))
+	assert.Equal(t, uint32(60), msg.Answers[0].Header.TTL)
+}
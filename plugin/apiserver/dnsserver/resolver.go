@@ -0,0 +1,198 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dnsserver
+
+import (
+	"math"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+
+	cacheapi "github.com/pole-io/pole-server/apis/cache"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+)
+
+// locateFunc 查询客户端所在的地域信息，查询不到时返回 nil
+type locateFunc func(host string) *svctypes.Location
+
+// lookupResult 域名解析的结果，service 为 nil 时表示域名不存在
+type lookupResult struct {
+	service   *svctypes.Service
+	instances []*svctypes.Instance
+}
+
+// recordResolver 将 <service>.<namespace>.<suffix> 解析为服务下健康、未隔离的实例
+type recordResolver struct {
+	serviceCache    cacheapi.ServiceCache
+	instanceCache   cacheapi.InstanceCache
+	suffix          string
+	weightedShuffle bool
+	nearbyFirst     bool
+	locate          locateFunc
+}
+
+// canonicalSuffix 统一为小写并以 . 结尾的域名后缀
+func canonicalSuffix(suffix string) string {
+	suffix = strings.ToLower(strings.Trim(strings.TrimSpace(suffix), "."))
+	return suffix + "."
+}
+
+// inZone 判断域名是否由当前服务负责解析
+func (r *recordResolver) inZone(name string) bool {
+	name = strings.ToLower(name)
+	return name == r.suffix || strings.HasSuffix(name, "."+r.suffix)
+}
+
+// splitName 去掉域名后缀，返回剩余的 label
+func (r *recordResolver) splitName(name string) []string {
+	if !r.inZone(name) || len(name) <= len(r.suffix) {
+		return nil
+	}
+	return strings.Split(name[:len(name)-len(r.suffix)-1], ".")
+}
+
+// lookup 解析域名，域名的最后一段为命名空间，其余部分为服务名。
+// 形如 <ip>.<service>.<namespace>.<suffix> 的域名为 SRV 记录的 target，只返回对应 IP 的实例
+func (r *recordResolver) lookup(name, clientIP string) *lookupResult {
+	labels := r.splitName(name)
+	if len(labels) < 2 {
+		return &lookupResult{}
+	}
+	namespace := labels[len(labels)-1]
+	if svc := r.findService(strings.Join(labels[:len(labels)-1], "."), namespace); svc != nil {
+		return &lookupResult{service: svc, instances: r.selectInstances(svc, "", clientIP)}
+	}
+	if len(labels) < 3 {
+		return &lookupResult{}
+	}
+	host := decodeTargetHost(labels[0])
+	if host == "" {
+		return &lookupResult{}
+	}
+	svc := r.findService(strings.Join(labels[1:len(labels)-1], "."), namespace)
+	if svc == nil {
+		return &lookupResult{}
+	}
+	instances := r.selectInstances(svc, host, clientIP)
+	if len(instances) == 0 {
+		// 实例下线后 target 域名也随之失效
+		return &lookupResult{}
+	}
+	return &lookupResult{service: svc, instances: instances}
+}
+
+// findService 按照服务名查找服务，服务别名指向实际的服务。递归解析器可能会改写域名的大小写，精确匹配失败时再尝试小写
+func (r *recordResolver) findService(name, namespace string) *svctypes.Service {
+	svc := r.serviceCache.GetServiceByName(name, namespace)
+	if svc == nil {
+		lowerName, lowerNamespace := strings.ToLower(name), strings.ToLower(namespace)
+		if lowerName == name && lowerNamespace == namespace {
+			return nil
+		}
+		if svc = r.serviceCache.GetServiceByName(lowerName, lowerNamespace); svc == nil {
+			return nil
+		}
+	}
+	if svc.IsAlias() {
+		return r.serviceCache.GetServiceByID(svc.Reference)
+	}
+	return svc
+}
+
+// selectInstances 返回服务下健康、未隔离并且权重大于 0 的实例，host 不为空时只返回对应 IP 的实例
+func (r *recordResolver) selectInstances(svc *svctypes.Service, host, clientIP string) []*svctypes.Instance {
+	instances := make([]*svctypes.Instance, 0, 8)
+	r.instanceCache.DiscoverServiceInstances(svc.ID, true, func(ins *svctypes.Instance) {
+		if ins.Isolate() || ins.Weight() == 0 || net.ParseIP(ins.Host()) == nil {
+			return
+		}
+		if host != "" && !net.ParseIP(ins.Host()).Equal(net.ParseIP(host)) {
+			return
+		}
+		instances = append(instances, ins)
+	})
+	if r.nearbyFirst && r.locate != nil && len(instances) > 1 {
+		instances = nearbyInstances(r.locate(clientIP), instances)
+	}
+	if r.weightedShuffle {
+		weightedShuffle(instances)
+	} else {
+		sort.Slice(instances, func(i, j int) bool {
+			return instances[i].Host() < instances[j].Host() ||
+				(instances[i].Host() == instances[j].Host() && instances[i].Port() < instances[j].Port())
+		})
+	}
+	return instances
+}
+
+// nearbyInstances 优先返回与客户端同可用区的实例，其次为同地域的实例，都没有时返回全部实例
+func nearbyInstances(loc *svctypes.Location, instances []*svctypes.Instance) []*svctypes.Instance {
+	if loc == nil || loc.Proto == nil {
+		return instances
+	}
+	region, zone := loc.Proto.GetRegion().GetValue(), loc.Proto.GetZone().GetValue()
+	if region == "" {
+		return instances
+	}
+	sameZone := make([]*svctypes.Instance, 0, len(instances))
+	sameRegion := make([]*svctypes.Instance, 0, len(instances))
+	for _, ins := range instances {
+		if ins.Location().GetRegion().GetValue() != region {
+			continue
+		}
+		sameRegion = append(sameRegion, ins)
+		if zone != "" && ins.Location().GetZone().GetValue() == zone {
+			sameZone = append(sameZone, ins)
+		}
+	}
+	if len(sameZone) > 0 {
+		return sameZone
+	}
+	if len(sameRegion) > 0 {
+		return sameRegion
+	}
+	return instances
+}
+
+// weightedShuffle 按照实例权重随机排序，权重越大越有可能排在前面
+func weightedShuffle(instances []*svctypes.Instance) {
+	keys := make(map[*svctypes.Instance]float64, len(instances))
+	for _, ins := range instances {
+		keys[ins] = -math.Log(1-rand.Float64()) / float64(ins.Weight())
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return keys[instances[i]] < keys[instances[j]]
+	})
+}
+
+// encodeTargetHost 将实例 IP 编码为 SRV 记录 target 中的一段 label
+func encodeTargetHost(host string) string {
+	return strings.NewReplacer(".", "-", ":", "-").Replace(host)
+}
+
+// decodeTargetHost 解析 encodeTargetHost 编码的 IP，不是合法的 IP 时返回空
+func decodeTargetHost(label string) string {
+	if ip := net.ParseIP(strings.ReplaceAll(label, "-", ".")); ip != nil {
+		return ip.String()
+	}
+	if ip := net.ParseIP(strings.ReplaceAll(label, "-", ":")); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
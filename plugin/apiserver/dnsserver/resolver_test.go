@@ -0,0 +1,191 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dnsserver
+
+import (
+	"testing"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	cacheapi "github.com/pole-io/pole-server/apis/cache"
+	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+)
+
+type fakeServiceCache struct {
+	cacheapi.ServiceCache
+	services map[string]*svctypes.Service
+}
+
+func (f *fakeServiceCache) GetServiceByName(name string, namespace string) *svctypes.Service {
+	return f.services[namespace+"/"+name]
+}
+
+func (f *fakeServiceCache) GetServiceByID(id string) *svctypes.Service {
+	for _, svc := range f.services {
+		if svc.ID == id {
+			return svc
+		}
+	}
+	return nil
+}
+
+type fakeInstanceCache struct {
+	cacheapi.InstanceCache
+	instances map[string][]*svctypes.Instance
+}
+
+func (f *fakeInstanceCache) DiscoverServiceInstances(serviceID string, onlyHealthy bool,
+	consumer func(*svctypes.Instance)) {
+	for _, ins := range f.instances[serviceID] {
+		if !onlyHealthy || ins.Healthy() {
+			consumer(ins)
+		}
+	}
+}
+
+func newInstance(host string, port, weight uint32, healthy, isolate bool, zone string) *svctypes.Instance {
+	return &svctypes.Instance{Proto: &apiservice.Instance{
+		Host:    protobuf.NewStringValue(host),
+		Port:    protobuf.NewUInt32Value(port),
+		Weight:  protobuf.NewUInt32Value(weight),
+		Healthy: protobuf.NewBoolValue(healthy),
+		Isolate: protobuf.NewBoolValue(isolate),
+		Location: &apimodel.Location{
+			Region: protobuf.NewStringValue("south"),
+			Zone:   protobuf.NewStringValue(zone),
+		},
+	}}
+}
+
+func newTestResolver() *recordResolver {
+	return &recordResolver{
+		serviceCache: &fakeServiceCache{services: map[string]*svctypes.Service{
+			"default/order":          {ID: "order-id", Name: "order", Namespace: "default"},
+			"default/order.api":      {ID: "order-api-id", Name: "order.api", Namespace: "default"},
+			"default/order-alias":    {ID: "alias-id", Name: "order-alias", Namespace: "default", Reference: "order-id"},
+			"production/pay-service": {ID: "pay-id", Name: "pay-service", Namespace: "production"},
+		}},
+		instanceCache: &fakeInstanceCache{instances: map[string][]*svctypes.Instance{
+			"order-id": {
+				newInstance("10.0.0.1", 8080, 100, true, false, "zone-a"),
+				newInstance("10.0.0.2", 8080, 100, true, false, "zone-b"),
+				newInstance("10.0.0.3", 8080, 100, false, false, "zone-a"),
+				newInstance("10.0.0.4", 8080, 100, true, true, "zone-a"),
+				newInstance("10.0.0.5", 8080, 0, true, false, "zone-a"),
+				newInstance("fd00::1", 8080, 100, true, false, "zone-a"),
+			},
+			"order-api-id": {
+				newInstance("10.0.1.1", 9090, 100, true, false, "zone-a"),
+			},
+		}},
+		suffix: canonicalSuffix(".Pole.Local."),
+	}
+}
+
+func hosts(instances []*svctypes.Instance) []string {
+	ret := make([]string, 0, len(instances))
+	for _, ins := range instances {
+		ret = append(ret, ins.Host())
+	}
+	return ret
+}
+
+func Test_recordResolver_lookup(t *testing.T) {
+	resolver := newTestResolver()
+	assert.Equal(t, "pole.local.", resolver.suffix)
+	assert.True(t, resolver.inZone("order.default.POLE.local."))
+	assert.False(t, resolver.inZone("order.default.pole.com."))
+	assert.False(t, resolver.inZone("xpole.local."))
+
+	// 只返回健康、未隔离并且权重大于 0 的实例
+	result := resolver.lookup("order.default.pole.local.", "")
+	assert.Equal(t, "order-id", result.service.ID)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2", "fd00::1"}, hosts(result.instances))
+
+	// 服务名中可以带有 .
+	result = resolver.lookup("order.api.default.pole.local.", "")
+	assert.Equal(t, []string{"10.0.1.1"}, hosts(result.instances))
+
+	// 服务别名以及大小写被改写的域名
+	result = resolver.lookup("order-alias.default.pole.local.", "")
+	assert.Equal(t, "order-id", result.service.ID)
+	result = resolver.lookup("Pay-Service.Production.pole.local.", "")
+	assert.Equal(t, "pay-id", result.service.ID)
+	assert.Empty(t, result.instances)
+
+	// SRV 记录的 target 只返回对应 IP 的实例
+	result = resolver.lookup("10-0-0-2.order.default.pole.local.", "")
+	assert.Equal(t, []string{"10.0.0.2"}, hosts(result.instances))
+	result = resolver.lookup("fd00--1.order.default.pole.local.", "")
+	assert.Equal(t, []string{"fd00::1"}, hosts(result.instances))
+	result = resolver.lookup("10-0-0-3.order.default.pole.local.", "")
+	assert.Nil(t, result.service)
+
+	assert.Nil(t, resolver.lookup("unknown.default.pole.local.", "").service)
+	assert.Nil(t, resolver.lookup("default.pole.local.", "").service)
+	assert.Nil(t, resolver.lookup("pole.local.", "").service)
+}
+
+func Test_recordResolver_nearbyFirst(t *testing.T) {
+	resolver := newTestResolver()
+	resolver.nearbyFirst = true
+	resolver.weightedShuffle = true
+	locations := map[string]*svctypes.Location{
+		"192.168.0.1": {Proto: &apimodel.Location{Region: protobuf.NewStringValue("south"),
+			Zone: protobuf.NewStringValue("zone-b")}},
+		"192.168.0.2": {Proto: &apimodel.Location{Region: protobuf.NewStringValue("south"),
+			Zone: protobuf.NewStringValue("zone-c")}},
+		"192.168.0.3": {Proto: &apimodel.Location{Region: protobuf.NewStringValue("north"),
+			Zone: protobuf.NewStringValue("zone-b")}},
+	}
+	resolver.locate = func(host string) *svctypes.Location {
+		return locations[host]
+	}
+
+	assert.Equal(t, []string{"10.0.0.2"}, hosts(resolver.lookup("order.default.pole.local.", "192.168.0.1").instances))
+	assert.ElementsMatch(t, []string{"10.0.0.1", "10.0.0.2", "fd00::1"},
+		hosts(resolver.lookup("order.default.pole.local.", "192.168.0.2").instances))
+	assert.ElementsMatch(t, []string{"10.0.0.1", "10.0.0.2", "fd00::1"},
+		hosts(resolver.lookup("order.default.pole.local.", "192.168.0.3").instances))
+	assert.ElementsMatch(t, []string{"10.0.0.1", "10.0.0.2", "fd00::1"},
+		hosts(resolver.lookup("order.default.pole.local.", "192.168.0.4").instances))
+}
+
+func Test_weightedShuffle(t *testing.T) {
+	heavy := newInstance("10.0.0.1", 8080, 1000, true, false, "")
+	light := newInstance("10.0.0.2", 8080, 1, true, false, "")
+	heavyFirst := 0
+	for i := 0; i < 1000; i++ {
+		instances := []*svctypes.Instance{light, heavy}
+		weightedShuffle(instances)
+		if instances[0] == heavy {
+			heavyFirst++
+		}
+	}
+	assert.Greater(t, heavyFirst, 950)
+}
+
+func Test_targetHost(t *testing.T) {
+	assert.Equal(t, "10-0-0-1", encodeTargetHost("10.0.0.1"))
+	assert.Equal(t, "10.0.0.1", decodeTargetHost("10-0-0-1"))
+	assert.Equal(t, "fd00::1", decodeTargetHost(encodeTargetHost("fd00::1")))
+	assert.Equal(t, "", decodeTargetHost("order"))
+}
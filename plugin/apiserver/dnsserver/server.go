@@ -0,0 +1,356 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dnsserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/pole-io/pole-server/apis/apiserver"
+	"github.com/pole-io/pole-server/apis/cmdb"
+	"github.com/pole-io/pole-server/apis/observability/statis"
+	"github.com/pole-io/pole-server/apis/pkg/types/metrics"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/pkg/service"
+)
+
+const (
+	ServerDNS = "dns"
+
+	optionListenIP        = "listenIP"
+	optionListenPort      = "listenPort"
+	optionSuffix          = "suffix"
+	optionTTL             = "ttl"
+	optionWeightedShuffle = "weightedShuffle"
+	optionNearbyFirst     = "nearbyFirst"
+	optionUpstreams       = "upstreams"
+	optionUpstreamTimeout = "upstreamTimeout"
+
+	DefaultListenIP   = "0.0.0.0"
+	DefaultListenPort = 53
+	// DefaultSuffix 服务域名的后缀，完整的域名为 <service>.<namespace>.<suffix>
+	DefaultSuffix = "pole.local"
+	// DefaultTTL 实例会随时上下线，记录的 TTL 需要尽量短
+	DefaultTTL             = 5 * time.Second
+	DefaultUpstreamTimeout = 2 * time.Second
+
+	// tcpIdleTimeout TCP 连接上两次查询之间允许的最长空闲时间
+	tcpIdleTimeout = 10 * time.Second
+)
+
+// DNSServer 基于实例缓存对外提供 DNS 协议的服务发现
+type DNSServer struct {
+	udpConn         net.PacketConn
+	tcpListener     net.Listener
+	handler         *dnsHandler
+	option          map[string]interface{}
+	openAPI         map[string]apiserver.APIConfig
+	listenIP        string
+	listenPort      uint32
+	suffix          string
+	ttl             time.Duration
+	weightedShuffle bool
+	nearbyFirst     bool
+	upstreams       []string
+	upstreamTimeout time.Duration
+	exitCh          chan struct{}
+	start           bool
+	restart         bool
+	statis          statis.Statis
+}
+
+// GetPort 获取端口
+func (h *DNSServer) GetPort() uint32 {
+	return h.listenPort
+}
+
+// GetProtocol 获取协议
+func (h *DNSServer) GetProtocol() string {
+	return ServerDNS
+}
+
+// Initialize 初始化 DNS 服务器
+func (h *DNSServer) Initialize(_ context.Context, option map[string]interface{},
+	api map[string]apiserver.APIConfig) error {
+	h.option = option
+	h.openAPI = api
+	h.listenIP = DefaultListenIP
+	if ipValue, ok := option[optionListenIP].(string); ok && ipValue != "" {
+		h.listenIP = ipValue
+	}
+	h.listenPort = DefaultListenPort
+	if portValue, ok := option[optionListenPort].(int); ok && portValue > 0 {
+		h.listenPort = uint32(portValue)
+	}
+	h.suffix = DefaultSuffix
+	if suffix, ok := option[optionSuffix].(string); ok && suffix != "" {
+		h.suffix = suffix
+	}
+	if _, err := dnsmessage.NewName("hostmaster." + canonicalSuffix(h.suffix)); err != nil {
+		return fmt.Errorf("invalid dns suffix %q: %w", h.suffix, err)
+	}
+	h.ttl = DefaultTTL
+	if raw, ok := option[optionTTL].(string); ok && raw != "" {
+		ttl, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		h.ttl = ttl
+	}
+	h.weightedShuffle = true
+	if shuffle, ok := option[optionWeightedShuffle].(bool); ok {
+		h.weightedShuffle = shuffle
+	}
+	h.nearbyFirst, _ = option[optionNearbyFirst].(bool)
+	h.upstreams = nil
+	if raw, ok := option[optionUpstreams].([]interface{}); ok {
+		for _, item := range raw {
+			upstream, _ := item.(string)
+			if _, _, err := net.SplitHostPort(upstream); err != nil {
+				return fmt.Errorf("invalid dns upstream %v: %w", item, err)
+			}
+			h.upstreams = append(h.upstreams, upstream)
+		}
+	}
+	h.upstreamTimeout = DefaultUpstreamTimeout
+	if raw, ok := option[optionUpstreamTimeout].(string); ok && raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		h.upstreamTimeout = timeout
+	}
+	return nil
+}
+
+// Run 启动 DNS 服务器，同时监听 UDP 以及 TCP
+func (h *DNSServer) Run(errCh chan error) {
+	log.Infof("start DNSServer")
+	h.exitCh = make(chan struct{})
+	h.start = true
+	defer func() {
+		close(h.exitCh)
+		h.start = false
+	}()
+
+	namingServer, err := service.GetOriginServer()
+	if err != nil {
+		log.Errorf("%v", err)
+		errCh <- err
+		return
+	}
+	resolver := &recordResolver{
+		serviceCache:    namingServer.Cache().Service(),
+		instanceCache:   namingServer.Cache().Instance(),
+		suffix:          canonicalSuffix(h.suffix),
+		weightedShuffle: h.weightedShuffle,
+		nearbyFirst:     h.nearbyFirst,
+	}
+	if cmdbPlugin := cmdb.GetCMDB(); cmdbPlugin != nil {
+		resolver.locate = func(host string) *svctypes.Location {
+			loc, err := cmdbPlugin.GetLocation(host)
+			if err != nil {
+				log.Error("[DNS] get client location", zap.String("host", host), zap.Error(err))
+				return nil
+			}
+			return loc
+		}
+	}
+	h.handler = &dnsHandler{
+		resolver:        resolver,
+		ttl:             uint32(h.ttl / time.Second),
+		upstreams:       h.upstreams,
+		upstreamTimeout: h.upstreamTimeout,
+	}
+	h.statis = statis.GetStatis()
+
+	address := fmt.Sprintf("%v:%v", h.listenIP, h.listenPort)
+	udpConn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		log.Errorf("net listen udp(%s) err: %s", address, err.Error())
+		errCh <- err
+		return
+	}
+	tcpListener, err := net.Listen("tcp", address)
+	if err != nil {
+		_ = udpConn.Close()
+		log.Errorf("net listen tcp(%s) err: %s", address, err.Error())
+		errCh <- err
+		return
+	}
+	h.udpConn = udpConn
+	h.tcpListener = tcpListener
+
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	errs := make(chan error, 2)
+	go func() {
+		defer wg.Done()
+		errs <- h.serveUDP(udpConn)
+	}()
+	go func() {
+		defer wg.Done()
+		errs <- h.serveTCP(tcpListener)
+	}()
+	// 任意一个协议停止服务后，另一个协议也随之停止
+	err = <-errs
+	_ = udpConn.Close()
+	_ = tcpListener.Close()
+	wg.Wait()
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Errorf("%+v", err)
+		if !h.restart {
+			log.Infof("not in restart progress, broadcast error")
+			errCh <- err
+		}
+		return
+	}
+	log.Infof("DNSServer stop")
+}
+
+// serveUDP 每个查询报文独立处理
+func (h *DNSServer) serveUDP(conn net.PacketConn) error {
+	buf := make([]byte, maxTCPSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		req := make([]byte, n)
+		copy(req, buf[:n])
+		go func() {
+			rsp := h.process(req, addr, false)
+			if rsp == nil {
+				return
+			}
+			if _, err := conn.WriteTo(rsp, addr); err != nil {
+				log.Debug("[DNS] write udp response", zap.String("client-address", addr.String()),
+					zap.Error(err))
+			}
+		}()
+	}
+}
+
+// serveTCP TCP 连接上可以连续发送多个查询
+func (h *DNSServer) serveTCP(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go h.handleTCPConn(conn)
+	}
+}
+
+func (h *DNSServer) handleTCPConn(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+	for {
+		_ = conn.SetDeadline(time.Now().Add(tcpIdleTimeout))
+		req, err := readTCPMessage(conn)
+		if err != nil {
+			return
+		}
+		rsp := h.process(req, conn.RemoteAddr(), true)
+		if rsp == nil {
+			return
+		}
+		if err := writeTCPMessage(conn, rsp); err != nil {
+			return
+		}
+	}
+}
+
+// process 处理查询并统计接口调用
+func (h *DNSServer) process(req []byte, addr net.Addr, tcp bool) []byte {
+	startTime := time.Now()
+	clientIP, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		clientIP = addr.String()
+	}
+	rsp, api, rcode := h.handler.handle(req, clientIP, tcp)
+
+	diff := time.Since(startTime)
+	if diff > time.Second {
+		accesslog.Info("handling time > 1s",
+			zap.String("client-address", addr.String()),
+			zap.String("api", api),
+			zap.Duration("handling-time", diff),
+		)
+	}
+	if api == "" || h.statis == nil {
+		return rsp
+	}
+	h.statis.ReportCallMetrics(metrics.CallMetric{
+		Type:     metrics.ServerCallMetric,
+		API:      api,
+		Protocol: "DNS",
+		Code:     int(rcode),
+		Duration: diff,
+	})
+	return rsp
+}
+
+// Stop 结束 DNSServer 的运行
+func (h *DNSServer) Stop() {
+	if h.udpConn != nil {
+		_ = h.udpConn.Close()
+	}
+	if h.tcpListener != nil {
+		_ = h.tcpListener.Close()
+	}
+}
+
+// Restart 重启 DNSServer
+func (h *DNSServer) Restart(
+	option map[string]interface{}, api map[string]apiserver.APIConfig, errCh chan error) error {
+	log.Infof("restart dns server new config: %+v", option)
+	backupOption := h.option
+	backupAPI := h.openAPI
+
+	// 设置restart标记，防止stop的时候把错误抛出
+	h.restart = true
+	h.Stop()
+	if h.start {
+		<-h.exitCh
+	}
+
+	if err := h.Initialize(context.Background(), option, api); err != nil {
+		h.restart = false
+		if initErr := h.Initialize(context.Background(), backupOption, backupAPI); initErr != nil {
+			log.Errorf("start dns server with backup cfg err: %s", initErr.Error())
+			return initErr
+		}
+		go h.Run(errCh)
+
+		log.Errorf("restart dns server initialize err: %s", err.Error())
+		return err
+	}
+
+	h.restart = false
+	go h.Run(errCh)
+	return nil
+}
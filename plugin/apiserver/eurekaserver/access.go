@@ -28,6 +28,7 @@ import (
 
 	"github.com/pole-io/pole-server/apis/pkg/types"
 	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 )
 
 const (
@@ -382,7 +383,7 @@ func (h *EurekaServer) RegisterApplication(req *restful.Request, rsp *restful.Re
 		return
 	}
 
-	ctx := context.WithValue(context.Background(), types.ContextAuthTokenKey, token)
+	ctx := context.WithValue(tracing.Detach(req.Request.Context()), types.ContextAuthTokenKey, token)
 
 	namespace := readNamespaceFromRequest(req, h.namespace)
 	eurekalog.Infof(
@@ -436,7 +437,7 @@ func (h *EurekaServer) UpdateStatus(req *restful.Request, rsp *restful.Response)
 		writeHeader(http.StatusOK, rsp)
 		return
 	}
-	ctx := context.WithValue(context.Background(), sourceFromEureka{}, true)
+	ctx := context.WithValue(tracing.Detach(req.Request.Context()), sourceFromEureka{}, true)
 	code := h.updateStatus(ctx, namespace, appId, instId, status, false)
 	writePolarisStatusCode(req, code)
 	if code == api.ExecuteSuccess || code == api.NoNeedUpdate {
@@ -481,7 +482,7 @@ func (h *EurekaServer) DeleteStatus(req *restful.Request, rsp *restful.Response)
 		"client: %s,namespace=%s, instId=%s, appId=%s",
 		remoteAddr, namespace, instId, appId)
 
-	ctx := context.WithValue(context.Background(), sourceFromEureka{}, true)
+	ctx := context.WithValue(tracing.Detach(req.Request.Context()), sourceFromEureka{}, true)
 	code := h.updateStatus(ctx, namespace, appId, instId, StatusUp, false)
 	writePolarisStatusCode(req, code)
 	if code == api.ExecuteSuccess {
@@ -521,7 +522,7 @@ func (h *EurekaServer) RenewInstance(req *restful.Request, rsp *restful.Response
 		return
 	}
 	namespace := readNamespaceFromRequest(req, h.namespace)
-	code := h.renew(context.Background(), namespace, appId, instId, false)
+	code := h.renew(tracing.Detach(req.Request.Context()), namespace, appId, instId, false)
 	writePolarisStatusCode(req, code)
 	if code == api.ExecuteSuccess || code == api.HeartbeatExceedLimit {
 		writeHeader(http.StatusOK, rsp)
@@ -559,7 +560,7 @@ func (h *EurekaServer) CancelInstance(req *restful.Request, rsp *restful.Respons
 	eurekalog.Infof("[EUREKA-SERVER]received instance deregistered request, "+
 		"client: %s, namespace: %s, instId: %s, appId: %s",
 		remoteAddr, namespace, instId, appId)
-	code := h.deregisterInstance(context.Background(), namespace, appId, instId, false)
+	code := h.deregisterInstance(tracing.Detach(req.Request.Context()), namespace, appId, instId, false)
 	writePolarisStatusCode(req, code)
 	if code == api.ExecuteSuccess || code == api.NotFoundResource || code == api.SameInstanceRequest {
 		writeHeader(http.StatusOK, rsp)
@@ -635,7 +636,7 @@ func (h *EurekaServer) UpdateMetadata(req *restful.Request, rsp *restful.Respons
 		}
 		metadataMap[key] = values[0]
 	}
-	code := h.updateMetadata(context.Background(), namespace, appId, instId, metadataMap)
+	code := h.updateMetadata(tracing.Detach(req.Request.Context()), namespace, appId, instId, metadataMap)
 	writePolarisStatusCode(req, code)
 	if code == api.ExecuteSuccess {
 		eurekalog.Infof("[EUREKA-SERVER]instance metadata (namespace=%s, instId=%s, appId=%s) has been updated successfully",
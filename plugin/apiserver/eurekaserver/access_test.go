@@ -250,7 +250,7 @@ func Test_EurekaWrite(t *testing.T) {
 	assert.Equal(t, restfulReq.Attribute(statusCodeHeader), uint32(apimodel.Code_ExecuteSuccess))
 
 	_ = discoverSuit.CacheMgr().TestUpdate()
-	saveIns, err := discoverSuit.Storage.GetInstance(mockIns.InstanceId)
+	saveIns, err := discoverSuit.Storage.GetInstance(context.Background(), mockIns.InstanceId)
 	assert.NoError(t, err)
 	assert.NotNil(t, saveIns)
 
@@ -272,7 +272,7 @@ func Test_EurekaWrite(t *testing.T) {
 			assert.Equal(t, restfulReq.Attribute(statusCodeHeader), uint32(apimodel.Code_ExecuteSuccess))
 
 			//
-			saveIns, err := discoverSuit.Storage.GetInstance(mockIns.InstanceId)
+			saveIns, err := discoverSuit.Storage.GetInstance(context.Background(), mockIns.InstanceId)
 			assert.NoError(t, err)
 			assert.NotNil(t, saveIns)
 			assert.False(t, saveIns.Isolate())
@@ -295,7 +295,7 @@ func Test_EurekaWrite(t *testing.T) {
 			assert.Equal(t, restfulReq.Attribute(statusCodeHeader), uint32(apimodel.Code_ExecuteSuccess), fmt.Sprintf("%d", restfulReq.Attribute(statusCodeHeader)))
 
 			//
-			saveIns, err := discoverSuit.Storage.GetInstance(mockIns.InstanceId)
+			saveIns, err := discoverSuit.Storage.GetInstance(context.Background(), mockIns.InstanceId)
 			assert.NoError(t, err)
 			assert.True(t, saveIns.Isolate())
 			assert.Equal(t, StatusDown, saveIns.Proto.Metadata[InternalMetadataStatus])
@@ -318,7 +318,7 @@ func Test_EurekaWrite(t *testing.T) {
 			assert.Equal(t, restfulReq.Attribute(statusCodeHeader), uint32(apimodel.Code_ExecuteSuccess), fmt.Sprintf("%d", restfulReq.Attribute(statusCodeHeader)))
 
 			//
-			saveIns, err := discoverSuit.Storage.GetInstance(mockIns.InstanceId)
+			saveIns, err := discoverSuit.Storage.GetInstance(context.Background(), mockIns.InstanceId)
 			assert.NoError(t, err)
 			assert.False(t, saveIns.Isolate())
 			assert.Equal(t, StatusUp, saveIns.Proto.Metadata[InternalMetadataStatus])
@@ -343,7 +343,7 @@ func Test_EurekaWrite(t *testing.T) {
 			assert.Equal(t, apimodel.Code_ExecuteSuccess, apimodel.Code(rsp.GetCode().GetValue()))
 
 			// 在获取一次
-			saveIns, err := discoverSuit.Storage.GetInstance(mockIns.InstanceId)
+			saveIns, err := discoverSuit.Storage.GetInstance(context.Background(), mockIns.InstanceId)
 			assert.NoError(t, err)
 			assert.True(t, saveIns.Isolate())
 			assert.Equal(t, StatusOutOfService, saveIns.Proto.Metadata[InternalMetadataStatus])
@@ -359,7 +359,7 @@ func Test_EurekaWrite(t *testing.T) {
 			assert.Equal(t, apimodel.Code_ExecuteSuccess, apimodel.Code(rsp.GetCode().GetValue()))
 
 			// 在获取一次
-			saveIns, err := discoverSuit.Storage.GetInstance(mockIns.InstanceId)
+			saveIns, err := discoverSuit.Storage.GetInstance(context.Background(), mockIns.InstanceId)
 			assert.NoError(t, err)
 			assert.NotNil(t, saveIns)
 			assert.True(t, saveIns.Isolate())
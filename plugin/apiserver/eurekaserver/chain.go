@@ -61,7 +61,7 @@ func (c *EurekaInstanceChain) AfterUpdate(ctx context.Context, instances ...*svc
 		} else {
 			metadata[InternalMetadataStatus] = StatusUp
 		}
-		if err := c.s.BatchAppendInstanceMetadata([]*store.InstanceMetadataRequest{
+		if err := c.s.BatchAppendInstanceMetadata(ctx, []*store.InstanceMetadataRequest{
 			{
 				InstanceID: ins.ID(),
 				Revision:   utils.NewUUID(),
@@ -31,6 +31,7 @@ const (
 	optionPeerNodesToReplicate   = "peersToReplicate"
 	optionCustomValues           = "customValues"
 	optionGenerateUniqueInstId   = "generateUniqueInstId"
+	optionMigration              = "migration"
 )
 
 const (
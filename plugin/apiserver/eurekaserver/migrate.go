@@ -0,0 +1,295 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package eurekaserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/mitchellh/mapstructure"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+	"github.com/pole-io/pole-server/pkg/service"
+	"github.com/pole-io/pole-server/pkg/service/migrate"
+)
+
+const (
+	DefaultMigrationInterval = 30 * time.Second
+	DefaultMigrationTimeout  = 5 * time.Second
+)
+
+var errLegacyNotFound = errors.New("resource not found in legacy eureka")
+
+// MigrationConfig 从存量 eureka 集群迁移服务实例的配置
+type MigrationConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// Address 存量 eureka 的服务地址，例如 http://127.0.0.1:8761/eureka
+	Address string `mapstructure:"address"`
+	// Namespace 存量实例镜像到 pole-server 的命名空间，默认为 eureka 插件的命名空间
+	Namespace string        `mapstructure:"namespace"`
+	Interval  time.Duration `mapstructure:"interval"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+	// WriteBack 是否将直接注册到 pole-server 的实例回写到存量 eureka，方便尚未迁移的客户端发现
+	WriteBack bool `mapstructure:"writeBack"`
+}
+
+func parseMigrationConfig(raw map[interface{}]interface{}, defaultNamespace string) (*MigrationConfig, error) {
+	cfg := &MigrationConfig{}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     cfg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return nil, err
+	}
+	if !cfg.Enable {
+		return cfg, nil
+	}
+	if len(cfg.Address) == 0 {
+		return nil, errors.New("eureka migration address is empty")
+	}
+	cfg.Address = strings.TrimSuffix(cfg.Address, "/")
+	if len(cfg.Namespace) == 0 {
+		cfg.Namespace = defaultNamespace
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultMigrationInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultMigrationTimeout
+	}
+	return cfg, nil
+}
+
+// legacyMigrator 周期性拉取存量 eureka 的全量实例镜像到 pole-server，并按需回写 pole-server 的实例
+type legacyMigrator struct {
+	cfg                  *MigrationConfig
+	client               *legacyEurekaClient
+	mirror               *migrate.Mirror
+	defaultNamespace     string
+	generateUniqueInstId bool
+	isLeader             func() bool
+}
+
+func newLegacyMigrator(cfg *MigrationConfig, svr service.DiscoverServer, defaultNamespace string,
+	generateUniqueInstId bool, isLeader func() bool) *legacyMigrator {
+	return &legacyMigrator{
+		cfg:                  cfg,
+		client:               newLegacyEurekaClient(cfg.Address, cfg.Timeout),
+		mirror:               migrate.NewMirror(svr, cfg.Namespace, ServerEureka),
+		defaultNamespace:     defaultNamespace,
+		generateUniqueInstId: generateUniqueInstId,
+		isLeader:             isLeader,
+	}
+}
+
+func (m *legacyMigrator) run(ctx context.Context, exitCh <-chan struct{}) {
+	eurekalog.Infof("[EUREKA-SERVER] start migrating from legacy eureka %s to namespace %s",
+		m.cfg.Address, m.cfg.Namespace)
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-exitCh:
+			return
+		case <-ticker.C:
+			if !m.isLeader() {
+				continue
+			}
+			if err := m.syncOnce(ctx); err != nil {
+				eurekalog.Errorf("[EUREKA-SERVER] fail to migrate from legacy eureka %s: %v", m.cfg.Address, err)
+			}
+		}
+	}
+}
+
+func (m *legacyMigrator) syncOnce(ctx context.Context) error {
+	apps, err := m.client.fetchApps(ctx)
+	if err != nil {
+		return err
+	}
+	specs := make([]*apiservice.Instance, 0, 32)
+	for _, app := range apps.Application {
+		for _, instance := range app.Instance {
+			if isWriteBackInstance(instance) {
+				continue
+			}
+			if err := convertInstancePorts(instance); err != nil {
+				eurekalog.Warnf("[EUREKA-SERVER] skip legacy instance %s of %s: %v", instance.InstanceId, app.Name, err)
+				continue
+			}
+			appId := formatWriteName(app.Name)
+			specs = append(specs, convertEurekaInstance(instance, m.cfg.Namespace, m.defaultNamespace,
+				appId, m.generateUniqueInstId))
+		}
+	}
+	if err := m.mirror.Sync(ctx, specs); err != nil {
+		return err
+	}
+	if !m.cfg.WriteBack {
+		return nil
+	}
+	return m.writeBack(ctx, apps)
+}
+
+// writeBack 将直接注册到 pole-server 的实例回写到存量 eureka，回写的实例带有标记，拉取时会被跳过，避免循环同步
+func (m *legacyMigrator) writeBack(ctx context.Context, apps *Applications) error {
+	written := map[string]*InstanceInfo{}
+	for _, app := range apps.Application {
+		for _, instance := range app.Instance {
+			if isWriteBackInstance(instance) {
+				written[formatReadName(app.Name)+"/"+instance.InstanceId] = instance
+			}
+		}
+	}
+
+	var errs []error
+	for svcName, instances := range m.mirror.NativeInstances() {
+		appName := formatReadName(svcName)
+		for _, ins := range instances {
+			info := buildInstance(appName, ins.Proto, ins.ModifyTime.UnixNano()/1e6)
+			info.Metadata.Meta[types.MetaKeyMigrateWriteBack] = "true"
+			// 回写实例的续约由迁移任务负责，租约时长需要覆盖迁移周期
+			info.LeaseInfo = &LeaseInfo{
+				RenewalIntervalInSecs: int(m.cfg.Interval / time.Second),
+				DurationInSecs:        int(3 * m.cfg.Interval / time.Second),
+			}
+			key := appName + "/" + info.InstanceId
+			exist, ok := written[key]
+			delete(written, key)
+			if ok && exist.Status == info.Status {
+				err := m.client.heartbeat(ctx, appName, info.InstanceId)
+				if !errors.Is(err, errLegacyNotFound) {
+					if err != nil {
+						errs = append(errs, err)
+					}
+					continue
+				}
+			}
+			if err := m.client.register(ctx, info); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for key, instance := range written {
+		appName := key[:strings.Index(key, "/")]
+		if err := m.client.deregister(ctx, appName, instance.InstanceId); err != nil &&
+			!errors.Is(err, errLegacyNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isWriteBackInstance(instance *InstanceInfo) bool {
+	if instance.Metadata == nil {
+		return false
+	}
+	_, ok := instance.Metadata.Meta[types.MetaKeyMigrateWriteBack]
+	return ok
+}
+
+// legacyEurekaClient 基于 eureka 的 REST API 访问存量 eureka 集群
+type legacyEurekaClient struct {
+	address string
+	client  *http.Client
+}
+
+func newLegacyEurekaClient(address string, timeout time.Duration) *legacyEurekaClient {
+	return &legacyEurekaClient{
+		address: address,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *legacyEurekaClient) fetchApps(ctx context.Context) (*Applications, error) {
+	body, err := c.do(ctx, http.MethodGet, "/apps", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ApplicationsResponse{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, err
+	}
+	if resp.Applications == nil {
+		return &Applications{}, nil
+	}
+	return resp.Applications, nil
+}
+
+func (c *legacyEurekaClient) register(ctx context.Context, instance *InstanceInfo) error {
+	data, err := json.Marshal(&RegistrationRequest{Instance: instance})
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, http.MethodPost, "/apps/"+instance.AppName, data)
+	return err
+}
+
+func (c *legacyEurekaClient) heartbeat(ctx context.Context, appName, instanceId string) error {
+	_, err := c.do(ctx, http.MethodPut, fmt.Sprintf("/apps/%s/%s?status=%s", appName, instanceId, StatusUp), nil)
+	return err
+}
+
+func (c *legacyEurekaClient) deregister(ctx context.Context, appName, instanceId string) error {
+	_, err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/apps/%s/%s", appName, instanceId), nil)
+	return err
+}
+
+func (c *legacyEurekaClient) do(ctx context.Context, method, path string, data []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.address+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(restful.HEADER_Accept, restful.MIME_JSON)
+	if len(data) > 0 {
+		req.Header.Set(restful.HEADER_ContentType, restful.MIME_JSON)
+	}
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode == http.StatusNotFound {
+		return nil, errLegacyNotFound
+	}
+	if rsp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("%s %s: status %d, %s", method, path, rsp.StatusCode, string(body))
+	}
+	return body, nil
+}
@@ -0,0 +1,140 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package eurekaserver
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+)
+
+func TestParseMigrationConfig(t *testing.T) {
+	cfg, err := parseMigrationConfig(map[interface{}]interface{}{
+		"enable":  true,
+		"address": "http://127.0.0.1:8761/eureka/",
+	}, "default")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:8761/eureka", cfg.Address)
+	assert.Equal(t, "default", cfg.Namespace)
+	assert.Equal(t, DefaultMigrationInterval, cfg.Interval)
+	assert.Equal(t, DefaultMigrationTimeout, cfg.Timeout)
+	assert.False(t, cfg.WriteBack)
+
+	cfg, err = parseMigrationConfig(map[interface{}]interface{}{
+		"enable":    true,
+		"address":   "http://127.0.0.1:8761/eureka",
+		"namespace": "legacy",
+		"interval":  "10s",
+		"writeBack": true,
+	}, "default")
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy", cfg.Namespace)
+	assert.Equal(t, 10*time.Second, cfg.Interval)
+	assert.True(t, cfg.WriteBack)
+
+	_, err = parseMigrationConfig(map[interface{}]interface{}{"enable": true}, "default")
+	assert.Error(t, err)
+}
+
+// fakeLegacyEureka 模拟存量 eureka 的 REST API，返回的数据结构与 eureka apiserver 保持一致
+type fakeLegacyEureka struct {
+	lock     sync.Mutex
+	apps     *Applications
+	requests []string
+}
+
+func (f *fakeLegacyEureka) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/eureka/apps":
+		_ = json.NewEncoder(w).Encode(&ApplicationsResponse{Applications: f.apps})
+	case r.Method == http.MethodPost:
+		body, _ := io.ReadAll(r.Body)
+		req := &RegistrationRequest{}
+		if err := json.Unmarshal(body, req); err != nil || req.Instance == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut && r.URL.Path == "/eureka/apps/SVC/missing":
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestLegacyEurekaClient(t *testing.T) {
+	instance := buildInstance("SVC", &apiservice.Instance{
+		Id:       &wrappers.StringValue{Value: "ins-1"},
+		Host:     &wrappers.StringValue{Value: "127.0.0.1"},
+		Port:     &wrappers.UInt32Value{Value: 8080},
+		Protocol: &wrappers.StringValue{Value: InsecureProtocol},
+		Healthy:  &wrappers.BoolValue{Value: true},
+		Metadata: map[string]string{"k": "v"},
+	}, time.Now().UnixMilli())
+	legacy := &fakeLegacyEureka{apps: &Applications{
+		Application: []*Application{{Name: "SVC", Instance: []*InstanceInfo{instance}}},
+	}}
+	server := httptest.NewServer(legacy)
+	defer server.Close()
+
+	client := newLegacyEurekaClient(server.URL+"/eureka", time.Second)
+	apps, err := client.fetchApps(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, apps.Application, 1)
+	got := apps.Application[0].Instance[0]
+	assert.NoError(t, convertInstancePorts(got))
+	assert.Equal(t, 8080, got.Port.RealPort)
+	assert.False(t, isWriteBackInstance(got))
+
+	spec := convertEurekaInstance(got, "default", "default", formatWriteName("SVC"), false)
+	assert.Equal(t, "svc", spec.GetService().GetValue())
+	assert.Equal(t, "127.0.0.1", spec.GetHost().GetValue())
+	assert.Equal(t, uint32(8080), spec.GetPort().GetValue())
+	assert.Equal(t, "v", spec.GetMetadata()["k"])
+
+	instance.Metadata.Meta[types.MetaKeyMigrateWriteBack] = "true"
+	assert.NoError(t, client.register(context.Background(), instance))
+	assert.NoError(t, client.heartbeat(context.Background(), "SVC", "ins-1"))
+	assert.ErrorIs(t, client.heartbeat(context.Background(), "SVC", "missing"), errLegacyNotFound)
+	assert.NoError(t, client.deregister(context.Background(), "SVC", "ins-1"))
+	assert.Equal(t, []string{
+		"GET /eureka/apps",
+		"POST /eureka/apps/SVC",
+		"PUT /eureka/apps/SVC/ins-1",
+		"PUT /eureka/apps/SVC/missing",
+		"DELETE /eureka/apps/SVC/ins-1",
+	}, legacy.requests)
+
+	apps, err = client.fetchApps(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, isWriteBackInstance(apps.Application[0].Instance[0]))
+}
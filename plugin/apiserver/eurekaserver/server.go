@@ -38,9 +38,11 @@ import (
 	connlimit "github.com/pole-io/pole-server/pkg/common/conn/limit"
 	"github.com/pole-io/pole-server/pkg/common/eventhub"
 	"github.com/pole-io/pole-server/pkg/common/secure"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 	"github.com/pole-io/pole-server/pkg/service"
 	"github.com/pole-io/pole-server/pkg/service/healthcheck"
+	"github.com/pole-io/pole-server/pkg/service/migrate"
 )
 
 const (
@@ -155,6 +157,8 @@ type EurekaServer struct {
 	subCtxs              []*eventhub.SubscribtionContext
 
 	allowAsyncRegis bool
+
+	migrationConfig *MigrationConfig
 }
 
 // GetPort 获取端口
@@ -260,6 +264,14 @@ func (h *EurekaServer) Initialize(ctx context.Context, option map[string]interfa
 		}
 	}
 
+	if raw, _ := option[optionMigration].(map[interface{}]interface{}); raw != nil {
+		migrationConfig, err := parseMigrationConfig(raw, h.namespace)
+		if err != nil {
+			return err
+		}
+		h.migrationConfig = migrationConfig
+	}
+
 	eurekalog.Infof("[EUREKA] custom eureka parameters: %v", CustomEurekaParameters)
 	return nil
 }
@@ -357,6 +369,16 @@ func (h *EurekaServer) Run(errCh chan error) {
 	h.workers = NewApplicationsWorkers(h.refreshInterval, h.deltaExpireInterval, h.enableSelfPreservation,
 		h.namingServer, h.healthCheckServer, h.namespace)
 	h.statis = statis.GetStatis()
+	if h.migrationConfig != nil && h.migrationConfig.Enable {
+		isLeader, err := migrate.StartElection()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		migrator := newLegacyMigrator(h.migrationConfig, h.originDiscoverSvr, h.namespace,
+			h.generateUniqueInstId, isLeader)
+		go migrator.run(context.Background(), h.exitCh)
+	}
 	// 初始化http server
 	address := fmt.Sprintf("%v:%v", h.listenIP, h.listenPort)
 
@@ -408,6 +430,7 @@ func (h *EurekaServer) Run(errCh chan error) {
 // 创建handler
 func (h *EurekaServer) createRestfulContainer() (*restful.Container, error) {
 	wsContainer := restful.NewContainer()
+	wsContainer.Filter(tracing.RestfulFilter("EUREKA"))
 	wsContainer.Filter(h.process)
 	wsContainer.Add(h.GetEurekaV2Server())
 	wsContainer.Add(h.GetEurekaV1Server())
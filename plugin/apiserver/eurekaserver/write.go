@@ -259,7 +259,7 @@ func (h *EurekaServer) updateStatus(
 	instanceId = checkOrBuildNewInstanceIdByNamespace(namespace, h.namespace, appId, instanceId, h.generateUniqueInstId)
 
 	svr := h.originDiscoverSvr.(*service.Server)
-	saveIns, err := svr.Store().GetInstance(instanceId)
+	saveIns, err := svr.Store().GetInstance(ctx, instanceId)
 	if err != nil {
 		eurekalog.Error("[EUREKA-SERVER] get instance from store when update status", zap.Error(err))
 		return uint32(storeapi.StoreCode2APICode(err))
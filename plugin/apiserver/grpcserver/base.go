@@ -29,6 +29,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
@@ -38,9 +39,11 @@ import (
 	authcommon "github.com/pole-io/pole-server/apis/pkg/types/auth"
 	"github.com/pole-io/pole-server/apis/pkg/types/metrics"
 	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+	conndrain "github.com/pole-io/pole-server/pkg/common/conn/drain"
 	connlimit "github.com/pole-io/pole-server/pkg/common/conn/limit"
 	commonlog "github.com/pole-io/pole-server/pkg/common/log"
 	"github.com/pole-io/pole-server/pkg/common/secure"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 )
 
@@ -62,6 +65,7 @@ type BaseGrpcServer struct {
 	bz authcommon.BzModule
 
 	server     *grpc.Server
+	tracker    *conndrain.StreamTracker
 	statis     statis.Statis
 	ratelimit  ratelimit.Ratelimit
 	OpenMethod map[string]bool
@@ -85,6 +89,7 @@ func (b *BaseGrpcServer) Initialize(ctx context.Context, conf map[string]interfa
 
 	b.listenIP = conf["listenIP"].(string)
 	b.listenPort = uint32(conf["listenPort"].(int))
+	b.tracker = conndrain.NewStreamTracker()
 
 	if raw, _ := conf["connLimit"].(map[interface{}]interface{}); raw != nil {
 		connConfig, err := connlimit.ParseConnLimitConfig(raw)
@@ -122,6 +127,15 @@ func (b *BaseGrpcServer) Stop(protocol string) {
 	}
 }
 
+// Drain 拒绝新的 stream, 并在 window 时间内分批断开存量的 stream
+func (b *BaseGrpcServer) Drain(ctx context.Context, window time.Duration) {
+	if b.tracker == nil {
+		return
+	}
+	b.log.Infof("[API-Server] %s server begin drain %d streams", b.protocol, b.tracker.Count())
+	b.tracker.Drain(ctx, window)
+}
+
 // Run server main loop
 func (b *BaseGrpcServer) Run(errCh chan error, protocol string, initServer InitServer) {
 	b.log.Infof("[API-Server] start %s server", protocol)
@@ -171,8 +185,9 @@ func (b *BaseGrpcServer) Run(errCh chan error, protocol string, initServer InitS
 
 	// 设置 grpc server options
 	opts := []grpc.ServerOption{
-		grpc.UnaryInterceptor(b.unaryInterceptor),
-		grpc.StreamInterceptor(b.streamInterceptor),
+		grpc.ChainUnaryInterceptor(tracing.UnaryServerInterceptor("gRPC"), b.unaryInterceptor),
+		grpc.ChainStreamInterceptor(b.tracker.StreamServerInterceptor(), tracing.StreamServerInterceptor("gRPC"),
+			b.streamInterceptor),
 	}
 	if creds != nil {
 		// 指定使用 TLS credentials
@@ -184,6 +199,7 @@ func (b *BaseGrpcServer) Run(errCh chan error, protocol string, initServer InitS
 		errCh <- err
 		return
 	}
+	healthpb.RegisterHealthServer(server, &healthServer{})
 	b.server = server
 
 	b.statis = statis.GetStatis()
@@ -203,6 +219,9 @@ var notPrintableMethods = map[string]bool{
 
 func (b *BaseGrpcServer) unaryInterceptor(ctx context.Context, req interface{},
 	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (rsp interface{}, err error) {
+	if isHealthMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
 	stream := newVirtualStream(ctx,
 		WithVirtualStreamBaseServer(b),
 		WithVirtualStreamLogger(b.log),
@@ -251,6 +270,9 @@ func (b *BaseGrpcServer) recoverFunc(i interface{}, w http.ResponseWriter) {
 
 func (b *BaseGrpcServer) streamInterceptor(srv interface{}, ss grpc.ServerStream,
 	info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	if isHealthMethod(info.FullMethod) {
+		return handler(srv, ss)
+	}
 	stream := newVirtualStream(ss.Context(),
 		WithVirtualStreamBaseServer(b),
 		WithVirtualStreamServerStream(ss),
@@ -0,0 +1,83 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package grpcserver
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/pole-io/pole-server/pkg/admin"
+)
+
+const (
+	// healthMethodPrefix gRPC 健康检查协议的方法前缀
+	healthMethodPrefix = "/grpc.health.v1.Health/"
+	// healthWatchInterval Watch 场景下重新计算就绪状态的间隔
+	healthWatchInterval = 5 * time.Second
+)
+
+// isHealthMethod 健康检查请求不受接口开放列表以及限流的约束
+func isHealthMethod(method string) bool {
+	return strings.HasPrefix(method, healthMethodPrefix)
+}
+
+// healthServer 基于就绪探测的结果实现 gRPC 健康检查协议, 所有的 service 名称共享进程级别的就绪状态
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+}
+
+// Check 返回当前的就绪状态
+func (h *healthServer) Check(ctx context.Context,
+	req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return &healthpb.HealthCheckResponse{Status: servingStatus(ctx)}, nil
+}
+
+// Watch 就绪状态变化时推送给客户端
+func (h *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		if cur := servingStatus(stream.Context()); cur != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: cur}); err != nil {
+				return err
+			}
+			last = cur
+		}
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func servingStatus(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	svr, err := admin.GetServer()
+	if err != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	if ret := svr.Readiness(ctx); !ret.Healthy {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
@@ -0,0 +1,254 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package limiter
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apilimiter "github.com/polarismesh/specification/source/go/api/v1/traffic_manage/ratelimiter"
+)
+
+var (
+	codeSuccess      = uint32(apimodel.Code_ExecuteSuccess)
+	codeInvalidParam = uint32(apimodel.Code_InvalidParameter)
+)
+
+func nowMs() int64 {
+	return time.Now().UnixMilli()
+}
+
+// Service 限流接口, 客户端通过该双向流完成计数器初始化以及配额上报
+func (g *RateLimitGRPCServer) Service(stream apilimiter.RateLimitGRPCV2_ServiceServer) error {
+	forwarded := isForwarded(stream.Context())
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		resp := g.handle(stream.Context(), req, forwarded)
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// TimeAdjust 时间对齐接口
+func (g *RateLimitGRPCServer) TimeAdjust(ctx context.Context,
+	req *apilimiter.TimeAdjustRequest) (*apilimiter.TimeAdjustResponse, error) {
+	return &apilimiter.TimeAdjustResponse{
+		ServerTimestamp: nowMs(),
+	}, nil
+}
+
+func (g *RateLimitGRPCServer) handle(ctx context.Context, req *apilimiter.RateLimitRequest,
+	forwarded bool) *apilimiter.RateLimitResponse {
+	resp := &apilimiter.RateLimitResponse{
+		Cmd: req.GetCmd(),
+	}
+	switch req.GetCmd() {
+	case apilimiter.RateLimitCmd_INIT:
+		resp.RateLimitInitResponse = g.initTarget(ctx, req.GetRateLimitInitRequest(), forwarded)
+	case apilimiter.RateLimitCmd_BATCH_INIT:
+		resp.RateLimitBatchInitResponse = g.batchInitTarget(ctx, req.GetRateLimitBatchInitRequest(), forwarded)
+	case apilimiter.RateLimitCmd_ACQUIRE, apilimiter.RateLimitCmd_BATCH_ACQUIRE:
+		resp.RateLimitReportResponse = g.acquireQuota(ctx, req.GetRateLimitReportRequest())
+	default:
+		log.Warn("[API-Server][Limiter] unknown ratelimit cmd", zap.String("cmd", req.GetCmd().String()))
+		resp.RateLimitReportResponse = &apilimiter.RateLimitReportResponse{
+			Code:      codeInvalidParam,
+			Timestamp: nowMs(),
+		}
+	}
+	return resp
+}
+
+func (g *RateLimitGRPCServer) slideCount(req *apilimiter.RateLimitInitRequest) uint32 {
+	if req.GetSlideCount() > 0 {
+		return req.GetSlideCount()
+	}
+	return g.cfg.SlideCount
+}
+
+// initTarget 初始化单个限流目标, 计数器不归属于本节点时转发到 owner 节点
+func (g *RateLimitGRPCServer) initTarget(ctx context.Context, req *apilimiter.RateLimitInitRequest,
+	forwarded bool) *apilimiter.RateLimitInitResponse {
+	now := nowMs()
+	resp := &apilimiter.RateLimitInitResponse{
+		Code:       codeSuccess,
+		Target:     req.GetTarget(),
+		SlideCount: g.slideCount(req),
+		Timestamp:  now,
+	}
+	target := req.GetTarget()
+	if target == nil || target.GetNamespace() == "" || target.GetService() == "" || len(req.GetTotals()) == 0 {
+		resp.Code = codeInvalidParam
+		return resp
+	}
+	resp.ClientKey = g.quota.RegisterClient(req.GetClientId(), now)
+
+	addr, isLocal := g.ring.Owner(shardKey(target.GetNamespace(), target.GetService(), target.GetLabels()))
+	if forwarded || isLocal {
+		resp.Counters = g.quota.Init(resp.ClientKey, req, now)
+		return resp
+	}
+
+	remoteResp, err := g.peers.call(ctx, addr, &apilimiter.RateLimitRequest{
+		Cmd:                  apilimiter.RateLimitCmd_INIT,
+		RateLimitInitRequest: req,
+	})
+	if err != nil || remoteResp.GetRateLimitInitResponse() == nil {
+		// owner 节点不可达时降级为本地计数, 等待 hash 环刷新后恢复
+		log.Error("[API-Server][Limiter] forward init request", zap.String("peer", addr),
+			zap.String("namespace", target.GetNamespace()), zap.String("service", target.GetService()),
+			zap.Error(err))
+		resp.Counters = g.quota.Init(resp.ClientKey, req, now)
+		return resp
+	}
+	initResp := remoteResp.GetRateLimitInitResponse()
+	if initResp.GetCode() != codeSuccess {
+		resp.Code = initResp.GetCode()
+		return resp
+	}
+	resp.SlideCount = initResp.GetSlideCount()
+	resp.Counters = g.proxies.Bind(resp.ClientKey, addr, initResp.GetClientKey(), initResp.GetCounters(), now)
+	return resp
+}
+
+// batchInitTarget 批量初始化限流目标, 每个标签组合独立分片
+func (g *RateLimitGRPCServer) batchInitTarget(ctx context.Context, req *apilimiter.RateLimitBatchInitRequest,
+	forwarded bool) *apilimiter.RateLimitBatchInitResponse {
+	now := nowMs()
+	resp := &apilimiter.RateLimitBatchInitResponse{
+		Code:      codeSuccess,
+		ClientKey: g.quota.RegisterClient(req.GetClientId(), now),
+		Timestamp: now,
+		Result:    make([]*apilimiter.BatchInitResult, 0, len(req.GetRequest())),
+	}
+	for _, item := range req.GetRequest() {
+		target := item.GetTarget()
+		result := &apilimiter.BatchInitResult{
+			Code:       codeSuccess,
+			Target:     target,
+			SlideCount: g.slideCount(item),
+		}
+		labelsList := target.GetLabelsList()
+		if len(labelsList) == 0 {
+			labelsList = []string{target.GetLabels()}
+		}
+		for _, labels := range labelsList {
+			initResp := g.initTarget(ctx, &apilimiter.RateLimitInitRequest{
+				Target: &apilimiter.LimitTarget{
+					Namespace: target.GetNamespace(),
+					Service:   target.GetService(),
+					Labels:    labels,
+				},
+				ClientId:   req.GetClientId(),
+				Totals:     item.GetTotals(),
+				SlideCount: item.GetSlideCount(),
+				Mode:       item.GetMode(),
+			}, forwarded)
+			if initResp.GetCode() != codeSuccess {
+				result.Code = initResp.GetCode()
+				continue
+			}
+			result.SlideCount = initResp.GetSlideCount()
+			result.Counters = append(result.Counters, &apilimiter.LabeledQuotaCounter{
+				Labels:   labels,
+				Counters: initResp.GetCounters(),
+			})
+		}
+		resp.Result = append(resp.Result, result)
+	}
+	return resp
+}
+
+// acquireQuota 上报配额使用情况, 本地计数器直接累加, 代理的计数器按节点聚合后转发
+func (g *RateLimitGRPCServer) acquireQuota(ctx context.Context,
+	req *apilimiter.RateLimitReportRequest) *apilimiter.RateLimitReportResponse {
+	now := nowMs()
+	resp := &apilimiter.RateLimitReportResponse{
+		Code:      codeSuccess,
+		Timestamp: now,
+	}
+	if req == nil {
+		resp.Code = codeInvalidParam
+		return resp
+	}
+
+	localSums := make([]*apilimiter.QuotaSum, 0, len(req.GetQuotaUses()))
+	remoteSums := map[string][]*apilimiter.QuotaSum{}
+	// remoteKeys addr -> 远端 counterKey -> 下发给客户端的 counterKey
+	remoteKeys := map[string]map[uint32]uint32{}
+	for _, sum := range req.GetQuotaUses() {
+		proxy, ok := g.proxies.Get(sum.GetCounterKey(), now)
+		if !ok {
+			localSums = append(localSums, sum)
+			continue
+		}
+		remoteSums[proxy.addr] = append(remoteSums[proxy.addr], &apilimiter.QuotaSum{
+			CounterKey: proxy.remoteKey,
+			Used:       sum.GetUsed(),
+			Limited:    sum.GetLimited(),
+		})
+		if _, ok := remoteKeys[proxy.addr]; !ok {
+			remoteKeys[proxy.addr] = map[uint32]uint32{}
+		}
+		remoteKeys[proxy.addr][proxy.remoteKey] = sum.GetCounterKey()
+	}
+
+	resp.QuotaLefts = g.quota.Acquire(req.GetClientKey(), localSums, req.GetTimestamp(), now)
+	for addr, sums := range remoteSums {
+		remoteClientKey, ok := g.proxies.RemoteClientKey(req.GetClientKey(), addr)
+		if !ok {
+			continue
+		}
+		remoteResp, err := g.peers.call(ctx, addr, &apilimiter.RateLimitRequest{
+			Cmd: apilimiter.RateLimitCmd_ACQUIRE,
+			RateLimitReportRequest: &apilimiter.RateLimitReportRequest{
+				ClientKey: remoteClientKey,
+				QuotaUses: sums,
+				Timestamp: req.GetTimestamp(),
+			},
+		})
+		if err != nil {
+			log.Error("[API-Server][Limiter] forward acquire request", zap.String("peer", addr), zap.Error(err))
+			continue
+		}
+		for _, left := range remoteResp.GetRateLimitReportResponse().GetQuotaLefts() {
+			localKey, ok := remoteKeys[addr][left.GetCounterKey()]
+			if !ok {
+				continue
+			}
+			resp.QuotaLefts = append(resp.QuotaLefts, &apilimiter.QuotaLeft{
+				CounterKey:  localKey,
+				Left:        left.GetLeft(),
+				Mode:        left.GetMode(),
+				ClientCount: left.GetClientCount(),
+			})
+		}
+	}
+	return resp
+}
@@ -0,0 +1,90 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package limiter
+
+import (
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// DefaultNamespace 限流 server 集群自注册所在的命名空间
+	DefaultNamespace = "pole-system"
+	// DefaultService 限流 server 集群自注册的服务名
+	DefaultService = "pole.limiter"
+	// DefaultSlideCount 默认的滑窗个数
+	DefaultSlideCount = 10
+	// DefaultClientExpire 客户端多久没有上报则认为已经下线
+	DefaultClientExpire = 60 * time.Second
+	// DefaultCounterExpire 计数器多久没有访问则被回收
+	DefaultCounterExpire = 5 * time.Minute
+	// DefaultRefreshInterval 刷新集群一致性 hash 环的间隔
+	DefaultRefreshInterval = 5 * time.Second
+)
+
+// LimiterConfig 分布式限流 server 的配置
+type LimiterConfig struct {
+	// Namespace 限流 server 集群自注册所在的命名空间
+	Namespace string `mapstructure:"namespace"`
+	// Service 限流 server 集群自注册的服务名, 用于构建一致性 hash 环进行计数器分片
+	Service string `mapstructure:"service"`
+	// SlideCount 客户端未指定时使用的滑窗个数
+	SlideCount uint32 `mapstructure:"slideCount"`
+	// ClientExpire 客户端多久没有上报则认为已经下线
+	ClientExpire time.Duration `mapstructure:"clientExpire"`
+	// CounterExpire 计数器多久没有访问则被回收
+	CounterExpire time.Duration `mapstructure:"counterExpire"`
+	// RefreshInterval 刷新集群一致性 hash 环的间隔
+	RefreshInterval time.Duration `mapstructure:"refreshInterval"`
+}
+
+func loadLimiterConfig(raw map[string]interface{}) (*LimiterConfig, error) {
+	cfg := &LimiterConfig{
+		Namespace:       DefaultNamespace,
+		Service:         DefaultService,
+		SlideCount:      DefaultSlideCount,
+		ClientExpire:    DefaultClientExpire,
+		CounterExpire:   DefaultCounterExpire,
+		RefreshInterval: DefaultRefreshInterval,
+	}
+	decodeConfig := &mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     cfg,
+	}
+	decoder, err := mapstructure.NewDecoder(decodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return nil, err
+	}
+	if cfg.SlideCount == 0 {
+		cfg.SlideCount = DefaultSlideCount
+	}
+	if cfg.ClientExpire <= 0 {
+		cfg.ClientExpire = DefaultClientExpire
+	}
+	if cfg.CounterExpire <= 0 {
+		cfg.CounterExpire = DefaultCounterExpire
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = DefaultRefreshInterval
+	}
+	return cfg, nil
+}
@@ -0,0 +1,220 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	apilimiter "github.com/polarismesh/specification/source/go/api/v1/traffic_manage/ratelimiter"
+)
+
+const (
+	// forwardedHeader 标识请求是由集群内其他节点转发过来的, 收到该请求的节点直接在本地处理, 避免循环转发
+	forwardedHeader = "x-pole-limiter-forwarded"
+	forwardTimeout  = 3 * time.Second
+)
+
+func isForwarded(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	return len(md.Get(forwardedHeader)) > 0
+}
+
+// peerClients 到集群内其他限流节点的 gRPC 连接
+type peerClients struct {
+	lock  sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+func newPeerClients() *peerClients {
+	return &peerClients{
+		conns: map[string]*grpc.ClientConn{},
+	}
+}
+
+func (p *peerClients) get(addr string) (apilimiter.RateLimitGRPCV2Client, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	conn, ok := p.conns[addr]
+	if !ok {
+		var err error
+		conn, err = grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, err
+		}
+		p.conns[addr] = conn
+	}
+	return apilimiter.NewRateLimitGRPCV2Client(conn), nil
+}
+
+// call 向目标节点发起一次限流请求
+func (p *peerClients) call(ctx context.Context, addr string,
+	req *apilimiter.RateLimitRequest) (*apilimiter.RateLimitResponse, error) {
+	client, err := p.get(addr)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, forwardTimeout)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, forwardedHeader, "true")
+	stream, err := client.Service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = stream.CloseSend()
+	}()
+	if err := stream.Send(req); err != nil {
+		return nil, err
+	}
+	return stream.Recv()
+}
+
+// retain 关闭已经不在集群中的节点连接
+func (p *peerClients) retain(addrs []string) {
+	alive := make(map[string]struct{}, len(addrs))
+	for _, addr := range addrs {
+		alive[addr] = struct{}{}
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for addr, conn := range p.conns {
+		if _, ok := alive[addr]; ok {
+			continue
+		}
+		_ = conn.Close()
+		delete(p.conns, addr)
+	}
+}
+
+func (p *peerClients) closeAll() {
+	p.retain(nil)
+}
+
+// proxyCounter 本节点代理的、由其他节点负责的计数器
+type proxyCounter struct {
+	addr       string
+	remoteKey  uint32
+	clientKey  uint32
+	lastAccess int64
+}
+
+// proxyTable 维护下发给客户端的 counterKey 与远端节点计数器之间的映射
+type proxyTable struct {
+	lock   sync.RWMutex
+	keySeq *uint32
+	// keys addr#remoteKey#clientKey -> 下发给客户端的 counterKey
+	keys     map[string]uint32
+	counters map[uint32]*proxyCounter
+	// remoteClients clientKey -> addr -> 远端节点分配的 clientKey
+	remoteClients map[uint32]map[string]uint32
+}
+
+func newProxyTable(keySeq *uint32) *proxyTable {
+	return &proxyTable{
+		keySeq:        keySeq,
+		keys:          map[string]uint32{},
+		counters:      map[uint32]*proxyCounter{},
+		remoteClients: map[uint32]map[string]uint32{},
+	}
+}
+
+// Bind 记录远端节点返回的计数器, 并替换为本节点下发给客户端的 counterKey
+func (p *proxyTable) Bind(clientKey uint32, addr string, remoteClientKey uint32,
+	counters []*apilimiter.QuotaCounter, nowMs int64) []*apilimiter.QuotaCounter {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.remoteClients[clientKey]; !ok {
+		p.remoteClients[clientKey] = map[string]uint32{}
+	}
+	p.remoteClients[clientKey][addr] = remoteClientKey
+
+	ret := make([]*apilimiter.QuotaCounter, 0, len(counters))
+	for _, counter := range counters {
+		id := fmt.Sprintf("%s#%d#%d", addr, counter.GetCounterKey(), clientKey)
+		localKey, ok := p.keys[id]
+		if !ok {
+			localKey = atomic.AddUint32(p.keySeq, 1)
+			p.keys[id] = localKey
+			p.counters[localKey] = &proxyCounter{
+				addr:      addr,
+				remoteKey: counter.GetCounterKey(),
+				clientKey: clientKey,
+			}
+		}
+		p.counters[localKey].lastAccess = nowMs
+		ret = append(ret, &apilimiter.QuotaCounter{
+			Duration:    counter.GetDuration(),
+			CounterKey:  localKey,
+			Left:        counter.GetLeft(),
+			Mode:        counter.GetMode(),
+			ClientCount: counter.GetClientCount(),
+		})
+	}
+	return ret
+}
+
+// Get 根据下发给客户端的 counterKey 查找远端计数器
+func (p *proxyTable) Get(localKey uint32, nowMs int64) (*proxyCounter, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	counter, ok := p.counters[localKey]
+	if ok {
+		counter.lastAccess = nowMs
+	}
+	return counter, ok
+}
+
+// RemoteClientKey 获取客户端在远端节点上的 clientKey
+func (p *proxyTable) RemoteClientKey(clientKey uint32, addr string) (uint32, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	key, ok := p.remoteClients[clientKey][addr]
+	return key, ok
+}
+
+// CleanExpired 清理长时间不活跃的代理计数器
+func (p *proxyTable) CleanExpired(nowMs int64, counterExpire time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	activeClients := map[uint32]struct{}{}
+	for localKey, counter := range p.counters {
+		if nowMs-counter.lastAccess <= counterExpire.Milliseconds() {
+			activeClients[counter.clientKey] = struct{}{}
+			continue
+		}
+		delete(p.counters, localKey)
+		delete(p.keys, fmt.Sprintf("%s#%d#%d", counter.addr, counter.remoteKey, counter.clientKey))
+	}
+	for clientKey := range p.remoteClients {
+		if _, ok := activeClients[clientKey]; !ok {
+			delete(p.remoteClients, clientKey)
+		}
+	}
+}
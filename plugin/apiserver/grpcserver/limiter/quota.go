@@ -0,0 +1,321 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package limiter
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	apilimiter "github.com/polarismesh/specification/source/go/api/v1/traffic_manage/ratelimiter"
+)
+
+// counterIdentity 计数器的唯一标识, 同一个限流目标的同一个统计周期共享一个计数器
+type counterIdentity struct {
+	Namespace string
+	Service   string
+	Labels    string
+	Duration  uint32
+}
+
+type windowBucket struct {
+	start int64
+	count int64
+}
+
+// slidingWindow 滑动窗口计数器, 非并发安全, 由 quotaCounter 加锁保护
+type slidingWindow struct {
+	bucketMs int64
+	buckets  []windowBucket
+}
+
+func newSlidingWindow(duration time.Duration, slideCount uint32) *slidingWindow {
+	if slideCount == 0 {
+		slideCount = 1
+	}
+	bucketMs := duration.Milliseconds() / int64(slideCount)
+	if bucketMs <= 0 {
+		bucketMs = 1
+	}
+	return &slidingWindow{
+		bucketMs: bucketMs,
+		buckets:  make([]windowBucket, slideCount),
+	}
+}
+
+func (w *slidingWindow) add(nowMs int64, count int64) {
+	start := nowMs - nowMs%w.bucketMs
+	bucket := &w.buckets[(start/w.bucketMs)%int64(len(w.buckets))]
+	if bucket.start != start {
+		bucket.start = start
+		bucket.count = 0
+	}
+	bucket.count += count
+}
+
+func (w *slidingWindow) sum(nowMs int64) int64 {
+	windowStart := nowMs - w.bucketMs*int64(len(w.buckets))
+	var total int64
+	for i := range w.buckets {
+		if w.buckets[i].start > windowStart && w.buckets[i].start <= nowMs {
+			total += w.buckets[i].count
+		}
+	}
+	return total
+}
+
+// quotaCounter 单个限流目标在某个统计周期下的配额计数器
+type quotaCounter struct {
+	lock       sync.Mutex
+	key        uint32
+	identity   counterIdentity
+	maxAmount  uint32
+	quotaMode  apilimiter.QuotaMode
+	mode       apilimiter.Mode
+	slideCount uint32
+	window     *slidingWindow
+	// clients clientKey -> 最近一次访问的时间, 单位ms
+	clients    map[uint32]int64
+	lastAccess int64
+}
+
+// update 规则的阈值可能发生变更, 以最新一次初始化请求为准
+func (c *quotaCounter) update(total *apilimiter.QuotaTotal, mode apilimiter.Mode) {
+	c.maxAmount = total.GetMaxAmount()
+	c.quotaMode = total.GetMode()
+	c.mode = mode
+}
+
+func (c *quotaCounter) touch(clientKey uint32, nowMs int64) {
+	c.clients[clientKey] = nowMs
+	c.lastAccess = nowMs
+}
+
+// left 计算剩余配额, 允许为负数
+func (c *quotaCounter) left(nowMs int64) (int64, uint32) {
+	clientCount := uint32(len(c.clients))
+	if clientCount == 0 {
+		clientCount = 1
+	}
+	total := int64(c.maxAmount)
+	if c.quotaMode == apilimiter.QuotaMode_DIVIDE {
+		total = total * int64(clientCount)
+	}
+	left := total - c.window.sum(nowMs)
+	if c.mode == apilimiter.Mode_BATCH_SHARE && left > 0 {
+		left = left / int64(clientCount)
+	}
+	return left, clientCount
+}
+
+func (c *quotaCounter) toQuotaCounter(nowMs int64) *apilimiter.QuotaCounter {
+	left, clientCount := c.left(nowMs)
+	return &apilimiter.QuotaCounter{
+		Duration:    c.identity.Duration,
+		CounterKey:  c.key,
+		Left:        left,
+		Mode:        c.mode,
+		ClientCount: clientCount,
+	}
+}
+
+type quotaClient struct {
+	id         string
+	key        uint32
+	lastActive int64
+}
+
+// QuotaCenter 本节点负责的所有限流计数器
+type QuotaCenter struct {
+	lock       sync.RWMutex
+	slideCount uint32
+	keySeq     *uint32
+	clientSeq  uint32
+	// clients clientId -> client
+	clients map[string]*quotaClient
+	// clientKeys clientKey -> client
+	clientKeys  map[uint32]*quotaClient
+	counters    map[counterIdentity]*quotaCounter
+	counterKeys map[uint32]*quotaCounter
+}
+
+func newQuotaCenter(slideCount uint32, keySeq *uint32) *QuotaCenter {
+	return &QuotaCenter{
+		slideCount:  slideCount,
+		keySeq:      keySeq,
+		clients:     map[string]*quotaClient{},
+		clientKeys:  map[uint32]*quotaClient{},
+		counters:    map[counterIdentity]*quotaCounter{},
+		counterKeys: map[uint32]*quotaCounter{},
+	}
+}
+
+// RegisterClient 注册客户端, 返回本节点内唯一的 clientKey
+func (q *QuotaCenter) RegisterClient(clientId string, nowMs int64) uint32 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	client, ok := q.clients[clientId]
+	if !ok {
+		q.clientSeq++
+		client = &quotaClient{
+			id:  clientId,
+			key: q.clientSeq,
+		}
+		q.clients[clientId] = client
+		q.clientKeys[client.key] = client
+	}
+	client.lastActive = nowMs
+	return client.key
+}
+
+// ClientId 根据 clientKey 获取 clientId
+func (q *QuotaCenter) ClientId(clientKey uint32) (string, bool) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	client, ok := q.clientKeys[clientKey]
+	if !ok {
+		return "", false
+	}
+	return client.id, true
+}
+
+// Init 初始化限流目标的计数器, 返回每个统计周期对应的计数器信息
+func (q *QuotaCenter) Init(clientKey uint32, req *apilimiter.RateLimitInitRequest,
+	nowMs int64) []*apilimiter.QuotaCounter {
+	target := req.GetTarget()
+	slideCount := req.GetSlideCount()
+	if slideCount == 0 {
+		slideCount = q.slideCount
+	}
+
+	ret := make([]*apilimiter.QuotaCounter, 0, len(req.GetTotals()))
+	for _, total := range req.GetTotals() {
+		identity := counterIdentity{
+			Namespace: target.GetNamespace(),
+			Service:   target.GetService(),
+			Labels:    target.GetLabels(),
+			Duration:  total.GetDuration(),
+		}
+		counter := q.getOrCreateCounter(identity, slideCount)
+		counter.lock.Lock()
+		counter.update(total, req.GetMode())
+		counter.touch(clientKey, nowMs)
+		ret = append(ret, counter.toQuotaCounter(nowMs))
+		counter.lock.Unlock()
+	}
+	return ret
+}
+
+func (q *QuotaCenter) getOrCreateCounter(identity counterIdentity, slideCount uint32) *quotaCounter {
+	q.lock.RLock()
+	counter, ok := q.counters[identity]
+	q.lock.RUnlock()
+	if ok {
+		return counter
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if counter, ok = q.counters[identity]; ok {
+		return counter
+	}
+	counter = &quotaCounter{
+		key:        atomic.AddUint32(q.keySeq, 1),
+		identity:   identity,
+		slideCount: slideCount,
+		window:     newSlidingWindow(time.Duration(identity.Duration)*time.Second, slideCount),
+		clients:    map[uint32]int64{},
+	}
+	q.counters[identity] = counter
+	q.counterKeys[counter.key] = counter
+	return counter
+}
+
+// Acquire 上报已使用的配额, 返回计数器的剩余配额, 找不到的计数器不返回
+func (q *QuotaCenter) Acquire(clientKey uint32, sums []*apilimiter.QuotaSum, timestamp int64,
+	nowMs int64) []*apilimiter.QuotaLeft {
+	if timestamp <= 0 || timestamp > nowMs {
+		timestamp = nowMs
+	}
+	q.lock.Lock()
+	if client, ok := q.clientKeys[clientKey]; ok {
+		client.lastActive = nowMs
+	}
+	q.lock.Unlock()
+
+	ret := make([]*apilimiter.QuotaLeft, 0, len(sums))
+	for _, sum := range sums {
+		q.lock.RLock()
+		counter, ok := q.counterKeys[sum.GetCounterKey()]
+		q.lock.RUnlock()
+		if !ok {
+			continue
+		}
+		counter.lock.Lock()
+		counter.touch(clientKey, nowMs)
+		if sum.GetUsed() > 0 {
+			counter.window.add(timestamp, int64(sum.GetUsed()))
+		}
+		left, clientCount := counter.left(nowMs)
+		ret = append(ret, &apilimiter.QuotaLeft{
+			CounterKey:  counter.key,
+			Left:        left,
+			Mode:        counter.mode,
+			ClientCount: clientCount,
+		})
+		counter.lock.Unlock()
+	}
+	return ret
+}
+
+// CleanExpired 清理长时间不活跃的客户端以及计数器
+func (q *QuotaCenter) CleanExpired(nowMs int64, clientExpire, counterExpire time.Duration) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	expiredClients := map[uint32]struct{}{}
+	for id, client := range q.clients {
+		if nowMs-client.lastActive > clientExpire.Milliseconds() {
+			expiredClients[client.key] = struct{}{}
+			delete(q.clients, id)
+			delete(q.clientKeys, client.key)
+		}
+	}
+	for identity, counter := range q.counters {
+		counter.lock.Lock()
+		for clientKey, lastAccess := range counter.clients {
+			_, expired := expiredClients[clientKey]
+			if expired || nowMs-lastAccess > clientExpire.Milliseconds() {
+				delete(counter.clients, clientKey)
+			}
+		}
+		expired := nowMs-counter.lastAccess > counterExpire.Milliseconds()
+		counter.lock.Unlock()
+		if expired {
+			delete(q.counters, identity)
+			delete(q.counterKeys, counter.key)
+		}
+	}
+}
+
+// CounterCount 当前本节点持有的计数器个数
+func (q *QuotaCenter) CounterCount() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return len(q.counters)
+}
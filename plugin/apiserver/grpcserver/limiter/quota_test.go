@@ -0,0 +1,192 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	apilimiter "github.com/polarismesh/specification/source/go/api/v1/traffic_manage/ratelimiter"
+)
+
+func Test_slidingWindow(t *testing.T) {
+	w := newSlidingWindow(time.Second, 10)
+	w.add(1000, 5)
+	w.add(1050, 5)
+	w.add(1500, 3)
+	assert.Equal(t, int64(13), w.sum(1500))
+	assert.Equal(t, int64(13), w.sum(1999))
+	// 第一个 bucket [1000, 1100) 滑出窗口
+	assert.Equal(t, int64(3), w.sum(2100))
+	assert.Equal(t, int64(0), w.sum(2600))
+}
+
+func newTestInitRequest(clientId string, mode apilimiter.Mode,
+	quotaMode apilimiter.QuotaMode) *apilimiter.RateLimitInitRequest {
+	return &apilimiter.RateLimitInitRequest{
+		ClientId: clientId,
+		Target: &apilimiter.LimitTarget{
+			Namespace: "default",
+			Service:   "test-svc",
+			Labels:    "method:GET",
+		},
+		Totals: []*apilimiter.QuotaTotal{
+			{Mode: quotaMode, Duration: 1, MaxAmount: 100},
+		},
+		Mode: mode,
+	}
+}
+
+func TestQuotaCenter_InitAndAcquire(t *testing.T) {
+	var seq uint32
+	center := newQuotaCenter(DefaultSlideCount, &seq)
+	now := time.Now().UnixMilli()
+
+	clientA := center.RegisterClient("client-a", now)
+	clientB := center.RegisterClient("client-b", now)
+	assert.NotEqual(t, clientA, clientB)
+	assert.Equal(t, clientA, center.RegisterClient("client-a", now))
+
+	countersA := center.Init(clientA, newTestInitRequest("client-a", apilimiter.Mode_BATCH_OCCUPY,
+		apilimiter.QuotaMode_WHOLE), now)
+	countersB := center.Init(clientB, newTestInitRequest("client-b", apilimiter.Mode_BATCH_OCCUPY,
+		apilimiter.QuotaMode_WHOLE), now)
+	assert.Equal(t, 1, len(countersA))
+	assert.Equal(t, countersA[0].GetCounterKey(), countersB[0].GetCounterKey())
+	assert.Equal(t, int64(100), countersB[0].GetLeft())
+	assert.Equal(t, uint32(2), countersB[0].GetClientCount())
+	assert.Equal(t, 1, center.CounterCount())
+
+	key := countersA[0].GetCounterKey()
+	lefts := center.Acquire(clientA, []*apilimiter.QuotaSum{{CounterKey: key, Used: 30}}, now, now)
+	assert.Equal(t, int64(70), lefts[0].GetLeft())
+	lefts = center.Acquire(clientB, []*apilimiter.QuotaSum{{CounterKey: key, Used: 80}}, now, now)
+	assert.Equal(t, int64(-10), lefts[0].GetLeft())
+
+	// 不存在的计数器直接忽略
+	lefts = center.Acquire(clientB, []*apilimiter.QuotaSum{{CounterKey: key + 100, Used: 1}}, now, now)
+	assert.Equal(t, 0, len(lefts))
+
+	// 窗口滑过之后配额恢复
+	later := now + 2000
+	lefts = center.Acquire(clientA, []*apilimiter.QuotaSum{{CounterKey: key}}, later, later)
+	assert.Equal(t, int64(100), lefts[0].GetLeft())
+}
+
+func TestQuotaCenter_DivideAndShare(t *testing.T) {
+	var seq uint32
+	center := newQuotaCenter(DefaultSlideCount, &seq)
+	now := time.Now().UnixMilli()
+
+	clientA := center.RegisterClient("client-a", now)
+	clientB := center.RegisterClient("client-b", now)
+	center.Init(clientA, newTestInitRequest("client-a", apilimiter.Mode_BATCH_SHARE,
+		apilimiter.QuotaMode_DIVIDE), now)
+	counters := center.Init(clientB, newTestInitRequest("client-b", apilimiter.Mode_BATCH_SHARE,
+		apilimiter.QuotaMode_DIVIDE), now)
+	// 单机阈值 100, 两个客户端总配额 200, 按客户端数分摊
+	assert.Equal(t, int64(100), counters[0].GetLeft())
+
+	lefts := center.Acquire(clientA, []*apilimiter.QuotaSum{
+		{CounterKey: counters[0].GetCounterKey(), Used: 50},
+	}, now, now)
+	assert.Equal(t, int64(75), lefts[0].GetLeft())
+}
+
+func TestQuotaCenter_CleanExpired(t *testing.T) {
+	var seq uint32
+	center := newQuotaCenter(DefaultSlideCount, &seq)
+	now := time.Now().UnixMilli()
+
+	clientA := center.RegisterClient("client-a", now)
+	clientB := center.RegisterClient("client-b", now)
+	center.Init(clientA, newTestInitRequest("client-a", apilimiter.Mode_BATCH_OCCUPY,
+		apilimiter.QuotaMode_DIVIDE), now)
+	counters := center.Init(clientB, newTestInitRequest("client-b", apilimiter.Mode_BATCH_OCCUPY,
+		apilimiter.QuotaMode_DIVIDE), now)
+	assert.Equal(t, uint32(2), counters[0].GetClientCount())
+
+	// client-b 持续上报, client-a 过期
+	later := now + 30*1000
+	center.Acquire(clientB, []*apilimiter.QuotaSum{{CounterKey: counters[0].GetCounterKey()}}, later, later)
+	center.CleanExpired(later+1, 20*time.Second, time.Minute)
+	_, ok := center.ClientId(clientA)
+	assert.False(t, ok)
+	lefts := center.Acquire(clientB, []*apilimiter.QuotaSum{{CounterKey: counters[0].GetCounterKey()}}, later, later)
+	assert.Equal(t, uint32(1), lefts[0].GetClientCount())
+	assert.Equal(t, int64(100), lefts[0].GetLeft())
+
+	// 计数器长时间无访问被回收
+	center.CleanExpired(later+2*60*1000, 20*time.Second, time.Minute)
+	assert.Equal(t, 0, center.CounterCount())
+}
+
+func Test_peerRing(t *testing.T) {
+	ring := newPeerRing("127.0.0.1:8101")
+	addr, isLocal := ring.Owner(shardKey("default", "svc", ""))
+	assert.True(t, isLocal)
+	assert.Equal(t, "127.0.0.1:8101", addr)
+
+	peers := []string{"127.0.0.1:8101", "127.0.0.2:8101", "127.0.0.3:8101"}
+	assert.True(t, ring.Reload(peers))
+	assert.False(t, ring.Reload([]string{"127.0.0.3:8101", "127.0.0.2:8101", "127.0.0.1:8101"}))
+	assert.Equal(t, 3, ring.Peers())
+
+	owners := map[string]int{}
+	for i := 0; i < 300; i++ {
+		addr, isLocal := ring.Owner(shardKey("default", "svc", string(rune('a'+i%26))+string(rune(i))))
+		assert.Equal(t, addr == "127.0.0.1:8101", isLocal)
+		owners[addr]++
+		// 同一个 key 的 owner 稳定
+		again, _ := ring.Owner(shardKey("default", "svc", string(rune('a'+i%26))+string(rune(i))))
+		assert.Equal(t, addr, again)
+	}
+	assert.Equal(t, 3, len(owners))
+}
+
+func Test_proxyTable(t *testing.T) {
+	var seq uint32
+	table := newProxyTable(&seq)
+	now := time.Now().UnixMilli()
+
+	counters := table.Bind(1, "127.0.0.2:8101", 9, []*apilimiter.QuotaCounter{
+		{Duration: 1, CounterKey: 5, Left: 10},
+	}, now)
+	assert.Equal(t, 1, len(counters))
+	localKey := counters[0].GetCounterKey()
+	assert.Equal(t, int64(10), counters[0].GetLeft())
+
+	proxy, ok := table.Get(localKey, now)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(5), proxy.remoteKey)
+	remoteClient, ok := table.RemoteClientKey(1, "127.0.0.2:8101")
+	assert.True(t, ok)
+	assert.Equal(t, uint32(9), remoteClient)
+
+	// 重复绑定返回同一个 counterKey
+	again := table.Bind(1, "127.0.0.2:8101", 9, []*apilimiter.QuotaCounter{{Duration: 1, CounterKey: 5}}, now)
+	assert.Equal(t, localKey, again[0].GetCounterKey())
+
+	table.CleanExpired(now+2*60*1000, time.Minute)
+	_, ok = table.Get(localKey, now)
+	assert.False(t, ok)
+	_, ok = table.RemoteClientKey(1, "127.0.0.2:8101")
+	assert.False(t, ok)
+}
@@ -0,0 +1,371 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	regexp "github.com/dlclark/regexp2"
+	ratelimitv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"go.uber.org/zap"
+
+	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+	apilimiter "github.com/polarismesh/specification/source/go/api/v1/traffic_manage/ratelimiter"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/rules"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+	"github.com/pole-io/pole-server/plugin/apiserver/xdsserverv3/resource"
+)
+
+var errCounterNotFound = errors.New("ratelimit counter not found")
+
+// rlsCounter RLS 已经初始化过的计数器
+type rlsCounter struct {
+	key       uint32
+	maxAmount uint32
+}
+
+// envoyRateLimiter Envoy RLS 协议实现, 将 descriptor 匹配到 pole 的全局限流规则,
+// 作为本节点内的一个虚拟客户端复用限流 server 的分布式计数器
+type envoyRateLimiter struct {
+	svr      *RateLimitGRPCServer
+	clientId string
+	// findService 根据服务名以及命名空间判断服务是否存在
+	findService func(name, namespace string) bool
+	// findRules 获取服务下的限流规则
+	findRules func(svcKey svctypes.ServiceKey) []*rules.RateLimit
+
+	lock      sync.Mutex
+	clientKey uint32
+	counters  map[counterIdentity]rlsCounter
+	regexes   sync.Map
+}
+
+func newEnvoyRateLimiter(svr *RateLimitGRPCServer) *envoyRateLimiter {
+	limiter := &envoyRateLimiter{
+		svr:      svr,
+		clientId: "envoy-rls-" + svr.ring.localAddr,
+		counters: map[counterIdentity]rlsCounter{},
+	}
+	if svr.cacheMgr != nil {
+		limiter.findService = func(name, namespace string) bool {
+			return svr.cacheMgr.Service().GetServiceByName(name, namespace) != nil
+		}
+		limiter.findRules = func(svcKey svctypes.ServiceKey) []*rules.RateLimit {
+			ret, _ := svr.cacheMgr.RateLimit().GetRateLimitRules(svcKey)
+			return ret
+		}
+	}
+	return limiter
+}
+
+// ShouldRateLimit Envoy 的全局限流接口, 每个 descriptor 独立判断, 任意一个超限则整体超限
+func (e *envoyRateLimiter) ShouldRateLimit(ctx context.Context,
+	req *rlsv3.RateLimitRequest) (*rlsv3.RateLimitResponse, error) {
+	resp := &rlsv3.RateLimitResponse{
+		OverallCode: rlsv3.RateLimitResponse_OK,
+		Statuses:    make([]*rlsv3.RateLimitResponse_DescriptorStatus, 0, len(req.GetDescriptors())),
+	}
+	svcKey, ok := e.parseDomain(req.GetDomain())
+	if !ok {
+		for range req.GetDescriptors() {
+			resp.Statuses = append(resp.Statuses, &rlsv3.RateLimitResponse_DescriptorStatus{
+				Code: rlsv3.RateLimitResponse_OK,
+			})
+		}
+		return resp, nil
+	}
+	hits := req.GetHitsAddend()
+	if hits == 0 {
+		hits = 1
+	}
+
+	limitRules := e.globalRules(svcKey)
+	for _, descriptor := range req.GetDescriptors() {
+		status := e.checkDescriptor(ctx, svcKey, limitRules, descriptor, hits)
+		if status.GetCode() == rlsv3.RateLimitResponse_OVER_LIMIT {
+			resp.OverallCode = rlsv3.RateLimitResponse_OVER_LIMIT
+		}
+		resp.Statuses = append(resp.Statuses, status)
+	}
+	return resp, nil
+}
+
+// parseDomain domain 的格式为 {service}.{namespace}, 服务名以及命名空间都可能带有 '.', 需要依次尝试
+func (e *envoyRateLimiter) parseDomain(domain string) (svctypes.ServiceKey, bool) {
+	for i := strings.LastIndex(domain, "."); i > 0; i = strings.LastIndex(domain[:i], ".") {
+		svcKey := svctypes.ServiceKey{
+			Namespace: domain[i+1:],
+			Name:      domain[:i],
+		}
+		if svcKey.Namespace == "" {
+			continue
+		}
+		if e.findService == nil || e.findService(svcKey.Name, svcKey.Namespace) {
+			return svcKey, true
+		}
+	}
+	return svctypes.ServiceKey{}, false
+}
+
+func (e *envoyRateLimiter) globalRules(svcKey svctypes.ServiceKey) []*apitraffic.Rule {
+	if e.findRules == nil {
+		return nil
+	}
+	ret := make([]*apitraffic.Rule, 0, 4)
+	for _, item := range e.findRules(svcKey) {
+		rule := item.Proto
+		if rule == nil || rule.GetDisable().GetValue() || rule.GetType() != apitraffic.Rule_GLOBAL {
+			continue
+		}
+		ret = append(ret, rule)
+	}
+	return ret
+}
+
+func (e *envoyRateLimiter) checkDescriptor(ctx context.Context, svcKey svctypes.ServiceKey,
+	limitRules []*apitraffic.Rule, descriptor *ratelimitv3.RateLimitDescriptor,
+	hits uint32) *rlsv3.RateLimitResponse_DescriptorStatus {
+	status := &rlsv3.RateLimitResponse_DescriptorStatus{
+		Code: rlsv3.RateLimitResponse_OK,
+	}
+	var remaining int64 = -1
+	for _, rule := range limitRules {
+		if !e.matchDescriptor(rule, descriptor) {
+			continue
+		}
+		for _, amount := range rule.GetAmounts() {
+			duration := uint32(amount.GetValidDuration().AsDuration().Seconds())
+			if duration == 0 {
+				duration = 1
+			}
+			total := &apilimiter.QuotaTotal{
+				Mode:      apilimiter.QuotaMode_WHOLE,
+				Duration:  duration,
+				MaxAmount: amount.GetMaxAmount().GetValue(),
+			}
+			identity := counterIdentity{
+				Namespace: svcKey.Namespace,
+				Service:   svcKey.Name,
+				Labels:    rule.GetId().GetValue(),
+				Duration:  duration,
+			}
+			left, passed, err := e.acquire(ctx, identity, total, hits)
+			if err != nil {
+				// 计数器异常时放通, 避免限流服务故障影响业务流量
+				log.Error("[API-Server][Limiter][RLS] acquire quota", zap.String("namespace", svcKey.Namespace),
+					zap.String("service", svcKey.Name), zap.String("rule", rule.GetId().GetValue()), zap.Error(err))
+				continue
+			}
+			if !passed {
+				status.Code = rlsv3.RateLimitResponse_OVER_LIMIT
+			}
+			if left < 0 {
+				left = 0
+			}
+			if remaining < 0 || left < remaining {
+				remaining = left
+				status.CurrentLimit = toRateLimit(rule.GetName().GetValue(), total)
+			}
+		}
+	}
+	if remaining >= 0 {
+		status.LimitRemaining = uint32(remaining)
+	}
+	return status
+}
+
+// matchDescriptor descriptor 的 key 集合需要与规则生成的 entries 完全一致,
+// 固定取值的 entry 直接比较, 取值来自请求本身的 entry 使用规则的匹配条件判断
+func (e *envoyRateLimiter) matchDescriptor(rule *apitraffic.Rule, descriptor *ratelimitv3.RateLimitDescriptor) bool {
+	_, expects := resource.BuildRateLimitDescriptors(rule)
+	if len(expects) == 0 {
+		return false
+	}
+	expectEntries := expects[0].GetEntries()
+	actualKeys := map[string][]string{}
+	for _, entry := range descriptor.GetEntries() {
+		actualKeys[entry.GetKey()] = append(actualKeys[entry.GetKey()], entry.GetValue())
+	}
+	expectKeys := map[string]struct{}{}
+	for _, entry := range expectEntries {
+		expectKeys[entry.GetKey()] = struct{}{}
+	}
+	if len(expectKeys) != len(actualKeys) {
+		return false
+	}
+
+	dynamicArgs := map[string]*apitraffic.MatchArgument{}
+	for _, arg := range rule.GetArguments() {
+		switch arg.GetType() {
+		case apitraffic.MatchArgument_METHOD:
+			dynamicArgs[strings.ToLower(arg.GetType().String())+"."+arg.GetKey()] = arg
+		case apitraffic.MatchArgument_CALLER_IP:
+			dynamicArgs["remote_address"] = arg
+		}
+	}
+	for _, entry := range expectEntries {
+		values, ok := actualKeys[entry.GetKey()]
+		if !ok {
+			return false
+		}
+		arg, dynamic := dynamicArgs[entry.GetKey()]
+		matched := false
+		for _, value := range values {
+			if dynamic {
+				matched = utils.MatchString(value, arg.GetValue(), e.compileRegex)
+			} else {
+				matched = value == entry.GetValue()
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *envoyRateLimiter) compileRegex(s string) *regexp.Regexp {
+	if val, ok := e.regexes.Load(s); ok {
+		return val.(*regexp.Regexp)
+	}
+	regex, err := regexp.Compile(s, regexp.RE2)
+	if err != nil {
+		log.Error("[API-Server][Limiter][RLS] compile regex failed", zap.String("regex", s), zap.Error(err))
+		return nil
+	}
+	e.regexes.Store(s, regex)
+	return regex
+}
+
+// acquire 扣减配额并根据扣减后的剩余配额判断是否超限, 扣减与判断在计数器内原子完成, 并发请求不会共同突破阈值.
+// 与 envoy 官方限流服务一致, 超限的请求同样计入配额
+func (e *envoyRateLimiter) acquire(ctx context.Context, identity counterIdentity,
+	total *apilimiter.QuotaTotal, hits uint32) (int64, bool, error) {
+	counter, clientKey, err := e.counter(ctx, identity, total)
+	if err != nil {
+		return 0, false, err
+	}
+	left, err := e.report(ctx, clientKey, counter.key, hits)
+	if err == errCounterNotFound {
+		// 计数器已经被回收, 本次没有发生扣减, 重新初始化后再扣减
+		e.forget(identity)
+		if counter, clientKey, err = e.counter(ctx, identity, total); err != nil {
+			return 0, false, err
+		}
+		left, err = e.report(ctx, clientKey, counter.key, hits)
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return left, left >= 0, nil
+}
+
+// counter 获取计数器, 阈值发生变化时重新初始化以便更新计数器的阈值
+func (e *envoyRateLimiter) counter(ctx context.Context, identity counterIdentity,
+	total *apilimiter.QuotaTotal) (rlsCounter, uint32, error) {
+	e.lock.Lock()
+	counter, ok := e.counters[identity]
+	clientKey := e.clientKey
+	e.lock.Unlock()
+	if ok && counter.maxAmount == total.GetMaxAmount() {
+		return counter, clientKey, nil
+	}
+
+	resp := e.svr.initTarget(ctx, &apilimiter.RateLimitInitRequest{
+		Target: &apilimiter.LimitTarget{
+			Namespace: identity.Namespace,
+			Service:   identity.Service,
+			Labels:    identity.Labels,
+		},
+		ClientId: e.clientId,
+		Totals:   []*apilimiter.QuotaTotal{total},
+		Mode:     apilimiter.Mode_BATCH_OCCUPY,
+	}, false)
+	if resp.GetCode() != codeSuccess || len(resp.GetCounters()) == 0 {
+		return rlsCounter{}, 0, fmt.Errorf("init ratelimit counter failed, code %d", resp.GetCode())
+	}
+	counter = rlsCounter{
+		key:       resp.GetCounters()[0].GetCounterKey(),
+		maxAmount: total.GetMaxAmount(),
+	}
+	e.lock.Lock()
+	e.clientKey = resp.GetClientKey()
+	e.counters[identity] = counter
+	e.lock.Unlock()
+	return counter, resp.GetClientKey(), nil
+}
+
+func (e *envoyRateLimiter) forget(identity counterIdentity) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	delete(e.counters, identity)
+}
+
+func (e *envoyRateLimiter) report(ctx context.Context, clientKey, counterKey uint32, used uint32) (int64, error) {
+	resp := e.svr.acquireQuota(ctx, &apilimiter.RateLimitReportRequest{
+		ClientKey: clientKey,
+		QuotaUses: []*apilimiter.QuotaSum{
+			{
+				CounterKey: counterKey,
+				Used:       used,
+			},
+		},
+		Timestamp: nowMs(),
+	})
+	if resp.GetCode() != codeSuccess {
+		return 0, fmt.Errorf("acquire ratelimit quota failed, code %d", resp.GetCode())
+	}
+	for _, left := range resp.GetQuotaLefts() {
+		if left.GetCounterKey() == counterKey {
+			return left.GetLeft(), nil
+		}
+	}
+	return 0, errCounterNotFound
+}
+
+func toRateLimit(name string, total *apilimiter.QuotaTotal) *rlsv3.RateLimitResponse_RateLimit {
+	limit := &rlsv3.RateLimitResponse_RateLimit{
+		Name:            name,
+		RequestsPerUnit: total.GetMaxAmount(),
+	}
+	switch total.GetDuration() {
+	case 1:
+		limit.Unit = rlsv3.RateLimitResponse_RateLimit_SECOND
+	case 60:
+		limit.Unit = rlsv3.RateLimitResponse_RateLimit_MINUTE
+	case 3600:
+		limit.Unit = rlsv3.RateLimitResponse_RateLimit_HOUR
+	case 86400:
+		limit.Unit = rlsv3.RateLimitResponse_RateLimit_DAY
+	default:
+		// 非标准的统计周期无法用 Unit 表达, 在 name 中补充说明
+		limit.Unit = rlsv3.RateLimitResponse_RateLimit_UNKNOWN
+		limit.Name = fmt.Sprintf("%s(%d/%ds)", name, total.GetMaxAmount(), total.GetDuration())
+	}
+	return limit
+}
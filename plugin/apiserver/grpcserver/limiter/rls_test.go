@@ -0,0 +1,221 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package limiter
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ratelimitv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/rules"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+)
+
+func newTestEnvoyRateLimiter(limitRules ...*apitraffic.Rule) *envoyRateLimiter {
+	svr := &RateLimitGRPCServer{
+		cfg: &LimiterConfig{SlideCount: 10},
+	}
+	svr.ring = newPeerRing("127.0.0.1:8101")
+	svr.quota = newQuotaCenter(svr.cfg.SlideCount, &svr.keySeq)
+	svr.proxies = newProxyTable(&svr.keySeq)
+	svr.peers = newPeerClients()
+
+	rls := newEnvoyRateLimiter(svr)
+	rls.findService = func(name, namespace string) bool {
+		return name == "svc.a" && namespace == "default"
+	}
+	rls.findRules = func(svcKey svctypes.ServiceKey) []*rules.RateLimit {
+		ret := make([]*rules.RateLimit, 0, len(limitRules))
+		for _, rule := range limitRules {
+			ret = append(ret, &rules.RateLimit{Proto: rule})
+		}
+		return ret
+	}
+	return rls
+}
+
+func newTestGlobalRule(id string, maxAmount uint32, args ...*apitraffic.MatchArgument) *apitraffic.Rule {
+	return &apitraffic.Rule{
+		Id:   wrapperspb.String(id),
+		Name: wrapperspb.String(id),
+		Type: apitraffic.Rule_GLOBAL,
+		Method: &apimodel.MatchString{
+			Type:  apimodel.MatchString_EXACT,
+			Value: wrapperspb.String("/echo"),
+		},
+		Arguments: args,
+		Amounts: []*apitraffic.Amount{
+			{
+				MaxAmount:     wrapperspb.UInt32(maxAmount),
+				ValidDuration: durationpb.New(time.Minute),
+			},
+		},
+	}
+}
+
+func newTestDescriptor(kvs ...string) *ratelimitv3.RateLimitDescriptor {
+	descriptor := &ratelimitv3.RateLimitDescriptor{}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		descriptor.Entries = append(descriptor.Entries, &ratelimitv3.RateLimitDescriptor_Entry{
+			Key:   kvs[i],
+			Value: kvs[i+1],
+		})
+	}
+	return descriptor
+}
+
+func Test_envoyRateLimiter_parseDomain(t *testing.T) {
+	rls := newTestEnvoyRateLimiter()
+	svcKey, ok := rls.parseDomain("svc.a.default")
+	assert.True(t, ok)
+	assert.Equal(t, svctypes.ServiceKey{Namespace: "default", Name: "svc.a"}, svcKey)
+
+	_, ok = rls.parseDomain("unknown.default")
+	assert.False(t, ok)
+	_, ok = rls.parseDomain("default")
+	assert.False(t, ok)
+}
+
+func Test_envoyRateLimiter_matchDescriptor(t *testing.T) {
+	rls := newTestEnvoyRateLimiter()
+	rule := newTestGlobalRule("rule-1", 10, &apitraffic.MatchArgument{
+		Type: apitraffic.MatchArgument_HEADER,
+		Key:  "uid",
+		Value: &apimodel.MatchString{
+			Type:  apimodel.MatchString_EXACT,
+			Value: wrapperspb.String("123"),
+		},
+	}, &apitraffic.MatchArgument{
+		Type: apitraffic.MatchArgument_METHOD,
+		Key:  "method",
+		Value: &apimodel.MatchString{
+			Type:  apimodel.MatchString_IN,
+			Value: wrapperspb.String("GET,POST"),
+		},
+	})
+
+	assert.True(t, rls.matchDescriptor(rule, newTestDescriptor(":path", "/echo", "header.uid", "123", "method.method", "GET")))
+	assert.False(t, rls.matchDescriptor(rule, newTestDescriptor(":path", "/echo", "header.uid", "123", "method.method", "PUT")))
+	assert.False(t, rls.matchDescriptor(rule, newTestDescriptor(":path", "/echo", "header.uid", "456", "method.method", "GET")))
+	// key 集合不一致时不能匹配, 避免命中别的规则生成的 descriptor
+	assert.False(t, rls.matchDescriptor(rule, newTestDescriptor(":path", "/echo", "header.uid", "123")))
+	assert.False(t, rls.matchDescriptor(rule, newTestDescriptor(":path", "/echo", "header.uid", "123",
+		"method.method", "GET", "remote_address", "127.0.0.1")))
+}
+
+func Test_envoyRateLimiter_ShouldRateLimit(t *testing.T) {
+	disabled := newTestGlobalRule("rule-disabled", 1)
+	disabled.Disable = wrapperspb.Bool(true)
+	local := newTestGlobalRule("rule-local", 1)
+	local.Type = apitraffic.Rule_LOCAL
+	rls := newTestEnvoyRateLimiter(newTestGlobalRule("rule-1", 3), disabled, local)
+
+	req := &rlsv3.RateLimitRequest{
+		Domain:      "svc.a.default",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{newTestDescriptor(":path", "/echo")},
+		HitsAddend:  1,
+	}
+	for i := 0; i < 3; i++ {
+		resp, err := rls.ShouldRateLimit(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+		assert.Equal(t, uint32(2-i), resp.GetStatuses()[0].GetLimitRemaining())
+		assert.Equal(t, uint32(3), resp.GetStatuses()[0].GetCurrentLimit().GetRequestsPerUnit())
+		assert.Equal(t, rlsv3.RateLimitResponse_RateLimit_MINUTE, resp.GetStatuses()[0].GetCurrentLimit().GetUnit())
+	}
+	// 超限的请求同样计入配额
+	for i := 0; i < 2; i++ {
+		resp, err := rls.ShouldRateLimit(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, rlsv3.RateLimitResponse_OVER_LIMIT, resp.GetOverallCode())
+		assert.Equal(t, rlsv3.RateLimitResponse_OVER_LIMIT, resp.GetStatuses()[0].GetCode())
+	}
+
+	// 未匹配任何规则的 descriptor 以及未知的 domain 直接放通
+	resp, err := rls.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{
+		Domain:      "svc.a.default",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{newTestDescriptor(":path", "/other")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	resp, err = rls.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{
+		Domain:      "unknown.default",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{newTestDescriptor(":path", "/echo")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	assert.Len(t, resp.GetStatuses(), 1)
+}
+
+func Test_envoyRateLimiter_ThresholdChanged(t *testing.T) {
+	rule := newTestGlobalRule("rule-1", 1)
+	rls := newTestEnvoyRateLimiter(rule)
+	req := &rlsv3.RateLimitRequest{
+		Domain:      "svc.a.default",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{newTestDescriptor(":path", "/echo")},
+	}
+	resp, _ := rls.ShouldRateLimit(context.Background(), req)
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	resp, _ = rls.ShouldRateLimit(context.Background(), req)
+	assert.Equal(t, rlsv3.RateLimitResponse_OVER_LIMIT, resp.GetOverallCode())
+
+	// 规则阈值调大后计数器随之更新, 之前超限的请求同样计入配额
+	rule.Amounts[0].MaxAmount = wrapperspb.UInt32(5)
+	resp, _ = rls.ShouldRateLimit(context.Background(), req)
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	assert.Equal(t, uint32(2), resp.GetStatuses()[0].GetLimitRemaining())
+}
+
+func Test_envoyRateLimiter_Concurrent(t *testing.T) {
+	rls := newTestEnvoyRateLimiter(newTestGlobalRule("rule-1", 10))
+	req := &rlsv3.RateLimitRequest{
+		Domain:      "svc.a.default",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{newTestDescriptor(":path", "/echo")},
+	}
+	// 预先初始化计数器
+	resp, _ := rls.ShouldRateLimit(context.Background(), req)
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+
+	var (
+		wg     sync.WaitGroup
+		passed int32
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := rls.ShouldRateLimit(context.Background(), req)
+			if err == nil && resp.GetOverallCode() == rlsv3.RateLimitResponse_OK {
+				atomic.AddInt32(&passed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(9), passed)
+}
@@ -0,0 +1,167 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	apilimiter "github.com/polarismesh/specification/source/go/api/v1/traffic_manage/ratelimiter"
+
+	"github.com/pole-io/pole-server/apis/apiserver"
+	authcommon "github.com/pole-io/pole-server/apis/pkg/types/auth"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/pkg/cache"
+	commonlog "github.com/pole-io/pole-server/pkg/common/log"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+	"github.com/pole-io/pole-server/plugin/apiserver/grpcserver"
+)
+
+// RateLimitGRPCServer 分布式限流 server, 为 SDK 的全局限流规则提供配额计数服务
+type RateLimitGRPCServer struct {
+	grpcserver.BaseGrpcServer
+	cfg      *LimiterConfig
+	cacheMgr *cache.CacheManager
+	keySeq   uint32
+	ring     *peerRing
+	quota    *QuotaCenter
+	proxies  *proxyTable
+	peers    *peerClients
+	rls      *envoyRateLimiter
+	cancel   context.CancelFunc
+}
+
+// GetPort 获取端口
+func (g *RateLimitGRPCServer) GetPort() uint32 {
+	return g.BaseGrpcServer.GetPort()
+}
+
+// GetProtocol 获取Server的协议
+func (g *RateLimitGRPCServer) GetProtocol() string {
+	return "grpc"
+}
+
+// Initialize 初始化限流 server
+func (g *RateLimitGRPCServer) Initialize(ctx context.Context, option map[string]interface{},
+	apiConf map[string]apiserver.APIConfig) error {
+	cfg, err := loadLimiterConfig(option)
+	if err != nil {
+		return err
+	}
+	g.cfg = cfg
+	if err := g.BaseGrpcServer.Initialize(ctx, option,
+		grpcserver.WithModule(authcommon.DiscoverModule),
+		grpcserver.WithProtocol(g.GetProtocol()),
+		grpcserver.WithLogger(commonlog.FindScope(commonlog.APIServerLoggerName)),
+	); err != nil {
+		return err
+	}
+
+	if g.cacheMgr, err = cache.GetCacheManager(); err != nil {
+		log.Errorf("[API-Server][Limiter] %v", err)
+		return err
+	}
+	g.ring = newPeerRing(fmt.Sprintf("%s:%d", utils.LocalHost, g.GetPort()))
+	g.quota = newQuotaCenter(cfg.SlideCount, &g.keySeq)
+	g.proxies = newProxyTable(&g.keySeq)
+	g.peers = newPeerClients()
+	g.rls = newEnvoyRateLimiter(g)
+	return nil
+}
+
+// Run 启动限流 server
+func (g *RateLimitGRPCServer) Run(errCh chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g.cancel = cancel
+	go g.runMaintainTask(ctx)
+
+	g.BaseGrpcServer.Run(errCh, g.GetProtocol(), func(server *grpc.Server) error {
+		apilimiter.RegisterRateLimitGRPCV2Server(server, g)
+		// Envoy 的 ratelimit filter 通过 RLS 协议接入同一套分布式计数器
+		rlsv3.RegisterRateLimitServiceServer(server, g.rls)
+		return nil
+	})
+}
+
+// Stop 关闭限流 server
+func (g *RateLimitGRPCServer) Stop() {
+	if g.cancel != nil {
+		g.cancel()
+	}
+	if g.peers != nil {
+		g.peers.closeAll()
+	}
+	g.BaseGrpcServer.Stop(g.GetProtocol())
+}
+
+// Restart 重启限流 server
+func (g *RateLimitGRPCServer) Restart(option map[string]interface{}, apiConf map[string]apiserver.APIConfig,
+	errCh chan error) error {
+	initFunc := func() error {
+		return g.Initialize(context.Background(), option, apiConf)
+	}
+	runFunc := func() {
+		g.Run(errCh)
+	}
+	return g.BaseGrpcServer.Restart(initFunc, runFunc, g.GetProtocol(), option)
+}
+
+// runMaintainTask 定期刷新集群 hash 环, 并回收过期的客户端以及计数器
+func (g *RateLimitGRPCServer) runMaintainTask(ctx context.Context) {
+	g.refreshPeers()
+	refreshTicker := time.NewTicker(g.cfg.RefreshInterval)
+	defer refreshTicker.Stop()
+	cleanTicker := time.NewTicker(g.cfg.ClientExpire)
+	defer cleanTicker.Stop()
+
+	for {
+		select {
+		case <-refreshTicker.C:
+			g.refreshPeers()
+		case <-cleanTicker.C:
+			now := nowMs()
+			g.quota.CleanExpired(now, g.cfg.ClientExpire, g.cfg.CounterExpire)
+			g.proxies.CleanExpired(now, g.cfg.CounterExpire)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (g *RateLimitGRPCServer) refreshPeers() {
+	addrs := make([]string, 0, 8)
+	svc := g.cacheMgr.Service().GetServiceByName(g.cfg.Service, g.cfg.Namespace)
+	if svc != nil {
+		g.cacheMgr.Instance().DiscoverServiceInstances(svc.ID, true, func(ins *svctypes.Instance) {
+			if ins.Isolate() {
+				return
+			}
+			addrs = append(addrs, fmt.Sprintf("%s:%d", ins.Host(), ins.Port()))
+		})
+	}
+	if g.ring.Reload(addrs) {
+		log.Info("[API-Server][Limiter] ratelimit cluster changed", zap.Strings("peers", addrs),
+			zap.Int("counters", g.quota.CounterCount()))
+		g.peers.retain(addrs)
+	}
+}
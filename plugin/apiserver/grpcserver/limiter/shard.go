@@ -0,0 +1,93 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package limiter
+
+import (
+	"fmt"
+	"sync"
+
+	commonhash "github.com/pole-io/pole-server/pkg/common/hash"
+)
+
+const peerWeight = 100
+
+// peerRing 基于一致性 hash 将限流计数器分片到限流 server 集群的各个节点
+type peerRing struct {
+	localAddr string
+	lock      sync.RWMutex
+	buckets   map[commonhash.Bucket]bool
+	continuum *commonhash.Continuum
+}
+
+func newPeerRing(localAddr string) *peerRing {
+	return &peerRing{
+		localAddr: localAddr,
+		buckets:   map[commonhash.Bucket]bool{},
+	}
+}
+
+// Reload 根据最新的集群节点列表重建 hash 环, 节点未发生变化时返回 false
+func (r *peerRing) Reload(addrs []string) bool {
+	next := make(map[commonhash.Bucket]bool, len(addrs))
+	for _, addr := range addrs {
+		next[commonhash.Bucket{Host: addr, Weight: peerWeight}] = true
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(next) == len(r.buckets) {
+		changed := false
+		for bucket := range next {
+			if _, ok := r.buckets[bucket]; !ok {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return false
+		}
+	}
+	r.buckets = next
+	r.continuum = commonhash.New(next)
+	return true
+}
+
+// Owner 获取负责该计数器的节点地址, 集群信息缺失或者本节点就是 owner 时返回 true
+func (r *peerRing) Owner(key string) (string, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if r.continuum == nil {
+		return r.localAddr, true
+	}
+	addr := r.continuum.Hash(commonhash.HashString(key))
+	if addr == "" || addr == r.localAddr {
+		return r.localAddr, true
+	}
+	return addr, false
+}
+
+// Peers 当前 hash 环上的节点数
+func (r *peerRing) Peers() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return len(r.buckets)
+}
+
+func shardKey(namespace, service, labels string) string {
+	return fmt.Sprintf("%s#%s#%s", namespace, service, labels)
+}
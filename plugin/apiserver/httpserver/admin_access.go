@@ -18,10 +18,15 @@
 package httpserver
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
 	"github.com/golang/protobuf/jsonpb"
@@ -33,16 +38,26 @@ import (
 	"github.com/pole-io/pole-server/apis/pkg/types"
 	"github.com/pole-io/pole-server/apis/pkg/types/admin"
 	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/pkg/admin/backup"
 	api "github.com/pole-io/pole-server/pkg/common/api/v1"
 	"github.com/pole-io/pole-server/plugin/apiserver/httpserver/docs"
 	httpcommon "github.com/pole-io/pole-server/plugin/apiserver/httpserver/utils"
 )
 
+const (
+	backupMIME = "application/zip"
+	// maxBackupSize 恢复时允许上传的备份文件大小
+	maxBackupSize = 512 << 20
+)
+
 // GetIndexServer get index server
 func (h *HTTPServer) GetIndexServer() *restful.WebService {
 	ws := new(restful.WebService)
 
 	ws.Route(ws.GET("/").To(h.index))
+	ws.Route(docs.EnrichLivenessApiDocs(ws.GET("/livez").To(h.Liveness)))
+	ws.Route(docs.EnrichReadinessApiDocs(ws.GET("/readyz").To(h.Readiness)))
 
 	return ws
 }
@@ -52,6 +67,24 @@ func (h *HTTPServer) index(_ *restful.Request, rsp *restful.Response) {
 	_, _ = rsp.Write([]byte("Polaris Server"))
 }
 
+// Liveness 存活探针 URL: "/livez"
+func (h *HTTPServer) Liveness(req *restful.Request, rsp *restful.Response) {
+	writeProbeResult(rsp, h.maintainServer.Liveness(initContext(req)))
+}
+
+// Readiness 就绪探针 URL: "/readyz"
+func (h *HTTPServer) Readiness(req *restful.Request, rsp *restful.Response) {
+	writeProbeResult(rsp, h.maintainServer.Readiness(initContext(req)))
+}
+
+func writeProbeResult(rsp *restful.Response, ret *admin.ProbeResult) {
+	code := http.StatusOK
+	if !ret.Healthy {
+		code = http.StatusServiceUnavailable
+	}
+	_ = rsp.WriteHeaderAndJson(code, ret, restful.MIME_JSON)
+}
+
 // GetAdminAccessServer 运维接口
 func (h *HTTPServer) GetAdminAccessServer() *restful.WebService {
 	ws := new(restful.WebService)
@@ -69,9 +102,18 @@ func (h *HTTPServer) GetAdminAccessServer() *restful.WebService {
 	ws.Route(docs.EnrichListLeaderElectionsApiDocs(ws.GET("/leaders").To(h.ListLeaderElections)))
 	ws.Route(docs.EnrichReleaseLeaderElectionApiDocs(ws.POST("/leaders/release").To(h.ReleaseLeaderElection)))
 	ws.Route(docs.EnrichGetCMDBInfoApiDocs(ws.GET("/cmdb/info").To(h.GetCMDBInfo)))
+	ws.Route(docs.EnrichGetSelfPreservationApiDocs(ws.GET("/healthcheck/selfpreservation").To(h.GetSelfPreservation)))
+	ws.Route(docs.EnrichListHealthPoliciesApiDocs(ws.GET("/healthpolicies").To(h.ListHealthPolicies)))
+	ws.Route(docs.EnrichUpsertHealthPolicyApiDocs(ws.POST("/healthpolicies").To(h.UpsertHealthPolicy)))
+	ws.Route(docs.EnrichDeleteHealthPolicyApiDocs(ws.POST("/healthpolicies/delete").To(h.DeleteHealthPolicy)))
 	ws.Route(docs.EnrichGetReportClientsApiDocs(ws.GET("/report/clients").To(h.GetReportClients)))
 	ws.Route(docs.EnrichEnablePprofApiDocs(ws.POST("/pprof/enable").To(h.EnablePprof)))
 	ws.Route(docs.EnrichGetServerFunctionsApiDocs(ws.GET("/server/functions").To(h.GetServerFunctions)))
+	ws.Route(docs.EnrichReloadConfigApiDocs(ws.POST("/config/reload").To(h.ReloadConfig)))
+	ws.Route(docs.EnrichExportBackupApiDocs(ws.GET("/backup").To(h.ExportBackup).
+		Produces(backupMIME, restful.MIME_JSON)))
+	ws.Route(docs.EnrichRestoreBackupApiDocs(ws.POST("/backup/restore").To(h.RestoreBackup).
+		Consumes(backupMIME, restful.MIME_OCTET)))
 	ws.Route(ws.GET("/mainuser/exist").To(h.HasMainUser))
 	ws.Route(ws.POST("/mainuser/create").To(h.InitMainUser))
 	return ws
@@ -260,6 +302,58 @@ func (h *HTTPServer) SetLogOutputLevel(req *restful.Request, rsp *restful.Respon
 	_ = rsp.WriteEntity("ok")
 }
 
+// ReloadConfig 重新加载配置文件并热更新可以在运行期间生效的配置
+func (h *HTTPServer) ReloadConfig(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	ret, err := h.maintainServer.ReloadConfig(ctx)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteEntity(ret)
+}
+
+// ExportBackup 导出全部资源的备份文件
+func (h *HTTPServer) ExportBackup(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	// 先写入内存, 导出失败时仍然可以返回错误信息
+	buf := &bytes.Buffer{}
+	if err := h.maintainServer.ExportBackup(ctx, buf); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	fileName := fmt.Sprintf("pole-backup-%s.zip", time.Now().Format("20060102150405"))
+	rsp.AddHeader(restful.HEADER_ContentType, backupMIME)
+	rsp.AddHeader("Content-Disposition", "attachment; filename="+fileName)
+	_, _ = rsp.Write(buf.Bytes())
+}
+
+// RestoreBackup 从请求体中的备份文件恢复资源
+func (h *HTTPServer) RestoreBackup(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	policy, err := backup.ParseConflictPolicy(req.QueryParameter("conflict"))
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	dryRun, _ := strconv.ParseBool(req.QueryParameter("dryRun"))
+	data, err := io.ReadAll(http.MaxBytesReader(rsp, req.Request.Body, maxBackupSize))
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	ret, err := h.maintainServer.RestoreBackup(ctx, data, policy, dryRun)
+	if errors.Is(err, backup.ErrConflict) {
+		_ = rsp.WriteHeaderAndJson(http.StatusConflict, ret, restful.MIME_JSON)
+		return
+	}
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
 func (h *HTTPServer) ListLeaderElections(req *restful.Request, rsp *restful.Response) {
 	ctx := initContext(req)
 	leaders, err := h.maintainServer.ListLeaderElections(ctx)
@@ -301,6 +395,63 @@ func (h *HTTPServer) GetCMDBInfo(req *restful.Request, rsp *restful.Response) {
 	_ = rsp.WriteAsJson(ret)
 }
 
+// GetSelfPreservation 查看健康检查自我保护的状态
+func (h *HTTPServer) GetSelfPreservation(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+
+	ret, err := h.maintainServer.GetSelfPreservation(ctx)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// ListHealthPolicies 查询命名空间、服务维度的健康检查以及过期策略
+func (h *HTTPServer) ListHealthPolicies(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+
+	ret, err := h.maintainServer.ListHealthPolicies(ctx)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// UpsertHealthPolicy 创建或者更新健康检查以及过期策略
+func (h *HTTPServer) UpsertHealthPolicy(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	policy := &svctypes.HealthPolicy{}
+	if err := httpcommon.ParseJsonBody(req, policy); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.maintainServer.UpsertHealthPolicy(ctx, policy); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteEntity("ok")
+}
+
+// DeleteHealthPolicy 删除健康检查以及过期策略
+func (h *HTTPServer) DeleteHealthPolicy(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	var policy struct {
+		Namespace string `json:"namespace"`
+		Service   string `json:"service"`
+	}
+	if err := httpcommon.ParseJsonBody(req, &policy); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.maintainServer.DeleteHealthPolicy(ctx, policy.Namespace, policy.Service); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteEntity("ok")
+}
+
 func (h *HTTPServer) EnablePprof(req *restful.Request, rsp *restful.Response) {
 	var pprofEnable struct {
 		Enable bool `json:"enable"`
@@ -0,0 +1,155 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package aimcp
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/pole-io/pole-server/apis/access_control/auth"
+	authcommon "github.com/pole-io/pole-server/apis/pkg/types/auth"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+// toolAuthorizer 调用 tool 前通过鉴权策略检查调用方是否拥有 MCP tool 的调用权限，再按照用户组的 tool 白名单进行过滤，
+// 会修改资源的 tool 需要策略中显式授权 CallMCPWriteTool
+type toolAuthorizer struct {
+	userSvr   auth.UserServer
+	policySvr auth.StrategyServer
+	policies  []ToolPolicy
+}
+
+func newToolAuthorizer(userSvr auth.UserServer, policySvr auth.StrategyServer,
+	policies []ToolPolicy) *toolAuthorizer {
+	return &toolAuthorizer{
+		userSvr:   userSvr,
+		policySvr: policySvr,
+		policies:  policies,
+	}
+}
+
+// isReadOnlyTool 没有显式声明只读的 tool 都视为会修改资源
+func isReadOnlyTool(tool mcp.Tool) bool {
+	return tool.Annotations.ReadOnlyHint != nil && *tool.Annotations.ReadOnlyHint
+}
+
+// authorize 检查调用方是否可以调用 tool，返回注入了操作者信息的请求上下文
+func (a *toolAuthorizer) authorize(ctx context.Context, tool mcp.Tool) (context.Context, error) {
+	op, method := authcommon.Read, authcommon.CallMCPReadTool
+	if !isReadOnlyTool(tool) {
+		op, method = authcommon.Modify, authcommon.CallMCPWriteTool
+	}
+	authCtx := authcommon.NewAcquireContext(
+		authcommon.WithRequestContext(ctx),
+		authcommon.WithOperation(op),
+		authcommon.WithModule(authcommon.AIMCPModule),
+		authcommon.WithMethod(method),
+	)
+	if _, err := a.policySvr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return nil, err
+	}
+	ctx = authCtx.GetRequestContext()
+	if !a.allowed(ctx, tool) {
+		return nil, fmt.Errorf("tool %s is not allowed for the caller", tool.Name)
+	}
+	return ctx, nil
+}
+
+// allowed 按照用户组的 tool 白名单判断是否允许调用
+func (a *toolAuthorizer) allowed(ctx context.Context, tool mcp.Tool) bool {
+	if len(a.policies) == 0 || utils.ParseIsOwner(ctx) {
+		return true
+	}
+	groups := a.callerGroups(ctx)
+	for _, policy := range a.policies {
+		if policy.ReadOnly && !isReadOnlyTool(tool) {
+			continue
+		}
+		if policy.matchGroups(groups) && policy.matchTool(tool.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// callerGroups 查询调用方所在的用户组
+func (a *toolAuthorizer) callerGroups(ctx context.Context) map[string]struct{} {
+	groups := map[string]struct{}{}
+	userID := utils.ParseUserID(ctx)
+	if userID == "" || a.userSvr == nil {
+		return groups
+	}
+	for _, group := range a.userSvr.GetUserHelper().GetUserOwnGroup(ctx, &apisecurity.User{
+		Id: wrapperspb.String(userID),
+	}) {
+		groups[group.GetName().GetValue()] = struct{}{}
+	}
+	return groups
+}
+
+// wrap 为 tool 的处理函数增加权限检查
+func (a *toolAuthorizer) wrap(tool mcp.Tool, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		authCtx, err := a.authorize(ctx, tool)
+		if err != nil {
+			log.Warn("[apiserver][ai-mcp] call tool permission denied", zap.String("tool", tool.Name),
+				zap.String("operator", utils.ParseOperator(ctx)), zap.Error(err))
+			return mcp.NewToolResultError("permission denied: " + err.Error()), nil
+		}
+		return handler(authCtx, req)
+	}
+}
+
+// filter tools/list 只返回调用方有权限调用的 tool
+func (a *toolAuthorizer) filter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	ret := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if _, err := a.authorize(ctx, tool); err == nil {
+			ret = append(ret, tool)
+		}
+	}
+	return ret
+}
+
+func (p *ToolPolicy) matchGroups(groups map[string]struct{}) bool {
+	for _, group := range p.Groups {
+		if group == allGroups {
+			return true
+		}
+		if _, ok := groups[group]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ToolPolicy) matchTool(name string) bool {
+	for _, pattern := range p.Tools {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package aimcp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/pole-io/pole-server/apis/access_control/auth"
+	"github.com/pole-io/pole-server/apis/pkg/types"
+	authcommon "github.com/pole-io/pole-server/apis/pkg/types/auth"
+)
+
+type fakeStrategyServer struct {
+	auth.StrategyServer
+	checker auth.AuthChecker
+}
+
+func (s *fakeStrategyServer) GetAuthChecker() auth.AuthChecker {
+	return s.checker
+}
+
+// fakeAuthChecker deny-user 没有 CallMCPWriteTool 的权限
+type fakeAuthChecker struct {
+	auth.AuthChecker
+}
+
+func (c *fakeAuthChecker) CheckConsolePermission(authCtx *authcommon.AcquireContext) (bool, error) {
+	for _, method := range authCtx.GetMethods() {
+		if method == authcommon.CallMCPWriteTool &&
+			authCtx.GetRequestContext().Value(types.ContextUserIDKey) == "deny-user" {
+			return false, errors.New("permission denied")
+		}
+	}
+	return true, nil
+}
+
+type fakeUserServer struct {
+	auth.UserServer
+	helper auth.UserHelper
+}
+
+func (s *fakeUserServer) GetUserHelper() auth.UserHelper {
+	return s.helper
+}
+
+type fakeUserHelper struct {
+	auth.UserHelper
+	groups map[string][]string
+}
+
+func (h *fakeUserHelper) GetUserOwnGroup(_ context.Context, user *apisecurity.User) []*apisecurity.UserGroup {
+	ret := make([]*apisecurity.UserGroup, 0)
+	for _, name := range h.groups[user.GetId().GetValue()] {
+		ret = append(ret, &apisecurity.UserGroup{Name: wrapperspb.String(name)})
+	}
+	return ret
+}
+
+func Test_toolAuthorizer(t *testing.T) {
+	policySvr := &fakeStrategyServer{checker: &fakeAuthChecker{}}
+	userSvr := &fakeUserServer{helper: &fakeUserHelper{groups: map[string][]string{"ops-user": {"ops"}}}}
+
+	readTool := mcp.NewTool("list_services", mcp.WithReadOnlyHintAnnotation(true))
+	writeTool := mcp.NewTool("update_router_rules")
+	tools := []mcp.Tool{readTool, writeTool}
+
+	userCtx := func(userID string) context.Context {
+		return context.WithValue(context.Background(), types.ContextUserIDKey, userID)
+	}
+
+	t.Run("无白名单时只检查鉴权策略", func(t *testing.T) {
+		authorizer := newToolAuthorizer(userSvr, policySvr, nil)
+		assert.Len(t, authorizer.filter(userCtx("normal-user"), tools), 2)
+		assert.Len(t, authorizer.filter(userCtx("deny-user"), tools), 1)
+		_, err := authorizer.authorize(userCtx("deny-user"), writeTool)
+		assert.Error(t, err)
+	})
+
+	t.Run("按用户组过滤", func(t *testing.T) {
+		authorizer := newToolAuthorizer(userSvr, policySvr, []ToolPolicy{
+			{Groups: []string{allGroups}, Tools: []string{"*"}, ReadOnly: true},
+			{Groups: []string{"ops"}, Tools: []string{"update_*"}},
+		})
+		ret := authorizer.filter(userCtx("normal-user"), tools)
+		assert.Len(t, ret, 1)
+		assert.Equal(t, "list_services", ret[0].Name)
+		assert.Len(t, authorizer.filter(userCtx("ops-user"), tools), 2)
+
+		ownerCtx := context.WithValue(userCtx("owner"), types.ContextIsOwnerKey, true)
+		assert.Len(t, authorizer.filter(ownerCtx, tools), 2)
+	})
+
+	t.Run("拒绝调用时返回错误结果", func(t *testing.T) {
+		authorizer := newToolAuthorizer(userSvr, policySvr, []ToolPolicy{
+			{Groups: []string{allGroups}, Tools: []string{"list_*"}, ReadOnly: true},
+		})
+		called := false
+		handler := authorizer.wrap(writeTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			called = true
+			return mcp.NewToolResultText("ok"), nil
+		})
+		ret, err := handler(userCtx("normal-user"), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		assert.True(t, ret.IsError)
+		assert.False(t, called)
+	})
+}
+
+func Test_sessionManager(t *testing.T) {
+	mgr := newSessionManager(time.Minute)
+	sessionID := mgr.Generate()
+	assert.True(t, strings.HasPrefix(sessionID, sessionIDPrefix))
+
+	terminated, err := mgr.Validate(sessionID)
+	assert.NoError(t, err)
+	assert.False(t, terminated)
+
+	_, err = mgr.Validate("unknown")
+	assert.ErrorIs(t, err, errSessionNotFound)
+
+	// 会话过期后不能再恢复
+	mgr.sessions[sessionID].lastActive = time.Now().Add(-2 * time.Minute)
+	_, err = mgr.Validate(sessionID)
+	assert.ErrorIs(t, err, errSessionNotFound)
+
+	sessionID = mgr.Generate()
+	_, err = mgr.Terminate(sessionID)
+	assert.NoError(t, err)
+	terminated, err = mgr.Validate(sessionID)
+	assert.NoError(t, err)
+	assert.True(t, terminated)
+}
+
+func Test_ParseConfig(t *testing.T) {
+	cfg, err := ParseConfig(map[interface{}]interface{}{
+		"sessionTTL": "10m",
+		"toolPolicies": []interface{}{
+			map[interface{}]interface{}{
+				"groups":   []interface{}{"*"},
+				"tools":    []interface{}{"list_*"},
+				"readOnly": true,
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, cfg.SessionTTL)
+	assert.Equal(t, []ToolPolicy{{Groups: []string{"*"}, Tools: []string{"list_*"}, ReadOnly: true}}, cfg.ToolPolicies)
+
+	cfg, err = ParseConfig(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultSessionTTL, cfg.SessionTTL)
+}
@@ -0,0 +1,211 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package aimcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pmezard/go-difflib/difflib"
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+)
+
+func (h *HTTPServer) addToolsConfig(mcpSvr *server.MCPServer) {
+	h.addToolGetConfigFile(mcpSvr)
+	h.addToolDiffConfigFile(mcpSvr)
+	h.addToolPublishConfigFile(mcpSvr)
+	h.addToolSearchReleaseHistories(mcpSvr)
+}
+
+// configFileOptions 定位单个配置文件的参数
+func configFileOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithString("namespace",
+			mcp.Description("配置文件所在的命名空间"),
+			mcp.Required(),
+		),
+		mcp.WithString("group",
+			mcp.Description("配置文件所在的分组"),
+			mcp.Required(),
+		),
+		mcp.WithString("name",
+			mcp.Description("配置文件名称"),
+			mcp.Required(),
+		),
+	}
+}
+
+// parseConfigFileKey 解析定位单个配置文件的参数
+func parseConfigFileKey(args map[string]interface{}) (map[string]string, error) {
+	key := toQuery(args, "namespace", "group", "name")
+	if key["namespace"] == "" || key["group"] == "" || key["name"] == "" {
+		return nil, fmt.Errorf("namespace, group and name are required")
+	}
+	return key, nil
+}
+
+// 配置文件相关的 tools
+func (h *HTTPServer) addToolGetConfigFile(mcpSvr *server.MCPServer) {
+	opts := append([]mcp.ToolOption{
+		mcp.WithDescription("此工具用于读取配置中心下的单个配置文件，包括当前编辑中的内容以及发布状态"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	}, configFileOptions()...)
+	h.addTool(mcpSvr,
+		mcp.NewTool("get_config_file", opts...),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handleGetConfigFile", zap.Any("params", req.Params))
+			key, err := parseConfigFileKey(req.GetArguments())
+			if err != nil {
+				return mcp.NewToolResultError("invalid: " + err.Error()), nil
+			}
+			return toolResult(h.configServer.GetConfigFileRichInfo(ctx, &apiconfig.ConfigFile{
+				Namespace: wrapperspb.String(key["namespace"]),
+				Group:     wrapperspb.String(key["group"]),
+				Name:      wrapperspb.String(key["name"]),
+			}))
+		})
+}
+
+func (h *HTTPServer) addToolDiffConfigFile(mcpSvr *server.MCPServer) {
+	opts := append([]mcp.ToolOption{
+		mcp.WithDescription("此工具用于对比配置文件当前编辑中的内容与已发布的内容，返回 unified diff 格式的差异"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("release",
+			mcp.Description("对比的发布版本名称，为空时与当前生效的发布版本对比"),
+		),
+	}, configFileOptions()...)
+	h.addTool(mcpSvr,
+		mcp.NewTool("diff_config_file", opts...),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handleDiffConfigFile", zap.Any("params", req.Params))
+			key, err := parseConfigFileKey(req.GetArguments())
+			if err != nil {
+				return mcp.NewToolResultError("invalid: " + err.Error()), nil
+			}
+			releaseName := toQuery(req.GetArguments(), "release")["release"]
+
+			fileRsp := h.configServer.GetConfigFileRichInfo(ctx, &apiconfig.ConfigFile{
+				Namespace: wrapperspb.String(key["namespace"]),
+				Group:     wrapperspb.String(key["group"]),
+				Name:      wrapperspb.String(key["name"]),
+			})
+			if !api.IsSuccess(fileRsp) {
+				return mcp.NewToolResultError(fileRsp.GetInfo().GetValue()), nil
+			}
+			releaseRsp := h.configServer.GetConfigFileRelease(ctx, &apiconfig.ConfigFileRelease{
+				Name:      wrapperspb.String(releaseName),
+				Namespace: wrapperspb.String(key["namespace"]),
+				Group:     wrapperspb.String(key["group"]),
+				FileName:  wrapperspb.String(key["name"]),
+			})
+			if !api.IsSuccess(releaseRsp) {
+				return mcp.NewToolResultError(releaseRsp.GetInfo().GetValue()), nil
+			}
+			release := releaseRsp.GetConfigFileRelease()
+			diff, err := diffConfigContent(release.GetName().GetValue(), release.GetContent().GetValue(),
+				fileRsp.GetConfigFile().GetContent().GetValue())
+			if err != nil {
+				return nil, err
+			}
+			if diff == "" {
+				return mcp.NewToolResultText("no difference between the current content and the release"), nil
+			}
+			return mcp.NewToolResultText(diff), nil
+		})
+}
+
+// diffConfigContent 生成已发布内容与当前编辑内容的 unified diff
+func diffConfigContent(releaseName, released, current string) (string, error) {
+	from := "release"
+	if releaseName != "" {
+		from = "release/" + releaseName
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(released),
+		B:        difflib.SplitLines(current),
+		FromFile: from,
+		ToFile:   "current",
+		Context:  3,
+	})
+}
+
+func (h *HTTPServer) addToolPublishConfigFile(mcpSvr *server.MCPServer) {
+	opts := append([]mcp.ToolOption{
+		mcp.WithDescription("此工具用于发布配置文件当前编辑中的内容，调用方需要拥有配置文件的发布权限"),
+		mcp.WithString("release",
+			mcp.Description("发布版本名称，为空时自动生成"),
+		),
+		mcp.WithString("comment",
+			mcp.Description("发布备注"),
+		),
+	}, configFileOptions()...)
+	h.addTool(mcpSvr,
+		mcp.NewTool("publish_config_file", opts...),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handlePublishConfigFile", zap.Any("params", req.Params))
+			key, err := parseConfigFileKey(req.GetArguments())
+			if err != nil {
+				return mcp.NewToolResultError("invalid: " + err.Error()), nil
+			}
+			extra := toQuery(req.GetArguments(), "release", "comment")
+			return toolResult(h.configServer.PublishConfigFile(ctx, &apiconfig.ConfigFileRelease{
+				Name:               wrapperspb.String(extra["release"]),
+				Namespace:          wrapperspb.String(key["namespace"]),
+				Group:              wrapperspb.String(key["group"]),
+				FileName:           wrapperspb.String(key["name"]),
+				ReleaseDescription: wrapperspb.String(extra["comment"]),
+			}))
+		})
+}
+
+// 操作记录相关的 tools
+func (h *HTTPServer) addToolSearchReleaseHistories(mcpSvr *server.MCPServer) {
+	h.addTool(mcpSvr,
+		mcp.NewTool("search_release_histories",
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDescription("此工具用于搜索配置文件的发布操作记录，可以根据命名空间、分组、文件名称进行过滤或者进行分页查询"),
+			mcp.WithString("namespace",
+				mcp.Description("配置文件所在的命名空间"),
+			),
+			mcp.WithString("group",
+				mcp.Description("配置文件所在的分组"),
+			),
+			mcp.WithString("name",
+				mcp.Description("配置文件名称"),
+			),
+			mcp.WithNumber("offset",
+				mcp.Description("查询的偏移量，默认值为0"),
+				mcp.DefaultNumber(0),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("限制返回的记录数量"),
+				mcp.DefaultNumber(100),
+			),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handleSearchReleaseHistories", zap.Any("params", req.Params))
+			query := toQuery(req.GetArguments(), "namespace", "group", "name", "offset", "limit")
+			return toolResult(h.configServer.GetConfigFileReleaseHistories(ctx, query))
+		})
+}
@@ -0,0 +1,252 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package aimcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+)
+
+const (
+	// summaryPageSize 统计服务健康状态时分页拉取实例的大小
+	summaryPageSize = 100
+)
+
+func (h *HTTPServer) addToolsDiscovery(mcpSvr *server.MCPServer) {
+	h.addToolQueryServices(mcpSvr)
+	h.addToolQueryInstances(mcpSvr)
+	h.addToolServiceHealthSummary(mcpSvr)
+	h.addToolFindMCPServers(mcpSvr)
+}
+
+// 服务、实例相关的 tools
+func (h *HTTPServer) addToolQueryServices(mcpSvr *server.MCPServer) {
+	h.addTool(mcpSvr,
+		mcp.NewTool("list_services",
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDescription("此工具用于查询服务治理中心下的服务列表，可以根据命名空间、服务名称进行过滤或者进行分页查询"),
+			mcp.WithString("namespace",
+				mcp.Description("服务所在的命名空间"),
+			),
+			mcp.WithString("name",
+				mcp.Description("根据服务名称查询过滤，支持模糊查询，后缀查询：*test、前缀查询：test*、全模糊查询：*test*"),
+			),
+			mcp.WithNumber("offset",
+				mcp.Description("查询的偏移量，默认值为0"),
+				mcp.DefaultNumber(0),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("限制返回的服务数量"),
+				mcp.DefaultNumber(100),
+			),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handleQueryServices", zap.Any("params", req.Params))
+			query := toQuery(req.GetArguments(), "namespace", "name", "offset", "limit")
+			return toolResult(h.discoverySvr.GetServices(ctx, query))
+		})
+}
+
+func (h *HTTPServer) addToolQueryInstances(mcpSvr *server.MCPServer) {
+	h.addTool(mcpSvr,
+		mcp.NewTool("list_instances",
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDescription("此工具用于查询某个服务下的实例列表，可以根据实例 IP、健康状态、隔离状态进行过滤或者进行分页查询"),
+			mcp.WithString("namespace",
+				mcp.Description("服务所在的命名空间"),
+				mcp.Required(),
+			),
+			mcp.WithString("service",
+				mcp.Description("服务名称"),
+				mcp.Required(),
+			),
+			mcp.WithString("host",
+				mcp.Description("根据实例 IP 查询过滤"),
+			),
+			mcp.WithBoolean("healthy",
+				mcp.Description("根据实例健康状态查询过滤"),
+			),
+			mcp.WithBoolean("isolate",
+				mcp.Description("根据实例隔离状态查询过滤"),
+			),
+			mcp.WithNumber("offset",
+				mcp.Description("查询的偏移量，默认值为0"),
+				mcp.DefaultNumber(0),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("限制返回的实例数量"),
+				mcp.DefaultNumber(100),
+			),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handleQueryInstances", zap.Any("params", req.Params))
+			query := toQuery(req.GetArguments(),
+				"namespace", "service", "host", "healthy", "isolate", "offset", "limit")
+			if query["namespace"] == "" || query["service"] == "" {
+				return mcp.NewToolResultError("invalid: namespace and service are required"), nil
+			}
+			return toolResult(h.discoverySvr.GetInstances(ctx, query))
+		})
+}
+
+// serviceHealthSummary 服务实例健康状态汇总
+type serviceHealthSummary struct {
+	Namespace string `json:"namespace"`
+	Service   string `json:"service"`
+	Total     int    `json:"total"`
+	Healthy   int    `json:"healthy"`
+	Unhealthy int    `json:"unhealthy"`
+	Isolated  int    `json:"isolated"`
+	// UnhealthyInstances 不健康的实例地址
+	UnhealthyInstances []string `json:"unhealthyInstances"`
+	// FlappingInstances 健康状态存在抖动的实例地址以及抖动次数
+	FlappingInstances map[string]string `json:"flappingInstances"`
+}
+
+func (h *HTTPServer) addToolServiceHealthSummary(mcpSvr *server.MCPServer) {
+	h.addTool(mcpSvr,
+		mcp.NewTool("get_service_health_summary",
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDescription("此工具用于汇总某个服务下实例的健康状态，包括健康、不健康、隔离的实例数量，以及不健康和健康状态存在抖动的实例"),
+			mcp.WithString("namespace",
+				mcp.Description("服务所在的命名空间"),
+				mcp.Required(),
+			),
+			mcp.WithString("service",
+				mcp.Description("服务名称"),
+				mcp.Required(),
+			),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handleServiceHealthSummary", zap.Any("params", req.Params))
+			query := toQuery(req.GetArguments(), "namespace", "service")
+			if query["namespace"] == "" || query["service"] == "" {
+				return mcp.NewToolResultError("invalid: namespace and service are required"), nil
+			}
+			summary, errRsp := h.summaryServiceHealth(ctx, query["namespace"], query["service"])
+			if errRsp != nil {
+				return errRsp, nil
+			}
+			ret, err := json.MarshalIndent(summary, "", " ")
+			if err != nil {
+				return nil, err
+			}
+			return mcp.NewToolResultText(string(ret)), nil
+		})
+}
+
+func (h *HTTPServer) summaryServiceHealth(ctx context.Context, namespace,
+	service string) (*serviceHealthSummary, *mcp.CallToolResult) {
+	summary := &serviceHealthSummary{
+		Namespace:          namespace,
+		Service:            service,
+		UnhealthyInstances: []string{},
+		FlappingInstances:  map[string]string{},
+	}
+	for offset := 0; ; offset += summaryPageSize {
+		rsp := h.discoverySvr.GetInstances(ctx, map[string]string{
+			"namespace": namespace,
+			"service":   service,
+			"offset":    strconv.Itoa(offset),
+			"limit":     strconv.Itoa(summaryPageSize),
+		})
+		if !api.IsSuccess(rsp) {
+			return nil, mcp.NewToolResultError(rsp.GetInfo().GetValue())
+		}
+		for _, ins := range rsp.GetInstances() {
+			address := fmt.Sprintf("%s:%d", ins.GetHost().GetValue(), ins.GetPort().GetValue())
+			summary.Total++
+			if ins.GetIsolate().GetValue() {
+				summary.Isolated++
+			}
+			if ins.GetHealthy().GetValue() {
+				summary.Healthy++
+			} else {
+				summary.Unhealthy++
+				summary.UnhealthyInstances = append(summary.UnhealthyInstances, address)
+			}
+			if count, ok := ins.GetMetadata()[svctypes.MetadataInstanceFlapCount]; ok {
+				summary.FlappingInstances[address] = count
+			}
+		}
+		if len(rsp.GetInstances()) < summaryPageSize {
+			break
+		}
+	}
+	return summary, nil
+}
+
+// mcpServerList find_mcp_servers 的返回结果
+type mcpServerList struct {
+	Amount  uint32                `json:"amount"`
+	Servers []*svctypes.MCPServer `json:"servers"`
+}
+
+func (h *HTTPServer) addToolFindMCPServers(mcpSvr *server.MCPServer) {
+	h.addTool(mcpSvr,
+		mcp.NewTool("find_mcp_servers",
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDescription("此工具用于查找注册在服务治理中心的 MCP Server，可以根据 tool 名称查找提供该 tool 的 MCP Server，返回 MCP Server 的能力清单以及接入点"),
+			mcp.WithString("tool",
+				mcp.Description("MCP Server 提供的 tool 名称，支持通配：*query*"),
+			),
+			mcp.WithString("namespace",
+				mcp.Description("MCP Server 所在的命名空间"),
+			),
+			mcp.WithString("name",
+				mcp.Description("MCP Server 名称"),
+			),
+			mcp.WithBoolean("healthy_only",
+				mcp.Description("只返回存在健康接入点的 MCP Server"),
+				mcp.DefaultBool(true),
+			),
+			mcp.WithNumber("offset",
+				mcp.Description("查询的偏移量，默认值为0"),
+				mcp.DefaultNumber(0),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("限制返回的 MCP Server 数量"),
+				mcp.DefaultNumber(100),
+			),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handleFindMCPServers", zap.Any("params", req.Params))
+			query := toQuery(req.GetArguments(), "tool", "namespace", "name", "healthy_only", "offset", "limit")
+			if _, ok := query["healthy_only"]; !ok {
+				query["healthy_only"] = "true"
+			}
+			amount, servers, err := h.discoverySvr.GetMCPServers(ctx, query)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			ret, err := json.MarshalIndent(&mcpServerList{Amount: amount, Servers: servers}, "", " ")
+			if err != nil {
+				return nil, err
+			}
+			return mcp.NewToolResultText(string(ret)), nil
+		})
+}
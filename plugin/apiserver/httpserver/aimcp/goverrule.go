@@ -0,0 +1,160 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package aimcp
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	apifault "github.com/polarismesh/specification/source/go/api/v1/fault_tolerance"
+	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+	"go.uber.org/zap"
+
+	httpcommon "github.com/pole-io/pole-server/plugin/apiserver/httpserver/utils"
+)
+
+// ruleQueryKeys 治理规则查询支持的过滤条件
+var ruleQueryKeys = []string{"namespace", "name", "service", "offset", "limit"}
+
+func (h *HTTPServer) addToolsGoverRule(mcpSvr *server.MCPServer) {
+	h.addToolQueryRouterRules(mcpSvr)
+	h.addToolUpdateRouterRules(mcpSvr)
+	h.addToolQueryRateLimitRules(mcpSvr)
+	h.addToolUpdateRateLimitRules(mcpSvr)
+	h.addToolQueryCircuitBreakerRules(mcpSvr)
+	h.addToolUpdateCircuitBreakerRules(mcpSvr)
+}
+
+// newRuleQueryTool 治理规则查询类 tool 的通用参数
+func newRuleQueryTool(name, description string) mcp.Tool {
+	return mcp.NewTool(name,
+		mcp.WithDescription(description),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("namespace",
+			mcp.Description("规则所在的命名空间"),
+		),
+		mcp.WithString("name",
+			mcp.Description("根据规则名称查询过滤"),
+		),
+		mcp.WithString("service",
+			mcp.Description("根据规则关联的服务名称查询过滤"),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("查询的偏移量，默认值为0"),
+			mcp.DefaultNumber(0),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("限制返回的规则数量"),
+			mcp.DefaultNumber(100),
+		),
+	)
+}
+
+// 路由规则相关的 tools
+func (h *HTTPServer) addToolQueryRouterRules(mcpSvr *server.MCPServer) {
+	h.addTool(mcpSvr,
+		newRuleQueryTool("list_router_rules", "此工具用于查询服务治理中心下的路由规则列表"),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handleQueryRouterRules", zap.Any("params", req.Params))
+			return toolResult(h.ruleServer.QueryRouterRules(ctx, toQuery(req.GetArguments(), ruleQueryKeys...)))
+		})
+}
+
+func (h *HTTPServer) addToolUpdateRouterRules(mcpSvr *server.MCPServer) {
+	h.addTool(mcpSvr,
+		mcp.NewTool("update_router_rules",
+			mcp.WithDescription("此工具用于更新服务治理中心下的多个路由规则，规则需要包含 id，调用方需要拥有规则的编辑权限"),
+			mcp.WithArray("rules",
+				mcp.Description("路由规则数组"),
+				mcp.Items(httpcommon.MarshalPBJsonToMap(&apitraffic.RouteRule{})),
+				mcp.Required(),
+			),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handleUpdateRouterRules", zap.Any("params", req.Params))
+			rules, err := parseArrayArg(req.GetArguments(), "rules",
+				func() *apitraffic.RouteRule { return &apitraffic.RouteRule{} })
+			if err != nil {
+				return mcp.NewToolResultError("invalid: rules parse fail: " + err.Error()), nil
+			}
+			return toolResult(h.ruleServer.UpdateRouterRules(ctx, rules))
+		})
+}
+
+// 限流规则相关的 tools
+func (h *HTTPServer) addToolQueryRateLimitRules(mcpSvr *server.MCPServer) {
+	h.addTool(mcpSvr,
+		newRuleQueryTool("list_ratelimit_rules", "此工具用于查询服务治理中心下的限流规则列表"),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handleQueryRateLimitRules", zap.Any("params", req.Params))
+			return toolResult(h.ruleServer.GetRateLimits(ctx, toQuery(req.GetArguments(), ruleQueryKeys...)))
+		})
+}
+
+func (h *HTTPServer) addToolUpdateRateLimitRules(mcpSvr *server.MCPServer) {
+	h.addTool(mcpSvr,
+		mcp.NewTool("update_ratelimit_rules",
+			mcp.WithDescription("此工具用于更新服务治理中心下的多个限流规则，规则需要包含 id，调用方需要拥有规则的编辑权限"),
+			mcp.WithArray("rules",
+				mcp.Description("限流规则数组"),
+				mcp.Items(httpcommon.MarshalPBJsonToMap(&apitraffic.Rule{})),
+				mcp.Required(),
+			),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handleUpdateRateLimitRules", zap.Any("params", req.Params))
+			rules, err := parseArrayArg(req.GetArguments(), "rules",
+				func() *apitraffic.Rule { return &apitraffic.Rule{} })
+			if err != nil {
+				return mcp.NewToolResultError("invalid: rules parse fail: " + err.Error()), nil
+			}
+			return toolResult(h.ruleServer.UpdateRateLimits(ctx, rules))
+		})
+}
+
+// 熔断规则相关的 tools
+func (h *HTTPServer) addToolQueryCircuitBreakerRules(mcpSvr *server.MCPServer) {
+	h.addTool(mcpSvr,
+		newRuleQueryTool("list_circuitbreaker_rules", "此工具用于查询服务治理中心下的熔断规则列表"),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handleQueryCircuitBreakerRules", zap.Any("params", req.Params))
+			return toolResult(h.ruleServer.GetCircuitBreakerRules(ctx, toQuery(req.GetArguments(), ruleQueryKeys...)))
+		})
+}
+
+func (h *HTTPServer) addToolUpdateCircuitBreakerRules(mcpSvr *server.MCPServer) {
+	h.addTool(mcpSvr,
+		mcp.NewTool("update_circuitbreaker_rules",
+			mcp.WithDescription("此工具用于更新服务治理中心下的多个熔断规则，规则需要包含 id，调用方需要拥有规则的编辑权限"),
+			mcp.WithArray("rules",
+				mcp.Description("熔断规则数组"),
+				mcp.Items(httpcommon.MarshalPBJsonToMap(&apifault.CircuitBreakerRule{})),
+				mcp.Required(),
+			),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			log.Info("[apiserver][ai-mcp] handleUpdateCircuitBreakerRules", zap.Any("params", req.Params))
+			rules, err := parseArrayArg(req.GetArguments(), "rules",
+				func() *apifault.CircuitBreakerRule { return &apifault.CircuitBreakerRule{} })
+			if err != nil {
+				return mcp.NewToolResultError("invalid: rules parse fail: " + err.Error()), nil
+			}
+			return toolResult(h.ruleServer.UpdateCircuitBreakerRules(ctx, rules))
+		})
+}
@@ -26,8 +26,9 @@ func (h *HTTPServer) addToolsNamespace(mcpSvr *server.MCPServer) {
 
 // 命名空间相关的 tools
 func (h *HTTPServer) addToolQueryNamespaces(mcpSvr *server.MCPServer) {
-	mcpSvr.AddTool(
+	h.addTool(mcpSvr,
 		mcp.NewTool("list_namespaces",
+			mcp.WithReadOnlyHintAnnotation(true),
 			mcp.WithDescription("此工具用于查询服务治理中心下的命名空间列表，可以查询所有命名空间，也可以根据名称进行模糊查询或者进行分页查询"),
 			mcp.WithString("name",
 				mcp.Description("根据名称查询过滤，支持模糊查询，后缀查询：*test、前缀查询：test*、全模糊查询：*test*"),
@@ -47,7 +48,7 @@ func (h *HTTPServer) addToolQueryNamespaces(mcpSvr *server.MCPServer) {
 		),
 		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Info("[apiserver][ai-mcp] handleQueryNamespaces", zap.Any("params", req.Params))
-			args := req.Params.Arguments
+			args := req.GetArguments()
 			if args == nil {
 				return mcp.NewToolResultError("invalid: args is empty"), nil
 			}
@@ -105,7 +106,7 @@ func (h *HTTPServer) addToolQueryNamespaces(mcpSvr *server.MCPServer) {
 }
 
 func (h *HTTPServer) addToolCreateNamespaces(mcpSvr *server.MCPServer) {
-	mcpSvr.AddTool(
+	h.addTool(mcpSvr,
 		mcp.NewTool("create_namespaces",
 			mcp.WithDescription("此工具用于在服务治理中心下的创建多个命名空间"),
 			mcp.WithArray("namespaces",
@@ -115,7 +116,7 @@ func (h *HTTPServer) addToolCreateNamespaces(mcpSvr *server.MCPServer) {
 		),
 		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Info("[apiserver][ai-mcp] handleCreateNamespaces", zap.Any("params", req.Params))
-			args := req.Params.Arguments
+			args := req.GetArguments()
 			if args == nil {
 				return mcp.NewToolResultError("invalid: args is empty"), nil
 			}
@@ -142,7 +143,7 @@ func (h *HTTPServer) addToolCreateNamespaces(mcpSvr *server.MCPServer) {
 }
 
 func (h *HTTPServer) addToolDeleteNamespaces(mcpSvr *server.MCPServer) {
-	mcpSvr.AddTool(
+	h.addTool(mcpSvr,
 		mcp.NewTool("delete_namespaces",
 			mcp.WithDescription("此工具用于在服务治理中心下的删除多个命名空间"),
 			mcp.WithArray("namespaces",
@@ -154,14 +155,15 @@ func (h *HTTPServer) addToolDeleteNamespaces(mcpSvr *server.MCPServer) {
 		),
 		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Info("[apiserver][ai-mcp] handleDeleteNamespaces", zap.Any("params", req.Params))
-			args := req.Params.Arguments
+			args := req.GetArguments()
 			if args == nil {
 				return mcp.NewToolResultError("invalid: args is empty"), nil
 			}
 
-			namespaces, ok := args["namespaces"].([]*apimodel.Namespace)
-			if !ok || len(namespaces) == 0 {
-				return mcp.NewToolResultError("invalid: namespaces is empty or invalid"), nil
+			namespaces, err := parseArrayArg(args, "namespaces",
+				func() *apimodel.Namespace { return &apimodel.Namespace{} })
+			if err != nil {
+				return mcp.NewToolResultError("invalid: namespaces parse fail: " + err.Error()), nil
 			}
 
 			rsp := h.namespaceServer.DeleteNamespaces(ctx, namespaces)
@@ -175,7 +177,7 @@ func (h *HTTPServer) addToolDeleteNamespaces(mcpSvr *server.MCPServer) {
 }
 
 func (h *HTTPServer) addToolUpdateNamespaces(mcpSvr *server.MCPServer) {
-	mcpSvr.AddTool(
+	h.addTool(mcpSvr,
 		mcp.NewTool("update_namespaces",
 			mcp.WithDescription("此工具用于在服务治理中心下的更新多个命名空间"),
 			mcp.WithArray("namespaces",
@@ -185,17 +187,18 @@ func (h *HTTPServer) addToolUpdateNamespaces(mcpSvr *server.MCPServer) {
 		),
 		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			log.Info("[apiserver][ai-mcp] handleUpdatwNamespaces", zap.Any("params", req.Params))
-			args := req.Params.Arguments
+			args := req.GetArguments()
 			if args == nil {
 				return mcp.NewToolResultError("invalid: args is empty"), nil
 			}
 
-			namespaces, ok := args["namespaces"].([]*apimodel.Namespace)
-			if !ok || len(namespaces) == 0 {
-				return mcp.NewToolResultError("invalid: namespaces is empty or invalid"), nil
+			namespaces, err := parseArrayArg(args, "namespaces",
+				func() *apimodel.Namespace { return &apimodel.Namespace{} })
+			if err != nil {
+				return mcp.NewToolResultError("invalid: namespaces parse fail: " + err.Error()), nil
 			}
 
-			rsp := h.namespaceServer.DeleteNamespaces(ctx, namespaces)
+			rsp := h.namespaceServer.UpdateNamespaces(ctx, namespaces)
 			if !api.IsSuccess(rsp) {
 				return mcp.NewToolResultError(rsp.GetInfo().GetValue()), nil
 			}
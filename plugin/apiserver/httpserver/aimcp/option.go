@@ -0,0 +1,75 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package aimcp
+
+import (
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	defaultSessionTTL = 30 * time.Minute
+	// allGroups 匹配所有用户
+	allGroups = "*"
+)
+
+// Config MCP 接入配置
+type Config struct {
+	// SessionTTL Streamable HTTP 会话的空闲有效期，有效期内客户端可以携带 Mcp-Session-Id 恢复会话
+	SessionTTL time.Duration `mapstructure:"sessionTTL"`
+	// ToolPolicies 按照用户组限制允许调用的 tool，为空时不做额外限制，仍然需要通过鉴权策略的检查
+	ToolPolicies []ToolPolicy `mapstructure:"toolPolicies"`
+}
+
+// ToolPolicy 用户组允许调用的 tool 列表
+type ToolPolicy struct {
+	// Groups 用户组名称，* 表示所有用户
+	Groups []string `mapstructure:"groups"`
+	// Tools 允许调用的 tool 名称，支持 * 通配，比如 list_*
+	Tools []string `mapstructure:"tools"`
+	// ReadOnly 只允许调用其中的只读 tool
+	ReadOnly bool `mapstructure:"readOnly"`
+}
+
+// SetDefault 设置默认值
+func (c *Config) SetDefault() {
+	if c.SessionTTL <= 0 {
+		c.SessionTTL = defaultSessionTTL
+	}
+}
+
+// ParseConfig 解析 MCP 接入配置
+func ParseConfig(raw map[interface{}]interface{}) (*Config, error) {
+	config := &Config{}
+	if raw != nil {
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+			Result:     config,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := decoder.Decode(raw); err != nil {
+			log.Errorf("[apiserver][ai-mcp] parse mcp config(%+v) err: %s", raw, err.Error())
+			return nil, err
+		}
+	}
+	config.SetDefault()
+	return config, nil
+}
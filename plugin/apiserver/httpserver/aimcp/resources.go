@@ -0,0 +1,99 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package aimcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+	httpcommon "github.com/pole-io/pole-server/plugin/apiserver/httpserver/utils"
+)
+
+const (
+	instancesResourceTemplate  = "pole://services/{namespace}/{service}/instances"
+	configFileResourceTemplate = "pole://configs/{namespace}/{group}/{name}"
+)
+
+func (h *HTTPServer) addResources(mcpSvr *server.MCPServer) {
+	mcpSvr.AddResourceTemplate(
+		mcp.NewResourceTemplate(instancesResourceTemplate, "service instances",
+			mcp.WithTemplateDescription("服务下的实例列表，包括实例的健康状态以及元数据"),
+			mcp.WithTemplateMIMEType("application/json"),
+		),
+		h.readInstancesResource,
+	)
+	mcpSvr.AddResourceTemplate(
+		mcp.NewResourceTemplate(configFileResourceTemplate, "config file",
+			mcp.WithTemplateDescription("配置文件当前编辑中的内容"),
+			mcp.WithTemplateMIMEType("text/plain"),
+		),
+		h.readConfigFileResource,
+	)
+}
+
+func (h *HTTPServer) readInstancesResource(ctx context.Context,
+	req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	log.Info("[apiserver][ai-mcp] handleReadInstances", zap.String("uri", req.Params.URI))
+	query := toQuery(req.Params.Arguments, "namespace", "service")
+	rsp := h.discoverySvr.GetInstances(ctx, query)
+	if !api.IsSuccess(rsp) {
+		return nil, fmt.Errorf("%s", rsp.GetInfo().GetValue())
+	}
+	ret, err := httpcommon.MarshalPBJson(rsp)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: "application/json",
+			Text:     ret,
+		},
+	}, nil
+}
+
+func (h *HTTPServer) readConfigFileResource(ctx context.Context,
+	req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	log.Info("[apiserver][ai-mcp] handleReadConfigFile", zap.String("uri", req.Params.URI))
+	key, err := parseConfigFileKey(req.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	rsp := h.configServer.GetConfigFileRichInfo(ctx, &apiconfig.ConfigFile{
+		Namespace: wrapperspb.String(key["namespace"]),
+		Group:     wrapperspb.String(key["group"]),
+		Name:      wrapperspb.String(key["name"]),
+	})
+	if !api.IsSuccess(rsp) {
+		return nil, fmt.Errorf("%s", rsp.GetInfo().GetValue())
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: "text/plain",
+			Text:     rsp.GetConfigFile().GetContent().GetValue(),
+		},
+	}, nil
+}
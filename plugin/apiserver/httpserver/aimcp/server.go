@@ -23,14 +23,17 @@ import (
 	"strings"
 
 	"github.com/emicklei/go-restful/v3"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 
+	"github.com/pole-io/pole-server/apis/access_control/auth"
 	"github.com/pole-io/pole-server/apis/pkg/types"
 	"github.com/pole-io/pole-server/pkg/admin"
 	commonlog "github.com/pole-io/pole-server/pkg/common/log"
 	"github.com/pole-io/pole-server/pkg/common/version"
 	"github.com/pole-io/pole-server/pkg/config"
+	"github.com/pole-io/pole-server/pkg/goverrule"
 	"github.com/pole-io/pole-server/pkg/namespace"
 	"github.com/pole-io/pole-server/pkg/service"
 )
@@ -44,6 +47,8 @@ const (
 
 	sseEp string = "/sse"
 	msgEp string = "/message"
+	// streamableEp MCP Streamable HTTP transport 的入口
+	streamableEp string = "/mcp"
 )
 
 // HTTPServer
@@ -52,14 +57,20 @@ type HTTPServer struct {
 	namespaceServer namespace.NamespaceOperateServer
 	configServer    config.ConfigCenterServer
 	discoverySvr    service.DiscoverServer
+	ruleServer      goverrule.GoverRuleServer
+	authorizer      *toolAuthorizer
 	mcpSvr          *server.MCPServer
 	sseSvr          *server.SSEServer
+	streamableSvr   *server.StreamableHTTPServer
 }
 
 // NewServer 创建配置中心的 HttpServer
 func NewServer(
 	maintainServer admin.AdminOperateServer,
-	namespaceServer namespace.NamespaceOperateServer) (*HTTPServer, error) {
+	namespaceServer namespace.NamespaceOperateServer,
+	userSvr auth.UserServer,
+	policySvr auth.StrategyServer,
+	cfg *Config) (*HTTPServer, error) {
 	// 初始化配置中心模块
 	configServer, err := config.GetServer()
 	if err != nil {
@@ -72,44 +83,78 @@ func NewServer(
 		commonlog.Errorf("set discovery server to http server error. %v", err)
 		return nil, err
 	}
+	// 初始化服务治理规则模块
+	ruleServer, err := goverrule.GetServer()
+	if err != nil {
+		commonlog.Errorf("set gover rule server to http server error. %v", err)
+		return nil, err
+	}
+
+	if cfg == nil {
+		cfg = &Config{}
+		cfg.SetDefault()
+	}
+	authorizer := newToolAuthorizer(userSvr, policySvr, cfg.ToolPolicies)
 
 	mcpSvr := server.NewMCPServer("pole.io", version.Get(),
 		server.WithResourceCapabilities(true, true),
 		server.WithPromptCapabilities(true),
+		server.WithToolFilter(authorizer.filter),
 		server.WithLogging(),
 	)
 	sseSvr := server.NewSSEServer(mcpSvr,
 		server.WithBasePath(basePath),
-		server.WithSSEContextFunc(func(ctx context.Context, r *http.Request) context.Context {
-			newheaders := make(http.Header)
-			for k, v := range r.Header {
-				if len(v) == 0 {
-					continue
-				}
-				if k == types.HeaderAuthorizationKey {
-					v[0] = strings.TrimPrefix(v[0], "Bearer ")
-				}
-				newheaders[k] = v
-			}
-			if log.DebugEnabled() {
-				log.Debug("[apiserver][ai-mcp] sse rewrite header", zap.Any("origin", r.Header), zap.Any("new", newheaders))
-			}
-			return types.AppendRequestHeader(ctx, newheaders)
-		}),
+		server.WithSSEContextFunc(parseRequestContext),
 		server.WithSSEEndpoint(sseEp),
 		server.WithMessageEndpoint(msgEp),
 	)
+	streamableSvr := server.NewStreamableHTTPServer(mcpSvr,
+		server.WithEndpointPath(basePath+streamableEp),
+		server.WithHTTPContextFunc(parseRequestContext),
+		server.WithSessionIdManager(newSessionManager(cfg.SessionTTL)),
+	)
 
 	return &HTTPServer{
 		maintainServer:  maintainServer,
 		namespaceServer: namespaceServer,
 		configServer:    configServer,
 		discoverySvr:    discoverySvr,
+		ruleServer:      ruleServer,
+		authorizer:      authorizer,
 		mcpSvr:          mcpSvr,
 		sseSvr:          sseSvr,
+		streamableSvr:   streamableSvr,
 	}, nil
 }
 
+// parseRequestContext 将 MCP 请求的 header 转换为与控制台 OpenAPI 一致的请求上下文，保证 tools 经过相同的鉴权链路
+func parseRequestContext(ctx context.Context, r *http.Request) context.Context {
+	newheaders := make(http.Header)
+	for k, v := range r.Header {
+		if len(v) == 0 {
+			continue
+		}
+		if k == types.HeaderAuthorizationKey {
+			v[0] = strings.TrimPrefix(v[0], "Bearer ")
+		}
+		newheaders[k] = v
+	}
+	if log.DebugEnabled() {
+		log.Debug("[apiserver][ai-mcp] sse rewrite header", zap.Any("origin", r.Header), zap.Any("new", newheaders))
+	}
+	ctx = types.AppendRequestHeader(ctx, newheaders)
+	ctx = context.WithValue(ctx, types.StringContext("request-id"), newheaders.Get("Request-Id"))
+	ctx = context.WithValue(ctx, types.ContextClientAddress, r.RemoteAddr)
+	if authToken := newheaders.Get(types.HeaderAuthorizationKey); authToken != "" {
+		ctx = context.WithValue(ctx, types.ContextAuthTokenKey, authToken)
+	}
+	operator := "MCP:" + r.RemoteAddr
+	if staffName := newheaders.Get("Staffname"); staffName != "" {
+		operator = staffName
+	}
+	return context.WithValue(ctx, types.StringContext("operator"), operator)
+}
+
 // GetConfigAccessServer 获取配置中心接口
 func (h *HTTPServer) GetMCPAccessServer(include []string) *restful.WebService {
 	commonlog.Info("enable ai-mcp access server")
@@ -136,6 +181,15 @@ func (h *HTTPServer) GetMCPAccessServer(include []string) *restful.WebService {
 
 func (h *HTTPServer) addMcpTools() {
 	h.addToolsNamespace(h.mcpSvr)
+	h.addToolsDiscovery(h.mcpSvr)
+	h.addToolsGoverRule(h.mcpSvr)
+	h.addToolsConfig(h.mcpSvr)
+	h.addResources(h.mcpSvr)
+}
+
+// addTool 注册 tool，调用前需要通过鉴权策略以及用户组 tool 白名单的检查
+func (h *HTTPServer) addTool(mcpSvr *server.MCPServer, tool mcp.Tool, handler server.ToolHandlerFunc) {
+	mcpSvr.AddTool(tool, h.authorizer.wrap(tool, handler))
 }
 
 func (h *HTTPServer) addDefaultAccess(ws *restful.WebService) {
@@ -154,4 +208,15 @@ func (h *HTTPServer) addDefaultAccess(ws *restful.WebService) {
 	ws.Route(ws.POST(msgEp).To(func(req *restful.Request, rsp *restful.Response) {
 		h.sseSvr.ServeHTTP(rsp, req.Request)
 	}))
+
+	// MCP Streamable HTTP handler, 客户端携带 Mcp-Session-Id 在会话有效期内恢复会话
+	ws.Route(ws.GET(streamableEp).To(func(req *restful.Request, rsp *restful.Response) {
+		h.streamableSvr.ServeHTTP(rsp, req.Request)
+	}))
+	ws.Route(ws.POST(streamableEp).To(func(req *restful.Request, rsp *restful.Response) {
+		h.streamableSvr.ServeHTTP(rsp, req.Request)
+	}))
+	ws.Route(ws.DELETE(streamableEp).To(func(req *restful.Request, rsp *restful.Response) {
+		h.streamableSvr.ServeHTTP(rsp, req.Request)
+	}))
 }
@@ -0,0 +1,67 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package aimcp
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+func Test_parseRequestContext(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/ai/mcp/v1/sse", nil)
+	r.Header.Set(types.HeaderAuthorizationKey, "Bearer mock-token")
+	r.RemoteAddr = "127.0.0.1:8090"
+
+	ctx := parseRequestContext(context.Background(), r)
+	assert.Equal(t, "mock-token", utils.ParseAuthToken(ctx))
+	assert.Equal(t, "127.0.0.1", utils.ParseClientIP(ctx))
+	assert.Equal(t, "MCP:127.0.0.1:8090", utils.ParseOperator(ctx))
+}
+
+func Test_toQuery(t *testing.T) {
+	query := toQuery(map[string]interface{}{
+		"namespace": "default",
+		"name":      "",
+		"offset":    float64(10),
+		"healthy":   true,
+		"ignore":    "ignore",
+	}, "namespace", "name", "offset", "healthy")
+	assert.Equal(t, map[string]string{
+		"namespace": "default",
+		"offset":    "10",
+		"healthy":   "true",
+	}, query)
+}
+
+func Test_diffConfigContent(t *testing.T) {
+	diff, err := diffConfigContent("v1", "a: 1\nb: 2\n", "a: 1\nb: 3\n")
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "--- release/v1")
+	assert.Contains(t, diff, "-b: 2")
+	assert.Contains(t, diff, "+b: 3")
+
+	diff, err = diffConfigContent("", "a: 1\n", "a: 1\n")
+	assert.NoError(t, err)
+	assert.Empty(t, diff)
+}
@@ -0,0 +1,109 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package aimcp
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const sessionIDPrefix = "pole-mcp-"
+
+var (
+	errSessionNotFound = errors.New("mcp session not found or expired")
+)
+
+var _ server.SessionIdManager = (*sessionManager)(nil)
+
+type sessionEntry struct {
+	lastActive time.Time
+	terminated bool
+}
+
+// sessionManager 管理 Streamable HTTP 的会话，会话空闲时间未超过 ttl 时，客户端断线重连后可以携带原来的
+// Mcp-Session-Id 恢复会话。会话只保存在当前节点的内存中
+type sessionManager struct {
+	ttl time.Duration
+
+	lock     sync.Mutex
+	sessions map[string]*sessionEntry
+}
+
+func newSessionManager(ttl time.Duration) *sessionManager {
+	return &sessionManager{
+		ttl:      ttl,
+		sessions: map[string]*sessionEntry{},
+	}
+}
+
+// Generate 客户端 initialize 时创建新的会话
+func (m *sessionManager) Generate() string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	now := time.Now()
+	m.sweep(now)
+	sessionID := sessionIDPrefix + uuid.NewString()
+	m.sessions[sessionID] = &sessionEntry{lastActive: now}
+	return sessionID
+}
+
+// Validate 校验会话是否有效，有效时刷新会话的活跃时间
+func (m *sessionManager) Validate(sessionID string) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	entry, ok := m.sessions[sessionID]
+	if !ok {
+		return false, errSessionNotFound
+	}
+	now := time.Now()
+	if now.Sub(entry.lastActive) > m.ttl {
+		delete(m.sessions, sessionID)
+		return false, errSessionNotFound
+	}
+	if entry.terminated {
+		return true, nil
+	}
+	entry.lastActive = now
+	return false, nil
+}
+
+// Terminate 客户端主动结束会话，在 ttl 内保留记录，用于告知客户端会话已经结束
+func (m *sessionManager) Terminate(sessionID string) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	entry, ok := m.sessions[sessionID]
+	if !ok {
+		return false, errSessionNotFound
+	}
+	entry.terminated = true
+	entry.lastActive = time.Now()
+	return false, nil
+}
+
+// sweep 清理过期的会话，调用方需要持有锁
+func (m *sessionManager) sweep(now time.Time) {
+	for id, entry := range m.sessions {
+		if now.Sub(entry.lastActive) > m.ttl {
+			delete(m.sessions, id)
+		}
+	}
+}
@@ -1,8 +1,17 @@
 package aimcp
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+	httpcommon "github.com/pole-io/pole-server/plugin/apiserver/httpserver/utils"
 )
 
 func marshal(pb proto.Message) (string, error) {
@@ -14,3 +23,43 @@ func marshal(pb proto.Message) (string, error) {
 	}
 	return jsonStr, nil
 }
+
+// toQuery 将 tool 的入参转换为查询条件，只保留 keys 中的参数
+func toQuery(args map[string]interface{}, keys ...string) map[string]string {
+	query := make(map[string]string, len(keys))
+	for _, key := range keys {
+		switch v := args[key].(type) {
+		case string:
+			if v != "" {
+				query[key] = v
+			}
+		case float64:
+			query[key] = strconv.FormatInt(int64(v), 10)
+		case bool:
+			query[key] = strconv.FormatBool(v)
+		}
+	}
+	return query
+}
+
+// parseArrayArg 将数组类型的入参解析为 pb 对象列表
+func parseArrayArg[T proto.Message](args map[string]interface{}, key string, m func() T) ([]T, error) {
+	items, ok := args[key].([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("%s is empty", key)
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	return httpcommon.UnmarshalArray(json.NewDecoder(bytes.NewReader(data)), m)
+}
+
+// toolResult 将 OpenAPI 的应答转换为 tool 的执行结果
+func toolResult(rsp api.ResponseMessage) (*mcp.CallToolResult, error) {
+	if !api.IsSuccess(rsp) {
+		return mcp.NewToolResultError(rsp.GetInfo().GetValue()), nil
+	}
+	ret, err := httpcommon.MarshalPBJson(rsp)
+	return mcp.NewToolResultText(ret), err
+}
@@ -0,0 +1,100 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package discover
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful/v3"
+
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/plugin/apiserver/httpserver/docs"
+	httpcommon "github.com/pole-io/pole-server/plugin/apiserver/httpserver/utils"
+)
+
+// mcpServerList MCP Server 的查询结果
+type mcpServerList struct {
+	Amount  uint32                `json:"amount"`
+	Size    uint32                `json:"size"`
+	Servers []*svctypes.MCPServer `json:"servers"`
+}
+
+// addMCPServerAccess .
+func (h *HTTPServer) addMCPServerAccess(ws *restful.WebService) {
+	ws.Route(docs.EnrichRegisterMCPServerApiDocs(ws.POST("/mcp/servers").To(h.RegisterMCPServer)))
+	ws.Route(docs.EnrichDeregisterMCPServerApiDocs(ws.POST("/mcp/servers/delete").To(h.DeregisterMCPServer)))
+	ws.Route(docs.EnrichGetMCPServersApiDocs(ws.GET("/mcp/servers").To(h.GetMCPServers)))
+}
+
+// RegisterMCPServer 注册 MCP Server
+func (h *HTTPServer) RegisterMCPServer(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+	ctx := handler.ParseHeaderContext()
+	server := &svctypes.MCPServer{}
+	if err := httpcommon.ParseJsonBody(req, server); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	ret, err := h.namingServer.RegisterMCPServer(ctx, server)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// DeregisterMCPServer 反注册 MCP Server
+func (h *HTTPServer) DeregisterMCPServer(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+	ctx := handler.ParseHeaderContext()
+	server := &svctypes.MCPServer{}
+	if err := httpcommon.ParseJsonBody(req, server); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.namingServer.DeregisterMCPServer(ctx, server); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteEntity("ok")
+}
+
+// GetMCPServers 查询 MCP Server，支持通过 tool 参数查找提供某个 tool 的 MCP Server
+func (h *HTTPServer) GetMCPServers(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+	ctx := handler.ParseHeaderContext()
+	amount, servers, err := h.namingServer.GetMCPServers(ctx, httpcommon.ParseQueryParams(req))
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(&mcpServerList{
+		Amount:  amount,
+		Size:    uint32(len(servers)),
+		Servers: servers,
+	})
+}
@@ -119,6 +119,7 @@ func (h *HTTPServer) addDefaultReadAccess(ws *restful.WebService) {
 		ws.GET("/service/contracts").To(h.GetServiceContracts)))
 	ws.Route(docs.EnrichGetServiceContractsApiDocs(
 		ws.GET("/service/contract/versions").To(h.GetServiceContractVersions)))
+	ws.Route(docs.EnrichGetMCPServersApiDocs(ws.GET("/mcp/servers").To(h.GetMCPServers)))
 	ws.Route(ws.GET("/routings").To(h.GetRoutings))
 }
 
@@ -132,6 +133,7 @@ func (h *HTTPServer) addDefaultAccess(ws *restful.WebService) {
 	h.addRateLimitRuleAccess(ws)
 	h.addCircuitBreakerRuleAccess(ws)
 	h.addFaultDetectRuleAccess(ws)
+	h.addMCPServerAccess(ws)
 }
 
 // GetClientAccessServer get client access server
@@ -113,6 +113,44 @@ func EnrichSetLogOutputLevelApiDocs(r *restful.RouteBuilder) *restful.RouteBuild
 		}{})
 }
 
+func EnrichReloadConfigApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("重新加载配置文件").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Returns(0, "", admin.ReloadResult{})
+}
+
+func EnrichExportBackupApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("导出全部资源的备份文件(zip), 需要写权限, 用户以及用户组的 Token 不会导出").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags)
+}
+
+func EnrichRestoreBackupApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("从备份文件恢复资源, 请求体为导出的备份文件").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Param(restful.QueryParameter("conflict", "已存在资源的处理策略: skip, overwrite, fail").
+			DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("dryRun", "只统计将要执行的操作, 不写入数据").
+			DataType(typeNameBool).Required(false)).
+		Returns(0, "", admin.RestoreReport{})
+}
+
+func EnrichLivenessApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("存活探测").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Returns(0, "", admin.ProbeResult{})
+}
+
+func EnrichReadinessApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("就绪探测, 未就绪时返回 503 以及各检查项的结果").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Returns(0, "", admin.ProbeResult{})
+}
+
 func EnrichListLeaderElectionsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("获取选主的结果").
@@ -136,6 +174,33 @@ func EnrichGetCMDBInfoApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 		Returns(0, "", []svctypes.LocationView{})
 }
 
+func EnrichGetSelfPreservationApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询健康检查自我保护状态").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Returns(0, "", admin.SelfPreservationStatus{})
+}
+
+func EnrichListHealthPoliciesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询健康检查以及过期策略").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Returns(0, "", []svctypes.HealthPolicy{})
+}
+
+func EnrichUpsertHealthPolicyApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("创建或者更新健康检查以及过期策略").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Reads(svctypes.HealthPolicy{})
+}
+
+func EnrichDeleteHealthPolicyApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("删除健康检查以及过期策略").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags)
+}
+
 func EnrichGetReportClientsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("查询SDK实例列表").
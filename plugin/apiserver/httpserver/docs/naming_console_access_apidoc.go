@@ -28,6 +28,8 @@ import (
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 	"github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
 	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
 )
 
 var (
@@ -40,6 +42,7 @@ var (
 	circuitBreakerRulesApiTags = []string{"CircuitBreakerRules"}
 	faultDetectsApiTags        = []string{"FaultDetects"}
 	serviceContractApiTags     = []string{"ServiceContract"}
+	mcpServerApiTags           = []string{"MCPServer"}
 )
 
 const (
@@ -756,3 +759,33 @@ func EnrichDeleteServiceContractInterfacesApiDocs(r *restful.RouteBuilder) *rest
 	return r.Doc("删除服务契约接口描述").
 		Metadata(restfulspec.KeyOpenAPITags, serviceContractApiTags)
 }
+
+func EnrichRegisterMCPServerApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.Doc("注册 MCP Server").
+		Metadata(restfulspec.KeyOpenAPITags, mcpServerApiTags).
+		Reads(svctypes.MCPServer{}).
+		Returns(0, "", svctypes.MCPServer{})
+}
+
+func EnrichDeregisterMCPServerApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.Doc("反注册 MCP Server").
+		Metadata(restfulspec.KeyOpenAPITags, mcpServerApiTags).
+		Reads(svctypes.MCPServer{})
+}
+
+func EnrichGetMCPServersApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.Doc("查询 MCP Server").
+		Metadata(restfulspec.KeyOpenAPITags, mcpServerApiTags).
+		Param(restful.QueryParameter("namespace", "命名空间").
+			DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("name", "MCP Server 名称").
+			DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("tool", "提供的 tool 名称，支持 * 通配").
+			DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("healthy_only", "只返回存在健康接入点的 MCP Server").
+			DataType(typeNameBool).Required(false)).
+		Param(restful.QueryParameter("offset", "查询偏移量").
+			DataType(typeNameInteger).Required(false).DefaultValue("0")).
+		Param(restful.QueryParameter("limit", "查询条数，**最多查询100条**").
+			DataType(typeNameInteger).Required(false))
+}
@@ -47,6 +47,7 @@ import (
 	connlimit "github.com/pole-io/pole-server/pkg/common/conn/limit"
 	commonlog "github.com/pole-io/pole-server/pkg/common/log"
 	"github.com/pole-io/pole-server/pkg/common/secure"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 	"github.com/pole-io/pole-server/pkg/config"
 	"github.com/pole-io/pole-server/pkg/goverrule"
@@ -101,6 +102,8 @@ type HTTPServer struct {
 	configSvr   *confighttp.HTTPServer
 	aimcpSvr    *aimcp.HTTPServer
 	authSvr     *auth.HTTPServer
+	// mcpConfig MCP 接入的配置
+	mcpConfig *aimcp.Config
 
 	// apiserverSlots
 	apiserverSlots map[string]apiserver.Apiserver
@@ -161,6 +164,14 @@ func (h *HTTPServer) Initialize(ctx context.Context, option map[string]interface
 		httpcommon.InitProtoCache(option, types, discoverCacheConvert)
 	}
 
+	// MCP 接入配置
+	mcpRaw, _ := option["mcp"].(map[interface{}]interface{})
+	mcpConfig, err := aimcp.ParseConfig(mcpRaw)
+	if err != nil {
+		return err
+	}
+	h.mcpConfig = mcpConfig
+
 	// tls 配置信息
 	if raw, _ := option["tls"].(map[interface{}]interface{}); raw != nil {
 		tlsConfig, err := secure.ParseTLSConfig(raw)
@@ -249,7 +260,7 @@ func (h *HTTPServer) Run(errCh chan error) {
 		return
 	}
 
-	aimcpSvr, err := aimcp.NewServer(h.maintainServer, h.namespaceServer)
+	aimcpSvr, err := aimcp.NewServer(h.maintainServer, h.namespaceServer, userSvr, policySvr, h.mcpConfig)
 	if err != nil {
 		errCh <- err
 		return
@@ -382,6 +393,7 @@ func (h *HTTPServer) createRestfulContainer() (*restful.Container, error) {
 	// Incr container filter to respond to OPTIONS
 	wsContainer.Filter(wsContainer.OPTIONSFilter)
 
+	wsContainer.Filter(tracing.RestfulFilter("HTTP"))
 	wsContainer.Filter(h.process)
 
 	for name, apiConfig := range h.openAPI {
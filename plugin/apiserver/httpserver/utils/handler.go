@@ -43,6 +43,7 @@ import (
 	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
 	api "github.com/pole-io/pole-server/pkg/common/api/v1"
 	commonlog "github.com/pole-io/pole-server/pkg/common/log"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 	"github.com/pole-io/pole-server/pkg/common/valid"
 	"github.com/pole-io/pole-server/plugin/apiserver/httpserver/i18n"
@@ -106,7 +107,7 @@ func (h *Handler) ParseHeaderContext() context.Context {
 	token := h.Request.HeaderParameter("Polaris-Token")
 	authToken := h.Request.HeaderParameter(types.HeaderAuthorizationKey)
 
-	ctx := context.Background()
+	ctx := tracing.Detach(h.Request.Request.Context())
 	ctx = context.WithValue(ctx, types.StringContext("request-id"), requestID)
 	ctx = types.AppendRequestHeader(ctx, h.Request.Request.Header)
 	ctx = context.WithValue(ctx, types.ContextClientAddress, h.Request.Request.RemoteAddr)
@@ -33,6 +33,7 @@ type NacosConfig struct {
 	DefaultNamespace string            `mapstructure:defaultNamespace`
 	ServerService    string            `mapstructure:"serverService"`
 	ServerNamespace  string            `mapstructure:"serverNamespace"`
+	Migration        *MigrationConfig  `mapstructure:"migration"`
 }
 
 func loadNacosConfig(raw map[string]interface{}) (*NacosConfig, error) {
@@ -56,5 +57,10 @@ func loadNacosConfig(raw map[string]interface{}) (*NacosConfig, error) {
 		// grpc port is not set, use http port + 1000
 		defaultCfg.GrpcListenPort = defaultCfg.ListenPort + 1000
 	}
+	if defaultCfg.Migration != nil && defaultCfg.Migration.Enable {
+		if err := defaultCfg.Migration.init(); err != nil {
+			return nil, err
+		}
+	}
 	return defaultCfg, nil
 }
@@ -0,0 +1,525 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package nacosserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	cachetypes "github.com/pole-io/pole-server/apis/cache"
+	"github.com/pole-io/pole-server/apis/pkg/types"
+	conftypes "github.com/pole-io/pole-server/apis/pkg/types/config"
+	"github.com/pole-io/pole-server/pkg/config"
+	"github.com/pole-io/pole-server/pkg/service"
+	"github.com/pole-io/pole-server/pkg/service/migrate"
+	"github.com/pole-io/pole-server/plugin/apiserver/nacosserver/logger"
+	"github.com/pole-io/pole-server/plugin/apiserver/nacosserver/model"
+)
+
+const (
+	migrateSource = "nacos"
+
+	DefaultMigrationInterval = 30 * time.Second
+	DefaultMigrationTimeout  = 5 * time.Second
+
+	legacyPageSize = 100
+)
+
+var (
+	nacoslog = logger.GetNacosLog()
+
+	errLegacyNotFound = errors.New("resource not found in legacy nacos")
+)
+
+// MigrationConfig 从存量 nacos 集群迁移服务实例以及配置的配置
+type MigrationConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// Address 存量 nacos 的服务地址，例如 http://127.0.0.1:8848
+	Address  string `mapstructure:"address"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// Namespace 存量 nacos 的命名空间 ID，默认为 public
+	Namespace string `mapstructure:"namespace"`
+	// Groups 需要迁移的服务分组，默认为 DEFAULT_GROUP
+	Groups []string `mapstructure:"groups"`
+	// Configs 需要迁移的配置，nacos 的公开 API 不支持列出全部配置，因此需要显式指定
+	Configs []MigrationConfigItem `mapstructure:"configs"`
+	// TargetNamespace 存量数据镜像到 pole-server 的命名空间，默认按照 nacos 命名空间的映射规则转换
+	TargetNamespace string        `mapstructure:"targetNamespace"`
+	Interval        time.Duration `mapstructure:"interval"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+	// WriteBack 是否将直接注册到 pole-server 的实例回写到存量 nacos，方便尚未迁移的客户端发现
+	WriteBack bool `mapstructure:"writeBack"`
+}
+
+// MigrationConfigItem 需要迁移的 nacos 配置
+type MigrationConfigItem struct {
+	Group  string `mapstructure:"group"`
+	DataId string `mapstructure:"dataId"`
+}
+
+func (c *MigrationConfig) init() error {
+	if len(c.Address) == 0 {
+		return errors.New("nacos migration address is empty")
+	}
+	c.Address = strings.TrimSuffix(c.Address, "/")
+	if len(c.Namespace) == 0 {
+		c.Namespace = model.DefaultNacosNamespace
+	}
+	if len(c.Groups) == 0 {
+		c.Groups = []string{model.DefaultServiceGroup}
+	}
+	for i := range c.Configs {
+		if len(c.Configs[i].Group) == 0 {
+			c.Configs[i].Group = model.DefaultServiceGroup
+		}
+	}
+	if len(c.TargetNamespace) == 0 {
+		c.TargetNamespace = model.ToPolarisNamespace(c.Namespace)
+	}
+	if c.Interval <= 0 {
+		c.Interval = DefaultMigrationInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultMigrationTimeout
+	}
+	return nil
+}
+
+// legacyMigrator 周期性拉取存量 nacos 的服务实例以及配置镜像到 pole-server，并按需回写 pole-server 的实例
+type legacyMigrator struct {
+	cfg       *MigrationConfig
+	client    *legacyNacosClient
+	mirror    *migrate.Mirror
+	configSvr config.ConfigCenterServer
+	fileCache cachetypes.ConfigFileCache
+	isLeader  func() bool
+}
+
+func newLegacyMigrator(cfg *MigrationConfig, discoverSvr service.DiscoverServer,
+	configSvr config.ConfigCenterServer, isLeader func() bool) *legacyMigrator {
+	return &legacyMigrator{
+		cfg:       cfg,
+		client:    newLegacyNacosClient(cfg),
+		mirror:    migrate.NewMirror(discoverSvr, cfg.TargetNamespace, migrateSource),
+		configSvr: configSvr,
+		fileCache: discoverSvr.Cache().ConfigFile(),
+		isLeader:  isLeader,
+	}
+}
+
+func (m *legacyMigrator) run(ctx context.Context) {
+	nacoslog.Info("[NACOS-MIGRATE] start migrating from legacy nacos", zap.String("address", m.cfg.Address),
+		zap.String("namespace", m.cfg.Namespace), zap.String("target", m.cfg.TargetNamespace))
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !m.isLeader() {
+				continue
+			}
+			if err := m.syncOnce(ctx); err != nil {
+				nacoslog.Error("[NACOS-MIGRATE] fail to migrate from legacy nacos",
+					zap.String("address", m.cfg.Address), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (m *legacyMigrator) syncOnce(ctx context.Context) error {
+	services, err := m.fetchServices(ctx)
+	if err != nil {
+		return err
+	}
+	specs := make([]*apiservice.Instance, 0, 32)
+	for _, svc := range services {
+		for _, ins := range svc.Hosts {
+			if migrate.IsWriteBack(ins.Metadata) {
+				continue
+			}
+			if ins.Metadata == nil {
+				ins.Metadata = map[string]string{}
+			}
+			ins.ClusterName = defaultString(ins.ClusterName, model.DefaultServiceClusterName)
+			specs = append(specs, model.PrepareSpecInstance(m.cfg.TargetNamespace,
+				svc.GroupName+model.DefaultNacosGroupConnectStr+svc.Name, ins))
+		}
+	}
+
+	errs := []error{m.mirror.Sync(ctx, specs)}
+	if m.cfg.WriteBack {
+		errs = append(errs, m.writeBack(ctx, services))
+	}
+	errs = append(errs, m.syncConfigs(ctx))
+	return errors.Join(errs...)
+}
+
+// fetchServices 拉取存量 nacos 中指定分组下的全部服务以及实例
+func (m *legacyMigrator) fetchServices(ctx context.Context) ([]*model.ServiceInfo, error) {
+	ret := make([]*model.ServiceInfo, 0, 16)
+	for _, group := range m.cfg.Groups {
+		names, err := m.client.listServices(ctx, group)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			svc, err := m.client.listInstances(ctx, group, name)
+			if err != nil {
+				return nil, err
+			}
+			svc.Name = name
+			svc.GroupName = group
+			ret = append(ret, svc)
+		}
+	}
+	return ret, nil
+}
+
+// writeBack 将直接注册到 pole-server 的实例以持久化实例的方式回写到存量 nacos，无需代替客户端发送心跳；
+// 回写的实例带有标记，拉取时会被跳过，避免循环同步
+func (m *legacyMigrator) writeBack(ctx context.Context, services []*model.ServiceInfo) error {
+	groups := map[string]struct{}{}
+	for _, group := range m.cfg.Groups {
+		groups[group] = struct{}{}
+	}
+	written := map[string]*model.Instance{}
+	for _, svc := range services {
+		for _, ins := range svc.Hosts {
+			if migrate.IsWriteBack(ins.Metadata) {
+				ins.ServiceName = svc.GroupName + model.DefaultNacosGroupConnectStr + svc.Name
+				written[writeBackKey(ins)] = ins
+			}
+		}
+	}
+
+	var errs []error
+	for svcName, instances := range m.mirror.NativeInstances() {
+		group := model.GetGroupName(svcName)
+		if _, ok := groups[group]; !ok {
+			continue
+		}
+		for _, item := range instances {
+			ins := &model.Instance{}
+			ins.FromSpecInstance(item)
+			ins.ServiceName = group + model.DefaultNacosGroupConnectStr + model.GetServiceName(svcName)
+			ins.ClusterName = defaultString(ins.ClusterName, model.DefaultServiceClusterName)
+			ins.Ephemeral = false
+			metadata := map[string]string{}
+			for k, v := range ins.Metadata {
+				if !strings.HasPrefix(k, "internal-") {
+					metadata[k] = v
+				}
+			}
+			metadata[types.MetaKeyMigrateWriteBack] = "true"
+			ins.Metadata = metadata
+
+			key := writeBackKey(ins)
+			exist, ok := written[key]
+			delete(written, key)
+			if ok && sameLegacyInstance(exist, ins) {
+				continue
+			}
+			if err := m.client.registerInstance(ctx, ins, ok); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for _, ins := range written {
+		if err := m.client.deregisterInstance(ctx, ins); err != nil && !errors.Is(err, errLegacyNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// syncConfigs 将存量 nacos 中指定的配置发布到 pole-server，内容未发生变化时不会重复发布
+func (m *legacyMigrator) syncConfigs(ctx context.Context) error {
+	var errs []error
+	for _, item := range m.cfg.Configs {
+		content, format, err := m.client.getConfig(ctx, item.Group, item.DataId)
+		if errors.Is(err, errLegacyNotFound) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		release := m.fileCache.GetActiveRelease(m.cfg.TargetNamespace, item.Group, item.DataId)
+		if release != nil && release.Content == content {
+			continue
+		}
+		file := &model.ConfigFile{
+			ConfigFileBase: model.ConfigFileBase{
+				Namespace: m.cfg.TargetNamespace,
+				Group:     item.Group,
+				DataId:    item.DataId,
+			},
+			Content: content,
+			Type:    defaultString(format, conftypes.FileFormatText),
+		}
+		spec := file.ToSpecConfigFile()
+		spec.Tags = append(spec.Tags, &config_manage.ConfigFileTag{
+			Key:   wrapperspb.String(types.MetaKeyMigrateFrom),
+			Value: wrapperspb.String(migrateSource),
+		})
+		resp := m.configSvr.UpsertAndReleaseConfigFile(ctx, spec)
+		if resp.GetCode().GetValue() != uint32(apimodel.Code_ExecuteSuccess) {
+			errs = append(errs, fmt.Errorf("publish config %s/%s: %s", item.Group, item.DataId,
+				resp.GetInfo().GetValue()))
+			continue
+		}
+		nacoslog.Info("[NACOS-MIGRATE] migrate config from legacy nacos", zap.String("group", item.Group),
+			zap.String("dataId", item.DataId), zap.String("namespace", m.cfg.TargetNamespace))
+	}
+	return errors.Join(errs...)
+}
+
+func writeBackKey(ins *model.Instance) string {
+	return fmt.Sprintf("%s#%s#%d", ins.ServiceName, ins.IP, ins.Port)
+}
+
+func sameLegacyInstance(a, b *model.Instance) bool {
+	if a.Weight != b.Weight || a.Healthy != b.Healthy || a.Enabled != b.Enabled ||
+		a.ClusterName != b.ClusterName || len(a.Metadata) != len(b.Metadata) {
+		return false
+	}
+	for k, v := range a.Metadata {
+		if b.Metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func defaultString(val, defaultVal string) string {
+	if len(val) == 0 {
+		return defaultVal
+	}
+	return val
+}
+
+// legacyNacosClient 基于 nacos v1 的 OpenAPI 访问存量 nacos 集群
+type legacyNacosClient struct {
+	cfg    *MigrationConfig
+	client *http.Client
+
+	lock        sync.Mutex
+	accessToken string
+	expireAt    time.Time
+}
+
+func newLegacyNacosClient(cfg *MigrationConfig) *legacyNacosClient {
+	return &legacyNacosClient{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (c *legacyNacosClient) listServices(ctx context.Context, group string) ([]string, error) {
+	ret := make([]string, 0, legacyPageSize)
+	for pageNo := 1; ; pageNo++ {
+		params := c.namingParams()
+		params.Set(model.ParamGroupName, group)
+		params.Set(model.ParamPageNo, strconv.Itoa(pageNo))
+		params.Set(model.ParamPageSize, strconv.Itoa(legacyPageSize))
+		body, _, err := c.do(ctx, http.MethodGet, "/nacos/v1/ns/service/list", params)
+		if err != nil {
+			return nil, err
+		}
+		resp := struct {
+			Count int      `json:"count"`
+			Doms  []string `json:"doms"`
+		}{}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, err
+		}
+		ret = append(ret, resp.Doms...)
+		if len(resp.Doms) < legacyPageSize || len(ret) >= resp.Count {
+			return ret, nil
+		}
+	}
+}
+
+func (c *legacyNacosClient) listInstances(ctx context.Context, group, name string) (*model.ServiceInfo, error) {
+	params := c.namingParams()
+	params.Set(model.ParamServiceName, group+model.DefaultNacosGroupConnectStr+name)
+	params.Set("healthyOnly", "false")
+	body, _, err := c.do(ctx, http.MethodGet, "/nacos/v1/ns/instance/list", params)
+	if err != nil {
+		return nil, err
+	}
+	resp := &model.ServiceInfo{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// registerInstance 注册或者更新存量 nacos 中的持久化实例
+func (c *legacyNacosClient) registerInstance(ctx context.Context, ins *model.Instance, update bool) error {
+	metadata, err := json.Marshal(ins.Metadata)
+	if err != nil {
+		return err
+	}
+	params := c.instanceParams(ins)
+	params.Set(model.ParamInstanceWeight, strconv.FormatFloat(ins.Weight, 'f', -1, 64))
+	params.Set(model.ParamInstanceHealthy, strconv.FormatBool(ins.Healthy))
+	params.Set(model.ParamInstanceEnabled, strconv.FormatBool(ins.Enabled))
+	params.Set(model.ParamInstanceMetadata, string(metadata))
+	method := http.MethodPost
+	if update {
+		method = http.MethodPut
+	}
+	_, _, err = c.do(ctx, method, "/nacos/v1/ns/instance", params)
+	return err
+}
+
+func (c *legacyNacosClient) deregisterInstance(ctx context.Context, ins *model.Instance) error {
+	_, _, err := c.do(ctx, http.MethodDelete, "/nacos/v1/ns/instance", c.instanceParams(ins))
+	return err
+}
+
+// getConfig 查询存量 nacos 中的配置，返回配置内容以及配置格式
+func (c *legacyNacosClient) getConfig(ctx context.Context, group, dataId string) (string, string, error) {
+	params := url.Values{}
+	params.Set("dataId", dataId)
+	params.Set("group", group)
+	if c.cfg.Namespace != model.DefaultNacosNamespace {
+		params.Set(model.ParamTenant, c.cfg.Namespace)
+	}
+	body, header, err := c.do(ctx, http.MethodGet, "/nacos/v1/cs/configs", params)
+	if err != nil {
+		return "", "", err
+	}
+	return string(body), header.Get("Config-Type"), nil
+}
+
+func (c *legacyNacosClient) namingParams() url.Values {
+	params := url.Values{}
+	params.Set(model.ParamNamespaceID, c.cfg.Namespace)
+	return params
+}
+
+func (c *legacyNacosClient) instanceParams(ins *model.Instance) url.Values {
+	params := c.namingParams()
+	params.Set(model.ParamServiceName, ins.ServiceName)
+	params.Set(model.ParamGroupName, model.GetGroupName(ins.ServiceName))
+	params.Set(model.ParamClusterName, ins.ClusterName)
+	params.Set(model.ParamInstanceIP, ins.IP)
+	params.Set(model.ParamInstancePort, strconv.Itoa(int(ins.Port)))
+	params.Set(model.ParamInstanceEphemeral, strconv.FormatBool(ins.Ephemeral))
+	return params
+}
+
+// do 发起请求，nacos 的 OpenAPI 同时支持从 query 中读取参数，这里统一将参数放在 query 中
+func (c *legacyNacosClient) do(ctx context.Context, method, path string,
+	params url.Values) ([]byte, http.Header, error) {
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(token) != 0 {
+		params.Set(model.NacosClientAuthHeader, token)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.Address+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rsp.StatusCode == http.StatusNotFound {
+		return nil, nil, errLegacyNotFound
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%s %s: status %d, %s", method, path, rsp.StatusCode, string(body))
+	}
+	return body, rsp.Header, nil
+}
+
+// token 存量 nacos 开启鉴权时需要先登录获取 accessToken，并在 token 过期前重新登录
+func (c *legacyNacosClient) token(ctx context.Context) (string, error) {
+	if len(c.cfg.Username) == 0 {
+		return "", nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.accessToken) != 0 && time.Now().Before(c.expireAt) {
+		return c.accessToken, nil
+	}
+
+	params := url.Values{}
+	params.Set("username", c.cfg.Username)
+	params.Set("password", c.cfg.Password)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.cfg.Address+"/nacos/v1/auth/login?"+params.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return "", err
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("login legacy nacos: status %d, %s", rsp.StatusCode, string(body))
+	}
+	resp := struct {
+		AccessToken string `json:"accessToken"`
+		TokenTtl    int64  `json:"tokenTtl"`
+	}{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", err
+	}
+	c.accessToken = resp.AccessToken
+	// 提前一半的有效期刷新 token
+	c.expireAt = time.Now().Add(time.Duration(resp.TokenTtl) * time.Second / 2)
+	return c.accessToken, nil
+}
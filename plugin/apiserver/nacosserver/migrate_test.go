@@ -0,0 +1,193 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package nacosserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+	"github.com/pole-io/pole-server/pkg/service/migrate"
+	"github.com/pole-io/pole-server/plugin/apiserver/nacosserver/model"
+)
+
+func TestLoadMigrationConfig(t *testing.T) {
+	cfg, err := loadNacosConfig(map[string]interface{}{
+		"migration": map[string]interface{}{
+			"enable":   true,
+			"address":  "http://127.0.0.1:8848/",
+			"interval": "10s",
+			"configs": []interface{}{
+				map[string]interface{}{"dataId": "app.yaml"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	migration := cfg.Migration
+	assert.Equal(t, "http://127.0.0.1:8848", migration.Address)
+	assert.Equal(t, model.DefaultNacosNamespace, migration.Namespace)
+	assert.Equal(t, model.ToPolarisNamespace(model.DefaultNacosNamespace), migration.TargetNamespace)
+	assert.Equal(t, []string{model.DefaultServiceGroup}, migration.Groups)
+	assert.Equal(t, model.DefaultServiceGroup, migration.Configs[0].Group)
+	assert.Equal(t, 10*time.Second, migration.Interval)
+	assert.Equal(t, DefaultMigrationTimeout, migration.Timeout)
+
+	_, err = loadNacosConfig(map[string]interface{}{
+		"migration": map[string]interface{}{"enable": true},
+	})
+	assert.Error(t, err)
+}
+
+// fakeLegacyNacos 模拟存量 nacos 的 v1 OpenAPI，返回的数据结构与 nacos apiserver 保持一致
+type fakeLegacyNacos struct {
+	lock     sync.Mutex
+	requests []string
+	params   []map[string]string
+}
+
+func (f *fakeLegacyNacos) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	query := r.URL.Query()
+	params := map[string]string{}
+	for k := range query {
+		params[k] = query.Get(k)
+	}
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	f.params = append(f.params, params)
+
+	if r.URL.Path != "/nacos/v1/auth/login" && query.Get(model.NacosClientAuthHeader) != "token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	switch r.Method + " " + r.URL.Path {
+	case "POST /nacos/v1/auth/login":
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"accessToken": "token", "tokenTtl": 120})
+	case "GET /nacos/v1/ns/service/list":
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"count": 1, "doms": []string{"svc"}})
+	case "GET /nacos/v1/ns/instance/list":
+		_ = json.NewEncoder(w).Encode(&model.ServiceInfo{
+			Name:      query.Get(model.ParamServiceName),
+			GroupName: model.DefaultServiceGroup,
+			Hosts: []*model.Instance{
+				{IP: "127.0.0.1", Port: 8080, Weight: 1, Healthy: true, Enabled: true,
+					Metadata: map[string]string{"k": "v"}},
+				{IP: "127.0.0.2", Port: 8080, Weight: 1, Healthy: true, Enabled: true,
+					Metadata: map[string]string{types.MetaKeyMigrateWriteBack: "true"}},
+			},
+		})
+	case "GET /nacos/v1/cs/configs":
+		if query.Get("dataId") != "app.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Config-Type", "yaml")
+		_, _ = w.Write([]byte("key: value"))
+	default:
+		_, _ = w.Write([]byte("ok"))
+	}
+}
+
+func TestLegacyNacosClient(t *testing.T) {
+	legacy := &fakeLegacyNacos{}
+	server := httptest.NewServer(legacy)
+	defer server.Close()
+
+	cfg := &MigrationConfig{
+		Enable:   true,
+		Address:  server.URL,
+		Username: "nacos",
+		Password: "nacos",
+	}
+	assert.NoError(t, cfg.init())
+	client := newLegacyNacosClient(cfg)
+	ctx := context.Background()
+
+	names, err := client.listServices(ctx, model.DefaultServiceGroup)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"svc"}, names)
+
+	svc, err := client.listInstances(ctx, model.DefaultServiceGroup, "svc")
+	assert.NoError(t, err)
+	assert.Len(t, svc.Hosts, 2)
+	assert.False(t, migrate.IsWriteBack(svc.Hosts[0].Metadata))
+	assert.True(t, migrate.IsWriteBack(svc.Hosts[1].Metadata))
+
+	spec := model.PrepareSpecInstance("default", "DEFAULT_GROUP@@svc", svc.Hosts[0])
+	assert.Equal(t, "svc", spec.GetService().GetValue())
+	assert.Equal(t, "127.0.0.1", spec.GetHost().GetValue())
+	assert.Equal(t, "v", spec.GetMetadata()["k"])
+
+	content, format, err := client.getConfig(ctx, model.DefaultServiceGroup, "app.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "key: value", content)
+	assert.Equal(t, "yaml", format)
+	_, _, err = client.getConfig(ctx, model.DefaultServiceGroup, "missing.yaml")
+	assert.ErrorIs(t, err, errLegacyNotFound)
+
+	ins := &model.Instance{
+		IP:          "127.0.0.3",
+		Port:        8080,
+		Weight:      1,
+		Healthy:     true,
+		Enabled:     true,
+		ClusterName: model.DefaultServiceClusterName,
+		ServiceName: "DEFAULT_GROUP@@svc",
+		Metadata:    map[string]string{types.MetaKeyMigrateWriteBack: "true"},
+	}
+	assert.NoError(t, client.registerInstance(ctx, ins, false))
+	assert.NoError(t, client.registerInstance(ctx, ins, true))
+	assert.NoError(t, client.deregisterInstance(ctx, ins))
+
+	assert.Equal(t, []string{
+		"POST /nacos/v1/auth/login",
+		"GET /nacos/v1/ns/service/list",
+		"GET /nacos/v1/ns/instance/list",
+		"GET /nacos/v1/cs/configs",
+		"GET /nacos/v1/cs/configs",
+		"POST /nacos/v1/ns/instance",
+		"PUT /nacos/v1/ns/instance",
+		"DELETE /nacos/v1/ns/instance",
+	}, legacy.requests)
+	register := legacy.params[5]
+	assert.Equal(t, "127.0.0.3", register[model.ParamInstanceIP])
+	assert.Equal(t, "false", register[model.ParamInstanceEphemeral])
+	assert.Equal(t, model.DefaultServiceGroup, register[model.ParamGroupName])
+	assert.JSONEq(t, `{"pole-migrate-writeback":"true"}`, register[model.ParamInstanceMetadata])
+}
+
+func TestSameLegacyInstance(t *testing.T) {
+	a := &model.Instance{IP: "127.0.0.1", Port: 8080, Weight: 1, Healthy: true, Enabled: true,
+		ServiceName: "DEFAULT_GROUP@@svc", Metadata: map[string]string{"k": "v"}}
+	b := a.DeepClone()
+	assert.True(t, sameLegacyInstance(a, b))
+	assert.Equal(t, writeBackKey(a), writeBackKey(b))
+
+	b.Enabled = false
+	assert.False(t, sameLegacyInstance(a, b))
+	b = a.DeepClone()
+	b.Metadata["k"] = "v2"
+	assert.False(t, sameLegacyInstance(a, b))
+}
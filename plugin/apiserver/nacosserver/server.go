@@ -20,6 +20,7 @@ package nacosserver
 import (
 	"context"
 	"sync"
+	"time"
 
 	authapi "github.com/pole-io/pole-server/apis/access_control/auth"
 	"github.com/pole-io/pole-server/apis/apiserver"
@@ -29,6 +30,7 @@ import (
 	"github.com/pole-io/pole-server/pkg/namespace"
 	"github.com/pole-io/pole-server/pkg/service"
 	"github.com/pole-io/pole-server/pkg/service/healthcheck"
+	"github.com/pole-io/pole-server/pkg/service/migrate"
 	"github.com/pole-io/pole-server/plugin/apiserver/nacosserver/core"
 	"github.com/pole-io/pole-server/plugin/apiserver/nacosserver/model"
 	nacosv1 "github.com/pole-io/pole-server/plugin/apiserver/nacosserver/v1"
@@ -62,6 +64,9 @@ type NacosServer struct {
 
 	v1Svr *nacosv1.NacosV1Server
 	v2Svr *nacosv2.NacosV2Server
+
+	migration     *MigrationConfig
+	cancelMigrate context.CancelFunc
 }
 
 // GetProtocol API协议名
@@ -100,6 +105,7 @@ func (n *NacosServer) Initialize(ctx context.Context, option map[string]interfac
 		}
 	}
 	model.ConvertPolarisNamespaceVal = cfg.DefaultNamespace
+	n.migration = cfg.Migration
 	return nil
 }
 
@@ -110,6 +116,17 @@ func (n *NacosServer) Run(errCh chan error) {
 		return
 	}
 
+	if n.migration != nil && n.migration.Enable {
+		isLeader, err := migrate.StartElection()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		n.cancelMigrate = cancel
+		go newLegacyMigrator(n.migration, n.originDiscoverSvr, n.originConfigSvr, isLeader).run(ctx)
+	}
+
 	wg := &sync.WaitGroup{}
 	wg.Add(2)
 
@@ -223,6 +240,9 @@ func (n *NacosServer) prepareRun() error {
 
 // Stop 停止API端口监听
 func (n *NacosServer) Stop() {
+	if n.cancelMigrate != nil {
+		n.cancelMigrate()
+	}
 	if n.v1Svr != nil {
 		n.v1Svr.Stop()
 	}
@@ -231,6 +251,13 @@ func (n *NacosServer) Stop() {
 	}
 }
 
+// Drain 引导 nacos-client 的长连接迁移到其他节点
+func (n *NacosServer) Drain(ctx context.Context, window time.Duration) {
+	if n.v2Svr != nil {
+		n.v2Svr.Drain(ctx, window)
+	}
+}
+
 // Restart 重启API
 func (n *NacosServer) Restart(option map[string]interface{}, api map[string]apiserver.APIConfig,
 	errCh chan error) error {
@@ -62,7 +62,7 @@ func (n *DiscoverServer) handleUpdate(ctx context.Context, namespace, serviceNam
 		specIns.Id = wrapperspb.String(insId)
 	}
 	svr := n.discoverSvr.(*service.Server)
-	saveIns, err := svr.Store().GetInstance(specIns.GetId().GetValue())
+	saveIns, err := svr.Store().GetInstance(ctx, specIns.GetId().GetValue())
 	if err != nil {
 		return &model.NacosError{
 			ErrCode: int32(model.ExceptionCode_ServerError),
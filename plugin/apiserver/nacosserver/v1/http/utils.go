@@ -31,6 +31,7 @@ import (
 	restful "github.com/emicklei/go-restful/v3"
 
 	"github.com/pole-io/pole-server/apis/pkg/types"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 	"github.com/pole-io/pole-server/pkg/common/valid"
 	"github.com/pole-io/pole-server/plugin/apiserver/nacosserver/model"
@@ -69,7 +70,7 @@ type Handler struct {
 }
 
 func (h *Handler) postParseMessage(requestID string) (context.Context, error) {
-	ctx := context.Background()
+	ctx := tracing.Detach(h.Request.Request.Context())
 	ctx = context.WithValue(ctx, types.StringContext("request-id"), requestID)
 
 	var operator string
@@ -86,7 +87,7 @@ func (h *Handler) postParseMessage(requestID string) (context.Context, error) {
 func (h *Handler) ParseHeaderContext() context.Context {
 	requestID := h.Request.HeaderParameter("Request-Id")
 
-	ctx := context.Background()
+	ctx := tracing.Detach(h.Request.Request.Context())
 	if requestID == "" {
 		requestID = utils.NewUUID()
 	}
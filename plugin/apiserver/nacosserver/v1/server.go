@@ -42,6 +42,7 @@ import (
 	connlimit "github.com/pole-io/pole-server/pkg/common/conn/limit"
 	"github.com/pole-io/pole-server/pkg/common/log"
 	"github.com/pole-io/pole-server/pkg/common/secure"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 	"github.com/pole-io/pole-server/plugin/apiserver/nacosserver/core"
 	"github.com/pole-io/pole-server/plugin/apiserver/nacosserver/model"
@@ -205,6 +206,7 @@ func (h *NacosV1Server) createRestfulContainer() (*restful.Container, error) {
 
 	// Incr container filter to respond to OPTIONS
 	wsContainer.Filter(wsContainer.OPTIONSFilter)
+	wsContainer.Filter(tracing.RestfulFilter("NACOS-V1"))
 	wsContainer.Filter(h.process)
 
 	clientSvc, err := h.discoverSvr.GetClientServer()
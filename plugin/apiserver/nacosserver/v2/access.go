@@ -27,6 +27,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/pole-io/pole-server/apis/pkg/types"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 	nacosmodel "github.com/pole-io/pole-server/plugin/apiserver/nacosserver/model"
 	nacospb "github.com/pole-io/pole-server/plugin/apiserver/nacosserver/v2/pb"
@@ -68,6 +69,7 @@ func (h *NacosV2Server) Request(ctx context.Context, payload *nacospb.Payload) (
 	}
 	nacoslog.Debug("[NACOS-V2] handler client request", zap.String("conn-id", remote.ValueConnID(ctx)),
 		utils.ZapRequestID(msg.GetRequestId()), zap.String("type", msg.GetRequestType()))
+	tracing.SetName(ctx, "NACOS-V2 "+msg.GetRequestType())
 	connMeta := remote.ValueConnMeta(ctx)
 
 	startTime := time.Now()
@@ -272,7 +272,7 @@ func (c *Checker) realCheck() {
 		nacoslog.Info("[NACOS-V2][Checker] batch set instance health_status to unhealthy",
 			zap.Any("instance-ids", ids))
 		if err := svr.Store().
-			BatchSetInstanceHealthStatus(ids, utils.StatusBoolToInt(false), utils.NewUUID()); err != nil {
+			BatchSetInstanceHealthStatus(context.Background(), ids, utils.StatusBoolToInt(false), utils.NewUUID()); err != nil {
 			nacoslog.Error("[NACOS-V2][Checker] batch set instance health_status to unhealthy",
 				zap.Any("instance-ids", ids), zap.Error(err))
 		}
@@ -286,7 +286,7 @@ func (c *Checker) realCheck() {
 		nacoslog.Info("[NACOS-V2][Checker] batch set instance health_status to healty",
 			zap.Any("instance-ids", ids))
 		if err := svr.Store().
-			BatchSetInstanceHealthStatus(ids, utils.StatusBoolToInt(true), utils.NewUUID()); err != nil {
+			BatchSetInstanceHealthStatus(context.Background(), ids, utils.StatusBoolToInt(true), utils.NewUUID()); err != nil {
 			nacoslog.Error("[NACOS-V2][Checker] batch set instance health_status to healty",
 				zap.Any("instance-ids", ids), zap.Error(err))
 		}
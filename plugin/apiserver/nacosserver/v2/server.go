@@ -39,9 +39,11 @@ import (
 	"github.com/pole-io/pole-server/apis/pkg/types"
 	"github.com/pole-io/pole-server/apis/pkg/types/metrics"
 	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+	conndrain "github.com/pole-io/pole-server/pkg/common/conn/drain"
 	connhook "github.com/pole-io/pole-server/pkg/common/conn/hook"
 	connlimit "github.com/pole-io/pole-server/pkg/common/conn/limit"
 	"github.com/pole-io/pole-server/pkg/common/secure"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 	"github.com/pole-io/pole-server/pkg/common/utils"
 	"github.com/pole-io/pole-server/plugin/apiserver/nacosserver/core"
 	v1 "github.com/pole-io/pole-server/plugin/apiserver/nacosserver/v1"
@@ -93,6 +95,7 @@ type NacosV2Server struct {
 	protocol        string
 
 	server     *grpc.Server
+	tracker    *conndrain.StreamTracker
 	ratelimit  ratelimit.Ratelimit
 	OpenMethod map[string]bool
 	whitelist  whitelist.Whitelist
@@ -143,6 +146,7 @@ func (h *NacosV2Server) Initialize(ctx context.Context, option map[string]interf
 		nacoslog.Infof("[API-Server] %s server open the ratelimit", h.protocol)
 		h.ratelimit = ratelimit
 	}
+	h.tracker = conndrain.NewStreamTracker()
 	h.initHandlers()
 	return nil
 }
@@ -221,8 +225,9 @@ func (h *NacosV2Server) Run(errCh chan error) {
 
 	// 设置 grpc server options
 	opts := []grpc.ServerOption{
-		grpc.UnaryInterceptor(h.unaryInterceptor),
-		grpc.StreamInterceptor(h.streamInterceptor),
+		grpc.ChainUnaryInterceptor(tracing.UnaryServerInterceptor("NACOS-V2"), h.unaryInterceptor),
+		grpc.ChainStreamInterceptor(h.tracker.StreamServerInterceptor(), tracing.StreamServerInterceptor("NACOS-V2"),
+			h.streamInterceptor),
 		grpc.StatsHandler(h.connectionManager),
 	}
 	if creds != nil {
@@ -359,6 +364,45 @@ func (h *NacosV2Server) Stop() {
 	}
 }
 
+// Drain 拒绝新的双向流, 在 window 时间内分批向存量客户端发送 ConnectResetRequest,
+// 引导 nacos-client 切换到其他节点, 最后断开仍未迁移的双向流
+func (h *NacosV2Server) Drain(ctx context.Context, window time.Duration) {
+	if h.tracker == nil {
+		return
+	}
+	clients := make([]*remote.Client, 0, 16)
+	for _, client := range h.connectionManager.ListConnections() {
+		clients = append(clients, client)
+	}
+	nacoslog.Info("[API-Server][NACOS-V2] begin drain connections", zap.Int("count", len(clients)))
+
+	// 先拒绝新的双向流, 避免收到重置请求的客户端重新连回本节点
+	h.tracker.Reject()
+	conndrain.Spread(ctx, len(clients), window, func(i int) {
+		h.sendConnectReset(clients[i])
+	})
+	h.tracker.Drain(ctx, 0)
+}
+
+func (h *NacosV2Server) sendConnectReset(client *remote.Client) {
+	stream, ok := client.LoadStream()
+	if !ok {
+		return
+	}
+	req := nacospb.NewConnectResetRequest()
+	req.RequestId = utils.NewUUID()
+	payload, err := remote.MarshalPayload(req)
+	if err != nil {
+		nacoslog.Error("[API-Server][NACOS-V2] marshal ConnectResetRequest", zap.String("conn-id", client.ID),
+			zap.Error(err))
+		return
+	}
+	if err := stream.SendMsg(payload); err != nil {
+		nacoslog.Error("[API-Server][NACOS-V2] send ConnectResetRequest", zap.String("conn-id", client.ID),
+			zap.Error(err))
+	}
+}
+
 // EnterRatelimit api ratelimit
 func (h *NacosV2Server) EnterRatelimit(ip string, method string) uint32 {
 	if h.ratelimit == nil {
@@ -430,7 +474,7 @@ func (h *NacosV2Server) ConvertContext(ctx context.Context) context.Context {
 		}
 	}
 
-	ctx = context.Background()
+	ctx = tracing.Detach(ctx)
 	ctx = context.WithValue(ctx, types.ContextGrpcHeader, meta)
 	ctx = context.WithValue(ctx, types.StringContext("request-id"), requestID)
 	ctx = context.WithValue(ctx, types.ContextClientAddress, address)
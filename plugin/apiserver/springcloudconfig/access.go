@@ -0,0 +1,146 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package springcloudconfig
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+	"go.uber.org/zap"
+
+	"github.com/pole-io/pole-server/apis/pkg/types"
+)
+
+const (
+	// attrAPI 请求命中的接口名称，用于接口调用统计
+	attrAPI = "springcloud-api"
+
+	operationEnvironment      = "GET:/{application}/{profile}/{label}"
+	operationEnvironmentNoLbl = "GET:/{application}/{profile}"
+	operationFile             = "GET:/{label}/{application}-{profile}.{ext}"
+	operationFileNoLbl        = "GET:/{application}-{profile}.{ext}"
+)
+
+// GetSpringCloudConfigServer 注册 Spring Cloud Config Server 协议的路由
+func (h *SpringCloudConfigServer) GetSpringCloudConfigServer() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/{first}").To(h.getFirst))
+	ws.Route(ws.GET("/{first}/{second}").To(h.getSecond))
+	ws.Route(ws.GET("/{application}/{profile}/{label}").To(h.getEnvironment))
+	return ws
+}
+
+// getFirst 处理 /{application}-{profile}.{ext}
+func (h *SpringCloudConfigServer) getFirst(req *restful.Request, rsp *restful.Response) {
+	application, profile, format, ok := parseFileRequest(req.PathParameter("first"))
+	if !ok {
+		_ = rsp.WriteErrorString(http.StatusNotFound, "not found")
+		return
+	}
+	req.SetAttribute(attrAPI, operationFileNoLbl)
+	h.writeFile(req, rsp, application, profile, h.defaultLabel, format)
+}
+
+// getSecond 同时兼容 /{label}/{application}-{profile}.{ext} 以及 /{application}/{profile}
+func (h *SpringCloudConfigServer) getSecond(req *restful.Request, rsp *restful.Response) {
+	first, second := req.PathParameter("first"), req.PathParameter("second")
+	if application, profile, format, ok := parseFileRequest(second); ok {
+		req.SetAttribute(attrAPI, operationFile)
+		h.writeFile(req, rsp, application, profile, first, format)
+		return
+	}
+	req.SetAttribute(attrAPI, operationEnvironmentNoLbl)
+	h.writeEnvironment(req, rsp, first, second, h.defaultLabel)
+}
+
+// getEnvironment 处理 /{application}/{profile}/{label}
+func (h *SpringCloudConfigServer) getEnvironment(req *restful.Request, rsp *restful.Response) {
+	req.SetAttribute(attrAPI, operationEnvironment)
+	h.writeEnvironment(req, rsp, req.PathParameter("application"), req.PathParameter("profile"),
+		req.PathParameter("label"))
+}
+
+func (h *SpringCloudConfigServer) writeEnvironment(req *restful.Request, rsp *restful.Response,
+	application, profile, label string) {
+	env, err := h.resolver.resolve(newRequestContext(req), application, profile, label)
+	if err != nil {
+		h.writeError(req, rsp, err)
+		return
+	}
+	_ = rsp.WriteAsJson(env)
+}
+
+func (h *SpringCloudConfigServer) writeFile(req *restful.Request, rsp *restful.Response,
+	application, profile, label, format string) {
+	env, err := h.resolver.resolve(newRequestContext(req), application, profile, label)
+	if err != nil {
+		h.writeError(req, rsp, err)
+		return
+	}
+	data, err := renderSource(format, env.Merge())
+	if err != nil {
+		h.writeError(req, rsp, err)
+		return
+	}
+	contentType := "text/plain"
+	if format == formatJson {
+		contentType = restful.MIME_JSON
+	}
+	rsp.Header().Set(restful.HEADER_ContentType, contentType+"; charset=utf-8")
+	rsp.WriteHeader(http.StatusOK)
+	_, _ = rsp.Write(data)
+}
+
+func (h *SpringCloudConfigServer) writeError(req *restful.Request, rsp *restful.Response, err error) {
+	log.Error("[SpringCloudConfig] resolve environment", zap.String("url", req.Request.URL.String()),
+		zap.Error(err))
+	status := http.StatusInternalServerError
+	var rspErr *responseError
+	if errors.As(err, &rspErr) && rspErr.statusCode() >= http.StatusBadRequest {
+		status = rspErr.statusCode()
+	}
+	_ = rsp.WriteErrorString(status, err.Error())
+}
+
+// parseFileRequest 解析 {application}-{profile}.{ext}，profile 取最后一个 - 之后的部分
+func parseFileRequest(name string) (string, string, string, bool) {
+	format := fileFormat(name)
+	if format == "" {
+		return "", "", "", false
+	}
+	base := strings.TrimSuffix(name, path.Ext(name))
+	index := strings.LastIndex(base, "-")
+	if index <= 0 || index == len(base)-1 {
+		return "", "", "", false
+	}
+	return base[:index], base[index+1:], format, true
+}
+
+// newRequestContext 构造请求上下文，Authorization 中携带的访问凭据用于配置读取的鉴权
+func newRequestContext(req *restful.Request) context.Context {
+	ctx := context.WithValue(req.Request.Context(), types.ContextRequestId, req.HeaderParameter("Request-Id"))
+	ctx = context.WithValue(ctx, types.ContextClientAddress, req.Request.RemoteAddr)
+	if authToken := req.HeaderParameter(types.HeaderAuthorizationKey); authToken != "" {
+		ctx = context.WithValue(ctx, types.ContextAuthTokenKey, authToken)
+	}
+	return ctx
+}
@@ -0,0 +1,194 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package springcloudconfig
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"go.uber.org/zap"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+	"github.com/pole-io/pole-server/pkg/config"
+)
+
+const (
+	// sharedApplication Spring 约定的所有应用共享的配置文件名称
+	sharedApplication = "application"
+	// defaultProfile 未指定 profile 时 Spring 使用的 profile
+	defaultProfile = "default"
+)
+
+// fileExtensions 同名配置文件的优先级，与 Spring Boot 一致 properties 优先于 yml
+var fileExtensions = []string{".properties", ".yml", ".yaml", ".json"}
+
+// Environment Spring Cloud Config Server 的 /{application}/{profile}/{label} 返回结构
+type Environment struct {
+	Name            string            `json:"name"`
+	Profiles        []string          `json:"profiles"`
+	Label           string            `json:"label"`
+	Version         string            `json:"version"`
+	State           *string           `json:"state"`
+	PropertySources []*PropertySource `json:"propertySources"`
+}
+
+// PropertySource 单个配置文件的属性，PropertySources 中越靠前优先级越高
+type PropertySource struct {
+	Name   string                 `json:"name"`
+	Source map[string]interface{} `json:"source"`
+}
+
+// Merge 按照 Spring 的优先级合并全部的属性
+func (e *Environment) Merge() map[string]interface{} {
+	ret := map[string]interface{}{}
+	for i := len(e.PropertySources) - 1; i >= 0; i-- {
+		for k, v := range e.PropertySources[i].Source {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// decryptFunc 解密 GetConfigFileWithCache 返回的配置内容
+type decryptFunc func(file *apiconfig.ClientConfigFileInfo) (string, error)
+
+// responseError 配置中心返回的错误码，返回给客户端时转换为对应的 HTTP 状态码
+type responseError struct {
+	code uint32
+	info string
+}
+
+func (e *responseError) Error() string {
+	return e.info
+}
+
+// statusCode 错误码的前三位与 HTTP 状态码一致
+func (e *responseError) statusCode() int {
+	return int(e.code / 1000)
+}
+
+// environmentResolver 将 Spring 的 application/profile/label 映射为 配置分组/文件名/命名空间，
+// 通过配置中心的客户端接口读取已发布的配置，和其他客户端一样经过鉴权
+type environmentResolver struct {
+	configSvr config.ConfigFileClientOperate
+	decrypt   decryptFunc
+}
+
+// parseProfiles 解析逗号分隔的 profile 列表
+func parseProfiles(profile string) []string {
+	profiles := make([]string, 0, 2)
+	for _, item := range strings.Split(profile, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			profiles = append(profiles, item)
+		}
+	}
+	if len(profiles) == 0 {
+		profiles = append(profiles, defaultProfile)
+	}
+	return profiles
+}
+
+// candidateFiles 按照优先级从高到低返回需要加载的配置文件名称
+// 后出现的 profile 优先级更高，应用自身的配置优先于共享的 application 配置
+func candidateFiles(application string, profiles []string) []string {
+	bases := make([]string, 0, 2*len(profiles)+2)
+	for i := len(profiles) - 1; i >= 0; i-- {
+		bases = append(bases, application+"-"+profiles[i])
+		if application != sharedApplication {
+			bases = append(bases, sharedApplication+"-"+profiles[i])
+		}
+	}
+	bases = append(bases, application)
+	if application != sharedApplication {
+		bases = append(bases, sharedApplication)
+	}
+
+	names := make([]string, 0, len(bases)*len(fileExtensions))
+	for _, base := range bases {
+		for _, ext := range fileExtensions {
+			names = append(names, base+ext)
+		}
+	}
+	return names
+}
+
+// resolve 加载 application 在 label 命名空间下的配置
+func (r *environmentResolver) resolve(ctx context.Context, application, profile,
+	label string) (*Environment, error) {
+	profiles := parseProfiles(profile)
+	env := &Environment{
+		Name:            application,
+		Profiles:        profiles,
+		Label:           label,
+		PropertySources: []*PropertySource{},
+	}
+
+	digests := make([]string, 0, 4)
+	for _, name := range candidateFiles(application, profiles) {
+		file, err := r.getFile(ctx, label, application, name)
+		if err != nil {
+			return nil, err
+		}
+		if file == nil {
+			continue
+		}
+		content, err := r.decrypt(file)
+		if err != nil {
+			return nil, err
+		}
+		source, err := parseSource(fileFormat(name), content)
+		if err != nil {
+			// 单个文件格式错误不影响其他配置的下发
+			log.Error("[SpringCloudConfig] parse config file", utils.RequestID(ctx), utils.ZapNamespace(label),
+				utils.ZapGroup(application), utils.ZapFileName(name), zap.Error(err))
+			continue
+		}
+		env.PropertySources = append(env.PropertySources, &PropertySource{
+			Name:   "pole://" + label + "/" + application + "/" + name,
+			Source: source,
+		})
+		digests = append(digests, name+"@"+file.GetMd5().GetValue())
+	}
+	if len(digests) > 0 {
+		sort.Strings(digests)
+		env.Version, _ = utils.BuildSha1Digest(strings.Join(digests, ","))
+	}
+	return env, nil
+}
+
+// getFile 查询单个已发布的配置文件，不存在时返回 nil
+func (r *environmentResolver) getFile(ctx context.Context, namespace, group,
+	fileName string) (*apiconfig.ClientConfigFileInfo, error) {
+	rsp := r.configSvr.GetConfigFileWithCache(ctx, &apiconfig.ClientConfigFileInfo{
+		Namespace: protobuf.NewStringValue(namespace),
+		Group:     protobuf.NewStringValue(group),
+		FileName:  protobuf.NewStringValue(fileName),
+	})
+	switch rsp.GetCode().GetValue() {
+	case uint32(apimodel.Code_ExecuteSuccess):
+		return rsp.GetConfigFile(), nil
+	case uint32(apimodel.Code_NotFoundResource):
+		return nil, nil
+	default:
+		return nil, &responseError{code: rsp.GetCode().GetValue(), info: rsp.GetInfo().GetValue()}
+	}
+}
@@ -0,0 +1,142 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package springcloudconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
+	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+	"github.com/pole-io/pole-server/pkg/config"
+)
+
+type fakeConfigServer struct {
+	config.ConfigFileClientOperate
+	files map[string]*apiconfig.ClientConfigFileInfo
+	code  apimodel.Code
+}
+
+func (f *fakeConfigServer) GetConfigFileWithCache(_ context.Context,
+	req *apiconfig.ClientConfigFileInfo) *apiconfig.ConfigClientResponse {
+	if f.code != 0 {
+		return api.NewConfigClientResponse(f.code, nil)
+	}
+	file, ok := f.files[req.GetNamespace().GetValue()+"/"+req.GetGroup().GetValue()+"/"+
+		req.GetFileName().GetValue()]
+	if !ok {
+		return api.NewConfigClientResponse(apimodel.Code_NotFoundResource, nil)
+	}
+	return api.NewConfigClientResponse(apimodel.Code_ExecuteSuccess, file)
+}
+
+func newFile(name, content string) *apiconfig.ClientConfigFileInfo {
+	return &apiconfig.ClientConfigFileInfo{
+		FileName: protobuf.NewStringValue(name),
+		Content:  protobuf.NewStringValue(content),
+		Md5:      protobuf.NewStringValue(name),
+	}
+}
+
+func noopDecrypt(file *apiconfig.ClientConfigFileInfo) (string, error) {
+	return file.GetContent().GetValue(), nil
+}
+
+func Test_candidateFiles(t *testing.T) {
+	names := candidateFiles("order", []string{"dev", "prod"})
+	bases := make([]string, 0, len(names)/len(fileExtensions))
+	for i := 0; i < len(names); i += len(fileExtensions) {
+		assert.Equal(t, names[i][:len(names[i])-len(".properties")]+".yml", names[i+1])
+		bases = append(bases, names[i][:len(names[i])-len(".properties")])
+	}
+	assert.Equal(t, []string{"order-prod", "application-prod", "order-dev", "application-dev",
+		"order", "application"}, bases)
+
+	names = candidateFiles(sharedApplication, []string{defaultProfile})
+	assert.Equal(t, 2*len(fileExtensions), len(names))
+	assert.Equal(t, []string{"default"}, parseProfiles(" , "))
+	assert.Equal(t, []string{"dev", "prod"}, parseProfiles("dev, prod"))
+}
+
+func Test_environmentResolver(t *testing.T) {
+	configSvr := &fakeConfigServer{files: map[string]*apiconfig.ClientConfigFileInfo{
+		"ns/order/application.yml":       newFile("application.yml", "a: shared\nb: shared\nc: shared\nd: shared\n"),
+		"ns/order/order.properties":      newFile("order.properties", "b=order\nc=order\nd=order\n"),
+		"ns/order/order.yml":             newFile("order.yml", "b: order-yml\n"),
+		"ns/order/application-prod.yaml": newFile("application-prod.yaml", "c: shared-prod\nd: shared-prod\n"),
+		"ns/order/order-prod.json":       newFile("order-prod.json", `{"d":"order-prod"}`),
+		"ns/order/order-dev.yml":         newFile("order-dev.yml", "a: [\n"),
+		"other/order/order-prod.json":    newFile("order-prod.json", `{"d":"other"}`),
+	}}
+	resolver := &environmentResolver{configSvr: configSvr, decrypt: noopDecrypt}
+
+	env, err := resolver.resolve(context.Background(), "order", "dev,prod", "ns")
+	assert.NoError(t, err)
+	assert.Equal(t, "order", env.Name)
+	assert.Equal(t, "ns", env.Label)
+	assert.Equal(t, []string{"dev", "prod"}, env.Profiles)
+	assert.NotEmpty(t, env.Version)
+
+	sources := make([]string, 0, len(env.PropertySources))
+	for _, source := range env.PropertySources {
+		sources = append(sources, source.Name)
+	}
+	// 格式错误的 order-dev.yml 被忽略
+	assert.Equal(t, []string{
+		"pole://ns/order/order-prod.json",
+		"pole://ns/order/application-prod.yaml",
+		"pole://ns/order/order.properties",
+		"pole://ns/order/order.yml",
+		"pole://ns/order/application.yml",
+	}, sources)
+	assert.Equal(t, map[string]interface{}{
+		"a": "shared",
+		"b": "order",
+		"c": "shared-prod",
+		"d": "order-prod",
+	}, env.Merge())
+
+	// 版本只和发布内容相关
+	again, err := resolver.resolve(context.Background(), "order", "dev,prod", "ns")
+	assert.NoError(t, err)
+	assert.Equal(t, env.Version, again.Version)
+
+	env, err = resolver.resolve(context.Background(), "missing", "", "ns")
+	assert.NoError(t, err)
+	assert.Empty(t, env.PropertySources)
+	assert.Empty(t, env.Version)
+	assert.Equal(t, []string{defaultProfile}, env.Profiles)
+
+	resolver.decrypt = func(*apiconfig.ClientConfigFileInfo) (string, error) {
+		return "", errors.New("decrypt failed")
+	}
+	_, err = resolver.resolve(context.Background(), "order", "prod", "ns")
+	assert.Error(t, err)
+
+	// 鉴权失败时返回对应的 HTTP 状态码
+	configSvr.code = apimodel.Code_NotAllowedAccess
+	_, err = resolver.resolve(context.Background(), "order", "prod", "ns")
+	var rspErr *responseError
+	assert.True(t, errors.As(err, &rspErr))
+	assert.Equal(t, 401, rspErr.statusCode())
+}
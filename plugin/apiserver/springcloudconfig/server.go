@@ -0,0 +1,276 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package springcloudconfig
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"go.uber.org/zap"
+
+	"github.com/pole-io/pole-server/apis/apiserver"
+	"github.com/pole-io/pole-server/apis/observability/statis"
+	"github.com/pole-io/pole-server/apis/pkg/types/metrics"
+	"github.com/pole-io/pole-server/pkg/common/conn/keepalive"
+	connlimit "github.com/pole-io/pole-server/pkg/common/conn/limit"
+	"github.com/pole-io/pole-server/pkg/common/secure"
+	"github.com/pole-io/pole-server/pkg/config"
+)
+
+const (
+	ServerSpringCloudConfig = "springcloud-config"
+
+	optionListenIP     = "listenIP"
+	optionListenPort   = "listenPort"
+	optionDefaultLabel = "defaultLabel"
+	optionConnLimit    = "connLimit"
+	optionTLS          = "tls"
+
+	DefaultListenIP   = "0.0.0.0"
+	DefaultListenPort = 8888
+	// DefaultLabel 未指定 label 时使用的命名空间
+	DefaultLabel = "default"
+)
+
+// SpringCloudConfigServer 兼容 Spring Cloud Config Server 协议的配置下发服务
+type SpringCloudConfigServer struct {
+	server          *http.Server
+	resolver        *environmentResolver
+	connLimitConfig *connlimit.Config
+	tlsInfo         *secure.TLSInfo
+	option          map[string]interface{}
+	openAPI         map[string]apiserver.APIConfig
+	listenIP        string
+	listenPort      uint32
+	defaultLabel    string
+	exitCh          chan struct{}
+	start           bool
+	restart         bool
+	statis          statis.Statis
+}
+
+// GetPort 获取端口
+func (h *SpringCloudConfigServer) GetPort() uint32 {
+	return h.listenPort
+}
+
+// GetProtocol 获取协议
+func (h *SpringCloudConfigServer) GetProtocol() string {
+	return ServerSpringCloudConfig
+}
+
+// Initialize 初始化 Spring Cloud Config 服务器
+func (h *SpringCloudConfigServer) Initialize(_ context.Context, option map[string]interface{},
+	api map[string]apiserver.APIConfig) error {
+	h.option = option
+	h.openAPI = api
+	h.listenIP = DefaultListenIP
+	if ipValue, ok := option[optionListenIP].(string); ok && ipValue != "" {
+		h.listenIP = ipValue
+	}
+	h.listenPort = DefaultListenPort
+	if portValue, ok := option[optionListenPort].(int); ok && portValue > 0 {
+		h.listenPort = uint32(portValue)
+	}
+	h.defaultLabel = DefaultLabel
+	if labelValue, ok := option[optionDefaultLabel].(string); ok && labelValue != "" {
+		h.defaultLabel = labelValue
+	}
+
+	// 连接数限制的配置
+	if raw, _ := option[optionConnLimit].(map[interface{}]interface{}); raw != nil {
+		connLimitConfig, err := connlimit.ParseConnLimitConfig(raw)
+		if err != nil {
+			return err
+		}
+		h.connLimitConfig = connLimitConfig
+	}
+	if raw, _ := option[optionTLS].(map[interface{}]interface{}); raw != nil {
+		tlsConfig, err := secure.ParseTLSConfig(raw)
+		if err != nil {
+			return err
+		}
+		h.tlsInfo = &secure.TLSInfo{
+			CertFile:      tlsConfig.CertFile,
+			KeyFile:       tlsConfig.KeyFile,
+			TrustedCAFile: tlsConfig.TrustedCAFile,
+		}
+	}
+	return nil
+}
+
+// Run 启动 Spring Cloud Config 服务器
+func (h *SpringCloudConfigServer) Run(errCh chan error) {
+	log.Infof("start SpringCloudConfigServer")
+	h.exitCh = make(chan struct{})
+	h.start = true
+	defer func() {
+		close(h.exitCh)
+		h.start = false
+	}()
+
+	configSvr, err := config.GetServer()
+	if err != nil {
+		log.Errorf("%v", err)
+		errCh <- err
+		return
+	}
+	originConfigSvr, err := config.GetOriginServer()
+	if err != nil {
+		log.Errorf("%v", err)
+		errCh <- err
+		return
+	}
+	h.resolver = &environmentResolver{
+		configSvr: configSvr,
+		decrypt:   originConfigSvr.DecryptClientConfigFile,
+	}
+	h.statis = statis.GetStatis()
+
+	address := fmt.Sprintf("%v:%v", h.listenIP, h.listenPort)
+	server := http.Server{Addr: address, Handler: h.createRestfulContainer(), WriteTimeout: 1 * time.Minute}
+
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Errorf("net listen(%s) err: %s", address, err.Error())
+		errCh <- err
+		return
+	}
+	ln = keepalive.NewTcpKeepAliveListener(3*time.Minute, ln.(*net.TCPListener))
+	// 开启最大连接数限制
+	if h.connLimitConfig != nil && h.connLimitConfig.OpenConnLimit {
+		log.Infof("http server use max connection limit per ip: %d, http max limit: %d",
+			h.connLimitConfig.MaxConnPerHost, h.connLimitConfig.MaxConnLimit)
+		ln, err = connlimit.NewListener(ln, h.GetProtocol(), h.connLimitConfig)
+		if err != nil {
+			log.Errorf("conn limit init err: %s", err.Error())
+			errCh <- err
+			return
+		}
+	}
+	h.server = &server
+
+	if h.tlsInfo.IsEmpty() {
+		err = server.Serve(ln)
+	} else {
+		err = server.ServeTLS(ln, h.tlsInfo.CertFile, h.tlsInfo.KeyFile)
+	}
+	if err != nil && err != http.ErrServerClosed {
+		log.Errorf("%+v", err)
+		if !h.restart {
+			log.Infof("not in restart progress, broadcast error")
+			errCh <- err
+		}
+		return
+	}
+	log.Infof("SpringCloudConfigServer stop")
+}
+
+// createRestfulContainer 创建handler
+func (h *SpringCloudConfigServer) createRestfulContainer() *restful.Container {
+	wsContainer := restful.NewContainer()
+	wsContainer.Filter(h.process)
+	wsContainer.Add(h.GetSpringCloudConfigServer())
+	wsContainer.RecoverHandler(h.recoverFunc)
+	return wsContainer
+}
+
+func (h *SpringCloudConfigServer) recoverFunc(i interface{}, w http.ResponseWriter) {
+	log.Errorf("panic %+v", i)
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Header().Add(restful.HEADER_ContentType, restful.MIME_JSON)
+}
+
+// process 在接收和回复时统一处理请求
+func (h *SpringCloudConfigServer) process(req *restful.Request, rsp *restful.Response,
+	chain *restful.FilterChain) {
+	startTime := time.Now()
+	chain.ProcessFilter(req, rsp)
+
+	diff := time.Since(startTime)
+	// 打印耗时超过1s的请求
+	if diff > time.Second {
+		accesslog.Info("handling time > 1s",
+			zap.String("client-address", req.Request.RemoteAddr),
+			zap.String("user-agent", req.HeaderParameter("User-Agent")),
+			zap.String("method", req.Request.Method),
+			zap.String("url", req.Request.URL.String()),
+			zap.Duration("handling-time", diff),
+		)
+	}
+	// 未匹配到路由的请求不做统计
+	api, ok := req.Attribute(attrAPI).(string)
+	if !ok || h.statis == nil {
+		return
+	}
+	h.statis.ReportCallMetrics(metrics.CallMetric{
+		API:      api,
+		Protocol: "HTTP",
+		Code:     rsp.StatusCode(),
+		Duration: diff,
+	})
+}
+
+// Stop 结束 SpringCloudConfigServer 的运行
+func (h *SpringCloudConfigServer) Stop() {
+	// 释放connLimit的数据，如果没有开启，也需要执行一下
+	// 目的：防止restart的时候，connLimit冲突
+	connlimit.RemoveLimitListener(h.GetProtocol())
+	if h.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := h.server.Shutdown(ctx); nil != err {
+			log.Errorf("SpringCloudConfigServer shutdown failed, err: %v", err)
+		}
+	}
+}
+
+// Restart 重启 SpringCloudConfigServer
+func (h *SpringCloudConfigServer) Restart(
+	option map[string]interface{}, api map[string]apiserver.APIConfig, errCh chan error) error {
+	log.Infof("restart springcloud config server new config: %+v", option)
+	backupOption := h.option
+	backupAPI := h.openAPI
+
+	// 设置restart标记，防止stop的时候把错误抛出
+	h.restart = true
+	h.Stop()
+	if h.start {
+		<-h.exitCh
+	}
+
+	if err := h.Initialize(context.Background(), option, api); err != nil {
+		h.restart = false
+		if initErr := h.Initialize(context.Background(), backupOption, backupAPI); initErr != nil {
+			log.Errorf("start springcloud config server with backup cfg err: %s", initErr.Error())
+			return initErr
+		}
+		go h.Run(errCh)
+
+		log.Errorf("restart springcloud config server initialize err: %s", err.Error())
+		return err
+	}
+
+	h.restart = false
+	go h.Run(errCh)
+	return nil
+}
@@ -0,0 +1,230 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package springcloudconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+const (
+	formatProperties = "properties"
+	formatYaml       = "yaml"
+	formatJson       = "json"
+)
+
+// fileFormat 根据文件后缀判断配置文件的格式，不支持的格式返回空
+func fileFormat(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".properties":
+		return formatProperties
+	case ".yml", ".yaml":
+		return formatYaml
+	case ".json":
+		return formatJson
+	}
+	return ""
+}
+
+// parseSource 将配置内容解析为 Spring 风格的扁平化属性，嵌套的 key 使用 . 连接，数组使用 [index]
+func parseSource(format, content string) (map[string]interface{}, error) {
+	ret := map[string]interface{}{}
+	switch format {
+	case formatProperties:
+		for k, v := range utils.ParseProperties(content) {
+			ret[k] = v
+		}
+	case formatYaml:
+		decoder := yaml.NewDecoder(strings.NewReader(content))
+		for {
+			var doc interface{}
+			if err := decoder.Decode(&doc); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, err
+			}
+			flatten("", doc, ret)
+		}
+	case formatJson:
+		var doc interface{}
+		if err := json.Unmarshal([]byte(content), &doc); err != nil {
+			return nil, err
+		}
+		flatten("", doc, ret)
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s", format)
+	}
+	return ret, nil
+}
+
+func flatten(prefix string, value interface{}, ret map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			flatten(joinKey(prefix, k), item, ret)
+		}
+	case map[interface{}]interface{}:
+		for k, item := range v {
+			flatten(joinKey(prefix, fmt.Sprintf("%v", k)), item, ret)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flatten(prefix+"["+strconv.Itoa(i)+"]", item, ret)
+		}
+	case nil:
+		if prefix != "" {
+			ret[prefix] = ""
+		}
+	default:
+		if prefix != "" {
+			ret[prefix] = v
+		}
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// renderSource 将扁平化的属性按照格式输出
+func renderSource(format string, flat map[string]interface{}) ([]byte, error) {
+	switch format {
+	case formatProperties:
+		keys := make([]string, 0, len(flat))
+		for k := range flat {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf := bytes.NewBuffer(nil)
+		for _, k := range keys {
+			_, _ = fmt.Fprintf(buf, "%s: %v\n", k, flat[k])
+		}
+		return buf.Bytes(), nil
+	case formatYaml:
+		return yaml.Marshal(unflatten(flat))
+	case formatJson:
+		return json.Marshal(unflatten(flat))
+	}
+	return nil, fmt.Errorf("unsupported config format: %s", format)
+}
+
+// unflatten 将扁平化的属性还原为嵌套结构
+func unflatten(flat map[string]interface{}) map[string]interface{} {
+	root := map[string]interface{}{}
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	// 保证同样的输入得到同样的输出
+	sort.Strings(keys)
+	for _, k := range keys {
+		segments := splitKey(k)
+		node := root
+		for i, seg := range segments {
+			if i == len(segments)-1 {
+				if _, ok := node[seg].(map[string]interface{}); !ok {
+					node[seg] = flat[k]
+				}
+				break
+			}
+			child, ok := node[seg].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				node[seg] = child
+			}
+			node = child
+		}
+	}
+	return toSlices(root).(map[string]interface{})
+}
+
+// splitKey 拆分属性 key，a.b[0].c 拆分为 a b [0] c
+func splitKey(key string) []string {
+	segments := make([]string, 0, 4)
+	for _, part := range strings.Split(key, ".") {
+		for {
+			idx := strings.Index(part, "[")
+			if idx < 0 || !strings.HasSuffix(part, "]") {
+				segments = append(segments, part)
+				break
+			}
+			if idx > 0 {
+				segments = append(segments, part[:idx])
+			}
+			end := strings.Index(part, "]")
+			segments = append(segments, part[idx:end+1])
+			part = part[end+1:]
+			if part == "" {
+				break
+			}
+		}
+	}
+	return segments
+}
+
+// toSlices 将 key 全部是 [index] 的节点转换为数组
+func toSlices(node interface{}) interface{} {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return node
+	}
+	allIndex := len(m) > 0
+	for k, v := range m {
+		m[k] = toSlices(v)
+		if _, ok := parseIndexKey(k); !ok {
+			allIndex = false
+		}
+	}
+	if !allIndex {
+		return m
+	}
+	indexes := make([]int, 0, len(m))
+	for k := range m {
+		i, _ := parseIndexKey(k)
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	ret := make([]interface{}, 0, len(indexes))
+	for _, i := range indexes {
+		ret = append(ret, m["["+strconv.Itoa(i)+"]"])
+	}
+	return ret
+}
+
+func parseIndexKey(key string) (int, bool) {
+	if !strings.HasPrefix(key, "[") || !strings.HasSuffix(key, "]") {
+		return 0, false
+	}
+	i, err := strconv.Atoi(key[1 : len(key)-1])
+	return i, err == nil
+}
@@ -0,0 +1,102 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package springcloudconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseSource(t *testing.T) {
+	t.Run("properties", func(t *testing.T) {
+		content := "# comment\n! comment\nserver.port=8080\nspring.name : demo\nmulti = a,\\\n    b\nescaped\\ key value\n"
+		ret, err := parseSource(formatProperties, content)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"server.port": "8080",
+			"spring.name": "demo",
+			"multi":       "a,b",
+			"escaped key": "value",
+		}, ret)
+	})
+
+	t.Run("yaml-multi-document", func(t *testing.T) {
+		content := "server:\n  port: 8080\nlist:\n  - a\n  - b\n---\nserver:\n  port: 9090\nempty:\n"
+		ret, err := parseSource(formatYaml, content)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"server.port": 9090,
+			"list[0]":     "a",
+			"list[1]":     "b",
+			"empty":       "",
+		}, ret)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		ret, err := parseSource(formatJson, `{"a":{"b":[1,{"c":true}]}}`)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"a.b[0]":   float64(1),
+			"a.b[1].c": true,
+		}, ret)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parseSource(formatYaml, "a: [")
+		assert.Error(t, err)
+		_, err = parseSource("xml", "<a/>")
+		assert.Error(t, err)
+	})
+}
+
+func Test_renderSource(t *testing.T) {
+	flat := map[string]interface{}{
+		"server.port": 8080,
+		"list[0]":     "a",
+		"list[1]":     "b",
+		"name":        "demo",
+	}
+
+	data, err := renderSource(formatProperties, flat)
+	assert.NoError(t, err)
+	assert.Equal(t, "list[0]: a\nlist[1]: b\nname: demo\nserver.port: 8080\n", string(data))
+
+	data, err = renderSource(formatJson, flat)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"list":["a","b"],"name":"demo","server":{"port":8080}}`, string(data))
+
+	data, err = renderSource(formatYaml, flat)
+	assert.NoError(t, err)
+	ret, err := parseSource(formatYaml, string(data))
+	assert.NoError(t, err)
+	assert.Equal(t, flat, ret)
+}
+
+func Test_parseFileRequest(t *testing.T) {
+	application, profile, format, ok := parseFileRequest("order-service-prod.yml")
+	assert.True(t, ok)
+	assert.Equal(t, "order-service", application)
+	assert.Equal(t, "prod", profile)
+	assert.Equal(t, formatYaml, format)
+
+	for _, name := range []string{"order.yml", "-prod.yml", "order-.yml", "order-prod.txt", "order-prod"} {
+		_, _, _, ok = parseFileRequest(name)
+		assert.False(t, ok, name)
+	}
+}
@@ -0,0 +1,288 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ca
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pole-io/pole-server/apis/crypto"
+	"github.com/pole-io/pole-server/apis/pkg/types/admin"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+const (
+	// RootSettingName 根证书在 server_setting 中的记录名称
+	RootSettingName = "xds.ca.root"
+	// clockSkew 签发证书时生效时间提前一段时间, 避免节点之间时钟不一致导致证书尚未生效
+	clockSkew = time.Minute
+)
+
+// SettingStore 根证书的持久化接口, 由 store.AdminStore 实现
+type SettingStore interface {
+	// GetServerSetting get server setting by name, return nil when not exist
+	GetServerSetting(name string) (*admin.ServerSetting, error)
+	// CreateServerSettingIfAbsent create server setting, do nothing when the name already exists
+	CreateServerSettingIfAbsent(setting *admin.ServerSetting) error
+}
+
+// rootRecord 持久化的根证书记录, 私钥经过加密插件加密
+type rootRecord struct {
+	Cert      string `json:"cert"`
+	Key       string `json:"key"`
+	Algorithm string `json:"algorithm"`
+}
+
+// Certificate 签发的工作负载证书
+type Certificate struct {
+	// Identity SPIFFE 身份
+	Identity string
+	// CertChain PEM 格式的证书链, 包含工作负载证书以及根证书
+	CertChain []byte
+	// PrivateKey PEM 格式的私钥
+	PrivateKey []byte
+	// NotAfter 证书过期时间
+	NotAfter time.Time
+}
+
+// CertificateAuthority 内置的工作负载 CA, 为每个 namespace/service 身份签发短期证书
+type CertificateAuthority struct {
+	cfg      *Config
+	rootCert *x509.Certificate
+	rootKey  *ecdsa.PrivateKey
+	rootPEM  []byte
+}
+
+// NewCertificateAuthority 从存储中加载根证书, 不存在时生成并持久化.
+// 多个节点同时初始化时以第一个写入存储的根证书为准
+func NewCertificateAuthority(cfg *Config, s SettingStore, c crypto.Crypto) (*CertificateAuthority, error) {
+	if s == nil || c == nil {
+		return nil, errors.New("store and crypto plugin are required by xds ca")
+	}
+	// 加密密钥不能和根证书私钥存储在一起, 否则加密没有意义, 因此必须通过配置提供
+	if cfg.EncryptKey == "" {
+		return nil, errors.New("xds ca encryptKey is required to protect the root private key")
+	}
+	encryptKey, err := base64.StdEncoding.DecodeString(cfg.EncryptKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xds ca encryptKey: %w", err)
+	}
+
+	setting, err := s.GetServerSetting(RootSettingName)
+	if err != nil {
+		return nil, err
+	}
+	if setting == nil {
+		record, err := newRootRecord(cfg, c, encryptKey)
+		if err != nil {
+			return nil, err
+		}
+		rule, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.CreateServerSettingIfAbsent(&admin.ServerSetting{
+			ID:   utils.NewUUID(),
+			Name: RootSettingName,
+			Rule: string(rule),
+		}); err != nil {
+			return nil, err
+		}
+		// 重新读取一次, 其他节点可能已经抢先写入了根证书
+		if setting, err = s.GetServerSetting(RootSettingName); err != nil {
+			return nil, err
+		}
+		if setting == nil {
+			return nil, errors.New("xds ca root certificate not found after created")
+		}
+	}
+
+	record := &rootRecord{}
+	if err := json.Unmarshal([]byte(setting.Rule), record); err != nil {
+		return nil, err
+	}
+	ca, err := loadRootRecord(cfg, record, c, encryptKey)
+	if err != nil {
+		return nil, err
+	}
+	if time.Until(ca.rootCert.NotAfter) < cfg.CertTTL {
+		log.Warn("[XDS][CA] root certificate is about to expire", zap.Time("not-after", ca.rootCert.NotAfter))
+	}
+	return ca, nil
+}
+
+func newRootRecord(cfg *Config, c crypto.Crypto, encryptKey []byte) (*rootRecord, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"pole-server"},
+			CommonName:   "pole-server root CA",
+		},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              now.Add(cfg.RootTTL),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &rootRecord{
+		Cert:      string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		Algorithm: cfg.CryptoAlgo,
+	}
+	if record.Key, err = c.Encrypt(string(keyPEM), encryptKey); err != nil {
+		return nil, err
+	}
+	log.Info("[XDS][CA] generate new root certificate", zap.Time("not-after", tmpl.NotAfter))
+	return record, nil
+}
+
+func loadRootRecord(cfg *Config, record *rootRecord, c crypto.Crypto, encryptKey []byte) (*CertificateAuthority, error) {
+	keyPEM, err := c.Decrypt(record.Key, encryptKey)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt xds ca root key: %w", err)
+	}
+	keyBlock, _ := pem.Decode([]byte(keyPEM))
+	if keyBlock == nil {
+		return nil, errors.New("invalid xds ca root key")
+	}
+	parsedKey, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rootKey, ok := parsedKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("xds ca root key is not ecdsa key")
+	}
+	certBlock, _ := pem.Decode([]byte(record.Cert))
+	if certBlock == nil {
+		return nil, errors.New("invalid xds ca root certificate")
+	}
+	rootCert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &CertificateAuthority{
+		cfg:      cfg,
+		rootCert: rootCert,
+		rootKey:  rootKey,
+		rootPEM:  []byte(record.Cert),
+	}, nil
+}
+
+// RootPEM PEM 格式的根证书
+func (c *CertificateAuthority) RootPEM() []byte {
+	return c.rootPEM
+}
+
+// Identity namespace/service 对应的 SPIFFE 身份
+func (c *CertificateAuthority) Identity(namespace, service string) string {
+	return fmt.Sprintf("spiffe://%s/ns/%s/sa/%s", c.cfg.TrustDomain, namespace, service)
+}
+
+// Issue 为 namespace/service 签发工作负载证书
+func (c *CertificateAuthority) Issue(namespace, service string) (*Certificate, error) {
+	identity := c.Identity(namespace, service)
+	uri, err := url.Parse(identity)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	notAfter := now.Add(c.cfg.CertTTL)
+	if notAfter.After(c.rootCert.NotAfter) {
+		notAfter = c.rootCert.NotAfter
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		NotBefore:    now.Add(-clockSkew),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		URIs:         []*url.URL{uri},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, c.rootCert, key.Public(), c.rootKey)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	chain = append(chain, c.rootPEM...)
+	return &Certificate{
+		Identity:   identity,
+		CertChain:  chain,
+		PrivateKey: keyPEM,
+		NotAfter:   notAfter,
+	}, nil
+}
+
+// NeedRotate 证书是否已经进入轮换窗口
+func (c *CertificateAuthority) NeedRotate(cert *Certificate, now time.Time) bool {
+	return cert == nil || !now.Add(c.cfg.RotateBefore).Before(cert.NotAfter)
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func newSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
@@ -0,0 +1,154 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ca
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/apis/pkg/types/admin"
+	"github.com/pole-io/pole-server/plugin/crypto/aes"
+)
+
+type memorySettingStore struct {
+	lock     sync.Mutex
+	settings map[string]*admin.ServerSetting
+}
+
+func newMemorySettingStore() *memorySettingStore {
+	return &memorySettingStore{settings: map[string]*admin.ServerSetting{}}
+}
+
+func (m *memorySettingStore) GetServerSetting(name string) (*admin.ServerSetting, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.settings[name], nil
+}
+
+func (m *memorySettingStore) CreateServerSettingIfAbsent(setting *admin.ServerSetting) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.settings[setting.Name]; !ok {
+		m.settings[setting.Name] = setting
+	}
+	return nil
+}
+
+func newTestConfig(t *testing.T, raw map[string]interface{}) *Config {
+	key, err := (&aes.AESCrypto{}).GenerateKey()
+	assert.NoError(t, err)
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+	raw["encryptKey"] = base64.StdEncoding.EncodeToString(key)
+	cfg, err := LoadConfig(raw)
+	assert.NoError(t, err)
+	return cfg
+}
+
+func TestNewCertificateAuthority_Persist(t *testing.T) {
+	cfg := newTestConfig(t, nil)
+	s := newMemorySettingStore()
+
+	first, err := NewCertificateAuthority(cfg, s, &aes.AESCrypto{})
+	assert.NoError(t, err)
+	second, err := NewCertificateAuthority(cfg, s, &aes.AESCrypto{})
+	assert.NoError(t, err)
+	assert.Equal(t, first.RootPEM(), second.RootPEM())
+	assert.Len(t, s.settings, 1)
+
+	// 私钥以加密形式存储
+	record := &rootRecord{}
+	assert.NoError(t, json.Unmarshal([]byte(s.settings[RootSettingName].Rule), record))
+	assert.NotContains(t, record.Key, "PRIVATE KEY")
+	assert.NotContains(t, s.settings[RootSettingName].Rule, cfg.EncryptKey)
+}
+
+func TestNewCertificateAuthority_RequireEncryptKey(t *testing.T) {
+	cfg, err := LoadConfig(nil)
+	assert.NoError(t, err)
+	s := newMemorySettingStore()
+	_, err = NewCertificateAuthority(cfg, s, &aes.AESCrypto{})
+	assert.Error(t, err)
+	assert.Empty(t, s.settings)
+}
+
+func TestNewCertificateAuthority_EncryptKey(t *testing.T) {
+	c := &aes.AESCrypto{}
+	key, err := c.GenerateKey()
+	assert.NoError(t, err)
+	cfg, err := LoadConfig(map[string]interface{}{
+		"encryptKey": base64.StdEncoding.EncodeToString(key),
+	})
+	assert.NoError(t, err)
+	s := newMemorySettingStore()
+
+	_, err = NewCertificateAuthority(cfg, s, c)
+	assert.NoError(t, err)
+
+	// 使用错误的密钥无法加载根证书
+	otherKey, err := c.GenerateKey()
+	assert.NoError(t, err)
+	other, err := LoadConfig(map[string]interface{}{
+		"encryptKey": base64.StdEncoding.EncodeToString(otherKey),
+	})
+	assert.NoError(t, err)
+	_, err = NewCertificateAuthority(other, s, c)
+	assert.Error(t, err)
+}
+
+func TestCertificateAuthority_Issue(t *testing.T) {
+	cfg := newTestConfig(t, map[string]interface{}{
+		"trustDomain": "pole.io",
+		"certTTL":     "1h",
+	})
+	authority, err := NewCertificateAuthority(cfg, newMemorySettingStore(), &aes.AESCrypto{})
+	assert.NoError(t, err)
+
+	cert, err := authority.Issue("default", "echo")
+	assert.NoError(t, err)
+	assert.Equal(t, "spiffe://pole.io/ns/default/sa/echo", cert.Identity)
+
+	block, rest := pem.Decode(cert.CertChain)
+	assert.NotNil(t, block)
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	assert.NoError(t, err)
+	assert.Equal(t, cert.Identity, leaf.URIs[0].String())
+	assert.Equal(t, authority.RootPEM(), rest)
+
+	roots := x509.NewCertPool()
+	assert.True(t, roots.AppendCertsFromPEM(authority.RootPEM()))
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	assert.NoError(t, err)
+
+	// 默认在有效期剩余 1/3 时轮换
+	assert.Equal(t, 20*time.Minute, cfg.RotateBefore)
+	assert.True(t, authority.NeedRotate(nil, time.Now()))
+	assert.False(t, authority.NeedRotate(cert, time.Now()))
+	assert.True(t, authority.NeedRotate(cert, cert.NotAfter.Add(-10*time.Minute)))
+}
@@ -0,0 +1,96 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ca
+
+import (
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// DefaultTrustDomain 默认的信任域, 与 sidecar 入流量校验的 SAN 前缀保持一致
+	DefaultTrustDomain = "cluster.local"
+	// DefaultRootTTL 根证书的有效期
+	DefaultRootTTL = 10 * 365 * 24 * time.Hour
+	// DefaultCertTTL 工作负载证书的有效期
+	DefaultCertTTL = 24 * time.Hour
+	// DefaultRotateBefore 工作负载证书在过期前多久进行轮换
+	DefaultRotateBefore = 8 * time.Hour
+	// DefaultCryptoAlgo 加密根证书私钥使用的加密插件
+	DefaultCryptoAlgo = "AES"
+)
+
+// Config 内置 CA 的配置
+type Config struct {
+	// Enable 是否开启内置 CA, 开启后通过 SDS 为开启了 mTLS 的 sidecar 签发证书
+	Enable bool `mapstructure:"enable"`
+	// TrustDomain SPIFFE 身份的信任域
+	TrustDomain string `mapstructure:"trustDomain"`
+	// RootTTL 根证书的有效期, 仅在首次生成根证书时生效
+	RootTTL time.Duration `mapstructure:"rootTTL"`
+	// CertTTL 工作负载证书的有效期
+	CertTTL time.Duration `mapstructure:"certTTL"`
+	// RotateBefore 工作负载证书在过期前多久进行轮换
+	RotateBefore time.Duration `mapstructure:"rotateBefore"`
+	// CryptoAlgo 加密根证书私钥使用的加密插件名称
+	CryptoAlgo string `mapstructure:"cryptoAlgo"`
+	// EncryptKey base64 编码的根证书私钥加密密钥, 开启内置 CA 时必须配置
+	EncryptKey string `mapstructure:"encryptKey"`
+}
+
+// LoadConfig 解析内置 CA 配置, 未设置的字段使用默认值
+func LoadConfig(raw interface{}) (*Config, error) {
+	cfg := &Config{
+		TrustDomain:  DefaultTrustDomain,
+		RootTTL:      DefaultRootTTL,
+		CertTTL:      DefaultCertTTL,
+		RotateBefore: DefaultRotateBefore,
+		CryptoAlgo:   DefaultCryptoAlgo,
+	}
+	if raw == nil {
+		return cfg, nil
+	}
+	decodeConfig := &mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     cfg,
+	}
+	decoder, err := mapstructure.NewDecoder(decodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return nil, err
+	}
+	if cfg.TrustDomain == "" {
+		cfg.TrustDomain = DefaultTrustDomain
+	}
+	if cfg.RootTTL <= 0 {
+		cfg.RootTTL = DefaultRootTTL
+	}
+	if cfg.CertTTL <= 0 {
+		cfg.CertTTL = DefaultCertTTL
+	}
+	if cfg.RotateBefore <= 0 || cfg.RotateBefore >= cfg.CertTTL {
+		cfg.RotateBefore = cfg.CertTTL / 3
+	}
+	if cfg.CryptoAlgo == "" {
+		cfg.CryptoAlgo = DefaultCryptoAlgo
+	}
+	return cfg, nil
+}
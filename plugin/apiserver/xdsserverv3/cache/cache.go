@@ -17,6 +17,8 @@ package cache
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -74,6 +76,7 @@ func NewUpdateResourcesRequest() *UpdateResourcesRequest {
 	return &UpdateResourcesRequest{
 		lock:               &sync.Mutex{},
 		Lds:                map[string]map[string]types.Resource{},
+		Sds:                map[string]map[string]types.Resource{},
 		NamespaceResources: map[string]*NamespaceUpdateResourcesRequest{},
 	}
 }
@@ -83,6 +86,8 @@ type UpdateResourcesRequest struct {
 	lock *sync.Mutex
 	// Lds LDS 相关的资源
 	Lds map[string]map[string]types.Resource
+	// Sds 每个 Envoy Node 独立的证书资源, 每次更新都整体替换
+	Sds map[string]map[string]types.Resource
 	// NamespaceResources .
 	NamespaceResources map[string]*NamespaceUpdateResourcesRequest
 }
@@ -111,6 +116,32 @@ func (r *UpdateResourcesRequest) RemoveNormalNamespaces(namespace string, tls re
 	r.NamespaceResources[namespace].RemoveNormal(xdsType, res)
 }
 
+// ------------- 针对 proxyless gRPC 的 xDS 资源 -------------
+
+// AddProxylessNamespaces .
+func (r *UpdateResourcesRequest) AddProxylessNamespaces(namespace string,
+	xdsType resource.XDSType, res []types.Resource) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.NamespaceResources[namespace]; !ok {
+		r.NamespaceResources[namespace] = NewNamespaceUpdateResourcesRequest()
+	}
+	r.NamespaceResources[namespace].AddProxyless(xdsType, res)
+}
+
+// RemoveProxylessNamespaces .
+func (r *UpdateResourcesRequest) RemoveProxylessNamespaces(namespace string,
+	xdsType resource.XDSType, res []types.Resource) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.NamespaceResources[namespace]; !ok {
+		r.NamespaceResources[namespace] = NewNamespaceUpdateResourcesRequest()
+	}
+	r.NamespaceResources[namespace].RemoveProxyless(xdsType, res)
+}
+
 // ------------- 针对 TLS 相关的 xDS 资源 -------------
 
 // AddTlsNamespaces .
@@ -139,9 +170,10 @@ func (r *UpdateResourcesRequest) RemoveTlsNamespaces(namespace string, tls resou
 
 func NewNamespaceUpdateResourcesRequest() *NamespaceUpdateResourcesRequest {
 	return &NamespaceUpdateResourcesRequest{
-		NormalResources: map[resource.XDSType]*TypeResources{},
-		DemandResources: map[resource.XDSType]*TypeResources{},
-		TlsResources:    map[resource.TLSMode]map[resource.XDSType]*TypeResources{},
+		NormalResources:    map[resource.XDSType]*TypeResources{},
+		DemandResources:    map[resource.XDSType]*TypeResources{},
+		TlsResources:       map[resource.TLSMode]map[resource.XDSType]*TypeResources{},
+		ProxylessResources: map[resource.XDSType]*TypeResources{},
 	}
 }
 
@@ -153,6 +185,8 @@ type NamespaceUpdateResourcesRequest struct {
 	DemandResources map[resource.XDSType]*TypeResources
 	// TlsResources .
 	TlsResources map[resource.TLSMode]map[resource.XDSType]*TypeResources
+	// ProxylessResources proxyless gRPC 使用的 LDS/RDS/CDS/EDS 资源
+	ProxylessResources map[resource.XDSType]*TypeResources
 }
 
 func (r *NamespaceUpdateResourcesRequest) AddTls(tlsMode resource.TLSMode, xdsType resource.XDSType, res []types.Resource) {
@@ -175,6 +209,20 @@ func (r *NamespaceUpdateResourcesRequest) RemoveTls(tlsMode resource.TLSMode, xd
 	r.TlsResources[tlsMode][xdsType].AppendRemoves(cachev3.IndexRawResourcesByName(res))
 }
 
+func (r *NamespaceUpdateResourcesRequest) AddProxyless(xdsType resource.XDSType, res []types.Resource) {
+	if _, ok := r.ProxylessResources[xdsType]; !ok {
+		r.ProxylessResources[xdsType] = NewTypeResources()
+	}
+	r.ProxylessResources[xdsType].AppendUpserts(cachev3.IndexRawResourcesByName(res))
+}
+
+func (r *NamespaceUpdateResourcesRequest) RemoveProxyless(xdsType resource.XDSType, res []types.Resource) {
+	if _, ok := r.ProxylessResources[xdsType]; !ok {
+		r.ProxylessResources[xdsType] = NewTypeResources()
+	}
+	r.ProxylessResources[xdsType].AppendRemoves(cachev3.IndexRawResourcesByName(res))
+}
+
 func (r *NamespaceUpdateResourcesRequest) AddNormals(xdsType resource.XDSType, res []types.Resource) {
 	if _, ok := r.NormalResources[xdsType]; !ok {
 		r.NormalResources[xdsType] = NewTypeResources()
@@ -257,6 +305,7 @@ func newNamespaceResourcesContainer(ns string) *NamespaceResourcesContainer {
 		resourcesContainer: map[resource.XDSType]*ResourcesContainer{},
 		demandResources:    map[resource.XDSType]*ResourcesContainer{},
 		tlsResources:       map[resource.TLSMode]map[resource.XDSType]*ResourcesContainer{},
+		proxylessResources: map[resource.XDSType]*ResourcesContainer{},
 	}
 }
 
@@ -268,6 +317,8 @@ type NamespaceResourcesContainer struct {
 	demandResources map[resource.XDSType]*ResourcesContainer
 	// tlsResources 记录了使用了 tls 的资源(目前而言只有 CDS)
 	tlsResources map[resource.TLSMode]map[resource.XDSType]*ResourcesContainer
+	// proxylessResources 记录了 proxyless gRPC 使用的资源, 与 Envoy 的资源相互隔离
+	proxylessResources map[resource.XDSType]*ResourcesContainer
 }
 
 func newNamespaceStatusInfo(ns string) *NamespaceStatusInfo {
@@ -295,6 +346,8 @@ type ResourceCache struct {
 	ads bool
 	// ldsResources 记录 Envoy Node LDS 的资源记录信息
 	ldsResources map[string]*ResourcesContainer
+	// sdsResources 记录 Envoy Node SDS 的证书资源信息
+	sdsResources map[string]*ResourcesContainer
 	// namespaceContainer 按照命名空间级别隔离 xDS resources
 	namespaceContainer map[string]*NamespaceResourcesContainer
 	// status information for all nodes indexed by node IDs
@@ -317,6 +370,7 @@ func NewResourceCache(hook CacheHook) *ResourceCache {
 		hook:               hook,
 		ads:                true,
 		ldsResources:       make(map[string]*ResourcesContainer),
+		sdsResources:       make(map[string]*ResourcesContainer),
 		namespaceContainer: make(map[string]*NamespaceResourcesContainer),
 		status:             make(map[string]*NamespaceStatusInfo),
 	}
@@ -329,6 +383,7 @@ func (sc *ResourceCache) CleanEnvoyNodeCache(node *corev3.Node) error {
 	defer sc.mu.Unlock()
 
 	delete(sc.ldsResources, node.GetId())
+	delete(sc.sdsResources, node.GetId())
 	return nil
 }
 
@@ -344,6 +399,15 @@ func (sc *ResourceCache) updateResourceContainer(ctx context.Context, req *Updat
 		}
 	}
 
+	// 更新 SDS 资源信息, 证书轮换后需要整体替换
+	for nodeId, resources := range req.Sds {
+		sc.sdsResources[nodeId] = &ResourcesContainer{
+			Resources: resources,
+		}
+		sc.sdsResources[nodeId].updateGlobalRevision()
+		_ = sc.sdsResources[nodeId].ConstructVersionMap(nil)
+	}
+
 	namespaceResources := req.NamespaceResources
 	for ns, nsResources := range namespaceResources {
 		if _, ok := sc.namespaceContainer[ns]; !ok {
@@ -389,26 +453,35 @@ func (sc *ResourceCache) updateResourceContainer(ctx context.Context, req *Updat
 
 		// 更新非 LDS 的 XDS 规则到 ResourceContainer 中
 		for typeUrl, resources := range nsResources.NormalResources {
-			if container, ok := namespaceContainer.resourcesContainer[typeUrl]; !ok {
-				container = &ResourcesContainer{
-					Resources: resources.UpsertResources,
-				}
-				namespaceContainer.resourcesContainer[typeUrl] = container
-			} else {
-				for name := range resources.RemoveResources {
-					delete(container.Resources, name)
-					delete(container.VersionMap, name)
-				}
-				modified := make([]string, 0, len(resources.UpsertResources))
-				for name, res := range resources.UpsertResources {
-					container.Resources[name] = res
-					modified = append(modified, name)
-				}
-			}
+			mergeResourcesContainer(namespaceContainer.resourcesContainer, typeUrl, resources)
+		}
+		// 更新 proxyless gRPC 的 XDS 规则
+		for typeUrl, resources := range nsResources.ProxylessResources {
+			mergeResourcesContainer(namespaceContainer.proxylessResources, typeUrl, resources)
+		}
+	}
+}
 
-			namespaceContainer.resourcesContainer[typeUrl].updateGlobalRevision()
+func mergeResourcesContainer(containers map[resource.XDSType]*ResourcesContainer, typeUrl resource.XDSType,
+	resources *TypeResources) {
+	if container, ok := containers[typeUrl]; !ok {
+		container = &ResourcesContainer{
+			Resources: resources.UpsertResources,
+		}
+		containers[typeUrl] = container
+	} else {
+		for name := range resources.RemoveResources {
+			delete(container.Resources, name)
+			delete(container.VersionMap, name)
+		}
+		modified := make([]string, 0, len(resources.UpsertResources))
+		for name, res := range resources.UpsertResources {
+			container.Resources[name] = res
+			modified = append(modified, name)
 		}
 	}
+
+	containers[typeUrl].updateGlobalRevision()
 }
 
 // UpdateResources updates a snapshot for a node.
@@ -425,7 +498,7 @@ func (sc *ResourceCache) UpdateResources(ctx context.Context, req *UpdateResourc
 			defer info.mu.Unlock()
 			for id, watch := range info.watches {
 				watchType := resource.FormatTypeUrl(watch.Request.TypeUrl)
-				container, exists := sc.loadResourceContainer(info.client, watchType)
+				container, exists := sc.loadResourceContainer(info.client, watchType, watch.Request.GetResourceNames())
 				if !exists {
 					continue
 				}
@@ -443,29 +516,39 @@ func (sc *ResourceCache) UpdateResources(ctx context.Context, req *UpdateResourc
 				}
 			}
 
-			namespaceContainer, exist := sc.namespaceContainer[ns]
-			if !exist {
-				continue
-			}
 			// We only calculate version hashes when using delta. We don't
 			// want to do this when using SOTW so we can avoid unnecessary
 			// computational cost if not using delta.
-			if len(info.deltaWatches) > 0 {
+			if namespaceContainer, exist := sc.namespaceContainer[ns]; exist && len(info.deltaWatches) > 0 {
 				for _, container := range namespaceContainer.resourcesContainer {
 					if err := container.ConstructVersionMap(nil); err != nil {
 						log.Errorf("failed to compute version for snapshot resources inline: %s", err)
 						return err
 					}
 				}
+				for _, container := range namespaceContainer.proxylessResources {
+					if err := container.ConstructVersionMap(nil); err != nil {
+						log.Errorf("failed to compute version for snapshot resources inline: %s", err)
+						return err
+					}
+				}
 			}
 
 			// process our delta watches
 			for id, watch := range info.deltaWatches {
 				watchType := resource.FormatTypeUrl(watch.Request.TypeUrl)
-				container, exist := sc.loadResourceContainer(info.client, watchType)
+				container, exist := sc.loadResourceContainer(info.client, watchType,
+					subscribedNames(watch.StreamState))
 				if !exist {
 					continue
 				}
+				if container.VersionMap == nil {
+					// proxyless gRPC 按请求的命名空间加载的资源可能还未计算版本
+					if err := container.ConstructVersionMap(nil); err != nil {
+						log.Errorf("failed to compute version for snapshot resources inline: %s", err)
+						return err
+					}
+				}
 				res, err := sc.respondDelta(
 					ctx,
 					container,
@@ -525,7 +608,8 @@ func (sc *ResourceCache) CreateWatch(request *cachev3.Request, streamState strea
 
 	var version string
 
-	container, exists := sc.loadResourceContainer(client, resource.FormatTypeUrl(request.GetTypeUrl()))
+	container, exists := sc.loadResourceContainer(client, resource.FormatTypeUrl(request.GetTypeUrl()),
+		request.GetResourceNames())
 
 	if exists {
 		version = container.GlobalVersion
@@ -610,8 +694,9 @@ func (sc *ResourceCache) respond(ctx context.Context, request *cachev3.Request,
 ) error {
 
 	// for ADS, the request names must match the snapshot names
-	// if they do not, then the watch is never responded, and it is expected that envoy makes another request
-	if len(request.ResourceNames) != 0 && sc.ads {
+	// if they do not, then the watch is never responded, and it is expected that envoy makes another request.
+	// proxyless gRPC 只会订阅自己访问的服务, 命名空间级别的资源只需要按名称过滤后返回
+	if len(request.ResourceNames) != 0 && sc.ads && !isProxylessNode(request.GetNode()) {
 		if err := superset(nameSet(request.ResourceNames), resources); err != nil {
 			log.Warnf("ADS mode: not responding to request: %v", err)
 			return nil
@@ -673,7 +758,8 @@ func (sc *ResourceCache) CreateDeltaWatch(request *cachev3.DeltaRequest, state s
 	// update last watch request time
 	info.setLastDeltaWatchRequestTime(time.Now())
 
-	container, exists := sc.loadResourceContainer(client, resource.FormatTypeUrl(request.GetTypeUrl()))
+	container, exists := sc.loadResourceContainer(client, resource.FormatTypeUrl(request.GetTypeUrl()),
+		subscribedNames(state))
 
 	// There are three different cases that leads to a delayed watch trigger:
 	// - no snapshot exists for the requested nodeID
@@ -776,7 +862,8 @@ func (sc *ResourceCache) Fetch(ctx context.Context, request *cachev3.Request) (c
 	sc.mu.RLock()
 	defer sc.mu.RUnlock()
 
-	container, exists := sc.loadResourceContainer(client, resource.FormatTypeUrl(request.GetTypeUrl()))
+	container, exists := sc.loadResourceContainer(client, resource.FormatTypeUrl(request.GetTypeUrl()),
+		request.GetResourceNames())
 
 	if exists {
 		// Respond only if the request version is distinct from the current snapshot state.
@@ -810,7 +897,17 @@ func (sc *ResourceCache) loadWatchStatus(node *corev3.Node) (*statusInfo, *resou
 	return info, client
 }
 
-func (sc *ResourceCache) loadResourceContainer(client *resource.XDSClient, watchType resource.XDSType) (*ResourcesContainer, bool) {
+func (sc *ResourceCache) loadResourceContainer(client *resource.XDSClient, watchType resource.XDSType,
+	resourceNames []string) (*ResourcesContainer, bool) {
+	if watchType == resource.SDS {
+		// 证书资源和 Envoy Node 强关联, 不区分命名空间
+		container, exists := sc.sdsResources[client.GetNodeID()]
+		return container, exists
+	}
+
+	if client.RunType == resource.RunTypeProxyless {
+		return sc.loadProxylessContainer(client, watchType, resourceNames)
+	}
 
 	namespaceContainer, ok := sc.namespaceContainer[client.GetSelfNamespace()]
 	if !ok {
@@ -845,6 +942,76 @@ func (sc *ResourceCache) loadResourceContainer(client *resource.XDSClient, watch
 	return container, exists
 }
 
+// loadProxylessContainer proxyless gRPC 通过 xds:///{service}.{namespace} 可以访问任意命名空间下的服务,
+// 因此根据请求的资源名称解析命名空间, 请求涉及多个命名空间时将对应的资源合并后返回
+func (sc *ResourceCache) loadProxylessContainer(client *resource.XDSClient, watchType resource.XDSType,
+	resourceNames []string) (*ResourcesContainer, bool) {
+	namespaces := map[string]struct{}{}
+	for _, name := range resourceNames {
+		if ns, ok := resource.ParseProxylessNamespace(watchType, name); ok {
+			namespaces[ns] = struct{}{}
+		}
+	}
+	if len(namespaces) == 0 {
+		// 通配订阅时无法从名称中得知命名空间, 使用客户端自身所在的命名空间
+		namespaces[client.GetSelfNamespace()] = struct{}{}
+	}
+
+	containers := make([]*ResourcesContainer, 0, len(namespaces))
+	versions := make([]string, 0, len(namespaces))
+	sortedNamespaces := make([]string, 0, len(namespaces))
+	for ns := range namespaces {
+		sortedNamespaces = append(sortedNamespaces, ns)
+	}
+	sort.Strings(sortedNamespaces)
+	for _, ns := range sortedNamespaces {
+		namespaceContainer, ok := sc.namespaceContainer[ns]
+		if !ok {
+			continue
+		}
+		container, ok := namespaceContainer.proxylessResources[watchType]
+		if !ok {
+			continue
+		}
+		containers = append(containers, container)
+		versions = append(versions, ns+"/"+container.GlobalVersion)
+	}
+
+	switch len(containers) {
+	case 0:
+		log.Error("load proxyless resource container not found", zap.String("id", client.GetNodeID()),
+			zap.String("type", watchType.String()), zap.Strings("namespaces", sortedNamespaces))
+		return nil, false
+	case 1:
+		return containers[0], true
+	}
+
+	merged := &ResourcesContainer{
+		GlobalVersion: strings.Join(versions, ","),
+		Resources:     map[string]types.Resource{},
+	}
+	for _, container := range containers {
+		for name, res := range container.Resources {
+			merged.Resources[name] = res
+		}
+	}
+	return merged, true
+}
+
+// subscribedNames delta 订阅的资源名称
+func subscribedNames(state stream.StreamState) []string {
+	names := make([]string, 0, len(state.GetSubscribedResourceNames()))
+	for name := range state.GetSubscribedResourceNames() {
+		names = append(names, name)
+	}
+	return names
+}
+
+func isProxylessNode(node *corev3.Node) bool {
+	runType, _, _, _ := resource.ParseNodeID(node.GetId())
+	return resource.RunType(runType) == resource.RunTypeProxyless
+}
+
 // GetStatusKeys retrieves all node IDs in the status map.
 func (sc *ResourceCache) GetStatusKeys() []string {
 	sc.mu.RLock()
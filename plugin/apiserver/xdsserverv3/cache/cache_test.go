@@ -0,0 +1,65 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	resourcev3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/plugin/apiserver/xdsserverv3/resource"
+)
+
+func TestFetchProxylessOtherNamespace(t *testing.T) {
+	sc := NewResourceCache(nil)
+	req := NewUpdateResourcesRequest()
+	req.AddProxylessNamespaces("default", resource.LDS, []types.Resource{&listenerv3.Listener{Name: "echo.default"}})
+	req.AddProxylessNamespaces("otherns", resource.LDS, []types.Resource{&listenerv3.Listener{Name: "echo.otherns"}})
+	assert.NoError(t, sc.UpdateResources(context.Background(), req))
+
+	node := &corev3.Node{Id: "proxyless~default/12345~127.0.0.1"}
+	fetch := func(names ...string) []string {
+		resp, err := sc.Fetch(context.Background(), &cachev3.Request{
+			Node:          node,
+			TypeUrl:       resourcev3.ListenerType,
+			ResourceNames: names,
+		})
+		assert.NoError(t, err)
+		out, err := resp.GetDiscoveryResponse()
+		assert.NoError(t, err)
+		ret := make([]string, 0, len(out.GetResources()))
+		for _, res := range out.GetResources() {
+			listener := &listenerv3.Listener{}
+			assert.NoError(t, res.UnmarshalTo(listener))
+			ret = append(ret, listener.GetName())
+		}
+		return ret
+	}
+
+	// 请求其他命名空间的服务, 资源从目标命名空间中获取
+	assert.Equal(t, []string{"echo.otherns"}, fetch("echo.otherns"))
+	assert.ElementsMatch(t, []string{"echo.default", "echo.otherns"}, fetch("echo.default", "echo.otherns"))
+	// 通配订阅使用自身所在的命名空间
+	assert.Equal(t, []string{"echo.default"}, fetch())
+}
@@ -19,11 +19,13 @@ package cache
 
 import (
 	"context"
+	"sync"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	"go.uber.org/zap"
 
+	"github.com/pole-io/pole-server/pkg/common/utils"
 	"github.com/pole-io/pole-server/plugin/apiserver/xdsserverv3/resource"
 )
 
@@ -37,30 +39,53 @@ func NewCallback(cacheMgr *ResourceCache, nodeMgr *resource.XDSNodeManager) *Cal
 type Callbacks struct {
 	cacheMgr *ResourceCache
 	nodeMgr  *resource.XDSNodeManager
+	// streamTokens streamId -> 建立 xDS 连接时携带的鉴权 token
+	streamTokens sync.Map
 }
 
-func (cb *Callbacks) OnStreamOpen(_ context.Context, id int64, typ string) error {
+func (cb *Callbacks) OnStreamOpen(ctx context.Context, id int64, typ string) error {
+	cb.saveStreamToken(ctx, id)
 	return nil
 }
 
-func (cb *Callbacks) OnDeltaStreamOpen(_ context.Context, id int64, typ string) error {
+func (cb *Callbacks) OnDeltaStreamOpen(ctx context.Context, id int64, typ string) error {
+	cb.saveStreamToken(ctx, id)
 	return nil
 }
 
+func (cb *Callbacks) saveStreamToken(ctx context.Context, id int64) {
+	if token := utils.ParseAuthToken(utils.ConvertGRPCContext(ctx)); token != "" {
+		cb.streamTokens.Store(id, token)
+	}
+}
+
+// bindNode 记录 xDS 节点信息, 并将连接上携带的鉴权 token 与节点关联
+func (cb *Callbacks) bindNode(id int64, node *corev3.Node) {
+	cb.nodeMgr.AddNodeIfAbsent(id, node)
+	if node == nil {
+		return
+	}
+	if token, ok := cb.streamTokens.Load(id); ok {
+		cb.nodeMgr.SetAuthToken(node.GetId(), token.(string))
+	}
+}
+
 func (cb *Callbacks) OnStreamClosed(id int64, node *corev3.Node) {
+	cb.streamTokens.Delete(id)
 	cb.nodeMgr.DelNode(id)
 	// 清理 cache
 	_ = cb.cacheMgr.CleanEnvoyNodeCache(node)
 }
 
 func (cb *Callbacks) OnDeltaStreamClosed(id int64, node *corev3.Node) {
+	cb.streamTokens.Delete(id)
 	cb.nodeMgr.DelNode(id)
 	// 清理 cache
 	_ = cb.cacheMgr.CleanEnvoyNodeCache(node)
 }
 
 func (cb *Callbacks) OnStreamRequest(id int64, req *discovery.DiscoveryRequest) error {
-	cb.nodeMgr.AddNodeIfAbsent(id, req.GetNode())
+	cb.bindNode(id, req.GetNode())
 	node := req.Node
 	req.Node = nil
 	log.Info("[XDSV3][Receive] receive stream request", zap.Int64("stream-id", id), zap.String("node-id", node.Id), zap.Any("req", req))
@@ -77,7 +102,7 @@ func (cb *Callbacks) OnStreamResponse(_ context.Context, id int64, req *discover
 }
 
 func (cb *Callbacks) OnStreamDeltaRequest(id int64, req *discovery.DeltaDiscoveryRequest) error {
-	cb.nodeMgr.AddNodeIfAbsent(id, req.GetNode())
+	cb.bindNode(id, req.GetNode())
 	node := req.Node
 	req.Node = nil
 	log.Info("[XDSV3][Receive] receive delta stream request", zap.Int64("stream-id", id), zap.String("node-id", node.Id), zap.Any("req", req))
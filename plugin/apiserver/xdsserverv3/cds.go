@@ -52,6 +52,10 @@ const (
 func (cds *CDSBuilder) Generate(option *resource.BuildOption) (interface{}, error) {
 	var clusters []types.Resource
 
+	if option.RunType == resource.RunTypeProxyless {
+		return cds.makeProxylessClusters(option), nil
+	}
+
 	// 默认 passthrough cluster
 	clusters = append(clusters, resource.PassthroughCluster)
 
@@ -89,7 +93,7 @@ func (cds *CDSBuilder) GenerateByDirection(option *resource.BuildOption,
 					Name:  "tls-mode",
 					Match: resource.MTLSTransportSocketMatch,
 					TransportSocket: resource.MakeTLSTransportSocket(&tlstrans.UpstreamTlsContext{
-						CommonTlsContext: resource.OutboundCommonTLSContext,
+						CommonTlsContext: resource.OutboundCommonTLSContext(option.BuiltinCA),
 						Sni:              fmt.Sprintf(SniTemp, svc.Name, svc.Namespace),
 					}),
 				},
@@ -110,7 +114,7 @@ func (cds *CDSBuilder) GenerateByDirection(option *resource.BuildOption,
 				{
 					Name: "tls-mode",
 					TransportSocket: resource.MakeTLSTransportSocket(&tlstrans.UpstreamTlsContext{
-						CommonTlsContext: resource.OutboundCommonTLSContext,
+						CommonTlsContext: resource.OutboundCommonTLSContext(option.BuiltinCA),
 						Sni:              fmt.Sprintf(SniTemp, svc.Name, svc.Namespace),
 					}),
 				},
@@ -147,3 +151,15 @@ func (cds *CDSBuilder) makeCluster(svcInfo *resource.ServiceInfo,
 	}
 	return c
 }
+
+// makeProxylessClusters 每个服务生成一个默认 cluster, 路由规则中的实例分组单独生成 cluster
+func (cds *CDSBuilder) makeProxylessClusters(option *resource.BuildOption) []types.Resource {
+	var clusters []types.Resource
+	for _, svcInfo := range option.Services {
+		clusters = append(clusters, resource.MakeProxylessCluster(svcInfo, nil))
+		for _, labels := range resource.ListProxylessSubsets(svcInfo) {
+			clusters = append(clusters, resource.MakeProxylessCluster(svcInfo, labels))
+		}
+	}
+	return clusters
+}
@@ -27,6 +27,8 @@ import (
 	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
 	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
 	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
 	"github.com/pole-io/pole-server/pkg/service"
@@ -42,6 +44,9 @@ func (eds *EDSBuilder) Init(svr service.DiscoverServer) {
 
 func (eds *EDSBuilder) Generate(option *resource.BuildOption) (interface{}, error) {
 	var resources []types.Resource
+	if option.RunType == resource.RunTypeProxyless {
+		return eds.makeProxylessEndpoints(option), nil
+	}
 	// sidecar 场景，如果流量方向是 envoy -> 业务 POD，那么 endpoint 只能是 本地 127.0.0.1
 	switch option.TrafficDirection {
 	case core.TrafficDirection_INBOUND:
@@ -75,8 +80,12 @@ func (eds *EDSBuilder) makeBoundEndpoints(option *resource.BuildOption,
 }
 
 func (eds *EDSBuilder) buildServiceEndpoint(serviceInfo *resource.ServiceInfo) []*endpoint.LocalityLbEndpoints {
+	return eds.buildEndpoints(serviceInfo.Instances)
+}
+
+func (eds *EDSBuilder) buildEndpoints(instances []*apiservice.Instance) []*endpoint.LocalityLbEndpoints {
 	locality := map[string]map[string]map[string][]*endpoint.LbEndpoint{}
-	for _, instance := range serviceInfo.Instances {
+	for _, instance := range instances {
 		// 处于隔离状态或者权重为0的实例不进行下发
 		if !resource.IsNormalEndpoint(instance) {
 			continue
@@ -119,7 +128,7 @@ func (eds *EDSBuilder) buildServiceEndpoint(serviceInfo *resource.ServiceInfo) [
 		locality[region][zone][campus] = append(locality[region][zone][campus], ep)
 	}
 
-	retVal := make([]*endpoint.LocalityLbEndpoints, 0, len(serviceInfo.Instances))
+	retVal := make([]*endpoint.LocalityLbEndpoints, 0, len(instances))
 
 	for region := range locality {
 		for zone := range locality[region] {
@@ -201,3 +210,35 @@ func (eds *EDSBuilder) makeSelfEndpoint(option *resource.BuildOption) []types.Re
 	clusterLoads = append(clusterLoads, cla)
 	return clusterLoads
 }
+
+// makeProxylessEndpoints 默认 cluster 包含服务的所有实例, 实例分组的 cluster 只包含标签匹配的实例
+func (eds *EDSBuilder) makeProxylessEndpoints(option *resource.BuildOption) []types.Resource {
+	var clusterLoads []types.Resource
+	for _, svcInfo := range option.Services {
+		subsets := append([]map[string]string{nil}, resource.ListProxylessSubsets(svcInfo)...)
+		for _, labels := range subsets {
+			cla := &endpoint.ClusterLoadAssignment{
+				ClusterName: resource.MakeProxylessClusterName(svcInfo.ServiceKey, labels),
+			}
+			if !option.ForceDelete {
+				instances := make([]*apiservice.Instance, 0, len(svcInfo.Instances))
+				for _, ins := range svcInfo.Instances {
+					if resource.MatchProxylessSubset(ins, labels) {
+						instances = append(instances, ins)
+					}
+				}
+				cla.Endpoints = eds.buildEndpoints(instances)
+				// gRPC 会忽略没有设置权重的 locality
+				for _, localityEndpoints := range cla.Endpoints {
+					var weight uint32
+					for _, ep := range localityEndpoints.GetLbEndpoints() {
+						weight += ep.GetLoadBalancingWeight().GetValue()
+					}
+					localityEndpoints.LoadBalancingWeight = wrapperspb.UInt32(weight)
+				}
+			}
+			clusterLoads = append(clusterLoads, cla)
+		}
+	}
+	return clusterLoads
+}
@@ -54,6 +54,8 @@ type XdsResourceGenerator struct {
 	versionNum      *atomic.Uint64
 	xdsNodesMgr     *resource.XDSNodeManager
 	svcInfoProvider CurrentServiceInfoProvider
+	// builtinCA 是否开启了内置 CA
+	builtinCA bool
 }
 
 // Generate 构建 XDS 资源缓存数据信息
@@ -92,6 +94,7 @@ func (x *XdsResourceGenerator) Generate(versionLocal string, needUpdate, needRem
 				Namespace:        namespace,
 				Services:         services,
 				TrafficDirection: direction,
+				BuiltinCA:        x.builtinCA,
 			}
 			// sidecar 和 gateway 大部份资源都是复用的，所以这里只需要构建一次即可，gateway 只有 RDS/LDS 存在特别，单独针对构建即可
 			if runType == resource.RunTypeSidecar {
@@ -110,8 +113,24 @@ func (x *XdsResourceGenerator) Generate(versionLocal string, needUpdate, needRem
 		}
 	}
 
+	// proxyless gRPC 只需要出流量方向的资源, 且不区分 TLS 以及按需加载
+	proxylessOp := func(infos ServiceInfos, isRemove bool) {
+		for namespace, services := range infos {
+			opt := &resource.BuildOption{
+				RunType:          resource.RunTypeProxyless,
+				Namespace:        namespace,
+				Services:         services,
+				TLSMode:          resource.TLSModeNone,
+				TrafficDirection: corev3.TrafficDirection_OUTBOUND,
+			}
+			for _, xdsType := range []resource.XDSType{resource.LDS, resource.RDS, resource.CDS, resource.EDS} {
+				x.buildUpdateRequest(updateRequest, xdsType, opt, isRemove)
+			}
+		}
+	}
+
 	wg := &sync.WaitGroup{}
-	wg.Add(2)
+	wg.Add(3)
 	go func() {
 		defer wg.Done()
 		// 处理 Sideacr
@@ -126,6 +145,13 @@ func (x *XdsResourceGenerator) Generate(versionLocal string, needUpdate, needRem
 		deltaOp(resource.RunTypeGateway, needRemove, true)
 	}()
 
+	go func() {
+		defer wg.Done()
+		// 处理 proxyless gRPC
+		proxylessOp(needUpdate, false)
+		proxylessOp(needRemove, true)
+	}()
+
 	wg.Wait()
 
 	if err := x.cache.UpdateResources(context.Background(), updateRequest); err != nil {
@@ -134,11 +160,16 @@ func (x *XdsResourceGenerator) Generate(versionLocal string, needUpdate, needRem
 }
 
 func (x *XdsResourceGenerator) buildOneEnvoyXDSCache(node *resource.XDSClient) error {
+	// proxyless gRPC 的 LDS 资源是命名空间级别的, 不需要针对节点单独构建
+	if node.RunType == resource.RunTypeProxyless {
+		return nil
+	}
 	opt := &resource.BuildOption{
 		RunType:   node.RunType,
 		Client:    node,
 		TLSMode:   node.TLSMode,
 		Namespace: node.GetSelfNamespace(),
+		BuiltinCA: x.builtinCA,
 		SelfService: svctypes.ServiceKey{
 			Namespace: node.GetSelfNamespace(),
 			Name:      node.GetSelfService(),
@@ -182,6 +213,15 @@ func (x *XdsResourceGenerator) buildUpdateRequest(req *cache.UpdateResourcesRequ
 		return
 	}
 
+	if opt.RunType == resource.RunTypeProxyless {
+		if opt.ForceDelete {
+			req.RemoveProxylessNamespaces(opt.Namespace, xdsType, xxds)
+		} else {
+			req.AddProxylessNamespaces(opt.Namespace, xdsType, xxds)
+		}
+		return
+	}
+
 	switch opt.TLSMode {
 	case resource.TLSModeNone:
 		if opt.ForceDelete {
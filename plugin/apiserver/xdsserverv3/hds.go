@@ -26,7 +26,6 @@ import (
 
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
-	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	healthservice "github.com/envoyproxy/go-control-plane/envoy/service/health/v3"
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
@@ -97,87 +96,87 @@ func (x *XDSServer) StreamHealthCheck(checksvr healthservice.HealthDiscoveryServ
 		zap.String("user-agent", userAgent),
 	)
 
-	for {
-		req, err := checksvr.Recv()
-		if err != nil {
-			if io.EOF == err {
-				return nil
-			}
-			return err
-		}
-
-		var client *resource.XDSClient
-		checkReq := req.GetHealthCheckRequest()
-		if checkReq != nil {
-			client = resource.ParseXDSClient(checkReq.Node)
-			code := x.registerService(context.Background(), client)
-			if code != apimodel.Code_ExecuteSuccess {
-				return status.Errorf(codes.Unavailable, "fail to register services, code is %v", code)
-			}
-			resp := buildHealthCheckSpecifier(client)
-			if log.DebugEnabled() {
-				hdsRespStr := toJsonStr(resp)
-				log.Debugf("[XDSV3] send hds register resp to channel %s, value is \n%s",
-					clientAddress, hdsRespStr)
+	reqCh := make(chan *healthservice.HealthCheckRequestOrEndpointHealthResponse)
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			req, err := checksvr.Recv()
+			if err != nil {
+				errCh <- err
+				return
 			}
-			if err = checksvr.Send(resp); nil != err {
-				log.Errorf("[XDSV3] fail to send hds register resp to channel %s, err is %v",
-					clientAddress, err)
-				return err
+			select {
+			case reqCh <- req:
+			case <-checksvr.Context().Done():
+				return
 			}
 		}
-		if endpointHealthResponse := req.GetEndpointHealthResponse(); nil != endpointHealthResponse {
-			// 处理心跳上报
-			if client == nil {
-				return status.Errorf(codes.NotFound, "xds node info not found")
-			}
-			err := x.processEndpointHealthResponse(client, endpointHealthResponse)
-			if nil != err {
-				return err
-			}
+	}()
+
+	var (
+		client *resource.XDSClient
+		notify <-chan struct{}
+	)
+	defer func() {
+		if client != nil {
+			x.hds.remove(client.ID)
 		}
-	}
-}
+	}()
 
-func buildHealthCheckSpecifier(proxy *resource.XDSClient) *healthservice.HealthCheckSpecifier {
-	var minTtl = defaultTTl
-	specifier := &healthservice.HealthCheckSpecifier{}
-	if len(proxy.GetRegisterServices()) == 0 {
-		return specifier
-	}
-	for _, serviceInfo := range proxy.GetRegisterServices() {
-		if len(serviceInfo.Ports) == 0 {
-			continue
+	sendSpecifier := func() error {
+		resp := x.hds.specifier(client.ID)
+		if resp == nil {
+			return nil
 		}
-		if serviceInfo.HealthCheckTtl > 0 && serviceInfo.HealthCheckTtl < minTtl {
-			minTtl = serviceInfo.HealthCheckTtl
+		if log.DebugEnabled() {
+			log.Debugf("[XDSV3] send hds specifier to channel %s, value is \n%s",
+				clientAddress, toJsonStr(resp))
 		}
-		for _, ports := range serviceInfo.Ports {
-			for _, port := range ports {
-				chc := &healthservice.ClusterHealthCheck{}
-				specifier.ClusterHealthChecks = append(specifier.ClusterHealthChecks, chc)
-				chc.ClusterName = buildSubsetName(core.TrafficDirection_INBOUND, port, serviceInfo.Name)
-				chc.HealthChecks = []*corev3.HealthCheck{
-					buildHealthCheck(serviceInfo.HealthCheckPath),
+		if err := checksvr.Send(resp); nil != err {
+			log.Errorf("[XDSV3] fail to send hds specifier to channel %s, err is %v",
+				clientAddress, err)
+			return err
+		}
+		return nil
+	}
+
+	for {
+		select {
+		case <-checksvr.Context().Done():
+			return nil
+		case err := <-errCh:
+			if io.EOF == err {
+				return nil
+			}
+			return err
+		case <-notify:
+			// 节点负责探测的实例发生变化
+			if err := sendSpecifier(); err != nil {
+				return err
+			}
+		case req := <-reqCh:
+			if checkReq := req.GetHealthCheckRequest(); checkReq != nil {
+				if client == nil {
+					client = resource.ParseXDSClient(checkReq.Node)
+					code := x.registerService(context.Background(), client)
+					if code != apimodel.Code_ExecuteSuccess {
+						return status.Errorf(codes.Unavailable, "fail to register services, code is %v", code)
+					}
+					// 首次分配的结果通过 notify 推送
+					notify = x.hds.watch(client)
+				} else if err := sendSpecifier(); err != nil {
+					return err
 				}
-				localityEndpoints := &healthservice.LocalityEndpoints{}
-				theEndpoint := &endpoint.Endpoint{
-					Address: &corev3.Address{
-						Address: &core.Address_SocketAddress{
-							SocketAddress: &core.SocketAddress{
-								Address:       proxy.IPAddr,
-								PortSpecifier: &core.SocketAddress_PortValue{PortValue: uint32(port)},
-							}},
-					},
+			}
+			if endpointHealthResponse := req.GetEndpointHealthResponse(); nil != endpointHealthResponse {
+				// 处理心跳上报
+				if client == nil {
+					return status.Errorf(codes.NotFound, "xds node info not found")
 				}
-				localityEndpoints.Endpoints = append(localityEndpoints.Endpoints, theEndpoint)
-				chc.LocalityEndpoints = append(chc.LocalityEndpoints, localityEndpoints)
+				x.processEndpointHealthResponse([]string{client.ID}, endpointHealthResponse)
 			}
 		}
 	}
-	targetTtl := time.Second * time.Duration(minTtl)
-	specifier.Interval = durationpb.New(targetTtl)
-	return specifier
 }
 
 const (
@@ -228,14 +227,33 @@ func toJsonStr(msg proto.Message) string {
 	return jsonStr
 }
 
-// TODO(htuch): Unlike the gRPC version, there is no stream-based binding of
-// request/response. Should we add an identifier to the HealthCheckSpecifier
-// to bind with the response?
+// FetchHealthCheck 单次拉取健康检查任务或者上报探测结果. 拉取任务的节点需要在 N 个上报周期内再次拉取,
+// 否则其负责的实例会重新分配给其他节点. EndpointHealthResponse 不携带节点信息, 因此上报的探测结果只对
+// 来自同一 IP 并且已经拉取过任务的节点所负责的实例生效
 func (x *XDSServer) FetchHealthCheck(ctx context.Context,
 	req *healthservice.HealthCheckRequestOrEndpointHealthResponse) (*healthservice.HealthCheckSpecifier, error) {
 
-	// TODO: implement
-	return nil, status.Errorf(codes.Unimplemented, "FetchHealthCheck unimplemented")
+	if checkReq := req.GetHealthCheckRequest(); checkReq != nil {
+		if checkReq.GetNode() == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "xds node info not found")
+		}
+		client := resource.ParseXDSClient(checkReq.Node)
+		code := x.registerService(context.Background(), client)
+		if code != apimodel.Code_ExecuteSuccess {
+			return nil, status.Errorf(codes.Unavailable, "fail to register services, code is %v", code)
+		}
+		return x.hds.fetch(client), nil
+	}
+	if endpointHealthResponse := req.GetEndpointHealthResponse(); endpointHealthResponse != nil {
+		clientIP, _ := utils.ConvertGRPCContext(ctx).Value(types.StringContext("client-ip")).(string)
+		nodeIDs := x.hds.fetchNodes(clientIP)
+		if len(nodeIDs) == 0 {
+			return nil, status.Errorf(codes.PermissionDenied, "no health check assigned to client %s", clientIP)
+		}
+		x.processEndpointHealthResponse(nodeIDs, endpointHealthResponse)
+		return &healthservice.HealthCheckSpecifier{}, nil
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "health check request or endpoint health response is required")
 }
 
 const (
@@ -350,71 +368,60 @@ func (x *XDSServer) registerService(ctx context.Context, client *resource.XDSCli
 	return apimodel.Code_ExecuteSuccess
 }
 
+// processEndpointHealthResponse 将 Envoy 的探测结果应用到实例的健康状态上, 探测成功时上报心跳,
+// 探测失败时不再上报心跳, 由健康检查模块在心跳超时后将实例置为不健康, DRAINING 状态的实例直接反注册.
+// 只处理分配给上报节点探测的实例, 其余实例的上报结果直接忽略
 func (x *XDSServer) processEndpointHealthResponse(
-	client *resource.XDSClient, endpointHealthResponse *healthservice.EndpointHealthResponse) error {
-	// 处理心跳上报
-	endpoints := endpointHealthResponse.GetClusterEndpointsHealth()
-	if len(endpoints) == 0 {
-		return nil
-	}
-	log.Debugf("heartbeat for service(%s) namespace(%s)", client.GetSelfService(), client.GetSelfNamespace())
-	namespaceName := client.GetSelfNamespace()
-	serviceName := client.GetSelfService()
+	nodeIDs []string, endpointHealthResponse *healthservice.EndpointHealthResponse) {
 	ctx := context.Background()
-	for _, targetEndpoint := range endpoints {
-		var host string
-		var port uint32
-		localityEndpoints := targetEndpoint.GetLocalityEndpointsHealth()
-		for _, localityEndpoint := range localityEndpoints {
-			for _, endopint := range localityEndpoint.EndpointsHealth {
-				address := endopint.GetEndpoint().GetAddress().GetAddress()
-				if socketAddr, ok := address.(*corev3.Address_SocketAddress); ok {
-					host = socketAddr.SocketAddress.Address
-					portSpecifier := socketAddr.SocketAddress.PortSpecifier
-					if portValue, ok := portSpecifier.(*corev3.SocketAddress_PortValue); ok {
-						port = portValue.PortValue
-					}
+	for _, clusterHealth := range endpointHealthResponse.GetClusterEndpointsHealth() {
+		for _, localityEndpoint := range clusterHealth.GetLocalityEndpointsHealth() {
+			for _, endpointHealth := range localityEndpoint.GetEndpointsHealth() {
+				socketAddr := endpointHealth.GetEndpoint().GetAddress().GetSocketAddress()
+				host := socketAddr.GetAddress()
+				port := socketAddr.GetPortValue()
+				if len(host) == 0 || port == 0 {
+					log.Errorf("[XDSV3] tuple arguments is invalid, cluster %s, host %s, port %d",
+						clusterHealth.GetClusterName(), host, port)
+					continue
 				}
-				if len(namespaceName) == 0 || len(host) == 0 || port == 0 {
-					log.Errorf("tuple arguments is invalid, namespace %s, host %s, port %d", namespaceName, host, port)
-					return status.Errorf(codes.InvalidArgument,
-						"tuple arguments is invalid, namespace %s, host %s, port %d",
-						namespaceName, host, port)
+				svcKey, ok := x.hds.resolveEndpoint(nodeIDs, clusterHealth.GetClusterName(), host, port)
+				if !ok {
+					log.Warn("[XDSV3] hds endpoint not assigned to node, ignore endpoint health",
+						zap.Strings("nodes", nodeIDs), zap.String("cluster", clusterHealth.GetClusterName()),
+						zap.String("host", host), zap.Uint32("port", port))
+					continue
 				}
-
 				ins := &service_manage.Instance{
-					Namespace: protobuf.NewStringValue(client.GetSelfNamespace()),
-					Service:   protobuf.NewStringValue(client.GetSelfService()),
+					Namespace: protobuf.NewStringValue(svcKey.Namespace),
+					Service:   protobuf.NewStringValue(svcKey.Name),
 					Host:      protobuf.NewStringValue(host),
 					Port:      protobuf.NewUInt32Value(port),
 				}
 
-				switch endopint.GetHealthStatus() {
+				switch endpointHealth.GetHealthStatus() {
 				case corev3.HealthStatus_HEALTHY:
 					resp := x.healthSvr.Report(ctx, ins)
 					code := apimodel.Code(resp.GetCode().GetValue())
 					if code != apimodel.Code_ExecuteSuccess && code != apimodel.Code_HeartbeatExceedLimit {
-						log.Errorf("[XdsV2Server] fail to do heartbeat, namespace %s, service %s, host %s, port %d, err is %v",
-							namespaceName, serviceName, host, port, resp.GetInfo().GetValue())
-						return status.Errorf(codes.InvalidArgument,
-							"fail to do heartbeat, code is %d, namespace %s, service %s, host %s, port %d",
-							code, namespaceName, serviceName, host, port)
+						log.Errorf("[XDSV3] fail to do heartbeat, namespace %s, service %s, host %s, port %d, err is %v",
+							svcKey.Namespace, svcKey.Name, host, port, resp.GetInfo().GetValue())
 					}
 				case corev3.HealthStatus_DRAINING:
 					// 进行反注册
 					resp := x.namingServer.DeregisterInstance(ctx, ins)
 					code := apimodel.Code(resp.GetCode().GetValue())
 					if code != apimodel.Code_ExecuteSuccess && code != apimodel.Code_NotFoundResource {
-						log.Errorf("[XdsV2Server] fail to process endpoint health, err is %v", resp.GetInfo().GetValue())
-						return status.Errorf(codes.InvalidArgument,
-							"fail to do deregister, code is %d, namespace %s, service %s, host %s, port %d",
-							code, namespaceName, serviceName, host, port)
+						log.Errorf("[XDSV3] fail to do deregister, namespace %s, service %s, host %s, port %d, err is %v",
+							svcKey.Namespace, svcKey.Name, host, port, resp.GetInfo().GetValue())
 					}
 				default:
+					if log.DebugEnabled() {
+						log.Debugf("[XDSV3] node %v report endpoint %s:%d of %s unhealthy: %s", nodeIDs,
+							host, port, svcKey.Domain(), endpointHealth.GetHealthStatus())
+					}
 				}
 			}
 		}
-
 	}
-	return nil
 }
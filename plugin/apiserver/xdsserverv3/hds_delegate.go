@@ -0,0 +1,371 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package xdsserverv3
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	healthservice "github.com/envoyproxy/go-control-plane/envoy/service/health/v3"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/plugin/apiserver/xdsserverv3/resource"
+)
+
+const (
+	// hdsRebuildInterval 定期重新计算健康检查的分配结果, 感知实例的注册以及注销
+	hdsRebuildInterval = 5 * time.Second
+	// hdsFetchLeaseTimes 通过 FetchHealthCheck 拉取任务的节点, 超过 N 个上报周期没有再次拉取则认为节点已经下线
+	hdsFetchLeaseTimes = 3
+)
+
+// hdsEndpoint 需要委托 Envoy 进行健康检查的实例
+type hdsEndpoint struct {
+	svcKey svctypes.ServiceKey
+	host   string
+	port   uint32
+	// path 不为空时使用 HTTP 探测, 否则使用 TCP 探测
+	path string
+	ttl  uint32
+}
+
+func (e *hdsEndpoint) key() string {
+	return e.svcKey.Namespace + "/" + e.svcKey.Name + "/" + e.host + ":" + strconv.FormatUint(uint64(e.port), 10)
+}
+
+// assignKey HDS cluster 名称以及实例地址, 用于校验 Envoy 上报的实例是否分配给了该节点
+func assignKey(cluster, host string, port uint32) string {
+	return cluster + "/" + host + ":" + strconv.FormatUint(uint64(port), 10)
+}
+
+// hdsChecker 开启了 HDS 的 Envoy 节点
+type hdsChecker struct {
+	client *resource.XDSClient
+	// notify 分配结果变化时通知 stream 推送新的 HealthCheckSpecifier, 通过 fetch 拉取的节点为 nil
+	notify chan struct{}
+	// expireAt 通过 fetch 拉取的节点的租约到期时间
+	expireAt  time.Time
+	specifier *healthservice.HealthCheckSpecifier
+	// assigned 分配给该节点探测的实例, assignKey -> 服务
+	assigned map[string]svctypes.ServiceKey
+}
+
+// healthDelegator 将通过 xDS 注册并开启了健康检查的实例分散给各个 Envoy 节点进行探测,
+// 每个实例只会被一个节点探测, 优先由实例所在主机上的 Envoy 进行探测, 其余实例按照 rendezvous hash 分配,
+// 避免每个 Envoy 都探测所有实例
+type healthDelegator struct {
+	lock     sync.Mutex
+	checkers map[string]*hdsChecker
+	// endpoints 最近一次 rebuild 获取的实例列表, 节点上下线时基于该列表重新分配, 避免每次都遍历实例缓存
+	endpoints []*hdsEndpoint
+	loaded    bool
+	// listEndpoints 获取所有需要委托探测的实例
+	listEndpoints func() []*hdsEndpoint
+}
+
+func newHealthDelegator(listEndpoints func() []*hdsEndpoint) *healthDelegator {
+	return &healthDelegator{
+		checkers:      map[string]*hdsChecker{},
+		listEndpoints: listEndpoints,
+	}
+}
+
+func (h *healthDelegator) run(ctx context.Context) {
+	ticker := time.NewTicker(hdsRebuildInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			h.rebuild()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// watch 注册通过 stream 连接的节点, 返回的 channel 在节点的分配结果变化时收到通知
+func (h *healthDelegator) watch(client *resource.XDSClient) <-chan struct{} {
+	notify := make(chan struct{}, 1)
+	h.lock.Lock()
+	h.checkers[client.ID] = &hdsChecker{
+		client: client,
+		notify: notify,
+	}
+	h.lock.Unlock()
+	h.reassign()
+	return notify
+}
+
+// fetch 注册通过 FetchHealthCheck 拉取任务的节点, 并返回该节点的分配结果
+func (h *healthDelegator) fetch(client *resource.XDSClient) *healthservice.HealthCheckSpecifier {
+	h.lock.Lock()
+	checker, ok := h.checkers[client.ID]
+	if !ok {
+		checker = &hdsChecker{
+			client: client,
+		}
+		h.checkers[client.ID] = checker
+	}
+	h.lock.Unlock()
+
+	// 已经注册的节点直接返回当前的分配结果并续约, 分配结果由定时 rebuild 刷新
+	if !ok {
+		h.reassign()
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	specifier := checker.specifier
+	if checker.notify == nil {
+		checker.expireAt = time.Now().Add(hdsFetchLeaseTimes * specifier.GetInterval().AsDuration())
+	}
+	return specifier
+}
+
+// remove 节点断开连接后, 其负责的实例重新分配给其他节点
+func (h *healthDelegator) remove(nodeID string) {
+	h.lock.Lock()
+	delete(h.checkers, nodeID)
+	h.lock.Unlock()
+	h.reassign()
+}
+
+// specifier 获取节点当前的分配结果
+func (h *healthDelegator) specifier(nodeID string) *healthservice.HealthCheckSpecifier {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if checker, ok := h.checkers[nodeID]; ok {
+		return checker.specifier
+	}
+	return nil
+}
+
+// fetchNodes 查找来自该 IP 并通过 FetchHealthCheck 拉取任务的节点
+func (h *healthDelegator) fetchNodes(ip string) []string {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	var nodeIDs []string
+	for id, checker := range h.checkers {
+		if checker.notify == nil && checker.client.IPAddr == ip {
+			nodeIDs = append(nodeIDs, id)
+		}
+	}
+	return nodeIDs
+}
+
+// resolveEndpoint 校验实例是否分配给了其中某个节点进行探测, 并返回实例所属的服务
+func (h *healthDelegator) resolveEndpoint(nodeIDs []string,
+	cluster, host string, port uint32) (svctypes.ServiceKey, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	key := assignKey(cluster, host, port)
+	for _, id := range nodeIDs {
+		checker, ok := h.checkers[id]
+		if !ok {
+			continue
+		}
+		if svcKey, ok := checker.assigned[key]; ok {
+			return svcKey, true
+		}
+	}
+	return svctypes.ServiceKey{}, false
+}
+
+// rebuild 重新获取需要委托探测的实例并重新分配
+func (h *healthDelegator) rebuild() {
+	endpoints := h.listEndpoints()
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.endpoints = endpoints
+	h.loaded = true
+	h.assignLocked()
+}
+
+// reassign 节点上下线时基于已经获取的实例列表重新分配
+func (h *healthDelegator) reassign() {
+	h.lock.Lock()
+	loaded := h.loaded
+	h.lock.Unlock()
+	if !loaded {
+		h.rebuild()
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.assignLocked()
+}
+
+func (h *healthDelegator) assignLocked() {
+	endpoints := h.endpoints
+
+	now := time.Now()
+	for id, checker := range h.checkers {
+		if checker.notify == nil && !checker.expireAt.IsZero() && now.After(checker.expireAt) {
+			delete(h.checkers, id)
+		}
+	}
+
+	assigned := make(map[string][]*hdsEndpoint, len(h.checkers))
+	for _, ep := range endpoints {
+		if checker := h.selectChecker(ep); checker != nil {
+			assigned[checker.client.ID] = append(assigned[checker.client.ID], ep)
+		}
+	}
+
+	for id, checker := range h.checkers {
+		specifier, assignedKeys := buildDelegateSpecifier(assigned[id])
+		checker.assigned = assignedKeys
+		if proto.Equal(specifier, checker.specifier) {
+			continue
+		}
+		checker.specifier = specifier
+		if checker.notify != nil {
+			select {
+			case checker.notify <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// selectChecker 优先选择实例所在主机的节点, 其次选择同命名空间的节点, 最后在所有节点中选择
+func (h *healthDelegator) selectChecker(ep *hdsEndpoint) *hdsChecker {
+	var (
+		sameNamespace []*hdsChecker
+		all           []*hdsChecker
+	)
+	for _, checker := range h.checkers {
+		if checker.client.IPAddr == ep.host && checker.client.GetSelfNamespace() == ep.svcKey.Namespace {
+			return checker
+		}
+		if checker.client.GetSelfNamespace() == ep.svcKey.Namespace {
+			sameNamespace = append(sameNamespace, checker)
+		}
+		all = append(all, checker)
+	}
+	if len(sameNamespace) > 0 {
+		return rendezvousSelect(ep.key(), sameNamespace)
+	}
+	return rendezvousSelect(ep.key(), all)
+}
+
+func rendezvousSelect(key string, checkers []*hdsChecker) *hdsChecker {
+	var (
+		selected *hdsChecker
+		maxScore uint64
+	)
+	for _, checker := range checkers {
+		hasher := fnv.New64a()
+		_, _ = hasher.Write([]byte(checker.client.ID))
+		_, _ = hasher.Write([]byte(key))
+		score := hasher.Sum64()
+		if selected == nil || score > maxScore || (score == maxScore && checker.client.ID < selected.client.ID) {
+			selected = checker
+			maxScore = score
+		}
+	}
+	return selected
+}
+
+func buildDelegateSpecifier(
+	endpoints []*hdsEndpoint) (*healthservice.HealthCheckSpecifier, map[string]svctypes.ServiceKey) {
+	minTtl := uint32(defaultTTl)
+	checks := map[string]*healthservice.ClusterHealthCheck{}
+	assigned := make(map[string]svctypes.ServiceKey, len(endpoints))
+	for _, ep := range endpoints {
+		if ep.ttl > 0 && ep.ttl < minTtl {
+			minTtl = ep.ttl
+		}
+		name := buildSubsetName(core.TrafficDirection_INBOUND, int(ep.port), ep.svcKey.Domain())
+		assigned[assignKey(name, ep.host, ep.port)] = ep.svcKey
+		chc, ok := checks[name]
+		if !ok {
+			chc = &healthservice.ClusterHealthCheck{
+				ClusterName:       name,
+				HealthChecks:      []*core.HealthCheck{buildHealthCheck(ep.path)},
+				LocalityEndpoints: []*healthservice.LocalityEndpoints{{}},
+			}
+			checks[name] = chc
+		}
+		chc.LocalityEndpoints[0].Endpoints = append(chc.LocalityEndpoints[0].Endpoints, &endpoint.Endpoint{
+			Address: &core.Address{
+				Address: &core.Address_SocketAddress{
+					SocketAddress: &core.SocketAddress{
+						Address:       ep.host,
+						PortSpecifier: &core.SocketAddress_PortValue{PortValue: ep.port},
+					},
+				},
+			},
+		})
+	}
+
+	specifier := &healthservice.HealthCheckSpecifier{
+		Interval: durationpb.New(time.Duration(minTtl) * time.Second),
+	}
+	for _, chc := range checks {
+		sort.Slice(chc.LocalityEndpoints[0].Endpoints, func(i, j int) bool {
+			return endpointAddress(chc.LocalityEndpoints[0].Endpoints[i]) <
+				endpointAddress(chc.LocalityEndpoints[0].Endpoints[j])
+		})
+		specifier.ClusterHealthChecks = append(specifier.ClusterHealthChecks, chc)
+	}
+	sort.Slice(specifier.ClusterHealthChecks, func(i, j int) bool {
+		return specifier.ClusterHealthChecks[i].ClusterName < specifier.ClusterHealthChecks[j].ClusterName
+	})
+	return specifier, assigned
+}
+
+func endpointAddress(ep *endpoint.Endpoint) string {
+	addr := ep.GetAddress().GetSocketAddress()
+	return addr.GetAddress() + ":" + strconv.FormatUint(uint64(addr.GetPortValue()), 10)
+}
+
+// listHealthCheckEndpoints 通过 xDS 注册、开启了健康检查并且没有被隔离的实例都需要委托 Envoy 进行探测
+func (x *XDSServer) listHealthCheckEndpoints() []*hdsEndpoint {
+	var endpoints []*hdsEndpoint
+	_ = x.namingServer.Cache().Instance().IteratorInstances(func(key string, ins *svctypes.Instance) (bool, error) {
+		if ins.Metadata()[svctypes.MetadataRegisterFrom] != x.GetProtocol() {
+			return true, nil
+		}
+		if !ins.EnableHealthCheck() || ins.Isolate() {
+			return true, nil
+		}
+		endpoints = append(endpoints, &hdsEndpoint{
+			svcKey: svctypes.ServiceKey{
+				Namespace: ins.Namespace(),
+				Name:      ins.Service(),
+			},
+			host: ins.Host(),
+			port: ins.Port(),
+			path: ins.Metadata()[svctypes.MetadataInternalMetaHealthCheckPath],
+			ttl:  ins.HealthCheck().GetHeartbeat().GetTtl().GetValue(),
+		})
+		return true, nil
+	})
+	return endpoints
+}
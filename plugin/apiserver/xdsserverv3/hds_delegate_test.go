@@ -0,0 +1,173 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package xdsserverv3
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	healthservice "github.com/envoyproxy/go-control-plane/envoy/service/health/v3"
+	"github.com/stretchr/testify/assert"
+
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/plugin/apiserver/xdsserverv3/resource"
+)
+
+func newTestHDSClient(namespace, uuid, ip string) *resource.XDSClient {
+	return resource.ParseXDSClient(&core.Node{
+		Id: fmt.Sprintf("sidecar~%s/%s~%s", namespace, uuid, ip),
+	})
+}
+
+func countAssigned(specifier *healthservice.HealthCheckSpecifier) int {
+	count := 0
+	for _, chc := range specifier.GetClusterHealthChecks() {
+		for _, locality := range chc.GetLocalityEndpoints() {
+			count += len(locality.GetEndpoints())
+		}
+	}
+	return count
+}
+
+func Test_healthDelegator_Assign(t *testing.T) {
+	var endpoints []*hdsEndpoint
+	for i := 0; i < 100; i++ {
+		endpoints = append(endpoints, &hdsEndpoint{
+			svcKey: svctypes.ServiceKey{Namespace: "default", Name: "echo"},
+			host:   fmt.Sprintf("10.0.1.%d", i),
+			port:   8080,
+			ttl:    5,
+		})
+	}
+	// 与节点 node-1 在同一主机上的实例
+	endpoints = append(endpoints, &hdsEndpoint{
+		svcKey: svctypes.ServiceKey{Namespace: "default", Name: "local"},
+		host:   "10.0.0.1",
+		port:   9090,
+		path:   "/health",
+	})
+	h := newHealthDelegator(func() []*hdsEndpoint { return endpoints })
+
+	notify1 := h.watch(newTestHDSClient("default", "node-1", "10.0.0.1"))
+	notify2 := h.watch(newTestHDSClient("default", "node-2", "10.0.0.2"))
+	<-notify1
+	<-notify2
+
+	spec1 := h.specifier(newTestHDSClient("default", "node-1", "10.0.0.1").ID)
+	spec2 := h.specifier(newTestHDSClient("default", "node-2", "10.0.0.2").ID)
+	// 每个实例只会被一个节点探测, 并且分散在各个节点上
+	assert.Equal(t, len(endpoints), countAssigned(spec1)+countAssigned(spec2))
+	assert.Greater(t, countAssigned(spec1), 1)
+	assert.Greater(t, countAssigned(spec2), 0)
+	assert.Equal(t, 5*time.Second, spec1.GetInterval().AsDuration())
+
+	localCluster := buildSubsetName(core.TrafficDirection_INBOUND, 9090, "local.default")
+	var found bool
+	for _, chc := range spec1.GetClusterHealthChecks() {
+		if chc.GetClusterName() == localCluster {
+			found = true
+			assert.Equal(t, "/health", chc.GetHealthChecks()[0].GetHttpHealthCheck().GetPath())
+		}
+	}
+	assert.True(t, found, "endpoint on the same host should be checked by the local node")
+	node1 := newTestHDSClient("default", "node-1", "10.0.0.1").ID
+	node2 := newTestHDSClient("default", "node-2", "10.0.0.2").ID
+	svcKey, ok := h.resolveEndpoint([]string{node1}, localCluster, "10.0.0.1", 9090)
+	assert.True(t, ok)
+	assert.Equal(t, svctypes.ServiceKey{Namespace: "default", Name: "local"}, svcKey)
+	// 没有分配给该节点的实例不接受其上报的探测结果
+	_, ok = h.resolveEndpoint([]string{node2}, localCluster, "10.0.0.1", 9090)
+	assert.False(t, ok)
+	_, ok = h.resolveEndpoint([]string{node1}, localCluster, "10.0.0.3", 9090)
+	assert.False(t, ok)
+
+	// 节点下线后实例全部转移给剩余的节点
+	h.remove(newTestHDSClient("default", "node-2", "10.0.0.2").ID)
+	spec1 = h.specifier(newTestHDSClient("default", "node-1", "10.0.0.1").ID)
+	assert.Equal(t, len(endpoints), countAssigned(spec1))
+	select {
+	case <-notify1:
+	default:
+		t.Fatal("node-1 should be notified when assignment changed")
+	}
+}
+
+func Test_healthDelegator_PreferSameNamespace(t *testing.T) {
+	endpoints := []*hdsEndpoint{
+		{svcKey: svctypes.ServiceKey{Namespace: "ns-a", Name: "echo"}, host: "10.0.1.1", port: 8080},
+		{svcKey: svctypes.ServiceKey{Namespace: "ns-b", Name: "echo"}, host: "10.0.1.2", port: 8080},
+	}
+	h := newHealthDelegator(func() []*hdsEndpoint { return endpoints })
+	clientA := newTestHDSClient("ns-a", "node-a", "10.0.0.1")
+	clientB := newTestHDSClient("ns-b", "node-b", "10.0.0.2")
+	h.watch(clientA)
+	h.watch(clientB)
+
+	specA := h.specifier(clientA.ID)
+	assert.Equal(t, 1, countAssigned(specA))
+	assert.Equal(t, buildSubsetName(core.TrafficDirection_INBOUND, 8080, "echo.ns-a"),
+		specA.GetClusterHealthChecks()[0].GetClusterName())
+	assert.Equal(t, 1, countAssigned(h.specifier(clientB.ID)))
+}
+
+func Test_healthDelegator_FetchLease(t *testing.T) {
+	endpoints := []*hdsEndpoint{
+		{svcKey: svctypes.ServiceKey{Namespace: "default", Name: "echo"}, host: "10.0.1.1", port: 8080, ttl: 1},
+	}
+	h := newHealthDelegator(func() []*hdsEndpoint { return endpoints })
+	client := newTestHDSClient("default", "node-1", "10.0.0.1")
+
+	specifier := h.fetch(client)
+	assert.Equal(t, 1, countAssigned(specifier))
+	assert.Equal(t, []string{client.ID}, h.fetchNodes("10.0.0.1"))
+	assert.Empty(t, h.fetchNodes("10.0.0.2"))
+
+	// 租约过期后节点被移除
+	h.lock.Lock()
+	h.checkers[client.ID].expireAt = time.Now().Add(-time.Second)
+	h.lock.Unlock()
+	h.rebuild()
+	assert.Nil(t, h.specifier(client.ID))
+	_, ok := h.resolveEndpoint([]string{client.ID},
+		specifier.GetClusterHealthChecks()[0].GetClusterName(), "10.0.1.1", 8080)
+	assert.False(t, ok)
+	assert.Empty(t, h.fetchNodes("10.0.0.1"))
+}
+
+func Test_healthDelegator_ReassignWithoutList(t *testing.T) {
+	endpoints := []*hdsEndpoint{
+		{svcKey: svctypes.ServiceKey{Namespace: "default", Name: "echo"}, host: "10.0.1.1", port: 8080},
+	}
+	var listTimes int
+	h := newHealthDelegator(func() []*hdsEndpoint {
+		listTimes++
+		return endpoints
+	})
+	client := newTestHDSClient("default", "node-1", "10.0.0.1")
+	h.watch(client)
+	h.watch(newTestHDSClient("default", "node-2", "10.0.0.2"))
+	h.fetch(newTestHDSClient("default", "node-3", "10.0.0.3"))
+	h.fetch(newTestHDSClient("default", "node-3", "10.0.0.3"))
+	h.remove(client.ID)
+	// 节点上下线只基于已经获取的实例重新分配, 只有定时 rebuild 才会重新遍历实例
+	assert.Equal(t, 1, listTimes)
+	h.rebuild()
+	assert.Equal(t, 2, listTimes)
+}
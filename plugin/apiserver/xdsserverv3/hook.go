@@ -34,6 +34,7 @@ func (x *XDSServer) OnCreateWatch(request *cachev3.Request, streamState stream.S
 		return
 	}
 	_ = x.resourceGenerator.buildOneEnvoyXDSCache(client)
+	x.ensureSecret(client)
 }
 
 // OnCreateDeltaWatch before call cachev3.SnapshotCache OnCreateDeltaWatch
@@ -45,6 +46,7 @@ func (x *XDSServer) OnCreateDeltaWatch(request *cachev3.DeltaRequest, state stre
 		return
 	}
 	_ = x.resourceGenerator.buildOneEnvoyXDSCache(client)
+	x.ensureSecret(client)
 }
 
 // OnFetch before call cachev3.SnapshotCache OnFetch
@@ -55,4 +57,5 @@ func (x *XDSServer) OnFetch(ctx context.Context, request *cachev3.Request) {
 		return
 	}
 	_ = x.resourceGenerator.buildOneEnvoyXDSCache(client)
+	x.ensureSecret(client)
 }
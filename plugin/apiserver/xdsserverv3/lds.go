@@ -105,10 +105,23 @@ func (lds *LDSBuilder) Generate(option *resource.BuildOption) (interface{}, erro
 			}
 			resources = append(resources, outBoundListener...)
 		}
+	case resource.RunTypeProxyless:
+		resources = lds.makeProxylessListeners(option)
 	}
 	return resources, nil
 }
 
+// makeProxylessListeners 每个服务生成 gRPC xds resolver 所需的 ApiListener
+func (lds *LDSBuilder) makeProxylessListeners(option *resource.BuildOption) []types.Resource {
+	var resources []types.Resource
+	for svcKey, svcInfo := range option.Services {
+		for _, name := range resource.MakeProxylessListenerNames(svcInfo) {
+			resources = append(resources, resource.MakeProxylessListener(name, svcKey))
+		}
+	}
+	return resources
+}
+
 func (lds *LDSBuilder) makeListener(option *resource.BuildOption,
 	direction corev3.TrafficDirection) ([]types.Resource, error) {
 	isGateway := option.RunType == resource.RunTypeGateway
@@ -136,7 +149,7 @@ func (lds *LDSBuilder) makeListener(option *resource.BuildOption,
 					TransportProtocol: "tls",
 				},
 				TransportSocket: resource.MakeTLSTransportSocket(&tlstrans.DownstreamTlsContext{
-					CommonTlsContext: resource.InboundCommonTLSContext,
+					CommonTlsContext: resource.InboundCommonTLSContext(option.BuiltinCA),
 					RequireClientCertificate: &wrappers.BoolValue{
 						Value: true,
 					},
@@ -67,10 +67,33 @@ func (rds *RDSBuilder) Generate(option *resource.BuildOption) (interface{}, erro
 		case corev3.TrafficDirection_OUTBOUND:
 			resources = append(resources, rds.makeSidecarOutBoundRouteConfiguration(option)...)
 		}
+	case resource.RunTypeProxyless:
+		resources = rds.makeProxylessRouteConfiguration(option)
 	}
 	return resources, nil
 }
 
+// makeProxylessRouteConfiguration 每个服务对应一个 RouteConfiguration, 与 ApiListener 中的 RDS 名称一致
+func (rds *RDSBuilder) makeProxylessRouteConfiguration(option *resource.BuildOption) []types.Resource {
+	var resources []types.Resource
+	for svcKey, svcInfo := range option.Services {
+		routeConf := &route.RouteConfiguration{
+			Name: resource.MakeProxylessServiceName(svcKey),
+		}
+		if !option.ForceDelete {
+			routeConf.VirtualHosts = []*route.VirtualHost{
+				{
+					Name:    resource.MakeProxylessServiceName(svcKey),
+					Domains: resource.GenerateServiceDomains(svcInfo),
+					Routes:  resource.MakeProxylessRoutes(svcInfo),
+				},
+			}
+		}
+		resources = append(resources, routeConf)
+	}
+	return resources
+}
+
 func (rds *RDSBuilder) makeSidecarInBoundRouteConfiguration(option *resource.BuildOption) []types.Resource {
 	selfService := option.SelfService
 	// step 2: 生成 sidecar 所属服务的 INBOUND 规则
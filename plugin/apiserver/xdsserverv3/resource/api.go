@@ -47,6 +47,8 @@ type BuildOption struct {
 	TrafficDirection corev3.TrafficDirection
 	// ForceDelete 如果设置了该字段值为 true, 则不会真正执行 XDS 的构建工作, 仅仅生成对应资源的 Name 名称用于清理
 	ForceDelete bool
+	// BuiltinCA 是否开启了内置 CA, 开启后 mTLS 证书通过 ADS 下发, 否则走 sidecar 自带的 sds-grpc 集群
+	BuiltinCA bool
 }
 
 func (opt *BuildOption) CloseEnvoyDemand() {
@@ -70,5 +72,6 @@ func (opt *BuildOption) Clone() *BuildOption {
 		Namespace: opt.Namespace,
 		TLSMode:   opt.TLSMode,
 		Services:  opt.Services,
+		BuiltinCA: opt.BuiltinCA,
 	}
 }
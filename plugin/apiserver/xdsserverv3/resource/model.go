@@ -123,6 +123,8 @@ func FormatTypeUrl(typeUrl string) XDSType {
 		return RLS
 	case resourcev3.VirtualHostType:
 		return VHDS
+	case resourcev3.SecretType:
+		return SDS
 	default:
 		return UnknownXDS
 	}
@@ -147,6 +149,9 @@ func (x XDSType) ResourceType() resourcev3.Type {
 	if x == VHDS {
 		return resourcev3.VirtualHostType
 	}
+	if x == SDS {
+		return resourcev3.SecretType
+	}
 	return resourcev3.AnyType
 }
 
@@ -169,6 +174,9 @@ func (x XDSType) String() string {
 	if x == VHDS {
 		return resourcev3.VirtualHostType
 	}
+	if x == SDS {
+		return resourcev3.SecretType
+	}
 	return resourcev3.AnyType
 }
 
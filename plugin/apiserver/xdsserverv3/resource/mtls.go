@@ -26,6 +26,7 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
+// DefaultSdsConfig 未开启内置 CA 时, 证书由 sidecar 自行配置的 sds-grpc 集群下发
 var DefaultSdsConfig = &core.ConfigSource{
 	ConfigSourceSpecifier: &core.ConfigSource_ApiConfigSource{
 		ApiConfigSource: &core.ApiConfigSource{
@@ -46,65 +47,90 @@ var DefaultSdsConfig = &core.ConfigSource{
 	InitialFetchTimeout: &duration.Duration{},
 }
 
+// BuiltinCASdsConfig 开启内置 CA 时, 证书由 pole-server 签发, 和其他 xDS 资源一起通过 ADS 下发
+var BuiltinCASdsConfig = &core.ConfigSource{
+	ConfigSourceSpecifier: &core.ConfigSource_Ads{
+		Ads: &core.AggregatedConfigSource{},
+	},
+	ResourceApiVersion:  core.ApiVersion_V3,
+	InitialFetchTimeout: &duration.Duration{},
+}
+
+// SdsConfig 根据是否开启内置 CA 选择证书的下发来源
+func SdsConfig(builtinCA bool) *core.ConfigSource {
+	if builtinCA {
+		return BuiltinCASdsConfig
+	}
+	return DefaultSdsConfig
+}
+
 var MTLSTransportSocketMatch = &structpb.Struct{
 	Fields: map[string]*structpb.Value{
 		"acceptMTLS": {Kind: &structpb.Value_StringValue{StringValue: "true"}},
 	},
 }
 
-var OutboundCommonTLSContext = &tlstrans.CommonTlsContext{
-	TlsCertificateSdsSecretConfigs: []*tlstrans.SdsSecretConfig{
-		{
-			Name:      "default",
-			SdsConfig: DefaultSdsConfig,
+// OutboundCommonTLSContext 出流量方向的 TLS 配置
+func OutboundCommonTLSContext(builtinCA bool) *tlstrans.CommonTlsContext {
+	sdsConfig := SdsConfig(builtinCA)
+	return &tlstrans.CommonTlsContext{
+		TlsCertificateSdsSecretConfigs: []*tlstrans.SdsSecretConfig{
+			{
+				Name:      "default",
+				SdsConfig: sdsConfig,
+			},
 		},
-	},
-	ValidationContextType: &tlstrans.CommonTlsContext_CombinedValidationContext{
-		CombinedValidationContext: &tlstrans.CommonTlsContext_CombinedCertificateValidationContext{
-			DefaultValidationContext: &tlstrans.CertificateValidationContext{},
-			ValidationContextSdsSecretConfig: &tlstrans.SdsSecretConfig{
-				Name:      "ROOTCA",
-				SdsConfig: DefaultSdsConfig,
+		ValidationContextType: &tlstrans.CommonTlsContext_CombinedValidationContext{
+			CombinedValidationContext: &tlstrans.CommonTlsContext_CombinedCertificateValidationContext{
+				DefaultValidationContext: &tlstrans.CertificateValidationContext{},
+				ValidationContextSdsSecretConfig: &tlstrans.SdsSecretConfig{
+					Name:      "ROOTCA",
+					SdsConfig: sdsConfig,
+				},
 			},
 		},
-	},
+	}
 }
 
-var InboundCommonTLSContext = &tlstrans.CommonTlsContext{
-	TlsParams: &tlstrans.TlsParameters{
-		TlsMinimumProtocolVersion: tlstrans.TlsParameters_TLSv1_2,
-		CipherSuites: []string{
-			"ECDHE-ECDSA-AES256-GCM-SHA384",
-			"ECDHE-RSA-AES256-GCM-SHA384",
-			"ECDHE-ECDSA-AES128-GCM-SHA256",
-			"ECDHE-RSA-AES128-GCM-SHA256",
-			"AES256-GCM-SHA384",
-			"AES128-GCM-SHA256",
+// InboundCommonTLSContext 入流量方向的 TLS 配置
+func InboundCommonTLSContext(builtinCA bool) *tlstrans.CommonTlsContext {
+	sdsConfig := SdsConfig(builtinCA)
+	return &tlstrans.CommonTlsContext{
+		TlsParams: &tlstrans.TlsParameters{
+			TlsMinimumProtocolVersion: tlstrans.TlsParameters_TLSv1_2,
+			CipherSuites: []string{
+				"ECDHE-ECDSA-AES256-GCM-SHA384",
+				"ECDHE-RSA-AES256-GCM-SHA384",
+				"ECDHE-ECDSA-AES128-GCM-SHA256",
+				"ECDHE-RSA-AES128-GCM-SHA256",
+				"AES256-GCM-SHA384",
+				"AES128-GCM-SHA256",
+			},
 		},
-	},
-	TlsCertificateSdsSecretConfigs: []*tlstrans.SdsSecretConfig{
-		{
-			Name:      "default",
-			SdsConfig: DefaultSdsConfig,
+		TlsCertificateSdsSecretConfigs: []*tlstrans.SdsSecretConfig{
+			{
+				Name:      "default",
+				SdsConfig: sdsConfig,
+			},
 		},
-	},
-	ValidationContextType: &tlstrans.CommonTlsContext_CombinedValidationContext{
-		CombinedValidationContext: &tlstrans.CommonTlsContext_CombinedCertificateValidationContext{
-			DefaultValidationContext: &tlstrans.CertificateValidationContext{
-				MatchSubjectAltNames: []*matcherv3.StringMatcher{
-					{
-						MatchPattern: &matcherv3.StringMatcher_Prefix{
-							Prefix: "spiffe://cluster.local/",
+		ValidationContextType: &tlstrans.CommonTlsContext_CombinedValidationContext{
+			CombinedValidationContext: &tlstrans.CommonTlsContext_CombinedCertificateValidationContext{
+				DefaultValidationContext: &tlstrans.CertificateValidationContext{
+					MatchSubjectAltNames: []*matcherv3.StringMatcher{
+						{
+							MatchPattern: &matcherv3.StringMatcher_Prefix{
+								Prefix: "spiffe://cluster.local/",
+							},
 						},
 					},
 				},
-			},
-			ValidationContextSdsSecretConfig: &tlstrans.SdsSecretConfig{
-				Name:      "ROOTCA",
-				SdsConfig: DefaultSdsConfig,
+				ValidationContextSdsSecretConfig: &tlstrans.SdsSecretConfig{
+					Name:      "ROOTCA",
+					SdsConfig: sdsConfig,
+				},
 			},
 		},
-	},
+	}
 }
 
 func MakeTLSTransportSocket(ctx proto.Message) *core.TransportSocket {
@@ -0,0 +1,59 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package resource
+
+import (
+	"testing"
+
+	tlstrans "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommonTLSContextSdsConfig(t *testing.T) {
+	sdsConfigs := func(tlsCtx *tlstrans.CommonTlsContext) []*tlstrans.SdsSecretConfig {
+		return []*tlstrans.SdsSecretConfig{
+			tlsCtx.GetTlsCertificateSdsSecretConfigs()[0],
+			tlsCtx.GetCombinedValidationContext().GetValidationContextSdsSecretConfig(),
+		}
+	}
+
+	t.Run("builtin_ca_disabled", func(t *testing.T) {
+		// 未开启内置 CA, 证书依旧从 sidecar 自带的 sds-grpc 集群获取
+		for _, tlsCtx := range []*tlstrans.CommonTlsContext{
+			OutboundCommonTLSContext(false), InboundCommonTLSContext(false),
+		} {
+			for _, secret := range sdsConfigs(tlsCtx) {
+				grpcSvc := secret.GetSdsConfig().GetApiConfigSource().GetGrpcServices()
+				assert.Len(t, grpcSvc, 1)
+				assert.Equal(t, "sds-grpc", grpcSvc[0].GetEnvoyGrpc().GetClusterName())
+				assert.Nil(t, secret.GetSdsConfig().GetAds())
+			}
+		}
+	})
+
+	t.Run("builtin_ca_enabled", func(t *testing.T) {
+		for _, tlsCtx := range []*tlstrans.CommonTlsContext{
+			OutboundCommonTLSContext(true), InboundCommonTLSContext(true),
+		} {
+			for _, secret := range sdsConfigs(tlsCtx) {
+				assert.NotNil(t, secret.GetSdsConfig().GetAds())
+				assert.Nil(t, secret.GetSdsConfig().GetApiConfigSource())
+			}
+		}
+	})
+}
@@ -41,6 +41,8 @@ var (
 	RunTypeGateway RunType = "gateway"
 	// RunTypeSidecar xds node run type is sidecar
 	RunTypeSidecar RunType = "sidecar"
+	// RunTypeProxyless xds node run type is proxyless grpc, the node is a grpc client using the xds resolver
+	RunTypeProxyless RunType = "proxyless"
 )
 
 const (
@@ -85,10 +87,12 @@ type EnvoyNodeView struct {
 
 func NewXDSNodeManager() *XDSNodeManager {
 	return &XDSNodeManager{
-		nodes:         map[string]*XDSClient{},
-		streamTonodes: map[int64]*XDSClient{},
-		sidecarNodes:  map[string]*XDSClient{},
-		gatewayNodes:  map[string]*XDSClient{},
+		nodes:          map[string]*XDSClient{},
+		streamTonodes:  map[int64]*XDSClient{},
+		sidecarNodes:   map[string]*XDSClient{},
+		gatewayNodes:   map[string]*XDSClient{},
+		proxylessNodes: map[string]*XDSClient{},
+		authTokens:     map[string]string{},
 	}
 }
 
@@ -100,6 +104,10 @@ type XDSNodeManager struct {
 	sidecarNodes map[string]*XDSClient
 	// gatewayNodes The XDS client is the node list of the Gateway run mode
 	gatewayNodes map[string]*XDSClient
+	// proxylessNodes The XDS client is the node list of the proxyless gRPC run mode
+	proxylessNodes map[string]*XDSClient
+	// authTokens The auth token carried by the XDS client when the stream established
+	authTokens map[string]string
 }
 
 func (x *XDSNodeManager) AddNodeIfAbsent(streamId int64, node *core.Node) {
@@ -125,6 +133,12 @@ func (x *XDSNodeManager) AddNodeIfAbsent(streamId int64, node *core.Node) {
 			log.Info("[XDS][Node][V3] add gateway xds node", zap.Int64("stream", streamId),
 				zap.String("info", p.String()))
 		}
+	case RunTypeProxyless:
+		if _, ok := x.proxylessNodes[node.Id]; !ok {
+			x.proxylessNodes[node.Id] = p
+			log.Info("[XDS][Node][V3] add proxyless grpc xds node", zap.Int64("stream", streamId),
+				zap.String("info", p.String()))
+		}
 	default:
 		if _, ok := x.sidecarNodes[node.Id]; !ok {
 			x.sidecarNodes[node.Id] = p
@@ -140,6 +154,10 @@ func (x *XDSNodeManager) DelNode(streamId int64) {
 
 	if p, ok := x.streamTonodes[streamId]; ok {
 		delete(x.nodes, p.Node.Id)
+		delete(x.authTokens, p.Node.Id)
+		delete(x.sidecarNodes, p.Node.Id)
+		delete(x.gatewayNodes, p.Node.Id)
+		delete(x.proxylessNodes, p.Node.Id)
 		log.Info("[XDS][Node][V3] remove xds node", zap.Int64("stream", streamId),
 			zap.String("info", p.String()))
 	}
@@ -153,6 +171,22 @@ func (x *XDSNodeManager) GetNodeByStreamID(streamId int64) *XDSClient {
 	return x.streamTonodes[streamId]
 }
 
+// SetAuthToken 记录 xDS 节点建立连接时携带的鉴权 token
+func (x *XDSNodeManager) SetAuthToken(id string, token string) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	x.authTokens[id] = token
+}
+
+// GetAuthToken 获取 xDS 节点的鉴权 token
+func (x *XDSNodeManager) GetAuthToken(id string) string {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+
+	return x.authTokens[id]
+}
+
 func (x *XDSNodeManager) GetNode(id string) *XDSClient {
 	x.lock.RLock()
 	defer x.lock.RUnlock()
@@ -164,7 +198,7 @@ func (x *XDSNodeManager) HasEnvoyNodes() bool {
 	x.lock.RLock()
 	defer x.lock.RUnlock()
 
-	return len(x.gatewayNodes) != 0 || len(x.sidecarNodes) != 0
+	return len(x.gatewayNodes) != 0 || len(x.sidecarNodes) != 0 || len(x.proxylessNodes) != 0
 }
 
 func (x *XDSNodeManager) ListEnvoyNodes() []*XDSClient {
@@ -178,6 +212,9 @@ func (x *XDSNodeManager) ListEnvoyNodes() []*XDSClient {
 	for i := range x.gatewayNodes {
 		ret = append(ret, x.gatewayNodes[i])
 	}
+	for i := range x.proxylessNodes {
+		ret = append(ret, x.proxylessNodes[i])
+	}
 	return ret
 }
 
@@ -203,10 +240,28 @@ func (x *XDSNodeManager) ListSidecarNodes() []*XDSClient {
 	return ret
 }
 
+func (x *XDSNodeManager) ListProxylessNodes() []*XDSClient {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+
+	ret := make([]*XDSClient, 0, len(x.proxylessNodes))
+	for i := range x.proxylessNodes {
+		ret = append(ret, x.proxylessNodes[i])
+	}
+	return ret
+}
+
 func (x *XDSNodeManager) ListEnvoyNodesView(run RunType) []*EnvoyNodeView {
 	x.lock.RLock()
 	defer x.lock.RUnlock()
 
+	if run == RunTypeProxyless {
+		ret := make([]*EnvoyNodeView, 0, len(x.proxylessNodes))
+		for i := range x.proxylessNodes {
+			ret = append(ret, x.proxylessNodes[i].toView())
+		}
+		return ret
+	}
 	if run == RunTypeSidecar {
 		ret := make([]*EnvoyNodeView, 0, len(x.sidecarNodes))
 		for i := range x.sidecarNodes {
@@ -17,7 +17,12 @@
 
 package resource
 
-import "testing"
+import (
+	"testing"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/stretchr/testify/assert"
+)
 
 func Test_parseNodeID(t *testing.T) {
 	type args struct {
@@ -61,6 +66,16 @@ func Test_parseNodeID(t *testing.T) {
 			wantUuid:             "12345",
 			wantHostIP:           "127.0.0.1",
 		},
+		{
+			name: "test-1",
+			args: args{
+				nodeID: "proxyless~default/12345~127.0.0.1",
+			},
+			wantRunType:          string(RunTypeProxyless),
+			wantPolarisNamespace: "default",
+			wantUuid:             "12345",
+			wantHostIP:           "127.0.0.1",
+		},
 		{
 			name: "test-1",
 			args: args{
@@ -110,3 +125,25 @@ func Test_parseNodeID(t *testing.T) {
 		})
 	}
 }
+
+func TestXDSNodeManager_DelNode(t *testing.T) {
+	mgr := NewXDSNodeManager()
+	nodes := []*core.Node{
+		{Id: "sidecar~default/12345~127.0.0.1"},
+		{Id: "gateway~default/12346~127.0.0.2"},
+		{Id: "proxyless~default/12347~127.0.0.3"},
+	}
+	for i, node := range nodes {
+		mgr.AddNodeIfAbsent(int64(i), node)
+	}
+	assert.Len(t, mgr.ListEnvoyNodesView(RunTypeProxyless), 1)
+	assert.True(t, mgr.HasEnvoyNodes())
+
+	for i := range nodes {
+		mgr.DelNode(int64(i))
+	}
+	assert.Empty(t, mgr.ListEnvoyNodesView(RunTypeProxyless))
+	assert.Empty(t, mgr.ListEnvoyNodesView(RunTypeSidecar))
+	assert.Empty(t, mgr.ListEnvoyNodesView(RunTypeGateway))
+	assert.False(t, mgr.HasEnvoyNodes())
+}
@@ -0,0 +1,324 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package resource
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	routerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	resourcev3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+// proxyless gRPC 通过 xds:///{service}.{namespace}[:port] 的方式访问服务, 请求的 Listener 名称即为 target 的 authority,
+// 因此 Listener、RouteConfiguration 直接使用 {service}.{namespace} 作为名称
+
+// MakeProxylessListenerNames proxyless gRPC 访问服务时可能请求的 Listener 名称
+func MakeProxylessListenerNames(svcInfo *ServiceInfo) []string {
+	name := MakeProxylessServiceName(svcInfo.ServiceKey)
+	names := []string{name}
+	for _, port := range svcInfo.Ports {
+		names = append(names, name+":"+strconv.FormatUint(uint64(port.Port), 10))
+	}
+	return names
+}
+
+// MakeProxylessServiceName proxyless gRPC 场景下服务对应的 Listener/RouteConfiguration 名称
+func MakeProxylessServiceName(svcKey svctypes.ServiceKey) string {
+	return svcKey.Domain()
+}
+
+// MakeProxylessClusterName proxyless gRPC 不支持 LbSubsetConfig, 路由规则中带标签的目标实例分组需要拆分为独立的 cluster
+func MakeProxylessClusterName(svcKey svctypes.ServiceKey, labels map[string]string) string {
+	name := fmt.Sprintf("%s|%s|%s", string(RunTypeProxyless), svcKey.Namespace, svcKey.Name)
+	if len(labels) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+	return name + "|" + strings.Join(pairs, ",")
+}
+
+// ParseProxylessNamespace 从 proxyless gRPC 请求的资源名称中解析出服务所在的命名空间
+// Listener/RouteConfiguration 为 [xds:///]{service}.{namespace}[:port], Cluster/ClusterLoadAssignment 为
+// proxyless|{namespace}|{service}[|labels]
+func ParseProxylessNamespace(xdsType XDSType, name string) (string, bool) {
+	switch xdsType {
+	case LDS, RDS:
+		name = strings.TrimPrefix(name, "xds:///")
+		if index := strings.LastIndex(name, ":"); index != -1 {
+			if _, err := strconv.ParseUint(name[index+1:], 10, 32); err == nil {
+				name = name[:index]
+			}
+		}
+		index := strings.LastIndex(name, ".")
+		if index <= 0 || index == len(name)-1 {
+			return "", false
+		}
+		return name[index+1:], true
+	case CDS, EDS:
+		items := strings.Split(name, "|")
+		if len(items) < 3 || items[0] != string(RunTypeProxyless) || items[1] == "" {
+			return "", false
+		}
+		return items[1], true
+	default:
+		return "", false
+	}
+}
+
+// ParseProxylessDestinationLabels 解析路由目标的实例标签, 只支持精确匹配, 包含 * 时表示不区分实例分组
+func ParseProxylessDestinationLabels(dest *traffic_manage.DestinationGroup) map[string]string {
+	labels := make(map[string]string, len(dest.GetLabels()))
+	for k, v := range dest.GetLabels() {
+		if k == utils.MatchAll && v.GetValue().GetValue() == utils.MatchAll {
+			return map[string]string{}
+		}
+		labels[k] = v.GetValue().GetValue()
+	}
+	return labels
+}
+
+// ListProxylessSubsets 服务路由规则中所有需要拆分为独立 cluster 的实例分组
+func ListProxylessSubsets(svcInfo *ServiceInfo) []map[string]string {
+	subsets := make([]map[string]string, 0, 4)
+	exists := map[string]struct{}{}
+	for _, rule := range FilterInboundRouterRule(svcInfo) {
+		for _, dest := range rule.GetDestinations() {
+			if !svcInfo.MatchService(dest.GetNamespace(), dest.GetService()) {
+				continue
+			}
+			labels := ParseProxylessDestinationLabels(dest)
+			if len(labels) == 0 {
+				continue
+			}
+			name := MakeProxylessClusterName(svcInfo.ServiceKey, labels)
+			if _, ok := exists[name]; ok {
+				continue
+			}
+			exists[name] = struct{}{}
+			subsets = append(subsets, labels)
+		}
+	}
+	return subsets
+}
+
+// MatchProxylessSubset 实例是否属于该实例分组
+func MatchProxylessSubset(ins *apiservice.Instance, labels map[string]string) bool {
+	for k, v := range labels {
+		if ins.GetMetadata()[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// MakeProxylessHCM proxyless gRPC 的 ApiListener 配置, gRPC 只支持 router 作为最后一个 http filter
+func MakeProxylessHCM(svcKey svctypes.ServiceKey) *hcm.HttpConnectionManager {
+	return &hcm.HttpConnectionManager{
+		RouteSpecifier: &hcm.HttpConnectionManager_Rds{
+			Rds: &hcm.Rds{
+				ConfigSource: &core.ConfigSource{
+					ResourceApiVersion: resourcev3.DefaultAPIVersion,
+					ConfigSourceSpecifier: &core.ConfigSource_Ads{
+						Ads: &core.AggregatedConfigSource{},
+					},
+				},
+				RouteConfigName: MakeProxylessServiceName(svcKey),
+			},
+		},
+		HttpFilters: []*hcm.HttpFilter{
+			{
+				Name: wellknown.Router,
+				ConfigType: &hcm.HttpFilter_TypedConfig{
+					TypedConfig: MustNewAny(&routerv3.Router{}),
+				},
+			},
+		},
+	}
+}
+
+// MakeProxylessListener proxyless gRPC 使用 ApiListener, 不需要监听地址
+func MakeProxylessListener(name string, svcKey svctypes.ServiceKey) *listenerv3.Listener {
+	return &listenerv3.Listener{
+		Name: name,
+		ApiListener: &listenerv3.ApiListener{
+			ApiListener: MustNewAny(MakeProxylessHCM(svcKey)),
+		},
+	}
+}
+
+// MakeProxylessWeightClusters 将路由目标转换为 gRPC 支持的 WeightedCluster
+func MakeProxylessWeightClusters(svcInfo *ServiceInfo,
+	destinations []*traffic_manage.DestinationGroup) *route.WeightedCluster {
+	var (
+		clusters    []*route.WeightedCluster_ClusterWeight
+		totalWeight uint32
+	)
+	for _, dest := range destinations {
+		if dest.GetWeight() == 0 {
+			continue
+		}
+		clusters = append(clusters, &route.WeightedCluster_ClusterWeight{
+			Name:   MakeProxylessClusterName(svcInfo.ServiceKey, ParseProxylessDestinationLabels(dest)),
+			Weight: wrapperspb.UInt32(dest.GetWeight()),
+		})
+		totalWeight += dest.GetWeight()
+	}
+	if len(clusters) == 0 {
+		return nil
+	}
+	return &route.WeightedCluster{
+		TotalWeight: wrapperspb.UInt32(totalWeight),
+		Clusters:    clusters,
+	}
+}
+
+// MakeProxylessRoutes 将服务的路由规则转换为 proxyless gRPC 的路由
+func MakeProxylessRoutes(svcInfo *ServiceInfo) []*route.Route {
+	var (
+		routes        []*route.Route
+		matchAllRoute *route.Route
+	)
+	for _, rule := range FilterInboundRouterRule(svcInfo) {
+		var destinations []*traffic_manage.DestinationGroup
+		for _, dest := range rule.GetDestinations() {
+			if svcInfo.MatchService(dest.GetNamespace(), dest.GetService()) {
+				destinations = append(destinations, dest)
+			}
+		}
+		weightClusters := MakeProxylessWeightClusters(svcInfo, destinations)
+		if weightClusters == nil {
+			continue
+		}
+
+		matchAll := false
+		routeMatch := &route.RouteMatch{
+			PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"},
+		}
+		for _, source := range rule.GetSources() {
+			if len(source.GetArguments()) == 0 {
+				matchAll = true
+				break
+			}
+			for _, arg := range source.GetArguments() {
+				if arg.Key == utils.MatchAll {
+					matchAll = true
+					break
+				}
+			}
+			if matchAll {
+				break
+			}
+			BuildSidecarRouteMatch(routeMatch, source)
+		}
+		// gRPC 不支持按照 query 参数进行匹配
+		routeMatch.QueryParameters = nil
+
+		currentRoute := &route.Route{
+			Match: routeMatch,
+			Action: &route.Route_Route{
+				Route: &route.RouteAction{
+					ClusterSpecifier: &route.RouteAction_WeightedClusters{
+						WeightedClusters: weightClusters,
+					},
+				},
+			},
+		}
+		if matchAll {
+			if matchAllRoute == nil {
+				matchAllRoute = currentRoute
+			}
+			continue
+		}
+		routes = append(routes, currentRoute)
+	}
+	if matchAllRoute == nil {
+		matchAllRoute = &route.Route{
+			Match: &route.RouteMatch{
+				PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"},
+			},
+			Action: &route.Route_Route{
+				Route: &route.RouteAction{
+					ClusterSpecifier: &route.RouteAction_Cluster{
+						Cluster: MakeProxylessClusterName(svcInfo.ServiceKey, nil),
+					},
+				},
+			},
+		}
+	}
+	return append(routes, matchAllRoute)
+}
+
+// MakeProxylessCluster proxyless gRPC 的 cluster, 实例通过 ADS 获取
+func MakeProxylessCluster(svcInfo *ServiceInfo, labels map[string]string) *cluster.Cluster {
+	name := MakeProxylessClusterName(svcInfo.ServiceKey, labels)
+	return &cluster.Cluster{
+		Name:                 name,
+		ConnectTimeout:       durationpb.New(5 * time.Second),
+		ClusterDiscoveryType: &cluster.Cluster_Type{Type: cluster.Cluster_EDS},
+		LbPolicy:             cluster.Cluster_ROUND_ROBIN,
+		EdsClusterConfig: &cluster.Cluster_EdsClusterConfig{
+			ServiceName: name,
+			EdsConfig: &core.ConfigSource{
+				ResourceApiVersion: resourcev3.DefaultAPIVersion,
+				ConfigSourceSpecifier: &core.ConfigSource_Ads{
+					Ads: &core.AggregatedConfigSource{},
+				},
+			},
+		},
+		OutlierDetection: MakeProxylessOutlierDetection(svcInfo),
+	}
+}
+
+// MakeProxylessOutlierDetection gRPC 只支持 success_rate 以及 failure_percentage 两种摘除算法,
+// 熔断规则的错误率需要显式开启 failure_percentage 的摘除
+func MakeProxylessOutlierDetection(svcInfo *ServiceInfo) *cluster.OutlierDetection {
+	outlierDetection := MakeOutlierDetection(svcInfo)
+	if outlierDetection == nil {
+		return nil
+	}
+	if outlierDetection.GetFailurePercentageThreshold().GetValue() > 0 {
+		outlierDetection.EnforcingFailurePercentage = wrapperspb.UInt32(100)
+	}
+	return outlierDetection
+}
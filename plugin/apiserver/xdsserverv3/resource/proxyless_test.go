@@ -0,0 +1,164 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package resource
+
+import (
+	"testing"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	apifault "github.com/polarismesh/specification/source/go/api/v1/fault_tolerance"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+)
+
+func newProxylessTestService(t *testing.T) *ServiceInfo {
+	routingConfig, err := ptypes.MarshalAny(&traffic_manage.RuleRoutingConfig{
+		Rules: []*traffic_manage.SubRuleRouting{
+			{
+				Sources: []*traffic_manage.SourceService{
+					{
+						Arguments: []*traffic_manage.SourceMatch{
+							{
+								Type: traffic_manage.SourceMatch_HEADER,
+								Key:  "env",
+								Value: &apimodel.MatchString{
+									Type:  apimodel.MatchString_EXACT,
+									Value: wrapperspb.String("gray"),
+								},
+							},
+						},
+					},
+				},
+				Destinations: []*traffic_manage.DestinationGroup{
+					{
+						Service:   "echo",
+						Namespace: "default",
+						Weight:    100,
+						Labels: map[string]*apimodel.MatchString{
+							"version": {Value: wrapperspb.String("v2")},
+						},
+					},
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	svcKey := svctypes.ServiceKey{Namespace: "default", Name: "echo"}
+	return &ServiceInfo{
+		Name:       "echo",
+		Namespace:  "default",
+		ServiceKey: svcKey,
+		Ports:      []*svctypes.ServicePort{{Port: 8080, Protocol: "grpc"}},
+		Routing: &traffic_manage.Routing{
+			Rules: []*traffic_manage.RouteRule{
+				{
+					RoutingPolicy: traffic_manage.RoutingPolicy_RulePolicy,
+					RoutingConfig: routingConfig,
+				},
+			},
+		},
+		Instances: []*apiservice.Instance{
+			{Metadata: map[string]string{"version": "v1"}},
+			{Metadata: map[string]string{"version": "v2"}},
+		},
+		CircuitBreaker: &apifault.CircuitBreaker{
+			Rules: []*apifault.CircuitBreakerRule{
+				{
+					Enable: true,
+					Level:  apifault.Level_INSTANCE,
+					TriggerCondition: []*apifault.TriggerCondition{
+						{Interval: 10, ErrorPercent: 50, MinimumRequest: 10},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestMakeProxylessResourceNames(t *testing.T) {
+	svcInfo := newProxylessTestService(t)
+	assert.Equal(t, []string{"echo.default", "echo.default:8080"}, MakeProxylessListenerNames(svcInfo))
+	assert.Equal(t, "proxyless|default|echo", MakeProxylessClusterName(svcInfo.ServiceKey, nil))
+	assert.Equal(t, "proxyless|default|echo|a=1,b=2",
+		MakeProxylessClusterName(svcInfo.ServiceKey, map[string]string{"b": "2", "a": "1"}))
+
+	subsets := ListProxylessSubsets(svcInfo)
+	assert.Equal(t, []map[string]string{{"version": "v2"}}, subsets)
+	assert.False(t, MatchProxylessSubset(svcInfo.Instances[0], subsets[0]))
+	assert.True(t, MatchProxylessSubset(svcInfo.Instances[1], subsets[0]))
+	assert.True(t, MatchProxylessSubset(svcInfo.Instances[0], nil))
+}
+
+func TestParseProxylessNamespace(t *testing.T) {
+	for _, c := range []struct {
+		xdsType XDSType
+		name    string
+		ns      string
+		ok      bool
+	}{
+		{LDS, "echo.default", "default", true},
+		{LDS, "echo.otherns:8080", "otherns", true},
+		{LDS, "xds:///echo.otherns", "otherns", true},
+		{RDS, "v1.echo.otherns", "otherns", true},
+		{LDS, "echo", "", false},
+		{CDS, "proxyless|otherns|echo", "otherns", true},
+		{EDS, "proxyless|otherns|echo|version=v2", "otherns", true},
+		{CDS, "outbound|echo", "", false},
+		{SDS, "default", "", false},
+	} {
+		ns, ok := ParseProxylessNamespace(c.xdsType, c.name)
+		assert.Equal(t, c.ok, ok, c.name)
+		assert.Equal(t, c.ns, ns, c.name)
+	}
+}
+
+func TestMakeProxylessRoutes(t *testing.T) {
+	svcInfo := newProxylessTestService(t)
+	routes := MakeProxylessRoutes(svcInfo)
+	assert.Len(t, routes, 2)
+
+	// 带标签的路由目标转换为实例分组 cluster
+	grayRoute := routes[0]
+	assert.Equal(t, "env", grayRoute.GetMatch().GetHeaders()[0].GetName())
+	clusters := grayRoute.GetRoute().GetWeightedClusters().GetClusters()
+	assert.Len(t, clusters, 1)
+	assert.Equal(t, "proxyless|default|echo|version=v2", clusters[0].GetName())
+	assert.Nil(t, clusters[0].GetMetadataMatch())
+
+	// 兜底路由指向服务的默认 cluster
+	assert.Equal(t, &route.RouteMatch_Prefix{Prefix: "/"}, routes[1].GetMatch().GetPathSpecifier())
+	assert.Equal(t, "proxyless|default|echo", routes[1].GetRoute().GetCluster())
+}
+
+func TestMakeProxylessCluster(t *testing.T) {
+	svcInfo := newProxylessTestService(t)
+	c := MakeProxylessCluster(svcInfo, map[string]string{"version": "v2"})
+	assert.Equal(t, "proxyless|default|echo|version=v2", c.GetName())
+	assert.Equal(t, c.GetName(), c.GetEdsClusterConfig().GetServiceName())
+	assert.Nil(t, c.GetLbSubsetConfig())
+	assert.Equal(t, uint32(50), c.GetOutlierDetection().GetFailurePercentageThreshold().GetValue())
+	assert.Equal(t, uint32(100), c.GetOutlierDetection().GetEnforcingFailurePercentage().GetValue())
+}
@@ -0,0 +1,225 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package xdsserverv3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"go.uber.org/zap"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+
+	apitypes "github.com/pole-io/pole-server/apis/pkg/types"
+	authcommon "github.com/pole-io/pole-server/apis/pkg/types/auth"
+	"github.com/pole-io/pole-server/plugin/apiserver/xdsserverv3/ca"
+	xdscache "github.com/pole-io/pole-server/plugin/apiserver/xdsserverv3/cache"
+	"github.com/pole-io/pole-server/plugin/apiserver/xdsserverv3/resource"
+)
+
+const (
+	// defaultSecretName sidecar 自身证书在 SDS 中的资源名称
+	defaultSecretName = "default"
+	// rootCASecretName 根证书在 SDS 中的资源名称
+	rootCASecretName = "ROOTCA"
+	// rotateCheckInterval 检查证书是否需要轮换的间隔
+	rotateCheckInterval = time.Minute
+)
+
+// secretManager 基于内置 CA 为开启了 mTLS 的 xDS 节点签发证书, 通过 SDS 下发并在过期前轮换
+type secretManager struct {
+	ca      *ca.CertificateAuthority
+	nodeMgr *resource.XDSNodeManager
+	cache   *xdscache.ResourceCache
+	// authorize 校验 xDS 节点是否有权限获取其声明的服务身份证书
+	authorize func(client *resource.XDSClient) error
+
+	lock sync.Mutex
+	// issued nodeId -> 已经下发的证书
+	issued map[string]*ca.Certificate
+}
+
+func newSecretManager(authority *ca.CertificateAuthority, nodeMgr *resource.XDSNodeManager,
+	cache *xdscache.ResourceCache, authorize func(client *resource.XDSClient) error) *secretManager {
+	return &secretManager{
+		ca:        authority,
+		nodeMgr:   nodeMgr,
+		cache:     cache,
+		authorize: authorize,
+		issued:    map[string]*ca.Certificate{},
+	}
+}
+
+// ensure 节点开启了 mTLS 并且尚未持有有效证书时签发证书
+func (s *secretManager) ensure(client *resource.XDSClient) error {
+	if client.TLSMode == resource.TLSModeNone {
+		return nil
+	}
+	s.lock.Lock()
+	cert := s.issued[client.ID]
+	s.lock.Unlock()
+	if !s.ca.NeedRotate(cert, time.Now()) {
+		return nil
+	}
+	return s.issue(client)
+}
+
+func (s *secretManager) issue(client *resource.XDSClient) error {
+	svcKey := client.GetSelfServiceKey()
+	if svcKey.Namespace == "" || svcKey.Name == "" {
+		return errors.New("xds node does not declare service identity")
+	}
+	if err := s.authorize(client); err != nil {
+		return err
+	}
+	cert, err := s.ca.Issue(svcKey.Namespace, svcKey.Name)
+	if err != nil {
+		return err
+	}
+
+	secrets := []types.Resource{
+		&tlsv3.Secret{
+			Name: defaultSecretName,
+			Type: &tlsv3.Secret_TlsCertificate{
+				TlsCertificate: &tlsv3.TlsCertificate{
+					CertificateChain: inlineBytes(cert.CertChain),
+					PrivateKey:       inlineBytes(cert.PrivateKey),
+				},
+			},
+		},
+		&tlsv3.Secret{
+			Name: rootCASecretName,
+			Type: &tlsv3.Secret_ValidationContext{
+				ValidationContext: &tlsv3.CertificateValidationContext{
+					TrustedCa: inlineBytes(s.ca.RootPEM()),
+				},
+			},
+		},
+	}
+	if err := s.cache.UpdateResources(context.Background(), &xdscache.UpdateResourcesRequest{
+		Sds: map[string]map[string]types.Resource{
+			client.ID: cachev3.IndexRawResourcesByName(secrets),
+		},
+	}); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.issued[client.ID] = cert
+	s.lock.Unlock()
+	log.Info("[XDS][SDS] issue workload certificate", zap.String("node", client.ID),
+		zap.String("identity", cert.Identity), zap.Time("not-after", cert.NotAfter))
+	return nil
+}
+
+// runRotateTask 定期轮换即将过期的证书, 并清理已经断开连接的节点证书
+func (s *secretManager) runRotateTask(ctx context.Context) {
+	ticker := time.NewTicker(rotateCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.rotate()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *secretManager) rotate() {
+	s.lock.Lock()
+	nodeIds := make([]string, 0, len(s.issued))
+	for nodeId := range s.issued {
+		nodeIds = append(nodeIds, nodeId)
+	}
+	s.lock.Unlock()
+
+	for _, nodeId := range nodeIds {
+		client := s.nodeMgr.GetNode(nodeId)
+		if client == nil {
+			s.lock.Lock()
+			delete(s.issued, nodeId)
+			s.lock.Unlock()
+			continue
+		}
+		if err := s.ensure(client); err != nil {
+			log.Error("[XDS][SDS] rotate workload certificate", zap.String("node", nodeId), zap.Error(err))
+		}
+	}
+}
+
+func inlineBytes(data []byte) *corev3.DataSource {
+	return &corev3.DataSource{
+		Specifier: &corev3.DataSource_InlineBytes{
+			InlineBytes: data,
+		},
+	}
+}
+
+// ensureSecret 开启内置 CA 时, 为 xDS 节点准备 SDS 证书
+func (x *XDSServer) ensureSecret(client *resource.XDSClient) {
+	if x.secretMgr == nil {
+		return
+	}
+	if err := x.secretMgr.ensure(client); err != nil {
+		log.Error("[XDS][SDS] issue workload certificate", zap.String("node", client.ID), zap.Error(err))
+	}
+}
+
+// authorizeSecret xDS 节点声明的服务需要存在, 并且节点携带的 token 对该服务具有写权限,
+// 只读权限不足以获取服务的身份证书, 否则可以读取服务的节点就能冒充该服务
+func (x *XDSServer) authorizeSecret(client *resource.XDSClient) error {
+	svcKey := client.GetSelfServiceKey()
+	svc := x.namingServer.Cache().Service().GetServiceByName(svcKey.Name, svcKey.Namespace)
+	if svc == nil {
+		return fmt.Errorf("service %s/%s not found", svcKey.Namespace, svcKey.Name)
+	}
+	if x.policySvr == nil {
+		return errors.New("auth strategy server not available")
+	}
+	ctx := apitypes.AppendContextValue(context.Background(), apitypes.ContextAuthTokenKey,
+		x.nodeMgr.GetAuthToken(client.ID))
+	authCtx := authcommon.NewAcquireContext(
+		authcommon.WithRequestContext(ctx),
+		authcommon.WithOperation(authcommon.Modify),
+		authcommon.WithModule(authcommon.DiscoverModule),
+		authcommon.WithMethod(authcommon.IssueWorkloadCertificate),
+		authcommon.WithAccessResources(map[apisecurity.ResourceType][]authcommon.ResourceEntry{
+			apisecurity.ResourceType_Services: {
+				{
+					Type:     apisecurity.ResourceType_Services,
+					ID:       svc.ID,
+					Owner:    svc.Owner,
+					Metadata: svc.Meta,
+				},
+			},
+		}),
+	)
+	if _, err := x.policySvr.GetAuthChecker().CheckClientPermission(authCtx); err != nil {
+		return fmt.Errorf("xds node not allowed to issue certificate of service %s/%s: %w",
+			svcKey.Namespace, svcKey.Name, err)
+	}
+	return nil
+}
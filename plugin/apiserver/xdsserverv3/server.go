@@ -41,17 +41,22 @@ import (
 
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 
+	"github.com/pole-io/pole-server/apis/access_control/auth"
 	"github.com/pole-io/pole-server/apis/apiserver"
+	"github.com/pole-io/pole-server/apis/crypto"
 	"github.com/pole-io/pole-server/apis/pkg/types"
 	"github.com/pole-io/pole-server/apis/pkg/types/protobuf"
 	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/apis/store"
 	"github.com/pole-io/pole-server/pkg/cache"
 	api "github.com/pole-io/pole-server/pkg/common/api/v1"
+	conndrain "github.com/pole-io/pole-server/pkg/common/conn/drain"
 	connlimit "github.com/pole-io/pole-server/pkg/common/conn/limit"
 	commonatomic "github.com/pole-io/pole-server/pkg/common/syncs/atomic"
 	"github.com/pole-io/pole-server/pkg/goverrule"
 	"github.com/pole-io/pole-server/pkg/service"
 	"github.com/pole-io/pole-server/pkg/service/healthcheck"
+	"github.com/pole-io/pole-server/plugin/apiserver/xdsserverv3/ca"
 	xdscache "github.com/pole-io/pole-server/plugin/apiserver/xdsserverv3/cache"
 	"github.com/pole-io/pole-server/plugin/apiserver/xdsserverv3/resource"
 )
@@ -74,11 +79,15 @@ type XDSServer struct {
 	cache           *xdscache.ResourceCache
 	versionNum      *atomic.Uint64
 	server          *grpc.Server
+	tracker         *conndrain.StreamTracker
 	connLimitConfig *connlimit.Config
 
 	nodeMgr           *resource.XDSNodeManager
 	registryInfo      *commonatomic.AtomicValue[ServiceInfos]
 	resourceGenerator *XdsResourceGenerator
+	secretMgr         *secretManager
+	policySvr         auth.StrategyServer
+	hds               *healthDelegator
 
 	active         *atomic.Bool
 	finishCtx      context.Context
@@ -94,6 +103,7 @@ func (x *XDSServer) Initialize(ctx context.Context, option map[string]interface{
 	x.listenPort = uint32(option["listenPort"].(int))
 	x.listenIP = option["listenIP"].(string)
 	x.nodeMgr = resource.NewXDSNodeManager()
+	x.tracker = conndrain.NewStreamTracker()
 	x.cache = xdscache.NewResourceCache(x)
 	x.active = atomic.NewBool(false)
 	x.versionNum = atomic.NewUint64(0)
@@ -131,7 +141,38 @@ func (x *XDSServer) Initialize(ctx context.Context, option map[string]interface{
 		xdsNodesMgr:     x.nodeMgr,
 		svcInfoProvider: x.fetchCurrentServices,
 	}
+	x.hds = newHealthDelegator(x.listHealthCheckEndpoints)
 	resource.Init()
+	return x.initSecretManager(option)
+}
+
+func (x *XDSServer) initSecretManager(option map[string]interface{}) error {
+	x.secretMgr = nil
+	caConfig, err := ca.LoadConfig(option["ca"])
+	if err != nil {
+		return err
+	}
+	if !caConfig.Enable {
+		return nil
+	}
+	s, err := store.GetStore()
+	if err != nil {
+		return err
+	}
+	cryptoImpl, err := crypto.GetCryptoManager().GetCrypto(caConfig.CryptoAlgo)
+	if err != nil {
+		return err
+	}
+	if x.policySvr, err = auth.GetStrategyServer(); err != nil {
+		return err
+	}
+	authority, err := ca.NewCertificateAuthority(caConfig, s, cryptoImpl)
+	if err != nil {
+		log.Errorf("init xds certificate authority fail: %v", err)
+		return err
+	}
+	x.secretMgr = newSecretManager(authority, x.nodeMgr, x.cache, x.authorizeSecret)
+	x.resourceGenerator.builtinCA = true
 	return nil
 }
 
@@ -142,7 +183,8 @@ func (x *XDSServer) Run(errCh chan error) {
 	cb := xdscache.NewCallback(x.cache, x.nodeMgr)
 	srv := serverv3.NewServer(ctx, x.cache, cb, sotw.WithOrderedADS())
 	var grpcOptions []grpc.ServerOption
-	grpcOptions = append(grpcOptions, grpc.MaxConcurrentStreams(1000))
+	grpcOptions = append(grpcOptions, grpc.MaxConcurrentStreams(1000),
+		grpc.StreamInterceptor(x.tracker.StreamServerInterceptor()))
 	grpcServer := grpc.NewServer(grpcOptions...)
 	x.server = grpcServer
 	address := fmt.Sprintf("%v:%v", x.listenIP, x.listenPort)
@@ -165,6 +207,9 @@ func (x *XDSServer) Run(errCh chan error) {
 	}
 
 	registerServer(grpcServer, srv, x)
+	hdsCtx, hdsCancel := context.WithCancel(context.Background())
+	defer hdsCancel()
+	go x.hds.run(hdsCtx)
 	log.Infof("management server listening on %d\n", x.listenPort)
 	if err = grpcServer.Serve(listener); err != nil {
 		log.Errorf("%v", err)
@@ -195,6 +240,15 @@ func (x *XDSServer) Stop() {
 	}
 }
 
+// Drain 拒绝新的 xDS stream, 并在 window 时间内分批断开存量的 stream, 避免 Envoy 集中重连
+func (x *XDSServer) Drain(ctx context.Context, window time.Duration) {
+	if x.tracker == nil {
+		return
+	}
+	log.Infof("begin drain %d xds streams", x.tracker.Count())
+	x.tracker.Drain(ctx, window)
+}
+
 // Restart 重启服务
 func (x *XDSServer) Restart(option map[string]interface{}, apiConf map[string]apiserver.APIConfig,
 	errCh chan error) error {
@@ -249,6 +303,9 @@ func (x *XDSServer) activeUpdateTask() {
 	// 首次更新没有需要移除的 XDS 资源信息
 	x.Generate(x.registryInfo.Load(), nil)
 	go x.startSynTask(x.ctx)
+	if x.secretMgr != nil {
+		go x.secretMgr.runRotateTask(x.ctx)
+	}
 }
 
 func (x *XDSServer) startSynTask(ctx context.Context) {
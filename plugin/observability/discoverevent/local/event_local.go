@@ -173,6 +173,7 @@ var (
 		string(svctypes.EventInstanceOnline):       {},
 		string(svctypes.EventInstanceTurnHealth):   {},
 		string(svctypes.EventInstanceTurnUnHealth): {},
+		string(svctypes.EventInstanceFlapping):     {},
 	}
 )
 
@@ -56,6 +56,11 @@ func (h *HistoryLogger) Initialize(c *apis.ConfigEntry) error {
 	return nil
 }
 
+// Reload 插件热更新, 日志插件没有需要更新的配置
+func (h *HistoryLogger) Reload(c *apis.ConfigEntry) error {
+	return nil
+}
+
 func (h *HistoryLogger) Type() apis.PluginType {
 	return apis.PluginTypeHistory
 }
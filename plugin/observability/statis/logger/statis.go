@@ -45,6 +45,9 @@ func init() {
 type StatisWorker struct {
 	*base.BaseWorker
 	cancel context.CancelFunc
+	// ctx 插件生命周期, runCancel 仅控制定时输出协程, 热更新周期时重建
+	ctx       context.Context
+	runCancel context.CancelFunc
 }
 
 // Name 获取统计插件名称
@@ -64,14 +67,32 @@ func (s *StatisWorker) Initialize(conf *apis.ConfigEntry) error {
 	s.cancel = cancel
 	s.BaseWorker = baseWorker
 
+	s.ctx = ctx
+	s.startRun(conf)
+	return nil
+}
+
+// Reload 热更新统计输出周期
+func (s *StatisWorker) Reload(conf *apis.ConfigEntry) error {
+	if s.ctx == nil {
+		return s.Initialize(conf)
+	}
+	s.startRun(conf)
+	return nil
+}
+
+func (s *StatisWorker) startRun(conf *apis.ConfigEntry) {
+	if s.runCancel != nil {
+		s.runCancel()
+	}
 	// 设置统计打印周期
 	interval, _ := conf.Option["interval"].(int)
 	if interval == 0 {
 		interval = 60
 	}
-
-	go s.Run(ctx, time.Duration(interval)*time.Second)
-	return nil
+	runCtx, runCancel := context.WithCancel(s.ctx)
+	s.runCancel = runCancel
+	go s.Run(runCtx, time.Duration(interval)*time.Second)
 }
 
 func (s *StatisWorker) Type() apis.PluginType {
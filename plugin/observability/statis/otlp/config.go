@@ -0,0 +1,93 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package otlp
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// DefaultEndpoint 默认的 OTLP gRPC 接收端地址
+	DefaultEndpoint = "127.0.0.1:4317"
+	// DefaultInterval 默认的统计及推送周期, 单位秒, 与其他统计插件保持一致
+	DefaultInterval = 60
+	// DefaultTimeout 单次推送的超时时间
+	DefaultTimeout = 10 * time.Second
+	// DefaultServiceName 上报指标时使用的服务名
+	DefaultServiceName = "pole-server"
+)
+
+// Config OTLP 统计插件的配置
+type Config struct {
+	// Endpoint OTLP gRPC 接收端地址, 例如 otel-collector:4317
+	Endpoint string `mapstructure:"endpoint"`
+	// Insecure 是否使用明文连接接收端
+	Insecure bool `mapstructure:"insecure"`
+	// Headers 推送指标时携带的额外请求头, 例如鉴权 token
+	Headers map[string]string `mapstructure:"headers"`
+	// Interval 接口调用指标的统计周期以及指标的推送周期, 单位秒
+	Interval int `mapstructure:"interval"`
+	// Timeout 单次推送的超时时间
+	Timeout time.Duration `mapstructure:"timeout"`
+	// ServiceName 上报指标时使用的服务名
+	ServiceName string `mapstructure:"serviceName"`
+	// ResourceAttributes 附加在 resource 上的属性, 例如集群、地域
+	ResourceAttributes map[string]string `mapstructure:"resourceAttributes"`
+}
+
+// LoadConfig 解析插件配置, 未设置的字段使用默认值
+func LoadConfig(raw map[string]interface{}) (*Config, error) {
+	cfg := &Config{
+		Endpoint:    DefaultEndpoint,
+		Insecure:    true,
+		Interval:    DefaultInterval,
+		Timeout:     DefaultTimeout,
+		ServiceName: DefaultServiceName,
+	}
+	if raw == nil {
+		return cfg, nil
+	}
+	decodeConfig := &mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           cfg,
+	}
+	decoder, err := mapstructure.NewDecoder(decodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return nil, err
+	}
+	if cfg.Endpoint == "" {
+		return nil, errors.New("otlp statis endpoint is empty")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = DefaultServiceName
+	}
+	return cfg, nil
+}
@@ -0,0 +1,88 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package otlp
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	metricstypes "github.com/pole-io/pole-server/apis/pkg/types/metrics"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+)
+
+type gaugePoint struct {
+	attrs attribute.Set
+	value float64
+}
+
+// gaugeSet 记录 gauge 类指标的最新值, 推送时由 observable gauge 的回调读取,
+// 与 prometheus 插件中 GaugeVec 的 Set/Delete 语义一致
+type gaugeSet struct {
+	lock   sync.RWMutex
+	values map[string]map[attribute.Distinct]gaugePoint
+}
+
+func newGaugeSet() *gaugeSet {
+	return &gaugeSet{
+		values: map[string]map[attribute.Distinct]gaugePoint{},
+	}
+}
+
+func (g *gaugeSet) set(name string, labels map[string]string, value float64) {
+	attrs := buildAttributes(labels)
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	points, ok := g.values[name]
+	if !ok {
+		points = map[attribute.Distinct]gaugePoint{}
+		g.values[name] = points
+	}
+	points[attrs.Equivalent()] = gaugePoint{attrs: attrs, value: value}
+}
+
+func (g *gaugeSet) delete(name string, labels map[string]string) {
+	attrs := buildAttributes(labels)
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	delete(g.values[name], attrs.Equivalent())
+}
+
+// callback 返回指定指标的回调函数
+func (g *gaugeSet) callback(name string) metric.Float64Callback {
+	return func(_ context.Context, o metric.Float64Observer) error {
+		g.lock.RLock()
+		defer g.lock.RUnlock()
+		for _, point := range g.values[name] {
+			o.Observe(point.value, metric.WithAttributeSet(point.attrs))
+		}
+		return nil
+	}
+}
+
+// buildAttributes 将 prometheus 风格的 label 转换为 OTLP 属性, 并补充当前节点的 label
+func buildAttributes(labels map[string]string) attribute.Set {
+	kvs := make([]attribute.KeyValue, 0, len(labels)+1)
+	for k, v := range labels {
+		kvs = append(kvs, attribute.String(k, v))
+	}
+	kvs = append(kvs, attribute.String(metricstypes.LabelServerNode, utils.LocalHost))
+	return attribute.NewSet(kvs...)
+}
@@ -0,0 +1,75 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package otlp
+
+const (
+	// MetricForClientTotal 客户端实例总数
+	MetricForClientTotal = "client_total"
+	// MetricForServiceCount 服务总数
+	MetricForServiceCount = "service_count"
+	// MetricForServiceOnlineCount 在线的服务数
+	MetricForServiceOnlineCount = "service_online_count"
+	// MetricForServiceAbnormalCount 异常的服务数
+	MetricForServiceAbnormalCount = "service_abnormal_count"
+	// MetricForServiceOfflineCount 离线的服务数
+	MetricForServiceOfflineCount = "service_offline_count"
+	// MetricForInstanceCount 实例总数
+	MetricForInstanceCount = "instance_count"
+	// MetricForInstanceOnlineCount 健康的实例数
+	MetricForInstanceOnlineCount = "instance_online_count"
+	// MetricForInstanceAbnormalCount 不健康的实例数
+	MetricForInstanceAbnormalCount = "instance_abnormal_count"
+	// MetricForInstanceIsolateCount 隔离的实例数
+	MetricForInstanceIsolateCount = "instance_isolate_count"
+	// MetricForConfigGroupCount 配置分组总数
+	MetricForConfigGroupCount = "config_group_count"
+	// MetricForConfigFileCount 每个配置分组下的配置文件数
+	MetricForConfigFileCount = "config_file_count"
+	// MetricForConfigReleaseFileCount 每个配置分组下已发布的配置文件数
+	MetricForConfigReleaseFileCount = "config_release_file_count"
+	// MetricForClientDiscoverTotal 客户端拉取资源的请求次数
+	MetricForClientDiscoverTotal = "client_discover_total"
+	// MetricForClientDiscoverCost 客户端拉取资源的请求耗时
+	MetricForClientDiscoverCost = "client_discover_cost"
+
+	// LabelAction 客户端拉取资源的类型, 例如 DISCOVER_INSTANCE
+	LabelAction = "action"
+	// LabelSuccess 客户端拉取资源是否成功
+	LabelSuccess = "success"
+)
+
+type gaugeDesc struct {
+	name string
+	help string
+}
+
+// gaugeDescList 与 prometheus 插件保持一致的资源数量类指标, label 直接使用上报数据中的 Labels
+var gaugeDescList = []gaugeDesc{
+	{name: MetricForClientTotal, help: "polaris client instance total number"},
+	{name: MetricForServiceCount, help: "service total number"},
+	{name: MetricForServiceOnlineCount, help: "total number of service status is online"},
+	{name: MetricForServiceAbnormalCount, help: "total number of service status is abnormal"},
+	{name: MetricForServiceOfflineCount, help: "total number of service status is offline"},
+	{name: MetricForInstanceCount, help: "instance total number"},
+	{name: MetricForInstanceOnlineCount, help: "total number of instance status is health"},
+	{name: MetricForInstanceAbnormalCount, help: "total number of instance status is unhealth"},
+	{name: MetricForInstanceIsolateCount, help: "total number of instance status is isolate"},
+	{name: MetricForConfigGroupCount, help: "polaris config group total number"},
+	{name: MetricForConfigFileCount, help: "total number of config_file each config group"},
+	{name: MetricForConfigReleaseFileCount, help: "total number of config_release_file each config group"},
+}
@@ -0,0 +1,323 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/pole-io/pole-server/apis"
+	metricstypes "github.com/pole-io/pole-server/apis/pkg/types/metrics"
+	"github.com/pole-io/pole-server/pkg/common/log"
+	"github.com/pole-io/pole-server/pkg/common/utils"
+	"github.com/pole-io/pole-server/pkg/common/version"
+	"github.com/pole-io/pole-server/plugin/observability/statis/base"
+)
+
+const (
+	PluginName = "otlp"
+
+	// instrumentationName 创建 meter 时使用的埋点库名称
+	instrumentationName = "github.com/pole-io/pole-server/plugin/observability/statis/otlp"
+)
+
+func init() {
+	s := &StatisWorker{}
+	apis.RegisterPlugin(s.Name(), s)
+}
+
+// StatisWorker 通过 OTLP 协议主动推送指标的统计插件
+type StatisWorker struct {
+	*base.BaseWorker
+	cancel context.CancelFunc
+	// ctx 插件生命周期, runCancel 仅控制定时统计协程, 热更新周期时重建
+	ctx       context.Context
+	runCancel context.CancelFunc
+	gauges    *gaugeSet
+
+	// lock 保护热更新时替换的 provider 以及同步上报的指标
+	lock          sync.RWMutex
+	config        *Config
+	provider      *sdkmetric.MeterProvider
+	discoverTotal metric.Int64Counter
+	discoverCost  metric.Float64Histogram
+}
+
+// Name 获取统计插件名称
+func (s *StatisWorker) Name() string {
+	return PluginName
+}
+
+// Initialize 初始化统计插件
+func (s *StatisWorker) Initialize(conf *apis.ConfigEntry) error {
+	cfg, err := LoadConfig(conf.Option)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.gauges = newGaugeSet()
+	if err := s.setupProvider(ctx, cfg); err != nil {
+		cancel()
+		return err
+	}
+
+	baseWorker, err := base.NewBaseWorker(ctx, s.metricsHandle)
+	if err != nil {
+		cancel()
+		return err
+	}
+	s.cancel = cancel
+	s.BaseWorker = baseWorker
+
+	s.ctx = ctx
+	s.startRun(cfg)
+	return nil
+}
+
+// Reload 热更新接收端地址以及统计周期, 已经记录的 gauge 指标会在新的 provider 中继续推送
+func (s *StatisWorker) Reload(conf *apis.ConfigEntry) error {
+	if s.ctx == nil {
+		return s.Initialize(conf)
+	}
+	cfg, err := LoadConfig(conf.Option)
+	if err != nil {
+		return err
+	}
+	if err := s.setupProvider(s.ctx, cfg); err != nil {
+		return err
+	}
+	s.startRun(cfg)
+	return nil
+}
+
+func (s *StatisWorker) startRun(cfg *Config) {
+	if s.runCancel != nil {
+		s.runCancel()
+	}
+	runCtx, runCancel := context.WithCancel(s.ctx)
+	s.runCancel = runCancel
+	go s.Run(runCtx, time.Duration(cfg.Interval)*time.Second)
+}
+
+// setupProvider 按照配置创建新的 MeterProvider 并注册全部指标, 成功后关闭旧的 provider
+func (s *StatisWorker) setupProvider(ctx context.Context, cfg *Config) error {
+	opts := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(cfg.Endpoint),
+		otlpmetricgrpc.WithTimeout(cfg.Timeout),
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	if len(cfg.Headers) != 0 {
+		opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.Headers))
+	}
+	// exporter 内部异步建立连接，接收端不可用时不会阻塞启动
+	exporter, err := otlpmetricgrpc.New(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("create otlp metric exporter: %w", err)
+	}
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter,
+			sdkmetric.WithInterval(time.Duration(cfg.Interval)*time.Second))),
+		sdkmetric.WithResource(newResource(cfg)),
+	)
+	meter := provider.Meter(instrumentationName, metric.WithInstrumentationVersion(version.Get()))
+	if err := s.registerGauges(meter); err != nil {
+		_ = provider.Shutdown(context.Background())
+		return err
+	}
+	discoverTotal, err := meter.Int64Counter(MetricForClientDiscoverTotal,
+		metric.WithDescription("total number of client discover requests"))
+	if err != nil {
+		_ = provider.Shutdown(context.Background())
+		return err
+	}
+	discoverCost, err := meter.Float64Histogram(MetricForClientDiscoverCost,
+		metric.WithDescription("cost time of client discover requests"), metric.WithUnit("ms"))
+	if err != nil {
+		_ = provider.Shutdown(context.Background())
+		return err
+	}
+
+	s.lock.Lock()
+	oldProvider, oldConfig := s.provider, s.config
+	s.config = cfg
+	s.provider = provider
+	s.discoverTotal = discoverTotal
+	s.discoverCost = discoverCost
+	s.lock.Unlock()
+
+	if oldProvider != nil {
+		go shutdownProvider(oldProvider, oldConfig.Timeout)
+	}
+	return nil
+}
+
+// registerGauges 注册与 prometheus 插件同名的 gauge 指标
+func (s *StatisWorker) registerGauges(meter metric.Meter) error {
+	for _, desc := range base.MetricDescList {
+		if _, err := meter.Float64ObservableGauge(desc.Name, metric.WithDescription(desc.Help),
+			metric.WithUnit("ms"), metric.WithFloat64Callback(s.gauges.callback(desc.Name))); err != nil {
+			return err
+		}
+	}
+	for _, desc := range gaugeDescList {
+		if _, err := meter.Float64ObservableGauge(desc.name, metric.WithDescription(desc.help),
+			metric.WithFloat64Callback(s.gauges.callback(desc.name))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newResource(cfg *Config) *resource.Resource {
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(version.Get()),
+		semconv.ServiceInstanceID(utils.LocalHost),
+	}
+	for k, v := range cfg.ResourceAttributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	return resource.NewSchemaless(attrs...)
+}
+
+func shutdownProvider(provider *sdkmetric.MeterProvider, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := provider.Shutdown(ctx); err != nil {
+		log.Errorf("[APICall] shutdown otlp meter provider error, %v", err)
+	}
+}
+
+func (s *StatisWorker) Type() apis.PluginType {
+	return apis.PluginTypeStatis
+}
+
+// Destroy 销毁统计插件, 退出前推送剩余的指标
+func (s *StatisWorker) Destroy() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.lock.Lock()
+	provider, cfg := s.provider, s.config
+	s.provider = nil
+	s.lock.Unlock()
+	if provider != nil {
+		shutdownProvider(provider, cfg.Timeout)
+	}
+	return nil
+}
+
+// ReportCallMetrics report call metrics info
+func (s *StatisWorker) ReportCallMetrics(metric metricstypes.CallMetric) {
+	// 只上报服务端接受客户端请求调用的结果
+	if metric.Type != metricstypes.ServerCallMetric {
+		return
+	}
+	s.BaseWorker.ReportCallMetrics(metric)
+}
+
+// ReportDiscoveryMetrics report discovery metrics
+func (s *StatisWorker) ReportDiscoveryMetrics(metric ...metricstypes.DiscoveryMetric) {
+	for i := range metric {
+		m := metric[i]
+		switch m.Type {
+		case metricstypes.ServiceMetrics:
+			s.gauges.set(MetricForServiceCount, m.Labels, float64(m.Total))
+			s.gauges.set(MetricForServiceAbnormalCount, m.Labels, float64(m.Abnormal))
+			s.gauges.set(MetricForServiceOfflineCount, m.Labels, float64(m.Offline))
+			s.gauges.set(MetricForServiceOnlineCount, m.Labels, float64(m.Online))
+		case metricstypes.InstanceMetrics:
+			s.gauges.set(MetricForInstanceCount, m.Labels, float64(m.Total))
+			s.gauges.set(MetricForInstanceAbnormalCount, m.Labels, float64(m.Abnormal))
+			s.gauges.set(MetricForInstanceIsolateCount, m.Labels, float64(m.Isolate))
+			s.gauges.set(MetricForInstanceOnlineCount, m.Labels, float64(m.Online))
+		case metricstypes.ClientMetrics:
+			s.gauges.set(MetricForClientTotal, nil, float64(m.Total))
+		}
+	}
+}
+
+// ReportConfigMetrics report config_center metrics
+func (s *StatisWorker) ReportConfigMetrics(metric ...metricstypes.ConfigMetrics) {
+	for i := range metric {
+		m := metric[i]
+		switch m.Type {
+		case metricstypes.ConfigGroupMetric:
+			s.gauges.set(MetricForConfigGroupCount, m.Labels, float64(m.Total))
+		case metricstypes.FileMetric:
+			s.gauges.set(MetricForConfigFileCount, m.Labels, float64(m.Total))
+		case metricstypes.ReleaseFileMetric:
+			s.gauges.set(MetricForConfigReleaseFileCount, m.Labels, float64(m.Total))
+		}
+	}
+}
+
+// ReportDiscoverCall report discover service times
+func (s *StatisWorker) ReportDiscoverCall(m metricstypes.ClientDiscoverMetric) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.provider == nil {
+		return
+	}
+	attrs := metric.WithAttributeSet(buildAttributes(map[string]string{
+		metricstypes.LabelNamespace: m.Namespace,
+		LabelAction:                 m.Action,
+		LabelSuccess:                strconv.FormatBool(m.Success),
+	}))
+	ctx := context.Background()
+	s.discoverTotal.Add(ctx, 1, attrs)
+	s.discoverCost.Record(ctx, float64(m.CostTime), attrs)
+}
+
+func (s *StatisWorker) metricsHandle(mt metricstypes.CallMetricType, start time.Time,
+	staticsSlice []*base.APICallStatisItem) {
+	if mt != metricstypes.ServerCallMetric {
+		return
+	}
+
+	for _, item := range staticsSlice {
+		labels := base.BuildMetricLabels(item)
+		if item.Count == 0 && item.ZeroDuration > base.MaxZeroDuration {
+			for _, desc := range base.MetricDescList {
+				s.gauges.delete(desc.Name, labels)
+			}
+			continue
+		}
+		var avgTime float64
+		if item.Count > 0 {
+			avgTime = float64(item.AccTime) / float64(item.Count) / 1e6
+		}
+		s.gauges.set(base.MetricForClientRqTimeoutMax, labels, float64(item.MaxTime)/1e6)
+		s.gauges.set(base.MetricForClientRqTimeoutAvg, labels, avgTime)
+		s.gauges.set(base.MetricForClientRqTimeout, labels, float64(item.AccTime)/1e6)
+		s.gauges.set(base.MetricForClientRqIntervalCount, labels, float64(item.Count))
+		s.gauges.set(base.MetricForClientRqTimeoutMin, labels, float64(item.MinTime)/1e6)
+	}
+}
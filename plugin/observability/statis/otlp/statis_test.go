@@ -0,0 +1,229 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package otlp
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	metricsv1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+
+	"github.com/pole-io/pole-server/apis"
+	metricstypes "github.com/pole-io/pole-server/apis/pkg/types/metrics"
+	"github.com/pole-io/pole-server/plugin/observability/statis/base"
+)
+
+// mockReceiver 进程内的 OTLP 指标接收端
+type mockReceiver struct {
+	collectormetrics.UnimplementedMetricsServiceServer
+	lock     sync.Mutex
+	requests []*collectormetrics.ExportMetricsServiceRequest
+}
+
+func (r *mockReceiver) Export(_ context.Context,
+	req *collectormetrics.ExportMetricsServiceRequest) (*collectormetrics.ExportMetricsServiceResponse, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.requests = append(r.requests, req)
+	return &collectormetrics.ExportMetricsServiceResponse{}, nil
+}
+
+// metrics 返回按照名称索引的指标以及 resource 属性
+func (r *mockReceiver) metrics() (map[string]*metricsv1.Metric, map[string]string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	ret := map[string]*metricsv1.Metric{}
+	res := map[string]string{}
+	for _, req := range r.requests {
+		for _, rm := range req.GetResourceMetrics() {
+			for k, v := range toLabels(rm.GetResource().GetAttributes()) {
+				res[k] = v
+			}
+			for _, sm := range rm.GetScopeMetrics() {
+				for _, m := range sm.GetMetrics() {
+					ret[m.GetName()] = m
+				}
+			}
+		}
+	}
+	return ret, res
+}
+
+func toLabels(kvs []*commonv1.KeyValue) map[string]string {
+	labels := map[string]string{}
+	for _, kv := range kvs {
+		labels[kv.GetKey()] = kv.GetValue().GetStringValue()
+	}
+	return labels
+}
+
+func startReceiver(t *testing.T) (*mockReceiver, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	receiver := &mockReceiver{}
+	server := grpc.NewServer()
+	collectormetrics.RegisterMetricsServiceServer(server, receiver)
+	go func() {
+		_ = server.Serve(ln)
+	}()
+	t.Cleanup(server.Stop)
+	return receiver, ln.Addr().String()
+}
+
+func newTestWorker(t *testing.T, endpoint string) *StatisWorker {
+	s := &StatisWorker{}
+	err := s.Initialize(&apis.ConfigEntry{
+		Name: PluginName,
+		Option: map[string]interface{}{
+			"endpoint": endpoint,
+			"insecure": true,
+			"interval": 3600,
+			"resourceAttributes": map[string]interface{}{
+				"deployment.environment": "test",
+			},
+		},
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = s.Destroy()
+	})
+	return s
+}
+
+func flush(t *testing.T, s *StatisWorker) {
+	s.lock.RLock()
+	provider := s.provider
+	s.lock.RUnlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, provider.ForceFlush(ctx))
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultEndpoint, cfg.Endpoint)
+	assert.Equal(t, DefaultInterval, cfg.Interval)
+
+	cfg, err = LoadConfig(map[string]interface{}{
+		"endpoint": "otel-collector:4317",
+		"interval": "30",
+		"timeout":  "3s",
+		"headers": map[string]interface{}{
+			"authorization": "token",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "otel-collector:4317", cfg.Endpoint)
+	assert.Equal(t, 30, cfg.Interval)
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+	assert.Equal(t, "token", cfg.Headers["authorization"])
+}
+
+func TestStatisWorker_Export(t *testing.T) {
+	receiver, endpoint := startReceiver(t)
+	s := newTestWorker(t, endpoint)
+
+	s.ReportDiscoveryMetrics(metricstypes.DiscoveryMetric{
+		Type:     metricstypes.InstanceMetrics,
+		Total:    3,
+		Online:   2,
+		Abnormal: 1,
+		Labels: map[string]string{
+			metricstypes.LabelNamespace: "default",
+			metricstypes.LabelService:   "echo",
+		},
+	}, metricstypes.DiscoveryMetric{
+		Type:  metricstypes.ClientMetrics,
+		Total: 5,
+	})
+	s.ReportConfigMetrics(metricstypes.ConfigMetrics{
+		Type:  metricstypes.FileMetric,
+		Total: 4,
+		Labels: map[string]string{
+			metricstypes.LabelNamespace: "default",
+			metricstypes.LabelGroup:     "app",
+		},
+	})
+	s.ReportDiscoverCall(metricstypes.ClientDiscoverMetric{
+		Action:    metricstypes.ActionDiscoverInstance,
+		Namespace: "default",
+		CostTime:  12,
+		Success:   true,
+	})
+	s.metricsHandle(metricstypes.ServerCallMetric, time.Now(), []*base.APICallStatisItem{
+		{
+			API:      "Discover",
+			Protocol: "grpc",
+			Code:     200000,
+			Count:    2,
+			AccTime:  int64(30 * time.Millisecond),
+			MinTime:  int64(10 * time.Millisecond),
+			MaxTime:  int64(20 * time.Millisecond),
+		},
+	})
+	flush(t, s)
+
+	ms, res := receiver.metrics()
+	assert.Equal(t, DefaultServiceName, res["service.name"])
+	assert.Equal(t, "test", res["deployment.environment"])
+
+	instances := ms[MetricForInstanceCount].GetGauge().GetDataPoints()
+	assert.Len(t, instances, 1)
+	assert.Equal(t, float64(3), instances[0].GetAsDouble())
+	labels := toLabels(instances[0].GetAttributes())
+	assert.Equal(t, "default", labels[metricstypes.LabelNamespace])
+	assert.Equal(t, "echo", labels[metricstypes.LabelService])
+	assert.Contains(t, labels, metricstypes.LabelServerNode)
+
+	assert.Equal(t, float64(5), ms[MetricForClientTotal].GetGauge().GetDataPoints()[0].GetAsDouble())
+	assert.Equal(t, float64(4), ms[MetricForConfigFileCount].GetGauge().GetDataPoints()[0].GetAsDouble())
+
+	discover := ms[MetricForClientDiscoverTotal].GetSum().GetDataPoints()
+	assert.Len(t, discover, 1)
+	assert.Equal(t, int64(1), discover[0].GetAsInt())
+	assert.Equal(t, "true", toLabels(discover[0].GetAttributes())[LabelSuccess])
+	assert.Equal(t, uint64(1), ms[MetricForClientDiscoverCost].GetHistogram().GetDataPoints()[0].GetCount())
+
+	avg := ms[base.MetricForClientRqTimeoutAvg].GetGauge().GetDataPoints()
+	assert.Len(t, avg, 1)
+	assert.Equal(t, float64(15), avg[0].GetAsDouble())
+	assert.Equal(t, "Discover", toLabels(avg[0].GetAttributes())[metricstypes.LabelApi])
+}
+
+func TestStatisWorker_DeleteIdleCall(t *testing.T) {
+	receiver, endpoint := startReceiver(t)
+	s := newTestWorker(t, endpoint)
+
+	item := &base.APICallStatisItem{API: "Discover", Protocol: "grpc", Code: 200000, Count: 1}
+	s.metricsHandle(metricstypes.ServerCallMetric, time.Now(), []*base.APICallStatisItem{item})
+	item = &base.APICallStatisItem{API: "Discover", Protocol: "grpc", Code: 200000,
+		ZeroDuration: base.MaxZeroDuration + 1}
+	s.metricsHandle(metricstypes.ServerCallMetric, time.Now(), []*base.APICallStatisItem{item})
+	flush(t, s)
+
+	ms, _ := receiver.metrics()
+	assert.Empty(t, ms[base.MetricForClientRqIntervalCount].GetGauge().GetDataPoints())
+}
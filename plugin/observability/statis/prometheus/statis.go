@@ -43,7 +43,10 @@ func init() {
 // PrometheusStatis is a struct for prometheus statistics
 type StatisWorker struct {
 	*base.BaseWorker
-	cancel           context.CancelFunc
+	cancel context.CancelFunc
+	// ctx 插件生命周期, runCancel 仅控制定时输出协程, 热更新周期时重建
+	ctx              context.Context
+	runCancel        context.CancelFunc
 	discoveryHandler *discoveryMetricHandle
 	configHandler    *configMetricHandle
 	metricVecCaches  map[string]*prometheus.GaugeVec
@@ -65,12 +68,6 @@ func (s *StatisWorker) Initialize(conf *apis.ConfigEntry) error {
 		return err
 	}
 
-	// 设置统计打印周期
-	interval, _ := conf.Option["interval"].(int)
-	if interval == 0 {
-		interval = 60
-	}
-
 	baseWorker, err := base.NewBaseWorker(ctx, s.metricsHandle)
 	if err != nil {
 		cancel()
@@ -78,10 +75,34 @@ func (s *StatisWorker) Initialize(conf *apis.ConfigEntry) error {
 	}
 	s.BaseWorker = baseWorker
 
-	go s.Run(ctx, time.Duration(interval)*time.Second)
+	s.ctx = ctx
+	s.startRun(conf)
+	return nil
+}
+
+// Reload 热更新统计输出周期
+func (s *StatisWorker) Reload(conf *apis.ConfigEntry) error {
+	if s.ctx == nil {
+		return s.Initialize(conf)
+	}
+	s.startRun(conf)
 	return nil
 }
 
+func (s *StatisWorker) startRun(conf *apis.ConfigEntry) {
+	if s.runCancel != nil {
+		s.runCancel()
+	}
+	// 设置统计打印周期
+	interval, _ := conf.Option["interval"].(int)
+	if interval == 0 {
+		interval = 60
+	}
+	runCtx, runCancel := context.WithCancel(s.ctx)
+	s.runCancel = runCancel
+	go s.Run(runCtx, time.Duration(interval)*time.Second)
+}
+
 func (s *StatisWorker) Type() apis.PluginType {
 	return apis.PluginTypeStatis
 }
@@ -141,17 +141,17 @@ func (mr *MockStoreMockRecorder) AddGroup(tx, group interface{}) *gomock.Call {
 }
 
 // AddInstance mocks base method.
-func (m *MockStore) AddInstance(instance *service.Instance) error {
+func (m *MockStore) AddInstance(ctx context.Context, instance *service.Instance) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddInstance", instance)
+	ret := m.ctrl.Call(m, "AddInstance", ctx, instance)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddInstance indicates an expected call of AddInstance.
-func (mr *MockStoreMockRecorder) AddInstance(instance interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) AddInstance(ctx, instance interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddInstance", reflect.TypeOf((*MockStore)(nil).AddInstance), instance)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddInstance", reflect.TypeOf((*MockStore)(nil).AddInstance), ctx, instance)
 }
 
 // AddLaneGroup mocks base method.
@@ -281,31 +281,46 @@ func (mr *MockStoreMockRecorder) BatchAddClients(clients interface{}) *gomock.Ca
 }
 
 // BatchAddInstances mocks base method.
-func (m *MockStore) BatchAddInstances(instances []*service.Instance) error {
+func (m *MockStore) BatchAddInstances(ctx context.Context, instances []*service.Instance) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BatchAddInstances", instances)
+	ret := m.ctrl.Call(m, "BatchAddInstances", ctx, instances)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // BatchAddInstances indicates an expected call of BatchAddInstances.
-func (mr *MockStoreMockRecorder) BatchAddInstances(instances interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) BatchAddInstances(ctx, instances interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchAddInstances", reflect.TypeOf((*MockStore)(nil).BatchAddInstances), instances)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchAddInstances", reflect.TypeOf((*MockStore)(nil).BatchAddInstances), ctx, instances)
 }
 
 // BatchAppendInstanceMetadata mocks base method.
-func (m *MockStore) BatchAppendInstanceMetadata(requests []*store.InstanceMetadataRequest) error {
+func (m *MockStore) BatchAppendInstanceMetadata(ctx context.Context, requests []*store.InstanceMetadataRequest) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BatchAppendInstanceMetadata", requests)
+	ret := m.ctrl.Call(m, "BatchAppendInstanceMetadata", ctx, requests)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // BatchAppendInstanceMetadata indicates an expected call of BatchAppendInstanceMetadata.
-func (mr *MockStoreMockRecorder) BatchAppendInstanceMetadata(requests interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) BatchAppendInstanceMetadata(ctx, requests interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchAppendInstanceMetadata", reflect.TypeOf((*MockStore)(nil).BatchAppendInstanceMetadata), requests)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchAppendInstanceMetadata", reflect.TypeOf((*MockStore)(nil).BatchAppendInstanceMetadata), ctx, requests)
+}
+
+// BatchCleanChangeLogs mocks base method.
+func (m *MockStore) BatchCleanChangeLogs(timeout time.Duration, batchSize uint32) (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchCleanChangeLogs", timeout, batchSize)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchCleanChangeLogs indicates an expected call of BatchCleanChangeLogs.
+func (mr *MockStoreMockRecorder) BatchCleanChangeLogs(timeout, batchSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchCleanChangeLogs", reflect.TypeOf((*MockStore)(nil).BatchCleanChangeLogs), timeout, batchSize)
 }
 
 // BatchCleanDeletedClients mocks base method.
@@ -413,60 +428,60 @@ func (mr *MockStoreMockRecorder) BatchDeleteClients(ids interface{}) *gomock.Cal
 }
 
 // BatchDeleteInstances mocks base method.
-func (m *MockStore) BatchDeleteInstances(ids []interface{}) error {
+func (m *MockStore) BatchDeleteInstances(ctx context.Context, ids []interface{}) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BatchDeleteInstances", ids)
+	ret := m.ctrl.Call(m, "BatchDeleteInstances", ctx, ids)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // BatchDeleteInstances indicates an expected call of BatchDeleteInstances.
-func (mr *MockStoreMockRecorder) BatchDeleteInstances(ids interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) BatchDeleteInstances(ctx, ids interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchDeleteInstances", reflect.TypeOf((*MockStore)(nil).BatchDeleteInstances), ids)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchDeleteInstances", reflect.TypeOf((*MockStore)(nil).BatchDeleteInstances), ctx, ids)
 }
 
 // BatchGetInstanceIsolate mocks base method.
-func (m *MockStore) BatchGetInstanceIsolate(ids map[string]bool) (map[string]bool, error) {
+func (m *MockStore) BatchGetInstanceIsolate(ctx context.Context, ids map[string]bool) (map[string]bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BatchGetInstanceIsolate", ids)
+	ret := m.ctrl.Call(m, "BatchGetInstanceIsolate", ctx, ids)
 	ret0, _ := ret[0].(map[string]bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // BatchGetInstanceIsolate indicates an expected call of BatchGetInstanceIsolate.
-func (mr *MockStoreMockRecorder) BatchGetInstanceIsolate(ids interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) BatchGetInstanceIsolate(ctx, ids interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchGetInstanceIsolate", reflect.TypeOf((*MockStore)(nil).BatchGetInstanceIsolate), ids)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchGetInstanceIsolate", reflect.TypeOf((*MockStore)(nil).BatchGetInstanceIsolate), ctx, ids)
 }
 
 // BatchRemoveInstanceMetadata mocks base method.
-func (m *MockStore) BatchRemoveInstanceMetadata(requests []*store.InstanceMetadataRequest) error {
+func (m *MockStore) BatchRemoveInstanceMetadata(ctx context.Context, requests []*store.InstanceMetadataRequest) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BatchRemoveInstanceMetadata", requests)
+	ret := m.ctrl.Call(m, "BatchRemoveInstanceMetadata", ctx, requests)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // BatchRemoveInstanceMetadata indicates an expected call of BatchRemoveInstanceMetadata.
-func (mr *MockStoreMockRecorder) BatchRemoveInstanceMetadata(requests interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) BatchRemoveInstanceMetadata(ctx, requests interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchRemoveInstanceMetadata", reflect.TypeOf((*MockStore)(nil).BatchRemoveInstanceMetadata), requests)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchRemoveInstanceMetadata", reflect.TypeOf((*MockStore)(nil).BatchRemoveInstanceMetadata), ctx, requests)
 }
 
 // BatchSetInstanceHealthStatus mocks base method.
-func (m *MockStore) BatchSetInstanceHealthStatus(ids []interface{}, healthy int, revision string) error {
+func (m *MockStore) BatchSetInstanceHealthStatus(ctx context.Context, ids []interface{}, healthy int, revision string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BatchSetInstanceHealthStatus", ids, healthy, revision)
+	ret := m.ctrl.Call(m, "BatchSetInstanceHealthStatus", ctx, ids, healthy, revision)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // BatchSetInstanceHealthStatus indicates an expected call of BatchSetInstanceHealthStatus.
-func (mr *MockStoreMockRecorder) BatchSetInstanceHealthStatus(ids, healthy, revision interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) BatchSetInstanceHealthStatus(ctx, ids, healthy, revision interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchSetInstanceHealthStatus", reflect.TypeOf((*MockStore)(nil).BatchSetInstanceHealthStatus), ids, healthy, revision)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchSetInstanceHealthStatus", reflect.TypeOf((*MockStore)(nil).BatchSetInstanceHealthStatus), ctx, ids, healthy, revision)
 }
 
 // BatchSetInstanceIsolate mocks base method.
@@ -768,6 +783,20 @@ func (mr *MockStoreMockRecorder) CreateRoutingConfigTx(tx, conf interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRoutingConfigTx", reflect.TypeOf((*MockStore)(nil).CreateRoutingConfigTx), tx, conf)
 }
 
+// CreateServerSettingIfAbsent mocks base method.
+func (m *MockStore) CreateServerSettingIfAbsent(setting *admin.ServerSetting) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateServerSettingIfAbsent", setting)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateServerSettingIfAbsent indicates an expected call of CreateServerSettingIfAbsent.
+func (mr *MockStoreMockRecorder) CreateServerSettingIfAbsent(setting interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateServerSettingIfAbsent", reflect.TypeOf((*MockStore)(nil).CreateServerSettingIfAbsent), setting)
+}
+
 // CreateServiceContract mocks base method.
 func (m *MockStore) CreateServiceContract(contract *service.ServiceContract) error {
 	m.ctrl.T.Helper()
@@ -881,18 +910,32 @@ func (mr *MockStoreMockRecorder) DeleteGroup(tx, group interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroup", reflect.TypeOf((*MockStore)(nil).DeleteGroup), tx, group)
 }
 
+// DeleteHealthPolicy mocks base method.
+func (m *MockStore) DeleteHealthPolicy(namespace, serviceName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteHealthPolicy", namespace, serviceName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteHealthPolicy indicates an expected call of DeleteHealthPolicy.
+func (mr *MockStoreMockRecorder) DeleteHealthPolicy(namespace, serviceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHealthPolicy", reflect.TypeOf((*MockStore)(nil).DeleteHealthPolicy), namespace, serviceName)
+}
+
 // DeleteInstance mocks base method.
-func (m *MockStore) DeleteInstance(instanceID string) error {
+func (m *MockStore) DeleteInstance(ctx context.Context, instanceID string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteInstance", instanceID)
+	ret := m.ctrl.Call(m, "DeleteInstance", ctx, instanceID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteInstance indicates an expected call of DeleteInstance.
-func (mr *MockStoreMockRecorder) DeleteInstance(instanceID interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) DeleteInstance(ctx, instanceID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInstance", reflect.TypeOf((*MockStore)(nil).DeleteInstance), instanceID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInstance", reflect.TypeOf((*MockStore)(nil).DeleteInstance), ctx, instanceID)
 }
 
 // DeleteLaneGroup mocks base method.
@@ -1440,34 +1483,64 @@ func (mr *MockStoreMockRecorder) GetGroupByName(name interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupByName", reflect.TypeOf((*MockStore)(nil).GetGroupByName), name)
 }
 
+// GetHealthPolicy mocks base method.
+func (m *MockStore) GetHealthPolicy(namespace, serviceName string) (*service.HealthPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHealthPolicy", namespace, serviceName)
+	ret0, _ := ret[0].(*service.HealthPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHealthPolicy indicates an expected call of GetHealthPolicy.
+func (mr *MockStoreMockRecorder) GetHealthPolicy(namespace, serviceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHealthPolicy", reflect.TypeOf((*MockStore)(nil).GetHealthPolicy), namespace, serviceName)
+}
+
 // GetInstance mocks base method.
-func (m *MockStore) GetInstance(instanceID string) (*service.Instance, error) {
+func (m *MockStore) GetInstance(ctx context.Context, instanceID string) (*service.Instance, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetInstance", instanceID)
+	ret := m.ctrl.Call(m, "GetInstance", ctx, instanceID)
 	ret0, _ := ret[0].(*service.Instance)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetInstance indicates an expected call of GetInstance.
-func (mr *MockStoreMockRecorder) GetInstance(instanceID interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) GetInstance(ctx, instanceID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstance", reflect.TypeOf((*MockStore)(nil).GetInstance), instanceID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstance", reflect.TypeOf((*MockStore)(nil).GetInstance), ctx, instanceID)
+}
+
+// GetChangeLogs mocks base method.
+func (m *MockStore) GetChangeLogs(seq int64, limit uint32) ([]*store.ChangeLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChangeLogs", seq, limit)
+	ret0, _ := ret[0].([]*store.ChangeLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChangeLogs indicates an expected call of GetChangeLogs.
+func (mr *MockStoreMockRecorder) GetChangeLogs(seq, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChangeLogs", reflect.TypeOf((*MockStore)(nil).GetChangeLogs), seq, limit)
 }
 
 // GetInstancesBrief mocks base method.
-func (m *MockStore) GetInstancesBrief(ids map[string]bool) (map[string]*service.Instance, error) {
+func (m *MockStore) GetInstancesBrief(ctx context.Context, ids map[string]bool) (map[string]*service.Instance, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetInstancesBrief", ids)
+	ret := m.ctrl.Call(m, "GetInstancesBrief", ctx, ids)
 	ret0, _ := ret[0].(map[string]*service.Instance)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetInstancesBrief indicates an expected call of GetInstancesBrief.
-func (mr *MockStoreMockRecorder) GetInstancesBrief(ids interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) GetInstancesBrief(ctx, ids interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstancesBrief", reflect.TypeOf((*MockStore)(nil).GetInstancesBrief), ids)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstancesBrief", reflect.TypeOf((*MockStore)(nil).GetInstancesBrief), ctx, ids)
 }
 
 // GetInstancesCount mocks base method.
@@ -1515,6 +1588,21 @@ func (mr *MockStoreMockRecorder) GetInstancesMainByService(serviceID, host inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstancesMainByService", reflect.TypeOf((*MockStore)(nil).GetInstancesMainByService), serviceID, host)
 }
 
+// GetInstancesByIDs mocks base method.
+func (m *MockStore) GetInstancesByIDs(tx store.Tx, ids []string) (map[string]*service.Instance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInstancesByIDs", tx, ids)
+	ret0, _ := ret[0].(map[string]*service.Instance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInstancesByIDs indicates an expected call of GetInstancesByIDs.
+func (mr *MockStoreMockRecorder) GetInstancesByIDs(tx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstancesByIDs", reflect.TypeOf((*MockStore)(nil).GetInstancesByIDs), tx, ids)
+}
+
 // GetInterfaceDescriptors mocks base method.
 func (m *MockStore) GetInterfaceDescriptors(ctx context.Context, filter map[string]string, offset, limit uint32) (uint32, []*service.InterfaceDescriptor, error) {
 	m.ctrl.T.Helper()
@@ -1607,6 +1695,21 @@ func (mr *MockStoreMockRecorder) GetMainUser() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMainUser", reflect.TypeOf((*MockStore)(nil).GetMainUser))
 }
 
+// GetMaxChangeLogSeq mocks base method.
+func (m *MockStore) GetMaxChangeLogSeq() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMaxChangeLogSeq")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMaxChangeLogSeq indicates an expected call of GetMaxChangeLogSeq.
+func (mr *MockStoreMockRecorder) GetMaxChangeLogSeq() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMaxChangeLogSeq", reflect.TypeOf((*MockStore)(nil).GetMaxChangeLogSeq))
+}
+
 // GetMoreClients mocks base method.
 func (m *MockStore) GetMoreClients(mtime time.Time, firstUpdate bool) (map[string]*types.Client, error) {
 	m.ctrl.T.Helper()
@@ -1667,6 +1770,21 @@ func (mr *MockStoreMockRecorder) GetMoreGroups(mtime, firstUpdate interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMoreGroups", reflect.TypeOf((*MockStore)(nil).GetMoreGroups), mtime, firstUpdate)
 }
 
+// GetMoreHealthPolicies mocks base method.
+func (m *MockStore) GetMoreHealthPolicies(firstUpdate bool, mtime time.Time) ([]*service.HealthPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMoreHealthPolicies", firstUpdate, mtime)
+	ret0, _ := ret[0].([]*service.HealthPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMoreHealthPolicies indicates an expected call of GetMoreHealthPolicies.
+func (mr *MockStoreMockRecorder) GetMoreHealthPolicies(firstUpdate, mtime interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMoreHealthPolicies", reflect.TypeOf((*MockStore)(nil).GetMoreHealthPolicies), firstUpdate, mtime)
+}
+
 // GetMoreInstances mocks base method.
 func (m *MockStore) GetMoreInstances(tx store.Tx, mtime time.Time, firstUpdate, needMeta bool, serviceID []string) (map[string]*service.Instance, error) {
 	m.ctrl.T.Helper()
@@ -1878,6 +1996,21 @@ func (mr *MockStoreMockRecorder) GetRole(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockStore)(nil).GetRole), id)
 }
 
+// GetRoleByName mocks base method.
+func (m *MockStore) GetRoleByName(name, owner string) (*auth.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoleByName", name, owner)
+	ret0, _ := ret[0].(*auth.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleByName indicates an expected call of GetRoleByName.
+func (mr *MockStoreMockRecorder) GetRoleByName(name, owner interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleByName", reflect.TypeOf((*MockStore)(nil).GetRoleByName), name, owner)
+}
+
 // GetRoutingConfigWithID mocks base method.
 func (m *MockStore) GetRoutingConfigWithID(id string) (*rules.RouterConfig, error) {
 	m.ctrl.T.Helper()
@@ -1923,6 +2056,36 @@ func (mr *MockStoreMockRecorder) GetRoutingConfigsForCache(mtime, firstUpdate in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoutingConfigsForCache", reflect.TypeOf((*MockStore)(nil).GetRoutingConfigsForCache), mtime, firstUpdate)
 }
 
+// GetServerSetting mocks base method.
+func (m *MockStore) GetServerSetting(name string) (*admin.ServerSetting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServerSetting", name)
+	ret0, _ := ret[0].(*admin.ServerSetting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServerSetting indicates an expected call of GetServerSetting.
+func (mr *MockStoreMockRecorder) GetServerSetting(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServerSetting", reflect.TypeOf((*MockStore)(nil).GetServerSetting), name)
+}
+
+// GetServerSettingsByPrefix mocks base method.
+func (m *MockStore) GetServerSettingsByPrefix(prefix string) ([]*admin.ServerSetting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServerSettingsByPrefix", prefix)
+	ret0, _ := ret[0].([]*admin.ServerSetting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServerSettingsByPrefix indicates an expected call of GetServerSettingsByPrefix.
+func (mr *MockStoreMockRecorder) GetServerSettingsByPrefix(prefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServerSettingsByPrefix", reflect.TypeOf((*MockStore)(nil).GetServerSettingsByPrefix), prefix)
+}
+
 // GetService mocks base method.
 func (m *MockStore) GetService(name, namespace string) (*service.Service, error) {
 	m.ctrl.T.Helper()
@@ -2076,6 +2239,21 @@ func (mr *MockStoreMockRecorder) GetStrategyDetail(id interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStrategyDetail", reflect.TypeOf((*MockStore)(nil).GetStrategyDetail), id)
 }
 
+// GetStrategyDetailByName mocks base method.
+func (m *MockStore) GetStrategyDetailByName(name string) (*auth.StrategyDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStrategyDetailByName", name)
+	ret0, _ := ret[0].(*auth.StrategyDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStrategyDetailByName indicates an expected call of GetStrategyDetailByName.
+func (mr *MockStoreMockRecorder) GetStrategyDetailByName(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStrategyDetailByName", reflect.TypeOf((*MockStore)(nil).GetStrategyDetailByName), name)
+}
+
 // GetStrategyResources mocks base method.
 func (m *MockStore) GetStrategyResources(principalId string, principalRole auth.PrincipalType) ([]auth.StrategyResource, error) {
 	m.ctrl.T.Helper()
@@ -2835,17 +3013,17 @@ func (mr *MockStoreMockRecorder) UpdateGroup(group interface{}) *gomock.Call {
 }
 
 // UpdateInstance mocks base method.
-func (m *MockStore) UpdateInstance(instance *service.Instance) error {
+func (m *MockStore) UpdateInstance(ctx context.Context, instance *service.Instance) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateInstance", instance)
+	ret := m.ctrl.Call(m, "UpdateInstance", ctx, instance)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateInstance indicates an expected call of UpdateInstance.
-func (mr *MockStoreMockRecorder) UpdateInstance(instance interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) UpdateInstance(ctx, instance interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInstance", reflect.TypeOf((*MockStore)(nil).UpdateInstance), instance)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInstance", reflect.TypeOf((*MockStore)(nil).UpdateInstance), ctx, instance)
 }
 
 // UpdateLaneGroup mocks base method.
@@ -3016,6 +3194,34 @@ func (mr *MockStoreMockRecorder) UpdateUser(user interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockStore)(nil).UpdateUser), user)
 }
 
+// UpsertHealthPolicy mocks base method.
+func (m *MockStore) UpsertHealthPolicy(policy *service.HealthPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertHealthPolicy", policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertHealthPolicy indicates an expected call of UpsertHealthPolicy.
+func (mr *MockStoreMockRecorder) UpsertHealthPolicy(policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertHealthPolicy", reflect.TypeOf((*MockStore)(nil).UpsertHealthPolicy), policy)
+}
+
+// UpsertServerSetting mocks base method.
+func (m *MockStore) UpsertServerSetting(setting *admin.ServerSetting) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertServerSetting", setting)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertServerSetting indicates an expected call of UpsertServerSetting.
+func (mr *MockStoreMockRecorder) UpsertServerSetting(setting interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertServerSetting", reflect.TypeOf((*MockStore)(nil).UpsertServerSetting), setting)
+}
+
 // MockNamespaceStore is a mock of NamespaceStore interface.
 type MockNamespaceStore struct {
 	ctrl     *gomock.Controller
@@ -743,3 +743,76 @@ func (m *adminStore) BatchCleanDeletedServiceContracts(timeout time.Duration, ba
 	})
 	return uint32(affectRows), err
 }
+
+// GetServerSetting get server setting by name
+func (m *adminStore) GetServerSetting(name string) (*admin.ServerSetting, error) {
+	mainStr := "select id, name, rule, UNIX_TIMESTAMP(ctime), UNIX_TIMESTAMP(mtime) from server_setting where name = ?"
+	var (
+		setting      = &admin.ServerSetting{}
+		ctime, mtime int64
+	)
+	err := m.master.QueryRow(mainStr, name).Scan(&setting.ID, &setting.Name, &setting.Rule, &ctime, &mtime)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		log.Errorf("[Store][database] get server setting (%s), err: %s", name, err.Error())
+		return nil, store.Error(err)
+	}
+	setting.CreateTime = time.Unix(ctime, 0)
+	setting.ModifyTime = time.Unix(mtime, 0)
+	return setting, nil
+}
+
+// CreateServerSettingIfAbsent create server setting, do nothing when the name already exists
+func (m *adminStore) CreateServerSettingIfAbsent(setting *admin.ServerSetting) error {
+	return m.master.processWithTransaction("createServerSettingIfAbsent", func(tx *BaseTx) error {
+		mainStr := "insert ignore into server_setting (id, name, rule) values (?, ?, ?)"
+		if _, err := tx.Exec(mainStr, setting.ID, setting.Name, setting.Rule); err != nil {
+			log.Errorf("[Store][database] create server setting (%s), err: %s", setting.Name, err.Error())
+			return store.Error(err)
+		}
+		return tx.Commit()
+	})
+}
+
+// UpsertServerSetting create server setting or update the rule when the name already exists
+func (m *adminStore) UpsertServerSetting(setting *admin.ServerSetting) error {
+	mainStr := "insert into server_setting (id, name, rule) values (?, ?, ?) " +
+		"on duplicate key update rule = ?, mtime = sysdate()"
+	if _, err := m.master.Exec(mainStr, setting.ID, setting.Name, setting.Rule, setting.Rule); err != nil {
+		log.Errorf("[Store][database] upsert server setting (%s), err: %s", setting.Name, err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetServerSettingsByPrefix get server settings whose name starts with the prefix
+func (m *adminStore) GetServerSettingsByPrefix(prefix string) ([]*admin.ServerSetting, error) {
+	mainStr := "select id, name, rule, UNIX_TIMESTAMP(ctime), UNIX_TIMESTAMP(mtime) from server_setting " +
+		"where name like ?"
+	rows, err := m.master.Query(mainStr, prefix+"%")
+	if err != nil {
+		log.Errorf("[Store][database] get server settings by prefix (%s), err: %s", prefix, err.Error())
+		return nil, store.Error(err)
+	}
+	defer rows.Close()
+
+	var settings []*admin.ServerSetting
+	for rows.Next() {
+		var (
+			setting      = &admin.ServerSetting{}
+			ctime, mtime int64
+		)
+		if err := rows.Scan(&setting.ID, &setting.Name, &setting.Rule, &ctime, &mtime); err != nil {
+			return nil, store.Error(err)
+		}
+		setting.CreateTime = time.Unix(ctime, 0)
+		setting.ModifyTime = time.Unix(mtime, 0)
+		settings = append(settings, setting)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, store.Error(err)
+	}
+	return settings, nil
+}
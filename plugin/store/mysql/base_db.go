@@ -24,9 +24,14 @@ import (
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/pole-io/pole-server/apis/observability/statis"
 	"github.com/pole-io/pole-server/apis/pkg/types/metrics"
 	"github.com/pole-io/pole-server/apis/store"
+	"github.com/pole-io/pole-server/pkg/common/tracing"
 )
 
 // db抛出的异常，需要重试的字符串组
@@ -97,12 +102,21 @@ func (b *BaseDB) openDatabase() error {
 
 // Exec 重写db.Exec函数 提供重试功能
 func (b *BaseDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return b.ExecContext(context.Background(), query, args...)
+}
+
+// ExecContext 同 Exec, ctx 只用于关联调用方的 trace, 不会中断语句的执行
+func (b *BaseDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	var (
 		result sql.Result
 		err    error
 		start  = time.Now()
 	)
 	defer reportCallMetrics("Exec", start, err)
+	span := startSpan(ctx, "Exec", query)
+	defer func() {
+		tracing.End(span, err)
+	}()
 
 	Retry("exec "+query, func() error {
 		result, err = b.DB.Exec(query, args...)
@@ -114,12 +128,21 @@ func (b *BaseDB) Exec(query string, args ...interface{}) (sql.Result, error) {
 
 // Query 重写db.Query函数
 func (b *BaseDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return b.QueryContext(context.Background(), query, args...)
+}
+
+// QueryContext 同 Query, ctx 只用于关联调用方的 trace, 不会中断语句的执行
+func (b *BaseDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 	var (
 		rows  *sql.Rows
 		err   error
 		start = time.Now()
 	)
 	defer reportCallMetrics("Query", start, err)
+	span := startSpan(ctx, "Query", query)
+	defer func() {
+		tracing.End(span, err)
+	}()
 
 	Retry("query "+query, func() error {
 		rows, err = b.DB.Query(query, args...)
@@ -131,12 +154,21 @@ func (b *BaseDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
 
 // QueryRow 重写db.Query函数
 func (b *BaseDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return b.QueryRowContext(context.Background(), query, args...)
+}
+
+// QueryRowContext 同 QueryRow, ctx 只用于关联调用方的 trace, 不会中断语句的执行
+func (b *BaseDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
 	var (
 		row   *sql.Row
 		err   error
 		start = time.Now()
 	)
 	defer reportCallMetrics("QueryRow", start, err)
+	span := startSpan(ctx, "QueryRow", query)
+	defer func() {
+		tracing.End(span, err)
+	}()
 
 	Retry("query "+query, func() error {
 		row = b.DB.QueryRow(query, args...)
@@ -149,6 +181,11 @@ func (b *BaseDB) QueryRow(query string, args ...interface{}) *sql.Row {
 
 // Begin 重写db.Begin
 func (b *BaseDB) Begin() (*BaseTx, error) {
+	return b.BeginContext(context.Background())
+}
+
+// BeginContext 同 Begin, ctx 只用于关联调用方的 trace, 事务的 span 覆盖从 Begin 到 Commit/Rollback
+func (b *BaseDB) BeginContext(ctx context.Context) (*BaseTx, error) {
 	var (
 		tx     *sql.Tx
 		err    error
@@ -160,13 +197,17 @@ func (b *BaseDB) Begin() (*BaseTx, error) {
 	}
 
 	defer reportCallMetrics("Begin", start, err)
+	span := startSpan(ctx, "Transaction", "")
 
 	Retry("begin", func() error {
 		tx, err = b.DB.BeginTx(context.Background(), option)
 		return err
 	})
+	if err != nil {
+		tracing.End(span, err)
+	}
 
-	return &BaseTx{Tx: tx}, err
+	return &BaseTx{Tx: tx, span: span}, err
 }
 
 // Begin 重写db.Begin
@@ -178,12 +219,16 @@ func (b *BaseDB) BeginTx(opt *sql.TxOptions) (*BaseTx, error) {
 	)
 
 	defer reportCallMetrics("Begin", start, err)
+	span := startSpan(context.Background(), "Transaction", "")
 	Retry("begin", func() error {
 		tx, err = b.DB.BeginTx(context.Background(), opt)
 		return err
 	})
+	if err != nil {
+		tracing.End(span, err)
+	}
 
-	return &BaseTx{Tx: tx}, err
+	return &BaseTx{Tx: tx, span: span}, err
 }
 
 func reportCallMetrics(label string, start time.Time, err error) {
@@ -204,9 +249,21 @@ func reportCallMetrics(label string, start time.Time, err error) {
 	})
 }
 
+// startSpan 创建存储层调用的 span, ctx 中没有调用方的 span 时不记录
+func startSpan(ctx context.Context, operation, query string) trace.Span {
+	attrs := []attribute.KeyValue{semconv.DBSystemMySQL}
+	if query != "" {
+		attrs = append(attrs, semconv.DBQueryText(query))
+	}
+	_, span := tracing.StartStore(ctx, operation, attrs...)
+	return span
+}
+
 // BaseTx 对sql.Tx的封装
 type BaseTx struct {
 	*sql.Tx
+	// span 覆盖从 Begin 到 Commit/Rollback 的整个事务
+	span trace.Span
 }
 
 // Commit .
@@ -217,6 +274,7 @@ func (b *BaseTx) Commit() error {
 	)
 	defer reportCallMetrics("Commit", start, err)
 	err = b.Tx.Commit()
+	tracing.End(b.span, err)
 	return err
 }
 
@@ -228,6 +286,9 @@ func (b *BaseTx) Rollback() error {
 	)
 	defer reportCallMetrics("Rollback", start, err)
 	err = b.Tx.Rollback()
+	// 事务提交后的 Rollback 不会再修改已经结束的 span
+	b.span.AddEvent("rollback")
+	b.span.End()
 	return err
 }
 
@@ -268,7 +329,11 @@ func RetryTransaction(label string, handle func() error) error {
 }
 
 func (b *BaseDB) processWithTransaction(label string, handle func(*BaseTx) error) error {
-	tx, err := b.Begin()
+	return b.processWithTransactionContext(context.Background(), label, handle)
+}
+
+func (b *BaseDB) processWithTransactionContext(ctx context.Context, label string, handle func(*BaseTx) error) error {
+	tx, err := b.BeginContext(ctx)
 	if err != nil {
 		log.Errorf("[Store][database] %s begin tx err: %s", label, err.Error())
 		return err
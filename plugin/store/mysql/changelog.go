@@ -0,0 +1,118 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/pole-io/pole-server/apis/store"
+)
+
+var _ store.ChangeLogStore = (*changeLogStore)(nil)
+
+type changeLogStore struct {
+	master *BaseDB
+	// slave 缓存相关的读取, 与缓存拉取资源数据使用同一个数据源, 避免主从延迟导致读取到的资源数据比变更记录更旧
+	slave *BaseDB
+}
+
+// GetChangeLogs 获取序号大于 seq 的变更记录
+func (c *changeLogStore) GetChangeLogs(seq int64, limit uint32) ([]*store.ChangeLog, error) {
+	str := "SELECT seq, resource, res_key, UNIX_TIMESTAMP(ctime) FROM change_log WHERE seq > ? ORDER BY seq LIMIT ?"
+	rows, err := c.slave.Query(str, seq, limit)
+	if err != nil {
+		log.Errorf("[Store][database] get change logs after seq(%d) err: %s", seq, err.Error())
+		return nil, store.Error(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	ret := make([]*store.ChangeLog, 0, limit)
+	for rows.Next() {
+		var (
+			item  store.ChangeLog
+			ctime int64
+		)
+		if err := rows.Scan(&item.Seq, &item.Resource, &item.Key, &ctime); err != nil {
+			log.Errorf("[Store][database] fetch change log rows err: %s", err.Error())
+			return nil, store.Error(err)
+		}
+		item.CreateTime = time.Unix(ctime, 0)
+		ret = append(ret, &item)
+	}
+	if err := rows.Err(); err != nil {
+		log.Errorf("[Store][database] fetch change log rows next err: %s", err.Error())
+		return nil, store.Error(err)
+	}
+	return ret, nil
+}
+
+// GetMaxChangeLogSeq 获取当前最大的变更序号
+func (c *changeLogStore) GetMaxChangeLogSeq() (int64, error) {
+	var seq sql.NullInt64
+	if err := c.slave.QueryRow("SELECT MAX(seq) FROM change_log").Scan(&seq); err != nil {
+		log.Errorf("[Store][database] get max change log seq err: %s", err.Error())
+		return 0, store.Error(err)
+	}
+	return seq.Int64, nil
+}
+
+// BatchCleanChangeLogs 清理过期的变更记录
+func (c *changeLogStore) BatchCleanChangeLogs(timeout time.Duration, batchSize uint32) (uint32, error) {
+	log.Infof("[Store][database] batch clean change logs(%d)", batchSize)
+	str := "DELETE FROM change_log WHERE ctime <= FROM_UNIXTIME(UNIX_TIMESTAMP(SYSDATE()) - ?) ORDER BY seq LIMIT ?"
+	result, err := c.master.Exec(str, int32(timeout.Seconds()), batchSize)
+	if err != nil {
+		log.Errorf("[Store][database] batch clean change logs(%d), err: %s", batchSize, err.Error())
+		return 0, store.Error(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		log.Errorf("[Store][database] batch clean change logs(%d), get RowsAffected err: %s",
+			batchSize, err.Error())
+		return 0, store.Error(err)
+	}
+	return uint32(rows), nil
+}
+
+// appendChangeLogs 在写资源的事务内追加变更记录, 事务回滚时变更记录一起回滚, 未开启变更记录同步时不写入
+func appendChangeLogs(tx *BaseTx, resource string, keys []interface{}) error {
+	if !store.ChangeLogEnabled() {
+		return nil
+	}
+	return BatchOperation("append-change-log", keys, func(objects []interface{}) error {
+		if len(objects) == 0 {
+			return nil
+		}
+		values := make([]string, 0, len(objects))
+		args := make([]interface{}, 0, len(objects)*2)
+		for _, key := range objects {
+			values = append(values, "(?, ?, sysdate())")
+			args = append(args, resource, key)
+		}
+		str := "INSERT INTO change_log(resource, res_key, ctime) VALUES " + strings.Join(values, ",")
+		if _, err := tx.Exec(str, args...); err != nil {
+			log.Errorf("[Store][database] append %s change logs err: %s", resource, err.Error())
+			return store.Error(err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,54 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/apis/store"
+)
+
+func Test_appendChangeLogs(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	t.Cleanup(func() {
+		store.SetChangeLogEnable(false)
+	})
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO change_log(resource, res_key, ctime) VALUES (?, ?, sysdate()),(?, ?, sysdate())").
+		WithArgs(store.ChangeLogInstance, "ins-1", store.ChangeLogInstance, "ins-2").
+		WillReturnResult(sqlmock.NewResult(2, 2))
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	baseTx := &BaseTx{Tx: tx}
+
+	// 未开启变更记录同步时不写入
+	store.SetChangeLogEnable(false)
+	assert.NoError(t, appendChangeLogs(baseTx, store.ChangeLogInstance, []interface{}{"ins-1", "ins-2"}))
+
+	store.SetChangeLogEnable(true)
+	assert.NoError(t, appendChangeLogs(baseTx, store.ChangeLogInstance, []interface{}{"ins-1", "ins-2"}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -57,6 +57,8 @@ type stableStore struct {
 	*faultDetectRuleStore
 	*serviceContractStore
 	*laneStore
+	*healthPolicyStore
+	*changeLogStore
 
 	// 配置中心 stores
 	*configFileGroupStore
@@ -101,6 +103,14 @@ func (s *stableStore) Initialize(conf *store.Config) error {
 	if err != nil {
 		return err
 	}
+	migrations, err := loadMigrations(migrationFiles, migrationDir)
+	if err != nil {
+		return err
+	}
+	if err := checkSchemaVersion(master.DB, migrations); err != nil {
+		_ = master.Close()
+		return err
+	}
 	s.master = master
 
 	if slaveConfig != nil {
@@ -259,6 +269,8 @@ func (s *stableStore) newStore() {
 	s.faultDetectRuleStore = &faultDetectRuleStore{master: s.master, slave: s.slave}
 	s.serviceContractStore = &serviceContractStore{master: s.master, slave: s.slave}
 	s.laneStore = &laneStore{master: s.master, slave: s.slave}
+	s.healthPolicyStore = &healthPolicyStore{master: s.master, slave: s.slave}
+	s.changeLogStore = &changeLogStore{master: s.master, slave: s.slave}
 
 	s.configFileGroupStore = &configFileGroupStore{master: s.master, slave: s.slave}
 	s.configFileStore = &configFileStore{master: s.master, slave: s.slave}
@@ -0,0 +1,145 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+
+	svctypes "github.com/pole-io/pole-server/apis/pkg/types/service"
+	"github.com/pole-io/pole-server/apis/store"
+)
+
+var _ store.HealthPolicyStore = (*healthPolicyStore)(nil)
+
+const healthPolicyColumns = "namespace, service, min_ttl, max_ttl, unhealthy_delete_delay, auto_delete, " +
+	"empty_service_retention, IFNULL(comment, ''), flag, UNIX_TIMESTAMP(ctime), UNIX_TIMESTAMP(mtime)"
+
+type healthPolicyStore struct {
+	master *BaseDB
+	slave  *BaseDB
+}
+
+// UpsertHealthPolicy 创建或者更新策略
+func (h *healthPolicyStore) UpsertHealthPolicy(policy *svctypes.HealthPolicy) error {
+	var autoDelete interface{}
+	if policy.AutoDelete != nil {
+		autoDelete = boolToInt(*policy.AutoDelete)
+	}
+	s := "INSERT INTO health_policy(namespace, service, min_ttl, max_ttl, unhealthy_delete_delay, auto_delete, " +
+		" empty_service_retention, comment, flag, ctime, mtime) VALUES (?, ?, ?, ?, ?, ?, ?, ?, 0, sysdate(), sysdate()) " +
+		" ON DUPLICATE KEY UPDATE min_ttl = ?, max_ttl = ?, unhealthy_delete_delay = ?, auto_delete = ?, " +
+		" empty_service_retention = ?, comment = ?, flag = 0, mtime = sysdate()"
+	args := []interface{}{
+		policy.Namespace, policy.Service, policy.MinTtl, policy.MaxTtl, policy.UnhealthyDeleteDelay, autoDelete,
+		policy.EmptyServiceRetention, policy.Comment,
+		policy.MinTtl, policy.MaxTtl, policy.UnhealthyDeleteDelay, autoDelete,
+		policy.EmptyServiceRetention, policy.Comment,
+	}
+	if _, err := h.master.Exec(s, args...); err != nil {
+		log.Error("[Store][HealthPolicy] upsert health policy", zap.String("namespace", policy.Namespace),
+			zap.String("service", policy.Service), zap.Error(err))
+		return store.Error(err)
+	}
+	return nil
+}
+
+// DeleteHealthPolicy 逻辑删除策略, 便于缓存感知到删除
+func (h *healthPolicyStore) DeleteHealthPolicy(namespace, service string) error {
+	s := "UPDATE health_policy SET flag = 1, mtime = sysdate() WHERE namespace = ? AND service = ?"
+	if _, err := h.master.Exec(s, namespace, service); err != nil {
+		log.Error("[Store][HealthPolicy] delete health policy", zap.String("namespace", namespace),
+			zap.String("service", service), zap.Error(err))
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetHealthPolicy 查询策略
+func (h *healthPolicyStore) GetHealthPolicy(namespace, service string) (*svctypes.HealthPolicy, error) {
+	s := "SELECT " + healthPolicyColumns + " FROM health_policy WHERE flag = 0 AND namespace = ? AND service = ?"
+	rows, err := h.master.Query(s, namespace, service)
+	if err != nil {
+		log.Error("[Store][HealthPolicy] get health policy", zap.String("namespace", namespace),
+			zap.String("service", service), zap.Error(err))
+		return nil, store.Error(err)
+	}
+	policies, err := fetchHealthPolicyRows(rows)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	return policies[0], nil
+}
+
+// GetMoreHealthPolicies 获取最近更新的策略, 此方法用于 cache 增量更新，需要注意 mtime 应为数据库时间戳
+func (h *healthPolicyStore) GetMoreHealthPolicies(firstUpdate bool,
+	mtime time.Time) ([]*svctypes.HealthPolicy, error) {
+	if firstUpdate {
+		mtime = time.Time{}
+	}
+	s := "SELECT " + healthPolicyColumns + " FROM health_policy WHERE mtime > FROM_UNIXTIME(?)"
+	if firstUpdate {
+		s += " AND flag = 0"
+	}
+	rows, err := h.slave.Query(s, timeToTimestamp(mtime))
+	if err != nil {
+		log.Error("[Store][HealthPolicy] get more health policies", zap.Error(err))
+		return nil, store.Error(err)
+	}
+	return fetchHealthPolicyRows(rows)
+}
+
+func fetchHealthPolicyRows(rows *sql.Rows) ([]*svctypes.HealthPolicy, error) {
+	if rows == nil {
+		return nil, nil
+	}
+	defer rows.Close()
+
+	var policies []*svctypes.HealthPolicy
+	for rows.Next() {
+		var (
+			flag, ctime, mtime int64
+			autoDelete         sql.NullInt64
+			policy             = &svctypes.HealthPolicy{}
+		)
+		if err := rows.Scan(&policy.Namespace, &policy.Service, &policy.MinTtl, &policy.MaxTtl,
+			&policy.UnhealthyDeleteDelay, &autoDelete, &policy.EmptyServiceRetention, &policy.Comment,
+			&flag, &ctime, &mtime); err != nil {
+			log.Error("[Store][HealthPolicy] fetch health policy rows", zap.Error(err))
+			return nil, err
+		}
+		if autoDelete.Valid {
+			enable := autoDelete.Int64 == 1
+			policy.AutoDelete = &enable
+		}
+		policy.Valid = flag == 0
+		policy.CreateTime = time.Unix(ctime, 0)
+		policy.ModifyTime = time.Unix(mtime, 0)
+		policies = append(policies, policy)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error("[Store][HealthPolicy] fetch health policy rows next", zap.Error(err))
+		return nil, err
+	}
+	return policies, nil
+}
@@ -18,6 +18,7 @@
 package sqldb
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -39,16 +40,16 @@ type instanceStore struct {
 }
 
 // AddInstance 添加实例
-func (ins *instanceStore) AddInstance(instance *svctypes.Instance) error {
+func (ins *instanceStore) AddInstance(ctx context.Context, instance *svctypes.Instance) error {
 	err := RetryTransaction("addInstance", func() error {
-		return ins.addInstance(instance)
+		return ins.addInstance(ctx, instance)
 	})
 	return store.Error(err)
 }
 
 // addInstance
-func (ins *instanceStore) addInstance(instance *svctypes.Instance) error {
-	tx, err := ins.master.Begin()
+func (ins *instanceStore) addInstance(ctx context.Context, instance *svctypes.Instance) error {
+	tx, err := ins.master.BeginContext(ctx)
 	if err != nil {
 		log.Errorf("[Store][database] add instance tx begin err: %s", err.Error())
 		return err
@@ -68,6 +69,9 @@ func (ins *instanceStore) addInstance(instance *svctypes.Instance) error {
 	if err := addInstanceCheck(tx, instance); err != nil {
 		return err
 	}
+	if err := appendChangeLogs(tx, store.ChangeLogInstance, []interface{}{instance.ID()}); err != nil {
+		return err
+	}
 	if err := tx.Commit(); err != nil {
 		log.Errorf("[Store][database] add instance commit tx err: %s", err.Error())
 		return err
@@ -77,17 +81,17 @@ func (ins *instanceStore) addInstance(instance *svctypes.Instance) error {
 }
 
 // BatchAddInstances 批量增加实例
-func (ins *instanceStore) BatchAddInstances(instances []*svctypes.Instance) error {
+func (ins *instanceStore) BatchAddInstances(ctx context.Context, instances []*svctypes.Instance) error {
 
 	err := RetryTransaction("batchAddInstances", func() error {
-		return ins.batchAddInstances(instances)
+		return ins.batchAddInstances(ctx, instances)
 	})
 	return store.Error(err)
 }
 
 // batchAddInstances batch add instances
-func (ins *instanceStore) batchAddInstances(instances []*svctypes.Instance) error {
-	tx, err := ins.master.Begin()
+func (ins *instanceStore) batchAddInstances(ctx context.Context, instances []*svctypes.Instance) error {
+	tx, err := ins.master.BeginContext(ctx)
 	if err != nil {
 		log.Errorf("[Store][database] batch add instances begin tx err: %s", err.Error())
 		return err
@@ -102,6 +106,13 @@ func (ins *instanceStore) batchAddInstances(instances []*svctypes.Instance) erro
 		log.Errorf("[Store][database] batch add instance check err: %s", err.Error())
 		return err
 	}
+	ids := make([]interface{}, 0, len(instances))
+	for _, entry := range instances {
+		ids = append(ids, entry.ID())
+	}
+	if err := appendChangeLogs(tx, store.ChangeLogInstance, ids); err != nil {
+		return err
+	}
 	if err := tx.Commit(); err != nil {
 		log.Errorf("[Store][database] batch add instance commit tx err: %s", err.Error())
 		return err
@@ -111,9 +122,9 @@ func (ins *instanceStore) batchAddInstances(instances []*svctypes.Instance) erro
 }
 
 // UpdateInstance 更新实例
-func (ins *instanceStore) UpdateInstance(instance *svctypes.Instance) error {
+func (ins *instanceStore) UpdateInstance(ctx context.Context, instance *svctypes.Instance) error {
 	err := RetryTransaction("updateInstance", func() error {
-		return ins.updateInstance(instance)
+		return ins.updateInstance(ctx, instance)
 	})
 	if err == nil {
 		return nil
@@ -127,8 +138,8 @@ func (ins *instanceStore) UpdateInstance(instance *svctypes.Instance) error {
 }
 
 // updateInstance update instance
-func (ins *instanceStore) updateInstance(instance *svctypes.Instance) error {
-	tx, err := ins.master.Begin()
+func (ins *instanceStore) updateInstance(ctx context.Context, instance *svctypes.Instance) error {
+	tx, err := ins.master.BeginContext(ctx)
 	if err != nil {
 		log.Errorf("[Store][database] update instance tx begin err: %s", err.Error())
 		return err
@@ -145,6 +156,9 @@ func (ins *instanceStore) updateInstance(instance *svctypes.Instance) error {
 		log.Errorf("[Store][database] update instance check err: %s", err.Error())
 		return err
 	}
+	if err := appendChangeLogs(tx, store.ChangeLogInstance, []interface{}{instance.ID()}); err != nil {
+		return err
+	}
 	if err := tx.Commit(); err != nil {
 		log.Errorf("[Store][database] update instance commit tx err: %s", err.Error())
 		return err
@@ -188,16 +202,19 @@ func cleanInstance(tx *BaseTx, instanceID string) error {
 }
 
 // DeleteInstance 删除一个实例，删除实例实际上是把flag置为1
-func (ins *instanceStore) DeleteInstance(instanceID string) error {
+func (ins *instanceStore) DeleteInstance(ctx context.Context, instanceID string) error {
 	if instanceID == "" {
 		return errors.New("delete Instance Missing instance id")
 	}
 	return RetryTransaction("deleteInstance", func() error {
-		return ins.master.processWithTransaction("deleteInstance", func(tx *BaseTx) error {
+		return ins.master.processWithTransactionContext(ctx, "deleteInstance", func(tx *BaseTx) error {
 			str := "update instance set flag = 1, mtime = sysdate() where `id` = ?"
 			if _, err := tx.Exec(str, instanceID); err != nil {
 				return store.Error(err)
 			}
+			if err := appendChangeLogs(tx, store.ChangeLogInstance, []interface{}{instanceID}); err != nil {
+				return err
+			}
 
 			if err := tx.Commit(); err != nil {
 				log.Errorf("[Store][database] delete instance commit tx err: %s", err.Error())
@@ -210,9 +227,9 @@ func (ins *instanceStore) DeleteInstance(instanceID string) error {
 }
 
 // BatchDeleteInstances 批量删除实例
-func (ins *instanceStore) BatchDeleteInstances(ids []interface{}) error {
+func (ins *instanceStore) BatchDeleteInstances(ctx context.Context, ids []interface{}) error {
 	return RetryTransaction("batchDeleteInstance", func() error {
-		return ins.master.processWithTransaction("batchDeleteInstance", func(tx *BaseTx) error {
+		return ins.master.processWithTransactionContext(ctx, "batchDeleteInstance", func(tx *BaseTx) error {
 			if err := BatchOperation("delete-instance", ids, func(objects []interface{}) error {
 				if len(objects) == 0 {
 					return nil
@@ -223,6 +240,9 @@ func (ins *instanceStore) BatchDeleteInstances(ids []interface{}) error {
 			}); err != nil {
 				return err
 			}
+			if err := appendChangeLogs(tx, store.ChangeLogInstance, ids); err != nil {
+				return err
+			}
 
 			if err := tx.Commit(); err != nil {
 				log.Errorf("[Store][database] batch delete instance commit tx err: %s", err.Error())
@@ -235,8 +255,8 @@ func (ins *instanceStore) BatchDeleteInstances(ids []interface{}) error {
 }
 
 // GetInstance 获取单个实例详情，只返回有效的数据
-func (ins *instanceStore) GetInstance(instanceID string) (*svctypes.Instance, error) {
-	instance, err := ins.getInstance(instanceID)
+func (ins *instanceStore) GetInstance(ctx context.Context, instanceID string) (*svctypes.Instance, error) {
+	instance, err := ins.getInstance(ctx, instanceID)
 	if err != nil {
 		return nil, err
 	}
@@ -250,7 +270,7 @@ func (ins *instanceStore) GetInstance(instanceID string) (*svctypes.Instance, er
 }
 
 // BatchGetInstanceIsolate 检查实例是否存在
-func (ins *instanceStore) BatchGetInstanceIsolate(ids map[string]bool) (map[string]bool, error) {
+func (ins *instanceStore) BatchGetInstanceIsolate(ctx context.Context, ids map[string]bool) (map[string]bool, error) {
 	if len(ids) == 0 {
 		return nil, nil
 	}
@@ -261,7 +281,7 @@ func (ins *instanceStore) BatchGetInstanceIsolate(ids map[string]bool) (map[stri
 		args = append(args, key)
 	}
 	instanceIsolate := make(map[string]bool, len(ids))
-	rows, err := ins.master.Query(str, args...)
+	rows, err := ins.master.QueryContext(ctx, str, args...)
 	if err != nil {
 		log.Errorf("[Store][database] check instances existed query err: %s", err.Error())
 		return nil, err
@@ -280,7 +300,7 @@ func (ins *instanceStore) BatchGetInstanceIsolate(ids map[string]bool) (map[stri
 }
 
 // GetInstancesBrief 批量获取实例的serviceID
-func (ins *instanceStore) GetInstancesBrief(ids map[string]bool) (map[string]*svctypes.Instance, error) {
+func (ins *instanceStore) GetInstancesBrief(ctx context.Context, ids map[string]bool) (map[string]*svctypes.Instance, error) {
 	if len(ids) == 0 {
 		return nil, nil
 	}
@@ -293,7 +313,7 @@ func (ins *instanceStore) GetInstancesBrief(ids map[string]bool) (map[string]*sv
 		args = append(args, key)
 	}
 
-	rows, err := ins.master.Query(str, args...)
+	rows, err := ins.master.QueryContext(ctx, str, args...)
 	if err != nil {
 		log.Errorf("[Store][database] get instances service token query err: %s", err.Error())
 		return nil, err
@@ -501,6 +521,9 @@ func (ins *instanceStore) SetInstanceHealthStatus(instanceID string, flag int, r
 			if _, err := tx.Exec(str, flag, revision, instanceID); err != nil {
 				return store.Error(err)
 			}
+			if err := appendChangeLogs(tx, store.ChangeLogInstance, []interface{}{instanceID}); err != nil {
+				return err
+			}
 
 			if err := tx.Commit(); err != nil {
 				log.Errorf("[Store][database] set instance health status commit tx err: %s", err.Error())
@@ -513,9 +536,10 @@ func (ins *instanceStore) SetInstanceHealthStatus(instanceID string, flag int, r
 }
 
 // BatchSetInstanceHealthStatus 批量设置健康状态
-func (ins *instanceStore) BatchSetInstanceHealthStatus(ids []interface{}, isolate int, revision string) error {
+func (ins *instanceStore) BatchSetInstanceHealthStatus(ctx context.Context, ids []interface{}, isolate int,
+	revision string) error {
 	return RetryTransaction("batchSetInstanceHealthStatus", func() error {
-		return ins.master.processWithTransaction("batchSetInstanceHealthStatus", func(tx *BaseTx) error {
+		return ins.master.processWithTransactionContext(ctx, "batchSetInstanceHealthStatus", func(tx *BaseTx) error {
 			if err := BatchOperation("set-instance-healthy", ids, func(objects []interface{}) error {
 				if len(objects) == 0 {
 					return nil
@@ -531,6 +555,9 @@ func (ins *instanceStore) BatchSetInstanceHealthStatus(ids []interface{}, isolat
 			}); err != nil {
 				return err
 			}
+			if err := appendChangeLogs(tx, store.ChangeLogInstance, ids); err != nil {
+				return err
+			}
 
 			if err := tx.Commit(); err != nil {
 				log.Errorf("[Store][database] batch set instance health status commit tx err: %s", err.Error())
@@ -561,6 +588,9 @@ func (ins *instanceStore) BatchSetInstanceIsolate(ids []interface{}, isolate int
 			}); err != nil {
 				return err
 			}
+			if err := appendChangeLogs(tx, store.ChangeLogInstance, ids); err != nil {
+				return err
+			}
 
 			if err := tx.Commit(); err != nil {
 				log.Errorf("[Store][database] batch set instance isolate commit tx err: %s", err.Error())
@@ -573,11 +603,11 @@ func (ins *instanceStore) BatchSetInstanceIsolate(ids []interface{}, isolate int
 }
 
 // BatchAppendInstanceMetadata 追加实例 metadata
-func (ins *instanceStore) BatchAppendInstanceMetadata(requests []*store.InstanceMetadataRequest) error {
+func (ins *instanceStore) BatchAppendInstanceMetadata(ctx context.Context, requests []*store.InstanceMetadataRequest) error {
 	if len(requests) == 0 {
 		return nil
 	}
-	return ins.master.processWithTransaction("AppendInstanceMetadata", func(tx *BaseTx) error {
+	return ins.master.processWithTransactionContext(ctx, "AppendInstanceMetadata", func(tx *BaseTx) error {
 		for i := range requests {
 			id := requests[i].InstanceID
 			revision := requests[i].Revision
@@ -604,16 +634,19 @@ func (ins *instanceStore) BatchAppendInstanceMetadata(requests []*store.Instance
 				return err
 			}
 		}
+		if err := appendChangeLogs(tx, store.ChangeLogInstance, metadataRequestIDs(requests)); err != nil {
+			return err
+		}
 		return tx.Commit()
 	})
 }
 
 // BatchRemoveInstanceMetadata 删除实例指定的 metadata
-func (ins *instanceStore) BatchRemoveInstanceMetadata(requests []*store.InstanceMetadataRequest) error {
+func (ins *instanceStore) BatchRemoveInstanceMetadata(ctx context.Context, requests []*store.InstanceMetadataRequest) error {
 	if len(requests) == 0 {
 		return nil
 	}
-	return ins.master.processWithTransaction("RemoveInstanceMetadata", func(tx *BaseTx) error {
+	return ins.master.processWithTransactionContext(ctx, "RemoveInstanceMetadata", func(tx *BaseTx) error {
 		for i := range requests {
 			id := requests[i].InstanceID
 			revision := requests[i].Revision
@@ -640,14 +673,58 @@ func (ins *instanceStore) BatchRemoveInstanceMetadata(requests []*store.Instance
 				return err
 			}
 		}
+		if err := appendChangeLogs(tx, store.ChangeLogInstance, metadataRequestIDs(requests)); err != nil {
+			return err
+		}
 		return tx.Commit()
 	})
 }
 
+// GetInstancesByIDs 获取指定实例的完整数据, 包括已经逻辑删除的实例
+func (ins *instanceStore) GetInstancesByIDs(tx store.Tx, ids []string) (map[string]*svctypes.Instance, error) {
+	dbTx := tx.GetDelegateTx().(*BaseTx)
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	ret := make(map[string]*svctypes.Instance, len(ids))
+	err := BatchOperation("get-instances-by-ids", args, func(objects []interface{}) error {
+		if len(objects) == 0 {
+			return nil
+		}
+		str := genCompleteInstanceSelectSQL() + " where instance.id in (" + PlaceholdersN(len(objects)) + ")"
+		rows, err := dbTx.Query(str, objects...)
+		if err != nil {
+			log.Errorf("[Store][database] get instances by ids query err: %s", err.Error())
+			return store.Error(err)
+		}
+		out, err := fetchInstanceWithMetaRows(rows)
+		if err != nil {
+			return err
+		}
+		for id, item := range out {
+			ret[id] = item
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func metadataRequestIDs(requests []*store.InstanceMetadataRequest) []interface{} {
+	ids := make([]interface{}, 0, len(requests))
+	for i := range requests {
+		ids = append(ids, requests[i].InstanceID)
+	}
+	return ids
+}
+
 // getInstance 内部获取instance函数，根据instanceID，直接读取元数据，不做其他过滤
-func (ins *instanceStore) getInstance(instanceID string) (*svctypes.Instance, error) {
+func (ins *instanceStore) getInstance(ctx context.Context, instanceID string) (*svctypes.Instance, error) {
 	str := genInstanceSelectSQL() + " where instance.id = ?"
-	rows, err := ins.master.Query(str, instanceID)
+	rows, err := ins.master.QueryContext(ctx, str, instanceID)
 	if err != nil {
 		log.Errorf("[Store][database] get instance query err: %s", err.Error())
 		return nil, err
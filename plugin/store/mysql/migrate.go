@@ -0,0 +1,301 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pole-io/pole-server/apis/store"
+)
+
+const (
+	// migrationDir 版本化变更脚本所在的目录, 文件名格式为 <版本号>_<名称>.sql
+	migrationDir = "scripts/migrations"
+	// schemaVersionTable 表结构版本记录表
+	schemaVersionTable = "schema_version"
+	// legacyCheckTable 引入版本化迁移之前由 pole_server.sql 初始化的数据库, 通过该表判断是否已经初始化
+	legacyCheckTable = "namespace"
+	// legacyVersion 引入版本化迁移之前的表结构对应的版本
+	legacyVersion = 1
+)
+
+//go:embed scripts/migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+
+// loadMigrations 加载变更脚本并按照版本号排序, 版本号必须从 1 开始连续递增
+func loadMigrations(fsys fs.FS, dir string) ([]*store.Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]*store.Migration, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, &store.Migration{
+			Version: version,
+			Name:    match[2],
+			SQL:     string(content),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i := range migrations {
+		if migrations[i].Version != i+1 {
+			return nil, fmt.Errorf("migration version must be continuous, expect %d but got %d(%s)",
+				i+1, migrations[i].Version, migrations[i].Name)
+		}
+	}
+	return migrations, nil
+}
+
+// splitStatements 将脚本拆分为单条 SQL, 忽略注释以及引号内的分号
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		buf        strings.Builder
+		quote      byte
+	)
+	flush := func() {
+		if stmt := strings.TrimSpace(buf.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		buf.Reset()
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		if quote != 0 {
+			buf.WriteByte(c)
+			if c == '\\' && i+1 < len(script) {
+				i++
+				buf.WriteByte(script[i])
+				continue
+			}
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			buf.WriteByte(c)
+		case c == '-' && strings.HasPrefix(script[i:], "--"), c == '#':
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end
+				buf.WriteByte('\n')
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+		case c == ';':
+			flush()
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
+
+// migrationStatus 比对数据库当前的版本与变更脚本
+func migrationStatus(db *sql.DB, migrations []*store.Migration) (*store.MigrationStatus, error) {
+	status := &store.MigrationStatus{Latest: len(migrations)}
+	tracked, err := tableExists(db, schemaVersionTable)
+	if err != nil {
+		return nil, err
+	}
+	status.Tracked = tracked
+	if tracked {
+		if err := db.QueryRow("SELECT IFNULL(MAX(version), 0) FROM " + schemaVersionTable).
+			Scan(&status.Current); err != nil {
+			return nil, err
+		}
+	} else {
+		legacy, err := tableExists(db, legacyCheckTable)
+		if err != nil {
+			return nil, err
+		}
+		if legacy {
+			status.Current = legacyVersion
+		}
+	}
+	for _, migration := range migrations {
+		if migration.Version > status.Current {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+func tableExists(db *sql.DB, table string) (bool, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() "+
+		"AND TABLE_NAME = ?", table).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// migrate 依次执行待执行的变更, 同一个连接上执行保证 SET 语句对后续语句生效
+func migrate(db *sql.DB, migrations []*store.Migration) ([]*store.Migration, error) {
+	status, err := migrationStatus(db, migrations)
+	if err != nil {
+		return nil, err
+	}
+	if status.Current > status.Latest {
+		return nil, newSchemaTooNewError(status)
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if !status.Tracked {
+		if _, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+schemaVersionTable+" ("+
+			"`version` INT NOT NULL COMMENT '表结构版本号', "+
+			"`name` VARCHAR(128) NOT NULL COMMENT '变更名称', "+
+			"`applied_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '执行时间', "+
+			"PRIMARY KEY (`version`)) ENGINE = InnoDB COMMENT = '表结构版本记录'"); err != nil {
+			return nil, err
+		}
+		// 已有的数据库以引入版本化迁移之前的表结构作为基线
+		for _, migration := range migrations {
+			if migration.Version > status.Current {
+				break
+			}
+			if err := recordMigration(ctx, conn, migration); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	applied := make([]*store.Migration, 0, len(status.Pending))
+	for _, migration := range status.Pending {
+		log.Infof("[Store][database] apply schema migration %d_%s", migration.Version, migration.Name)
+		for _, stmt := range splitStatements(migration.SQL) {
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return applied, fmt.Errorf("apply migration %d_%s fail: %w", migration.Version, migration.Name, err)
+			}
+		}
+		if err := recordMigration(ctx, conn, migration); err != nil {
+			return applied, err
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+func recordMigration(ctx context.Context, conn *sql.Conn, migration *store.Migration) error {
+	_, err := conn.ExecContext(ctx, "INSERT INTO "+schemaVersionTable+" (version, name) VALUES (?, ?)",
+		migration.Version, migration.Name)
+	return err
+}
+
+// checkSchemaVersion 启动时校验数据库的表结构版本与当前程序是否匹配
+func checkSchemaVersion(db *sql.DB, migrations []*store.Migration) error {
+	status, err := migrationStatus(db, migrations)
+	if err != nil {
+		return fmt.Errorf("check database schema version fail: %w", err)
+	}
+	switch {
+	case status.Current == 0:
+		return fmt.Errorf("database schema is not initialized, please run `pole-server migrate up` first")
+	case status.Current < status.Latest:
+		return fmt.Errorf("database schema version %d is older than required version %d, "+
+			"please run `pole-server migrate up` to upgrade the schema", status.Current, status.Latest)
+	case status.Current > status.Latest:
+		return newSchemaTooNewError(status)
+	}
+	return nil
+}
+
+func newSchemaTooNewError(status *store.MigrationStatus) error {
+	return fmt.Errorf("database schema version %d is newer than supported version %d, "+
+		"please upgrade pole-server", status.Current, status.Latest)
+}
+
+// openMigrationDB 迁移只操作主库
+func openMigrationDB(c *store.Config) (*BaseDB, error) {
+	masterConfig, _, err := parseDatabaseConf(c.Option)
+	if err != nil {
+		return nil, err
+	}
+	return NewBaseDB(masterConfig)
+}
+
+// MigrationStatus 查询数据库当前的版本以及待执行的变更
+func (s *stableStore) MigrationStatus(c *store.Config) (*store.MigrationStatus, error) {
+	migrations, err := loadMigrations(migrationFiles, migrationDir)
+	if err != nil {
+		return nil, err
+	}
+	db, err := openMigrationDB(c)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return migrationStatus(db.DB, migrations)
+}
+
+// Migrate 按照版本顺序执行待执行的变更
+func (s *stableStore) Migrate(c *store.Config) ([]*store.Migration, error) {
+	migrations, err := loadMigrations(migrationFiles, migrationDir)
+	if err != nil {
+		return nil, err
+	}
+	db, err := openMigrationDB(c)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return migrate(db.DB, migrations)
+}
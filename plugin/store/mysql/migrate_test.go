@@ -0,0 +1,182 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pole-io/pole-server/apis/store"
+)
+
+func Test_splitStatements(t *testing.T) {
+	script := `
+-- comment; should be ignored
+SET time_zone = "+00:00";
+/* block; comment */
+CREATE TABLE t (
+    id INT COMMENT 'id; with semicolon',
+    name VARCHAR(32) DEFAULT "a;b"
+);
+# another comment
+INSERT INTO t (id, name) VALUES (1, 'it\'s;ok');
+`
+	stmts := splitStatements(script)
+	assert.Equal(t, 3, len(stmts))
+	assert.Equal(t, `SET time_zone = "+00:00"`, stmts[0])
+	assert.Contains(t, stmts[1], "'id; with semicolon'")
+	assert.Contains(t, stmts[1], `"a;b"`)
+	assert.Equal(t, `INSERT INTO t (id, name) VALUES (1, 'it\'s;ok')`, stmts[2])
+}
+
+func Test_loadMigrations(t *testing.T) {
+	t.Run("embedded", func(t *testing.T) {
+		migrations, err := loadMigrations(migrationFiles, migrationDir)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, migrations)
+		assert.Equal(t, "init", migrations[0].Name)
+		for _, stmt := range splitStatements(migrations[0].SQL) {
+			// 迁移脚本作用于配置的数据库, 不能切换或者创建数据库
+			assert.False(t, strings.HasPrefix(strings.ToUpper(stmt), "USE "), stmt)
+			assert.False(t, strings.HasPrefix(strings.ToUpper(stmt), "CREATE DATABASE"), stmt)
+		}
+	})
+
+	t.Run("sorted", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/0002_add_column.sql": {Data: []byte("ALTER TABLE t ADD COLUMN c INT;")},
+			"m/0001_init.sql":       {Data: []byte("CREATE TABLE t (id INT);")},
+		}
+		migrations, err := loadMigrations(fsys, "m")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(migrations))
+		assert.Equal(t, 1, migrations[0].Version)
+		assert.Equal(t, "add_column", migrations[1].Name)
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/0001_init.sql":       {Data: []byte("CREATE TABLE t (id INT);")},
+			"m/0003_add_column.sql": {Data: []byte("ALTER TABLE t ADD COLUMN c INT;")},
+		}
+		_, err := loadMigrations(fsys, "m")
+		assert.Error(t, err)
+	})
+
+	t.Run("bad_name", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/init.sql": {Data: []byte("CREATE TABLE t (id INT);")},
+		}
+		_, err := loadMigrations(fsys, "m")
+		assert.Error(t, err)
+	})
+}
+
+func newTestMigrations() []*store.Migration {
+	return []*store.Migration{
+		{Version: 1, Name: "init", SQL: "CREATE TABLE t (id INT);"},
+		{Version: 2, Name: "add_column", SQL: "ALTER TABLE t ADD COLUMN c INT;"},
+	}
+}
+
+func expectTableExists(mock sqlmock.Sqlmock, table string, exists bool) {
+	count := 0
+	if exists {
+		count = 1
+	}
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM information_schema.TABLES").WithArgs(table).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func Test_migrate(t *testing.T) {
+	t.Run("fresh", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		expectTableExists(mock, schemaVersionTable, false)
+		expectTableExists(mock, legacyCheckTable, false)
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TABLE t").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO schema_version").WithArgs(1, "init").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("ALTER TABLE t").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO schema_version").WithArgs(2, "add_column").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		applied, err := migrate(db, newTestMigrations())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(applied))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("legacy_baseline", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		expectTableExists(mock, schemaVersionTable, false)
+		expectTableExists(mock, legacyCheckTable, true)
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version").WillReturnResult(sqlmock.NewResult(0, 0))
+		// 已有的表结构只记录基线版本, 不重复执行
+		mock.ExpectExec("INSERT INTO schema_version").WithArgs(1, "init").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("ALTER TABLE t").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO schema_version").WithArgs(2, "add_column").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		applied, err := migrate(db, newTestMigrations())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(applied))
+		assert.Equal(t, 2, applied[0].Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_checkSchemaVersion(t *testing.T) {
+	cases := []struct {
+		name    string
+		current int
+		wantErr string
+	}{
+		{name: "match", current: 2},
+		{name: "older", current: 1, wantErr: "pole-server migrate up"},
+		{name: "newer", current: 3, wantErr: "please upgrade pole-server"},
+		{name: "empty", current: 0, wantErr: "not initialized"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			expectTableExists(mock, schemaVersionTable, true)
+			mock.ExpectQuery("SELECT IFNULL\\(MAX\\(version\\), 0\\) FROM schema_version").
+				WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(c.current))
+
+			err = checkSchemaVersion(db, newTestMigrations())
+			if c.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, c.wantErr)
+		})
+	}
+}
@@ -175,6 +175,15 @@ func (s *roleStore) CleanPrincipalRoles(tx store.Tx, p *authcommon.Principal) er
 }
 
 func (s *roleStore) GetRole(id string) (*authcommon.Role, error) {
+	return s.getRole("id = ?", id)
+}
+
+// GetRoleByName 根据 name、owner 获取角色
+func (s *roleStore) GetRoleByName(name, owner string) (*authcommon.Role, error) {
+	return s.getRole("name = ? AND owner = ?", name, owner)
+}
+
+func (s *roleStore) getRole(filter string, args ...interface{}) (*authcommon.Role, error) {
 	tx, err := s.master.Begin()
 	if err != nil {
 		return nil, store.Error(err)
@@ -183,8 +192,7 @@ func (s *roleStore) GetRole(id string) (*authcommon.Role, error) {
 	defer func() { _ = tx.Commit() }()
 
 	querySql := "SELECT id, name, owner, source, role_type, comment, flag, metadata, UNIX_TIMESTAMP(ctime), " +
-		" UNIX_TIMESTAMP(mtime) FROM auth_role WHERE flag = 0 AND id = ?"
-	args := []interface{}{id}
+		" UNIX_TIMESTAMP(mtime) FROM auth_role WHERE flag = 0 AND " + filter
 
 	row := tx.QueryRow(querySql, args...)
 	var (
@@ -0,0 +1,33 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+/* 命名空间、服务维度的健康检查以及过期策略 */
+CREATE TABLE
+    `health_policy` (
+        `namespace` VARCHAR(64) NOT NULL COMMENT '命名空间',
+        `service` VARCHAR(128) NOT NULL DEFAULT '' COMMENT '服务名, 为空表示作用于整个命名空间',
+        `min_ttl` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '心跳 TTL 下限, 单位秒, 0 表示不限制',
+        `max_ttl` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '心跳 TTL 上限, 单位秒, 0 表示不限制',
+        `unhealthy_delete_delay` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '不健康实例的删除延迟, 单位秒, 0 表示使用全局配置',
+        `auto_delete` TINYINT (4) DEFAULT NULL COMMENT '是否自动删除不健康实例以及空服务, NULL 表示继承',
+        `empty_service_retention` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '空服务保留时间, 单位秒, 0 表示使用全局配置',
+        `comment` VARCHAR(1024) DEFAULT '' COMMENT '描述信息',
+        `flag` TINYINT (4) NOT NULL DEFAULT 0 COMMENT '逻辑删除标志位, 0 位有效, 1 为逻辑删除',
+        `ctime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+        `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+        PRIMARY KEY (`namespace`, `service`),
+        KEY `mtime` (`mtime`)
+    ) ENGINE = InnoDB COMMENT = '健康检查以及过期策略表';
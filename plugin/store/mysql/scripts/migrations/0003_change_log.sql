@@ -0,0 +1,26 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+/* 资源变更记录, 与资源写入在同一个事务内追加, 缓存按照序号顺序增量同步 */
+CREATE TABLE
+    `change_log` (
+        `seq` BIGINT NOT NULL AUTO_INCREMENT COMMENT '变更序号',
+        `resource` VARCHAR(64) NOT NULL COMMENT '资源类型',
+        `res_key` VARCHAR(128) NOT NULL COMMENT '资源唯一标识',
+        `ctime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+        PRIMARY KEY (`seq`),
+        KEY `ctime` (`ctime`)
+    ) ENGINE = InnoDB COMMENT = '资源变更记录表';
@@ -878,3 +878,48 @@ CREATE TABLE
         PRIMARY KEY (`id`),
         UNIQUE KEY `name` (`name`)
     ) ENGINE = InnoDB;
+
+/* 命名空间、服务维度的健康检查以及过期策略 */
+CREATE TABLE
+    `health_policy` (
+        `namespace` VARCHAR(64) NOT NULL COMMENT '命名空间',
+        `service` VARCHAR(128) NOT NULL DEFAULT '' COMMENT '服务名, 为空表示作用于整个命名空间',
+        `min_ttl` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '心跳 TTL 下限, 单位秒, 0 表示不限制',
+        `max_ttl` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '心跳 TTL 上限, 单位秒, 0 表示不限制',
+        `unhealthy_delete_delay` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '不健康实例的删除延迟, 单位秒, 0 表示使用全局配置',
+        `auto_delete` TINYINT (4) DEFAULT NULL COMMENT '是否自动删除不健康实例以及空服务, NULL 表示继承',
+        `empty_service_retention` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '空服务保留时间, 单位秒, 0 表示使用全局配置',
+        `comment` VARCHAR(1024) DEFAULT '' COMMENT '描述信息',
+        `flag` TINYINT (4) NOT NULL DEFAULT 0 COMMENT '逻辑删除标志位, 0 位有效, 1 为逻辑删除',
+        `ctime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+        `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+        PRIMARY KEY (`namespace`, `service`),
+        KEY `mtime` (`mtime`)
+    ) ENGINE = InnoDB COMMENT = '健康检查以及过期策略表';
+
+/* 资源变更记录, 与资源写入在同一个事务内追加, 缓存按照序号顺序增量同步 */
+CREATE TABLE
+    `change_log` (
+        `seq` BIGINT NOT NULL AUTO_INCREMENT COMMENT '变更序号',
+        `resource` VARCHAR(64) NOT NULL COMMENT '资源类型',
+        `res_key` VARCHAR(128) NOT NULL COMMENT '资源唯一标识',
+        `ctime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+        PRIMARY KEY (`seq`),
+        KEY `ctime` (`ctime`)
+    ) ENGINE = InnoDB COMMENT = '资源变更记录表';
+
+/* 表结构版本, 由 pole-server migrate 维护, 全新安装的数据库已包含全部的版本化变更 */
+CREATE TABLE
+    `schema_version` (
+        `version` INT NOT NULL COMMENT '表结构版本号',
+        `name` VARCHAR(128) NOT NULL COMMENT '变更名称',
+        `applied_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '执行时间',
+        PRIMARY KEY (`version`)
+    ) ENGINE = InnoDB COMMENT = '表结构版本记录';
+
+INSERT INTO
+    `schema_version` (`version`, `name`)
+VALUES
+    (1, 'init'),
+    (2, 'health_policy'),
+    (3, 'change_log');
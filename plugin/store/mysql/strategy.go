@@ -426,6 +426,22 @@ func (s *strategyStore) GetStrategyDetail(id string) (*authcommon.StrategyDetail
 	return s.getStrategyDetail(row)
 }
 
+// GetStrategyDetailByName 根据名称获取策略详情, 策略保存时 owner 固定为空, 名称即唯一标识
+func (s *strategyStore) GetStrategyDetailByName(name string) (*authcommon.StrategyDetail, error) {
+	if name == "" {
+		return nil, store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+			"get auth_strategy missing some params, name is %s", name))
+	}
+
+	querySql := "SELECT ag.id, ag.name, ag.action, ag.owner, ag.default, ag.comment, ag.revision, ag.flag, " +
+		" UNIX_TIMESTAMP(ag.ctime), UNIX_TIMESTAMP(ag.mtime) FROM auth_strategy AS ag " +
+		" WHERE ag.flag = 0 AND ag.name = ? AND ag.owner = ''"
+
+	row := s.master.QueryRow(querySql, name)
+
+	return s.getStrategyDetail(row)
+}
+
 // GetDefaultStrategyDetailByPrincipal 获取默认策略
 func (s *strategyStore) GetDefaultStrategyDetailByPrincipal(principalId string,
 	principalType authcommon.PrincipalType) (*authcommon.StrategyDetail, error) {
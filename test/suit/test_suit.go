@@ -305,6 +305,7 @@ func (d *DiscoverTestSuit) initialize(opts ...options) error {
 		d.Storage = d.caller()
 	} else {
 		storeapi.SetStoreConfig(&d.cfg.Store)
+		storeapi.SetChangeLogEnable(d.cfg.Cache.ChangeLog.Enable)
 		s, _ := storeplugin.TestGetStore()
 		d.Storage = s
 	}
@@ -379,6 +380,7 @@ func (d *DiscoverTestSuit) initialize(opts ...options) error {
 	d.healthCheckServer = healthCheckServer
 	healthCheckServer.SetServiceCache(cacheMgn.Service())
 	healthCheckServer.SetInstanceCache(cacheMgn.Instance())
+	healthCheckServer.SetHealthPolicyCache(cacheMgn.HealthPolicy())
 
 	val, originVal, err := service.TestInitialize(ctx, &d.cfg.Naming, &d.cfg.Cache, d.cfg.ServiceCacheEntries,
 		bc, cacheMgn, d.Storage, namespaceSvr, healthCheckServer, d.userMgn, d.strategyMgn)